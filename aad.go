@@ -0,0 +1,57 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// AADCipherText is a ciphertext bound to associated data, such as a document hash.
+// 关联数据密文：与关联数据（如文档哈希）绑定的密文。
+type AADCipherText = keyswitch.AADCipherText
+
+// EncryptWithAAD encrypts D with pub and binds the ciphertext to aad.
+// It is a wrapper of keyswitch.EncryptWithAAD.
+// 关联数据加密：使用公钥pub加密点D，并将密文与关联数据aad绑定。
+//
+// 参数：
+//		公钥		pub
+//		待加密点	D
+//		关联数据	aad
+// 返回：
+// 		关联数据密文
+func EncryptWithAAD(pub *sm2.PublicKey, D *CurvePoint, aad []byte) (*AADCipherText, error) {
+	return keyswitch.EncryptWithAAD(pub, D, aad)
+}
+
+// GenShareBundleWithAAD checks that act is bound to aad and only then generates
+// its share bundle with aad bound into the proof.
+// It is a wrapper of keyswitch.GenShareBundleWithAAD.
+// 关联数据份额包：检查密文act与关联数据aad绑定，通过后才生成份额包，并将aad计入证明。
+//
+// 参数：
+//		关联数据		aad
+//		集合公钥		pub
+//		关联数据密文	act
+//		目标公钥		targetPubKey
+//		私钥			priv
+// 返回：
+// 		份额包
+func GenShareBundleWithAAD(aad []byte, pub *sm2.PublicKey, act *AADCipherText, targetPubKey *sm2.PublicKey, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	return keyswitch.GenShareBundleWithAAD(aad, pub, act, targetPubKey, priv)
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/keyswitch"
+)
+
+// ShareAccumulator folds shares into a running sum as they arrive, so a coordinator
+// does not have to hold the whole CipherVector before calling ShareReplace.
+// The zero value is an empty accumulator ready to use. A ShareAccumulator is not
+// safe for concurrent use; use SafeAccumulator when several goroutines add shares.
+// 份额累加器：份额到达时即累加至聚合值sigma，协调者无需在置换前持有全部份额，内存占用为O(1)。
+// 零值即为可用的空累加器。ShareAccumulator不可并发使用，多个协程累加份额时应使用SafeAccumulator。
+type ShareAccumulator = keyswitch.ShareAccumulator
+
+// SafeAccumulator is a ShareAccumulator protected by a mutex, so several goroutines
+// receiving shares from the network can add them without external locking.
+// 并发安全的份额累加器：以互斥锁保护的ShareAccumulator，多个协程可直接并发累加份额。
+type SafeAccumulator = keyswitch.SafeAccumulator
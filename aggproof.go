@@ -0,0 +1,98 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"io"
+	"math/big"
+
+	"ppks/keyswitch"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// AggregateShareProof is one constant-size proof for a whole key switching session.
+// 聚合份额证明：整个密钥置换会话的定长证明。
+type AggregateShareProof = keyswitch.AggregateShareProof
+
+// ProofAggregator runs the coordinator side of an aggregated share proof.
+// 聚合证明协调者。
+type ProofAggregator = keyswitch.ProofAggregator
+
+// ShareProver is the part of one node in an aggregated share proof.
+// 份额聚合证明的节点参与方。
+type ShareProver = proof.Prover
+
+// NewShareProver prepares the part of a node in an aggregated share proof.
+// It is a wrapper of keyswitch.NewShareProver.
+// 创建份额聚合证明的节点参与方：使用计算份额的随机数ri与节点私钥priv。
+//
+// 参数：
+//		随机数：	ri
+//		节点私钥：	priv
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+// 返回：
+// 		参与方
+func NewShareProver(ri *big.Int, priv *sm2.PrivateKey, targetPubKey *sm2.PublicKey, rB *CurvePoint) (*ShareProver, error) {
+	return keyswitch.NewShareProver(ri, priv, targetPubKey, rB)
+}
+
+// NewProofAggregator starts an aggregated share proof for targetPubKey and rB with
+// the default challenge hash.
+// It is a wrapper of keyswitch.NewProofAggregator.
+// 创建聚合证明协调者：针对目标公钥targetPubKey与密文左侧点rB，使用默认挑战哈希。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+// 返回：
+// 		协调者
+func NewProofAggregator(targetPubKey *sm2.PublicKey, rB *CurvePoint) (*ProofAggregator, error) {
+	return keyswitch.NewProofAggregator(proof.DefaultSuite, targetPubKey, rB)
+}
+
+// LightVerifyShares checks the aggregate consistency of bundles with agg and fully
+// verifies only k bundles sampled at random, for resource-constrained requesters.
+// It is a wrapper of keyswitch.LightVerifyShares.
+// 轻量验证：检查份额包与聚合证明agg的一致性，并仅完整验证随机抽取的k个份额包，供资源受限的请求者使用。
+//
+// 参数：
+//		份额包slice：	bundles
+//		聚合份额证明：	agg
+//		抽样数量：		k
+//		随机源：		random
+// 返回：
+// 		验证结果：	bool
+func LightVerifyShares(bundles []*ShareBundle, agg *AggregateShareProof, k int, random io.Reader) (bool, error) {
+	return keyswitch.LightVerifyShares(bundles, agg, k, random)
+}
+
+// LightSampleSize returns the smallest sample for which LightVerifyShares misses
+// faulty invalid proofs among n with probability at most bound.
+// It is a wrapper of keyswitch.LightSampleSize.
+// 抽样数量：返回使n个份额包中faulty个无效证明的漏检概率不超过bound的最小抽样数量。
+//
+// 参数：
+//		份额包数量：	n
+//		无效证明数量：	faulty
+//		漏检概率上界：	bound
+// 返回：
+// 		抽样数量
+func LightSampleSize(n, faulty int, bound float64) int {
+	return keyswitch.LightSampleSize(n, faulty, bound)
+}
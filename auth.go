@@ -0,0 +1,58 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"math/big"
+
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// AuthCipherText is a ciphertext bound to its requester by an SM3-HMAC tag.
+// 认证密文：以SM3-HMAC认证标签与请求者绑定的密文。
+type AuthCipherText = keyswitch.AuthCipherText
+
+// NewAuthCipherText binds ct to requesterID with the MAC key key.
+// It is a wrapper of keyswitch.NewAuthCipherText.
+// 生成认证密文：以MAC密钥key将密文ct与请求者标识requesterID绑定。
+//
+// 参数：
+//		MAC密钥		key
+//		密文		ct
+//		请求者标识	requesterID
+// 返回：
+// 		认证密文
+func NewAuthCipherText(key []byte, ct *CipherText, requesterID []byte) (*AuthCipherText, error) {
+	return keyswitch.NewAuthCipherText(key, ct, requesterID)
+}
+
+// ShareCalAuth verifies the tag of act and only then calculates the share.
+// It is a wrapper of keyswitch.ShareCalAuth.
+// 认证份额计算：验证act的认证标签，通过后才计算份额。
+//
+// 参数：
+//		MAC密钥		key
+//		认证密文	act
+//		目标公钥	targetPubKey
+//		私钥		priv
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func ShareCalAuth(key []byte, act *AuthCipherText, targetPubKey *sm2.PublicKey, priv *sm2.PrivateKey) (*CipherText, *big.Int, error) {
+	return keyswitch.ShareCalAuth(key, act, targetPubKey, priv)
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"math/big"
+
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// BlindTarget blinds the target key targetPubKey, so KS servers calculate shares
+// without learning who requests access.
+// It is a wrapper of keyswitch.BlindTarget.
+// 目标公钥盲化：盲化目标公钥targetPubKey，使ks server在计算份额时无从得知请求者身份。
+//
+// 参数：
+//		目标公钥	targetPubKey
+// 返回：
+// 		盲化公钥
+//		盲化因子
+func BlindTarget(targetPubKey *sm2.PublicKey) (*sm2.PublicKey, *big.Int, error) {
+	return keyswitch.BlindTarget(targetPubKey)
+}
+
+// Unblind turns ct, switched to the blinded key, into a ciphertext under the
+// original target key.
+// It is a wrapper of keyswitch.Unblind.
+// 去盲：将置换到盲化公钥的密文ct变换为原目标公钥下的密文。
+//
+// 参数：
+//		置换后的密文	ct
+//		盲化因子		blind
+// 返回：
+// 		原目标公钥下的密文
+func Unblind(ct *CipherText, blind *big.Int) (*CipherText, error) {
+	return keyswitch.Unblind(ct, blind)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SignatureDomain separates the messages hashed by Sign from other uses of HashToG1.
+// Sign对消息哈希时所用的域分隔标签，与HashToG1的其他用途区分。
+const SignatureDomain = "ppks-bn254-bls-v1"
+
+// HashToG1 maps msg to a point of G1 by try-and-increment over SHA-256 under the
+// domain separation tag domain. The discrete logarithm of the result is unknown.
+// 哈希到G1：在域分隔标签domain下，以基于SHA-256的"尝试-递增"方式将msg映射为G1上的点，
+// 结果的离散对数未知。
+//
+// 参数：
+//		域分隔标签	domain
+//		消息		msg
+// 返回：
+// 		G1点
+func HashToG1(domain, msg []byte) (*elgamal.CurvePoint, error) {
+	for ctr := 0; ctr < 256; ctr++ {
+		// 64字节哈希值模p，偏差可忽略；末字节决定纵坐标奇偶性
+		var h []byte
+		for i := byte(0); i < 2; i++ {
+			d := sha256.New()
+			d.Write([]byte{byte(len(domain))})
+			d.Write(domain)
+			d.Write(msg)
+			d.Write([]byte{byte(ctr), i})
+			h = d.Sum(h)
+		}
+		x := new(big.Int).SetBytes(h)
+		x.Mod(x, fieldP)
+		y := new(big.Int).ModSqrt(rhs(x), fieldP)
+		if y == nil {
+			continue
+		}
+		if y.Bit(0) != uint(h[len(h)-1]&1) {
+			y.Sub(fieldP, y)
+		}
+		return elgamal.NewCurvePointFromXY(g1, x, y)
+	}
+	return nil, opError("HashToG1", elgamal.ErrNoPointFound)
+}
+
+// G2PublicKey returns the G2 public key D*H of priv, H the generator of G2. Nodes
+// publish it next to their G1 key; CheckKeyPair binds the two.
+// G2公钥：返回priv的G2公钥D*H，H为G2的生成元。节点将其与G1公钥一同公布，由CheckKeyPair验证二者对应。
+//
+// 参数：
+//		私钥	priv
+// 返回：
+// 		G2公钥
+func G2PublicKey(priv *sm2.PrivateKey) (*G2Point, error) {
+	if priv == nil || priv.D == nil || priv.Curve != g1 {
+		return nil, opError("G2PublicKey", elgamal.ErrCurveMismatch)
+	}
+	return G2ScalarBaseMult(priv.D), nil
+}
+
+// CheckKeyPair reports whether the G1 key pub and the G2 key pub2 share the same
+// private key, e(pub,H) = e(G,pub2).
+// 判断G1公钥pub与G2公钥pub2是否对应同一私钥，即e(pub,H) = e(G,pub2)。
+//
+// 参数：
+//		G1公钥	pub
+//		G2公钥	pub2
+// 返回：
+// 		是否对应
+func CheckKeyPair(pub *sm2.PublicKey, pub2 *G2Point) (bool, error) {
+	ok, err := PairingCheck(
+		[]*elgamal.CurvePoint{(*elgamal.CurvePoint)(pub), elgamal.Generator(g1)},
+		[]*G2Point{g2Gen.Neg(), pub2})
+	if err != nil {
+		return false, opError("CheckKeyPair", err)
+	}
+	return ok, nil
+}
+
+// Sign returns the BLS signature D*HashToG1(SignatureDomain, msg) of msg.
+// BLS签名：返回消息msg的签名D*HashToG1(SignatureDomain, msg)。
+//
+// 参数：
+//		私钥	priv
+//		消息	msg
+// 返回：
+// 		签名
+func Sign(priv *sm2.PrivateKey, msg []byte) (*elgamal.CurvePoint, error) {
+	if priv == nil || priv.D == nil || priv.Curve != g1 {
+		return nil, opError("Sign", elgamal.ErrCurveMismatch)
+	}
+	M, err := HashToG1([]byte(SignatureDomain), msg)
+	if err != nil {
+		return nil, opError("Sign", err)
+	}
+	var sig elgamal.CurvePoint
+	sig.Curve = g1
+	sig.X, sig.Y = g1.ScalarMult(M.X, M.Y, priv.D.Bytes())
+	return &sig, nil
+}
+
+// VerifySignature verifies the BLS signature sig of msg under the G2 key pub2.
+// BLS签名验证：使用G2公钥pub2验证消息msg的签名sig。
+//
+// 参数：
+//		G2公钥	pub2
+//		消息	msg
+//		签名	sig
+// 返回：
+// 		是否有效
+func VerifySignature(pub2 *G2Point, msg []byte, sig *elgamal.CurvePoint) (bool, error) {
+	return aggregateVerify("VerifySignature", []*G2Point{pub2}, [][]byte{msg}, sig)
+}
+
+// AggregateSignatures returns the sum of the BLS signatures sigs, a single point
+// verified by AggregateVerify.
+// 签名聚合：返回各BLS签名之和，为单个点，由AggregateVerify验证。
+//
+// 参数：
+//		签名集合	sigs
+// 返回：
+// 		聚合签名
+func AggregateSignatures(sigs []*elgamal.CurvePoint) (*elgamal.CurvePoint, error) {
+	if len(sigs) == 0 {
+		return nil, opError("AggregateSignatures", elgamal.ErrEmpty)
+	}
+	sum := elgamal.Infinity(g1)
+	for i, sig := range sigs {
+		if err := checkG1(sig); err != nil {
+			return nil, itemError("AggregateSignatures", "signature", i, err)
+		}
+		sum.X, sum.Y = g1.Add(sum.X, sum.Y, sig.X, sig.Y)
+	}
+	return sum, nil
+}
+
+// AggregateVerify verifies the aggregate signature sig of msgs[i] under pubs[i]
+// with n+1 pairings. The messages must be distinct, which rules out rogue key
+// attacks without proofs of possession.
+// 聚合签名验证：以n+1次配对验证消息msgs[i]在公钥pubs[i]下的聚合签名sig。
+// 各消息须互不相同，从而无需持有性证明即可抵御恶意密钥攻击。
+//
+// 参数：
+//		G2公钥集合	pubs
+//		消息集合	msgs
+//		聚合签名	sig
+// 返回：
+// 		是否有效
+func AggregateVerify(pubs []*G2Point, msgs [][]byte, sig *elgamal.CurvePoint) (bool, error) {
+	return aggregateVerify("AggregateVerify", pubs, msgs, sig)
+}
+
+// aggregateVerify implements AggregateVerify, reporting errors under op.
+// AggregateVerify的实现，以op报告错误。
+func aggregateVerify(op string, pubs []*G2Point, msgs [][]byte, sig *elgamal.CurvePoint) (bool, error) {
+	if len(pubs) != len(msgs) {
+		return false, opError(op, elgamal.ErrLengthMismatch)
+	}
+	if len(pubs) == 0 {
+		return false, opError(op, elgamal.ErrEmpty)
+	}
+	if err := checkG1(sig); err != nil {
+		return false, opError(op, err)
+	}
+
+	// e(sig,-H) * ∏e(H(m_i),pub_i) = 1
+	Ps := []*elgamal.CurvePoint{sig}
+	Qs := []*G2Point{g2Gen.Neg()}
+	seen := make(map[string]bool, len(msgs))
+	for i, msg := range msgs {
+		if seen[string(msg)] {
+			return false, itemError(op, "message", i, ErrDuplicateMessage)
+		}
+		seen[string(msg)] = true
+		if pubs[i] == nil || pubs[i].IsInfinity() {
+			return false, itemError(op, "public key", i, elgamal.ErrPointNotOnCurve)
+		}
+		M, err := HashToG1([]byte(SignatureDomain), msg)
+		if err != nil {
+			return false, opError(op, err)
+		}
+		Ps = append(Ps, M)
+		Qs = append(Qs, pubs[i])
+	}
+
+	ok, err := PairingCheck(Ps, Qs)
+	if err != nil {
+		return false, opError(op, err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSignAggregate(t *testing.T) {
+	msgs := [][]byte{[]byte("block 1"), []byte("block 2"), []byte("block 3")}
+	pubs := make([]*G2Point, len(msgs))
+	sigs := make([]*elgamal.CurvePoint, len(msgs))
+	for i := range msgs {
+		priv, err := GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pubs[i], err = G2PublicKey(priv); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := CheckKeyPair(&priv.PublicKey, pubs[i]); err != nil || !ok {
+			t.Fatalf("key pair %d does not match: %v", i, err)
+		}
+		if sigs[i], err = Sign(priv, msgs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ok, err := VerifySignature(pubs[0], msgs[0], sigs[0])
+	if err != nil || !ok {
+		t.Fatalf("signature failed to verify: %v", err)
+	}
+	if ok, _ := VerifySignature(pubs[1], msgs[0], sigs[0]); ok {
+		t.Fatal("signature verified under another key")
+	}
+	if ok, _ := CheckKeyPair((*sm2.PublicKey)(elgamal.Generator(G1())), pubs[0]); ok {
+		t.Fatal("mismatched key pair accepted")
+	}
+
+	agg, err := AggregateSignatures(sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err = AggregateVerify(pubs, msgs, agg)
+	if err != nil || !ok {
+		t.Fatalf("aggregate signature failed to verify: %v", err)
+	}
+
+	// 交换消息顺序后验证失败
+	swapped := [][]byte{msgs[1], msgs[0], msgs[2]}
+	if ok, _ := AggregateVerify(pubs, swapped, agg); ok {
+		t.Fatal("aggregate signature verified with swapped messages")
+	}
+
+	dup := [][]byte{msgs[0], msgs[0], msgs[2]}
+	if _, err := AggregateVerify(pubs, dup, agg); !errors.Is(err, ErrDuplicateMessage) {
+		t.Fatalf("got %v, want ErrDuplicateMessage", err)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bn254 provides the BN254 pairing-friendly curve (alt_bn128, as used by
+// Ethereum precompiles) as an optional backend for ledgers where on-chain
+// verification cost dominates. G1 is presented through the elliptic.Curve
+// interface, so PPKS keys and ciphertexts built on G1() work unchanged with
+// packages elgamal and keyswitch; the pairing into GT then lets share witnesses
+// and BLS signatures be aggregated non-interactively and checked with a constant
+// number of pairings.
+//
+// BLS12-381 is not provided. The arithmetic is built on math/big and is not
+// constant time.
+// BN254配对友好曲线（alt_bn128，即以太坊预编译合约所用曲线）：为链上验证开销占主导的账本提供的可选后端。
+// G1以elliptic.Curve接口呈现，基于G1()构造的PPKS密钥与密文可直接用于elgamal与keyswitch包；
+// 借助到GT的双线性配对，份额见证与BLS签名可非交互地聚合，并以常数次配对验证。
+//
+// 未提供BLS12-381。运算基于math/big实现，非常数时间。
+package bn254
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 曲线参数：y^2 = x^3 + 3，阶为素数r，G1 = (1,2)
+var (
+	fieldP, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	order, _  = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	curveB    = big.NewInt(3)
+
+	g1 = &curve{params: &elliptic.CurveParams{
+		P:       fieldP,
+		N:       order,
+		B:       curveB,
+		Gx:      big.NewInt(1),
+		Gy:      big.NewInt(2),
+		BitSize: 254,
+		Name:    "BN254",
+	}}
+)
+
+// G1 returns the group G1 of BN254, y^2 = x^3 + 3 over Fp. Its a coefficient is 0
+// rather than -3, so compressed SEC 1 encodings do not apply.
+// 返回BN254的G1群，即Fp上的y^2 = x^3 + 3。其系数a为0而非-3，因此不适用SEC 1压缩编码。
+func G1() elliptic.Curve {
+	return g1
+}
+
+// Order returns r, the prime order of G1, G2 and GT.
+// 返回G1、G2与GT的素数阶r。
+func Order() *big.Int {
+	return new(big.Int).Set(order)
+}
+
+// curve implements elliptic.Curve for G1 in affine coordinates, the identity
+// being (0,0).
+// 以仿射坐标实现G1的elliptic.Curve，单位元为(0,0)。
+type curve struct {
+	params *elliptic.CurveParams
+}
+
+// Params returns the parameters of G1.
+// 返回G1的参数。
+func (c *curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+// IsOnCurve reports whether y^2 = x^3 + 3.
+// 判断是否满足y^2 = x^3 + 3。
+func (c *curve) IsOnCurve(x, y *big.Int) bool {
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, fieldP)
+	return y2.Cmp(rhs(x)) == 0
+}
+
+// Add returns (x1,y1)+(x2,y2), handling the identity and equal points.
+// 点加：处理单位元及两点相同的情形。
+func (c *curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if isIdentity(x1, y1) {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	}
+	if isIdentity(x2, y2) {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+	if x1.Cmp(x2) == 0 {
+		if y1.Cmp(y2) == 0 {
+			return c.Double(x1, y1)
+		}
+		return new(big.Int), new(big.Int)
+	}
+
+	// λ = (y2-y1)/(x2-x1)
+	l := new(big.Int).Sub(x2, x1)
+	l.ModInverse(l.Mod(l, fieldP), fieldP)
+	l.Mul(l, new(big.Int).Sub(y2, y1))
+	return chord(l, x1, y1, x2)
+}
+
+// Double returns 2(x1,y1).
+// 倍点。
+func (c *curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	if isIdentity(x1, y1) || y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	// λ = 3x^2/2y
+	l := new(big.Int).Lsh(y1, 1)
+	l.ModInverse(l.Mod(l, fieldP), fieldP)
+	l.Mul(l, new(big.Int).Mul(big.NewInt(3), new(big.Int).Mul(x1, x1)))
+	return chord(l, x1, y1, x1)
+}
+
+// ScalarMult returns k(x1,y1), k in big-endian form.
+// 数乘：返回k(x1,y1)，k为大端字节。
+func (c *curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	x, y := new(big.Int), new(big.Int)
+	for _, b := range k {
+		for i := 7; i >= 0; i-- {
+			x, y = c.Double(x, y)
+			if b>>uint(i)&1 == 1 {
+				x, y = c.Add(x, y, x1, y1)
+			}
+		}
+	}
+	return x, y
+}
+
+// ScalarBaseMult returns kG, k in big-endian form.
+// 基点数乘：返回kG，k为大端字节。
+func (c *curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+// chord returns the third intersection, negated, of the line of slope l through
+// (x1,y1) and (x2,·).
+// 返回斜率为l、过(x1,y1)与(x2,·)的直线与曲线第三个交点的负点。
+func chord(l, x1, y1, x2 *big.Int) (*big.Int, *big.Int) {
+	l.Mod(l, fieldP)
+	x3 := new(big.Int).Mul(l, l)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, fieldP)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, l)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, fieldP)
+	return x3, y3
+}
+
+// rhs returns x^3 + 3 mod p.
+// 返回x^3 + 3模p。
+func rhs(x *big.Int) *big.Int {
+	r := new(big.Int).Mul(x, x)
+	r.Mul(r, x)
+	r.Add(r, curveB)
+	return r.Mod(r, fieldP)
+}
+
+func isIdentity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
+
+// GenerateKey generates a G1 key pair for PPKS, in the sm2 key types the other
+// packages take. Such keys must not be used with SM2 signatures or encryption.
+// 生成密钥：生成用于PPKS的G1密钥对，采用其他包所用的sm2密钥类型。此类密钥不可用于SM2签名或加密。
+//
+// 参数：
+//		随机源	random，为nil时使用crypto/rand
+// 返回：
+// 		私钥
+func GenerateKey(random io.Reader) (*sm2.PrivateKey, error) {
+	k, err := ec.RandFieldElement(g1, random)
+	if err != nil {
+		return nil, opError("GenerateKey", err)
+	}
+	priv := new(sm2.PrivateKey)
+	priv.Curve = g1
+	priv.D = k
+	priv.X, priv.Y = g1.ScalarBaseMult(k.Bytes())
+	return priv, nil
+}
+
+// checkG1 reports an error unless p is a finite point of G1.
+// 校验p为G1上的有限点。
+func checkG1(p *elgamal.CurvePoint) error {
+	if err := elgamal.CheckPoint(p); err != nil {
+		return err
+	}
+	if p.Curve != g1 {
+		return elgamal.ErrCurveMismatch
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestG1(t *testing.T) {
+	c := G1()
+	G := elgamal.Generator(c)
+	if err := elgamal.CheckPoint(G); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2G + G = 3G，G - G = O
+	x, y := c.Double(G.X, G.Y)
+	x, y = c.Add(x, y, G.X, G.Y)
+	P3 := elgamal.BaseMultiple(c, 3)
+	if x.Cmp(P3.X) != 0 || y.Cmp(P3.Y) != 0 {
+		t.Fatal("2G + G != 3G")
+	}
+	negG, err := elgamal.NegPoint(G)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y := c.Add(G.X, G.Y, negG.X, negG.Y); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("G - G is not the identity")
+	}
+	if x, y := c.ScalarBaseMult(Order().Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("rG is not the identity")
+	}
+	if c.IsOnCurve(big.NewInt(1), big.NewInt(3)) {
+		t.Fatal("(1,3) accepted")
+	}
+
+	priv, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.BaseMultiple(c, 99)
+	ct, err := elgamal.PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := elgamal.PointDecrypt(ct, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.X.Cmp(D.X) != 0 || got.Y.Cmp(D.Y) != 0 {
+		t.Fatal("decryption on G1 failed")
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"math/big"
+)
+
+// fp2 is a + b*u in Fp2 = Fp[u]/(u^2+1).
+// Fp2 = Fp[u]/(u^2+1)中的元素a + b*u。
+type fp2 struct {
+	a, b *big.Int
+}
+
+func newFp2(a, b *big.Int) fp2 {
+	return fp2{a: fpMod(a), b: fpMod(b)}
+}
+
+func fp2Zero() fp2 { return fp2{a: new(big.Int), b: new(big.Int)} }
+
+func (x fp2) add(y fp2) fp2 {
+	return newFp2(new(big.Int).Add(x.a, y.a), new(big.Int).Add(x.b, y.b))
+}
+
+func (x fp2) sub(y fp2) fp2 {
+	return newFp2(new(big.Int).Sub(x.a, y.a), new(big.Int).Sub(x.b, y.b))
+}
+
+func (x fp2) neg() fp2 {
+	return newFp2(new(big.Int).Neg(x.a), new(big.Int).Neg(x.b))
+}
+
+// mul returns x*y, (ac - bd) + (ad + bc)u.
+// 乘法：(ac - bd) + (ad + bc)u。
+func (x fp2) mul(y fp2) fp2 {
+	ac := new(big.Int).Mul(x.a, y.a)
+	bd := new(big.Int).Mul(x.b, y.b)
+	ad := new(big.Int).Mul(x.a, y.b)
+	bc := new(big.Int).Mul(x.b, y.a)
+	return newFp2(ac.Sub(ac, bd), ad.Add(ad, bc))
+}
+
+func (x fp2) mulScalar(k int64) fp2 {
+	K := big.NewInt(k)
+	return newFp2(new(big.Int).Mul(x.a, K), new(big.Int).Mul(x.b, K))
+}
+
+// inv returns 1/x = (a - bu)/(a^2 + b^2); x must not be zero.
+// 求逆：1/x = (a - bu)/(a^2 + b^2)，x不可为0。
+func (x fp2) inv() fp2 {
+	n := new(big.Int).Mul(x.a, x.a)
+	n.Add(n, new(big.Int).Mul(x.b, x.b))
+	n.ModInverse(n, fieldP)
+	return newFp2(new(big.Int).Mul(x.a, n), new(big.Int).Neg(new(big.Int).Mul(x.b, n)))
+}
+
+func (x fp2) isZero() bool { return x.a.Sign() == 0 && x.b.Sign() == 0 }
+
+func (x fp2) equal(y fp2) bool { return x.a.Cmp(y.a) == 0 && x.b.Cmp(y.b) == 0 }
+
+// fp12 is an element of Fp12 = Fp[w]/(w^12 - 18w^6 + 82), coefficient i of w^i.
+// Fp2 embeds into it by u -> w^6 - 9, and the twist by x -> x*w^2, y -> y*w^3.
+// Fp12 = Fp[w]/(w^12 - 18w^6 + 82)中的元素，第i个系数对应w^i。
+// Fp2经u -> w^6 - 9嵌入其中，扭曲线上的点经x -> x*w^2、y -> y*w^3映射到其上。
+type fp12 [12]*big.Int
+
+func fp12One() *fp12 {
+	var z fp12
+	for i := range z {
+		z[i] = new(big.Int)
+	}
+	z[0].SetInt64(1)
+	return &z
+}
+
+// fp12FromFp returns the embedding of a in Fp12.
+// 返回a在Fp12中的嵌入。
+func fp12FromFp(a *big.Int) *fp12 {
+	z := fp12One()
+	z[0] = fpMod(a)
+	return z
+}
+
+// untwist returns the embedding of x*w^shift for x in Fp2, shift < 6.
+// 返回Fp2中的x与w^shift之积在Fp12中的嵌入，shift < 6。
+func untwist(x fp2, shift int) *fp12 {
+	// a + bu -> (a - 9b) + b*w^6
+	z := fp12One()
+	z[0].SetInt64(0)
+	z[shift] = fpMod(new(big.Int).Sub(x.a, new(big.Int).Mul(big.NewInt(9), x.b)))
+	z[shift+6] = new(big.Int).Set(x.b)
+	return z
+}
+
+func (x *fp12) add(y *fp12) *fp12 {
+	var z fp12
+	for i := range z {
+		z[i] = fpMod(new(big.Int).Add(x[i], y[i]))
+	}
+	return &z
+}
+
+func (x *fp12) sub(y *fp12) *fp12 {
+	var z fp12
+	for i := range z {
+		z[i] = fpMod(new(big.Int).Sub(x[i], y[i]))
+	}
+	return &z
+}
+
+// mul returns x*y, reducing with w^12 = 18w^6 - 82.
+// 乘法：以w^12 = 18w^6 - 82约简。
+func (x *fp12) mul(y *fp12) *fp12 {
+	var t [23]big.Int
+	var m big.Int
+	for i := 0; i < 12; i++ {
+		if x[i].Sign() == 0 {
+			continue
+		}
+		for j := 0; j < 12; j++ {
+			t[i+j].Add(&t[i+j], m.Mul(x[i], y[j]))
+		}
+	}
+	for k := 22; k >= 12; k-- {
+		t[k].Mod(&t[k], fieldP)
+		t[k-6].Add(&t[k-6], m.Mul(&t[k], big18))
+		t[k-12].Sub(&t[k-12], m.Mul(&t[k], big82))
+	}
+	var z fp12
+	for i := range z {
+		z[i] = fpMod(&t[i])
+	}
+	return &z
+}
+
+// mulFp returns x*k for k in Fp.
+// 返回x与Fp中元素k之积。
+func (x *fp12) mulFp(k *big.Int) *fp12 {
+	var z fp12
+	for i := range z {
+		z[i] = fpMod(new(big.Int).Mul(x[i], k))
+	}
+	return &z
+}
+
+// frobenius returns x^p, mapping w^i to (w^p)^i.
+// Frobenius映射：返回x^p，即将w^i映射为(w^p)^i。
+func (x *fp12) frobenius() *fp12 {
+	z := fp12One()
+	z[0].SetInt64(0)
+	for i := range x {
+		if x[i].Sign() != 0 {
+			z = z.add(frobW[i].mulFp(x[i]))
+		}
+	}
+	return z
+}
+
+// inv returns 1/x as x^(p+p^2+...+p^11)/N(x), where the norm N(x) is in Fp.
+// 求逆：1/x = x^(p+p^2+...+p^11)/N(x)，其中范数N(x)属于Fp。
+func (x *fp12) inv() *fp12 {
+	conj := fp12One()
+	f := x
+	for i := 1; i < 12; i++ {
+		f = f.frobenius()
+		conj = conj.mul(f)
+	}
+	norm := x.mul(conj)[0]
+	return conj.mulFp(new(big.Int).ModInverse(norm, fieldP))
+}
+
+// exp returns x^k for k >= 0.
+// 幂运算：返回x^k，k >= 0。
+func (x *fp12) exp(k *big.Int) *fp12 {
+	z := fp12One()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		z = z.mul(z)
+		if k.Bit(i) == 1 {
+			z = z.mul(x)
+		}
+	}
+	return z
+}
+
+func (x *fp12) equal(y *fp12) bool {
+	for i := range x {
+		if x[i].Cmp(y[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *fp12) isOne() bool {
+	return x.equal(fp12One())
+}
+
+var (
+	big18 = big.NewInt(18)
+	big82 = big.NewInt(82)
+
+	// frobW[i] = (w^p)^i
+	frobW = frobeniusTable()
+)
+
+func frobeniusTable() [12]*fp12 {
+	w := fp12One()
+	w[0].SetInt64(0)
+	w[1].SetInt64(1)
+	wp := w.exp(fieldP)
+
+	var t [12]*fp12
+	t[0] = fp12One()
+	for i := 1; i < 12; i++ {
+		t[i] = t[i-1].mul(wp)
+	}
+	return t
+}
+
+func fpMod(a *big.Int) *big.Int { return new(big.Int).Mod(a, fieldP) }
@@ -0,0 +1,220 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+)
+
+// G2 lies on the twist y^2 = x^3 + 3/(9+u) over Fp2.
+// G2位于Fp2上的扭曲线y^2 = x^3 + 3/(9+u)。
+var (
+	twistB = newFp2(big.NewInt(3), new(big.Int)).mul(newFp2(big.NewInt(9), big.NewInt(1)).inv())
+
+	g2Gen = &G2Point{
+		x: newFp2(
+			bigFromString("10857046999023057135944570762232829481370756359578518086990519993285655852781"),
+			bigFromString("11559732032986387107991004021392285783925812861821192530917403151452391805634")),
+		y: newFp2(
+			bigFromString("8495653923123431417604973247489272438418190587263600148770280649306958101930"),
+			bigFromString("4082367875863433681332203403145435568316851327593401208105741076214120093531")),
+		finite: true,
+	}
+)
+
+// g2Size is the length of an encoded finite G2 point.
+// G2有限点编码的字节长度。
+const g2Size = 1 + 4*32
+
+// G2Point is a point of G2. The zero value is the identity.
+// G2上的点，零值为单位元。
+type G2Point struct {
+	x, y   fp2
+	finite bool
+}
+
+// G2Generator returns the generator of G2.
+// 返回G2的生成元。
+func G2Generator() *G2Point {
+	g := *g2Gen
+	return &g
+}
+
+// G2ScalarBaseMult returns k times the generator of G2.
+// 返回G2生成元的k倍。
+func G2ScalarBaseMult(k *big.Int) *G2Point {
+	return g2Gen.ScalarMult(k)
+}
+
+// IsInfinity reports whether q is the identity.
+// 判断q是否为单位元。
+func (q *G2Point) IsInfinity() bool {
+	return !q.finite
+}
+
+// Equal reports whether q and r are the same point.
+// 判断q与r是否为同一点。
+func (q *G2Point) Equal(r *G2Point) bool {
+	if !q.finite || !r.finite {
+		return q.finite == r.finite
+	}
+	return q.x.equal(r.x) && q.y.equal(r.y)
+}
+
+// Neg returns -q.
+// 返回-q。
+func (q *G2Point) Neg() *G2Point {
+	if !q.finite {
+		return &G2Point{}
+	}
+	return &G2Point{x: q.x, y: q.y.neg(), finite: true}
+}
+
+// Add returns q+r.
+// 返回q+r。
+func (q *G2Point) Add(r *G2Point) *G2Point {
+	if !q.finite {
+		return r.copy()
+	}
+	if !r.finite {
+		return q.copy()
+	}
+	if q.x.equal(r.x) {
+		if q.y.equal(r.y) {
+			return q.double()
+		}
+		return &G2Point{}
+	}
+	l := r.y.sub(q.y).mul(r.x.sub(q.x).inv())
+	return q.chord(l, r.x)
+}
+
+// ScalarMult returns kq, k is reduced modulo the group order.
+// 返回kq，k按群的阶取模。
+func (q *G2Point) ScalarMult(k *big.Int) *G2Point {
+	k = new(big.Int).Mod(k, order)
+	z := &G2Point{}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		z = z.double()
+		if k.Bit(i) == 1 {
+			z = z.Add(q)
+		}
+	}
+	return z
+}
+
+// Bytes returns 0x04||x.a||x.b||y.a||y.b for x = x.a + x.b*u, or 0x00 for the identity.
+// 返回点的编码0x04||x.a||x.b||y.a||y.b，其中x = x.a + x.b*u；单位元编码为0x00。
+func (q *G2Point) Bytes() []byte {
+	if !q.finite {
+		return []byte{0}
+	}
+	b := make([]byte, g2Size)
+	b[0] = 0x04
+	q.x.a.FillBytes(b[1:33])
+	q.x.b.FillBytes(b[33:65])
+	q.y.a.FillBytes(b[65:97])
+	q.y.b.FillBytes(b[97:129])
+	return b
+}
+
+// NewG2PointFromBytes decodes a finite G2 point encoded by Bytes, checking that it
+// lies on the twist and in the subgroup of order r.
+// 由字节构造G2点：解析Bytes编码的有限点，校验其在扭曲线上且位于阶为r的子群中。
+//
+// 参数：
+//		编码	b
+// 返回：
+// 		G2点
+func NewG2PointFromBytes(b []byte) (*G2Point, error) {
+	if len(b) != g2Size || b[0] != 0x04 {
+		return nil, opError("NewG2PointFromBytes", elgamal.ErrInvalidPointEncoding)
+	}
+	var c [4]*big.Int
+	for i := range c {
+		c[i] = new(big.Int).SetBytes(b[1+32*i : 33+32*i])
+		if c[i].Cmp(fieldP) >= 0 {
+			return nil, opError("NewG2PointFromBytes", elgamal.ErrPointNotOnCurve)
+		}
+	}
+	q := &G2Point{x: fp2{c[0], c[1]}, y: fp2{c[2], c[3]}, finite: true}
+	if !q.onTwist() {
+		return nil, opError("NewG2PointFromBytes", elgamal.ErrPointNotOnCurve)
+	}
+	// rq = O，ScalarMult对k取模，故计算(r-1)q + q
+	if q.ScalarMult(new(big.Int).Sub(order, big.NewInt(1))).Add(q).finite {
+		return nil, opError("NewG2PointFromBytes", ErrNotInSubgroup)
+	}
+	return q, nil
+}
+
+// AggregateG2 returns the sum of points, e.g. the G2 key of a committee or the
+// aggregate of share witnesses.
+// G2点聚合：返回各点之和，如委员会的G2公钥或份额见证的聚合。
+//
+// 参数：
+//		G2点集合	points
+// 返回：
+// 		各点之和
+func AggregateG2(points []*G2Point) (*G2Point, error) {
+	if len(points) == 0 {
+		return nil, opError("AggregateG2", elgamal.ErrEmpty)
+	}
+	sum := &G2Point{}
+	for i, q := range points {
+		if q == nil {
+			return nil, itemError("AggregateG2", "point", i, elgamal.ErrPointNotOnCurve)
+		}
+		sum = sum.Add(q)
+	}
+	return sum, nil
+}
+
+func (q *G2Point) copy() *G2Point {
+	c := *q
+	return &c
+}
+
+func (q *G2Point) double() *G2Point {
+	if !q.finite || q.y.isZero() {
+		return &G2Point{}
+	}
+	// λ = 3x^2/2y
+	l := q.x.mul(q.x).mulScalar(3).mul(q.y.mulScalar(2).inv())
+	return q.chord(l, q.x)
+}
+
+// chord returns the point on the line of slope l through q and (x2,·).
+// 返回斜率为l、过q与(x2,·)的直线所确定的点。
+func (q *G2Point) chord(l, x2 fp2) *G2Point {
+	x3 := l.mul(l).sub(q.x).sub(x2)
+	y3 := l.mul(q.x.sub(x3)).sub(q.y)
+	return &G2Point{x: x3, y: y3, finite: true}
+}
+
+func (q *G2Point) onTwist() bool {
+	return q.y.mul(q.y).equal(q.x.mul(q.x).mul(q.x).add(twistB))
+}
+
+func bigFromString(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("bn254: bad constant " + s)
+	}
+	return n
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// The identity-based mode follows the key structure of SM9 encryption (GM/T 0044)
+// on BN254: a key generation center holds the master key s and publishes
+// Ppub = s*G; the key of an identity ID is de = s/(h+s) * H, h = H1(ID||hid), and
+// anybody derives the point Q_ID = h*G + Ppub = (h+s)*G from ID alone. Requesters
+// therefore need no registered key pair: the committee switches a ciphertext to an
+// identity, and only the holder of de recovers e(D,H), from which the document key
+// is derived. The curve is BN254 rather than the SM9 curve, so keys do not
+// interoperate with SM9 toolchains.
+//
+// 基于标识的模式沿用SM9加密（GM/T 0044）在BN254上的密钥结构：密钥生成中心持有主私钥s并公布
+// Ppub = s*G；标识ID的私钥为de = s/(h+s) * H，h = H1(ID||hid)，任何人仅凭ID即可计算点
+// Q_ID = h*G + Ppub = (h+s)*G。因此请求者无需注册密钥对：委员会将密文置换到某一标识，
+// 只有持有de者能恢复e(D,H)，并由其派生文档密钥。所用曲线为BN254而非SM9曲线，密钥不能与SM9工具链互通。
+
+// IdentityHID is the SM9 function identifier of encryption keys, appended to the
+// identity before hashing.
+// SM9加密私钥的函数识别符，哈希前附加在标识之后。
+const IdentityHID = 0x03
+
+// identityContext is the proof context of identity shares.
+// 标识份额证明所用的上下文。
+const identityContext = "ppks-identity-share"
+
+// MasterKey is the master key of the key generation center.
+// 密钥生成中心的主私钥。
+type MasterKey struct {
+	s   *big.Int
+	pub *elgamal.CurvePoint
+}
+
+// GenerateMasterKey generates a master key, drawing it from random. A nil random
+// uses crypto/rand.
+// 生成主私钥：由random生成主私钥，random为nil时使用crypto/rand。
+//
+// 参数：
+//		随机源	random
+// 返回：
+// 		主私钥
+func GenerateMasterKey(random io.Reader) (*MasterKey, error) {
+	s, err := ec.RandFieldElement(g1, random)
+	if err != nil {
+		return nil, opError("GenerateMasterKey", err)
+	}
+	pub := elgamal.Generator(g1)
+	pub.X, pub.Y = g1.ScalarBaseMult(s.Bytes())
+	return &MasterKey{s: s, pub: pub}, nil
+}
+
+// Public returns the master public key Ppub = s*G.
+// 返回主公钥Ppub = s*G。
+func (m *MasterKey) Public() *elgamal.CurvePoint {
+	P := *m.pub
+	return &P
+}
+
+// Extract returns the key de = s/(h+s) * H of identity id, to hand to its holder
+// once authenticated.
+// 提取私钥：返回标识id的私钥de = s/(h+s) * H，在认证持有者身份后交付。
+//
+// 参数：
+//		标识	id
+// 返回：
+// 		标识私钥
+func (m *MasterKey) Extract(id []byte) (*G2Point, error) {
+	t := new(big.Int).Add(h1(id), m.s)
+	t.Mod(t, order)
+	if t.Sign() == 0 {
+		// 概率可忽略，SM9要求此时重新生成主私钥
+		return nil, opError("Extract", elgamal.ErrOutOfRange)
+	}
+	t.ModInverse(t, order)
+	t.Mul(t, m.s)
+	return G2ScalarBaseMult(t), nil
+}
+
+// IdentityPoint returns Q_ID = h*G + Ppub for identity id under the master public
+// key masterPub. It plays the part of the base point for the identity's shares.
+// 标识点：返回主公钥masterPub下标识id的点Q_ID = h*G + Ppub，在标识份额中充当基点。
+//
+// 参数：
+//		主公钥	masterPub
+//		标识	id
+// 返回：
+// 		标识点
+func IdentityPoint(masterPub *elgamal.CurvePoint, id []byte) (*elgamal.CurvePoint, error) {
+	if err := checkG1(masterPub); err != nil {
+		return nil, opError("IdentityPoint", err)
+	}
+	Q := elgamal.Generator(g1)
+	hx, hy := g1.ScalarBaseMult(h1(id).Bytes())
+	Q.X, Q.Y = g1.Add(hx, hy, masterPub.X, masterPub.Y)
+	if Q.IsInfinity() {
+		return nil, opError("IdentityPoint", elgamal.ErrPointNotOnCurve)
+	}
+	return Q, nil
+}
+
+// IdentityShareCal calculates the share of the node key priv switching the
+// ciphertext with left point rB to identity id: (K,C) = (ri*Q_ID, -k*rB + ri*Ppub).
+// keyswitch.ShareReplace combines such shares as usual.
+// 标识份额计算：以节点私钥priv计算将左侧点为rB的密文置换到标识id的份额
+// (K,C) = (ri*Q_ID, -k*rB + ri*Ppub)。此类份额仍由keyswitch.ShareReplace合并。
+//
+// 参数：
+//		主公钥			masterPub
+//		标识			id
+//		原密文左侧点	rB
+//		节点私钥		priv
+// 返回：
+// 		份额，随机数ri
+func IdentityShareCal(masterPub *elgamal.CurvePoint, id []byte, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*elgamal.CipherText, *big.Int, error) {
+	Q, err := IdentityPoint(masterPub, id)
+	if err != nil {
+		return nil, nil, opError("IdentityShareCal", err)
+	}
+	if err := checkG1(rB); err != nil {
+		return nil, nil, opError("IdentityShareCal", err)
+	}
+	if priv == nil || priv.D == nil || priv.Curve != g1 {
+		return nil, nil, opError("IdentityShareCal", elgamal.ErrCurveMismatch)
+	}
+	ri, err := ec.RandFieldElement(g1, nil)
+	if err != nil {
+		return nil, nil, opError("IdentityShareCal", err)
+	}
+
+	var share elgamal.CipherText
+	share.K.Curve, share.C.Curve = g1, g1
+	share.K.X, share.K.Y = g1.ScalarMult(Q.X, Q.Y, ri.Bytes())
+	kx, ky := g1.ScalarMult(rB.X, rB.Y, priv.D.Bytes())
+	kx, ky = ec.Neg(g1, kx, ky)
+	px, py := g1.ScalarMult(masterPub.X, masterPub.Y, ri.Bytes())
+	share.C.X, share.C.Y = g1.Add(kx, ky, px, py)
+	return &share, ri, nil
+}
+
+// IdentityShareProofGen proves that share was calculated by IdentityShareCal with
+// the nonce ri and the node key priv.
+// 标识份额证明生成：证明份额share由IdentityShareCal以随机数ri及节点私钥priv计算得到。
+//
+// 参数：
+//		随机数			ri
+//		节点私钥		priv
+//		份额			share
+//		主公钥			masterPub
+//		标识			id
+//		原密文左侧点	rB
+// 返回：
+// 		证明
+func IdentityShareProofGen(ri *big.Int, priv *sm2.PrivateKey, share *elgamal.CipherText, masterPub *elgamal.CurvePoint, id []byte, rB *elgamal.CurvePoint) (*proof.LinearProof, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("IdentityShareProofGen", elgamal.ErrCurveMismatch)
+	}
+	rel, err := identityRelation(share, &priv.PublicKey, masterPub, id, rB)
+	if err != nil {
+		return nil, opError("IdentityShareProofGen", err)
+	}
+	return proof.ProveLinear(identityContext, rel, []*big.Int{ri, priv.D})
+}
+
+// IdentityShareProofVry verifies a proof made by IdentityShareProofGen.
+// 标识份额证明验证：验证IdentityShareProofGen生成的证明。
+//
+// 参数：
+//		证明			p
+//		份额			share
+//		节点公钥		nodePubKey
+//		主公钥			masterPub
+//		标识			id
+//		原密文左侧点	rB
+// 返回：
+// 		验证结果
+func IdentityShareProofVry(p *proof.LinearProof, share *elgamal.CipherText, nodePubKey *sm2.PublicKey, masterPub *elgamal.CurvePoint, id []byte, rB *elgamal.CurvePoint) (bool, error) {
+	rel, err := identityRelation(share, nodePubKey, masterPub, id, rB)
+	if err != nil {
+		return false, opError("IdentityShareProofVry", err)
+	}
+	return proof.VerifyLinear(identityContext, rel, p)
+}
+
+// identityRelation returns {K = ri*Q_ID, C = ri*Ppub + k*(-rB), Y = k*G} for the
+// witnesses (ri, k).
+// 返回关于证据(ri, k)的关系{K = ri*Q_ID, C = ri*Ppub + k*(-rB), Y = k*G}。
+func identityRelation(share *elgamal.CipherText, nodePubKey *sm2.PublicKey, masterPub *elgamal.CurvePoint, id []byte, rB *elgamal.CurvePoint) (*proof.Relation, error) {
+	if err := elgamal.CheckCipherText(share); err != nil {
+		return nil, err
+	}
+	if err := checkG1((*elgamal.CurvePoint)(nodePubKey)); err != nil {
+		return nil, err
+	}
+	Q, err := IdentityPoint(masterPub, id)
+	if err != nil {
+		return nil, err
+	}
+	negRB, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return nil, err
+	}
+	return &proof.Relation{
+		Bases: [][]*elgamal.CurvePoint{
+			{Q, nil},
+			{masterPub, negRB},
+			{nil, elgamal.Generator(g1)},
+		},
+		Targets: []*elgamal.CurvePoint{&share.K, &share.C, (*elgamal.CurvePoint)(nodePubKey)},
+	}, nil
+}
+
+// IdentityDecrypt recovers e(D,H) from a ciphertext of D switched to the identity
+// whose key is de: e(C,H) / e(K,de), since C = D + R*Ppub and K = R*Q_ID.
+// 标识解密：由置换到标识私钥de的、D的密文恢复e(D,H)，即e(C,H) / e(K,de)，
+// 其中C = D + R*Ppub，K = R*Q_ID。
+//
+// 参数：
+//		置换后的密文	ct
+//		标识私钥		de
+// 返回：
+// 		e(D,H)
+func IdentityDecrypt(ct *elgamal.CipherText, de *G2Point) (*GT, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("IdentityDecrypt", err)
+	}
+	if de == nil || de.IsInfinity() {
+		return nil, opError("IdentityDecrypt", elgamal.ErrPointNotOnCurve)
+	}
+	if err := checkG1(&ct.K); err != nil {
+		return nil, opError("IdentityDecrypt", err)
+	}
+	f := miller(&ct.C, g2Gen).mul(miller(&ct.K, de.Neg()))
+	return &GT{f: finalExp(f)}, nil
+}
+
+// PointKey returns e(D,H), the value the owner of D derives the document key
+// from in the identity-based mode, as IdentityDecrypt does for the requester.
+// 返回e(D,H)：在基于标识的模式中，D的所有者由该值派生文档密钥，请求者则由IdentityDecrypt得到该值。
+//
+// 参数：
+//		明文点	D
+// 返回：
+// 		e(D,H)
+func PointKey(D *elgamal.CurvePoint) (*GT, error) {
+	if err := checkG1(D); err != nil {
+		return nil, opError("PointKey", err)
+	}
+	return Pair(D, g2Gen)
+}
+
+// h1 is the SM9 hash H1(id||hid, r): SM3 in counter mode over 0x01||id||hid, 40
+// bytes reduced to [1, r-1].
+// SM9的哈希函数H1(id||hid, r)：以计数器模式对0x01||id||hid计算SM3，取40字节并约简到[1, r-1]。
+func h1(id []byte) *big.Int {
+	var ha []byte
+	var ct [4]byte
+	for i := uint32(1); len(ha) < 40; i++ {
+		binary.BigEndian.PutUint32(ct[:], i)
+		h := sm3.New()
+		h.Write([]byte{0x01})
+		h.Write(id)
+		h.Write([]byte{IdentityHID})
+		h.Write(ct[:])
+		ha = append(ha, h.Sum(nil)...)
+	}
+	n1 := new(big.Int).Sub(order, big.NewInt(1))
+	h := new(big.Int).SetBytes(ha[:40])
+	h.Mod(h, n1)
+	return h.Add(h, big.NewInt(1))
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestIdentityKeySwitch(t *testing.T) {
+	master, err := GenerateMasterKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mpk := master.Public()
+	id := []byte("alice@example.com")
+	de, err := master.Extract(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privs := make([]*sm2.PrivateKey, 3)
+	pubs := make([]*sm2.PublicKey, len(privs))
+	for i := range privs {
+		if privs[i], err = GenerateKey(nil); err != nil {
+			t.Fatal(err)
+		}
+		pubs[i] = &privs[i].PublicKey
+	}
+	committee, err := keyswitch.AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.BaseMultiple(G1(), 777)
+	ct, err := elgamal.PointEncrypt(committee, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := make(elgamal.CipherVector, len(privs))
+	for i, priv := range privs {
+		share, ri, err := IdentityShareCal(mpk, id, &ct.K, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := IdentityShareProofGen(ri, priv, share, mpk, id, &ct.K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := IdentityShareProofVry(p, share, pubs[i], mpk, id, &ct.K); err != nil || !ok {
+			t.Fatalf("share %d proof failed: %v", i, err)
+		}
+		if ok, _ := IdentityShareProofVry(p, share, pubs[i], mpk, []byte("bob@example.com"), &ct.K); ok {
+			t.Fatal("proof verified for another identity")
+		}
+		shares[i] = *share
+	}
+	switched, err := keyswitch.ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := IdentityDecrypt(switched, de)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := PointKey(D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) || len(got.Bytes()) != 384 {
+		t.Fatal("identity decryption differs from the owner's key")
+	}
+
+	other, err := master.Extract([]byte("bob@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := IdentityDecrypt(switched, other); got.Equal(want) {
+		t.Fatal("another identity recovered the key")
+	}
+}
+
+func TestIdentityPointInvalid(t *testing.T) {
+	if _, err := IdentityPoint(elgamal.Generator(sm2.P256Sm2()), []byte("id")); err == nil {
+		t.Fatal("expected error for a master key on another curve")
+	}
+}
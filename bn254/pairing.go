@@ -0,0 +1,199 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+)
+
+// 配对参数
+var (
+	// 最优ate配对的循环参数6u+2，u = 4965661367192848881
+	ateLoop = bigFromString("29793968203157093288")
+	// π(Q) = pQ，π^2(Q) = p^2 Q，对G2中的Q成立
+	pModR  = new(big.Int).Mod(fieldP, order)
+	p2ModR = new(big.Int).Mod(new(big.Int).Mul(fieldP, fieldP), order)
+	// 最终幂的困难部分(p^4 - p^2 + 1)/r
+	hardExp = finalHardExponent()
+)
+
+func finalHardExponent() *big.Int {
+	p2 := new(big.Int).Mul(fieldP, fieldP)
+	e := new(big.Int).Mul(p2, p2)
+	e.Sub(e, p2)
+	e.Add(e, big.NewInt(1))
+	return e.Div(e, order)
+}
+
+// GT is an element of the target group, a subgroup of order r of Fp12*.
+// 目标群GT的元素，GT为Fp12乘法群中阶为r的子群。
+type GT struct {
+	f *fp12
+}
+
+// Equal reports whether a and b are the same element.
+// 判断a与b是否为同一元素。
+func (a *GT) Equal(b *GT) bool {
+	return a.f.equal(b.f)
+}
+
+// IsOne reports whether a is the identity of GT.
+// 判断a是否为GT的单位元。
+func (a *GT) IsOne() bool {
+	return a.f.isOne()
+}
+
+// Bytes returns the 384-byte encoding of a, its twelve coefficients over Fp, 32
+// bytes each, lowest degree first. It suits key derivation.
+// 返回a的384字节编码，即其在Fp上的12个系数，各32字节，低次项在前。可用于密钥派生。
+func (a *GT) Bytes() []byte {
+	b := make([]byte, 12*32)
+	for i, c := range a.f {
+		c.FillBytes(b[32*i : 32*(i+1)])
+	}
+	return b
+}
+
+// Mul returns a*b.
+// 返回a*b。
+func (a *GT) Mul(b *GT) *GT {
+	return &GT{f: a.f.mul(b.f)}
+}
+
+// Exp returns a^k, k is reduced modulo the group order.
+// 返回a^k，k按群的阶取模。
+func (a *GT) Exp(k *big.Int) *GT {
+	return &GT{f: a.f.exp(new(big.Int).Mod(k, order))}
+}
+
+// Pair returns the optimal ate pairing e(P,Q) of a point of G1, possibly the
+// identity, and a point of G2.
+// 配对：返回G1上的点P（可为单位元）与G2上的点Q的最优ate配对e(P,Q)。
+//
+// 参数：
+//		G1点	P
+//		G2点	Q
+// 返回：
+// 		e(P,Q)
+func Pair(P *elgamal.CurvePoint, Q *G2Point) (*GT, error) {
+	if err := checkPairingInput(P, Q); err != nil {
+		return nil, opError("Pair", err)
+	}
+	return &GT{f: finalExp(miller(P, Q))}, nil
+}
+
+// PairingCheck reports whether e(Ps[0],Qs[0])*...*e(Ps[n-1],Qs[n-1]) = 1, sharing
+// one final exponentiation among the pairings.
+// 配对检验：判断e(Ps[0],Qs[0])*...*e(Ps[n-1],Qs[n-1])是否为1，各配对共用一次最终幂运算。
+//
+// 参数：
+//		G1点集合	Ps
+//		G2点集合	Qs
+// 返回：
+// 		乘积是否为1
+func PairingCheck(Ps []*elgamal.CurvePoint, Qs []*G2Point) (bool, error) {
+	if len(Ps) != len(Qs) {
+		return false, opError("PairingCheck", elgamal.ErrLengthMismatch)
+	}
+	if len(Ps) == 0 {
+		return false, opError("PairingCheck", elgamal.ErrEmpty)
+	}
+	f := fp12One()
+	for i := range Ps {
+		if err := checkPairingInput(Ps[i], Qs[i]); err != nil {
+			return false, itemError("PairingCheck", "pair", i, err)
+		}
+		f = f.mul(miller(Ps[i], Qs[i]))
+	}
+	return finalExp(f).isOne(), nil
+}
+
+// checkPairingInput reports an error unless P is a point of G1 or its identity
+// and Q is not nil.
+// 校验P为G1上的点或单位元，且Q不为nil。
+func checkPairingInput(P *elgamal.CurvePoint, Q *G2Point) error {
+	if P == nil || Q == nil {
+		return elgamal.ErrPointNotOnCurve
+	}
+	if P.Curve == g1 && P.IsInfinity() {
+		return nil
+	}
+	return checkG1(P)
+}
+
+// miller runs the Miller loop of the optimal ate pairing, evaluating the lines on
+// the twist at P embedded in Fp12.
+// 最优ate配对的Miller循环：扭曲线上的直线在嵌入Fp12的P处求值。
+func miller(P *elgamal.CurvePoint, Q *G2Point) *fp12 {
+	f := fp12One()
+	if P.IsInfinity() || Q.IsInfinity() {
+		return f
+	}
+	xP, yP := fp12FromFp(P.X), fp12FromFp(P.Y)
+
+	R := Q
+	for i := ateLoop.BitLen() - 2; i >= 0; i-- {
+		f = f.mul(f).mul(line(R, R, xP, yP))
+		R = R.double()
+		if ateLoop.Bit(i) == 1 {
+			f = f.mul(line(R, Q, xP, yP))
+			R = R.Add(Q)
+		}
+	}
+
+	// 加上π(Q)与-π^2(Q)
+	Q1 := Q.ScalarMult(pModR)
+	nQ2 := Q.ScalarMult(p2ModR).Neg()
+	f = f.mul(line(R, Q1, xP, yP))
+	R = R.Add(Q1)
+	return f.mul(line(R, nQ2, xP, yP))
+}
+
+// line evaluates at (xP,yP) the line through A and B, the tangent when A = B.
+// The slope is left as a fraction: its denominator lies in a proper subfield of
+// Fp12 and is removed by the final exponentiation.
+// 在(xP,yP)处计算过A与B的直线（A = B时为切线）的值。斜率保留为分式：其分母属于Fp12的真子域，
+// 会被最终幂运算消去。
+func line(A, B *G2Point, xP, yP *fp12) *fp12 {
+	X1, Y1 := untwist(A.x, 2), untwist(A.y, 3)
+	var num, den *fp12
+	switch {
+	case !A.x.equal(B.x):
+		num = untwist(B.y, 3).sub(Y1)
+		den = untwist(B.x, 2).sub(X1)
+	case A.y.equal(B.y):
+		num = X1.mul(X1).mulFp(big.NewInt(3))
+		den = Y1.add(Y1)
+	default:
+		// 竖直线
+		return xP.sub(X1)
+	}
+	return num.mul(xP.sub(X1)).sub(den.mul(yP.sub(Y1)))
+}
+
+// finalExp returns f^((p^12-1)/r), as f^((p^6-1)(p^2+1)) raised to (p^4-p^2+1)/r.
+// 最终幂运算：返回f^((p^12-1)/r)，即先计算f^((p^6-1)(p^2+1))，再取(p^4-p^2+1)/r次幂。
+func finalExp(f *fp12) *fp12 {
+	t := f
+	for i := 0; i < 6; i++ {
+		t = t.frobenius()
+	}
+	f = t.mul(f.inv())
+	f = f.frobenius().frobenius().mul(f)
+	return f.exp(hardExp)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestPairingBilinear(t *testing.T) {
+	G := elgamal.Generator(G1())
+	H := G2Generator()
+
+	e, err := Pair(G, H)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.IsOne() {
+		t.Fatal("pairing is degenerate")
+	}
+	if !e.f.exp(order).isOne() {
+		t.Fatal("e(G,H) does not have order r")
+	}
+
+	a, _ := rand.Int(rand.Reader, order)
+	b, _ := rand.Int(rand.Reader, order)
+	aG := elgamal.Generator(G1())
+	aG.X, aG.Y = G1().ScalarBaseMult(a.Bytes())
+	eab, err := Pair(aG, H.ScalarMult(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eab.Equal(e.Exp(new(big.Int).Mul(a, b))) {
+		t.Fatal("e(aG,bH) != e(G,H)^ab")
+	}
+}
+
+func TestPairingCheck(t *testing.T) {
+	G := elgamal.Generator(G1())
+	H := G2Generator()
+	k := big.NewInt(12345)
+	kG := elgamal.BaseMultiple(G1(), 12345)
+
+	// e(kG,H) * e(G,-kH) = 1
+	ok, err := PairingCheck([]*elgamal.CurvePoint{kG, G}, []*G2Point{H, H.ScalarMult(k).Neg()})
+	if err != nil || !ok {
+		t.Fatalf("pairing check failed: %v", err)
+	}
+	ok, err = PairingCheck([]*elgamal.CurvePoint{kG, G}, []*G2Point{H, H.Neg()})
+	if err != nil || ok {
+		t.Fatal("unbalanced pairing check passed")
+	}
+
+	// 单位元的配对为1
+	e, err := Pair(elgamal.Infinity(G1()), H)
+	if err != nil || !e.IsOne() {
+		t.Fatal("e(O,H) is not 1")
+	}
+	if _, err := PairingCheck([]*elgamal.CurvePoint{G}, nil); err == nil {
+		t.Fatal("expected error for inputs of different lengths")
+	}
+}
+
+func TestG2Encoding(t *testing.T) {
+	H := G2Generator()
+	if !H.ScalarMult(new(big.Int).Sub(order, big.NewInt(1))).Add(H).IsInfinity() {
+		t.Fatal("generator of G2 does not have order r")
+	}
+	p2 := new(big.Int).Mul(fieldP, fieldP)
+	want := new(big.Int).Sub(new(big.Int).Mul(p2, p2), p2)
+	if new(big.Int).Mul(hardExp, order).Cmp(want.Add(want, big.NewInt(1))) != 0 {
+		t.Fatal("r does not divide p^4 - p^2 + 1")
+	}
+
+	Q := H.ScalarMult(big.NewInt(7))
+	R, err := NewG2PointFromBytes(Q.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !R.Equal(Q) || R.Equal(H) {
+		t.Fatal("G2 point does not round-trip")
+	}
+
+	b := Q.Bytes()
+	b[10] ^= 1
+	if _, err := NewG2PointFromBytes(b); err == nil {
+		t.Fatal("decoded a point off the twist")
+	}
+	if _, err := NewG2PointFromBytes(Q.Bytes()[:64]); err == nil {
+		t.Fatal("decoded a truncated point")
+	}
+}
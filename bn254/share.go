@@ -0,0 +1,128 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ShareWitness returns the witness ri*H of a share computed by keyswitch.ShareCal
+// with the nonce ri, H the generator of G2. Published next to the share, it
+// replaces the Sigma proof: VerifyShare checks the share with pairings, and the
+// witnesses of a committee add up to one witness of the summed share.
+// 份额见证：返回keyswitch.ShareCal以随机数ri计算的份额的见证ri*H，H为G2的生成元。
+// 见证与份额一同公布，代替Sigma证明：VerifyShare以配对验证份额，委员会各节点的见证可相加，
+// 得到份额之和的单个见证。
+//
+// 参数：
+//		随机数	ri
+// 返回：
+// 		见证
+func ShareWitness(ri *big.Int) *G2Point {
+	return G2ScalarBaseMult(ri)
+}
+
+// VerifyShare checks the share (K,C) = (ri*G, -k*rB + ri*U) against its witness
+// W = ri*H and the node's G2 key k*H: e(K,H) = e(G,W) and e(C,H)*e(rB,kH) = e(U,W).
+// The G2 key must have been bound to the node's G1 key by CheckKeyPair.
+// 份额验证：依据见证W = ri*H及节点的G2公钥k*H验证份额(K,C) = (ri*G, -k*rB + ri*U)，
+// 即e(K,H) = e(G,W)且e(C,H)*e(rB,kH) = e(U,W)。节点的G2公钥须已经CheckKeyPair与其G1公钥绑定。
+//
+// 参数：
+//		份额			share
+//		见证			witness
+//		节点G2公钥		nodePub2
+//		目标公钥		targetPubKey
+//		原密文左侧点	rB
+// 返回：
+// 		是否有效
+func VerifyShare(share *elgamal.CipherText, witness, nodePub2 *G2Point, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (bool, error) {
+	if err := elgamal.CheckCipherText(share); err != nil {
+		return false, opError("VerifyShare", err)
+	}
+	return verifyShare("VerifyShare", share, witness, nodePub2, targetPubKey, rB)
+}
+
+// VerifyAggregateShares checks the sum of the committee's shares against the sum
+// of their witnesses and the committee's G2 key, with five pairings whatever the
+// size of the committee. It vouches for what keyswitch.ShareReplace uses, the sum,
+// but not for each share.
+// 聚合份额验证：依据各见证之和及委员会的G2公钥验证委员会各份额之和，无论委员会规模均只需五次配对。
+// 验证的是keyswitch.ShareReplace所用的份额之和，而非每个份额。
+//
+// 参数：
+//		份额向量		shares
+//		聚合见证		witness
+//		委员会G2公钥	committeePub2
+//		目标公钥		targetPubKey
+//		原密文左侧点	rB
+// 返回：
+// 		是否有效
+func VerifyAggregateShares(shares elgamal.CipherVector, witness, committeePub2 *G2Point, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (bool, error) {
+	if len(shares) == 0 {
+		return false, opError("VerifyAggregateShares", elgamal.ErrEmpty)
+	}
+	sum := elgamal.CipherText{K: *elgamal.Infinity(g1), C: *elgamal.Infinity(g1)}
+	for i := range shares {
+		if err := elgamal.CheckCipherText(&shares[i]); err != nil {
+			return false, itemError("VerifyAggregateShares", "share", i, err)
+		}
+		if shares[i].Curve() != g1 {
+			return false, itemError("VerifyAggregateShares", "share", i, elgamal.ErrCurveMismatch)
+		}
+		sum.K.X, sum.K.Y = g1.Add(sum.K.X, sum.K.Y, shares[i].K.X, shares[i].K.Y)
+		sum.C.X, sum.C.Y = g1.Add(sum.C.X, sum.C.Y, shares[i].C.X, shares[i].C.Y)
+	}
+	return verifyShare("VerifyAggregateShares", &sum, witness, committeePub2, targetPubKey, rB)
+}
+
+// verifyShare implements the pairing checks of VerifyShare on a share whose
+// points may be the identity, reporting errors under op.
+// VerifyShare的配对检验实现，份额的点可为单位元，以op报告错误。
+func verifyShare(op string, share *elgamal.CipherText, witness, pub2 *G2Point, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (bool, error) {
+	if witness == nil || pub2 == nil {
+		return false, opError(op, elgamal.ErrPointNotOnCurve)
+	}
+	if err := checkG1((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return false, opError(op, err)
+	}
+	if err := checkG1(rB); err != nil {
+		return false, opError(op, err)
+	}
+	// e(K,-H) * e(G,W) = 1
+	ok, err := PairingCheck(
+		[]*elgamal.CurvePoint{&share.K, elgamal.Generator(g1)},
+		[]*G2Point{g2Gen.Neg(), witness})
+	if err != nil {
+		return false, opError(op, err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	// e(C,H) * e(rB,kH) * e(U,-W) = 1
+	ok, err = PairingCheck(
+		[]*elgamal.CurvePoint{&share.C, rB, (*elgamal.CurvePoint)(targetPubKey)},
+		[]*G2Point{g2Gen, pub2, witness.Neg()})
+	if err != nil {
+		return false, opError(op, err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bn254
+
+import (
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareWitness(t *testing.T) {
+	privs := make([]*sm2.PrivateKey, 3)
+	pubs := make([]*sm2.PublicKey, len(privs))
+	pub2s := make([]*G2Point, len(privs))
+	for i := range privs {
+		priv, err := GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i], pubs[i] = priv, &priv.PublicKey
+		if pub2s[i], err = G2PublicKey(priv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committee, err := keyswitch.AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committee2, err := AggregateG2(pub2s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	D := elgamal.BaseMultiple(G1(), 424242)
+	ct, err := elgamal.PointEncrypt(committee, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := make(elgamal.CipherVector, len(privs))
+	witnesses := make([]*G2Point, len(privs))
+	for i, priv := range privs {
+		share, ri, err := keyswitch.ShareCal(&target.PublicKey, &ct.K, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i], witnesses[i] = *share, ShareWitness(ri)
+		ok, err := VerifyShare(share, witnesses[i], pub2s[i], &target.PublicKey, &ct.K)
+		if err != nil || !ok {
+			t.Fatalf("share %d failed to verify: %v", i, err)
+		}
+	}
+	if ok, _ := VerifyShare(&shares[0], witnesses[1], pub2s[0], &target.PublicKey, &ct.K); ok {
+		t.Fatal("share verified with another node's witness")
+	}
+
+	W, err := AggregateG2(witnesses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := VerifyAggregateShares(shares, W, committee2, &target.PublicKey, &ct.K)
+	if err != nil || !ok {
+		t.Fatalf("aggregate shares failed to verify: %v", err)
+	}
+
+	// 去掉一个份额后聚合验证失败
+	if ok, _ := VerifyAggregateShares(shares[:2], W, committee2, &target.PublicKey, &ct.K); ok {
+		t.Fatal("incomplete shares verified")
+	}
+
+	// 置换后的密文由目标私钥解密为原明文
+	switched, err := keyswitch.ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := elgamal.PointDecrypt(switched, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.X.Cmp(D.X) != 0 || got.Y.Cmp(D.Y) != 0 {
+		t.Fatal("switched ciphertext decrypts to another point")
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/keyswitch"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ShareBundle is what a KS server sends back for one request: its share, the proof
+// of the share and the public statement (node key, target key, rB) the proof is about.
+// 份额包：ks server针对一次请求返回的内容，包括份额、份额计算证明，以及证明所针对的
+// 公开信息（节点公钥、目标公钥、密文左侧点rB）。
+type ShareBundle = keyswitch.ShareBundle
+
+// GenShareBundle calculates the share related with rB for targetPubKey with priv,
+// proves it and returns both as a bundle.
+// It is a wrapper of keyswitch.GenShareBundle.
+// 生成份额包：使用私钥priv为目标公钥targetPubKey计算关于点rB的份额，生成计算证明，
+// 打包后返回。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额包
+func GenShareBundle(targetPubKey *sm2.PublicKey, rB *CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	return keyswitch.GenShareBundle(targetPubKey, rB, priv)
+}
+
+// Policy holds the attributes, such as purpose and document class, bound into the
+// challenge of every share proof of a request.
+// 策略：协调者为一次请求指定的属性，如用途、文档类别与有效期，被计入该请求下每个份额证明的挑战值。
+type Policy = keyswitch.Policy
+
+// GenShareBundleWithPolicy is GenShareBundle binding policy into the proof, which
+// then verifies only under the same policy.
+// It is a wrapper of keyswitch.GenShareBundleWithPolicy.
+// 生成策略绑定的份额包：同GenShareBundle，但将策略policy计入证明的挑战值，证明仅在相同策略下通过验证。
+//
+// 参数：
+//		策略		policy
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额包
+func GenShareBundleWithPolicy(policy *Policy, targetPubKey *sm2.PublicKey, rB *CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	return keyswitch.GenShareBundleWithPolicy(policy, targetPubKey, rB, priv)
+}
+
+// BatchVerifyShares verifies the share proofs of bundles together, falling back to
+// one-by-one verification to locate failures, and returns the failed indices.
+// It is a wrapper of keyswitch.BatchVerifyShares.
+// 份额包批量验证：合并验证bundles中的证明，未通过时逐个验证以定位，返回验证失败的下标。
+//
+// 参数：
+//		份额包slice：	bundles
+// 返回：
+// 		验证失败的下标，全部通过时为空
+func BatchVerifyShares(bundles []*ShareBundle) ([]int, error) {
+	return keyswitch.BatchVerifyShares(bundles)
+}
+
+// SessionTranscript is the public record of one key switch, which any third party
+// can verify to confirm the requester legitimately obtained access.
+// 会话记录：一次密钥置换的公开记录，任何第三方均可验证以确认请求者合法获得了访问权限。
+type SessionTranscript = keyswitch.SessionTranscript
+
+// NewSessionTranscript starts the transcript of switching ct, encrypted under the
+// sum of nodePubKeys, to targetPubKey, optionally under policy.
+// It is a wrapper of keyswitch.NewSessionTranscript.
+// 创建会话记录：记录将委员会公钥nodePubKeys之和下的密文ct置换到目标公钥targetPubKey的过程，策略policy可为空。
+//
+// 参数：
+//		密文			ct
+//		目标公钥		targetPubKey
+//		节点公钥slice	nodePubKeys
+//		策略			policy
+// 返回：
+// 		会话记录
+func NewSessionTranscript(ct *CipherText, targetPubKey *sm2.PublicKey, nodePubKeys []*sm2.PublicKey, policy *Policy) (*SessionTranscript, error) {
+	return keyswitch.NewSessionTranscript(ct, targetPubKey, nodePubKeys, policy)
+}
+
+// ShareCalMulti calculates and proves the shares related with rB for each of
+// targets with priv, computing -k*rB only once.
+// It is a wrapper of keyswitch.ShareCalMulti.
+// 多目标份额计算：使用私钥priv，为targets中的每个目标公钥计算关于点rB的份额并生成证明，
+// -k*rB只计算一次。
+//
+// 参数：
+//		目标公钥slice	targets
+//		密文左侧点		rB
+//		私钥			priv
+// 返回：
+// 		份额包slice，与targets顺序一致
+func ShareCalMulti(targets []*sm2.PublicKey, rB *CurvePoint, priv *sm2.PrivateKey) ([]*ShareBundle, error) {
+	return keyswitch.ShareCalMulti(targets, rB, priv)
+}
+
+// BatchShareProof proves a batch of shares of one node for one target key at once,
+// sharing the commitment for the node key.
+// 批量份额证明：一次证明同一节点为同一目标公钥计算的一批份额，节点私钥的承诺由整批共用。
+type BatchShareProof = keyswitch.BatchShareProof
+
+// ShareCalBatch calculates the shares related with each of rBs for targetPubKey
+// with priv and proves them all with one BatchShareProof.
+// It is a wrapper of keyswitch.ShareCalBatch.
+// 批量份额计算：使用私钥priv为目标公钥targetPubKey计算关于rBs中各点的份额，并以一个BatchShareProof证明全部份额。
+//
+// 参数：
+//		目标公钥		targetPubKey
+//		密文左侧点slice	rBs
+//		私钥			priv
+// 返回：
+// 		份额slice
+//		批量证明
+func ShareCalBatch(targetPubKey *sm2.PublicKey, rBs []*CurvePoint, priv *sm2.PrivateKey) (CipherVector, *BatchShareProof, error) {
+	return keyswitch.ShareCalBatch(targetPubKey, rBs, priv)
+}
+
+// VerifyShareBatch verifies the proof p of ShareCalBatch.
+// It is a wrapper of keyswitch.VerifyShareBatch.
+// 批量份额证明验证：验证ShareCalBatch生成的证明p。
+//
+// 参数：
+//		份额slice		shares
+//		节点公钥		nodePubKey
+//		目标公钥		targetPubKey
+//		密文左侧点slice	rBs
+//		批量证明		p
+// 返回：
+// 		验证结果
+func VerifyShareBatch(shares CipherVector, nodePubKey, targetPubKey *sm2.PublicKey, rBs []*CurvePoint, p *BatchShareProof) (bool, error) {
+	return keyswitch.VerifyShareBatch(shares, nodePubKey, targetPubKey, rBs, p)
+}
+
+// SealShare signs b with the node key priv and encrypts it to b.TargetPubKey with
+// SM2 public-key encryption, for delivery over untrusted relays.
+// It is a wrapper of keyswitch.SealShare.
+// 份额包加密：以节点私钥priv对b签名，并以SM2公钥加密将其加密给b.TargetPubKey，用于经不可信中继传递。
+//
+// 参数：
+//		份额包		b
+//		节点私钥	priv
+// 返回：
+// 		加密份额包
+func SealShare(b *ShareBundle, priv *sm2.PrivateKey) ([]byte, error) {
+	return keyswitch.SealShare(b, priv)
+}
+
+// OpenShare decrypts a bundle sealed by SealShare with the requester's key priv and
+// checks its signature and proof.
+// It is a wrapper of keyswitch.OpenShare.
+// 份额包解密：以请求者私钥priv解密SealShare加密的份额包，并检查其签名与证明。
+//
+// 参数：
+//		请求者私钥	priv
+//		加密份额包	sealed
+// 返回：
+// 		份额包
+func OpenShare(priv *sm2.PrivateKey, sealed []byte) (*ShareBundle, error) {
+	return keyswitch.OpenShare(priv, sealed)
+}
+
+// EquivocationProof is a compact fraud proof against a server: a share bundle it
+// signed whose proof fails.
+// 违规证明：针对ks server的紧凑欺诈证明，即一个由其签名但证明未通过的份额包。
+type EquivocationProof = keyswitch.EquivocationProof
+
+// DetectEquivocation compares two bundles signed by the same server for the same
+// rB and target, returning the fraud proof if either fails its proof and nil if
+// both are consistent.
+// It is a wrapper of keyswitch.DetectEquivocation.
+// 违规检测：比较同一ks server对同一rB与目标公钥签名的两个份额包，其一证明未通过时返回欺诈证明，
+// 二者一致时返回nil。
+//
+// 参数：
+//		份额包及签名	b1,sig1
+//		份额包及签名	b2,sig2
+// 返回：
+// 		违规证明
+func DetectEquivocation(b1 *ShareBundle, sig1 *schnorr.Signature, b2 *ShareBundle, sig2 *schnorr.Signature) (*EquivocationProof, error) {
+	return keyswitch.DetectEquivocation(b1, sig1, b2, sig2)
+}
+
+// AnonymousShare is the audit record of a share attributed to "a member of the
+// committee" by a linkable ring signature instead of the node key.
+// 匿名份额：以可链接环签名代替节点公钥，将份额归于"委员会的某一成员"的审计记录。
+type AnonymousShare = keyswitch.AnonymousShare
+
+// CountAnonymous verifies the anonymous records of one request and returns how
+// many distinct members of committee responded, counting linked records once.
+// It is a wrapper of keyswitch.CountAnonymous.
+// 匿名份额计数：验证同一请求的各匿名记录，返回作出响应的委员会committee成员数，相互关联的记录仅计一次。
+//
+// 参数：
+//		委员会公钥		committee
+//		匿名份额slice	records
+// 返回：
+// 		响应成员数
+func CountAnonymous(committee []*sm2.PublicKey, records []*AnonymousShare) (int, error) {
+	return keyswitch.CountAnonymous(committee, records)
+}
+
+// CoSignedSwitch is the final switched ciphertext of a request, co-signed by the
+// contributing servers with one signature, for downstream systems that need
+// accountability rather than verifiability.
+// 联合签名的置换结果：由参与置换的ks server以一个签名联合签名的最终置换密文，
+// 供只需可追责而非可验证的下游系统使用。
+type CoSignedSwitch = keyswitch.CoSignedSwitch
@@ -0,0 +1,163 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/kdf"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Compact mode lets constrained publishers, e.g. on LPWAN links, upload an
+// encapsulated key in CompactCipherTextSize bytes: the compressed point K and a
+// short tag. The publisher shares a device key with the coordinator, from which C
+// is derived as a hash of K, so the coordinator expands the upload into a full
+// ciphertext (K, C) for key switching. The encapsulated point is then C - kP,
+// which the coordinator cannot compute, and the tag authenticates the upload and
+// its associated data under the device key.
+// 紧凑模式使受限的发布方（如经LPWAN链路）以CompactCipherTextSize字节上传封装的密钥，即压缩点K与
+// 短标签。发布方与协调方共享设备密钥，C由其与K的哈希派生，协调方因此可将上传内容扩展为完整密文(K, C)
+// 以进行密钥置换。被封装的点为C - kP，协调方无法计算；标签以设备密钥认证上传内容及其关联数据。
+
+// compactInfo is the KDF context of the keys derived from a device key.
+// 由设备密钥派生各密钥的KDF上下文。
+var compactInfo = []byte("ppks-compact-v1")
+
+// Sizes of compact ciphertexts.
+// 紧凑密文的各部分长度。
+const (
+	// CompactTagSize 紧凑密文认证标签的字节长度。
+	CompactTagSize = 16
+	// CompactCipherTextSize 紧凑密文的字节长度：压缩点K及认证标签。
+	CompactCipherTextSize = 33 + CompactTagSize
+)
+
+// EncapsulateCompact encapsulates a shared secret of KEMSecretSize bytes for pub
+// like Encapsulate and returns it with the compact ciphertext, bound to the
+// associated data ad under deviceKey. After ExpandCompact and key switching, the
+// requester recovers the secret with Decapsulate.
+// 紧凑密钥封装：与Encapsulate相同地为公钥pub封装KEMSecretSize字节的共享密钥，返回该密钥及以设备密钥
+// deviceKey与关联数据ad绑定的紧凑密文。经ExpandCompact扩展与密钥置换后，请求者以Decapsulate恢复共享密钥。
+//
+// 参数：
+//		公钥		pub
+//		设备密钥	deviceKey
+//		关联数据	ad
+// 返回：
+// 		共享密钥
+//		紧凑密文
+func EncapsulateCompact(pub *sm2.PublicKey, deviceKey, ad []byte) (*SecretBytes, []byte, error) {
+	if err := elgamal.CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, nil, opError("EncapsulateCompact", err)
+	}
+	curve := pub.Curve
+	k, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, opError("EncapsulateCompact", err)
+	}
+	K := elgamal.Generator(curve)
+	K.X, K.Y = curve.ScalarBaseMult(k.Bytes())
+
+	C, tag, err := compactDerive(pub, deviceKey, ad, K)
+	if err != nil {
+		return nil, nil, opError("EncapsulateCompact", err)
+	}
+
+	// D = C - kP
+	kPx, kPy := curve.ScalarMult(pub.X, pub.Y, k.Bytes())
+	ec.WipeInt(k)
+	kPx, kPy = ec.Neg(curve, kPx, kPy)
+	D := elgamal.Generator(curve)
+	D.X, D.Y = ec.Add(curve, C.X, C.Y, kPx, kPy)
+	secret, err := kemSecret(D)
+	if err != nil {
+		return nil, nil, opError("EncapsulateCompact", err)
+	}
+	return secret, append(K.CompressedBytes(), tag...), nil
+}
+
+// ExpandCompact checks the tag of the compact ciphertext compact for pub and the
+// associated data ad under deviceKey and expands it into the full ciphertext,
+// failing with ErrAuthFailed if any of them does not match.
+// 紧凑密文扩展：以设备密钥deviceKey检查紧凑密文compact对公钥pub及关联数据ad的认证标签，
+// 并将其扩展为完整密文，任一不符时返回ErrAuthFailed。
+//
+// 参数：
+//		公钥		pub
+//		设备密钥	deviceKey
+//		关联数据	ad
+//		紧凑密文	compact
+// 返回：
+// 		密文
+func ExpandCompact(pub *sm2.PublicKey, deviceKey, ad, compact []byte) (*CipherText, error) {
+	if err := elgamal.CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, opError("ExpandCompact", err)
+	}
+	if len(compact) != CompactCipherTextSize {
+		return nil, opError("ExpandCompact", ErrAuthFailed)
+	}
+	K, err := elgamal.NewCurvePointFromBytes(pub.Curve, compact[:33])
+	if err != nil {
+		return nil, opError("ExpandCompact", ErrAuthFailed)
+	}
+	C, tag, err := compactDerive(pub, deviceKey, ad, K)
+	if err != nil {
+		return nil, opError("ExpandCompact", err)
+	}
+	if !hmac.Equal(tag, compact[33:]) {
+		return nil, opError("ExpandCompact", ErrAuthFailed)
+	}
+	return &CipherText{K: *K, C: *C}, nil
+}
+
+// compactDerive returns the point C of K and its tag for ad under deviceKey.
+// 返回设备密钥deviceKey下K对应的点C，以及其对关联数据ad的认证标签。
+func compactDerive(pub *sm2.PublicKey, deviceKey, ad []byte, K *CurvePoint) (*CurvePoint, []byte, error) {
+	if len(deviceKey) == 0 {
+		return nil, nil, elgamal.ErrEmpty
+	}
+	keys, err := kdf.Key(compactInfo, deviceKey, []byte("point-and-mac"), 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer wipeBytes(keys)
+
+	P := (*CurvePoint)(pub).CompressedBytes()
+	k := K.CompressedBytes()
+	msg := append(append(append([]byte(nil), keys[:32]...), P...), k...)
+	C, err := elgamal.HashToPoint(compactInfo, msg)
+	wipeBytes(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var n [4]byte
+	mac := hmac.New(sm3.New, keys[32:])
+	mac.Write(compactInfo)
+	binary.BigEndian.PutUint32(n[:], uint32(len(ad)))
+	mac.Write(n[:])
+	mac.Write(ad)
+	mac.Write(P)
+	mac.Write(k)
+	return C, mac.Sum(nil)[:CompactTagSize], nil
+}
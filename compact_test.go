@@ -0,0 +1,85 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCompactCipherText(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceKey := []byte("0123456789abcdef")
+	ad := []byte("sensor-17/seq-42")
+
+	secret, compact, err := EncapsulateCompact(&priv.PublicKey, deviceKey, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compact) != CompactCipherTextSize {
+		t.Fatalf("compact ciphertext is %d bytes, want %d", len(compact), CompactCipherTextSize)
+	}
+
+	// 协调方扩展为完整密文后置换给请求者
+	ct, err := ExpandCompact(&priv.PublicKey, deviceKey, ad, compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, _, err := ShareCal(&q.PublicKey, &ct.K, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switched, err := ShareReplace(&CipherVector{*share}, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decapsulate(q, switched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), secret.Bytes()) {
+		t.Fatal("decapsulated secret differs")
+	}
+
+	tampered := append([]byte(nil), compact...)
+	tampered[len(tampered)-1] ^= 1
+	for _, tc := range []struct {
+		name      string
+		deviceKey []byte
+		ad        []byte
+		compact   []byte
+	}{
+		{"tag", deviceKey, ad, tampered},
+		{"ad", deviceKey, []byte("sensor-17/seq-43"), compact},
+		{"device key", []byte("fedcba9876543210"), ad, compact},
+		{"length", deviceKey, ad, compact[:len(compact)-1]},
+	} {
+		if _, err := ExpandCompact(&priv.PublicKey, tc.deviceKey, tc.ad, tc.compact); !errors.Is(err, ErrAuthFailed) {
+			t.Errorf("%s: got %v, want ErrAuthFailed", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"math/big"
+
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// DecryptWithProof decrypts ct with priv like PointDecrypt and returns a DLEQ proof
+// (c,r) of the decryption, so auditors can check the plaintext point without the key.
+// It is a wrapper of keyswitch.DecryptWithProof.
+// 可验证解密：与PointDecrypt相同地使用私钥priv解密密文ct，并返回解密正确的离散对数相等证明(c,r)，
+// 审计者无需私钥即可检验明文点。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+// 返回：
+// 		明文点M
+//		证明：	c,r
+func DecryptWithProof(priv *sm2.PrivateKey, ct *CipherText) (*CurvePoint, *big.Int, *big.Int, error) {
+	return keyswitch.DecryptWithProof(priv, ct)
+}
+
+// VerifyDecryption verifies the proof (c,r) of DecryptWithProof that M is the
+// decryption of ct under the private key of pub.
+// It is a wrapper of keyswitch.VerifyDecryption.
+// 解密验证：验证DecryptWithProof生成的证明(c,r)，即M为公钥pub对应私钥对密文ct的解密结果。
+//
+// 参数：
+//		证明：	c,r
+//		明文点	M
+//		密文	ct
+//		公钥	pub
+// 返回：
+// 		验证结果
+func VerifyDecryption(c, r *big.Int, M *CurvePoint, ct *CipherText, pub *sm2.PublicKey) (bool, error) {
+	return keyswitch.VerifyDecryption(c, r, M, ct, pub)
+}
+
+// PartialDecryption is a server's proven part of a joint decryption.
+// 部分解密：ks server对密文联合解密的部分结果及其证明。
+type PartialDecryption = keyswitch.PartialDecryption
+
+// PartialDecrypt calculates and proves the partial decryption of ct with priv.
+// It is a wrapper of keyswitch.PartialDecrypt.
+// 部分解密：使用私钥priv计算密文ct的部分解密结果并生成证明。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+// 返回：
+// 		部分解密结果
+func PartialDecrypt(priv *sm2.PrivateKey, ct *CipherText) (*PartialDecryption, error) {
+	return keyswitch.PartialDecrypt(priv, ct)
+}
+
+// CombinePartials verifies the partial decryptions of ct and combines them, with
+// the coefficients coeffs if not nil, into the plaintext point.
+// It is a wrapper of keyswitch.CombinePartials.
+// 合并部分解密结果：验证密文ct的各部分解密结果，并（coeffs不为nil时以其为系数）合并为明文点。
+//
+// 参数：
+//		密文				ct
+//		部分解密结果slice	partials
+//		系数slice			coeffs
+// 返回：
+// 		明文点
+func CombinePartials(ct *CipherText, partials []*PartialDecryption, coeffs []*big.Int) (*CurvePoint, error) {
+	return keyswitch.CombinePartials(ct, partials, coeffs)
+}
+
+// PlaintextWitness is the encryption randomness of a ciphertext or the private key
+// of its recipient, exactly one of which must be set.
+// 明文证据：密文的加密随机数，或其接收者的私钥，二者须且仅须设置其一。
+type PlaintextWitness = keyswitch.PlaintextWitness
+
+// SamePlaintextProof proves that two ciphertexts encrypt the same point.
+// 明文相等证明：证明两个密文加密的是同一点。
+type SamePlaintextProof = keyswitch.SamePlaintextProof
+
+// ProveSamePlaintext proves that ct1 under pub1 and ct2 under pub2 encrypt the
+// same point, without revealing it.
+// It is a wrapper of keyswitch.ProveSamePlaintext.
+// 明文相等证明生成：在不泄露明文的情况下，证明公钥pub1下的密文ct1与公钥pub2下的密文ct2加密的是同一点。
+//
+// 参数：
+//		密文及公钥	ct1,pub1
+//		密文及公钥	ct2,pub2
+//		证据		w1,w2
+// 返回：
+// 		明文相等证明
+func ProveSamePlaintext(ct1 *CipherText, pub1 *sm2.PublicKey, ct2 *CipherText, pub2 *sm2.PublicKey, w1, w2 *PlaintextWitness) (*SamePlaintextProof, error) {
+	return keyswitch.ProveSamePlaintext(ct1, pub1, ct2, pub2, w1, w2)
+}
+
+// VerifySamePlaintext verifies the proof p that ct1 under pub1 and ct2 under pub2
+// encrypt the same point.
+// It is a wrapper of keyswitch.VerifySamePlaintext.
+// 明文相等证明验证：验证证明p，即公钥pub1下的密文ct1与公钥pub2下的密文ct2加密的是同一点。
+//
+// 参数：
+//		密文及公钥	ct1,pub1
+//		密文及公钥	ct2,pub2
+//		证明		p
+// 返回：
+// 		验证结果
+func VerifySamePlaintext(ct1 *CipherText, pub1 *sm2.PublicKey, ct2 *CipherText, pub2 *sm2.PublicKey, p *SamePlaintextProof) (bool, error) {
+	return keyswitch.VerifySamePlaintext(ct1, pub1, ct2, pub2, p)
+}
+
+// CheckPlaintext tests with the trapdoor priv whether ct encrypts the known point
+// M, without decrypting it.
+// It is a wrapper of keyswitch.CheckPlaintext.
+// 明文检验：以陷门私钥priv检验密文ct是否为已知点M的密文，而不解密该密文。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+//		已知点	M
+// 返回：
+// 		检验结果
+func CheckPlaintext(priv *sm2.PrivateKey, ct *CipherText, M *CurvePoint) (bool, error) {
+	return keyswitch.CheckPlaintext(priv, ct, M)
+}
+
+// PlaintextCheck is the proven answer of the key holder to whether a ciphertext
+// encrypts a known point.
+// 明文检验证明：私钥持有者对密文是否为已知点的密文给出的可验证回答。
+type PlaintextCheck = keyswitch.PlaintextCheck
+
+// ProvePlaintextCheck answers with priv whether ct encrypts the known point M and
+// proves the answer.
+// It is a wrapper of keyswitch.ProvePlaintextCheck.
+// 可验证明文检验：以私钥priv回答密文ct是否为已知点M的密文并证明该回答。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+//		已知点	M
+// 返回：
+// 		明文检验证明
+func ProvePlaintextCheck(priv *sm2.PrivateKey, ct *CipherText, M *CurvePoint) (*PlaintextCheck, error) {
+	return keyswitch.ProvePlaintextCheck(priv, ct, M)
+}
+
+// VerifyPlaintextCheck verifies the answer p of ProvePlaintextCheck for ct under
+// pub and the known point M; if it is valid, p.Match tells whether ct encrypts M.
+// It is a wrapper of keyswitch.VerifyPlaintextCheck.
+// 明文检验证明验证：验证ProvePlaintextCheck对公钥pub下的密文ct与已知点M给出的回答p，
+// 验证通过时p.Match即ct是否为M的密文。
+//
+// 参数：
+//		明文检验证明	p
+//		已知点			M
+//		密文			ct
+//		公钥			pub
+// 返回：
+// 		验证结果
+func VerifyPlaintextCheck(p *PlaintextCheck, M *CurvePoint, ct *CipherText, pub *sm2.PublicKey) (bool, error) {
+	return keyswitch.VerifyPlaintextCheck(p, M, ct, pub)
+}
@@ -0,0 +1,464 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dkg implements the Pedersen distributed key generation, so a committee of
+// n KS servers establishes the collective public key and a t-of-n Shamir share of
+// the collective private key each, without any party ever knowing the full private
+// key.
+//
+// Every participant deals a random polynomial: it broadcasts a Commitment to the
+// coefficients and sends each other participant a Deal privately. A participant
+// that got no deal, or a deal not matching the commitment, broadcasts a Complaint,
+// which the dealer answers by revealing the share in a Justification. Dealers
+// with unanswered or wrongly answered complaints are disqualified, and Finalize
+// sums the deals of the qualified ones.
+//
+// 分布式密钥生成：实现Pedersen分布式密钥生成协议，n个ks server共同生成聚合公钥，各自得到聚合私钥的
+// t-of-n Shamir份额，任何一方都不知道完整的聚合私钥。
+//
+// 每个参与者分发一个随机多项式：广播对系数的承诺Commitment，并私下向其他每个参与者发送Deal。
+// 未收到Deal或Deal与承诺不符的参与者广播投诉Complaint，被投诉者以Justification公开该份额作答。
+// 有未答复或答复错误投诉的参与者被取消资格，Finalize加和合格参与者的份额。
+package dkg
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"math/big"
+	"sort"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Phase is the protocol phase a participant is in. Phases only move forward.
+// 阶段：参与者所处的协议阶段，只能前进。
+type Phase int
+
+const (
+	// PhaseCommit 收集各参与者的承诺。
+	PhaseCommit Phase = iota
+	// PhaseDeal 接收各参与者私下发送的份额。
+	PhaseDeal
+	// PhaseComplaint 广播并处理投诉。
+	PhaseComplaint
+	// PhaseJustification 处理被投诉者公开的份额。
+	PhaseJustification
+	// PhaseFinished 协议结束。
+	PhaseFinished
+)
+
+var phaseNames = [...]string{"commit", "deal", "complaint", "justification", "finished"}
+
+// String returns the name of the phase.
+// 返回阶段名称。
+func (ph Phase) String() string {
+	if ph < 0 || int(ph) >= len(phaseNames) {
+		return "unknown"
+	}
+	return phaseNames[ph]
+}
+
+// Commitment is broadcast by participant From: the Feldman commitment to its
+// polynomial.
+// 承诺：参与者From广播的对其多项式的Feldman承诺。
+type Commitment struct {
+	From   int
+	Points keyswitch.VSSCommitment
+}
+
+// Deal is sent privately from participant From to participant To: the value of
+// the polynomial of From at To.
+// 份额：参与者From私下发给参与者To的份额，即From的多项式在To处的值。
+type Deal struct {
+	From, To int
+	Share    *big.Int
+}
+
+// Complaint is broadcast by participant From against dealer Against, whose deal
+// was missing or did not match its commitment.
+// 投诉：参与者From广播的针对Against的投诉，Against的份额缺失或与其承诺不符。
+type Complaint struct {
+	From, Against int
+}
+
+// Justification is broadcast by dealer From answering the complaint of To: the
+// deal for To, in the clear.
+// 答复：被投诉者From对To的投诉的答复，即公开发给To的份额。
+type Justification struct {
+	From, To int
+	Share    *big.Int
+}
+
+// Result is the outcome of the protocol for one participant.
+// 结果：协议对一个参与者的输出。
+type Result struct {
+	// Key is the participant's Shamir share of the collective private key.
+	// 参与者持有的聚合私钥的Shamir份额。
+	Key keyswitch.ThresholdKey
+	// PublicKey is the collective public key.
+	// 聚合公钥。
+	PublicKey *sm2.PublicKey
+	// PublicShares[j-1] is the public key of the share of participant j, to verify
+	// its share proofs with keyswitch.WeightedPubKey.
+	// PublicShares[j-1]为参与者j的份额公钥，用于以keyswitch.WeightedPubKey验证其份额证明。
+	PublicShares []*sm2.PublicKey
+	// Qualified lists the participants whose deals make up the key, in order.
+	// 组成密钥的合格参与者编号，升序。
+	Qualified []int
+}
+
+// Participant runs the protocol for participant index of 1..n with threshold t.
+// It is not safe for concurrent use.
+// 参与者：以门限t为编号为index（1..n）的参与者运行协议。不可并发使用。
+type Participant struct {
+	index, t, n int
+	curve       elliptic.Curve
+	phase       Phase
+	poly        []*big.Int
+
+	commits      map[int]*Commitment
+	shares       map[int]*big.Int
+	complaints   map[int]map[int]bool
+	disqualified map[int]bool
+}
+
+// NewParticipant starts the protocol for participant index of 1..n with threshold
+// t, drawing its polynomial from random. A nil random uses crypto/rand.
+// 创建参与者：以门限t为编号为index（1..n）的参与者启动协议，由random生成其多项式。
+// random为nil时使用crypto/rand。
+//
+// 参数：
+//		编号	index
+//		门限	t
+//		人数	n
+//		随机源	random
+// 返回：
+// 		参与者
+func NewParticipant(index, t, n int, random io.Reader) (*Participant, error) {
+	if t < 1 || n < t || index < 1 || index > n {
+		return nil, opError("NewParticipant", keyswitch.ErrInvalidThreshold)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+
+	curve := sm2.P256Sm2()
+	poly := make([]*big.Int, t)
+	points := make(keyswitch.VSSCommitment, t)
+	for k := range poly {
+		a, err := ec.RandFieldElement(curve, random)
+		if err != nil {
+			return nil, opError("NewParticipant", err)
+		}
+		poly[k] = a
+		points[k] = new(elgamal.CurvePoint)
+		points[k].Curve = curve
+		points[k].X, points[k].Y = curve.ScalarBaseMult(a.Bytes())
+	}
+
+	p := &Participant{
+		index:        index,
+		t:            t,
+		n:            n,
+		curve:        curve,
+		poly:         poly,
+		commits:      make(map[int]*Commitment),
+		shares:       make(map[int]*big.Int),
+		complaints:   make(map[int]map[int]bool),
+		disqualified: make(map[int]bool),
+	}
+	p.commits[index] = &Commitment{From: index, Points: points}
+	p.shares[index] = p.eval(index)
+
+	return p, nil
+}
+
+// Index returns the index of the participant.
+// 返回参与者编号。
+func (p *Participant) Index() int {
+	return p.index
+}
+
+// Phase returns the phase the participant is in.
+// 返回参与者所处的阶段。
+func (p *Participant) Phase() Phase {
+	return p.phase
+}
+
+// Commitment returns the participant's commitment, to broadcast.
+// 返回参与者的承诺，用于广播。
+func (p *Participant) Commitment() *Commitment {
+	return p.commits[p.index]
+}
+
+// Deals returns the participant's deals for every other participant, each to be
+// sent privately to its recipient.
+// 返回参与者给其他每个参与者的份额，每份须私下发给其接收者。
+func (p *Participant) Deals() []*Deal {
+	deals := make([]*Deal, 0, p.n-1)
+	for j := 1; j <= p.n; j++ {
+		if j != p.index {
+			deals = append(deals, &Deal{From: p.index, To: j, Share: p.eval(j)})
+		}
+	}
+	return deals
+}
+
+// ProcessCommitment records the commitment of another participant.
+// 处理承诺：记录其他参与者的承诺。
+//
+// 参数：
+//		承诺	c
+func (p *Participant) ProcessCommitment(c *Commitment) error {
+	if p.phase != PhaseCommit {
+		return opError("ProcessCommitment", ErrWrongPhase)
+	}
+	if c == nil || !p.isPeer(c.From) || p.commits[c.From] != nil || len(c.Points) != p.t {
+		return opError("ProcessCommitment", ErrInvalidMessage)
+	}
+	for k, point := range c.Points {
+		if err := elgamal.CheckPoint(point); err != nil {
+			return itemError("ProcessCommitment", "point", k, err)
+		}
+	}
+	p.commits[c.From] = c
+	return nil
+}
+
+// ProcessDeal checks a deal sent to the participant against the commitment of its
+// dealer and keeps it if it matches. A deal that does not match is dropped, and
+// Complaints then complains about the dealer. The first deal closes the commit phase.
+// 处理份额：以份额发送者的承诺校验发给本参与者的份额，相符则保留。不符的份额被丢弃，
+// 此后Complaints将投诉其发送者。收到第一个份额即结束承诺阶段。
+//
+// 参数：
+//		份额	d
+func (p *Participant) ProcessDeal(d *Deal) error {
+	if p.phase != PhaseCommit && p.phase != PhaseDeal {
+		return opError("ProcessDeal", ErrWrongPhase)
+	}
+	if d == nil || d.To != p.index || !p.isPeer(d.From) || d.Share == nil {
+		return opError("ProcessDeal", ErrInvalidMessage)
+	}
+	c := p.commits[d.From]
+	if c == nil {
+		return opError("ProcessDeal", ErrInvalidMessage)
+	}
+	p.phase = PhaseDeal
+
+	if p.verifyShare(c, p.index, d.Share) {
+		p.shares[d.From] = new(big.Int).Set(d.Share)
+	}
+	return nil
+}
+
+// Complaints closes the deal phase and returns a complaint against every committed
+// participant whose deal is missing or did not match, to broadcast.
+// 结束份额阶段，针对份额缺失或不符的每个已承诺参与者生成投诉并返回，用于广播。
+//
+// 返回：
+// 		投诉slice
+func (p *Participant) Complaints() ([]*Complaint, error) {
+	if p.phase != PhaseCommit && p.phase != PhaseDeal {
+		return nil, opError("Complaints", ErrWrongPhase)
+	}
+	p.phase = PhaseComplaint
+
+	var complaints []*Complaint
+	for _, i := range p.committed() {
+		if p.shares[i] == nil {
+			complaints = append(complaints, &Complaint{From: p.index, Against: i})
+		}
+	}
+	return complaints, nil
+}
+
+// ProcessComplaint records a complaint, including the participant's own. A
+// complaint against the participant is answered with the justification returned,
+// to broadcast; otherwise the justification is nil.
+// 处理投诉：记录投诉（包括本参与者自己的投诉）。针对本参与者的投诉以返回的答复作答，用于广播；
+// 否则返回的答复为nil。
+//
+// 参数：
+//		投诉	c
+// 返回：
+// 		答复
+func (p *Participant) ProcessComplaint(c *Complaint) (*Justification, error) {
+	if p.phase != PhaseComplaint {
+		return nil, opError("ProcessComplaint", ErrWrongPhase)
+	}
+	if c == nil || !p.inRange(c.From) || !p.inRange(c.Against) || c.From == c.Against {
+		return nil, opError("ProcessComplaint", ErrInvalidMessage)
+	}
+
+	if p.complaints[c.Against] == nil {
+		p.complaints[c.Against] = make(map[int]bool)
+	}
+	p.complaints[c.Against][c.From] = true
+
+	if c.Against != p.index {
+		return nil, nil
+	}
+	return &Justification{From: p.index, To: c.From, Share: p.eval(c.From)}, nil
+}
+
+// ProcessJustification checks a revealed deal against the commitment of its
+// dealer. A matching one settles the complaint, and becomes the participant's deal
+// if it is addressed to it; a wrong one disqualifies the dealer.
+// 处理答复：以被投诉者的承诺校验其公开的份额。相符则投诉了结，若该份额发给本参与者则予以采用；
+// 不符则取消被投诉者的资格。
+//
+// 参数：
+//		答复	j
+func (p *Participant) ProcessJustification(j *Justification) error {
+	if p.phase != PhaseComplaint && p.phase != PhaseJustification {
+		return opError("ProcessJustification", ErrWrongPhase)
+	}
+	if j == nil || !p.inRange(j.From) || !p.inRange(j.To) || j.Share == nil || !p.complaints[j.From][j.To] {
+		return opError("ProcessJustification", ErrInvalidMessage)
+	}
+	p.phase = PhaseJustification
+
+	c := p.commits[j.From]
+	if c == nil || !p.verifyShare(c, j.To, j.Share) {
+		p.disqualified[j.From] = true
+		return nil
+	}
+	delete(p.complaints[j.From], j.To)
+	if j.To == p.index {
+		p.shares[j.From] = new(big.Int).Set(j.Share)
+	}
+	return nil
+}
+
+// Finalize ends the protocol: participants with unsettled complaints are
+// disqualified and the deals of the qualified ones, at least t of them, are summed
+// into the participant's key.
+// 结束协议：取消仍有未了结投诉的参与者的资格，加和合格参与者（至少t个）的份额，得到本参与者的密钥。
+//
+// 返回：
+// 		结果
+func (p *Participant) Finalize() (*Result, error) {
+	if p.phase != PhaseComplaint && p.phase != PhaseJustification {
+		return nil, opError("Finalize", ErrWrongPhase)
+	}
+	p.phase = PhaseFinished
+
+	var qual []int
+	for _, i := range p.committed() {
+		if !p.disqualified[i] && len(p.complaints[i]) == 0 {
+			qual = append(qual, i)
+		}
+	}
+	if len(qual) < p.t {
+		return nil, opError("Finalize", ErrTooFewQualified)
+	}
+
+	curve := p.curve
+	N := curve.Params().N
+	d := new(big.Int)
+	pubX, pubY := new(big.Int), new(big.Int)
+	for _, i := range qual {
+		s := p.shares[i]
+		if s == nil {
+			return nil, itemError("Finalize", "deal", i, ErrInvalidMessage)
+		}
+		d.Add(d, s)
+		c0 := p.commits[i].Points[0]
+		pubX, pubY = ec.Add(curve, pubX, pubY, c0.X, c0.Y)
+	}
+	d.Mod(d, N)
+
+	priv := new(sm2.PrivateKey)
+	priv.Curve = curve
+	priv.D = d
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+
+	pub := new(sm2.PublicKey)
+	pub.Curve = curve
+	pub.X, pub.Y = pubX, pubY
+
+	shares := make([]*sm2.PublicKey, p.n)
+	for j := 1; j <= p.n; j++ {
+		x, y := new(big.Int), new(big.Int)
+		for _, i := range qual {
+			c, err := p.commits[i].Points.ShareKey(j)
+			if err != nil {
+				return nil, err
+			}
+			x, y = ec.Add(curve, x, y, c.X, c.Y)
+		}
+		shares[j-1] = &sm2.PublicKey{Curve: curve, X: x, Y: y}
+	}
+
+	return &Result{
+		Key:          keyswitch.ThresholdKey{Index: p.index, Priv: priv},
+		PublicKey:    pub,
+		PublicShares: shares,
+		Qualified:    qual,
+	}, nil
+}
+
+// eval returns the participant's polynomial at x.
+// 返回本参与者的多项式在x处的值。
+func (p *Participant) eval(x int) *big.Int {
+	N := p.curve.Params().N
+	X := big.NewInt(int64(x))
+	v := new(big.Int)
+	for k := len(p.poly) - 1; k >= 0; k-- {
+		v.Mul(v, X)
+		v.Add(v, p.poly[k])
+		v.Mod(v, N)
+	}
+	return v
+}
+
+// verifyShare reports whether sB matches the commitment c evaluated at x.
+// 判断sB是否与承诺c在x处的值相符。
+func (p *Participant) verifyShare(c *Commitment, x int, s *big.Int) bool {
+	if s.Sign() < 0 || s.Cmp(p.curve.Params().N) >= 0 {
+		return false
+	}
+	k := keyswitch.ThresholdKey{Index: x, Priv: &sm2.PrivateKey{D: s}}
+	return c.Points.Verify(&k) == nil
+}
+
+// committed returns the participants that have committed, in order.
+// 返回已承诺的参与者编号，升序。
+func (p *Participant) committed() []int {
+	indices := make([]int, 0, len(p.commits))
+	for i := range p.commits {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// isPeer reports whether i is another participant.
+// 判断i是否为其他参与者。
+func (p *Participant) isPeer(i int) bool {
+	return p.inRange(i) && i != p.index
+}
+
+// inRange reports whether i is in 1..n.
+// 判断i是否位于1..n。
+func (p *Participant) inRange(i int) bool {
+	return i >= 1 && i <= p.n
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dkg
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// run 运行一次协议。tamper可篡改发出的份额，返回nil表示不发送；silent中的参与者不答复投诉。
+func run(t *testing.T, th, n int, tamper func(d *Deal) *Deal, silent map[int]bool) []*Result {
+	ps := make([]*Participant, n)
+	for i := range ps {
+		p, err := NewParticipant(i+1, th, n, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ps[i] = p
+	}
+
+	// 广播承诺
+	for _, p := range ps {
+		for _, q := range ps {
+			if p != q {
+				if err := q.ProcessCommitment(p.Commitment()); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	// 私下发送份额
+	for _, p := range ps {
+		for _, d := range p.Deals() {
+			if tamper != nil {
+				d = tamper(d)
+			}
+			if d == nil {
+				continue
+			}
+			if err := ps[d.To-1].ProcessDeal(d); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// 广播投诉
+	var complaints []*Complaint
+	for _, p := range ps {
+		cs, err := p.Complaints()
+		if err != nil {
+			t.Fatal(err)
+		}
+		complaints = append(complaints, cs...)
+	}
+	var justifications []*Justification
+	for _, c := range complaints {
+		for _, p := range ps {
+			j, err := p.ProcessComplaint(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if j != nil && !silent[p.Index()] {
+				justifications = append(justifications, j)
+			}
+		}
+	}
+	// 广播答复
+	for _, j := range justifications {
+		for _, p := range ps {
+			if err := p.ProcessJustification(j); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	results := make([]*Result, n)
+	for i, p := range ps {
+		r, err := p.Finalize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		results[i] = r
+	}
+	return results
+}
+
+// checkResults 检查各参与者结果一致，且任意门限数量的份额恢复出与聚合公钥对应的私钥。
+func checkResults(t *testing.T, results []*Result, th int, qual []int) {
+	pub := results[0].PublicKey
+	for _, r := range results {
+		if 0 != r.PublicKey.X.Cmp(pub.X) || 0 != r.PublicKey.Y.Cmp(pub.Y) {
+			t.Fatal("participants disagree on the collective public key")
+		}
+		if len(r.Qualified) != len(qual) {
+			t.Fatalf("qualified %v, want %v", r.Qualified, qual)
+		}
+		for i := range qual {
+			if r.Qualified[i] != qual[i] {
+				t.Fatalf("qualified %v, want %v", r.Qualified, qual)
+			}
+		}
+		share := results[0].PublicShares[r.Key.Index-1]
+		if 0 != share.X.Cmp(r.Key.Priv.X) || 0 != share.Y.Cmp(r.Key.Priv.Y) {
+			t.Fatalf("public share of %d differs from its key", r.Key.Index)
+		}
+	}
+
+	// 取前th个参与者以拉格朗日插值恢复私钥
+	indices := make([]int, th)
+	for i := range indices {
+		indices[i] = results[i].Key.Index
+	}
+	N := pub.Curve.Params().N
+	d := new(big.Int)
+	for i := 0; i < th; i++ {
+		w, err := results[i].Key.Weighted(indices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Add(d, w.D)
+	}
+	d.Mod(d, N)
+	x, y := pub.Curve.ScalarBaseMult(d.Bytes())
+	if 0 != x.Cmp(pub.X) || 0 != y.Cmp(pub.Y) {
+		t.Fatal("interpolated key does not match the collective public key")
+	}
+}
+
+func TestDKG(t *testing.T) {
+	results := run(t, 3, 5, nil, nil)
+	checkResults(t, results, 3, []int{1, 2, 3, 4, 5})
+
+	// 以生成的门限密钥完成一次密钥置换
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := elgamal.GenPoint()
+	rct, err := elgamal.PointEncrypt(results[0].PublicKey, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indices := []int{2, 3, 5}
+	shares := make(elgamal.CipherVector, len(indices))
+	for i, j := range indices {
+		share, _, err := keyswitch.ThresholdShareCal(&q.PublicKey, &rct.K, &results[j-1].Key, indices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = *share
+	}
+	ct, err := keyswitch.ShareReplace(&shares, rct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D, err := elgamal.PointDecrypt(ct, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(M.X) || 0 != D.Y.Cmp(M.Y) {
+		t.Fatal("DKG key failed to switch")
+	}
+}
+
+func TestDKGComplaints(t *testing.T) {
+	// 参与者2给4的份额错误，但如实答复投诉，仍然合格；
+	// 参与者3未给5发送份额且不答复投诉，被取消资格
+	tamper := func(d *Deal) *Deal {
+		switch {
+		case d.From == 2 && d.To == 4:
+			return &Deal{From: d.From, To: d.To, Share: new(big.Int).Add(d.Share, big.NewInt(1))}
+		case d.From == 3 && d.To == 5:
+			return nil
+		}
+		return d
+	}
+	results := run(t, 3, 5, tamper, map[int]bool{3: true})
+	checkResults(t, results, 3, []int{1, 2, 4, 5})
+}
+
+func TestDKGMisuse(t *testing.T) {
+	if _, err := NewParticipant(4, 2, 3, nil); !errors.Is(err, keyswitch.ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+
+	p1, err := NewParticipant(1, 2, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := NewParticipant(2, 2, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未收到承诺的份额
+	if err := p1.ProcessDeal(p2.Deals()[0]); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("got %v, want ErrInvalidMessage", err)
+	}
+	// 自己的承诺
+	if err := p1.ProcessCommitment(p1.Commitment()); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("got %v, want ErrInvalidMessage", err)
+	}
+	if err := p1.ProcessCommitment(p2.Commitment()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p1.Complaints(); err != nil {
+		t.Fatal(err)
+	}
+	// 阶段只能前进
+	if err := p1.ProcessCommitment(p2.Commitment()); !errors.Is(err, ErrWrongPhase) {
+		t.Fatalf("got %v, want ErrWrongPhase", err)
+	}
+	if p1.Phase() != PhaseComplaint || p1.Phase().String() != "complaint" {
+		t.Fatalf("unexpected phase %v", p1.Phase())
+	}
+	// 未投诉的答复
+	j := &Justification{From: 2, To: 3, Share: big.NewInt(1)}
+	if err := p1.ProcessJustification(j); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("got %v, want ErrInvalidMessage", err)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dkg
+
+import (
+	"errors"
+
+	"ppks/elgamal"
+)
+
+// Base errors of the distributed key generation, in addition to those of package
+// elgamal.
+// 分布式密钥生成的基础错误，elgamal包中的基础错误之外另有下列错误。
+var (
+	// ErrWrongPhase 消息或操作不属于参与者当前所处的阶段。
+	ErrWrongPhase = errors.New("wrong protocol phase")
+	// ErrInvalidMessage 消息的发送者、接收者或内容非法。
+	ErrInvalidMessage = errors.New("invalid protocol message")
+	// ErrTooFewQualified 合格参与者少于门限。
+	ErrTooFewQualified = errors.New("too few qualified participants")
+)
+
+// opError wraps err with the failing operation.
+// 以出错的操作包装err。
+func opError(op string, err error) error {
+	return &elgamal.Error{Op: op, Err: err}
+}
+
+// itemError wraps err with the failing operation and the index of the failing element.
+// 以出错的操作及出错元素的下标包装err。
+func itemError(op, item string, index int, err error) error {
+	return &elgamal.Error{Op: op, Item: item, Index: index, Err: err}
+}
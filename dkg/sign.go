@@ -0,0 +1,123 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dkg
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// signDomain separates signed DKG messages from other signed messages.
+// DKG消息签名所用的域分隔标签，与其他签名消息区分。
+const signDomain = "ppks-dkg-message"
+
+// Message is one of the protocol messages: *Commitment, *Deal, *Complaint or
+// *Justification.
+// 协议消息：*Commitment、*Deal、*Complaint或*Justification之一。
+type Message interface {
+	encode() ([]byte, error)
+}
+
+// Sign signs m with the sender's long-term key priv, so messages can be relayed
+// over an unauthenticated transport and a dealer cannot later deny its deals.
+// 消息签名：以发送者的长期私钥priv对m签名，消息可经未认证的信道转发，分发者事后也无法否认其份额。
+//
+// 参数：
+//		私钥	priv
+//		消息	m
+// 返回：
+// 		签名
+func Sign(priv *sm2.PrivateKey, m Message) (*schnorr.Signature, error) {
+	msg, err := m.encode()
+	if err != nil {
+		return nil, opError("Sign", err)
+	}
+	sig, err := schnorr.Sign(priv, msg)
+	if err != nil {
+		return nil, opError("Sign", err)
+	}
+	return sig, nil
+}
+
+// Verify reports whether sig is a signature of m under the sender's long-term
+// key pub.
+// 消息验签：判断sig是否为发送者长期公钥pub下对m的签名。
+//
+// 参数：
+//		公钥	pub
+//		消息	m
+//		签名	sig
+// 返回：
+// 		验证结果
+func Verify(pub *sm2.PublicKey, m Message, sig *schnorr.Signature) (bool, error) {
+	msg, err := m.encode()
+	if err != nil {
+		return false, opError("Verify", err)
+	}
+	ok, err := schnorr.Verify(pub, msg, sig)
+	if err != nil {
+		return false, opError("Verify", err)
+	}
+	return ok, nil
+}
+
+// 消息编码：域分隔标签、类型字节、各编号（4字节大端），以及消息内容
+
+func (c *Commitment) encode() ([]byte, error) {
+	b := header('C', c.From, len(c.Points))
+	for _, P := range c.Points {
+		if P == nil || P.Curve == nil {
+			return nil, ErrInvalidMessage
+		}
+		b = append(b, P.Bytes()...)
+	}
+	return b, nil
+}
+
+func (d *Deal) encode() ([]byte, error) {
+	return appendShare(header('D', d.From, d.To), d.Share)
+}
+
+func (c *Complaint) encode() ([]byte, error) {
+	return header('P', c.From, c.Against), nil
+}
+
+func (j *Justification) encode() ([]byte, error) {
+	return appendShare(header('J', j.From, j.To), j.Share)
+}
+
+func header(kind byte, ids ...int) []byte {
+	b := append([]byte(signDomain), kind)
+	var n [4]byte
+	for _, id := range ids {
+		binary.BigEndian.PutUint32(n[:], uint32(id))
+		b = append(b, n[:]...)
+	}
+	return b
+}
+
+func appendShare(b []byte, s *big.Int) ([]byte, error) {
+	if s == nil || s.Sign() < 0 || s.BitLen() > 256 {
+		return nil, ErrInvalidMessage
+	}
+	var v [32]byte
+	s.FillBytes(v[:])
+	return append(b, v[:]...), nil
+}
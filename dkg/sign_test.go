@@ -0,0 +1,74 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dkg
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSignMessages(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewParticipant(1, 2, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deal := p.Deals()[0]
+
+	msgs := []Message{
+		p.Commitment(),
+		deal,
+		&Complaint{From: 2, Against: 1},
+		&Justification{From: deal.From, To: deal.To, Share: deal.Share},
+	}
+	for i, m := range msgs {
+		sig, err := Sign(priv, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := Verify(&priv.PublicKey, m, sig)
+		if err != nil || !ok {
+			t.Fatalf("message %d failed to verify: %v", i, err)
+		}
+	}
+
+	// 份额相同的Deal与Justification签名不可互换
+	sig, err := Sign(priv, msgs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := Verify(&priv.PublicKey, msgs[3], sig); ok {
+		t.Fatal("deal signature verified as a justification")
+	}
+
+	// 篡改份额后验证失败
+	tampered := *deal
+	tampered.Share = new(big.Int).Add(deal.Share, big.NewInt(1))
+	if ok, _ := Verify(&priv.PublicKey, &tampered, sig); ok {
+		t.Fatal("signature verified for a tampered deal")
+	}
+
+	if _, err := Sign(priv, &Deal{From: 1, To: 2}); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("got %v, want ErrInvalidMessage", err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"ppks/internal/ec"
+)
+
+// Backend names an arithmetic backend registered by RegisterBackend, such as an
+// optimized constant-time SM2 implementation, that carries out the scalar
+// multiplications by secret scalars: the encryption randomness and the private
+// key. A backend changes no result, only how it is computed. The empty Backend
+// uses the curve's own arithmetic and the tables of Precompute, as the package
+// level functions do.
+// 算术后端：RegisterBackend注册的后端名称，如优化的常数时间SM2实现，以秘密标量（加密随机数与私钥）
+// 进行的标量乘由该后端完成。后端不改变任何结果，仅改变计算方式。空后端与包级函数相同，
+// 使用曲线自身的运算及Precompute的固定基点表。
+type Backend string
+
+// RegisterBackend registers impl under name as an arithmetic backend for the curve
+// with the same parameters, such as an assembly or fiat-crypto generated SM2, which
+// is then selected as Backend(name). impl must return the same coordinates as the
+// curve it replaces; a known-answer check of its scalar multiplications is run
+// before it is registered.
+// 注册算术后端：以name注册impl，作为与其参数相同的曲线的算术实现，如汇编或fiat-crypto生成的SM2实现，
+// 之后以Backend(name)选用。impl须与其替代的曲线返回相同坐标，注册前会以已知答案检查其标量乘。
+//
+// 参数：
+//		后端名称	name
+//		曲线实现	impl
+// 返回：
+// 		错误
+func RegisterBackend(name string, impl elliptic.Curve) error {
+	if !ec.RegisterBackend(name, impl) {
+		return opError("RegisterBackend", ErrInvalidBackend)
+	}
+	return nil
+}
+
+// impl returns the implementation of curve selected by b, or nil when b is empty.
+// 返回b为曲线curve选用的实现，b为空时返回nil。
+func (b Backend) impl(op string, curve elliptic.Curve) (elliptic.Curve, error) {
+	if b == "" {
+		return nil, nil
+	}
+	impl, ok := ec.Backend(string(b), curve)
+	if !ok {
+		return nil, opError(op, ErrUnknownBackend)
+	}
+	return impl, nil
+}
+
+// ScalarMult returns k*P computed by b, or by ScalarMult and its fixed-base tables
+// when b is empty.
+// 以后端b计算k*P；b为空时使用ScalarMult及其固定基点表。
+//
+// 参数：
+//		点		P
+//		标量	k
+// 返回：
+// 		k*P的横、纵坐标
+//		错误
+func (b Backend) ScalarMult(P *CurvePoint, k []byte) (*big.Int, *big.Int, error) {
+	if err := CheckPoint(P); err != nil {
+		return nil, nil, opError("Backend.ScalarMult", err)
+	}
+	impl, err := b.impl("Backend.ScalarMult", P.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	x, y := scalarMultWith(impl, P, k)
+	return x, y, nil
+}
+
+// ScalarBaseMult returns k*G on curve computed by b, or by curve when b is empty.
+// 以后端b计算曲线curve上的k*G；b为空时由curve计算。
+//
+// 参数：
+//		曲线	curve
+//		标量	k
+// 返回：
+// 		k*G的横、纵坐标
+//		错误
+func (b Backend) ScalarBaseMult(curve elliptic.Curve, k []byte) (*big.Int, *big.Int, error) {
+	if curve == nil {
+		return nil, nil, opError("Backend.ScalarBaseMult", ErrEmpty)
+	}
+	impl, err := b.impl("Backend.ScalarBaseMult", curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	x, y := scalarBaseMultWith(impl, curve, k)
+	return x, y, nil
+}
+
+// scalarMultWith returns k*P with impl, the result of Backend.impl, or with
+// ScalarMult when impl is nil.
+// 以impl（Backend.impl的结果）计算k*P；impl为nil时使用ScalarMult。
+func scalarMultWith(impl elliptic.Curve, P *CurvePoint, k []byte) (*big.Int, *big.Int) {
+	if impl == nil {
+		return ScalarMult(P, k)
+	}
+	return impl.ScalarMult(P.X, P.Y, k)
+}
+
+// scalarBaseMultWith returns k*G with impl, or on curve when impl is nil.
+// 以impl计算k*G；impl为nil时在curve上计算。
+func scalarBaseMultWith(impl, curve elliptic.Curve, k []byte) (*big.Int, *big.Int) {
+	if impl != nil {
+		curve = impl
+	}
+	return curve.ScalarBaseMult(k)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// countingCurve stands in for an optimized backend: the SM2 curve counting its
+// scalar multiplications.
+type countingCurve struct {
+	elliptic.Curve
+	mults *int64
+}
+
+func (c countingCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(c.mults, 1)
+	return c.Curve.ScalarMult(x, y, k)
+}
+
+func (c countingCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(c.mults, 1)
+	return c.Curve.ScalarBaseMult(k)
+}
+
+func TestBackend(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mults int64
+	if err := RegisterBackend("counting", countingCurve{sm2.P256Sm2(), &mults}); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt64(&mults, 0)
+	b := Backend("counting")
+
+	// 加密两次标量乘、解密一次标量乘，均由后端完成，结果与包级函数一致
+	D := GenPoint()
+	ct, err := b.PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&mults); n != 2 {
+		t.Fatalf("PointEncrypt: %d backend multiplications, want 2", n)
+	}
+	got, err := PointDecrypt(ct, priv)
+	if err != nil || got.X.Cmp(D.X) != 0 || got.Y.Cmp(D.Y) != 0 {
+		t.Fatal("ciphertext of the backend failed to decrypt")
+	}
+	ct, err = PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt64(&mults, 0)
+	if got, err = b.PointDecrypt(ct, priv); err != nil || got.X.Cmp(D.X) != 0 || got.Y.Cmp(D.Y) != 0 {
+		t.Fatal("backend failed to decrypt")
+	}
+	if n := atomic.LoadInt64(&mults); n != 1 {
+		t.Fatalf("PointDecrypt: %d backend multiplications, want 1", n)
+	}
+
+	// 向量与批量加密的每个点两次标量乘，向量解密每个点一次
+	points := PointVector{*GenPoint(), *GenPoint(), *GenPoint()}
+	for _, enc := range []func(*sm2.PublicKey, *PointVector) (*CipherVector, error){b.VectorEncrypt, b.EncryptBatch} {
+		atomic.StoreInt64(&mults, 0)
+		cts, err := enc(&priv.PublicKey, &points)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt64(&mults); n != 2*int64(len(points)) {
+			t.Fatalf("%d backend multiplications for %d points", n, len(points))
+		}
+		atomic.StoreInt64(&mults, 0)
+		dec, err := b.VectorDecrypt(priv, cts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt64(&mults); n != int64(len(points)) {
+			t.Fatalf("VectorDecrypt: %d backend multiplications for %d points", n, len(points))
+		}
+		for i := range points {
+			if (*dec)[i].X.Cmp(points[i].X) != 0 || (*dec)[i].Y.Cmp(points[i].Y) != 0 {
+				t.Fatalf("point %d: decryption mismatch", i)
+			}
+		}
+	}
+
+	// 未注册的后端
+	if _, err := Backend("missing").PointEncrypt(&priv.PublicKey, D); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("unknown backend: got %v", err)
+	}
+	if _, _, err := Backend("missing").ScalarMult(D, []byte{1}); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("unknown backend: got %v", err)
+	}
+	if err := RegisterBackend("", sm2.P256Sm2()); !errors.Is(err, ErrInvalidBackend) {
+		t.Fatalf("empty name: got %v", err)
+	}
+}
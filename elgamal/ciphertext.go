@@ -0,0 +1,67 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// NewCipherText returns the ciphertext (K, C) = ((kx,ky), (cx,cy)) on curve after
+// checking that both are finite points on the curve. A nil curve selects SM2.
+// 由坐标构造密文：校验(kx,ky)与(cx,cy)均为曲线curve上的有限点后，返回密文(K, C)，
+// curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线		curve
+//		左侧点坐标	kx,ky
+//		右侧点坐标	cx,cy
+// 返回：
+// 		密文
+func NewCipherText(curve elliptic.Curve, kx, ky, cx, cy *big.Int) (*CipherText, error) {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+	if !ec.IsValidXY(curve, kx, ky) || !ec.IsValidXY(curve, cx, cy) {
+		return nil, opError("NewCipherText", ErrPointNotOnCurve)
+	}
+
+	var ct CipherText
+	ct.K.Curve = curve
+	ct.K.X = new(big.Int).Set(kx)
+	ct.K.Y = new(big.Int).Set(ky)
+	ct.C.Curve = curve
+	ct.C.X = new(big.Int).Set(cx)
+	ct.C.Y = new(big.Int).Set(cy)
+
+	return &ct, nil
+}
+
+// Curve returns the curve of ct.
+// 返回密文ct所在的曲线。
+func (ct *CipherText) Curve() elliptic.Curve {
+	return ct.K.Curve
+}
+
+// Coordinates returns the coordinates of K and C, the inverse of NewCipherText.
+// 返回左侧点K与右侧点C的坐标，与NewCipherText互逆。
+func (ct *CipherText) Coordinates() (kx, ky, cx, cy *big.Int) {
+	return ct.K.X, ct.K.Y, ct.C.X, ct.C.Y
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestNewCipherText(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := GenPoint()
+	ct, err := PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 由线上收到的坐标重建密文后可解密
+	rebuilt, err := NewCipherText(nil, ct.K.X, ct.K.Y, ct.C.X, ct.C.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := PointDecrypt(rebuilt, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(pt.X) || 0 != D.Y.Cmp(pt.Y) {
+		t.Fatal("rebuilt ciphertext decrypts to a different point")
+	}
+
+	kx, ky, cx, cy := rebuilt.Coordinates()
+	if 0 != kx.Cmp(ct.K.X) || 0 != ky.Cmp(ct.K.Y) || 0 != cx.Cmp(ct.C.X) || 0 != cy.Cmp(ct.C.Y) {
+		t.Fatal("accessors differ from constructor input")
+	}
+	if rebuilt.Curve() != sm2.P256Sm2() {
+		t.Fatal("unexpected curve")
+	}
+
+	badCy := new(big.Int).Add(ct.C.Y, big.NewInt(1))
+	if _, err := NewCipherText(nil, ct.K.X, ct.K.Y, ct.C.X, badCy); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
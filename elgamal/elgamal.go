@@ -0,0 +1,193 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package elgamal implements point ElGamal encryption on SM2, the layer ppks builds
+// key switching on: curve points, ciphertexts, their encodings and validation.
+// 基于SM2曲线的点ElGamal加密，是ppks密钥置换的基础：曲线点、密文及其编码与校验。
+//
+// Concurrency: all functions are safe for concurrent use. Points and ciphertexts
+// may be read by several goroutines at once, but not written while being read.
+// 并发：所有函数均可并发调用。点与密文可被多个协程同时读取，但读取期间不可修改。
+package elgamal
+
+import (
+	"crypto/rand"
+	"log"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// CurvePoint 曲线上的点
+type CurvePoint sm2.PublicKey
+
+// PointVector 曲线点向量
+type PointVector []CurvePoint
+
+// CipherText is an ElGamal encrypted point.
+// 密文，基于群的ElGamal加密文本，形式为群上点对。
+type CipherText struct {
+	K, C CurvePoint
+}
+
+// CipherVector is a slice of ElGamal encrypted points.
+// 密文向量，基于群的ElGamal密文slice。
+type CipherVector []CipherText
+
+// GenPoint generates a curve point at random.
+// 生成点：随机生成一个点并返回。
+//
+// 参数：
+//
+// 返回：
+// 		点
+func GenPoint() *CurvePoint {
+	d, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return (*CurvePoint)(&d.PublicKey)
+}
+
+// PointEncrypt encrypts D with pub and returns the ciphertext.
+// 点加密：使用公钥加密点D，返回密文。
+// 本项目中使用点D的指定坐标来作为对称密钥（暂定为横坐标）。
+//
+// 参数：
+//		公钥		pub
+//		待加密点	D
+// 返回：
+// 		密文		ct{K,C}
+func PointEncrypt(pub *sm2.PublicKey, D *CurvePoint) (*CipherText, error) {
+	return Backend("").PointEncrypt(pub, D)
+}
+
+// PointEncrypt is PointEncrypt with the multiplications by the random value
+// carried out by b.
+// 点加密：同PointEncrypt，但以随机数进行的标量乘由后端b完成。
+//
+// 参数：
+//		公钥		pub
+//		待加密点	D
+// 返回：
+// 		密文		ct{K,C}
+func (b Backend) PointEncrypt(pub *sm2.PublicKey, D *CurvePoint) (*CipherText, error) {
+	var ct CipherText
+
+	// 检查公钥与待加密点
+	if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+		return &ct, opError("PointEncrypt", err)
+	}
+	if err := CheckPoint(D); err != nil {
+		return &ct, opError("PointEncrypt", err)
+	}
+
+	// 从公钥提取曲线及后端实现
+	curve := pub.Curve
+	impl, err := b.impl("PointEncrypt", curve)
+	if err != nil {
+		return &ct, err
+	}
+	// 从有限域中获得随机元素
+	r, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return &ct, opError("PointEncrypt", err)
+	}
+	rBytes := r.Bytes()
+	defer wipe(rBytes)
+
+	// 随机数数乘生成元，生成密文左侧点K，rB
+	ct.K.Curve = curve
+	ct.K.X, ct.K.Y = scalarBaseMultWith(impl, curve, rBytes)
+
+	// 随机数乘公钥得到点rK
+	rKx, rKy := scalarMultWith(impl, (*CurvePoint)(pub), rBytes)
+
+	// 待加密点与点rK相加，得到右侧点，ct.C
+	ct.C.Curve = curve
+	ct.C.X, ct.C.Y = curve.Add(rKx, rKy, D.X, D.Y)
+
+	return &ct, nil
+}
+
+// PointDecrypt decrypts ct with priv and returns the resulting curve point.
+// 点解密：使用私钥priv解密密文ct，返回结果点。
+// 本项目中使用其中指定坐标来作为对称密钥（暂定为横坐标）。
+//
+// 参数：
+//		密文		ct
+//		私钥		priv
+// 返回：
+// 		明文点
+func PointDecrypt(ct *CipherText, priv *sm2.PrivateKey) (*CurvePoint, error) {
+	return Backend("").PointDecrypt(ct, priv)
+}
+
+// PointDecrypt is PointDecrypt with the multiplication by the private key carried
+// out by b.
+// 点解密：同PointDecrypt，但以私钥进行的标量乘由后端b完成。
+//
+// 参数：
+//		密文		ct
+//		私钥		priv
+// 返回：
+// 		明文点
+func (b Backend) PointDecrypt(ct *CipherText, priv *sm2.PrivateKey) (*CurvePoint, error) {
+	// 检查密文
+	if err := CheckCipherText(ct); err != nil {
+		return nil, opError("PointDecrypt", err)
+	}
+
+	curve := priv.Curve
+	impl, err := b.impl("PointDecrypt", curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// 原算法
+	////////////////////////////////////////////////////////////////////////
+	// 私钥数乘左侧点K(rB)，得到点rK
+	dBytes := priv.D.Bytes()
+	defer wipe(dBytes)
+	rKx, rKy := scalarMultWith(impl, &ct.K, dBytes)
+
+	// 求点-rK
+	negrKx, negrKy := ec.Neg(curve, rKx, rKy)
+
+	// 密文右侧点C减去rK(加上负rK)，得到密文点
+	var D CurvePoint
+	D.Curve = curve
+	D.X, D.Y = priv.Curve.Add(ct.C.X, ct.C.Y, negrKx, negrKy)
+	////////////////////////////////////////////////////////////////////////
+
+	// 新算法
+	////////////////////////////////////////////////////////////////////////
+	// // 计算私钥模n的负数，-priv
+	// negPriv := priv.D.Neg(priv.D)
+	// negPriv.Mod(negPriv, curve.Params().N)
+
+	// // 计算点-rK，即rB*(-priv)
+	// negrKx, negrKy := curve.ScalarMult(ct.K.X, ct.K.Y, negPriv.Bytes())
+
+	// // 密文右侧点C加上点-rK，计算明文点D
+	// var D CurvePoint
+	// D.Curve = curve
+	// D.X, D.Y = curve.Add(ct.C.X, ct.C.Y, negrKx, negrKy)
+	////////////////////////////////////////////////////////////////////////
+
+	return &D, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestPointEncryptDecrypt(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	D := GenPoint()
+	ct, err := PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := PointDecrypt(ct, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(pt.X) || 0 != D.Y.Cmp(pt.Y) {
+		t.Fatal("decrypted point differs from original")
+	}
+
+	if _, err := PointDecrypt(&CipherText{}, priv); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
+
+func BenchmarkPointEncrypt(b *testing.B) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	D := GenPoint()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := PointEncrypt(&priv.PublicKey, D); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPointDecrypt(b *testing.B) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ct, err := PointEncrypt(&priv.PublicKey, GenPoint())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := PointDecrypt(ct, priv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
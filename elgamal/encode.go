@@ -0,0 +1,99 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// MaxPayloadLen is the longest payload EncodeToPoint embeds in an SM2 point: the
+// 32 bytes of X less a length byte and a counter byte.
+// EncodeToPoint可嵌入SM2点的最长数据长度：横坐标32字节减去长度字节与计数字节。
+const MaxPayloadLen = 30
+
+// EncodeToPoint embeds data, at most MaxPayloadLen bytes, in the X coordinate of an
+// SM2 point by try-and-increment: X = len || data || zero padding || ctr, for the
+// first ctr giving a point. Applications can thus encrypt a secret of their choice,
+// e.g. an SM4 key, instead of deriving it from a random point.
+// 数据编码为点：以"尝试-递增"方式将至多MaxPayloadLen字节的数据data嵌入SM2点的横坐标，
+// X = 长度 || 数据 || 零填充 || 计数，取第一个得到曲线点的计数。应用因此可加密自选的秘密（如SM4密钥），
+// 而不必由随机点派生。
+//
+// 参数：
+//		数据	data
+// 返回：
+// 		点
+func EncodeToPoint(data []byte) (*CurvePoint, error) {
+	if len(data) > MaxPayloadLen {
+		return nil, opError("EncodeToPoint", ErrOutOfRange)
+	}
+
+	curve := sm2.P256Sm2()
+	byteLen := (curve.Params().BitSize + 7) / 8
+	b := make([]byte, byteLen)
+	b[0] = byte(len(data))
+	copy(b[1:], data)
+
+	// 首字节为长度（不超过30），故x必小于P
+	for ctr := 0; ctr < 256; ctr++ {
+		b[byteLen-1] = byte(ctr)
+		x := new(big.Int).SetBytes(b)
+		y, ok := ec.LiftX(curve, x, false)
+		if !ok {
+			continue
+		}
+		return NewCurvePointFromXY(curve, x, y)
+	}
+
+	return nil, opError("EncodeToPoint", ErrNoPointFound)
+}
+
+// DecodeFromPoint returns the data embedded in p by EncodeToPoint, failing with
+// ErrInvalidPointEncoding if p does not carry a payload.
+// 由点解码数据：返回EncodeToPoint嵌入点p的数据，p不含数据时返回ErrInvalidPointEncoding。
+//
+// 参数：
+//		点	p
+// 返回：
+// 		数据
+func DecodeFromPoint(p *CurvePoint) ([]byte, error) {
+	if err := CheckPoint(p); err != nil {
+		return nil, opError("DecodeFromPoint", err)
+	}
+
+	byteLen := (p.Curve.Params().BitSize + 7) / 8
+	b := make([]byte, byteLen)
+	p.X.FillBytes(b)
+
+	// 校验长度字节与零填充
+	n := int(b[0])
+	if n > MaxPayloadLen || p.Y.Bit(0) != 0 {
+		return nil, opError("DecodeFromPoint", ErrInvalidPointEncoding)
+	}
+	for _, c := range b[1+n : byteLen-1] {
+		if c != 0 {
+			return nil, opError("DecodeFromPoint", ErrInvalidPointEncoding)
+		}
+	}
+
+	data := make([]byte, n)
+	copy(data, b[1:1+n])
+	return data, nil
+}
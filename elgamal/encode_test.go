@@ -0,0 +1,67 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEncodeToPoint(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{nil, {0}, key, bytes.Repeat([]byte{0xff}, MaxPayloadLen)} {
+		M, err := EncodeToPoint(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 经加密、解密后仍可解码
+		ct, err := PointEncrypt(&priv.PublicKey, M)
+		if err != nil {
+			t.Fatal(err)
+		}
+		D, err := PointDecrypt(ct, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeFromPoint(D)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("got %x, want %x", got, data)
+		}
+	}
+
+	if _, err := EncodeToPoint(make([]byte, MaxPayloadLen+1)); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("got %v, want ErrOutOfRange", err)
+	}
+	// 随机点几乎不可能携带数据
+	if _, err := DecodeFromPoint(GenPoint()); !errors.Is(err, ErrInvalidPointEncoding) {
+		t.Fatalf("got %v, want ErrInvalidPointEncoding", err)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Base errors. Errors returned by the ppks packages are *Error values wrapping one of
+// these (or an error from a lower layer), so callers can test them with errors.Is.
+// 基础错误。ppks各包返回的错误均为*Error，包装下列基础错误之一（或底层返回的错误），
+// 调用者可使用errors.Is判断。
+var (
+	// ErrPointNotOnCurve 坐标不在曲线上，或为无穷远点。
+	ErrPointNotOnCurve = errors.New("point not on curve")
+	// ErrInvalidPointEncoding 点编码格式错误。
+	ErrInvalidPointEncoding = errors.New("invalid point encoding")
+	// ErrLengthMismatch 成组输入的长度不一致。
+	ErrLengthMismatch = errors.New("length mismatch")
+	// ErrEmpty 输入为空。
+	ErrEmpty = errors.New("empty input")
+	// ErrNoPointFound 未能将输入映射到曲线上。
+	ErrNoPointFound = errors.New("no point found")
+	// ErrCurveMismatch 参与运算的点不在同一曲线上。
+	ErrCurveMismatch = errors.New("curve mismatch")
+	// ErrOutOfRange 整数超出离散对数表的范围。
+	ErrOutOfRange = errors.New("value out of range")
+	// ErrUnknownBackend 所选的算术后端未为该曲线注册。
+	ErrUnknownBackend = errors.New("arithmetic backend not registered for curve")
+	// ErrInvalidBackend 曲线实现未通过算术后端的自检。
+	ErrInvalidBackend = errors.New("arithmetic backend failed self-test")
+)
+
+// Error records the operation, and for vector inputs the element, that failed,
+// e.g. "ppks: ShareReplace: share 42: point not on curve".
+// 错误：记录出错的操作，对于向量输入还记录出错元素的下标，
+// 如"ppks: ShareReplace: share 42: point not on curve"。
+type Error struct {
+	// Op is the failing operation. 出错的操作。
+	Op string
+	// Item names the indexed element, empty when the error is not about one element.
+	// 出错元素的名称，与单个元素无关时为空。
+	Item string
+	// Index is the index of the failing element when Item is set.
+	// Item非空时，出错元素的下标。
+	Index int
+	// Err is the wrapped error. 被包装的错误。
+	Err error
+}
+
+func (e *Error) Error() string {
+	return "ppks: " + e.message()
+}
+
+// message returns the error text of e without the "ppks: " prefix. A wrapped
+// *Error is written the same way, so nested errors carry the prefix once, as in
+// "ppks: SealData: PointEncrypt: point not on curve".
+// 返回不含"ppks: "前缀的错误文本。被包装的*Error同样不含前缀，嵌套错误只带一次前缀，
+// 如"ppks: SealData: PointEncrypt: point not on curve"。
+func (e *Error) message() string {
+	var inner interface{} = e.Err
+	if w, ok := e.Err.(*Error); ok && w != nil {
+		inner = w.message()
+	}
+	if e.Item != "" {
+		return fmt.Sprintf("%s: %s %d: %v", e.Op, e.Item, e.Index, inner)
+	}
+	return fmt.Sprintf("%s: %v", e.Op, inner)
+}
+
+// Unwrap returns the wrapped error.
+// 返回被包装的错误。
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// opError wraps err with the failing operation.
+// 以出错的操作包装err。
+func opError(op string, err error) error {
+	return &Error{Op: op, Err: err}
+}
+
+// itemError wraps err with the failing operation and the index of the failing element.
+// 以出错的操作及出错元素的下标包装err。
+func itemError(op, item string, index int, err error) error {
+	return &Error{Op: op, Item: item, Index: index, Err: err}
+}
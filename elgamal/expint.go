@@ -0,0 +1,187 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// EncryptInt encrypts the integer m as the point mB with pub (exponential ElGamal).
+// Such ciphertexts add up with CipherAdd and switch like any other, and decrypt
+// with DecryptInt as long as the result stays in the range of a DLogTable.
+// 整数加密：将整数m编码为点mB，使用公钥pub加密（指数ElGamal）。此类密文可由CipherAdd相加，
+// 可照常置换，结果位于DLogTable范围内时可由DecryptInt解密。
+//
+// 参数：
+//		公钥	pub
+//		整数	m
+// 返回：
+// 		密文
+func EncryptInt(pub *sm2.PublicKey, m int64) (*CipherText, error) {
+	if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, opError("EncryptInt", err)
+	}
+
+	curve := pub.Curve
+	r, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, opError("EncryptInt", err)
+	}
+
+	// K = rB, C = r*pub + mB，m = 0时mB为无穷远点，C = r*pub
+	var ct CipherText
+	ct.K.Curve = curve
+	ct.K.X, ct.K.Y = curve.ScalarBaseMult(r.Bytes())
+	M := BaseMultiple(curve, m)
+	rPx, rPy := ScalarMult((*CurvePoint)(pub), r.Bytes())
+	ct.C.Curve = curve
+	ct.C.X, ct.C.Y = ec.Add(curve, rPx, rPy, M.X, M.Y)
+
+	return &ct, nil
+}
+
+// DecryptInt decrypts ct with priv and solves the discrete logarithm of the
+// plaintext point with table, failing with ErrOutOfRange beyond its range.
+// 整数解密：使用私钥priv解密密文ct，并以table求明文点的离散对数，超出其范围时返回ErrOutOfRange。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+//		离散对数表	table
+// 返回：
+// 		整数
+func DecryptInt(priv *sm2.PrivateKey, ct *CipherText, table *DLogTable) (int64, error) {
+	if err := CheckCipherText(ct); err != nil {
+		return 0, opError("DecryptInt", err)
+	}
+	if table == nil {
+		return 0, opError("DecryptInt", ErrEmpty)
+	}
+
+	// M = C - dK，可能为无穷远点（m = 0）
+	curve := priv.Curve
+	var M CurvePoint
+	M.Curve = curve
+	Sx, Sy := curve.ScalarMult(ct.K.X, ct.K.Y, priv.D.Bytes())
+	Sx, Sy = ec.Neg(curve, Sx, Sy)
+	M.X, M.Y = ec.Add(curve, ct.C.X, ct.C.Y, Sx, Sy)
+
+	m, err := table.DLog(&M)
+	if err != nil {
+		return 0, opError("DecryptInt", err)
+	}
+	return m, nil
+}
+
+// DLogTable solves discrete logarithms m of points mB for m in [0, Max] by
+// baby-step giant-step, with the baby steps precomputed once. It takes about
+// sqrt(Max) points of memory and time per lookup, and is safe for concurrent use.
+// 离散对数表：以小步大步算法求[0, Max]内点mB的离散对数m，小步预先计算一次。
+// 占用约sqrt(Max)个点的内存，每次查找耗时同阶，可并发使用。
+type DLogTable struct {
+	curve elliptic.Curve
+	max   int64
+	step  int64
+	baby  map[string]int64
+	giant *CurvePoint
+}
+
+// NewDLogTable precomputes the table for [0, max] on curve. A nil curve selects SM2.
+// 预计算曲线curve上[0, max]范围的离散对数表，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+//		上限	max
+// 返回：
+// 		离散对数表
+func NewDLogTable(curve elliptic.Curve, max int64) (*DLogTable, error) {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+	if max < 0 {
+		return nil, opError("NewDLogTable", ErrOutOfRange)
+	}
+
+	// 步长m = ceil(sqrt(max+1))，小步表记录jB，j∈[0,m)
+	step := new(big.Int).Sqrt(big.NewInt(max)).Int64() + 1
+	t := &DLogTable{
+		curve: curve,
+		max:   max,
+		step:  step,
+		baby:  make(map[string]int64, step),
+	}
+	x, y := new(big.Int), new(big.Int)
+	Gx, Gy := curve.Params().Gx, curve.Params().Gy
+	for j := int64(0); j < step; j++ {
+		t.baby[pointKey(x, y)] = j
+		x, y = ec.Add(curve, x, y, Gx, Gy)
+	}
+	// 大步为-mB
+	t.giant, _ = NegPoint(BaseMultiple(curve, step))
+
+	return t, nil
+}
+
+// Max returns the upper end of the range of t.
+// 返回t的范围上限。
+func (t *DLogTable) Max() int64 {
+	return t.max
+}
+
+// DLog returns m with M = mB, failing with ErrOutOfRange unless m is in [0, Max].
+// 求离散对数：返回满足M = mB的m，m不在[0, Max]内时返回ErrOutOfRange。
+//
+// 参数：
+//		点	M
+// 返回：
+// 		离散对数m
+func (t *DLogTable) DLog(M *CurvePoint) (int64, error) {
+	if M == nil || M.X == nil || M.Y == nil {
+		return 0, opError("DLog", ErrPointNotOnCurve)
+	}
+	if !M.IsInfinity() && !ec.IsValidXY(t.curve, M.X, M.Y) {
+		return 0, opError("DLog", ErrPointNotOnCurve)
+	}
+
+	// γ = M - i*mB，命中小步表jB时m = i*m + j
+	x, y := M.X, M.Y
+	for i := int64(0); i*t.step <= t.max; i++ {
+		if j, ok := t.baby[pointKey(x, y)]; ok {
+			if m := i*t.step + j; m <= t.max {
+				return m, nil
+			}
+			break
+		}
+		x, y = ec.Add(t.curve, x, y, t.giant.X, t.giant.Y)
+	}
+	return 0, opError("DLog", ErrOutOfRange)
+}
+
+// pointKey returns the map key of the point (x,y): x and the parity of y, or the
+// empty string for the point at infinity.
+// 返回点(x,y)的map键：横坐标及纵坐标奇偶性，无穷远点为空串。
+func pointKey(x, y *big.Int) string {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return ""
+	}
+	return string(append(x.Bytes(), byte(y.Bit(0))))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEncryptInt(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := NewDLogTable(nil, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []int64{0, 1, 2, 99, 100, 101, 9999, 10000} {
+		ct, err := EncryptInt(&priv.PublicKey, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecryptInt(priv, ct, table)
+		if err != nil {
+			t.Fatalf("%d: %v", m, err)
+		}
+		if got != m {
+			t.Fatalf("got %d, want %d", got, m)
+		}
+	}
+
+	// 计数器：密文相加后解密得到和
+	sum, err := EncryptInt(&priv.PublicKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []int64{17, 400, 3} {
+		ct, err := EncryptInt(&priv.PublicKey, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum, err = CipherAdd(sum, ct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := DecryptInt(priv, sum, table); err != nil || got != 420 {
+		t.Fatalf("got %d, %v, want 420", got, err)
+	}
+
+	for _, m := range []int64{10001, -1} {
+		ct, err := EncryptInt(&priv.PublicKey, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecryptInt(priv, ct, table); !errors.Is(err, ErrOutOfRange) {
+			t.Fatalf("%d: got %v, want ErrOutOfRange", m, err)
+		}
+	}
+}
+
+func TestDLogTable(t *testing.T) {
+	table, err := NewDLogTable(nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := table.DLog(Infinity(nil)); err != nil || m != 0 {
+		t.Fatalf("got %d, %v, want 0", m, err)
+	}
+	if _, err := table.DLog(Generator(nil)); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("got %v, want ErrOutOfRange", err)
+	}
+	if _, err := NewDLogTable(nil, -1); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("got %v, want ErrOutOfRange", err)
+	}
+}
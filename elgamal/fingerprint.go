@@ -0,0 +1,86 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"encoding/hex"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Fingerprint is the SM3 digest of the canonical encoding of a point, key or
+// ciphertext. Unlike CurvePoint it is comparable, so it can index maps and
+// deduplicate values.
+// 指纹：点、公钥或密文规范编码的SM3摘要。与CurvePoint不同，指纹可比较，可用作map的键或用于去重。
+type Fingerprint [32]byte
+
+// Fingerprint domain separation tags.
+// 指纹的域分隔标签。
+var (
+	fingerprintPoint      = []byte("ppks-fp-point")
+	fingerprintCipherText = []byte("ppks-fp-ciphertext")
+)
+
+// String returns the fingerprint in hex.
+// 以十六进制表示指纹。
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// Fingerprint returns the fingerprint of the uncompressed encoding of p. Points
+// without coordinates all have the zero fingerprint.
+// 返回点p非压缩编码的指纹，坐标缺失的点指纹均为零值。
+func (p *CurvePoint) Fingerprint() Fingerprint {
+	var f Fingerprint
+	if p == nil || p.Curve == nil || p.X == nil || p.Y == nil {
+		return f
+	}
+	h := sm3.New()
+	h.Write(fingerprintPoint)
+	h.Write(p.Bytes())
+	copy(f[:], h.Sum(nil))
+	return f
+}
+
+// Fingerprint returns the fingerprint of the ciphertext (K, C).
+// 返回密文(K, C)的指纹。
+func (ct *CipherText) Fingerprint() Fingerprint {
+	var f Fingerprint
+	if ct == nil {
+		return f
+	}
+	fk := ct.K.Fingerprint()
+	fc := ct.C.Fingerprint()
+	h := sm3.New()
+	h.Write(fingerprintCipherText)
+	h.Write(fk[:])
+	h.Write(fc[:])
+	copy(f[:], h.Sum(nil))
+	return f
+}
+
+// KeyFingerprint returns the fingerprint of the public key pub, equal to that of
+// pub as a CurvePoint.
+// 返回公钥pub的指纹，与将pub视为CurvePoint时的指纹相同。
+//
+// 参数：
+//		公钥	pub
+// 返回：
+// 		指纹
+func KeyFingerprint(pub *sm2.PublicKey) Fingerprint {
+	return (*CurvePoint)(pub).Fingerprint()
+}
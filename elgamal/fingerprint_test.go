@@ -0,0 +1,64 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestFingerprint(t *testing.T) {
+	D := GenPoint()
+	copyD, err := NewCurvePointFromXY(nil, D.X, D.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 坐标相同的点指纹相同，可作为map的键去重
+	seen := map[Fingerprint]int{}
+	seen[D.Fingerprint()]++
+	seen[copyD.Fingerprint()]++
+	seen[GenPoint().Fingerprint()]++
+	if len(seen) != 2 || seen[D.Fingerprint()] != 2 {
+		t.Fatalf("unexpected fingerprint map %v", seen)
+	}
+
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyFingerprint(&priv.PublicKey) != (*CurvePoint)(&priv.PublicKey).Fingerprint() {
+		t.Fatal("key and point fingerprints differ")
+	}
+
+	ct, err := PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := CipherText{K: ct.C, C: ct.K}
+	if ct.Fingerprint() == swapped.Fingerprint() || ct.Fingerprint() == ct.K.Fingerprint() {
+		t.Fatal("ciphertext fingerprint does not bind the point order")
+	}
+
+	if (&CurvePoint{}).Fingerprint() != (Fingerprint{}) {
+		t.Fatal("empty point should have the zero fingerprint")
+	}
+	if len(D.Fingerprint().String()) != 64 {
+		t.Fatal("unexpected hex length")
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"fmt"
+
+	"ppks/internal/ec"
+)
+
+// String returns the point as short hex prefixes of its coordinates.
+// 以坐标的十六进制短前缀表示点。
+func (p CurvePoint) String() string {
+	if p.X == nil || p.Y == nil {
+		return "CurvePoint(nil)"
+	}
+	if p.IsInfinity() {
+		return "CurvePoint(inf)"
+	}
+	return fmt.Sprintf("CurvePoint(%s, %s)", ec.ShortHex(p.X), ec.ShortHex(p.Y))
+}
+
+// String returns the ciphertext as short hex prefixes of its two points.
+// 以两个点的十六进制短前缀表示密文。
+func (ct CipherText) String() string {
+	return fmt.Sprintf("CipherText{K: %s, C: %s}", ct.K, ct.C)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCurvePointString(t *testing.T) {
+	G := MustParseCurvePoint("04" +
+		"32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7" +
+		"BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0")
+
+	if got, want := G.String(), "CurvePoint(32c4ae2c..., bc3736a2...)"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	// 指针与值格式化一致
+	if fmt.Sprint(G) != fmt.Sprint(*G) {
+		t.Fatal("pointer and value formatting differ")
+	}
+	if got := (CurvePoint{}).String(); got != "CurvePoint(nil)" {
+		t.Fatalf("got %s for empty point", got)
+	}
+
+	ct := CipherText{K: *G, C: *G}
+	if !strings.HasPrefix(ct.String(), "CipherText{K: CurvePoint(32c4ae2c") {
+		t.Fatalf("unexpected ciphertext format %s", ct)
+	}
+
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Generator returns the base point G of curve as a new CurvePoint. A nil curve
+// selects SM2.
+// 生成元：返回曲线curve的基点G，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+// 返回：
+// 		基点G
+func Generator(curve elliptic.Curve) *CurvePoint {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+
+	var G CurvePoint
+	G.Curve = curve
+	G.X = new(big.Int).Set(curve.Params().Gx)
+	G.Y = new(big.Int).Set(curve.Params().Gy)
+
+	return &G
+}
+
+// Infinity returns the identity element of curve, represented as (0,0) like the
+// results of curve arithmetic. It is not a valid input to CheckPoint. A nil curve
+// selects SM2.
+// 无穷远点：返回曲线curve的单位元，与曲线运算结果一致地表示为(0,0)，不能通过CheckPoint校验。
+// curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+// 返回：
+// 		无穷远点
+func Infinity(curve elliptic.Curve) *CurvePoint {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+
+	var O CurvePoint
+	O.Curve = curve
+	O.X = new(big.Int)
+	O.Y = new(big.Int)
+
+	return &O
+}
+
+// BaseMultiple returns kG on curve, k may be negative. Small multiples give fixed,
+// easily reproduced points for tests and examples. A nil curve selects SM2.
+// 基点倍点：返回曲线curve上的点kG，k可为负。小倍数可作为测试及示例中固定、易复现的点。
+// curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+//		倍数	k
+// 返回：
+// 		点kG
+func BaseMultiple(curve elliptic.Curve, k int64) *CurvePoint {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+	n := new(big.Int).Mod(big.NewInt(k), curve.Params().N)
+	if n.Sign() == 0 {
+		return Infinity(curve)
+	}
+
+	var P CurvePoint
+	P.Curve = curve
+	P.X, P.Y = curve.ScalarBaseMult(n.Bytes())
+
+	return &P
+}
+
+// IsInfinity reports whether p is the identity element (0,0).
+// 判断p是否为无穷远点(0,0)。
+func (p *CurvePoint) IsInfinity() bool {
+	return p.X != nil && p.Y != nil && p.X.Sign() == 0 && p.Y.Sign() == 0
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"testing"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestGenerator(t *testing.T) {
+	G := Generator(nil)
+	if err := CheckPoint(G); err != nil {
+		t.Fatal(err)
+	}
+	if 0 != G.X.Cmp(G.Curve.Params().Gx) || 0 != G.Y.Cmp(G.Curve.Params().Gy) {
+		t.Fatal("generator differs from curve parameters")
+	}
+	// 返回的是副本，修改不影响曲线参数
+	G.X.SetInt64(1)
+	if 0 == Generator(nil).X.Cmp(G.X) {
+		t.Fatal("Generator shares coordinates with the curve parameters")
+	}
+
+	if 0 != BaseMultiple(nil, 1).X.Cmp(Generator(nil).X) {
+		t.Fatal("1G differs from G")
+	}
+	x, y := ec.Add(G.Curve, BaseMultiple(nil, 2).X, BaseMultiple(nil, 2).Y, BaseMultiple(nil, -2).X, BaseMultiple(nil, -2).Y)
+	if !(&CurvePoint{Curve: G.Curve, X: x, Y: y}).IsInfinity() {
+		t.Fatal("2G + (-2G) is not infinity")
+	}
+}
+
+func TestGeneratorCurve(t *testing.T) {
+	// 与按曲线参数手工构造的生成元B一致，nil表示SM2曲线
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		var B CurvePoint
+		B.Curve = curve
+		B.X = B.Curve.Params().Gx
+		B.Y = B.Curve.Params().Gy
+
+		G := Generator(curve)
+		if G.Curve != curve || 0 != G.X.Cmp(B.X) || 0 != G.Y.Cmp(B.Y) {
+			t.Fatalf("%s: generator differs from (Gx, Gy)", curve.Params().Name)
+		}
+		if err := CheckPoint(G); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if Generator(nil).Curve.Params() != sm2.P256Sm2().Params() {
+		t.Fatal("nil curve is not SM2")
+	}
+}
+
+func TestInfinity(t *testing.T) {
+	O := Infinity(nil)
+	if !O.IsInfinity() || !BaseMultiple(nil, 0).IsInfinity() {
+		t.Fatal("identity not reported as infinity")
+	}
+	if CheckPoint(O) == nil {
+		t.Fatal("infinity passed CheckPoint")
+	}
+	if Generator(nil).IsInfinity() {
+		t.Fatal("generator reported as infinity")
+	}
+	if O.String() != "CurvePoint(inf)" {
+		t.Fatalf("got %s", O)
+	}
+}
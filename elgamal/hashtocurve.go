@@ -0,0 +1,180 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// HashToPointSuite is the RFC 9380 suite identifier of HashToPoint.
+// HashToPoint的RFC 9380算法套件标识。
+const HashToPointSuite = "SM2_XMD:SM3_SSWU_RO_"
+
+// Parameters of hash_to_field for SM2: L = ceil((256 + 128) / 8) bytes per element,
+// and the input block size of SM3.
+// SM2的hash_to_field参数：每个域元素L = ceil((256 + 128) / 8)字节，以及SM3的输入分组长度。
+const (
+	h2cFieldLen  = 48
+	h2cBlockSize = 64
+	sm3Size      = 32
+)
+
+// h2cZ is the Z of the simplified SWU map for SM2, found by find_z_sswu of RFC 9380.
+// SM2简化SWU映射的参数Z，由RFC 9380中的find_z_sswu求得。
+var h2cZ = big.NewInt(-9)
+
+// HashToPoint hashes msg to an SM2 point under the domain separation tag domain,
+// following hash_to_curve of RFC 9380 with expand_message_xmd over SM3 and the
+// simplified SWU map (suite HashToPointSuite). Unlike try-and-increment, the number
+// of steps does not depend on the input, and the discrete logarithm of the result is
+// unknown, for deterministic point derivation, VRFs and blinding.
+// 哈希到曲线：按RFC 9380的hash_to_curve，以基于SM3的expand_message_xmd及简化SWU映射
+// （算法套件HashToPointSuite），在域分隔标签domain下将msg哈希为SM2点。与"尝试-递增"不同，
+// 运算步数与输入无关，且结果的离散对数未知，可用于确定性点派生、VRF及盲化。
+//
+// 参数：
+//		域分隔标签	domain
+//		消息		msg
+// 返回：
+// 		点
+func HashToPoint(domain, msg []byte) (*CurvePoint, error) {
+	if len(domain) == 0 {
+		return nil, opError("HashToPoint", ErrEmpty)
+	}
+
+	curve := sm2.P256Sm2()
+	P := curve.Params().P
+	uniform := expandMessageXMD(msg, domain, 2*h2cFieldLen)
+	u0 := new(big.Int).SetBytes(uniform[:h2cFieldLen])
+	u0.Mod(u0, P)
+	u1 := new(big.Int).SetBytes(uniform[h2cFieldLen:])
+	u1.Mod(u1, P)
+
+	// SM2余因子为1，无需清除
+	x0, y0 := mapToCurveSSWU(u0)
+	x1, y1 := mapToCurveSSWU(u1)
+	x, y := ec.Add(curve, x0, y0, x1, y1)
+
+	return NewCurvePointFromXY(curve, x, y)
+}
+
+// expandMessageXMD is expand_message_xmd of RFC 9380 with SM3.
+// RFC 9380中以SM3实现的expand_message_xmd。
+func expandMessageXMD(msg, dst []byte, length int) []byte {
+	if len(dst) > 255 {
+		h := sm3.New()
+		h.Write([]byte("H2C-OVERSIZE-DST-"))
+		h.Write(dst)
+		dst = h.Sum(nil)
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+	ell := (length + sm3Size - 1) / sm3Size
+
+	// b0 = H(Z_pad || msg || l_i_b_str || 0 || DST_prime)
+	h := sm3.New()
+	h.Write(make([]byte, h2cBlockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	// b1 = H(b0 || 1 || DST_prime)，bi = H((b0 xor b(i-1)) || i || DST_prime)
+	out := make([]byte, 0, ell*sm3Size)
+	bi := make([]byte, sm3Size)
+	for i := 1; i <= ell; i++ {
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		h := sm3.New()
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:length]
+}
+
+// mapToCurveSSWU is map_to_curve_simple_swu of RFC 9380 for SM2, whose A = -3 and
+// B are both nonzero, so no isogeny is needed.
+// RFC 9380中SM2的map_to_curve_simple_swu，SM2的A = -3与B均不为0，无需同源映射。
+func mapToCurveSSWU(u *big.Int) (*big.Int, *big.Int) {
+	curve := sm2.P256Sm2()
+	params := curve.Params()
+	P := params.P
+	A := new(big.Int).Sub(P, big.NewInt(3))
+	B := params.B
+	Z := new(big.Int).Mod(h2cZ, P)
+
+	// tv1 = inv0(Z^2 * u^4 + Z * u^2)
+	u2 := new(big.Int).Mul(u, u)
+	u2.Mod(u2, P)
+	Zu2 := new(big.Int).Mul(Z, u2)
+	Zu2.Mod(Zu2, P)
+	tv1 := new(big.Int).Mul(Zu2, Zu2)
+	tv1.Add(tv1, Zu2)
+	tv1.Mod(tv1, P)
+	if tv1.Sign() != 0 {
+		tv1.ModInverse(tv1, P)
+	}
+
+	// x1 = (-B / A) * (1 + tv1)，tv1为0时x1 = B / (Z * A)
+	invA := new(big.Int).ModInverse(A, P)
+	x1 := new(big.Int)
+	if tv1.Sign() == 0 {
+		ZA := new(big.Int).Mul(Z, A)
+		x1.Mul(B, ZA.ModInverse(ZA.Mod(ZA, P), P))
+	} else {
+		x1.Neg(B)
+		x1.Mul(x1, invA)
+		x1.Mod(x1, P)
+		x1.Mul(x1, tv1.Add(tv1, big.NewInt(1)))
+	}
+	x1.Mod(x1, P)
+	gx1 := sswuG(x1, A, B, P)
+
+	// x2 = Z * u^2 * x1
+	x2 := new(big.Int).Mul(Zu2, x1)
+	x2.Mod(x2, P)
+	gx2 := sswuG(x2, A, B, P)
+
+	x, y := x1, new(big.Int).ModSqrt(gx1, P)
+	if y == nil {
+		x, y = x2, new(big.Int).ModSqrt(gx2, P)
+	}
+
+	// sgn0(u) == sgn0(y)
+	if u.Bit(0) != y.Bit(0) {
+		y.Sub(P, y)
+		y.Mod(y, P)
+	}
+	return x, y
+}
+
+// sswuG returns x^3 + A*x + B mod P.
+// 返回x^3 + A*x + B模P。
+func sswuG(x, A, B, P *big.Int) *big.Int {
+	g := new(big.Int).Mul(x, x)
+	g.Add(g, A)
+	g.Mul(g, x)
+	g.Add(g, B)
+	return g.Mod(g, P)
+}
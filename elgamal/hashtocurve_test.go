@@ -0,0 +1,78 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestHashToPoint(t *testing.T) {
+	domain := []byte("QUUX-V01-CS02-with-SM2")
+
+	// 回归向量；expand_message_xmd已以SHA-256对照RFC 9380附录K.1验证
+	P, err := HashToPoint(domain, []byte("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if P.X.Text(16) != "cf8ea961ccaae9d3f73aee7ea44c94de8f3a1e9e06add7ae4c9dc47073417dc" ||
+		P.Y.Text(16) != "f3021b044cd63dc9ec0435e5858b781067da4b92d400f36e24ff4e2ad619a6fd" {
+		t.Fatalf("unexpected point %s", P)
+	}
+
+	// 确定性
+	Q, err := HashToPoint(domain, []byte("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != Q.X.Cmp(P.X) || 0 != Q.Y.Cmp(P.Y) {
+		t.Fatal("HashToPoint is not deterministic")
+	}
+
+	// 不同消息或不同域得到不同点
+	for _, in := range [][2]string{{"QUUX-V01-CS02-with-SM2", "abd"}, {"other-domain", "abc"}} {
+		R, err := HashToPoint([]byte(in[0]), []byte(in[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 == R.X.Cmp(P.X) {
+			t.Fatalf("%q/%q collides", in[0], in[1])
+		}
+	}
+
+	// 空消息与超长标签
+	if _, err := HashToPoint(make([]byte, 300), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HashToPoint(nil, []byte("abc")); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+}
+
+func TestMapToCurveSSWU(t *testing.T) {
+	// u = 0时走tv1 = 0的分支
+	P := Generator(nil).Curve.Params().P
+	for _, u := range []int64{0, 1, 2, 9} {
+		x, y := mapToCurveSSWU(big.NewInt(u))
+		if !Generator(nil).Curve.IsOnCurve(x, y) {
+			t.Fatalf("u=%d maps off the curve", u)
+		}
+		if x.Cmp(P) >= 0 || y.Cmp(P) >= 0 {
+			t.Fatalf("u=%d gives unreduced coordinates", u)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"ppks/internal/ec"
+)
+
+// CipherAdd returns a+b = (Ka+Kb, Ca+Cb). For ciphertexts under the same public key
+// it decrypts to the sum of the plaintext points, so values can be aggregated
+// before switching. Both ciphertexts are checked and must be on the same curve; a
+// result with a point at infinity fails with ErrPointNotOnCurve.
+// 密文加法：返回a+b = (Ka+Kb, Ca+Cb)。同一公钥下的密文相加后，解密结果为明文点之和，
+// 可先聚合再置换。两份密文均须有效且位于同一曲线上；结果含无穷远点时返回ErrPointNotOnCurve。
+//
+// 参数：
+//		密文	a,b
+// 返回：
+// 		密文a+b
+func CipherAdd(a, b *CipherText) (*CipherText, error) {
+	return cipherCombine("CipherAdd", a, b, false)
+}
+
+// CipherSub returns a-b = (Ka-Kb, Ca-Cb), which decrypts to the difference of the
+// plaintext points. The checks are those of CipherAdd.
+// 密文减法：返回a-b = (Ka-Kb, Ca-Cb)，解密结果为明文点之差。校验与CipherAdd相同。
+//
+// 参数：
+//		密文	a,b
+// 返回：
+// 		密文a-b
+func CipherSub(a, b *CipherText) (*CipherText, error) {
+	return cipherCombine("CipherSub", a, b, true)
+}
+
+// cipherCombine returns a+b, or a-b if sub is set.
+// 返回a+b，sub为true时返回a-b。
+func cipherCombine(op string, a, b *CipherText, sub bool) (*CipherText, error) {
+	if err := CheckCipherText(a); err != nil {
+		return nil, opError(op, err)
+	}
+	if err := CheckCipherText(b); err != nil {
+		return nil, opError(op, err)
+	}
+	curve := a.K.Curve
+	if !sameCurve(curve, b.K.Curve) || !sameCurve(curve, a.C.Curve) || !sameCurve(curve, b.C.Curve) {
+		return nil, opError(op, ErrCurveMismatch)
+	}
+
+	bK, bC := &b.K, &b.C
+	if sub {
+		neg := GetCipherText()
+		defer PutCipherText(neg)
+		NegPointTo(&neg.K, bK)
+		NegPointTo(&neg.C, bC)
+		bK, bC = &neg.K, &neg.C
+	}
+
+	var ct CipherText
+	ct.K.Curve = curve
+	ct.K.X, ct.K.Y = ec.Add(curve, a.K.X, a.K.Y, bK.X, bK.Y)
+	ct.C.Curve = curve
+	ct.C.X, ct.C.Y = ec.Add(curve, a.C.X, a.C.Y, bC.X, bC.Y)
+	if err := CheckCipherText(&ct); err != nil {
+		return nil, opError(op, err)
+	}
+
+	return &ct, nil
+}
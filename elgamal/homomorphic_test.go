@@ -0,0 +1,84 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCipherAddSub(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M1, M2 := GenPoint(), GenPoint()
+	ct1, err := PointEncrypt(&priv.PublicKey, M1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct2, err := PointEncrypt(&priv.PublicKey, M2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := CipherAdd(ct1, ct2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D, err := PointDecrypt(sum, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := ec.Add(priv.Curve, M1.X, M1.Y, M2.X, M2.Y)
+	if 0 != D.X.Cmp(x) || 0 != D.Y.Cmp(y) {
+		t.Fatal("sum decrypts to the wrong point")
+	}
+
+	diff, err := CipherSub(sum, ct2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D, err = PointDecrypt(diff, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(M1.X) || 0 != D.Y.Cmp(M1.Y) {
+		t.Fatal("difference decrypts to the wrong point")
+	}
+
+	// 相同密文相加等同于倍点
+	if _, err := CipherAdd(ct1, ct1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CipherSub(ct1, ct1); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+
+	p256, err := PointEncrypt(&sm2.PublicKey{Curve: elliptic.P256(), X: elliptic.P256().Params().Gx, Y: elliptic.P256().Params().Gy}, Generator(elliptic.P256()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CipherAdd(ct1, p256); !errors.Is(err, ErrCurveMismatch) {
+		t.Fatalf("got %v, want ErrCurveMismatch", err)
+	}
+}
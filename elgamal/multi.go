@@ -0,0 +1,99 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// MultiCipherText is one point encrypted for several recipients with shared
+// randomness: a single left point K=rB and one right point C[i]=D+r*pubs[i] per
+// recipient. Recipient i's part is the ordinary ciphertext {K, C[i]}.
+// 多接收者密文：以共享随机数为多个接收者加密同一点，仅含一个左侧点K=rB，
+// 每个接收者一个右侧点C[i]=D+r*pubs[i]。接收者i的部分即普通密文{K, C[i]}。
+type MultiCipherText struct {
+	K CurvePoint
+	C PointVector
+}
+
+// MultiEncrypt encrypts D for every key in pubs with one random r, costing one
+// base multiplication plus one scalar multiplication per recipient instead of two,
+// and sending K only once. Sharing r is safe as long as the recipients' keys are
+// independently generated; the keys must be distinct.
+// 多接收者加密：以同一随机数r为pubs中的每个公钥加密点D，仅需一次基点数乘，每个接收者再加一次
+// 数乘（独立加密需两次），且K只需传输一次。接收者公钥相互独立生成时共享r是安全的，公钥不可重复。
+//
+// 参数：
+//		公钥slice	pubs
+//		待加密点	D
+// 返回：
+// 		多接收者密文
+func MultiEncrypt(pubs []*sm2.PublicKey, D *CurvePoint) (*MultiCipherText, error) {
+	if len(pubs) == 0 {
+		return nil, opError("MultiEncrypt", ErrEmpty)
+	}
+	if err := CheckPoint(D); err != nil {
+		return nil, opError("MultiEncrypt", err)
+	}
+	for i, pub := range pubs {
+		if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+			return nil, itemError("MultiEncrypt", "key", i, err)
+		}
+		if !sameCurve(pub.Curve, D.Curve) {
+			return nil, itemError("MultiEncrypt", "key", i, ErrCurveMismatch)
+		}
+	}
+
+	curve := D.Curve
+	r, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, opError("MultiEncrypt", err)
+	}
+	rBytes := r.Bytes()
+
+	// 左侧点K=rB，所有接收者共用
+	mct := &MultiCipherText{C: make(PointVector, len(pubs))}
+	mct.K.Curve = curve
+	mct.K.X, mct.K.Y = curve.ScalarBaseMult(rBytes)
+
+	// 右侧点C[i]=D+r*pubs[i]
+	for i, pub := range pubs {
+		rKx, rKy := ScalarMult((*CurvePoint)(pub), rBytes)
+		mct.C[i].Curve = curve
+		mct.C[i].X, mct.C[i].Y = ec.Add(curve, rKx, rKy, D.X, D.Y)
+	}
+
+	return mct, nil
+}
+
+// CipherText returns recipient i's part of m as an ordinary ciphertext, which can
+// be decrypted with PointDecrypt or key switched like any other.
+// 返回m中接收者i的部分，即普通密文，可使用PointDecrypt解密，也可如其他密文一样进行密钥置换。
+//
+// 参数：
+//		接收者下标	i
+// 返回：
+// 		密文
+func (m *MultiCipherText) CipherText(i int) (*CipherText, error) {
+	if i < 0 || i >= len(m.C) {
+		return nil, opError("MultiCipherText.CipherText", ErrOutOfRange)
+	}
+	return &CipherText{K: m.K, C: m.C[i]}, nil
+}
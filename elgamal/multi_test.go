@@ -0,0 +1,78 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestMultiEncrypt(t *testing.T) {
+	n := 4
+	privs := make([]*sm2.PrivateKey, n)
+	pubs := make([]*sm2.PublicKey, n)
+	for i := range privs {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i], pubs[i] = priv, &priv.PublicKey
+	}
+	D := GenPoint()
+
+	mct, err := MultiEncrypt(pubs, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mct.C) != n {
+		t.Fatalf("got %d right points, want %d", len(mct.C), n)
+	}
+
+	// 每个接收者均能以普通解密得到D
+	for i, priv := range privs {
+		ct, err := mct.CipherText(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		M, err := PointDecrypt(ct, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != M.X.Cmp(D.X) || 0 != M.Y.Cmp(D.Y) {
+			t.Fatalf("recipient %d: decrypted point differs", i)
+		}
+	}
+
+	// 其他接收者的私钥无法解密
+	ct, _ := mct.CipherText(0)
+	if M, _ := PointDecrypt(ct, privs[1]); M != nil && 0 == M.X.Cmp(D.X) {
+		t.Fatal("wrong key decrypted the ciphertext")
+	}
+
+	if _, err := mct.CipherText(n); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("got %v, want ErrOutOfRange", err)
+	}
+	if _, err := MultiEncrypt(nil, D); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+	bad := []*sm2.PublicKey{pubs[0], {Curve: pubs[0].Curve, X: D.X, Y: D.X}}
+	if _, err := MultiEncrypt(bad, D); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
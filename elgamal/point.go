@@ -0,0 +1,253 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Point encoding prefixes (SEC 1).
+// 点编码前缀（SEC 1）。
+const (
+	pointCompressedEven = 0x02
+	pointCompressedOdd  = 0x03
+	pointUncompressed   = 0x04
+)
+
+// NewCurvePointFromXY returns the point (x,y) on curve after checking that it is a
+// finite point on the curve. A nil curve selects SM2.
+// 由坐标构造点：校验(x,y)为曲线curve上的有限点后返回，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+//		坐标	x,y
+// 返回：
+// 		点
+func NewCurvePointFromXY(curve elliptic.Curve, x, y *big.Int) (*CurvePoint, error) {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+	if !ec.IsValidXY(curve, x, y) {
+		return nil, opError("NewCurvePointFromXY", ErrPointNotOnCurve)
+	}
+
+	var point CurvePoint
+	point.Curve = curve
+	point.X = new(big.Int).Set(x)
+	point.Y = new(big.Int).Set(y)
+
+	return &point, nil
+}
+
+// NewCurvePointFromBytes decodes a point on curve from its SEC 1 encoding, either
+// uncompressed (0x04||X||Y) or compressed (0x02/0x03||X). A nil curve selects SM2.
+// 由字节构造点：解析SEC 1格式的点编码（非压缩0x04||X||Y或压缩0x02/0x03||X），
+// 校验后返回，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+//		编码	b
+// 返回：
+// 		点
+func NewCurvePointFromBytes(curve elliptic.Curve, b []byte) (*CurvePoint, error) {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+	byteLen := (curve.Params().BitSize + 7) / 8
+	if len(b) == 0 {
+		return nil, opError("NewCurvePointFromBytes", ErrInvalidPointEncoding)
+	}
+
+	var x, y *big.Int
+
+	switch b[0] {
+	case pointUncompressed:
+		if len(b) != 1+2*byteLen {
+			return nil, opError("NewCurvePointFromBytes", ErrInvalidPointEncoding)
+		}
+		x = new(big.Int).SetBytes(b[1 : 1+byteLen])
+		y = new(big.Int).SetBytes(b[1+byteLen:])
+	case pointCompressedEven, pointCompressedOdd:
+		if len(b) != 1+byteLen {
+			return nil, opError("NewCurvePointFromBytes", ErrInvalidPointEncoding)
+		}
+		x = new(big.Int).SetBytes(b[1:])
+		if x.Cmp(curve.Params().P) >= 0 {
+			return nil, opError("NewCurvePointFromBytes", ErrPointNotOnCurve)
+		}
+		var ok bool
+		y, ok = ec.LiftX(curve, x, b[0] == pointCompressedOdd)
+		if !ok {
+			return nil, opError("NewCurvePointFromBytes", ErrPointNotOnCurve)
+		}
+	default:
+		return nil, opError("NewCurvePointFromBytes", ErrInvalidPointEncoding)
+	}
+
+	if !ec.IsValidXY(curve, x, y) {
+		return nil, opError("NewCurvePointFromBytes", ErrPointNotOnCurve)
+	}
+	var point CurvePoint
+	point.Curve = curve
+	point.X, point.Y = x, y
+
+	return &point, nil
+}
+
+// MustParseCurvePoint decodes a hex SEC 1 encoded SM2 point and panics on error.
+// It is intended for tests and fixed constants.
+// 解析十六进制编码的SM2点，出错时panic，用于测试及常量。
+func MustParseCurvePoint(s string) *CurvePoint {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	point, err := NewCurvePointFromBytes(nil, b)
+	if err != nil {
+		panic(err)
+	}
+	return point
+}
+
+// GenPointFromSeed maps seed to a curve point deterministically by try-and-increment
+// hashing, so the same content identifier always yields the same document key point.
+// 由种子生成点：以"尝试-递增"方式将种子seed哈希到曲线上，确定性地生成一个点并返回，
+// 相同的内容标识总是得到相同的文档密钥点。
+//
+// 参数：
+//		种子	seed
+// 返回：
+// 		点
+func GenPointFromSeed(seed []byte) (*CurvePoint, error) {
+	if len(seed) == 0 {
+		return nil, opError("GenPointFromSeed", ErrEmpty)
+	}
+
+	curve := sm2.P256Sm2()
+	P := curve.Params().P
+	byteLen := (curve.Params().BitSize + 7) / 8
+
+	// 候选横坐标x = KDF(label || seed || ctr)，最后一字节决定纵坐标奇偶性
+	for ctr := 0; ctr < 256; ctr++ {
+		h := ec.KDF(byteLen+1, []byte("ppks-point-seed"), seed, []byte{byte(ctr)})
+		x := new(big.Int).SetBytes(h[:byteLen])
+		if x.Cmp(P) >= 0 {
+			continue
+		}
+		y, ok := ec.LiftX(curve, x, h[byteLen]&1 == 1)
+		if !ok {
+			continue
+		}
+		return NewCurvePointFromXY(curve, x, y)
+	}
+
+	return nil, opError("GenPointFromSeed", ErrNoPointFound)
+}
+
+// Bytes returns the uncompressed SEC 1 encoding 0x04||X||Y of the point.
+// 返回点的SEC 1非压缩编码0x04||X||Y。
+func (p *CurvePoint) Bytes() []byte {
+	return p.AppendBytes(nil)
+}
+
+// AppendBytes appends the uncompressed encoding of Bytes to dst and returns the
+// extended slice, reusing the capacity of dst.
+// 将Bytes的非压缩编码追加到dst并返回扩展后的切片，复用dst的容量。
+func (p *CurvePoint) AppendBytes(dst []byte) []byte {
+	byteLen := (p.Curve.Params().BitSize + 7) / 8
+	n := len(dst) + 1 + 2*byteLen
+	if cap(dst) < n {
+		grown := make([]byte, len(dst), n)
+		copy(grown, dst)
+		dst = grown
+	}
+	b := dst[len(dst):n]
+	b[0] = pointUncompressed
+	p.X.FillBytes(b[1 : 1+byteLen])
+	p.Y.FillBytes(b[1+byteLen:])
+	return dst[:n]
+}
+
+// CompressedBytes returns the compressed SEC 1 encoding 0x02/0x03||X of the point.
+// 返回点的SEC 1压缩编码0x02/0x03||X。
+func (p *CurvePoint) CompressedBytes() []byte {
+	byteLen := (p.Curve.Params().BitSize + 7) / 8
+	b := make([]byte, 1+byteLen)
+	b[0] = pointCompressedEven
+	if p.Y.Bit(0) == 1 {
+		b[0] = pointCompressedOdd
+	}
+	p.X.FillBytes(b[1:])
+	return b
+}
+
+// NegPoint returns -p, the point (x, P-y), after checking that p is a finite point
+// on its curve.
+// 点取负：校验p为其曲线上的有限点后，返回点-p，即(x, P-y)。
+//
+// 参数：
+//		点	p
+// 返回：
+// 		点-p
+func NegPoint(p *CurvePoint) (*CurvePoint, error) {
+	if err := CheckPoint(p); err != nil {
+		return nil, opError("NegPoint", err)
+	}
+
+	var neg CurvePoint
+	neg.Curve = p.Curve
+	neg.X, neg.Y = ec.Neg(p.Curve, p.X, p.Y)
+
+	return &neg, nil
+}
+
+// CheckPoint reports ErrPointNotOnCurve unless p is a finite point on its curve.
+// 校验p为其曲线上的有限点，否则返回ErrPointNotOnCurve。
+func CheckPoint(p *CurvePoint) error {
+	if p == nil || p.Curve == nil || !ec.IsValidXY(p.Curve, p.X, p.Y) {
+		return ErrPointNotOnCurve
+	}
+	return nil
+}
+
+// sameCurve reports whether a and b are the same curve.
+// 判断a与b是否为同一曲线。
+func sameCurve(a, b elliptic.Curve) bool {
+	if a == b {
+		return true
+	}
+	pa, pb := a.Params(), b.Params()
+	return pa.Name == pb.Name && pa.P.Cmp(pb.P) == 0 && pa.N.Cmp(pb.N) == 0 &&
+		pa.Gx.Cmp(pb.Gx) == 0 && pa.Gy.Cmp(pb.Gy) == 0
+}
+
+// CheckCipherText reports ErrPointNotOnCurve unless both points of ct are valid.
+// 校验密文ct的两个点均有效。
+func CheckCipherText(ct *CipherText) error {
+	if ct == nil {
+		return ErrPointNotOnCurve
+	}
+	if err := CheckPoint(&ct.K); err != nil {
+		return err
+	}
+	return CheckPoint(&ct.C)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestNewCurvePointFromXY(t *testing.T) {
+	D := GenPoint()
+
+	p, err := NewCurvePointFromXY(nil, D.X, D.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != p.X.Cmp(D.X) || 0 != p.Y.Cmp(D.Y) || p.Curve != D.Curve {
+		t.Fatal("constructed point differs")
+	}
+
+	// 纵坐标加一后不在曲线上
+	badY := new(big.Int).Add(D.Y, big.NewInt(1))
+	if _, err := NewCurvePointFromXY(D.Curve, D.X, badY); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+	// 无穷远点与越界坐标
+	if _, err := NewCurvePointFromXY(nil, new(big.Int), new(big.Int)); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+	outX := new(big.Int).Add(D.X, D.Curve.Params().P)
+	if _, err := NewCurvePointFromXY(nil, outX, D.Y); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
+
+func TestNewCurvePointFromBytes(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		D := GenPoint()
+
+		// 非压缩编码
+		p, err := NewCurvePointFromBytes(nil, D.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != p.X.Cmp(D.X) || 0 != p.Y.Cmp(D.Y) {
+			t.Fatal("uncompressed round trip failed")
+		}
+
+		// 压缩编码
+		p, err = NewCurvePointFromBytes(nil, D.CompressedBytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != p.X.Cmp(D.X) || 0 != p.Y.Cmp(D.Y) {
+			t.Fatal("compressed round trip failed")
+		}
+	}
+
+	D := GenPoint()
+	b := D.Bytes()
+	if _, err := NewCurvePointFromBytes(nil, b[:len(b)-1]); !errors.Is(err, ErrInvalidPointEncoding) {
+		t.Fatalf("got %v, want ErrInvalidPointEncoding", err)
+	}
+	b[0] = 0x05
+	if _, err := NewCurvePointFromBytes(nil, b); !errors.Is(err, ErrInvalidPointEncoding) {
+		t.Fatalf("got %v, want ErrInvalidPointEncoding", err)
+	}
+	if _, err := NewCurvePointFromBytes(nil, nil); !errors.Is(err, ErrInvalidPointEncoding) {
+		t.Fatalf("got %v, want ErrInvalidPointEncoding", err)
+	}
+}
+
+func TestMustParseCurvePoint(t *testing.T) {
+	// SM2生成元
+	G := MustParseCurvePoint("04" +
+		"32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7" +
+		"BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0")
+	if 0 != G.X.Cmp(G.Curve.Params().Gx) || 0 != G.Y.Cmp(G.Curve.Params().Gy) {
+		t.Fatal("parsed generator differs from curve parameters")
+	}
+
+	Gc := MustParseCurvePoint(hex.EncodeToString(G.CompressedBytes()))
+	if 0 != Gc.Y.Cmp(G.Y) {
+		t.Fatal("compressed generator differs")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on malformed input")
+		}
+	}()
+	MustParseCurvePoint("04zz")
+}
+
+func TestGenPointFromSeed(t *testing.T) {
+	p1, err := GenPointFromSeed([]byte("doc:sha256:0f1e2d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := GenPointFromSeed([]byte("doc:sha256:0f1e2d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != p1.X.Cmp(p2.X) || 0 != p1.Y.Cmp(p2.Y) {
+		t.Fatal("same seed gave different points")
+	}
+	if !p1.Curve.IsOnCurve(p1.X, p1.Y) {
+		t.Fatal("seed point not on curve")
+	}
+
+	p3, err := GenPointFromSeed([]byte("doc:sha256:0f1e2e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 == p1.X.Cmp(p3.X) {
+		t.Fatal("different seeds gave the same point")
+	}
+
+	if _, err := GenPointFromSeed(nil); err == nil {
+		t.Fatal("expected error on empty seed")
+	}
+}
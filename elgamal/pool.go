@@ -0,0 +1,148 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"ppks/internal/ec"
+)
+
+// pooling is 1 while GetPoint and GetCipherText draw from the pools.
+// GetPoint与GetCipherText从池中取值时为1。
+var pooling int32 = 1
+
+var (
+	pointPool  = sync.Pool{New: func() interface{} { return newScratchPoint() }}
+	cipherPool = sync.Pool{New: func() interface{} { return newScratchCipherText() }}
+)
+
+// SetPooling enables or disables the pools of scratch points and ciphertexts
+// used by batch operations, and returns the previous setting. Pooling is on by
+// default; turning it off makes every scratch value a fresh allocation, which is
+// easier to reason about when profiling or hunting aliasing bugs.
+// 开关批量运算所用临时点与临时密文的对象池，返回此前的设置。默认开启；关闭后每个临时值都重新
+// 分配，便于性能分析或排查别名问题。
+//
+// 参数：
+//		是否启用	enabled
+// 返回：
+// 		此前的设置
+func SetPooling(enabled bool) bool {
+	v := int32(0)
+	if enabled {
+		v = 1
+	}
+	return atomic.SwapInt32(&pooling, v) == 1
+}
+
+// GetPoint returns a scratch point whose X and Y are zero integers owned by the
+// point, from the pool unless pooling is off. Fill it through X.Set and Y.Set,
+// never by pointing X or Y at an integer owned elsewhere, and hand it back with
+// PutPoint once nothing refers to it: PutPoint zeroes its integers and the next
+// GetPoint reuses them.
+// 取临时点：返回X、Y为该点自有的零值整数的点，启用对象池时取自池中。应通过X.Set、Y.Set赋值，
+// 切勿令X或Y指向他处所有的整数；不再被引用后以PutPoint归还：PutPoint将其整数清零，下一次
+// GetPoint复用之。
+func GetPoint() *CurvePoint {
+	if atomic.LoadInt32(&pooling) == 0 {
+		return newScratchPoint()
+	}
+	return pointPool.Get().(*CurvePoint)
+}
+
+// PutPoint returns a point from GetPoint to the pool; it does nothing when
+// pooling is off.
+// 归还GetPoint取得的临时点；对象池关闭时不做任何事。
+func PutPoint(p *CurvePoint) {
+	if p == nil || atomic.LoadInt32(&pooling) == 0 {
+		return
+	}
+	resetScratchPoint(p)
+	pointPool.Put(p)
+}
+
+// GetCipherText returns a scratch ciphertext whose four coordinates are zero
+// integers owned by it, on the terms of GetPoint.
+// 取临时密文：四个坐标均为该密文自有的零值整数，使用约定同GetPoint。
+func GetCipherText() *CipherText {
+	if atomic.LoadInt32(&pooling) == 0 {
+		return newScratchCipherText()
+	}
+	return cipherPool.Get().(*CipherText)
+}
+
+// PutCipherText returns a ciphertext from GetCipherText to the pool; it does
+// nothing when pooling is off.
+// 归还GetCipherText取得的临时密文；对象池关闭时不做任何事。
+func PutCipherText(ct *CipherText) {
+	if ct == nil || atomic.LoadInt32(&pooling) == 0 {
+		return
+	}
+	resetScratchPoint(&ct.K)
+	resetScratchPoint(&ct.C)
+	cipherPool.Put(ct)
+}
+
+// NegPointTo sets dst to -p, reusing the integers of dst, which must be non-nil
+// and may be those of p.
+// 令dst为-p，复用dst的整数；dst的坐标须非nil，可与p的相同。
+//
+// 参数：
+//		结果点	dst
+//		点		p
+// 返回：
+// 		错误
+func NegPointTo(dst, p *CurvePoint) error {
+	if err := CheckPoint(p); err != nil {
+		return opError("NegPointTo", err)
+	}
+	dst.Curve = p.Curve
+	ec.NegTo(p.Curve, dst.X, dst.Y, p.X, p.Y)
+	return nil
+}
+
+// newScratchPoint returns the zero point with its own integers.
+// 返回带自有整数的零值点。
+func newScratchPoint() *CurvePoint {
+	return &CurvePoint{X: new(big.Int), Y: new(big.Int)}
+}
+
+// newScratchCipherText returns the zero ciphertext with its own integers.
+// 返回带自有整数的零值密文。
+func newScratchCipherText() *CipherText {
+	return &CipherText{
+		K: CurvePoint{X: new(big.Int), Y: new(big.Int)},
+		C: CurvePoint{X: new(big.Int), Y: new(big.Int)},
+	}
+}
+
+// resetScratchPoint zeroes p before it goes back to a pool, reallocating an
+// integer a caller set to nil.
+// 归还前将p清零；调用方置为nil的整数重新分配。
+func resetScratchPoint(p *CurvePoint) {
+	p.Curve = nil
+	if p.X == nil {
+		p.X = new(big.Int)
+	}
+	if p.Y == nil {
+		p.Y = new(big.Int)
+	}
+	p.X.SetInt64(0)
+	p.Y.SetInt64(0)
+}
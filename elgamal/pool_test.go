@@ -0,0 +1,67 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		prev := SetPooling(enabled)
+		P := GenPoint()
+
+		neg := GetPoint()
+		if neg.Curve != nil || neg.X.Sign() != 0 || neg.Y.Sign() != 0 {
+			t.Fatalf("pooling %v: scratch point not zero", enabled)
+		}
+		if err := NegPointTo(neg, P); err != nil {
+			t.Fatal(err)
+		}
+		want, err := NegPoint(P)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if neg.X.Cmp(want.X) != 0 || neg.Y.Cmp(want.Y) != 0 {
+			t.Fatalf("pooling %v: NegPointTo differs from NegPoint", enabled)
+		}
+		PutPoint(neg)
+		// 归还后取回的点重新清零
+		if again := GetPoint(); again.Curve != nil || again.X.Sign() != 0 || again.Y.Sign() != 0 {
+			t.Fatalf("pooling %v: reused point not zero", enabled)
+		}
+
+		ct := GetCipherText()
+		if ct.K.X.Sign() != 0 || ct.C.Y.Sign() != 0 {
+			t.Fatalf("pooling %v: scratch ciphertext not zero", enabled)
+		}
+		ct.K.Curve = P.Curve
+		ct.K.X.Set(P.X)
+		PutCipherText(ct)
+		// P自身的坐标不受归还影响
+		if P.X.Sign() == 0 {
+			t.Fatalf("pooling %v: caller point zeroed", enabled)
+		}
+
+		if err := NegPointTo(GetPoint(), &CurvePoint{}); !errors.Is(err, ErrPointNotOnCurve) {
+			t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+		}
+		if SetPooling(prev) != enabled {
+			t.Fatal("SetPooling did not report the previous setting")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"math/big"
+	"sync"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// fixedBases holds the tables registered by Precompute, keyed by pointKey.
+// Precompute注册的固定基点表，以pointKey为键。
+var fixedBases sync.Map
+
+// Precompute builds a fixed-base table for pub, after which every multiplication
+// of pub by a scalar in this module, such as the rP of PointEncrypt or the riU of
+// keyswitch.ShareCal, is about ten times faster. The table is read in constant
+// time, like the curve's own multiplication, so secret scalars such as the
+// encryption randomness r may go through it. Building takes about as long as 7
+// ordinary multiplications, so register long-lived keys that encrypt or receive
+// often, such as a collective public key. Multiplications of the generator need
+// no table: the SM2 curve already has one built in.
+// 预计算公钥pub的固定基点表：此后本模块中以标量乘pub的运算（如PointEncrypt中的rP、
+// keyswitch.ShareCal中的riU）约快10倍。与曲线自身的标量乘相同，查表为常数时间，加密随机数r等
+// 秘密标量可使用该表。建表耗时约与7次普通标量乘相当，适用于频繁加密或接收的长期公钥，如聚合公钥。
+// 生成元的标量乘无需预计算：SM2曲线已内置其表。
+//
+// 参数：
+//		公钥	pub
+// 返回：
+// 		错误
+func Precompute(pub *sm2.PublicKey) error {
+	if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+		return opError("Precompute", err)
+	}
+	key := pointKey(pub.X, pub.Y)
+	if t, ok := fixedBases.Load(key); ok && t.(*ec.FixedBase).Curve().Params() == pub.Curve.Params() {
+		return nil
+	}
+	fixedBases.Store(key, ec.NewFixedBase(pub.Curve, pub.X, pub.Y))
+	return nil
+}
+
+// Forget drops the table Precompute built for pub, if any.
+// 删除Precompute为公钥pub建立的固定基点表。
+//
+// 参数：
+//		公钥	pub
+func Forget(pub *sm2.PublicKey) {
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return
+	}
+	fixedBases.Delete(pointKey(pub.X, pub.Y))
+}
+
+// ScalarMult returns k*P for the big-endian scalar k, like elliptic.Curve's
+// ScalarMult but faster for fixed bases: the generator goes through the built-in
+// table of ScalarBaseMult, a point registered by Precompute through its table, and
+// any other point through the curve's ScalarMult.
+// 标量乘：返回大端序标量k与点P之积，与elliptic.Curve的ScalarMult相同，但对固定基点更快：
+// 生成元使用ScalarBaseMult的内置表，已由Precompute注册的点使用其表，其他点使用曲线自身的标量乘。
+//
+// 参数：
+//		点		P
+//		标量	k
+// 返回：
+// 		点坐标	x,y
+func ScalarMult(P *CurvePoint, k []byte) (*big.Int, *big.Int) {
+	params := P.Curve.Params()
+	if P.X.Cmp(params.Gx) == 0 && P.Y.Cmp(params.Gy) == 0 {
+		return P.Curve.ScalarBaseMult(k)
+	}
+	if t, ok := fixedBases.Load(pointKey(P.X, P.Y)); ok {
+		if fb := t.(*ec.FixedBase); fb.Curve().Params() == params {
+			if x, y := fb.Point(); x.Cmp(P.X) == 0 && y.Cmp(P.Y) == 0 {
+				return fb.ScalarMult(k)
+			}
+		}
+	}
+	return P.Curve.ScalarMult(P.X, P.Y, k)
+}
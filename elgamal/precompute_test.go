@@ -0,0 +1,116 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestPrecompute(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	if err := Precompute(pub); err != nil {
+		t.Fatal(err)
+	}
+	defer Forget(pub)
+	// 重复注册不重建
+	if err := Precompute(pub); err != nil {
+		t.Fatal(err)
+	}
+
+	curve := pub.Curve
+	N := curve.Params().N
+	scalars := [][]byte{
+		{},
+		{0, 0, 1},
+		{255},
+		new(big.Int).Sub(N, big.NewInt(1)).Bytes(),
+		N.Bytes(),
+		append([]byte{1}, make([]byte, 32)...),
+	}
+	for i := 0; i < 20; i++ {
+		k, err := RandScalar(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scalars = append(scalars, k.Bytes())
+	}
+	other := GenPoint()
+	for _, k := range scalars {
+		for _, P := range []*CurvePoint{(*CurvePoint)(pub), Generator(curve), other} {
+			x, y := ScalarMult(P, k)
+			wx, wy := curve.ScalarMult(P.X, P.Y, k)
+			if x.Cmp(wx) != 0 || y.Cmp(wy) != 0 {
+				t.Fatalf("ScalarMult(%x) differs from the curve", k)
+			}
+		}
+	}
+
+	// 预计算后的加密与重新随机化仍可正确解密
+	M := GenPoint()
+	ct, err := PointEncrypt(pub, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct, err = Rerandomize(pub, ct); err != nil {
+		t.Fatal(err)
+	}
+	got, err := PointDecrypt(ct, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.X.Cmp(M.X) != 0 || got.Y.Cmp(M.Y) != 0 {
+		t.Fatal("decryption after Precompute failed")
+	}
+
+	Forget(pub)
+	x, y := ScalarMult((*CurvePoint)(pub), scalars[6])
+	wx, wy := curve.ScalarMult(pub.X, pub.Y, scalars[6])
+	if x.Cmp(wx) != 0 || y.Cmp(wy) != 0 {
+		t.Fatal("ScalarMult after Forget differs from the curve")
+	}
+
+	if err := Precompute(&sm2.PublicKey{Curve: curve, X: big.NewInt(1), Y: big.NewInt(1)}); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
+
+func BenchmarkScalarMultPrecomputed(b *testing.B) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	if err := Precompute(pub); err != nil {
+		b.Fatal(err)
+	}
+	defer Forget(pub)
+	P := (*CurvePoint)(pub)
+	k := priv.D.Bytes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ScalarMult(P, k)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Rerandomize returns ct, encrypted under pub, with fresh randomness s:
+// (K + sB, C + s*pub). It decrypts to the same point but cannot be linked to ct,
+// e.g. when an encrypted key is forwarded through several services.
+// 重新随机化：以新随机数s将公钥pub下的密文ct刷新为(K + sB, C + s*pub)并返回。
+// 解密结果不变，但无法与ct关联，用于加密密钥经多个服务转发等场景。
+//
+// 参数：
+//		公钥	pub
+//		密文	ct
+// 返回：
+// 		新密文
+func Rerandomize(pub *sm2.PublicKey, ct *CipherText) (*CipherText, error) {
+	if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, opError("Rerandomize", err)
+	}
+	if err := CheckCipherText(ct); err != nil {
+		return nil, opError("Rerandomize", err)
+	}
+
+	s, err := ec.RandFieldElement(pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, opError("Rerandomize", err)
+	}
+	return rerandomize(pub, ct, s), nil
+}
+
+// RerandomizeWith is Rerandomize with the given randomness s, for protocols that
+// must later reveal or prove s, such as verifiable shuffles.
+// 以给定随机数s重新随机化：同Rerandomize，但使用给定的s，用于之后需要公开或证明s的协议，如可验证混洗。
+//
+// 参数：
+//		公钥	pub
+//		密文	ct
+//		随机数	s
+// 返回：
+// 		新密文
+func RerandomizeWith(pub *sm2.PublicKey, ct *CipherText, s *big.Int) (*CipherText, error) {
+	if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, opError("RerandomizeWith", err)
+	}
+	if err := CheckCipherText(ct); err != nil {
+		return nil, opError("RerandomizeWith", err)
+	}
+	if s == nil {
+		return nil, opError("RerandomizeWith", ErrEmpty)
+	}
+	return rerandomize(pub, ct, new(big.Int).Mod(s, pub.Curve.Params().N)), nil
+}
+
+// rerandomize returns (K + sB, C + s*pub) for checked inputs.
+// 对已校验的输入返回(K + sB, C + s*pub)。
+func rerandomize(pub *sm2.PublicKey, ct *CipherText, s *big.Int) *CipherText {
+	curve := pub.Curve
+	var out CipherText
+	out.K.Curve = curve
+	out.C.Curve = curve
+	if s.Sign() == 0 {
+		out.K.X, out.K.Y = new(big.Int).Set(ct.K.X), new(big.Int).Set(ct.K.Y)
+		out.C.X, out.C.Y = new(big.Int).Set(ct.C.X), new(big.Int).Set(ct.C.Y)
+		return &out
+	}
+	sBx, sBy := curve.ScalarBaseMult(s.Bytes())
+	out.K.X, out.K.Y = ec.Add(curve, ct.K.X, ct.K.Y, sBx, sBy)
+	sPx, sPy := ScalarMult((*CurvePoint)(pub), s.Bytes())
+	out.C.X, out.C.Y = ec.Add(curve, ct.C.X, ct.C.Y, sPx, sPy)
+	return &out
+}
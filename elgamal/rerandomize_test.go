@@ -0,0 +1,97 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestRerandomize(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := GenPoint()
+	ct, err := PointEncrypt(&priv.PublicKey, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct2, err := Rerandomize(&priv.PublicKey, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 == ct2.K.X.Cmp(ct.K.X) || 0 == ct2.C.X.Cmp(ct.C.X) {
+		t.Fatal("ciphertext not refreshed")
+	}
+	D, err := PointDecrypt(ct2, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(M.X) || 0 != D.Y.Cmp(M.Y) {
+		t.Fatal("rerandomized ciphertext decrypts to another point")
+	}
+
+	if _, err := Rerandomize(&priv.PublicKey, &CipherText{}); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
+
+func TestRerandomizeWith(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := GenPoint()
+	ct, err := PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 相同随机数得到相同结果，随机数为0时密文不变
+	s := big.NewInt(7)
+	a, err := RerandomizeWith(&priv.PublicKey, ct, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RerandomizeWith(&priv.PublicKey, ct, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.K.X.Cmp(b.K.X) != 0 || a.C.X.Cmp(b.C.X) != 0 || a.K.X.Cmp(ct.K.X) == 0 {
+		t.Fatal("unexpected re-randomization")
+	}
+	z, err := RerandomizeWith(&priv.PublicKey, ct, new(big.Int))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.K.X.Cmp(ct.K.X) != 0 || z.C.Y.Cmp(ct.C.Y) != 0 {
+		t.Fatal("zero randomness changed the ciphertext")
+	}
+
+	got, err := PointDecrypt(a, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.X.Cmp(D.X) != 0 {
+		t.Fatal("re-randomized ciphertext decrypts differently")
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// RandScalar returns a random scalar in [1, N-1], N being the order of the base
+// point of curve. A nil curve selects SM2 and a nil random uses crypto/rand.
+// 生成随机标量：在曲线curve生成元的阶N内生成[1, N-1]中的随机数并返回。
+// curve为nil时使用SM2曲线，random为nil时使用crypto/rand。
+//
+// 参数：
+//		曲线		curve
+//		随机源		random
+// 返回：
+// 		随机标量
+func RandScalar(curve elliptic.Curve, random io.Reader) (*big.Int, error) {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	k, err := ec.RandFieldElement(curve, random)
+	if err != nil {
+		return nil, opError("RandScalar", err)
+	}
+	return k, nil
+}
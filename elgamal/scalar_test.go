@@ -0,0 +1,68 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestRandScalar(t *testing.T) {
+	N := sm2.P256Sm2().Params().N
+	k1, err := RandScalar(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1.Sign() <= 0 || k1.Cmp(N) >= 0 {
+		t.Fatal("scalar out of range")
+	}
+	k2, err := RandScalar(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 == k1.Cmp(k2) {
+		t.Fatal("two random scalars are equal")
+	}
+
+	// 随机源不足
+	if _, err := RandScalar(nil, bytes.NewReader([]byte{1, 2, 3})); err == nil {
+		t.Fatal("expected error on short random source")
+	}
+}
+
+func TestNegPoint(t *testing.T) {
+	D := GenPoint()
+	neg, err := NegPoint(D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != neg.X.Cmp(D.X) || !neg.Curve.IsOnCurve(neg.X, neg.Y) {
+		t.Fatal("negated point invalid")
+	}
+
+	// D + (-D)为无穷远点
+	x, y := D.Curve.Add(D.X, D.Y, neg.X, neg.Y)
+	if x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("D + (-D) is not infinity")
+	}
+
+	if _, err := NegPoint(&CurvePoint{}); !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// VectorEncrypt encrypts every point in points with pub and returns the ciphertexts
+// in order. The vector shares one constant-time fixed-base table for pub: the one
+// registered by Precompute, or else, from batchTableMin points on, one built for
+// this call.
+// 向量加密：使用公钥pub逐个加密points中的点，按原顺序返回密文向量。
+// 整个向量共用公钥pub的同一常数时间固定基点表：Precompute注册的表，或在点数不少于batchTableMin时
+// 为本次调用建立的表。
+//
+// 参数：
+//		公钥		pub
+//		待加密点向量	points
+// 返回：
+// 		密文向量
+func VectorEncrypt(pub *sm2.PublicKey, points *PointVector) (*CipherVector, error) {
+	return Backend("").VectorEncrypt(pub, points)
+}
+
+// VectorEncrypt is VectorEncrypt with the multiplications by the random values
+// carried out by b, which then takes the place of the fixed-base table.
+// 向量加密：同VectorEncrypt，但以随机数进行的标量乘由后端b完成，此时不再使用固定基点表。
+//
+// 参数：
+//		公钥		pub
+//		待加密点向量	points
+// 返回：
+// 		密文向量
+func (b Backend) VectorEncrypt(pub *sm2.PublicKey, points *PointVector) (*CipherVector, error) {
+	if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, opError("VectorEncrypt", err)
+	}
+	if points == nil {
+		return nil, opError("VectorEncrypt", ErrEmpty)
+	}
+
+	// 从公钥提取曲线、后端实现及r*pub的计算方式，整个向量共用
+	curve := pub.Curve
+	impl, err := b.impl("VectorEncrypt", curve)
+	if err != nil {
+		return nil, err
+	}
+	mult := pubMultiplier(impl, pub, len(*points))
+
+	cts := make(CipherVector, len(*points))
+	for i := range *points {
+		D := &(*points)[i]
+		if err := CheckPoint(D); err != nil {
+			return nil, itemError("VectorEncrypt", "point", i, err)
+		}
+
+		// 从有限域中获得随机元素
+		r, err := ec.RandFieldElement(curve, rand.Reader)
+		if err != nil {
+			return nil, itemError("VectorEncrypt", "point", i, err)
+		}
+		rBytes := r.Bytes()
+
+		// 随机数数乘生成元，生成密文左侧点K，rB
+		cts[i].K.Curve = curve
+		cts[i].K.X, cts[i].K.Y = scalarBaseMultWith(impl, curve, rBytes)
+
+		// 随机数乘公钥得到点rK，与待加密点相加得到右侧点C
+		rKx, rKy := mult(rBytes)
+		cts[i].C.Curve = curve
+		cts[i].C.X, cts[i].C.Y = ec.Add(curve, rKx, rKy, D.X, D.Y)
+		wipe(rBytes)
+		ec.WipeInt(r)
+	}
+
+	return &cts, nil
+}
+
+// batchTableMin is the batch size from which EncryptBatch builds a fixed-base
+// table for a public key that has none, about where building it costs less than
+// the multiplications it saves.
+// EncryptBatch为未预计算的公钥临时建立固定基点表的最小批量，约为建表开销低于其节省的标量乘开销之处。
+const batchTableMin = 32
+
+// EncryptBatch encrypts every point in points with pub, like VectorEncrypt, for
+// batches of thousands of document keys: the random values of the whole batch
+// come from one buffered read of crypto/rand, and every r*pub is taken from one
+// fixed-base table, the one registered by Precompute or else, from batchTableMin
+// points on, one built for this batch and dropped afterwards. The table is read
+// in constant time, and every random value is wiped once used. Each ciphertext
+// still has its own random value, so the ciphertexts are as independent as those
+// of PointEncrypt.
+// 批量加密：同VectorEncrypt，使用公钥pub加密points中的每个点，适用于成千上万个文档密钥：整批的
+// 随机数由对crypto/rand的一次缓冲读取得到，各r*pub均由同一固定基点表计算，即Precompute注册的表，
+// 或在点数不少于batchTableMin时为本批临时建立、用后丢弃的表。查表为常数时间，各随机数用后即擦除。
+// 每个密文仍有各自的随机数，密文之间与PointEncrypt的结果同样相互独立。
+//
+// 参数：
+//		公钥		pub
+//		待加密点向量	points
+// 返回：
+// 		密文向量
+func EncryptBatch(pub *sm2.PublicKey, points *PointVector) (*CipherVector, error) {
+	return Backend("").EncryptBatch(pub, points)
+}
+
+// EncryptBatch is EncryptBatch with the multiplications by the random values
+// carried out by b, which then takes the place of the fixed-base table.
+// 批量加密：同EncryptBatch，但以随机数进行的标量乘由后端b完成，此时不再使用固定基点表。
+//
+// 参数：
+//		公钥		pub
+//		待加密点向量	points
+// 返回：
+// 		密文向量
+func (b Backend) EncryptBatch(pub *sm2.PublicKey, points *PointVector) (*CipherVector, error) {
+	if err := CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, opError("EncryptBatch", err)
+	}
+	if points == nil {
+		return nil, opError("EncryptBatch", ErrEmpty)
+	}
+	for i := range *points {
+		if err := CheckPoint(&(*points)[i]); err != nil {
+			return nil, itemError("EncryptBatch", "point", i, err)
+		}
+	}
+
+	curve := pub.Curve
+	impl, err := b.impl("EncryptBatch", curve)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := ec.RandFieldElements(curve, rand.Reader, len(*points))
+	if err != nil {
+		return nil, opError("EncryptBatch", err)
+	}
+
+	mult := pubMultiplier(impl, pub, len(rs))
+	cts := make(CipherVector, len(rs))
+	for i, r := range rs {
+		rBytes := r.Bytes()
+		cts[i].K.Curve = curve
+		cts[i].K.X, cts[i].K.Y = scalarBaseMultWith(impl, curve, rBytes)
+		rKx, rKy := mult(rBytes)
+		cts[i].C.Curve = curve
+		cts[i].C.X, cts[i].C.Y = ec.Add(curve, rKx, rKy, (*points)[i].X, (*points)[i].Y)
+		wipe(rBytes)
+		ec.WipeInt(r)
+	}
+
+	return &cts, nil
+}
+
+// pubMultiplier returns the function computing r*pub for a batch of count
+// scalars: impl when it is not nil, otherwise ScalarMult, through the table
+// registered by Precompute if any, or else, from batchTableMin scalars on, a table
+// built for this batch.
+// 返回计算count个标量r*pub的函数：impl非nil时使用impl，否则为ScalarMult（若Precompute已注册则经由其表），
+// 或在标量数不少于batchTableMin时为本批建立的表。
+func pubMultiplier(impl elliptic.Curve, pub *sm2.PublicKey, count int) func(r []byte) (*big.Int, *big.Int) {
+	if impl != nil {
+		return func(r []byte) (*big.Int, *big.Int) {
+			return impl.ScalarMult(pub.X, pub.Y, r)
+		}
+	}
+	if _, ok := fixedBases.Load(pointKey(pub.X, pub.Y)); !ok && count >= batchTableMin {
+		return ec.NewFixedBase(pub.Curve, pub.X, pub.Y).ScalarMult
+	}
+	return func(r []byte) (*big.Int, *big.Int) {
+		return ScalarMult((*CurvePoint)(pub), r)
+	}
+}
+
+// wipe zeroes b.
+// 将b清零。
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// VectorDecrypt decrypts every ciphertext in cts with priv and returns the points in order.
+// 向量解密：使用私钥priv逐个解密cts中的密文，按原顺序返回明文点向量。
+// 私钥字节只计算一次，供整个向量共用，用后擦除。
+//
+// 参数：
+//		私钥		priv
+//		密文向量	cts
+// 返回：
+// 		明文点向量
+func VectorDecrypt(priv *sm2.PrivateKey, cts *CipherVector) (*PointVector, error) {
+	return Backend("").VectorDecrypt(priv, cts)
+}
+
+// VectorDecrypt is VectorDecrypt with the multiplications by the private key
+// carried out by b.
+// 向量解密：同VectorDecrypt，但以私钥进行的标量乘由后端b完成。
+//
+// 参数：
+//		私钥		priv
+//		密文向量	cts
+// 返回：
+// 		明文点向量
+func (b Backend) VectorDecrypt(priv *sm2.PrivateKey, cts *CipherVector) (*PointVector, error) {
+	if priv == nil || priv.D == nil || priv.Curve == nil || cts == nil {
+		return nil, opError("VectorDecrypt", ErrEmpty)
+	}
+
+	// 后端实现及私钥字节，整个向量共用
+	curve := priv.Curve
+	impl, err := b.impl("VectorDecrypt", curve)
+	if err != nil {
+		return nil, err
+	}
+	dBytes := priv.D.Bytes()
+	defer wipe(dBytes)
+
+	points := make(PointVector, len(*cts))
+	for i := range *cts {
+		ct := &(*cts)[i]
+		if err := CheckCipherText(ct); err != nil {
+			return nil, itemError("VectorDecrypt", "ciphertext", i, err)
+		}
+
+		// 私钥数乘左侧点K(rB)，得到点rK，并取负
+		rKx, rKy := scalarMultWith(impl, &ct.K, dBytes)
+		negrKx, negrKy := ec.Neg(curve, rKx, rKy)
+
+		// 密文右侧点C减去rK，得到明文点
+		points[i].Curve = curve
+		points[i].X, points[i].Y = ec.Add(curve, ct.C.X, ct.C.Y, negrKx, negrKy)
+	}
+
+	return &points, nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestVectorEncryptDecrypt(t *testing.T) {
+	////////////////////////
+	// 向量长度//////////////
+	lens := 20
+	////////////////////////
+
+	priv, err := sm2.GenerateKey(rand.Reader) // 生成密钥对
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 生成待加密的点向量
+	points := make(PointVector, lens)
+	for i := 0; i < lens; i++ {
+		points[i] = *GenPoint()
+	}
+
+	cts, err := VectorEncrypt(&priv.PublicKey, &points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*cts) != lens {
+		t.Fatalf("got %d ciphertexts, want %d", len(*cts), lens)
+	}
+
+	pts, err := VectorDecrypt(priv, cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 逐个比较明文点与原始点
+	for i := 0; i < lens; i++ {
+		if 0 != points[i].X.Cmp((*pts)[i].X) || 0 != points[i].Y.Cmp((*pts)[i].Y) {
+			t.Fatalf("point %d: decrypted point differs from original", i)
+		}
+	}
+
+	// 与单点解密结果一致
+	pt, err := PointDecrypt(&(*cts)[0], priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != pt.X.Cmp(points[0].X) || 0 != pt.Y.Cmp(points[0].Y) {
+		t.Fatal("PointDecrypt disagrees with VectorEncrypt")
+	}
+}
+
+func TestVectorEncryptEmpty(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cts, err := VectorEncrypt(&priv.PublicKey, &PointVector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts, err := VectorDecrypt(priv, cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*pts) != 0 {
+		t.Fatalf("got %d points, want 0", len(*pts))
+	}
+}
+
+func TestVectorNil(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VectorEncrypt(&priv.PublicKey, nil); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("VectorEncrypt: got %v, want ErrEmpty", err)
+	}
+	if _, err := VectorDecrypt(priv, nil); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("VectorDecrypt: got %v, want ErrEmpty", err)
+	}
+	if _, err := VectorDecrypt(nil, &CipherVector{}); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("VectorDecrypt: got %v, want ErrEmpty for a nil key", err)
+	}
+}
+
+func TestVectorEncryptTable(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 点数不少于batchTableMin时整个向量共用临时建立的表
+	points := make(PointVector, batchTableMin)
+	for i := range points {
+		points[i] = *GenPoint()
+	}
+	cts, err := VectorEncrypt(&priv.PublicKey, &points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range points {
+		pt, err := PointDecrypt(&(*cts)[i], priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != pt.X.Cmp(points[i].X) || 0 != pt.Y.Cmp(points[i].Y) {
+			t.Fatalf("point %d: decrypted point differs from original", i)
+		}
+	}
+}
+
+func TestEncryptBatch(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+
+	// 小批量逐个标量乘，大批量临时建表，已注册的公钥使用其表
+	for _, c := range []struct {
+		lens       int
+		precompute bool
+	}{{0, false}, {3, false}, {batchTableMin, false}, {5, true}} {
+		if c.precompute {
+			if err := Precompute(pub); err != nil {
+				t.Fatal(err)
+			}
+		}
+		points := make(PointVector, c.lens)
+		for i := range points {
+			points[i] = *GenPoint()
+		}
+		cts, err := EncryptBatch(pub, &points)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*cts) != c.lens {
+			t.Fatalf("got %d ciphertexts, want %d", len(*cts), c.lens)
+		}
+		pts, err := VectorDecrypt(priv, cts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range points {
+			if 0 != points[i].X.Cmp((*pts)[i].X) || 0 != points[i].Y.Cmp((*pts)[i].Y) {
+				t.Fatalf("%d points, point %d: decrypted point differs from original", c.lens, i)
+			}
+			// 各密文的随机数互不相同
+			if i > 0 && 0 == (*cts)[i].K.X.Cmp((*cts)[i-1].K.X) {
+				t.Fatalf("%d points: ciphertexts %d and %d share their randomness", c.lens, i-1, i)
+			}
+		}
+		if c.precompute {
+			Forget(pub)
+		}
+	}
+
+	points := PointVector{*GenPoint(), {}}
+	_, err = EncryptBatch(pub, &points)
+	var e *Error
+	if !errors.As(err, &e) || e.Index != 1 || !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve at index 1", err)
+	}
+	if _, err := EncryptBatch(pub, nil); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+}
+
+func BenchmarkEncryptBatch(b *testing.B) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	points := make(PointVector, 256)
+	for i := range points {
+		points[i] = *GenPoint()
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncryptBatch(&priv.PublicKey, &points); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
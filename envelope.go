@@ -0,0 +1,168 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+// envelopeInfo is the KDF context of envelope keys, also their GCM additional data.
+// 信封密钥的KDF上下文，同时作为GCM附加数据。
+var envelopeInfo = []byte("ppks-envelope-v1")
+
+// Sizes of the envelope encoding: version, two compressed points and the GCM nonce.
+// 信封编码各部分长度：版本、两个压缩点及GCM随机数。
+const (
+	envelopePointLen = 33
+	envelopeNonceLen = 12
+	envelopeHeadLen  = 1 + 2*envelopePointLen + envelopeNonceLen
+)
+
+// Envelope is data of any length sealed for a public key: Data is encrypted with
+// SM4-GCM under a key derived from a random point, and Key is the PointEncrypt
+// ciphertext of that point. Key switching Key, e.g. with ShareReplace, hands the
+// whole envelope over to the target key.
+// 数字信封：为公钥封装的任意长度数据。Data由随机点派生的密钥以SM4-GCM加密，Key为该点的PointEncrypt密文。
+// 对Key进行密钥置换（如ShareReplace），即可将整个信封转交给目标公钥。
+type Envelope struct {
+	// Key is the ciphertext of the key point. 密钥点密文。
+	Key CipherText
+	// Nonce is the GCM nonce. GCM随机数。
+	Nonce []byte
+	// Data is the sealed data with its GCM tag. 带GCM认证标签的加密数据。
+	Data []byte
+}
+
+// SealData seals data for pub: it generates a random point, derives an SM4-GCM key
+// from it, encrypts data and attaches the PointEncrypt ciphertext of the point.
+// 数据封装：生成随机点并由其派生SM4-GCM密钥，加密data，附上该点的PointEncrypt密文，返回数字信封。
+//
+// 参数：
+//		公钥	pub
+//		数据	data
+// 返回：
+// 		数字信封
+func SealData(pub *sm2.PublicKey, data []byte) (*Envelope, error) {
+	D := GenPoint()
+	ct, err := PointEncrypt(pub, D)
+	if err != nil {
+		return nil, opError("SealData", err)
+	}
+	aead, err := envelopeAEAD(D)
+	if err != nil {
+		return nil, opError("SealData", err)
+	}
+
+	nonce := make([]byte, envelopeNonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, opError("SealData", err)
+	}
+	return &Envelope{
+		Key:   *ct,
+		Nonce: nonce,
+		Data:  aead.Seal(nil, nonce, data, envelopeInfo),
+	}, nil
+}
+
+// OpenData opens env with priv, the private key env.Key is encrypted for, failing
+// with ErrAuthFailed if the envelope was tampered with or is for another key.
+// 数据解封：使用env.Key对应的私钥priv打开数字信封，信封被篡改或不属于该私钥时返回ErrAuthFailed。
+//
+// 参数：
+//		私钥	priv
+//		数字信封	env
+// 返回：
+// 		数据
+func OpenData(priv *sm2.PrivateKey, env *Envelope) ([]byte, error) {
+	if env == nil || len(env.Nonce) != envelopeNonceLen {
+		return nil, opError("OpenData", ErrInvalidEnvelope)
+	}
+	D, err := PointDecrypt(&env.Key, priv)
+	if err != nil {
+		return nil, opError("OpenData", err)
+	}
+	aead, err := envelopeAEAD(D)
+	if err != nil {
+		return nil, opError("OpenData", err)
+	}
+
+	data, err := aead.Open(nil, env.Nonce, env.Data, envelopeInfo)
+	if err != nil {
+		return nil, opError("OpenData", ErrAuthFailed)
+	}
+	return data, nil
+}
+
+// MarshalBinary encodes env as EncodingVersion, the compressed points of Key, the
+// nonce and Data.
+// 序列化数字信封：依次编码EncodingVersion、Key的两个压缩点、随机数及Data。
+func (env *Envelope) MarshalBinary() ([]byte, error) {
+	if err := elgamal.CheckCipherText(&env.Key); err != nil {
+		return nil, opError("Envelope.MarshalBinary", err)
+	}
+	if len(env.Nonce) != envelopeNonceLen {
+		return nil, opError("Envelope.MarshalBinary", ErrInvalidEnvelope)
+	}
+	b := make([]byte, 0, envelopeHeadLen+len(env.Data))
+	b = append(b, EncodingVersion)
+	b = append(b, env.Key.K.CompressedBytes()...)
+	b = append(b, env.Key.C.CompressedBytes()...)
+	b = append(b, env.Nonce...)
+	return append(b, env.Data...), nil
+}
+
+// UnmarshalBinary decodes an envelope encoded by MarshalBinary.
+// 反序列化数字信封：解析MarshalBinary编码的数字信封。
+func (env *Envelope) UnmarshalBinary(b []byte) error {
+	if len(b) < envelopeHeadLen || b[0] != EncodingVersion {
+		return opError("Envelope.UnmarshalBinary", ErrInvalidEnvelope)
+	}
+	K, err := NewCurvePointFromBytes(nil, b[1:1+envelopePointLen])
+	if err != nil {
+		return opError("Envelope.UnmarshalBinary", err)
+	}
+	C, err := NewCurvePointFromBytes(nil, b[1+envelopePointLen:1+2*envelopePointLen])
+	if err != nil {
+		return opError("Envelope.UnmarshalBinary", err)
+	}
+	env.Key = CipherText{K: *K, C: *C}
+	env.Nonce = append([]byte(nil), b[1+2*envelopePointLen:envelopeHeadLen]...)
+	env.Data = append([]byte(nil), b[envelopeHeadLen:]...)
+	return nil
+}
+
+// envelopeAEAD returns the SM4-GCM instance keyed from the point D.
+// 返回由点D派生密钥的SM4-GCM实例。
+func envelopeAEAD(D *CurvePoint) (cipher.AEAD, error) {
+	key, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceKDF, Info: envelopeInfo})
+	if err != nil {
+		return nil, err
+	}
+	defer key.Wipe()
+
+	block, err := sm4.NewCipher(key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
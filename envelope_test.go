@@ -0,0 +1,84 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpenData(t *testing.T) {
+	k, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("ppks envelope "), 100)
+
+	env, err := SealData(&k.PublicKey, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := OpenData(k, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("opened data differs")
+	}
+
+	// 置换信封的密钥点密文后，目标私钥可打开
+	share, _, err := ShareCal(&q.PublicKey, &env.Key.K, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switched, err := ShareReplace(&CipherVector{*share}, &env.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwarded := *env
+	forwarded.Key = *switched
+
+	// 序列化往返
+	b, err := forwarded.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Envelope
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OpenData(q, &decoded); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("switched envelope failed to open: %v", err)
+	}
+
+	// 非目标私钥或篡改的数据无法打开
+	if _, err := OpenData(k, &decoded); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	decoded.Data[0] ^= 1
+	if _, err := OpenData(q, &decoded); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+
+	if err := decoded.UnmarshalBinary(b[:40]); !errors.Is(err, ErrInvalidEnvelope) {
+		t.Fatalf("got %v, want ErrInvalidEnvelope", err)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"errors"
+
+	"ppks/bn254"
+	"ppks/elgamal"
+	"ppks/escrow"
+	"ppks/hdkey"
+	"ppks/kdf"
+	"ppks/keyswitch"
+	"ppks/pedersen"
+	"ppks/proof"
+	"ppks/schnorr"
+	"ppks/stealth"
+	"ppks/vrf"
+)
+
+// Base errors. Errors returned by this package are *Error values wrapping one of
+// these (or an error from a lower layer), so callers can test them with errors.Is.
+// Errors of the subpackages are the same values.
+// 基础错误。本包返回的错误均为*Error，包装下列基础错误之一（或底层返回的错误），
+// 调用者可使用errors.Is判断。与子包中的同名错误为同一值。
+var (
+	// ErrPointNotOnCurve 坐标不在曲线上，或为无穷远点。
+	ErrPointNotOnCurve = elgamal.ErrPointNotOnCurve
+	// ErrInvalidPointEncoding 点编码格式错误。
+	ErrInvalidPointEncoding = elgamal.ErrInvalidPointEncoding
+	// ErrLengthMismatch 成组输入的长度不一致。
+	ErrLengthMismatch = elgamal.ErrLengthMismatch
+	// ErrEmpty 输入为空。
+	ErrEmpty = elgamal.ErrEmpty
+	// ErrCurveMismatch 参与运算的点不在同一曲线上。
+	ErrCurveMismatch = elgamal.ErrCurveMismatch
+	// ErrOutOfRange 整数超出离散对数表的范围。
+	ErrOutOfRange = elgamal.ErrOutOfRange
+	// ErrSeedTooShort 种子过短。
+	ErrSeedTooShort = hdkey.ErrSeedTooShort
+	// ErrNoPointFound 未能将输入映射到曲线上。
+	ErrNoPointFound = elgamal.ErrNoPointFound
+	// ErrInvalidKeyLength 密钥长度非法。
+	ErrInvalidKeyLength = kdf.ErrInvalidKeyLength
+	// ErrUnknownKeySource 未知的对称密钥来源。
+	ErrUnknownKeySource = errors.New("unknown key source")
+	// ErrIncompleteStatement 证明所针对的公开信息不完整。
+	ErrIncompleteStatement = keyswitch.ErrIncompleteStatement
+	// ErrSecretWiped 已擦除的SecretBytes不可再复制。
+	ErrSecretWiped = errors.New("secret already wiped")
+	// ErrInvalidEnvelope 数字信封格式错误。
+	ErrInvalidEnvelope = errors.New("invalid envelope")
+	// ErrAuthFailed 认证失败：数据被篡改，或密钥不匹配。
+	ErrAuthFailed = keyswitch.ErrAuthFailed
+	// ErrVerifierClosed Verifier关闭后再提交份额包。
+	ErrVerifierClosed = keyswitch.ErrVerifierClosed
+	// ErrProofFailed 份额证明验证未通过。
+	ErrProofFailed = keyswitch.ErrProofFailed
+	// ErrIncompleteProof 证明缺少(c,r1,r2)中的值。
+	ErrIncompleteProof = keyswitch.ErrIncompleteProof
+	// ErrInvalidProofEncoding 证明编码格式错误。
+	ErrInvalidProofEncoding = keyswitch.ErrInvalidProofEncoding
+	// ErrUnsupportedKey 密钥类型不支持密钥置换。
+	ErrUnsupportedKey = keyswitch.ErrUnsupportedKey
+	// ErrInvalidThreshold 门限参数或参与者编号非法。
+	ErrInvalidThreshold = keyswitch.ErrInvalidThreshold
+	// ErrInvalidShare 私钥份额与分发者的承诺不符。
+	ErrInvalidShare = keyswitch.ErrInvalidShare
+	// ErrChallengeIssued 聚合证明的挑战值发出后再添加节点。
+	ErrChallengeIssued = keyswitch.ErrChallengeIssued
+	// ErrNonceReused 联合证明参与方的随机数已用于应答。
+	ErrNonceReused = proof.ErrNonceReused
+	// ErrInvalidOpening 打开值与承诺不符，或不完整。
+	ErrInvalidOpening = pedersen.ErrInvalidOpening
+	// ErrUnknownHash 未知的挑战哈希。
+	ErrUnknownHash = proof.ErrUnknownHash
+	// ErrNotInSubgroup G2点不在阶为r的子群中。
+	ErrNotInSubgroup = bn254.ErrNotInSubgroup
+	// ErrDuplicateMessage 聚合签名中的消息重复。
+	ErrDuplicateMessage = bn254.ErrDuplicateMessage
+	// ErrInvalidSignature 签名编码格式错误。
+	ErrInvalidSignature = schnorr.ErrInvalidSignature
+	// ErrNotInRing 签名者的公钥不在公钥环中。
+	ErrNotInRing = schnorr.ErrNotInRing
+	// ErrNonceMismatch 联合签名的随机数点与其承诺不符。
+	ErrNonceMismatch = schnorr.ErrNonceMismatch
+	// ErrNonceUsed 联合签名者已签名，随机数不可重用。
+	ErrNonceUsed = schnorr.ErrNonceUsed
+	// ErrNoPrivateKey 扩展公钥不能派生强化子密钥或导出私钥。
+	ErrNoPrivateKey = hdkey.ErrNoPrivateKey
+	// ErrInvalidChild 该编号的子密钥无效，应跳过该编号。
+	ErrInvalidChild = hdkey.ErrInvalidChild
+	// ErrEpochExpired 所请求的纪元已过期，其密钥已擦除。
+	ErrEpochExpired = keyswitch.ErrEpochExpired
+	// ErrEpochMismatch 密文的纪元与节点当前纪元不符。
+	ErrEpochMismatch = keyswitch.ErrEpochMismatch
+	// ErrSessionClosed 会话密钥已关闭并擦除。
+	ErrSessionClosed = keyswitch.ErrSessionClosed
+	// ErrPolicyExpired 策略已失效。
+	ErrPolicyExpired = keyswitch.ErrPolicyExpired
+	// ErrInvalidWitness 证据缺失，或与所证明的公开信息不符。
+	ErrInvalidWitness = keyswitch.ErrInvalidWitness
+	// ErrWrongPhase 承诺或揭示不属于当前阶段，或节点已提交过。
+	ErrWrongPhase = keyswitch.ErrWrongPhase
+	// ErrUnknownNode 节点不属于该会话，或重复出现。
+	ErrUnknownNode = keyswitch.ErrUnknownNode
+	// ErrInsufficientWeight 参与节点的总权重低于门限。
+	ErrInsufficientWeight = keyswitch.ErrInsufficientWeight
+	// ErrInvalidTranscript 会话记录编码格式错误。
+	ErrInvalidTranscript = keyswitch.ErrInvalidTranscript
+	// ErrReplayed 请求已服务过，疑为重放。
+	ErrReplayed = keyswitch.ErrReplayed
+	// ErrStatementMismatch 两个份额包的节点、目标公钥或rB不同，无法比较。
+	ErrStatementMismatch = keyswitch.ErrStatementMismatch
+	// ErrInvalidVRFProof VRF证明编码格式错误。
+	ErrInvalidVRFProof = vrf.ErrInvalidProof
+	// ErrRecoveryFailed 恢复出的私钥与托管的公钥不符。
+	ErrRecoveryFailed = escrow.ErrRecoveryFailed
+	// ErrNotOwner 一次性公钥不是为该长期私钥派生的。
+	ErrNotOwner = stealth.ErrNotOwner
+)
+
+// Error records the operation, and for vector inputs the element, that failed,
+// e.g. "ppks: ShareReplace: share 42: point not on curve".
+// 错误：记录出错的操作，对于向量输入还记录出错元素的下标，
+// 如"ppks: ShareReplace: share 42: point not on curve"。
+type Error = elgamal.Error
+
+// opError wraps err with the failing operation.
+// 以出错的操作包装err。
+func opError(op string, err error) error {
+	return &Error{Op: op, Err: err}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestShareReplaceErrorContext(t *testing.T) {
+	////////////////////////
+	// 份额数量//////////////
+	lens := 50
+	////////////////////////
+
+	q, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := PointEncrypt(&q.PublicKey, GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := make(CipherVector, lens)
+	for i := 0; i < lens; i++ {
+		shares[i] = CipherText{K: *GenPoint(), C: *GenPoint()}
+	}
+	// 第42个份额的点不在曲线上
+	shares[42].C.Y = new(big.Int).Add(shares[42].C.Y, big.NewInt(1))
+
+	_, err = ShareReplace(&shares, ct)
+	if err == nil {
+		t.Fatal("expected error for off-curve share")
+	}
+	if got, want := err.Error(), "ppks: ShareReplace: share 42: point not on curve"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatal("error does not wrap ErrPointNotOnCurve")
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "ShareReplace" || e.Index != 42 {
+		t.Fatalf("unexpected error detail %#v", e)
+	}
+
+	if _, err := ShareReplace(&CipherVector{}, ct); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+}
+
+func TestShareCalRejectsInvalidPoint(t *testing.T) {
+	priv, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 不在曲线上的rB可能来自无效曲线攻击，须拒绝
+	rB := GenPoint()
+	rB.X = new(big.Int).Add(rB.X, big.NewInt(1))
+	_, _, err = ShareCal(&q.PublicKey, rB, priv)
+	if !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+	if got, want := err.Error(), "ppks: ShareCal: point not on curve"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNestedErrorPrefix(t *testing.T) {
+	q, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 嵌套的*Error只带一次"ppks: "前缀
+	pub := q.PublicKey
+	pub.Y = new(big.Int).Add(pub.Y, big.NewInt(1))
+	_, err = SealData(&pub, []byte("data"))
+	if got, want := fmt.Sprint(err), "ppks: SealData: PointEncrypt: point not on curve"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrPointNotOnCurve) {
+		t.Fatal("nested error does not wrap ErrPointNotOnCurve")
+	}
+
+	nested := &Error{Op: "Outer", Item: "node", Index: 3, Err: &Error{Op: "Inner", Item: "share", Index: 7, Err: ErrEmpty}}
+	if got, want := nested.Error(), "ppks: Outer: node 3: Inner: share 7: empty input"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package escrow backs up a user's SM2 private key under the committee key with
+// verifiable encryption, so the committee can restore it to a recovery key by an
+// ordinary key-switch session once it approves the recovery.
+//
+// The key x is cut into ChunkCount chunks of ChunkBits bits, each encrypted as an
+// exponential ElGamal ciphertext under the committee key with a range proof. A
+// linear proof shows that the weighted sum of the chunks is the discrete logarithm
+// of the user's public key, so anyone can check, without any secret, that the
+// escrow really holds the key. To recover, the committee switches every chunk to
+// the recovery key, whose holder decrypts the chunks, solves their small discrete
+// logarithms and recombines x.
+// 密钥托管：以可验证加密将用户的SM2私钥托管于委员会公钥之下，委员会批准恢复后，可通过普通的密钥置换会话
+// 将其恢复给恢复公钥。
+//
+// 私钥x被切分为ChunkCount个ChunkBits比特的分块，每块以指数ElGamal加密于委员会公钥之下并附范围证明。
+// 线性关系证明表明各分块的加权和即用户公钥的离散对数，因此任何人无需秘密即可检查托管确实包含该私钥。
+// 恢复时，委员会将各分块置换到恢复公钥，其持有者解密各分块、求解较小的离散对数并重组x。
+package escrow
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/keyswitch"
+	"ppks/proof"
+	"ppks/rangeproof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Chunk layout of an escrowed key. 托管私钥的分块方式。
+const (
+	// ChunkBits is the size of a chunk in bits. 分块的比特数。
+	ChunkBits = 16
+	// ChunkCount is the number of chunks, covering 256 bits. 分块数，共覆盖256比特。
+	ChunkCount = 256 / ChunkBits
+)
+
+// escrowContext binds consistency proofs to their protocol.
+// 一致性证明的上下文。
+const escrowContext = "ppks-escrow-v1"
+
+// Escrow is a private key verifiably encrypted under a committee key.
+// 托管：在委员会公钥下可验证加密的私钥。
+type Escrow struct {
+	// PubKey is the public key of the escrowed key. 被托管私钥的公钥。
+	PubKey *sm2.PublicKey
+	// Chunks are the chunk ciphertexts, least significant first.
+	// 各分块的密文，低位在前。
+	Chunks elgamal.CipherVector
+	// Ranges prove each chunk to lie in [0, 2^ChunkBits). 证明各分块位于[0, 2^ChunkBits)内。
+	Ranges []*rangeproof.Proof
+	// Proof shows the chunks to make up the key of PubKey. 证明各分块组成PubKey的私钥。
+	Proof *proof.LinearProof
+}
+
+// New escrows priv under the committee key committeePubKey.
+// 托管私钥：在委员会公钥committeePubKey下托管私钥priv。
+//
+// 参数：
+//		委员会公钥	committeePubKey
+//		私钥		priv
+// 返回：
+// 		托管
+func New(committeePubKey *sm2.PublicKey, priv *sm2.PrivateKey) (*Escrow, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("New", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(committeePubKey)); err != nil {
+		return nil, opError("New", err)
+	}
+
+	N := committeePubKey.Curve.Params().N
+	e := &Escrow{
+		PubKey: &priv.PublicKey,
+		Chunks: make(elgamal.CipherVector, ChunkCount),
+		Ranges: make([]*rangeproof.Proof, ChunkCount),
+	}
+	mask := big.NewInt(1<<ChunkBits - 1)
+	x := new(big.Int).Set(priv.D)
+	R := new(big.Int)
+	for j := 0; j < ChunkCount; j++ {
+		m := new(big.Int).And(x, mask).Int64()
+		x.Rsh(x, ChunkBits)
+		ct, p, r, err := rangeproof.EncryptIntWithRandomness(committeePubKey, m, ChunkBits)
+		if err != nil {
+			return nil, itemError("New", "chunk", j, err)
+		}
+		e.Chunks[j], e.Ranges[j] = *ct, p
+		// 加权和的随机数：R = sum 2^(ChunkBits*j)*r_j
+		R.Add(R, r.Lsh(r, uint(ChunkBits*j)))
+	}
+	R.Mod(R, N)
+
+	rel, context := e.statement(committeePubKey)
+	p, err := proof.ProveLinear(context, rel, []*big.Int{R, priv.D})
+	ec.WipeInt(R)
+	if err != nil {
+		return nil, err
+	}
+	e.Proof = p
+	return e, nil
+}
+
+// Verify reports whether e holds the private key of e.PubKey encrypted under
+// committeePubKey: every chunk is in range and the chunks make up the key.
+// 验证托管：判断e是否确实包含在委员会公钥committeePubKey下加密的e.PubKey的私钥，即各分块均在范围内，
+// 且各分块组成该私钥。
+//
+// 参数：
+//		委员会公钥	committeePubKey
+// 返回：
+// 		验证结果
+func (e *Escrow) Verify(committeePubKey *sm2.PublicKey) (bool, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(committeePubKey)); err != nil {
+		return false, opError("Escrow.Verify", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(e.PubKey)); err != nil {
+		return false, opError("Escrow.Verify", err)
+	}
+	if len(e.Chunks) != ChunkCount || len(e.Ranges) != ChunkCount {
+		return false, nil
+	}
+	for j := range e.Chunks {
+		if err := elgamal.CheckCipherText(&e.Chunks[j]); err != nil {
+			return false, itemError("Escrow.Verify", "chunk", j, err)
+		}
+	}
+
+	// 先验证开销较小的一致性证明，再验证各分块的范围证明
+	rel, context := e.statement(committeePubKey)
+	ok, err := proof.VerifyLinear(context, rel, e.Proof)
+	if err != nil || !ok {
+		return false, err
+	}
+	for j := range e.Chunks {
+		ok, err := rangeproof.Verify(committeePubKey, &e.Chunks[j], e.Ranges[j], ChunkBits)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// RBs returns the left points K of the chunks, the rB on which each committee
+// member calculates its shares during recovery.
+// 返回各分块密文的左侧点K，即恢复时各委员会成员计算份额所针对的rB。
+func (e *Escrow) RBs() []*elgamal.CurvePoint {
+	rBs := make([]*elgamal.CurvePoint, len(e.Chunks))
+	for j := range e.Chunks {
+		rBs[j] = &e.Chunks[j].K
+	}
+	return rBs
+}
+
+// Replace switches the chunks with the shares of the committee, shares[j] holding
+// the members' shares for chunk j, and returns the switched chunks.
+// 置换分块：以委员会的份额置换各分块，shares[j]为各成员对分块j的份额，返回置换后的分块。
+//
+// 参数：
+//		份额slice	shares
+// 返回：
+// 		置换后的分块
+func (e *Escrow) Replace(shares []elgamal.CipherVector) (elgamal.CipherVector, error) {
+	if len(shares) != len(e.Chunks) {
+		return nil, opError("Escrow.Replace", elgamal.ErrLengthMismatch)
+	}
+	out := make(elgamal.CipherVector, len(e.Chunks))
+	for j := range e.Chunks {
+		ct, err := keyswitch.ShareReplace(&shares[j], &e.Chunks[j])
+		if err != nil {
+			return nil, itemError("Escrow.Replace", "chunk", j, err)
+		}
+		out[j] = *ct
+	}
+	return out, nil
+}
+
+// Recover decrypts the switched chunks with the recovery key priv, solving each
+// chunk with table, and returns the escrowed private key. A nil table builds one
+// for [0, 2^ChunkBits). A result not matching e.PubKey fails with
+// ErrRecoveryFailed.
+// 恢复私钥：以恢复私钥priv解密置换后的分块，以table求解各分块，返回被托管的私钥。table为nil时为
+// [0, 2^ChunkBits)新建离散对数表。结果与e.PubKey不符时返回ErrRecoveryFailed。
+//
+// 参数：
+//		恢复私钥	priv
+//		置换后的分块	switched
+//		离散对数表	table
+// 返回：
+// 		被托管的私钥
+func (e *Escrow) Recover(priv *sm2.PrivateKey, switched elgamal.CipherVector, table *elgamal.DLogTable) (*sm2.PrivateKey, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("Escrow.Recover", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(e.PubKey)); err != nil {
+		return nil, opError("Escrow.Recover", err)
+	}
+	if len(switched) != ChunkCount {
+		return nil, opError("Escrow.Recover", elgamal.ErrLengthMismatch)
+	}
+	curve := priv.Curve
+	if table == nil {
+		var err error
+		if table, err = elgamal.NewDLogTable(curve, 1<<ChunkBits); err != nil {
+			return nil, opError("Escrow.Recover", err)
+		}
+	}
+
+	x := new(big.Int)
+	for j := ChunkCount - 1; j >= 0; j-- {
+		m, err := elgamal.DecryptInt(priv, &switched[j], table)
+		if err != nil {
+			return nil, itemError("Escrow.Recover", "chunk", j, err)
+		}
+		x.Lsh(x, ChunkBits)
+		x.Add(x, big.NewInt(m))
+	}
+	x.Mod(x, curve.Params().N)
+
+	key := new(sm2.PrivateKey)
+	key.Curve = curve
+	key.D = x
+	key.X, key.Y = curve.ScalarBaseMult(x.Bytes())
+	if key.X.Cmp(e.PubKey.X) != 0 || key.Y.Cmp(e.PubKey.Y) != 0 {
+		return nil, opError("Escrow.Recover", ErrRecoveryFailed)
+	}
+	return key, nil
+}
+
+// statement returns the relation {K=R*B, C=R*P+x*B, X=x*B} over the weighted sums
+// K, C of the chunks, and the context binding the committee key P and the key X.
+// 返回关于各分块加权和K、C的关系{K=R*B, C=R*P+x*B, X=x*B}，以及绑定委员会公钥P与被托管公钥X的上下文。
+func (e *Escrow) statement(committeePubKey *sm2.PublicKey) (*proof.Relation, string) {
+	curve := committeePubKey.Curve
+	B := elgamal.Generator(curve)
+	K, C := weightedSum(curve, e.Chunks)
+	context := escrowContext +
+		string((*elgamal.CurvePoint)(committeePubKey).CompressedBytes()) +
+		string((*elgamal.CurvePoint)(e.PubKey).CompressedBytes())
+	return &proof.Relation{
+		Bases: [][]*elgamal.CurvePoint{
+			{B, nil},
+			{(*elgamal.CurvePoint)(committeePubKey), B},
+			{nil, B},
+		},
+		Targets: []*elgamal.CurvePoint{K, C, (*elgamal.CurvePoint)(e.PubKey)},
+	}, context
+}
+
+// weightedSum returns sum 2^(ChunkBits*j)*cts[j], component by component.
+// 逐分量返回sum 2^(ChunkBits*j)*cts[j]。
+func weightedSum(curve elliptic.Curve, cts elgamal.CipherVector) (*elgamal.CurvePoint, *elgamal.CurvePoint) {
+	K := &elgamal.CurvePoint{Curve: curve}
+	C := &elgamal.CurvePoint{Curve: curve}
+	for j := range cts {
+		w := new(big.Int).Lsh(big.NewInt(1), uint(ChunkBits*j)).Bytes()
+		kx, ky := curve.ScalarMult(cts[j].K.X, cts[j].K.Y, w)
+		cx, cy := curve.ScalarMult(cts[j].C.X, cts[j].C.Y, w)
+		if j == 0 {
+			K.X, K.Y, C.X, C.Y = kx, ky, cx, cy
+			continue
+		}
+		K.X, K.Y = ec.Add(curve, K.X, K.Y, kx, ky)
+		C.X, C.Y = ec.Add(curve, C.X, C.Y, cx, cy)
+	}
+	return K, C
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package escrow
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEscrowRecover(t *testing.T) {
+	var members []*sm2.PrivateKey
+	var pubs []*sm2.PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		members = append(members, priv)
+		pubs = append(pubs, &priv.PublicKey)
+	}
+	committee, err := keyswitch.AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := New(committee, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := e.Verify(committee); err != nil || !ok {
+		t.Fatalf("escrow failed to verify: %v", err)
+	}
+
+	// 委员会批准后，将各分块置换到恢复公钥
+	shares := make([]elgamal.CipherVector, ChunkCount)
+	for j, rB := range e.RBs() {
+		for _, m := range members {
+			b, err := keyswitch.GenShareBundle(&recovery.PublicKey, rB, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares[j] = append(shares[j], b.Share)
+		}
+	}
+	switched, err := e.Replace(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := e.Recover(recovery, switched, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.D.Cmp(user.D) != 0 {
+		t.Fatal("recovered key differs")
+	}
+
+	// 其他私钥无法恢复
+	if _, err := e.Recover(user, switched, nil); err == nil {
+		t.Fatal("recovered with the wrong key")
+	}
+
+	// 交换分块、更换公钥后验证失败
+	forged := *e
+	forged.Chunks = append(elgamal.CipherVector(nil), e.Chunks...)
+	forged.Chunks[0], forged.Chunks[1] = forged.Chunks[1], forged.Chunks[0]
+	if ok, _ := forged.Verify(committee); ok {
+		t.Fatal("escrow with swapped chunks verified")
+	}
+	forged = *e
+	forged.PubKey = &recovery.PublicKey
+	if ok, _ := forged.Verify(committee); ok {
+		t.Fatal("escrow verified for another key")
+	}
+	if ok, _ := e.Verify(&recovery.PublicKey); ok {
+		t.Fatal("escrow verified under another committee key")
+	}
+}
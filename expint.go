@@ -0,0 +1,70 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/elliptic"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// DLogTable solves discrete logarithms of points mB for m in [0, Max] by
+// baby-step giant-step.
+// 离散对数表：以小步大步算法求[0, Max]内点mB的离散对数m。
+type DLogTable = elgamal.DLogTable
+
+// EncryptInt encrypts the integer m as the point mB with pub (exponential ElGamal).
+// It is a wrapper of elgamal.EncryptInt.
+// 整数加密：将整数m编码为点mB，使用公钥pub加密（指数ElGamal）。
+//
+// 参数：
+//		公钥	pub
+//		整数	m
+// 返回：
+// 		密文
+func EncryptInt(pub *sm2.PublicKey, m int64) (*CipherText, error) {
+	return elgamal.EncryptInt(pub, m)
+}
+
+// DecryptInt decrypts ct with priv and solves the discrete logarithm of the
+// plaintext point with table.
+// It is a wrapper of elgamal.DecryptInt.
+// 整数解密：使用私钥priv解密密文ct，并以table求明文点的离散对数。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+//		离散对数表	table
+// 返回：
+// 		整数
+func DecryptInt(priv *sm2.PrivateKey, ct *CipherText, table *DLogTable) (int64, error) {
+	return elgamal.DecryptInt(priv, ct, table)
+}
+
+// NewDLogTable precomputes the table for [0, max] on curve. A nil curve selects SM2.
+// It is a wrapper of elgamal.NewDLogTable.
+// 预计算曲线curve上[0, max]范围的离散对数表，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+//		上限	max
+// 返回：
+// 		离散对数表
+func NewDLogTable(curve elliptic.Curve, max int64) (*DLogTable, error) {
+	return elgamal.NewDLogTable(curve, max)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"fmt"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// RedactedPrivKey formats priv for logs: the public key is shown, D is not.
+// 脱敏私钥：用于日志输出，仅显示公钥，不显示私钥D。
+//
+// 参数：
+//		私钥	priv
+// 返回：
+// 		脱敏后的字符串
+func RedactedPrivKey(priv *sm2.PrivateKey) string {
+	if priv == nil {
+		return "PrivateKey(nil)"
+	}
+	return fmt.Sprintf("PrivateKey(D=REDACTED, pub=%s)", CurvePoint(priv.PublicKey))
+}
+
+// Redacted formats the key pair for logs without revealing the private key.
+// 脱敏输出密钥对，不显示私钥。
+func (kp *KeyPair) Redacted() string {
+	return "KeyPair(" + RedactedPrivKey(kp.priv) + ")"
+}
+
+// String implements fmt.Stringer with the redacted form.
+// 字符串形式即脱敏形式。
+func (kp *KeyPair) String() string {
+	return kp.Redacted()
+}
+
+// GoString implements fmt.GoStringer with the redacted form, so %#v does not dump D.
+// %#v格式下同样输出脱敏形式，不打印私钥D。
+func (kp *KeyPair) GoString() string {
+	return kp.Redacted()
+}
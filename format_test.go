@@ -0,0 +1,44 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRedactedPrivKey(t *testing.T) {
+	kp, err := NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := fmt.Sprintf("%x", kp.PrivateKey().D)
+
+	for _, out := range []string{
+		RedactedPrivKey(kp.PrivateKey()),
+		fmt.Sprintf("%v", kp),
+		fmt.Sprintf("%+v", kp),
+		fmt.Sprintf("%#v", kp),
+	} {
+		if strings.Contains(out, d) || strings.Contains(out, kp.PrivateKey().D.String()) {
+			t.Fatalf("private key leaked: %s", out)
+		}
+		if !strings.Contains(out, "REDACTED") {
+			t.Fatalf("missing redaction marker: %s", out)
+		}
+	}
+}
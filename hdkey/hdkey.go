@@ -0,0 +1,279 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hdkey implements hierarchical deterministic derivation of SM2 keys with
+// chain codes, in the manner of BIP-32 with SM3-HKDF in place of HMAC-SHA512. A
+// node derives per-session or per-tenant sub-keys from one master key; since
+// non-hardened derivation only needs the extended public key, anybody holding the
+// nodes' extended public keys derives the matching collective key.
+//
+// hdkey包实现基于链码的SM2密钥分层确定性派生，方式同BIP-32，以SM3-HKDF代替HMAC-SHA512。
+// 节点可由一个主密钥派生各会话或各租户的子密钥；非强化派生只需扩展公钥，
+// 因此持有各节点扩展公钥者均可派生出相应的聚合公钥。
+package hdkey
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/kdf"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// HardenedOffset is the first index of hardened children, which only the holder
+// of the private key derives.
+// 强化子密钥的起始编号，强化子密钥只能由私钥持有者派生。
+const HardenedOffset uint32 = 1 << 31
+
+// ChainCodeSize is the length of chain codes in bytes.
+// 链码字节长度。
+const ChainCodeSize = 32
+
+// minSeedLen is the shortest seed NewMaster accepts.
+// NewMaster接受的最短种子长度。
+const minSeedLen = 16
+
+// ExtendedKey is an SM2 key with its chain code. An extended public key has no
+// private key; it derives non-hardened children only.
+// 扩展密钥：SM2密钥及其链码。扩展公钥不含私钥，只能派生非强化子密钥。
+type ExtendedKey struct {
+	priv      *sm2.PrivateKey
+	pub       *sm2.PublicKey
+	chainCode []byte
+}
+
+// NewMaster derives the master extended key from seed, at least 16 bytes long.
+// 由种子派生主扩展密钥，种子至少16字节。
+//
+// 参数：
+//		种子	seed
+// 返回：
+// 		主扩展密钥
+func NewMaster(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < minSeedLen {
+		return nil, opError("NewMaster", ErrSeedTooShort)
+	}
+	I, err := kdf.Key([]byte("ppks-hd-seed"), seed, []byte("master"), 2*ChainCodeSize)
+	if err != nil {
+		return nil, opError("NewMaster", err)
+	}
+	curve := sm2.P256Sm2()
+	d := new(big.Int).SetBytes(I[:ChainCodeSize])
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, opError("NewMaster", ErrInvalidChild)
+	}
+	return newPrivate(curve, d, I[ChainCodeSize:]), nil
+}
+
+// NewExtendedKey extends an existing private key, such as a node key, with
+// chainCode.
+// 以链码chainCode扩展已有私钥（如节点私钥）。
+//
+// 参数：
+//		私钥	priv
+//		链码	chainCode
+// 返回：
+// 		扩展私钥
+func NewExtendedKey(priv *sm2.PrivateKey, chainCode []byte) (*ExtendedKey, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("NewExtendedKey", ErrNoPrivateKey)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(&priv.PublicKey)); err != nil {
+		return nil, opError("NewExtendedKey", err)
+	}
+	if len(chainCode) != ChainCodeSize {
+		return nil, opError("NewExtendedKey", elgamal.ErrLengthMismatch)
+	}
+	return newPrivate(priv.Curve, priv.D, chainCode), nil
+}
+
+// NewExtendedPublicKey builds the extended public key of pub and chainCode, as
+// published by a node.
+// 由公钥pub与链码chainCode构造扩展公钥，即节点所公布的内容。
+//
+// 参数：
+//		公钥	pub
+//		链码	chainCode
+// 返回：
+// 		扩展公钥
+func NewExtendedPublicKey(pub *sm2.PublicKey, chainCode []byte) (*ExtendedKey, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, opError("NewExtendedPublicKey", err)
+	}
+	if len(chainCode) != ChainCodeSize {
+		return nil, opError("NewExtendedPublicKey", elgamal.ErrLengthMismatch)
+	}
+	P := *pub
+	return &ExtendedKey{pub: &P, chainCode: append([]byte(nil), chainCode...)}, nil
+}
+
+// newPrivate returns the extended key of d and chainCode.
+// 返回d与chainCode构成的扩展私钥。
+func newPrivate(curve elliptic.Curve, d *big.Int, chainCode []byte) *ExtendedKey {
+	priv := new(sm2.PrivateKey)
+	priv.Curve = curve
+	priv.D = new(big.Int).Set(d)
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+	return &ExtendedKey{priv: priv, pub: &priv.PublicKey, chainCode: append([]byte(nil), chainCode...)}
+}
+
+// IsPrivate reports whether k holds the private key.
+// 返回k是否含有私钥。
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.priv != nil
+}
+
+// PublicKey returns the public key of k.
+// 返回k的公钥。
+func (k *ExtendedKey) PublicKey() *sm2.PublicKey {
+	P := *k.pub
+	return &P
+}
+
+// PrivateKey returns the private key of k, or ErrNoPrivateKey for an extended
+// public key.
+// 返回k的私钥，k为扩展公钥时返回ErrNoPrivateKey。
+func (k *ExtendedKey) PrivateKey() (*sm2.PrivateKey, error) {
+	if k.priv == nil {
+		return nil, opError("ExtendedKey.PrivateKey", ErrNoPrivateKey)
+	}
+	priv := *k.priv
+	priv.D = new(big.Int).Set(k.priv.D)
+	return &priv, nil
+}
+
+// ChainCode returns a copy of the chain code of k.
+// 返回k的链码副本。
+func (k *ExtendedKey) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// Public returns the extended public key of k, which derives the same
+// non-hardened child public keys as k.
+// 返回k的扩展公钥，其派生的非强化子公钥与k相同。
+func (k *ExtendedKey) Public() *ExtendedKey {
+	return &ExtendedKey{pub: k.PublicKey(), chainCode: k.ChainCode()}
+}
+
+// Derive returns the child of k at index. Indices from HardenedOffset on are
+// hardened and need the private key. ErrInvalidChild, of negligible probability,
+// means the index should be skipped.
+// 派生子密钥：返回k在编号index处的子密钥。编号不小于HardenedOffset的为强化子密钥，需要私钥。
+// 返回ErrInvalidChild（概率可忽略）时应跳过该编号。
+//
+// 参数：
+//		编号	index
+// 返回：
+// 		子扩展密钥
+func (k *ExtendedKey) Derive(index uint32) (*ExtendedKey, error) {
+	curve := k.pub.Curve
+	byteLen := (curve.Params().BitSize + 7) / 8
+	var data []byte
+	if index >= HardenedOffset {
+		if k.priv == nil {
+			return nil, opError("ExtendedKey.Derive", ErrNoPrivateKey)
+		}
+		data = make([]byte, 1+byteLen)
+		k.priv.D.FillBytes(data[1:])
+	} else {
+		// 压缩编码的公钥
+		data = make([]byte, 1+byteLen)
+		data[0] = 0x02 | byte(k.pub.Y.Bit(0))
+		k.pub.X.FillBytes(data[1:])
+	}
+	var idx [4]byte
+	binary.BigEndian.PutUint32(idx[:], index)
+	data = append(data, idx[:]...)
+
+	I, err := kdf.Key(k.chainCode, data, []byte("ppks-hd-child"), byteLen+ChainCodeSize)
+	if err != nil {
+		return nil, opError("ExtendedKey.Derive", err)
+	}
+	N := curve.Params().N
+	t := new(big.Int).SetBytes(I[:byteLen])
+	if t.Cmp(N) >= 0 {
+		return nil, opError("ExtendedKey.Derive", ErrInvalidChild)
+	}
+	chainCode := I[byteLen:]
+
+	if k.priv != nil {
+		d := t.Add(t, k.priv.D)
+		d.Mod(d, N)
+		if d.Sign() == 0 {
+			return nil, opError("ExtendedKey.Derive", ErrInvalidChild)
+		}
+		return newPrivate(curve, d, chainCode), nil
+	}
+
+	// 子公钥为P + tG
+	tx, ty := curve.ScalarBaseMult(t.Bytes())
+	child := &sm2.PublicKey{Curve: curve}
+	child.X, child.Y = ec.Add(curve, k.pub.X, k.pub.Y, tx, ty)
+	if child.X.Sign() == 0 && child.Y.Sign() == 0 {
+		return nil, opError("ExtendedKey.Derive", ErrInvalidChild)
+	}
+	return &ExtendedKey{pub: child, chainCode: chainCode}, nil
+}
+
+// DerivePath derives the descendant of k along path, one index per level.
+// 沿路径path逐级派生k的后代密钥。
+//
+// 参数：
+//		路径	path
+// 返回：
+// 		后代扩展密钥
+func (k *ExtendedKey) DerivePath(path ...uint32) (*ExtendedKey, error) {
+	child := k
+	for i, index := range path {
+		next, err := child.Derive(index)
+		if err != nil {
+			return nil, itemError("ExtendedKey.DerivePath", "level", i, err)
+		}
+		child = next
+	}
+	return child, nil
+}
+
+// DeriveCollective derives the collective public key along path from the
+// extended public keys of the nodes: the sum of the nodes' child public keys,
+// whose private key is the sum of the child private keys each node derives along
+// the same path. The path must not be hardened.
+// 派生聚合公钥：由各节点的扩展公钥沿路径path派生聚合公钥，即各节点子公钥之和，
+// 其私钥为各节点沿同一路径派生的子私钥之和。路径中不能有强化编号。
+//
+// 参数：
+//		各节点扩展公钥	xpubs
+//		路径			path
+// 返回：
+// 		聚合公钥
+func DeriveCollective(xpubs []*ExtendedKey, path ...uint32) (*sm2.PublicKey, error) {
+	if len(xpubs) == 0 {
+		return nil, opError("DeriveCollective", elgamal.ErrEmpty)
+	}
+	pubs := make([]*sm2.PublicKey, len(xpubs))
+	for i, x := range xpubs {
+		child, err := x.Public().DerivePath(path...)
+		if err != nil {
+			return nil, itemError("DeriveCollective", "key", i, err)
+		}
+		pubs[i] = child.pub
+	}
+	return keyswitch.AggregatePubKeys(pubs)
+}
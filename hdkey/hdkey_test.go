@@ -0,0 +1,150 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hdkey
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestDerive(t *testing.T) {
+	seed := []byte("0123456789abcdef-hd-seed")
+	m, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.PublicKey().X.Cmp(m2.PublicKey().X) != 0 || !bytes.Equal(m.ChainCode(), m2.ChainCode()) {
+		t.Fatal("master key is not deterministic")
+	}
+
+	// 非强化派生：由扩展公钥派生的子公钥与私钥派生的一致
+	child, err := m.DerivePath(7, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubChild, err := m.Public().DerivePath(7, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pubChild.IsPrivate() || child.PublicKey().X.Cmp(pubChild.PublicKey().X) != 0 || child.PublicKey().Y.Cmp(pubChild.PublicKey().Y) != 0 {
+		t.Fatal("public derivation differs from private derivation")
+	}
+	priv, err := child.PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := priv.Curve.ScalarBaseMult(priv.D.Bytes())
+	if x.Cmp(priv.X) != 0 || y.Cmp(priv.Y) != 0 {
+		t.Fatal("child private key does not match its public key")
+	}
+
+	// 强化派生需要私钥
+	if _, err := m.Public().Derive(HardenedOffset); !errors.Is(err, ErrNoPrivateKey) {
+		t.Fatalf("expected ErrNoPrivateKey, got %v", err)
+	}
+	h, err := m.Derive(HardenedOffset + 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.PublicKey().X.Cmp(child.PublicKey().X) == 0 {
+		t.Fatal("hardened child equals a normal child")
+	}
+
+	if _, err := NewMaster(seed[:8]); !errors.Is(err, ErrSeedTooShort) {
+		t.Fatalf("expected ErrSeedTooShort, got %v", err)
+	}
+}
+
+func TestDeriveCollective(t *testing.T) {
+	nodes := make([]*ExtendedKey, 3)
+	xpubs := make([]*ExtendedKey, len(nodes))
+	for i := range nodes {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cc := make([]byte, ChainCodeSize)
+		if _, err := rand.Read(cc); err != nil {
+			t.Fatal(err)
+		}
+		if nodes[i], err = NewExtendedKey(priv, cc); err != nil {
+			t.Fatal(err)
+		}
+		// 节点只公布扩展公钥
+		if xpubs[i], err = NewExtendedPublicKey(&priv.PublicKey, cc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tenant := uint32(3)
+	collective, err := DeriveCollective(xpubs, tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 各节点以派生的子私钥计算份额，置换到请求者
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(collective, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make(elgamal.CipherVector, len(nodes))
+	for i, node := range nodes {
+		child, err := node.Derive(tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		priv, err := child.PrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, _, err := keyswitch.ShareCal(&q.PublicKey, &ct.K, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = *share
+	}
+	switched, err := keyswitch.ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := elgamal.PointDecrypt(switched, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+		t.Fatal("collective child key does not match the nodes' child keys")
+	}
+
+	if _, err := DeriveCollective(xpubs, HardenedOffset); err == nil {
+		t.Fatal("expected error for a hardened collective path")
+	}
+}
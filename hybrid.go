@@ -0,0 +1,195 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+// hybridInfo is the KDF label of hybrid envelope keys.
+// 混合信封密钥的KDF标签。
+var hybridInfo = []byte("ppks-hybrid-envelope-v1")
+
+// PQEncapsulator is the public key of a post-quantum KEM such as Kyber (ML-KEM).
+// The project has no lattice code of its own; an adapter over any implementation
+// plugs in here.
+// 后量子KEM（如Kyber/ML-KEM）的公钥。本项目不自带格密码实现，任意实现经适配后即可接入。
+type PQEncapsulator interface {
+	// Encapsulate returns a fresh KEM ciphertext and the shared secret it carries.
+	// 生成新的KEM密文及其封装的共享密钥。
+	Encapsulate() (ciphertext, secret []byte, err error)
+}
+
+// PQDecapsulator is the private key matching a PQEncapsulator.
+// 与PQEncapsulator对应的后量子KEM私钥。
+type PQDecapsulator interface {
+	// Decapsulate returns the shared secret carried by ciphertext.
+	// 返回密文ciphertext封装的共享密钥。
+	Decapsulate(ciphertext []byte) (secret []byte, err error)
+}
+
+// HybridEnvelope is an Envelope whose data key is also bound to a post-quantum KEM:
+// the SM4-GCM key is derived from both the key point and the KEM secret, so opening
+// takes both decapsulations and breaking the EC layer alone reveals nothing.
+// Key switching Key hands over only the EC half; the target must also hold the KEM
+// private key.
+// 混合数字信封：数据密钥同时绑定后量子KEM。SM4-GCM密钥由密钥点与KEM共享密钥共同派生，打开信封需要两次解封，
+// 仅攻破椭圆曲线部分无法得到任何信息。对Key进行密钥置换仅转交椭圆曲线部分，目标方还须持有KEM私钥。
+type HybridEnvelope struct {
+	Envelope
+	// PQKey is the KEM ciphertext. KEM密文。
+	PQKey []byte
+}
+
+// SealDataHybrid seals data for pub and the KEM public key pq.
+// 混合数据封装：为公钥pub及KEM公钥pq封装数据，返回混合数字信封。
+//
+// 参数：
+//		公钥		pub
+//		KEM公钥		pq
+//		数据		data
+// 返回：
+// 		混合数字信封
+func SealDataHybrid(pub *sm2.PublicKey, pq PQEncapsulator, data []byte) (*HybridEnvelope, error) {
+	if pq == nil {
+		return nil, opError("SealDataHybrid", ErrInvalidEnvelope)
+	}
+	D := GenPoint()
+	ct, err := PointEncrypt(pub, D)
+	if err != nil {
+		return nil, opError("SealDataHybrid", err)
+	}
+	pqct, secret, err := pq.Encapsulate()
+	if err != nil {
+		return nil, opError("SealDataHybrid", err)
+	}
+	aead, err := hybridAEAD(D, secret)
+	wipeBytes(secret)
+	if err != nil {
+		return nil, opError("SealDataHybrid", err)
+	}
+
+	nonce := make([]byte, envelopeNonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, opError("SealDataHybrid", err)
+	}
+	return &HybridEnvelope{
+		Envelope: Envelope{
+			Key:   *ct,
+			Nonce: nonce,
+			Data:  aead.Seal(nil, nonce, data, hybridAD(pqct)),
+		},
+		PQKey: pqct,
+	}, nil
+}
+
+// OpenDataHybrid opens env with priv and the KEM private key pq, failing with
+// ErrAuthFailed if the envelope was tampered with or either key does not match.
+// 混合数据解封：使用私钥priv及KEM私钥pq打开混合数字信封，信封被篡改或任一私钥不符时返回ErrAuthFailed。
+//
+// 参数：
+//		私钥		priv
+//		KEM私钥		pq
+//		混合数字信封	env
+// 返回：
+// 		数据
+func OpenDataHybrid(priv *sm2.PrivateKey, pq PQDecapsulator, env *HybridEnvelope) ([]byte, error) {
+	if pq == nil || env == nil || len(env.Nonce) != envelopeNonceLen || len(env.PQKey) == 0 {
+		return nil, opError("OpenDataHybrid", ErrInvalidEnvelope)
+	}
+	D, err := PointDecrypt(&env.Key, priv)
+	if err != nil {
+		return nil, opError("OpenDataHybrid", err)
+	}
+	secret, err := pq.Decapsulate(env.PQKey)
+	if err != nil {
+		return nil, opError("OpenDataHybrid", ErrAuthFailed)
+	}
+	aead, err := hybridAEAD(D, secret)
+	wipeBytes(secret)
+	if err != nil {
+		return nil, opError("OpenDataHybrid", err)
+	}
+
+	data, err := aead.Open(nil, env.Nonce, env.Data, hybridAD(env.PQKey))
+	if err != nil {
+		return nil, opError("OpenDataHybrid", ErrAuthFailed)
+	}
+	return data, nil
+}
+
+// MarshalBinary encodes env as the 2-byte big-endian length of PQKey, PQKey and the
+// encoding of the embedded Envelope.
+// 序列化混合数字信封：依次编码PQKey的2字节大端长度、PQKey及内嵌Envelope的编码。
+func (env *HybridEnvelope) MarshalBinary() ([]byte, error) {
+	if len(env.PQKey) == 0 || len(env.PQKey) > 0xffff {
+		return nil, opError("HybridEnvelope.MarshalBinary", ErrInvalidEnvelope)
+	}
+	inner, err := env.Envelope.MarshalBinary()
+	if err != nil {
+		return nil, opError("HybridEnvelope.MarshalBinary", err)
+	}
+	b := make([]byte, 2, 2+len(env.PQKey)+len(inner))
+	binary.BigEndian.PutUint16(b, uint16(len(env.PQKey)))
+	b = append(b, env.PQKey...)
+	return append(b, inner...), nil
+}
+
+// UnmarshalBinary decodes a hybrid envelope encoded by MarshalBinary.
+// 反序列化混合数字信封：解析MarshalBinary编码的混合数字信封。
+func (env *HybridEnvelope) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return opError("HybridEnvelope.UnmarshalBinary", ErrInvalidEnvelope)
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if n == 0 || len(b) < 2+n {
+		return opError("HybridEnvelope.UnmarshalBinary", ErrInvalidEnvelope)
+	}
+	if err := env.Envelope.UnmarshalBinary(b[2+n:]); err != nil {
+		return opError("HybridEnvelope.UnmarshalBinary", err)
+	}
+	env.PQKey = append([]byte(nil), b[2:2+n]...)
+	return nil
+}
+
+// hybridAEAD returns the SM4-GCM instance keyed from both the point D and the KEM
+// secret, the latter as the HKDF salt.
+// 返回由点D与KEM共享密钥（作为HKDF盐值）共同派生密钥的SM4-GCM实例。
+func hybridAEAD(D *CurvePoint, secret []byte) (cipher.AEAD, error) {
+	key, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceHKDF, Salt: secret, Info: hybridInfo})
+	if err != nil {
+		return nil, err
+	}
+	defer key.Wipe()
+
+	block, err := sm4.NewCipher(key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// hybridAD returns the GCM additional data, binding the KEM ciphertext.
+// 返回GCM附加数据，绑定KEM密文。
+func hybridAD(pqct []byte) []byte {
+	return append(append([]byte(nil), hybridInfo...), pqct...)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// testKEM is a stand-in KEM for the tests: the secret is SM3(key||ciphertext), so
+// only holders of key can decapsulate. It is not post-quantum, only the interface.
+type testKEM struct {
+	key []byte
+}
+
+func (k *testKEM) Encapsulate() ([]byte, []byte, error) {
+	ct := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, ct); err != nil {
+		return nil, nil, err
+	}
+	ss, err := k.Decapsulate(ct)
+	return ct, ss, err
+}
+
+func (k *testKEM) Decapsulate(ct []byte) ([]byte, error) {
+	h := sm3.New()
+	h.Write(k.key)
+	h.Write(ct)
+	return h.Sum(nil), nil
+}
+
+func TestSealOpenDataHybrid(t *testing.T) {
+	k, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pq := &testKEM{key: []byte("kem key")}
+	data := []byte("ppks hybrid envelope")
+
+	env, err := SealDataHybrid(&k.PublicKey, pq, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := env.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded HybridEnvelope
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OpenDataHybrid(k, pq, &decoded); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("hybrid envelope failed to open: %v", err)
+	}
+
+	// 两次解封缺一不可
+	if _, err := OpenDataHybrid(k, &testKEM{key: []byte("other key")}, &decoded); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	q, err := GenPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenDataHybrid(q, pq, &decoded); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	if _, err := OpenData(k, &decoded.Envelope); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	decoded.PQKey[0] ^= 1
+	if _, err := OpenDataHybrid(k, pq, &decoded); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SwitchingKeyCert is the certificate of a switching key by a server's long-term
+// identity key, or by an intermediate key certified by it.
+// 置换公钥证书：ks server的长期身份私钥（或经其认证的中间私钥）为置换公钥签发的证书。
+type SwitchingKeyCert = keyswitch.SwitchingKeyCert
+
+// CertifySwitchingKey certifies pub with the key issuer from epoch on.
+// It is a wrapper of keyswitch.CertifySwitchingKey.
+// 签发置换公钥证书：以私钥issuer为pub签发自纪元epoch起有效的证书。
+//
+// 参数：
+//		签发者私钥	issuer
+//		公钥		pub
+//		纪元		epoch
+// 返回：
+// 		置换公钥证书
+func CertifySwitchingKey(issuer *sm2.PrivateKey, pub *sm2.PublicKey, epoch uint64) (*SwitchingKeyCert, error) {
+	return keyswitch.CertifySwitchingKey(issuer, pub, epoch)
+}
+
+// VerifyCertChain checks chain from the identity key down to the switching key of
+// epoch and returns that key.
+// It is a wrapper of keyswitch.VerifyCertChain.
+// 验证证书链：检查由身份公钥identity至纪元epoch的置换公钥的证书链chain，返回该置换公钥。
+//
+// 参数：
+//		身份公钥	identity
+//		证书链		chain
+//		纪元		epoch
+// 返回：
+// 		置换公钥
+func VerifyCertChain(identity *sm2.PublicKey, chain []*SwitchingKeyCert, epoch uint64) (*sm2.PublicKey, error) {
+	return keyswitch.VerifyCertChain(identity, chain, epoch)
+}
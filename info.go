@@ -0,0 +1,100 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Version is the version of the ppks protocol implemented by this package, the
+// "ppks v3.0" heading the README. It is raised by hand with the share equations
+// or the proof transcripts, independently of EncodingVersion, and the README
+// heading with it.
+// 本包实现的ppks协议版本，即README开头的"ppks v3.0"。份额计算公式或证明记录改变时手动递增，
+// 与EncodingVersion相互独立，并同步修改README开头的版本。
+const Version = "3.0"
+
+// EncodingVersion is the version of the wire encodings of points and proofs. It
+// changes whenever a serialized value would no longer be read by older nodes.
+// 点与证明序列化格式的版本，序列化结果不再能被旧节点读取时递增。
+const EncodingVersion = 1
+
+// AlgorithmInfo describes the algorithms and encodings a node uses, so operators
+// can check that every node of a fleet is compatible before a key ceremony.
+// 算法信息：节点所用的算法及编码，运维人员可在密钥仪式前据此确认各节点参数兼容。
+type AlgorithmInfo struct {
+	// Version is the protocol version. 协议版本。
+	Version string `json:"version"`
+	// Curve is the name of the elliptic curve. 椭圆曲线名称。
+	Curve string `json:"curve"`
+	// Hash is the Fiat-Shamir challenge hash. Fiat-Shamir挑战哈希。
+	Hash string `json:"hash"`
+	// HashID is the identifier of Hash written into serialized proofs.
+	// 写入序列化证明的挑战哈希标识。
+	HashID uint8 `json:"hash_id"`
+	// Context is the context of the proof suite, empty when none is set: proofs
+	// made under one context do not verify under another.
+	// 证明参数组的上下文，未设置时为空：在某一上下文下生成的证明在其他上下文下无法通过验证。
+	Context string `json:"context"`
+	// ProofScheme names the share proof. 份额证明方案。
+	ProofScheme string `json:"proof_scheme"`
+	// PointEncoding names the encoding of curve points. 曲线点编码方式。
+	PointEncoding string `json:"point_encoding"`
+	// EncodingVersion is EncodingVersion. 序列化格式版本。
+	EncodingVersion int `json:"encoding_version"`
+}
+
+// Info returns the algorithm information of this package with the default proof suite.
+// 返回本包使用默认证明参数组时的算法信息。
+//
+// 参数：
+//
+// 返回：
+// 		算法信息
+func Info() AlgorithmInfo {
+	return InfoWithSuite(proof.DefaultSuite)
+}
+
+// InfoWithSuite returns the algorithm information of this package with the proof suite s.
+// 返回本包使用证明参数组s时的算法信息。
+//
+// 参数：
+//		证明参数组	s
+// 返回：
+// 		算法信息
+func InfoWithSuite(s proof.Suite) AlgorithmInfo {
+	if s.Hash == 0 {
+		s.Hash = proof.DefaultSuite.Hash
+	}
+	return AlgorithmInfo{
+		Version:         Version,
+		Curve:           sm2.P256Sm2().Params().Name,
+		Hash:            s.Hash.String(),
+		HashID:          uint8(s.Hash),
+		Context:         s.Context,
+		ProofScheme:     "fiat-shamir-transcript-dlog-linear",
+		PointEncoding:   "sec1",
+		EncodingVersion: EncodingVersion,
+	}
+}
+
+// Compatible reports whether nodes described by info and other can work together.
+// 判断info与other所描述的节点能否协同工作。
+func (info AlgorithmInfo) Compatible(other AlgorithmInfo) bool {
+	return info == other
+}
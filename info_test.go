@@ -0,0 +1,73 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"ppks/proof"
+)
+
+func TestVersionReadme(t *testing.T) {
+	// 协议版本须与README开头的版本一致
+	b, err := ioutil.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte("ppks v"+Version+"\n")) {
+		t.Fatalf("README does not name ppks v%s", Version)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	info := Info()
+	if info.Curve == "" || info.Hash != "SM3" || info.EncodingVersion != EncodingVersion {
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	// 机器可读：经JSON往返后仍兼容
+	b, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remote AlgorithmInfo
+	if err := json.Unmarshal(b, &remote); err != nil {
+		t.Fatal(err)
+	}
+	if !info.Compatible(remote) {
+		t.Fatalf("round-tripped info %s is not compatible", b)
+	}
+
+	sha := InfoWithSuite(proof.Suite{Hash: proof.HashSHA256})
+	if sha.Hash != "SHA-256" || info.Compatible(sha) {
+		t.Fatal("nodes with different challenge hashes reported compatible")
+	}
+
+	// 零哈希只取默认哈希，上下文保留；上下文不同的节点不兼容
+	ctx := InfoWithSuite(proof.Suite{Context: "fleet-a"})
+	if ctx.Hash != info.Hash || ctx.Context != "fleet-a" || info.Compatible(ctx) {
+		t.Fatalf("context lost or ignored: %+v", ctx)
+	}
+	if ctx.Compatible(InfoWithSuite(proof.Suite{Hash: proof.HashSM3, Context: "fleet-b"})) {
+		t.Fatal("nodes with different contexts reported compatible")
+	}
+	if !ctx.Compatible(InfoWithSuite(proof.Suite{Hash: proof.HashSM3, Context: "fleet-a"})) {
+		t.Fatal("nodes with the same context reported incompatible")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"sync"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// backends holds the implementations registered by RegisterBackend, keyed by
+// backend name.
+// RegisterBackend注册的曲线实现，以后端名称为键。
+var backends = struct {
+	sync.RWMutex
+	m map[string][]elliptic.Curve
+}{m: make(map[string][]elliptic.Curve)}
+
+// RegisterBackend registers impl under name as an arithmetic backend for the
+// curve with the same parameters, replacing any earlier implementation of that
+// curve under name. It reports false, registering nothing, when name is empty or
+// impl fails a known-answer check of its scalar multiplications against the
+// Jacobian arithmetic of this package.
+// 注册算术后端：以name注册impl，作为与其参数相同的曲线的算术实现，替换name下该曲线此前的实现。
+// name为空，或impl的标量乘与本包Jacobian运算的已知答案比对不一致时，不注册并返回false。
+func RegisterBackend(name string, impl elliptic.Curve) bool {
+	if name == "" || impl == nil || impl.Params() == nil || !selfTest(impl) {
+		return false
+	}
+	backends.Lock()
+	defer backends.Unlock()
+	impls := backends.m[name]
+	for i, c := range impls {
+		if sameParams(c.Params(), impl.Params()) {
+			impls[i] = impl
+			return true
+		}
+	}
+	backends.m[name] = append(impls, impl)
+	return true
+}
+
+// Backend returns the implementation registered under name for curve, reporting
+// false when there is none.
+// 返回以name为curve注册的实现，不存在时返回false。
+func Backend(name string, curve elliptic.Curve) (elliptic.Curve, bool) {
+	params := curve.Params()
+	backends.RLock()
+	defer backends.RUnlock()
+	for _, c := range backends.m[name] {
+		if sameParams(c.Params(), params) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// sameParams reports whether a and b describe the same curve.
+// 判断a与b是否描述同一曲线。
+func sameParams(a, b *elliptic.CurveParams) bool {
+	if a == b {
+		return true
+	}
+	return a.Name == b.Name && a.BitSize == b.BitSize && a.P.Cmp(b.P) == 0 &&
+		a.N.Cmp(b.N) == 0 && a.B.Cmp(b.B) == 0 && a.Gx.Cmp(b.Gx) == 0 && a.Gy.Cmp(b.Gy) == 0
+}
+
+// selfTest checks the generator of impl, (N-1)*G = -G, and one fixed-base and one
+// variable-base multiplication by a hashed scalar against MultiScalarMult.
+// 自检：校验impl的生成元、(N-1)*G = -G，并将以哈希标量进行的一次固定基点与一次变基点标量乘
+// 与MultiScalarMult的结果比对。
+func selfTest(impl elliptic.Curve) bool {
+	params := impl.Params()
+	if params.N == nil || params.Gx == nil || params.Gy == nil || !IsValidXY(impl, params.Gx, params.Gy) {
+		return false
+	}
+	nx, ny := Neg(impl, params.Gx, params.Gy)
+	n1 := new(big.Int).Sub(params.N, one)
+	if x, y := impl.ScalarBaseMult(n1.Bytes()); x.Cmp(nx) != 0 || y.Cmp(ny) != 0 {
+		return false
+	}
+
+	h := sm3.New()
+	h.Write([]byte("ppks backend self-test"))
+	k := new(big.Int).SetBytes(h.Sum(nil))
+	k.Mod(k, params.N)
+	wx, wy := MultiScalarMult(impl, []*big.Int{params.Gx}, []*big.Int{params.Gy}, []*big.Int{k})
+	if x, y := impl.ScalarBaseMult(k.Bytes()); x.Cmp(wx) != 0 || y.Cmp(wy) != 0 {
+		return false
+	}
+	px, py := impl.Double(params.Gx, params.Gy)
+	wx, wy = MultiScalarMult(impl, []*big.Int{px}, []*big.Int{py}, []*big.Int{k})
+	x, y := impl.ScalarMult(px, py, k.Bytes())
+	return x.Cmp(wx) == 0 && y.Cmp(wy) == 0
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// Context holds what an operation over many points of one curve would otherwise
+// look up for every point: N, P, Gx and Gy from Params, and the Jacobian
+// arithmetic of the curve. Loops take it once, before the first point.
+// 运算上下文：保存对同一曲线上大量点的运算中原本逐点查找的内容，即Params中的N、P、Gx、Gy，
+// 以及该曲线的Jacobian运算。循环在处理第一个点之前获取一次。
+type Context struct {
+	Curve        elliptic.Curve
+	N, P, Gx, Gy *big.Int
+	params       *elliptic.CurveParams
+	jc           *jacobianCurve
+	neg          negater
+}
+
+// NewContext returns the context of curve, or nil for a nil curve.
+// 返回曲线curve的运算上下文，curve为nil时返回nil。
+func NewContext(curve elliptic.Curve) *Context {
+	if curve == nil {
+		return nil
+	}
+	params := curve.Params()
+	c := &Context{Curve: curve, N: params.N, P: params.P, Gx: params.Gx, Gy: params.Gy, params: params}
+	c.jc, _ = jacobianOf(curve)
+	c.neg, _ = curve.(negater)
+	return c
+}
+
+// Has reports whether curve is the curve of c, as the packages compare curves: by
+// identity of their parameters.
+// 判断curve是否为c的曲线，与各包比较曲线的方式相同：比较参数的同一性。
+func (c *Context) Has(curve elliptic.Curve) bool {
+	return c != nil && curve != nil && curve.Params() == c.params
+}
+
+// IsValidXY is IsValidXY on the curve of c.
+// 即c的曲线上的IsValidXY。
+func (c *Context) IsValidXY(x, y *big.Int) bool {
+	return validXY(c.Curve, c.P, c.jc, x, y)
+}
+
+// NegTo is NegTo on the curve of c.
+// 即c的曲线上的NegTo。
+func (c *Context) NegTo(rx, ry, x, y *big.Int) {
+	if c.neg != nil {
+		nx, ny := c.neg.Neg(x, y)
+		rx.Set(nx)
+		ry.Set(ny)
+		return
+	}
+	rx.Set(x)
+	ry.Sub(c.P, y)
+	ry.Mod(ry, c.P)
+}
+
+// Sum returns an empty sum of points of the curve of c.
+// 返回c的曲线上的空点和。
+func (c *Context) Sum() Sum {
+	return Sum{c: c, acc: infinity()}
+}
+
+// Sum accumulates points of one curve. On curves with Jacobian arithmetic the
+// additions make no inversion, and Point makes one, so summing n points costs
+// one inversion instead of n.
+// 点和：累加同一曲线上的点。对有Jacobian运算的曲线，点加不求逆，仅Point求逆一次，
+// 累加n个点只需一次而非n次求逆。
+type Sum struct {
+	c    *Context
+	acc  jacobian
+	x, y *big.Int
+}
+
+// Add adds the point (x,y); the point at infinity (0,0) is skipped.
+// 累加点(x,y)；跳过无穷远点(0,0)。
+func (s *Sum) Add(x, y *big.Int) {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return
+	}
+	if jc := s.c.jc; jc != nil {
+		q := jc.toAffine(x, y)
+		jc.addAffine(&s.acc, &q)
+		return
+	}
+	if s.x == nil {
+		s.x, s.y = new(big.Int).Set(x), new(big.Int).Set(y)
+		return
+	}
+	s.x, s.y = Add(s.c.Curve, s.x, s.y, x, y)
+}
+
+// AddSum adds the points summed by t, which must be of the same context.
+// 累加t中的点和，t须属于同一上下文。
+func (s *Sum) AddSum(t *Sum) {
+	if jc := s.c.jc; jc != nil {
+		jc.add(&s.acc, &t.acc)
+		return
+	}
+	if t.x != nil {
+		s.Add(t.x, t.y)
+	}
+}
+
+// Point returns the sum in affine coordinates, (0,0) for the point at infinity.
+// 返回点和的仿射坐标，无穷远点为(0,0)。
+func (s *Sum) Point() (*big.Int, *big.Int) {
+	if jc := s.c.jc; jc != nil {
+		return jc.affine(&s.acc)
+	}
+	if s.x == nil {
+		return new(big.Int), new(big.Int)
+	}
+	return new(big.Int).Set(s.x), new(big.Int).Set(s.y)
+}
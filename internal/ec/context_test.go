@@ -0,0 +1,81 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestContextSum(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		ctx := NewContext(curve)
+		params := curve.Params()
+		xs := make([]*big.Int, 12)
+		ys := make([]*big.Int, 12)
+		for i := range xs {
+			d, err := rand.Int(rand.Reader, params.N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			xs[i], ys[i] = curve.ScalarBaseMult(d.Bytes())
+		}
+		// 重复的点与互为相反的点
+		xs[5], ys[5] = xs[2], ys[2]
+		xs[7], ys[7] = Neg(curve, xs[6], ys[6])
+
+		wx, wy := xs[0], ys[0]
+		for i := 1; i < len(xs); i++ {
+			wx, wy = Add(curve, wx, wy, xs[i], ys[i])
+		}
+		sum, half := ctx.Sum(), ctx.Sum()
+		for i := range xs {
+			if i < len(xs)/2 {
+				half.Add(xs[i], ys[i])
+			} else {
+				sum.Add(xs[i], ys[i])
+			}
+		}
+		sum.Add(new(big.Int), new(big.Int))
+		sum.AddSum(&half)
+		if x, y := sum.Point(); x.Cmp(wx) != 0 || y.Cmp(wy) != 0 {
+			t.Fatalf("%s: sum differs from repeated Add", params.Name)
+		}
+		empty := ctx.Sum()
+		if x, y := empty.Point(); x.Sign() != 0 || y.Sign() != 0 {
+			t.Fatalf("%s: empty sum is not the point at infinity", params.Name)
+		}
+
+		if !ctx.IsValidXY(wx, wy) || ctx.IsValidXY(wx, new(big.Int).Add(wy, one)) {
+			t.Fatalf("%s: IsValidXY disagrees with the curve", params.Name)
+		}
+		nx, ny := new(big.Int), new(big.Int)
+		ctx.NegTo(nx, ny, xs[6], ys[6])
+		if nx.Cmp(xs[7]) != 0 || ny.Cmp(ys[7]) != 0 {
+			t.Fatalf("%s: NegTo differs from Neg", params.Name)
+		}
+		if !ctx.Has(curve) || ctx.Has(nil) || (curve == elliptic.P256()) == ctx.Has(sm2.P256Sm2()) {
+			t.Fatalf("%s: Has misidentifies curves", params.Name)
+		}
+	}
+	if NewContext(nil) != nil {
+		t.Fatal("context of a nil curve")
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ec holds curve helpers shared by the ppks packages.
+// 各ppks包共用的椭圆曲线辅助函数。
+package ec
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+var one = new(big.Int).SetInt64(1)
+
+// RandFieldElement generates a random k in Z_curve.N and returns.
+// 在椭圆曲线对生成元点G的秩N内生成随机数并返回。
+func RandFieldElement(c elliptic.Curve, random io.Reader) (k *big.Int, err error) {
+	if random == nil {
+		random = rand.Reader //If there is no external trusted random source,please use rand.Reader to instead of it.
+	}
+	params := c.Params()
+	b := make([]byte, params.BitSize/8+8)
+	_, err = io.ReadFull(random, b)
+	if err != nil {
+		return
+	}
+	k = new(big.Int).SetBytes(b)
+	n := new(big.Int).Sub(params.N, one)
+	k.Mod(k, n)
+	k.Add(k, one)
+	return
+}
+
+// RandFieldElements generates count random k in Z_curve.N, each as
+// RandFieldElement does, from a single read of random; the buffer is wiped
+// before returning.
+// 以对random的一次读取生成count个Z_curve.N内的随机数，每个与RandFieldElement的生成方式相同；
+// 返回前擦除缓冲区。
+func RandFieldElements(c elliptic.Curve, random io.Reader, count int) ([]*big.Int, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	params := c.Params()
+	size := params.BitSize/8 + 8
+	buf := make([]byte, size*count)
+	defer func() {
+		for i := range buf {
+			buf[i] = 0
+		}
+	}()
+	if _, err := io.ReadFull(random, buf); err != nil {
+		return nil, err
+	}
+	n := new(big.Int).Sub(params.N, one)
+	ks := make([]*big.Int, count)
+	for i := range ks {
+		k := new(big.Int).SetBytes(buf[i*size : (i+1)*size])
+		k.Mod(k, n)
+		ks[i] = k.Add(k, one)
+	}
+	return ks, nil
+}
+
+// IsValidXY reports whether (x,y) is a finite point on curve with coordinates in [0,P).
+// 判断(x,y)是否为曲线curve上坐标位于[0,P)内的有限点。
+func IsValidXY(curve elliptic.Curve, x, y *big.Int) bool {
+	jc, _ := jacobianOf(curve)
+	return validXY(curve, curve.Params().P, jc, x, y)
+}
+
+// validXY is IsValidXY with the field prime P and the Jacobian arithmetic jc of
+// curve, nil when it has none, already looked up.
+// 即IsValidXY，曲线curve的域素数P及Jacobian运算jc（无则为nil）已预先取得。
+func validXY(curve elliptic.Curve, P *big.Int, jc *jacobianCurve, x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return false
+	}
+
+	// 坐标须位于[0,P)内，且不为无穷远点(0,0)
+	if x.Sign() < 0 || x.Cmp(P) >= 0 || y.Sign() < 0 || y.Cmp(P) >= 0 {
+		return false
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+	// 短Weierstrass曲线在Montgomery域上检查曲线方程，免去曲线自身实现的内存分配
+	if jc != nil {
+		return jc.onCurve(x, y)
+	}
+	return curve.IsOnCurve(x, y)
+}
+
+// LiftX returns the y coordinate of the point with abscissa x and the given parity,
+// i.e. a square root of x^3 - 3x + b mod P.
+// 由横坐标x求纵坐标：计算x^3 - 3x + b模P的平方根，并按奇偶性odd选取。
+func LiftX(curve elliptic.Curve, x *big.Int, odd bool) (*big.Int, bool) {
+	params := curve.Params()
+
+	// y^2 = x^3 - 3x + b
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	threeX := new(big.Int).Lsh(x, 1)
+	threeX.Add(threeX, x)
+	y2.Sub(y2, threeX)
+	y2.Add(y2, params.B)
+	y2.Mod(y2, params.P)
+
+	y := new(big.Int).ModSqrt(y2, params.P)
+	if y == nil {
+		return nil, false
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(params.P, y)
+		y.Mod(y, params.P)
+	}
+	return y, true
+}
+
+// Add returns (x1,y1)+(x2,y2) on curve, doubling when the points are equal since
+// the gmsm SM2 Add does not handle that case.
+// 点加：返回(x1,y1)+(x2,y2)；两点相同时改用倍点运算，因gmsm的SM2点加不处理该情形。
+func Add(curve elliptic.Curve, x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0 {
+		return curve.Double(x1, y1)
+	}
+	return curve.Add(x1, y1, x2, y2)
+}
+
+// negater is implemented by curves whose negation is not (x, P-y), such as groups
+// presented in Edwards coordinates.
+// 取负运算不为(x, P-y)的曲线实现此接口，如以Edwards坐标表示的群。
+type negater interface {
+	Neg(x, y *big.Int) (*big.Int, *big.Int)
+}
+
+// Neg returns -(x,y) on curve: (x, P-y) for short Weierstrass curves such as SM2,
+// or the negation of the curve itself when it provides one.
+// 点取负：返回曲线curve上的点-(x,y)，对SM2等短Weierstrass曲线为(x, P-y)，
+// 曲线自身提供取负运算时使用该运算。
+func Neg(curve elliptic.Curve, x, y *big.Int) (*big.Int, *big.Int) {
+	if n, ok := curve.(negater); ok {
+		return n.Neg(x, y)
+	}
+	P := curve.Params().P
+	negY := new(big.Int).Sub(P, y)
+	negY.Mod(negY, P)
+	return new(big.Int).Set(x), negY
+}
+
+// NegTo sets (rx,ry) to -(x,y) on curve, like Neg but into the given integers,
+// which may be x and y.
+// 令(rx,ry)为曲线curve上的-(x,y)，同Neg但写入给定的整数，可与x、y相同。
+func NegTo(curve elliptic.Curve, rx, ry, x, y *big.Int) {
+	if n, ok := curve.(negater); ok {
+		nx, ny := n.Neg(x, y)
+		rx.Set(nx)
+		ry.Set(ny)
+		return
+	}
+	P := curve.Params().P
+	rx.Set(x)
+	ry.Sub(P, y)
+	ry.Mod(ry, P)
+}
+
+// KDF is the key derivation function of GM/T 0003.4: it concatenates
+// SM3(Z || ct) for ct = 1, 2, ... and returns the first length bytes, where Z is
+// the concatenation of z.
+// GM/T 0003.4中的密钥派生函数：依次拼接SM3(Z || ct)，ct = 1, 2, ...，取前length字节，
+// 其中Z为z的拼接。
+func KDF(length int, z ...[]byte) []byte {
+	k := make([]byte, 0, length+32)
+	var ct [4]byte
+	for i := uint32(1); len(k) < length; i++ {
+		binary.BigEndian.PutUint32(ct[:], i)
+		h := sm3.New()
+		for _, zi := range z {
+			h.Write(zi)
+		}
+		h.Write(ct[:])
+		k = append(k, h.Sum(nil)...)
+	}
+	return k[:length]
+}
+
+// WipeInt overwrites the words of x with zeros and sets x to 0. Setting x to 0
+// alone only shortens its slice and leaves the words of a secret in memory.
+// 将x的各字清零并置x为0。仅将x置0只会缩短其切片，秘密的各字仍留在内存中。
+func WipeInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	words := x.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	x.SetInt64(0)
+}
+
+// hexPrefixLen is the number of hex digits kept by ShortHex.
+// ShortHex保留的十六进制位数。
+const hexPrefixLen = 8
+
+// ShortHex returns the leading hex digits of the 32-byte big-endian form of n.
+// 返回n的32字节大端表示的十六进制前缀。
+func ShortHex(n *big.Int) string {
+	if n == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%064x", n)[:hexPrefixLen] + "..."
+}
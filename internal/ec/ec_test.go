@@ -0,0 +1,72 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestIsValidXY(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		params := curve.Params()
+		for i := 0; i < 20; i++ {
+			d, err := rand.Int(rand.Reader, params.N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			x, y := curve.ScalarBaseMult(d.Bytes())
+			if !IsValidXY(curve, x, y) {
+				t.Fatalf("%s: point on the curve rejected", params.Name)
+			}
+			// 改动纵坐标后不在曲线上
+			y1 := new(big.Int).Add(y, big.NewInt(1))
+			y1.Mod(y1, params.P)
+			if IsValidXY(curve, x, y1) != curve.IsOnCurve(x, y1) {
+				t.Fatalf("%s: disagrees with the curve on (x, y+1)", params.Name)
+			}
+		}
+		if IsValidXY(curve, new(big.Int), new(big.Int)) {
+			t.Fatalf("%s: point at infinity accepted", params.Name)
+		}
+		if IsValidXY(curve, params.P, params.Gy) {
+			t.Fatalf("%s: coordinate out of range accepted", params.Name)
+		}
+	}
+}
+
+func TestWipeInt(t *testing.T) {
+	x, ok := new(big.Int).SetString("f0e1d2c3b4a5968778695a4b3c2d1e0f0123456789abcdef", 16)
+	if !ok {
+		t.Fatal("bad constant")
+	}
+	words := x.Bits()
+	WipeInt(x)
+	if x.Sign() != 0 {
+		t.Fatal("x not zero")
+	}
+	// 底层各字须被清零，而非仅缩短切片
+	for i, w := range words {
+		if w != 0 {
+			t.Fatalf("word %d left in memory", i)
+		}
+	}
+	WipeInt(nil)
+}
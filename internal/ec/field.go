@@ -0,0 +1,230 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// fe is an element of a prime field of at most 256 bits in Montgomery form,
+// x*2^256 mod p, as four little-endian 64-bit limbs.
+// 至多256比特素域中的元素，以Montgomery形式x*2^256 mod p保存为四个小端64比特字。
+type fe [4]uint64
+
+// montField is the Montgomery arithmetic modulo an odd prime p < 2^256. Unlike
+// math/big it needs no division nor allocation, which makes point arithmetic in
+// Jacobian coordinates an order of magnitude faster.
+// 模奇素数p < 2^256的Montgomery运算。与math/big不同，无需除法与内存分配，Jacobian坐标下的
+// 点运算因此快一个数量级。
+type montField struct {
+	p    fe
+	pinv uint64 // -p^-1 mod 2^64
+	one  fe
+	rr   fe // 2^512 mod p, converting into Montgomery form
+	big  *big.Int
+}
+
+// newMontField returns the field modulo p, or false unless p is odd and at most
+// 256 bits.
+// 返回模p的域，p须为至多256比特的奇数，否则返回false。
+func newMontField(p *big.Int) (*montField, bool) {
+	if p.Bit(0) == 0 || p.BitLen() > 256 {
+		return nil, false
+	}
+	f := &montField{big: new(big.Int).Set(p)}
+	f.p = limbs(p)
+	// 牛顿迭代求p0^-1 mod 2^64
+	inv := f.p[0]
+	for i := 0; i < 5; i++ {
+		inv *= 2 - f.p[0]*inv
+	}
+	f.pinv = -inv
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	f.one = limbs(new(big.Int).Mod(r, p))
+	f.rr = limbs(r.Mod(r.Mul(r, r), p))
+	return f, true
+}
+
+// limbs returns the little-endian limbs of x < 2^256.
+// 返回x < 2^256的小端64比特字。
+func limbs(x *big.Int) fe {
+	var b [32]byte
+	x.FillBytes(b[:])
+	var z fe
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			z[i] |= uint64(b[31-8*i-j]) << uint(8*j)
+		}
+	}
+	return z
+}
+
+// fromBig returns x, in [0,p), in Montgomery form.
+// 返回[0,p)内的x的Montgomery形式。
+func (f *montField) fromBig(x *big.Int) fe {
+	z := limbs(x)
+	f.mul(&z, &z, &f.rr)
+	return z
+}
+
+// toBig returns the integer of the Montgomery form x.
+// 返回Montgomery形式x对应的整数。
+func (f *montField) toBig(x *fe) *big.Int {
+	var z fe
+	f.mul(&z, x, &fe{1})
+	var b [32]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[31-8*i-j] = byte(z[i] >> uint(8*j))
+		}
+	}
+	return new(big.Int).SetBytes(b[:])
+}
+
+// mul sets z = x*y/2^256 mod p (CIOS Montgomery multiplication).
+// 令z = x*y/2^256 mod p（CIOS Montgomery乘法）。
+func (f *montField) mul(z, x, y *fe) {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		// t += x*y[i]
+		var C uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(x[j], y[i])
+			var c uint64
+			lo, c = bits.Add64(lo, t[j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, C, 0)
+			hi += c
+			t[j], C = lo, hi
+		}
+		var c uint64
+		t[4], c = bits.Add64(t[4], C, 0)
+		t[5] = c
+
+		// t = (t + m*p) / 2^64
+		m := t[0] * f.pinv
+		hi, lo := bits.Mul64(m, f.p[0])
+		_, c = bits.Add64(lo, t[0], 0)
+		C = hi + c
+		for j := 1; j < 4; j++ {
+			hi, lo := bits.Mul64(m, f.p[j])
+			lo, c = bits.Add64(lo, t[j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, C, 0)
+			hi += c
+			t[j-1], C = lo, hi
+		}
+		t[3], c = bits.Add64(t[4], C, 0)
+		t[4] = t[5] + c
+	}
+	f.reduce(z, &fe{t[0], t[1], t[2], t[3]}, t[4])
+}
+
+// reduce sets z = x + carry*2^256 - p if that is not negative, and z = x otherwise.
+// The choice is made with a mask, not a branch, so that the field arithmetic runs
+// in constant time.
+// 若x + carry*2^256 - p非负则令z为该值，否则令z = x。以掩码而非分支选择，使域运算为常数时间。
+func (f *montField) reduce(z, x *fe, carry uint64) {
+	var d fe
+	var b uint64
+	d[0], b = bits.Sub64(x[0], f.p[0], 0)
+	d[1], b = bits.Sub64(x[1], f.p[1], b)
+	d[2], b = bits.Sub64(x[2], f.p[2], b)
+	d[3], b = bits.Sub64(x[3], f.p[3], b)
+	// carry为0且有借位时保留x
+	feSelect(z, x, &d, -(b &^ carry))
+}
+
+// add sets z = x+y mod p.
+// 令z = x+y mod p。
+func (f *montField) add(z, x, y *fe) {
+	var s fe
+	var c uint64
+	s[0], c = bits.Add64(x[0], y[0], 0)
+	s[1], c = bits.Add64(x[1], y[1], c)
+	s[2], c = bits.Add64(x[2], y[2], c)
+	s[3], c = bits.Add64(x[3], y[3], c)
+	f.reduce(z, &s, c)
+}
+
+// sub sets z = x-y mod p.
+// 令z = x-y mod p。
+func (f *montField) sub(z, x, y *fe) {
+	var d fe
+	var b uint64
+	d[0], b = bits.Sub64(x[0], y[0], 0)
+	d[1], b = bits.Sub64(x[1], y[1], b)
+	d[2], b = bits.Sub64(x[2], y[2], b)
+	d[3], b = bits.Sub64(x[3], y[3], b)
+	// 有借位时加回p，以掩码代替分支
+	m := -b
+	var c uint64
+	z[0], c = bits.Add64(d[0], f.p[0]&m, 0)
+	z[1], c = bits.Add64(d[1], f.p[1]&m, c)
+	z[2], c = bits.Add64(d[2], f.p[2]&m, c)
+	z[3], _ = bits.Add64(d[3], f.p[3]&m, c)
+}
+
+// inv sets z = x^-1 mod p; the inverse of zero is zero. A single ModInverse
+// outruns the 256 squarings of x^(p-2) in this field.
+// 令z = x^-1 mod p，零的逆为零。单次ModInverse快于在本域中以x^(p-2)求逆所需的256次平方。
+func (f *montField) inv(z, x *fe) {
+	v := f.toBig(x)
+	if v.Sign() == 0 {
+		*z = fe{}
+		return
+	}
+	*z = f.fromBig(v.ModInverse(v, f.big))
+}
+
+// invCT sets z = x^(p-2) mod p, the inverse of a nonzero x, in constant time:
+// the exponent is public and x only goes through mul. It is slower than inv, so
+// it is kept for secret values.
+// 以常数时间令z = x^(p-2) mod p，即非零x的逆：指数公开，x只参与mul运算。慢于inv，仅用于秘密值。
+func (f *montField) invCT(z, x *fe) {
+	e := new(big.Int).Sub(f.big, big.NewInt(2))
+	r := f.one
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		f.mul(&r, &r, &r)
+		if e.Bit(i) == 1 {
+			f.mul(&r, &r, x)
+		}
+	}
+	*z = r
+}
+
+// feSelect sets z = x if mask is all ones and z = y if it is zero, without
+// branching on mask.
+// mask全为1时令z = x，为0时令z = y，不依据mask分支。
+func feSelect(z, x, y *fe, mask uint64) {
+	for i := range z {
+		z[i] = x[i]&mask | y[i]&^mask
+	}
+}
+
+// ctEq returns all ones if a == b and zero otherwise, in constant time.
+// 以常数时间比较：a == b时返回全1，否则返回0。
+func ctEq(a, b uint64) uint64 {
+	x := a ^ b
+	return ((x | -x) >> 63) - 1
+}
+
+// isZero reports whether x is zero.
+// 判断x是否为零。
+func (x *fe) isZero() bool {
+	return x[0]|x[1]|x[2]|x[3] == 0
+}
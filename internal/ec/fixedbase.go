@@ -0,0 +1,154 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// FixedBase is a precomputed table for multiplying one fixed point by many
+// secret scalars: row i holds j*256^i*P for j in [1,255], so a multiplication is
+// one point addition per scalar byte instead of a full double-and-add. It runs in
+// constant time: every byte, zero or not, reads all 255 entries of its row and
+// keeps the wanted one with a mask, the additions run in Jacobian coordinates on
+// branch-free field arithmetic, and the final inversion is a fixed
+// exponentiation. A table takes about 8000 point additions to build and 0.5 MiB
+// to hold, and is only built on short Weierstrass curves; on other curves
+// ScalarMult is the curve's own. It is safe for concurrent use.
+// 固定基点预计算表：用于以许多秘密标量乘同一固定点P。第i行存放j*256^i*P，j∈[1,255]，一次标量乘
+// 每个标量字节一次点加，而非完整的倍点-点加。运算为常数时间：每个字节（无论是否为零）都读取所在行
+// 全部255项并以掩码保留所需项，点加在无分支的域运算上以Jacobian坐标进行，最后的求逆为固定指数的幂运算。
+// 建表约需8000次点加、占用约0.5 MiB，且仅对短Weierstrass曲线建表，其他曲线的ScalarMult即曲线
+// 自身的标量乘。FixedBase可并发使用。
+type FixedBase struct {
+	curve elliptic.Curve
+	x, y  *big.Int
+	jc    *jacobianCurve
+	mont  [][255]affinePoint
+}
+
+// NewFixedBase precomputes the table of (x,y) on curve.
+// 预计算曲线curve上点(x,y)的固定基点表。
+func NewFixedBase(curve elliptic.Curve, x, y *big.Int) *FixedBase {
+	t := &FixedBase{
+		curve: curve,
+		x:     new(big.Int).Set(x),
+		y:     new(big.Int).Set(y),
+	}
+	jc, ok := jacobianOf(curve)
+	if !ok {
+		return t
+	}
+	t.jc = jc
+	t.mont = make([][255]affinePoint, (curve.Params().N.BitLen()+7)/8)
+	js := make([]jacobian, 256)
+	out := make([]affinePoint, 256)
+	finite := make([]bool, 256)
+	base := jc.toAffine(x, y)
+	for i := range t.mont {
+		// 第i行：j*256^i*P，末项256*256^i*P为下一行的基点，整行一并归一化
+		acc := infinity()
+		for j := range js {
+			jc.addAffine(&acc, &base)
+			js[j] = acc
+		}
+		jc.normalizeAll(js, out, finite)
+		copy(t.mont[i][:], out)
+		base = out[255]
+	}
+	return t
+}
+
+// Point returns the fixed point of t.
+// 返回t的固定基点。
+func (t *FixedBase) Point() (x, y *big.Int) {
+	return t.x, t.y
+}
+
+// Curve returns the curve of t.
+// 返回t所在的曲线。
+func (t *FixedBase) Curve() elliptic.Curve {
+	return t.curve
+}
+
+// ScalarMult returns k*P for the big-endian scalar k, like elliptic.Curve's
+// ScalarMult, in constant time. Scalars not below N are first reduced mod N, as
+// the SM2 curve of gmsm does.
+// 固定基点标量乘：以常数时间返回大端序标量k与P之积，与elliptic.Curve的ScalarMult相同。
+// 不小于N的标量先模N约化，与gmsm的SM2曲线相同。
+func (t *FixedBase) ScalarMult(k []byte) (*big.Int, *big.Int) {
+	if t.mont == nil {
+		return t.curve.ScalarMult(t.x, t.y, k)
+	}
+	n := len(t.mont)
+	N := t.curve.Params().N
+	if len(k) > n || (len(k) == n && new(big.Int).SetBytes(k).Cmp(N) >= 0) {
+		k = new(big.Int).Mod(new(big.Int).SetBytes(k), N).Bytes()
+	}
+	scalar := make([]byte, n)
+	copy(scalar[n-len(k):], k)
+	defer func() {
+		for i := range scalar {
+			scalar[i] = 0
+		}
+	}()
+
+	// k < N保证每次点加的两点既不相同也不互为相反点：此前累加值为(k mod 256^i)*P，
+	// 小于256^i <= b*256^i，且二者之和不超过k
+	jc, f := t.jc, t.jc.f
+	acc := infinity()
+	inf := ^uint64(0) // acc为无穷远点时全1
+	var q affinePoint
+	var sum, first jacobian
+	for i := 0; i < n; i++ {
+		b := uint64(scalar[n-1-i])
+		lookup(&q, &t.mont[i], b)
+		jc.madd(&sum, &acc, &q)
+		first = jacobian{x: q.x, y: q.y, z: f.one}
+		// b为零时保持acc；acc为无穷远点时取q，否则取acc+q
+		keep := ctEq(b, 0)
+		feSelect(&sum.x, &first.x, &sum.x, inf)
+		feSelect(&sum.y, &first.y, &sum.y, inf)
+		feSelect(&sum.z, &first.z, &sum.z, inf)
+		feSelect(&acc.x, &acc.x, &sum.x, keep)
+		feSelect(&acc.y, &acc.y, &sum.y, keep)
+		feSelect(&acc.z, &acc.z, &sum.z, keep)
+		inf &= keep
+	}
+	if inf != 0 {
+		return new(big.Int), new(big.Int)
+	}
+	var zinv, zinv2, x, y fe
+	f.invCT(&zinv, &acc.z)
+	f.mul(&zinv2, &zinv, &zinv)
+	f.mul(&x, &acc.x, &zinv2)
+	f.mul(&zinv2, &zinv2, &zinv)
+	f.mul(&y, &acc.y, &zinv2)
+	return f.toBig(&x), f.toBig(&y)
+}
+
+// lookup sets q to row[b-1], or to zero for b = 0, reading every entry of row
+// so that the access pattern does not depend on b.
+// 令q为row[b-1]，b为0时令q为零；读取row的每一项，使访存模式与b无关。
+func lookup(q *affinePoint, row *[255]affinePoint, b uint64) {
+	*q = affinePoint{}
+	for j := range row {
+		m := ctEq(uint64(j+1), b)
+		feSelect(&q.x, &row[j].x, &q.x, m)
+		feSelect(&q.y, &row[j].y, &q.y, m)
+	}
+}
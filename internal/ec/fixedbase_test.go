@@ -0,0 +1,65 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestFixedBase(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		params := curve.Params()
+		d, err := rand.Int(rand.Reader, params.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		px, py := curve.ScalarBaseMult(d.Bytes())
+		table := NewFixedBase(curve, px, py)
+
+		n1 := new(big.Int).Sub(params.N, one)
+		scalars := [][]byte{
+			{1}, {0, 0, 7}, {0xff}, n1.Bytes(), params.N.Bytes(),
+			new(big.Int).Add(params.N, big.NewInt(5)).Bytes(),
+			bytes.Repeat([]byte{0xff}, 40),
+			// 含零字节的标量
+			append([]byte{0x80}, make([]byte, 30)...),
+		}
+		for i := 0; i < 20; i++ {
+			k, err := rand.Int(rand.Reader, params.N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scalars = append(scalars, k.Bytes())
+		}
+		for _, k := range scalars {
+			wx, wy := curve.ScalarMult(px, py, k)
+			if x, y := table.ScalarMult(k); x.Cmp(wx) != 0 || y.Cmp(wy) != 0 {
+				t.Fatalf("%s: k = %x: table differs from ScalarMult", params.Name, k)
+			}
+		}
+		for _, k := range [][]byte{nil, {0}, params.N.Bytes()} {
+			if x, y := table.ScalarMult(k); x.Sign() != 0 || y.Sign() != 0 {
+				t.Fatalf("%s: k = %x: want the point at infinity", params.Name, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"sync"
+)
+
+// jacobianCurve is a short Weierstrass curve y^2 = x^3 + a*x + b on which points
+// can be added in Jacobian coordinates over a Montgomery field, deferring
+// inversions to the end of a computation.
+// 可在Montgomery域上以Jacobian坐标点加的短Weierstrass曲线y^2 = x^3 + a*x + b，求逆推迟到
+// 运算结束时进行。
+type jacobianCurve struct {
+	curve elliptic.Curve
+	f     *montField
+	a, b  fe
+	// aIsMinus3 selects the faster doubling of curves with a = -3, such as SM2.
+	// a = -3时（如SM2）使用更快的倍点公式。
+	aIsMinus3 bool
+}
+
+// jacobianCurves caches the jacobianCurve of each curve, or nil for curves not in
+// short Weierstrass form.
+// 各曲线的jacobianCurve缓存，非短Weierstrass形式的曲线为nil。
+var jacobianCurves sync.Map
+
+// jacobianOf returns the Jacobian arithmetic of curve, or false for curves that
+// provide their own negation, whose points are not short Weierstrass affine
+// coordinates, and for fields over 256 bits. The coefficient a is recovered from
+// the generator, since elliptic.CurveParams only records b.
+// 返回曲线curve的Jacobian运算；自身提供取负运算的曲线（其点不是短Weierstrass仿射坐标）及
+// 超过256比特的域返回false。系数a由生成元求得，因elliptic.CurveParams只记录b。
+func jacobianOf(curve elliptic.Curve) (*jacobianCurve, bool) {
+	params := curve.Params()
+	if j, ok := jacobianCurves.Load(params); ok {
+		jc := j.(*jacobianCurve)
+		return jc, jc != nil
+	}
+	var jc *jacobianCurve
+	f, ok := newMontField(params.P)
+	if _, neg := curve.(negater); ok && !neg && params.Gx.Sign() != 0 {
+		// a = (Gy^2 - Gx^3 - b) / Gx
+		p := params.P
+		a := new(big.Int).Mul(params.Gy, params.Gy)
+		x3 := new(big.Int).Mul(params.Gx, params.Gx)
+		x3.Mul(x3, params.Gx)
+		a.Sub(a, x3)
+		a.Sub(a, params.B)
+		a.Mul(a, new(big.Int).ModInverse(params.Gx, p))
+		a.Mod(a, p)
+		minus3 := new(big.Int).Sub(p, big.NewInt(3))
+		jc = &jacobianCurve{curve: curve, f: f, a: f.fromBig(a), b: f.fromBig(params.B), aIsMinus3: a.Cmp(minus3) == 0}
+	}
+	jacobianCurves.Store(params, jc)
+	return jc, jc != nil
+}
+
+// onCurve reports whether y^2 = x^3 + a*x + b for x, y in [0,p).
+// 判断[0,p)内的x, y是否满足y^2 = x^3 + a*x + b。
+func (c *jacobianCurve) onCurve(x, y *big.Int) bool {
+	f := c.f
+	q := c.toAffine(x, y)
+	var lhs, rhs, t fe
+	f.mul(&lhs, &q.y, &q.y)
+	f.mul(&rhs, &q.x, &q.x)
+	f.add(&rhs, &rhs, &c.a)
+	f.mul(&rhs, &rhs, &q.x)
+	f.add(&rhs, &rhs, &c.b)
+	f.sub(&t, &lhs, &rhs)
+	return t.isZero()
+}
+
+// jacobian is a point (x/z^2, y/z^3) in Jacobian coordinates over the Montgomery
+// field of its curve; inf marks the point at infinity.
+// 曲线Montgomery域上Jacobian坐标下的点(x/z^2, y/z^3)；inf表示无穷远点。
+type jacobian struct {
+	x, y, z fe
+	inf     bool
+}
+
+// affinePoint is a finite point in affine coordinates over the Montgomery field.
+// Montgomery域上仿射坐标下的有限点。
+type affinePoint struct {
+	x, y fe
+}
+
+// infinity returns the point at infinity.
+// 返回无穷远点。
+func infinity() jacobian {
+	return jacobian{inf: true}
+}
+
+// toAffine converts the finite point (x,y) to the Montgomery field.
+// 将有限点(x,y)转换到Montgomery域。
+func (c *jacobianCurve) toAffine(x, y *big.Int) affinePoint {
+	return affinePoint{x: c.f.fromBig(x), y: c.f.fromBig(y)}
+}
+
+// addAffine sets j to j+q with a mixed addition (madd-2007-bl).
+// 以混合坐标点加（madd-2007-bl）令j为j+q。
+func (c *jacobianCurve) addAffine(j *jacobian, q *affinePoint) {
+	if j.inf {
+		*j = jacobian{x: q.x, y: q.y, z: c.f.one}
+		return
+	}
+	if sameX, sameY := c.madd(j, j, q); sameX {
+		if sameY {
+			*j = jacobian{x: q.x, y: q.y, z: c.f.one}
+			c.double(j)
+			return
+		}
+		// 互为相反点，和为无穷远点
+		*j = infinity()
+	}
+}
+
+// madd sets out to j+q for a finite j without branching on the coordinates, and
+// reports whether j and q have the same x, and then the same y: in those cases
+// out is not their sum, which addAffine handles. out may be j.
+// 对有限点j不依据坐标分支地令out为j+q，并报告j与q的x坐标是否相同、y坐标是否相同：此时out不是
+// 两点之和，由addAffine处理。out可与j相同。
+func (c *jacobianCurve) madd(out, j *jacobian, q *affinePoint) (sameX, sameY bool) {
+	f := c.f
+	var z1z1, u2, s2, h, r, hh, i, jj, v, t fe
+	f.mul(&z1z1, &j.z, &j.z)
+	f.mul(&u2, &q.x, &z1z1)
+	f.mul(&t, &j.z, &z1z1)
+	f.mul(&s2, &q.y, &t)
+	f.sub(&h, &u2, &j.x)
+	f.sub(&r, &s2, &j.y)
+	f.add(&r, &r, &r)
+	sameX, sameY = h.isZero(), r.isZero()
+	f.mul(&hh, &h, &h)
+	f.add(&i, &hh, &hh)
+	f.add(&i, &i, &i)
+	f.mul(&jj, &h, &i)
+	f.mul(&v, &j.x, &i)
+
+	// x3 = r^2 - J - 2V
+	var x3, y3, z3 fe
+	f.mul(&x3, &r, &r)
+	f.sub(&x3, &x3, &jj)
+	f.sub(&x3, &x3, &v)
+	f.sub(&x3, &x3, &v)
+	// y3 = r*(V - x3) - 2*y1*J
+	f.sub(&t, &v, &x3)
+	f.mul(&y3, &r, &t)
+	f.mul(&t, &j.y, &jj)
+	f.add(&t, &t, &t)
+	f.sub(&y3, &y3, &t)
+	// z3 = (z1 + H)^2 - z1z1 - HH
+	f.add(&t, &j.z, &h)
+	f.mul(&z3, &t, &t)
+	f.sub(&z3, &z3, &z1z1)
+	f.sub(&z3, &z3, &hh)
+
+	*out = jacobian{x: x3, y: y3, z: z3}
+	return sameX, sameY
+}
+
+// add sets j to j+k (add-2007-bl).
+// 令j为j+k（add-2007-bl）。
+func (c *jacobianCurve) add(j, k *jacobian) {
+	if k.inf {
+		return
+	}
+	if j.inf {
+		*j = *k
+		return
+	}
+	f := c.f
+	var z1z1, z2z2, u1, u2, s1, s2, h, r, i, jj, v, t fe
+	f.mul(&z1z1, &j.z, &j.z)
+	f.mul(&z2z2, &k.z, &k.z)
+	f.mul(&u1, &j.x, &z2z2)
+	f.mul(&u2, &k.x, &z1z1)
+	f.mul(&t, &k.z, &z2z2)
+	f.mul(&s1, &j.y, &t)
+	f.mul(&t, &j.z, &z1z1)
+	f.mul(&s2, &k.y, &t)
+	f.sub(&h, &u2, &u1)
+	f.sub(&r, &s2, &s1)
+	f.add(&r, &r, &r)
+	if h.isZero() {
+		if r.isZero() {
+			c.double(j)
+			return
+		}
+		*j = infinity()
+		return
+	}
+	f.add(&i, &h, &h)
+	f.mul(&i, &i, &i)
+	f.mul(&jj, &h, &i)
+	f.mul(&v, &u1, &i)
+
+	var x3, y3, z3 fe
+	f.mul(&x3, &r, &r)
+	f.sub(&x3, &x3, &jj)
+	f.sub(&x3, &x3, &v)
+	f.sub(&x3, &x3, &v)
+	f.sub(&t, &v, &x3)
+	f.mul(&y3, &r, &t)
+	f.mul(&t, &s1, &jj)
+	f.add(&t, &t, &t)
+	f.sub(&y3, &y3, &t)
+	// z3 = ((z1 + z2)^2 - z1z1 - z2z2) * H
+	f.add(&t, &j.z, &k.z)
+	f.mul(&z3, &t, &t)
+	f.sub(&z3, &z3, &z1z1)
+	f.sub(&z3, &z3, &z2z2)
+	f.mul(&z3, &z3, &h)
+
+	j.x, j.y, j.z = x3, y3, z3
+}
+
+// double sets j to 2j (dbl-2001-b for a = -3, dbl-2007-bl otherwise).
+// 令j为2j（a = -3时用dbl-2001-b，否则用dbl-2007-bl）。
+func (c *jacobianCurve) double(j *jacobian) {
+	if j.inf {
+		return
+	}
+	if j.y.isZero() {
+		*j = infinity()
+		return
+	}
+	f := c.f
+	var delta, gamma, beta, alpha, t, u fe
+	f.mul(&delta, &j.z, &j.z)
+	f.mul(&gamma, &j.y, &j.y)
+	f.mul(&beta, &j.x, &gamma)
+
+	// alpha = 3*x^2 + a*z^4
+	if c.aIsMinus3 {
+		f.sub(&t, &j.x, &delta)
+		f.add(&u, &j.x, &delta)
+		f.mul(&alpha, &t, &u)
+	} else {
+		f.mul(&alpha, &j.x, &j.x)
+	}
+	f.add(&t, &alpha, &alpha)
+	f.add(&alpha, &alpha, &t)
+	if !c.aIsMinus3 && !c.a.isZero() {
+		f.mul(&t, &delta, &delta)
+		f.mul(&t, &t, &c.a)
+		f.add(&alpha, &alpha, &t)
+	}
+
+	var x3, y3, z3 fe
+	// x3 = alpha^2 - 8*beta
+	f.add(&u, &beta, &beta)
+	f.add(&u, &u, &u)
+	f.mul(&x3, &alpha, &alpha)
+	f.sub(&x3, &x3, &u)
+	f.sub(&x3, &x3, &u)
+	// z3 = (y1 + z1)^2 - gamma - delta
+	f.add(&t, &j.y, &j.z)
+	f.mul(&z3, &t, &t)
+	f.sub(&z3, &z3, &gamma)
+	f.sub(&z3, &z3, &delta)
+	// y3 = alpha*(4*beta - x3) - 8*gamma^2
+	f.sub(&t, &u, &x3)
+	f.mul(&y3, &alpha, &t)
+	f.mul(&t, &gamma, &gamma)
+	f.add(&t, &t, &t)
+	f.add(&t, &t, &t)
+	f.add(&t, &t, &t)
+	f.sub(&y3, &y3, &t)
+
+	j.x, j.y, j.z = x3, y3, z3
+}
+
+// normalizeAll converts js to affine coordinates with a single inversion
+// (Montgomery's trick), setting finite[i] to whether js[i] is finite and out[i]
+// to its affine coordinates if so.
+// 以一次求逆（Montgomery技巧）将js转换为仿射坐标：finite[i]表示js[i]是否为有限点，若是则
+// out[i]为其仿射坐标。
+func (c *jacobianCurve) normalizeAll(js []jacobian, out []affinePoint, finite []bool) {
+	f := c.f
+	// prefix[i]为前i个有限点z坐标之积
+	prefix := make([]fe, len(js)+1)
+	prefix[0] = f.one
+	for i := range js {
+		finite[i] = !js[i].inf
+		prefix[i+1] = prefix[i]
+		if finite[i] {
+			f.mul(&prefix[i+1], &prefix[i], &js[i].z)
+		}
+	}
+	var acc, zinv, zinv2, t fe
+	f.inv(&acc, &prefix[len(js)])
+	for i := len(js) - 1; i >= 0; i-- {
+		if !finite[i] {
+			continue
+		}
+		f.mul(&zinv, &acc, &prefix[i])
+		f.mul(&acc, &acc, &js[i].z)
+		f.mul(&zinv2, &zinv, &zinv)
+		f.mul(&out[i].x, &js[i].x, &zinv2)
+		f.mul(&t, &zinv2, &zinv)
+		f.mul(&out[i].y, &js[i].y, &t)
+	}
+}
+
+// affine returns j in affine coordinates, (0,0) for the point at infinity.
+// 返回j的仿射坐标，无穷远点为(0,0)。
+func (c *jacobianCurve) affine(j *jacobian) (*big.Int, *big.Int) {
+	if j.inf {
+		return new(big.Int), new(big.Int)
+	}
+	var zinv, zinv2, x, y fe
+	c.f.inv(&zinv, &j.z)
+	c.f.mul(&zinv2, &zinv, &zinv)
+	c.f.mul(&x, &j.x, &zinv2)
+	c.f.mul(&zinv2, &zinv2, &zinv)
+	c.f.mul(&y, &j.y, &zinv2)
+	return c.f.toBig(&x), c.f.toBig(&y)
+}
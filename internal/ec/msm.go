@@ -0,0 +1,141 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// MultiScalarMult returns sum(ks[i]*(xs[i],ys[i])) with Pippenger's bucket
+// method: the scalars are cut into c-bit windows, and in each window every point
+// is added once into the bucket of its digit, so n points cost about b/c*(n+2^c)
+// additions and b doublings for b-bit scalars, instead of n separate
+// multiplications. Terms with a zero scalar or the point at infinity (0,0) are
+// skipped. Curves
+// not in short Weierstrass form fall back to one ScalarMult per point.
+// 多标量乘法：以Pippenger桶方法返回sum(ks[i]*(xs[i],ys[i]))。标量按c比特分窗，每个窗口中
+// 各点按其窗口值加入对应的桶，b比特标量的n个点约需b/c*(n+2^c)次点加与b次倍点，
+// 而非n次独立的标量乘。标量为零或点为无穷远点(0,0)的项被跳过。非短Weierstrass形式的曲线
+// 退化为逐点ScalarMult。
+func MultiScalarMult(curve elliptic.Curve, xs, ys []*big.Int, ks []*big.Int) (*big.Int, *big.Int) {
+	scalars, idx, bits := reduceScalars(curve, xs, ys, ks)
+	jc, ok := jacobianOf(curve)
+	if !ok || len(idx) == 0 {
+		return sumScalarMults(curve, xs, ys, scalars, idx)
+	}
+
+	points := make([]affinePoint, len(xs))
+	for _, i := range idx {
+		points[i] = jc.toAffine(xs[i], ys[i])
+	}
+	c := msmWindow(len(idx))
+	windows := (bits + c - 1) / c
+	buckets := make([]jacobian, 1<<uint(c)-1)
+	acc := infinity()
+	for w := windows - 1; w >= 0; w-- {
+		for i := 0; i < c; i++ {
+			jc.double(&acc)
+		}
+
+		// 各点按其第w个窗口值加入桶
+		for b := range buckets {
+			buckets[b] = infinity()
+		}
+		for _, i := range idx {
+			if d := digit(scalars[i], w*c, c); d != 0 {
+				jc.addAffine(&buckets[d-1], &points[i])
+			}
+		}
+
+		// sum(d*bucket[d]) = 各后缀和之和
+		running, sum := infinity(), infinity()
+		for b := len(buckets) - 1; b >= 0; b-- {
+			jc.add(&running, &buckets[b])
+			jc.add(&sum, &running)
+		}
+		jc.add(&acc, &sum)
+	}
+	return jc.affine(&acc)
+}
+
+// reduceScalars reduces ks modulo the group order, sharing those already reduced,
+// and returns them with the
+// indices of the terms left to compute, those with a nonzero scalar and a finite
+// point, and the longest bit length among them.
+// 将ks模群阶约简，并返回需计算的项（标量非零且点为有限点）的下标及其中最长的比特长度。
+func reduceScalars(curve elliptic.Curve, xs, ys, ks []*big.Int) (scalars []*big.Int, idx []int, bits int) {
+	N := curve.Params().N
+	scalars = make([]*big.Int, len(ks))
+	for i, k := range ks {
+		if xs[i].Sign() == 0 && ys[i].Sign() == 0 {
+			continue
+		}
+		s := k
+		if k.Sign() < 0 || k.Cmp(N) >= 0 {
+			s = new(big.Int).Mod(k, N)
+		}
+		if s.Sign() == 0 {
+			continue
+		}
+		scalars[i] = s
+		idx = append(idx, i)
+		if s.BitLen() > bits {
+			bits = s.BitLen()
+		}
+	}
+	return scalars, idx, bits
+}
+
+// sumScalarMults adds up one curve.ScalarMult per indexed term, for curves
+// without Jacobian arithmetic.
+// 逐项以curve.ScalarMult计算并求和，用于无Jacobian运算的曲线。
+func sumScalarMults(curve elliptic.Curve, xs, ys, scalars []*big.Int, idx []int) (*big.Int, *big.Int) {
+	x, y := new(big.Int), new(big.Int)
+	for _, i := range idx {
+		px, py := curve.ScalarMult(xs[i], ys[i], scalars[i].Bytes())
+		if x.Sign() == 0 && y.Sign() == 0 {
+			x, y = px, py
+			continue
+		}
+		x, y = Add(curve, x, y, px, py)
+	}
+	return x, y
+}
+
+// msmWindow returns the window width minimizing the additions of a multi-scalar
+// multiplication of n points, about b/c*(n+2^(c+1)).
+// 返回使n个点的多标量乘法点加次数（约b/c*(n+2^(c+1))）最少的窗口宽度。
+func msmWindow(n int) int {
+	best, bestCost := 1, 0.0
+	for c := 1; c <= 16; c++ {
+		cost := float64(n+1<<uint(c+1)) / float64(c)
+		if c == 1 || cost < bestCost {
+			best, bestCost = c, cost
+		}
+	}
+	return best
+}
+
+// digit returns the c bits of k starting at bit off.
+// 返回k自第off比特起的c个比特。
+func digit(k *big.Int, off, c int) int {
+	d := 0
+	for i := c - 1; i >= 0; i-- {
+		d = d<<1 | int(k.Bit(off+i))
+	}
+	return d
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// naiveMultiScalarMult sums one ScalarMult per term.
+func naiveMultiScalarMult(curve elliptic.Curve, xs, ys, ks []*big.Int) (*big.Int, *big.Int) {
+	N := curve.Params().N
+	x, y := new(big.Int), new(big.Int)
+	for i := range ks {
+		k := new(big.Int).Mod(ks[i], N)
+		if k.Sign() == 0 || (xs[i].Sign() == 0 && ys[i].Sign() == 0) {
+			continue
+		}
+		px, py := curve.ScalarMult(xs[i], ys[i], k.Bytes())
+		if x.Sign() == 0 && y.Sign() == 0 {
+			x, y = px, py
+			continue
+		}
+		x, y = Add(curve, x, y, px, py)
+	}
+	return x, y
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		params := curve.Params()
+		for _, n := range []int{0, 1, 2, 3, 10, 40} {
+			xs := make([]*big.Int, n)
+			ys := make([]*big.Int, n)
+			ks := make([]*big.Int, n)
+			for i := 0; i < n; i++ {
+				d, err := rand.Int(rand.Reader, params.N)
+				if err != nil {
+					t.Fatal(err)
+				}
+				xs[i], ys[i] = curve.ScalarBaseMult(d.Bytes())
+				if ks[i], err = rand.Int(rand.Reader, params.N); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n > 3 {
+				// 重复点、零标量、负标量、等于阶的标量与无穷远点
+				xs[1], ys[1] = xs[0], ys[0]
+				ks[2] = new(big.Int)
+				ks[3] = new(big.Int).Neg(ks[3])
+				ks[4] = new(big.Int).Set(params.N)
+				xs[5], ys[5] = new(big.Int), new(big.Int)
+			}
+			gx, gy := MultiScalarMult(curve, xs, ys, ks)
+			wx, wy := naiveMultiScalarMult(curve, xs, ys, ks)
+			if gx.Cmp(wx) != 0 || gy.Cmp(wy) != 0 {
+				t.Fatalf("%s, %d points: multi-scalar multiplication mismatch", params.Name, n)
+			}
+		}
+
+		// 相互抵消的项得到无穷远点
+		k := big.NewInt(12345)
+		negK := new(big.Int).Sub(params.N, k)
+		x, y := MultiScalarMult(curve,
+			[]*big.Int{params.Gx, params.Gx}, []*big.Int{params.Gy, params.Gy}, []*big.Int{k, negK})
+		if x.Sign() != 0 || y.Sign() != 0 {
+			t.Fatalf("%s: cancelling terms should sum to infinity", params.Name)
+		}
+	}
+}
+
+// benchTerms returns n random points and scalars on SM2.
+func benchTerms(b *testing.B, n int) (elliptic.Curve, []*big.Int, []*big.Int, []*big.Int) {
+	curve := sm2.P256Sm2()
+	N := curve.Params().N
+	xs := make([]*big.Int, n)
+	ys := make([]*big.Int, n)
+	ks := make([]*big.Int, n)
+	for i := range ks {
+		d, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			b.Fatal(err)
+		}
+		xs[i], ys[i] = curve.ScalarBaseMult(d.Bytes())
+		if ks[i], err = rand.Int(rand.Reader, N); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return curve, xs, ys, ks
+}
+
+func BenchmarkMultiScalarMult(b *testing.B) {
+	curve, xs, ys, ks := benchTerms(b, 32)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MultiScalarMult(curve, xs, ys, ks)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// shamirMaxPoints bounds the points of ShamirMult, whose table has 2^n - 1
+// entries; larger sums go to MultiScalarMult.
+// ShamirMult的点数上限，其表有2^n - 1项；更多的点交由MultiScalarMult。
+const shamirMaxPoints = 4
+
+// DoubleScalarMult returns k1*(x1,y1) + k2*(x2,y2), computed jointly with
+// Shamir's trick.
+// 以Shamir技巧联合计算k1*(x1,y1) + k2*(x2,y2)。
+func DoubleScalarMult(curve elliptic.Curve, x1, y1, k1, x2, y2, k2 *big.Int) (*big.Int, *big.Int) {
+	return ShamirMult(curve, []*big.Int{x1, x2}, []*big.Int{y1, y2}, []*big.Int{k1, k2})
+}
+
+// ShamirMult returns sum(ks[i]*(xs[i],ys[i])) by interleaving the scalars
+// (Shamir's trick): the sums of every subset of the points are tabulated, then
+// one pass over the scalar bits doubles once per bit and adds the subset of the
+// points whose bit is set, so n points cost b doublings and under b additions
+// for b-bit scalars, instead of n*b of each. It suits the two or three terms of a
+// verification equation; more than shamirMaxPoints points go to
+// MultiScalarMult. Terms are skipped and curves fall back as in MultiScalarMult.
+// 以交错标量（Shamir技巧）计算sum(ks[i]*(xs[i],ys[i]))：先列出各点所有子集之和，再对标量
+// 比特单遍扫描，每比特倍点一次并加上该比特为1的点之和，b比特标量的n个点只需b次倍点与不足b次
+// 点加，而非各n*b次。适用于验证方程中的两三项；多于shamirMaxPoints个点时交由
+// MultiScalarMult。项的跳过与曲线的退化同MultiScalarMult。
+func ShamirMult(curve elliptic.Curve, xs, ys []*big.Int, ks []*big.Int) (*big.Int, *big.Int) {
+	if len(ks) > shamirMaxPoints {
+		return MultiScalarMult(curve, xs, ys, ks)
+	}
+	scalars, idx, bits := reduceScalars(curve, xs, ys, ks)
+	jc, ok := jacobianOf(curve)
+	if !ok || len(idx) == 0 {
+		return sumScalarMults(curve, xs, ys, scalars, idx)
+	}
+
+	// table[m-1]为掩码m所选各点之和，一并归一化为仿射坐标；finite[m-1]为false时该和为无穷远点
+	n := len(idx)
+	sums := make([]jacobian, 1<<uint(n))
+	sums[0] = infinity()
+	table := make([]affinePoint, len(sums)-1)
+	finite := make([]bool, len(sums)-1)
+	for m := 1; m < len(sums); m++ {
+		low := 0
+		for m>>uint(low)&1 == 0 {
+			low++
+		}
+		i := idx[low]
+		sums[m] = sums[m&(m-1)]
+		p := jc.toAffine(xs[i], ys[i])
+		jc.addAffine(&sums[m], &p)
+	}
+	jc.normalizeAll(sums[1:], table, finite)
+
+	acc := infinity()
+	for b := bits - 1; b >= 0; b-- {
+		jc.double(&acc)
+		m := 0
+		for j, i := range idx {
+			m |= int(scalars[i].Bit(b)) << uint(j)
+		}
+		if m != 0 && finite[m-1] {
+			jc.addAffine(&acc, &table[m-1])
+		}
+	}
+	return jc.affine(&acc)
+}
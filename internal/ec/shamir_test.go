@@ -0,0 +1,79 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ec
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShamirMult(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		params := curve.Params()
+		for n := 0; n <= shamirMaxPoints+1; n++ {
+			xs := make([]*big.Int, n)
+			ys := make([]*big.Int, n)
+			ks := make([]*big.Int, n)
+			for i := 0; i < n; i++ {
+				d, err := rand.Int(rand.Reader, params.N)
+				if err != nil {
+					t.Fatal(err)
+				}
+				xs[i], ys[i] = curve.ScalarBaseMult(d.Bytes())
+				if ks[i], err = rand.Int(rand.Reader, params.N); err != nil {
+					t.Fatal(err)
+				}
+			}
+			gx, gy := ShamirMult(curve, xs, ys, ks)
+			wx, wy := naiveMultiScalarMult(curve, xs, ys, ks)
+			if gx.Cmp(wx) != 0 || gy.Cmp(wy) != 0 {
+				t.Fatalf("%s, %d points: Shamir multiplication mismatch", params.Name, n)
+			}
+		}
+
+		// 相同点、相反点（子集和为无穷远点）、零标量与短标量
+		k, _ := rand.Int(rand.Reader, params.N)
+		negGy := new(big.Int).Sub(params.P, params.Gy)
+		cases := [][3][2]*big.Int{
+			{{params.Gx, params.Gy}, {params.Gx, params.Gy}, {k, big.NewInt(3)}},
+			{{params.Gx, params.Gy}, {params.Gx, negGy}, {k, big.NewInt(5)}},
+			{{params.Gx, params.Gy}, {params.Gx, negGy}, {k, k}},
+			{{params.Gx, params.Gy}, {new(big.Int), new(big.Int)}, {new(big.Int), k}},
+			{{params.Gx, params.Gy}, {params.Gx, params.Gy}, {big.NewInt(1), big.NewInt(2)}},
+		}
+		for i, c := range cases {
+			gx, gy := DoubleScalarMult(curve, c[0][0], c[0][1], c[2][0], c[1][0], c[1][1], c[2][1])
+			wx, wy := naiveMultiScalarMult(curve,
+				[]*big.Int{c[0][0], c[1][0]}, []*big.Int{c[0][1], c[1][1]}, []*big.Int{c[2][0], c[2][1]})
+			if gx.Cmp(wx) != 0 || gy.Cmp(wy) != 0 {
+				t.Fatalf("%s, case %d: double-scalar multiplication mismatch", params.Name, i)
+			}
+		}
+	}
+}
+
+func BenchmarkDoubleScalarMult(b *testing.B) {
+	curve, xs, ys, ks := benchTerms(b, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DoubleScalarMult(curve, xs[0], ys[0], ks[0], xs[1], ys[1], ks[1])
+	}
+}
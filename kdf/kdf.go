@@ -0,0 +1,135 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kdf derives keys of any length from secrets such as decrypted curve
+// points, with HKDF (RFC 5869) over SM3: Extract concentrates the secret into a
+// pseudorandom key, and Expand derives one output per label from it, so an SM4 key,
+// an IV and a MAC key taken from one point are independent.
+// 密钥派生：以基于SM3的HKDF（RFC 5869）由解密得到的曲线点等秘密派生任意长度的密钥。Extract将秘密
+// 压缩为伪随机密钥，Expand按标签由其派生各自的输出，由同一点派生的SM4密钥、IV与MAC密钥互相独立。
+package kdf
+
+import (
+	"crypto/hmac"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Size is the length of SM3 digests and of the pseudorandom keys of Extract.
+// SM3摘要长度，亦即Extract输出的伪随机密钥长度。
+const Size = 32
+
+// MaxLength is the longest output of Expand, 255 SM3 blocks.
+// Expand的最大输出长度，即255个SM3分组。
+const MaxLength = 255 * Size
+
+// Extract returns the pseudorandom key HMAC-SM3(salt, ikm). A nil salt stands for
+// Size zero bytes.
+// 提取：返回伪随机密钥HMAC-SM3(salt, ikm)，salt为nil时视为Size个零字节。
+//
+// 参数：
+//		盐值	salt
+//		输入密钥材料	ikm
+// 返回：
+// 		伪随机密钥
+func Extract(salt, ikm []byte) []byte {
+	if salt == nil {
+		salt = make([]byte, Size)
+	}
+	mac := hmac.New(sm3.New, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+// Expand derives length bytes from the pseudorandom key prk for the context info:
+// T(1) || T(2) || ..., T(i) = HMAC-SM3(prk, T(i-1) || info || i).
+// 扩展：由伪随机密钥prk为上下文info派生length字节，即T(1) || T(2) || ...，
+// 其中T(i) = HMAC-SM3(prk, T(i-1) || info || i)。
+//
+// 参数：
+//		伪随机密钥	prk
+//		上下文	info
+//		长度	length
+// 返回：
+// 		派生密钥
+func Expand(prk, info []byte, length int) ([]byte, error) {
+	if length < 1 || length > MaxLength {
+		return nil, opError("Expand", ErrInvalidKeyLength)
+	}
+
+	out := make([]byte, 0, length+Size)
+	var t []byte
+	mac := hmac.New(sm3.New, prk)
+	for i := 1; len(out) < length; i++ {
+		mac.Reset()
+		mac.Write(t)
+		mac.Write(info)
+		mac.Write([]byte{byte(i)})
+		t = mac.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:length], nil
+}
+
+// Key runs Extract then Expand.
+// 派生密钥：依次执行Extract与Expand。
+//
+// 参数：
+//		盐值	salt
+//		输入密钥材料	ikm
+//		上下文	info
+//		长度	length
+// 返回：
+// 		派生密钥
+func Key(salt, ikm, info []byte, length int) ([]byte, error) {
+	return Expand(Extract(salt, ikm), info, length)
+}
+
+// FromPoint derives length bytes for label from the point D: ikm is X || Y, each
+// of the curve's byte length. Distinct labels, e.g. "sm4-key" and "mac-key", give
+// independent keys.
+// 由点派生密钥：以X || Y（各为曲线字节长度）为输入密钥材料，为标签label派生length字节。
+// 不同标签（如"sm4-key"与"mac-key"）得到互相独立的密钥。
+//
+// 参数：
+//		点	D
+//		盐值	salt
+//		标签	label
+//		长度	length
+// 返回：
+// 		派生密钥
+func FromPoint(D *elgamal.CurvePoint, salt []byte, label string, length int) ([]byte, error) {
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, opError("FromPoint", err)
+	}
+	byteLen := (D.Curve.Params().BitSize + 7) / 8
+	ikm := make([]byte, 2*byteLen)
+	D.X.FillBytes(ikm[:byteLen])
+	D.Y.FillBytes(ikm[byteLen:])
+
+	prk := Extract(salt, ikm)
+	key, err := Expand(prk, []byte(label), length)
+
+	// 中间结果清零
+	for i := range ikm {
+		ikm[i] = 0
+	}
+	for i := range prk {
+		prk[i] = 0
+	}
+	return key, err
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kdf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestExpand(t *testing.T) {
+	prk := Extract([]byte("salt"), []byte("input key material"))
+	if len(prk) != Size {
+		t.Fatalf("got %d bytes, want %d", len(prk), Size)
+	}
+
+	// 较长输出的前缀与较短输出一致
+	k16, err := Expand(prk, []byte("info"), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k100, err := Expand(prk, []byte("info"), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(k100[:16], k16) {
+		t.Fatal("Expand output is not prefix consistent")
+	}
+
+	// nil盐值等同于全零盐值
+	if !bytes.Equal(Extract(nil, []byte("ikm")), Extract(make([]byte, Size), []byte("ikm"))) {
+		t.Fatal("nil salt differs from zero salt")
+	}
+
+	for _, n := range []int{0, MaxLength + 1} {
+		if _, err := Expand(prk, nil, n); !errors.Is(err, ErrInvalidKeyLength) {
+			t.Fatalf("%d: got %v, want ErrInvalidKeyLength", n, err)
+		}
+	}
+	if _, err := Expand(prk, nil, MaxLength); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromPoint(t *testing.T) {
+	D := elgamal.GenPoint()
+
+	key, err := FromPoint(D, nil, "sm4-key", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv, err := FromPoint(D, nil, "sm4-iv", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key, iv) {
+		t.Fatal("labels do not separate the outputs")
+	}
+	salted, err := FromPoint(D, []byte("session-1"), "sm4-key", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key, salted) {
+		t.Fatal("salt does not change the output")
+	}
+
+	again, err := FromPoint(D, nil, "sm4-key", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Fatal("FromPoint is not deterministic")
+	}
+
+	if _, err := FromPoint(elgamal.Infinity(nil), nil, "sm4-key", 16); !errors.Is(err, elgamal.ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/rand"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// kemInfo is the KDF context of encapsulated secrets.
+// 封装密钥的KDF上下文。
+var kemInfo = []byte("ppks-kem-v1")
+
+// KEMSecretSize is the length in bytes of the secrets of Encapsulate.
+// Encapsulate所生成共享密钥的字节长度。
+const KEMSecretSize = 32
+
+// Encapsulate generates a random point, encrypts it for pub with PointEncrypt and
+// derives a shared secret of KEMSecretSize bytes from it, following the KEM/DEM
+// pattern: the secret keys the DEM, the ciphertext travels with the data. Key
+// switching the ciphertext hands the secret over to the target key. The caller
+// should Wipe the secret once done.
+// 密钥封装：生成随机点，以PointEncrypt为公钥pub加密，并由其派生KEMSecretSize字节的共享密钥，
+// 符合KEM/DEM模式：共享密钥用于数据封装，密文随数据传输。对密文进行密钥置换即可将共享密钥转交给目标公钥。
+// 共享密钥使用完毕后，调用者应调用Wipe擦除。
+//
+// 参数：
+//		公钥	pub
+// 返回：
+// 		共享密钥
+//		密文
+func Encapsulate(pub *sm2.PublicKey) (*SecretBytes, *CipherText, error) {
+	if err := elgamal.CheckPoint((*CurvePoint)(pub)); err != nil {
+		return nil, nil, opError("Encapsulate", err)
+	}
+	curve := pub.Curve
+	d, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, opError("Encapsulate", err)
+	}
+	D := elgamal.Generator(curve)
+	D.X, D.Y = curve.ScalarBaseMult(d.Bytes())
+
+	ct, err := PointEncrypt(pub, D)
+	if err != nil {
+		return nil, nil, opError("Encapsulate", err)
+	}
+	secret, err := kemSecret(D)
+	if err != nil {
+		return nil, nil, opError("Encapsulate", err)
+	}
+	return secret, ct, nil
+}
+
+// Decapsulate recovers the shared secret of ct with priv. A ciphertext for another
+// key yields an unrelated secret rather than an error, so the DEM must authenticate.
+// 密钥解封：使用私钥priv恢复密文ct封装的共享密钥。密文不属于该私钥时得到无关的密钥而非错误，
+// 因此数据封装须带认证。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+// 返回：
+// 		共享密钥
+func Decapsulate(priv *sm2.PrivateKey, ct *CipherText) (*SecretBytes, error) {
+	D, err := PointDecrypt(ct, priv)
+	if err != nil {
+		return nil, opError("Decapsulate", err)
+	}
+	secret, err := kemSecret(D)
+	if err != nil {
+		return nil, opError("Decapsulate", err)
+	}
+	return secret, nil
+}
+
+// kemSecret derives the shared secret from the point D.
+// 由点D派生共享密钥。
+func kemSecret(D *CurvePoint) (*SecretBytes, error) {
+	return SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceKDF, Length: KEMSecretSize, Info: kemInfo})
+}
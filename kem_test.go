@@ -0,0 +1,79 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEncapsulate(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, ct, err := Encapsulate(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer secret.Wipe()
+	if secret.Len() != KEMSecretSize {
+		t.Fatalf("got %d bytes, want %d", secret.Len(), KEMSecretSize)
+	}
+
+	got, err := Decapsulate(priv, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), secret.Bytes()) {
+		t.Fatal("decapsulated secret differs")
+	}
+
+	// 置换后目标私钥得到同一共享密钥
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, _, err := ShareCal(&q.PublicKey, &ct.K, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switched, err := ShareReplace(&CipherVector{*share}, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = Decapsulate(q, switched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), secret.Bytes()) {
+		t.Fatal("switched ciphertext decapsulated to another secret")
+	}
+
+	other, err := Decapsulate(q, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(other.Bytes(), secret.Bytes()) {
+		t.Fatal("another key decapsulated the secret")
+	}
+	if _, _, err := Encapsulate(nil); err == nil {
+		t.Fatal("expected error for a nil public key")
+	}
+}
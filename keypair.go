@@ -0,0 +1,160 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"math/big"
+
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// minSeedLen is the shortest seed accepted by KeyPairFromSeed.
+// 由种子派生密钥对时种子的最小字节数。
+const minSeedLen = 16
+
+// KeyPair bundles an SM2 private key with its public key. The KeyPair owns the
+// private scalar D: callers must not modify the key returned by PrivateKey.
+// A KeyPair is read-only and safe for concurrent use.
+// 密钥对，绑定SM2私钥及其公钥。私钥D归KeyPair所有，调用者不应修改PrivateKey返回的私钥。
+// KeyPair只读，可并发使用。
+type KeyPair struct {
+	priv *sm2.PrivateKey
+}
+
+// NewKeyPair generates a key pair at random.
+// 生成密钥对：随机生成一个密钥对并返回。
+//
+// 参数：
+//
+// 返回：
+// 		密钥对
+func NewKeyPair() (*KeyPair, error) {
+	priv, err := GenPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{priv: priv}, nil
+}
+
+// KeyPairFromSeed derives a key pair deterministically from seed.
+// 由种子派生密钥对：以SM3计数器模式扩展种子seed，确定性地派生密钥对并返回。
+// 相同的种子总是得到相同的密钥对，种子至少16字节。
+//
+// 参数：
+//		种子	seed
+// 返回：
+// 		密钥对
+func KeyPairFromSeed(seed []byte) (*KeyPair, error) {
+	if len(seed) < minSeedLen {
+		return nil, opError("KeyPairFromSeed", ErrSeedTooShort)
+	}
+
+	curve := sm2.P256Sm2()
+	d, err := ec.RandFieldElement(curve, newSeedReader([]byte("ppks-keypair-seed"), seed))
+	if err != nil {
+		return nil, opError("KeyPairFromSeed", err)
+	}
+
+	return &KeyPair{priv: privKeyFromD(curve, d)}, nil
+}
+
+// KeyPairFromPEM parses a PKCS#8 PEM encoded SM2 private key, decrypting it with pwd
+// when pwd is not nil.
+// 由PEM导入密钥对：解析PKCS#8格式的PEM私钥，pwd非空时用其解密。
+//
+// 参数：
+//		PEM数据	pemBytes
+//		口令	pwd
+// 返回：
+// 		密钥对
+func KeyPairFromPEM(pemBytes, pwd []byte) (*KeyPair, error) {
+	priv, err := x509.ReadPrivateKeyFromPem(pemBytes, pwd)
+	if err != nil {
+		return nil, opError("KeyPairFromPEM", err)
+	}
+	return &KeyPair{priv: priv}, nil
+}
+
+// MarshalPEM encodes the private key as PKCS#8 PEM, encrypted with pwd when pwd is not nil.
+// 导出PEM：将私钥编码为PKCS#8格式的PEM，pwd非空时用其加密。
+func (kp *KeyPair) MarshalPEM(pwd []byte) ([]byte, error) {
+	pemBytes, err := x509.WritePrivateKeyToPem(kp.priv, pwd)
+	if err != nil {
+		return nil, opError("KeyPair.MarshalPEM", err)
+	}
+	return pemBytes, nil
+}
+
+// PrivateKey returns the private key owned by kp.
+// 返回kp持有的私钥。
+func (kp *KeyPair) PrivateKey() *sm2.PrivateKey {
+	return kp.priv
+}
+
+// PublicKey returns the public key of kp.
+// 返回kp的公钥。
+func (kp *KeyPair) PublicKey() *sm2.PublicKey {
+	return &kp.priv.PublicKey
+}
+
+// privKeyFromD builds a private key on curve from the scalar d.
+// 由私钥标量d构造曲线curve上的私钥。
+func privKeyFromD(curve elliptic.Curve, d *big.Int) *sm2.PrivateKey {
+	priv := new(sm2.PrivateKey)
+	priv.Curve = curve
+	priv.D = d
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+	return priv
+}
+
+// seedReader is an io.Reader producing the stream SM3(label || seed || counter).
+// 以SM3(label || seed || counter)输出确定性字节流的Reader。
+type seedReader struct {
+	label, seed []byte
+	counter     uint32
+	buf         []byte
+}
+
+func newSeedReader(label, seed []byte) *seedReader {
+	return &seedReader{label: label, seed: seed}
+}
+
+func (r *seedReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			var ctr [4]byte
+			binary.BigEndian.PutUint32(ctr[:], r.counter)
+			r.counter++
+
+			h := sm3.New()
+			h.Write(r.label)
+			h.Write(r.seed)
+			h.Write(ctr[:])
+			r.buf = h.Sum(nil)
+		}
+		c := copy(p[n:], r.buf)
+		r.buf = r.buf[c:]
+		n += c
+	}
+	return n, nil
+}
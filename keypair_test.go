@@ -0,0 +1,104 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"testing"
+)
+
+func TestNewKeyPair(t *testing.T) {
+	kp, err := NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 公钥应为私钥数乘生成元
+	pub := kp.PublicKey()
+	x, y := pub.Curve.ScalarBaseMult(kp.PrivateKey().D.Bytes())
+	if 0 != x.Cmp(pub.X) || 0 != y.Cmp(pub.Y) {
+		t.Fatal("public key does not match private key")
+	}
+}
+
+func TestKeyPairFromSeed(t *testing.T) {
+	seed := []byte("0123456789abcdef-node-1")
+
+	kp1, err := KeyPairFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp2, err := KeyPairFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != kp1.PrivateKey().D.Cmp(kp2.PrivateKey().D) {
+		t.Fatal("same seed gave different keys")
+	}
+
+	kp3, err := KeyPairFromSeed([]byte("0123456789abcdef-node-2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 == kp1.PrivateKey().D.Cmp(kp3.PrivateKey().D) {
+		t.Fatal("different seeds gave the same key")
+	}
+
+	// 派生密钥可正常解密
+	D := GenPoint()
+	ct, err := PointEncrypt(kp1.PublicKey(), D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := PointDecrypt(ct, kp1.PrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(pt.X) || 0 != D.Y.Cmp(pt.Y) {
+		t.Fatal("derived key failed to decrypt")
+	}
+
+	if _, err := KeyPairFromSeed([]byte("short")); err == nil {
+		t.Fatal("expected error on short seed")
+	}
+}
+
+func TestKeyPairFromPEM(t *testing.T) {
+	kp, err := NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pwd := range [][]byte{nil, []byte("passphrase")} {
+		pemBytes, err := kp.MarshalPEM(pwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp2, err := KeyPairFromPEM(pemBytes, pwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != kp.PrivateKey().D.Cmp(kp2.PrivateKey().D) {
+			t.Fatal("PEM round trip changed the key")
+		}
+		if 0 != kp.PublicKey().X.Cmp(kp2.PublicKey().X) {
+			t.Fatal("PEM round trip changed the public key")
+		}
+	}
+
+	if _, err := KeyPairFromPEM([]byte("not a pem"), nil); err == nil {
+		t.Fatal("expected error on malformed PEM")
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// aadDomain separates associated data contexts from other proof contexts.
+// 关联数据上下文的域分隔标签。
+const aadDomain = "ppks-aad-v1"
+
+// aadContext returns the proof context binding aad: the domain, then the SM3 hash
+// of aad, so associated data of any length adds 32 bytes to each transcript.
+// 返回绑定关联数据aad的证明上下文：域分隔标签及aad的SM3哈希，任意长度的关联数据只为每个记录
+// 增加32字节。
+func aadContext(aad []byte) string {
+	h := sm3.New()
+	h.Write(aad)
+	return aadDomain + string(h.Sum(nil))
+}
+
+// aadSuite returns base with aad bound after its context.
+// 返回在上下文之后绑定关联数据aad的参数组base。
+func aadSuite(base proof.Suite, aad []byte) proof.Suite {
+	base.Context += aadContext(aad)
+	return base
+}
+
+// AADCipherText is a ciphertext bound to associated data, such as the hash of the
+// document whose key it encrypts: Proof shows knowledge of the randomness of
+// CipherText under a context holding the associated data, as in EncryptWithProof.
+// Whoever does not know the randomness cannot present the ciphertext for other
+// associated data. The associated data itself is not stored; the verifier must
+// know it.
+// 关联数据密文：与关联数据（如所加密密钥对应文档的哈希）绑定的密文。Proof与EncryptWithProof相同，
+// 证明知道CipherText的随机数，但其上下文包含关联数据。不知道随机数者无法将该密文用于其他关联数据。
+// 关联数据本身不存储，验证方须自行知晓。
+type AADCipherText struct {
+	CipherText elgamal.CipherText
+	Proof      *proof.LinearProof
+}
+
+// EncryptWithAAD encrypts D with pub like elgamal.PointEncrypt and binds the
+// ciphertext to the associated data aad, which must not be empty.
+// 关联数据加密：与elgamal.PointEncrypt相同地使用公钥pub加密点D，并将密文与关联数据aad绑定，
+// aad不可为空。
+//
+// 参数：
+//		公钥		pub
+//		待加密点	D
+//		关联数据	aad
+// 返回：
+// 		关联数据密文
+func EncryptWithAAD(pub *sm2.PublicKey, D *elgamal.CurvePoint, aad []byte) (*AADCipherText, error) {
+	if len(aad) == 0 {
+		return nil, opError("EncryptWithAAD", ErrIncompleteStatement)
+	}
+	ct, p, err := encryptWithProof("EncryptWithAAD", pub, D, aadContext(aad))
+	if err != nil {
+		return nil, err
+	}
+	return &AADCipherText{CipherText: *ct, Proof: p}, nil
+}
+
+// Verify checks that a, encrypted to pub, is bound to aad, failing with
+// ErrAuthFailed if it was made for other associated data or its proof is invalid.
+// 验证关联数据密文：检查公钥pub下的密文a与关联数据aad绑定，a为其他关联数据生成或证明无效时返回
+// ErrAuthFailed。
+//
+// 参数：
+//		公钥		pub
+//		关联数据	aad
+// 返回：
+// 		错误
+func (a *AADCipherText) Verify(pub *sm2.PublicKey, aad []byte) error {
+	if len(aad) == 0 || a.Proof == nil {
+		return opError("AADCipherText.Verify", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return opError("AADCipherText.Verify", err)
+	}
+	if err := elgamal.CheckCipherText(&a.CipherText); err != nil {
+		return opError("AADCipherText.Verify", err)
+	}
+	rel, context := encryptionStatement(pub, &a.CipherText)
+	ok, err := proof.VerifyLinear(context+aadContext(aad), rel, a.Proof)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return opError("AADCipherText.Verify", ErrAuthFailed)
+	}
+	return nil
+}
+
+// GenShareBundleWithAAD checks that act, encrypted to the collective key pub, is
+// bound to aad, and only then generates the share bundle of its rB with aad bound
+// into the proof. The bundle records aad, so it verifies only with the same
+// associated data: a switched key cannot be claimed for another document.
+// 关联数据份额包：检查集合公钥pub下的密文act与关联数据aad绑定，通过后才为其rB生成份额包，并将aad
+// 计入证明。份额包记录aad，仅在相同关联数据下通过验证，因此置换后的密钥无法被声称属于其他文档。
+//
+// 参数：
+//		关联数据		aad
+//		集合公钥		pub
+//		关联数据密文	act
+//		目标公钥		targetPubKey
+//		私钥			priv
+// 返回：
+// 		份额包
+func GenShareBundleWithAAD(aad []byte, pub *sm2.PublicKey, act *AADCipherText, targetPubKey *sm2.PublicKey, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	if act == nil {
+		return nil, opError("GenShareBundleWithAAD", ErrIncompleteStatement)
+	}
+	if err := act.Verify(pub, aad); err != nil {
+		return nil, opError("GenShareBundleWithAAD", err)
+	}
+	b, err := genShareBundle(aadSuite(proof.DefaultSuite, aad), targetPubKey, &act.CipherText.K, priv)
+	if err != nil {
+		return nil, err
+	}
+	b.AAD = append([]byte(nil), aad...)
+	return b, nil
+}
+
+// VerifyAAD checks the proof carried by b under aad, whatever associated data b
+// records: it fails unless the share was produced for aad. A policy recorded in b
+// is still bound.
+// 按关联数据验证份额包：在关联数据aad下验证b中的证明，而不论b记录的关联数据，仅当份额是为aad
+// 生成时通过。b记录的策略仍然绑定。
+//
+// 参数：
+//		关联数据	aad
+// 返回：
+// 		验证结果：	bool
+func (b *ShareBundle) VerifyAAD(aad []byte) (bool, error) {
+	if len(aad) == 0 || b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return false, opError("ShareBundle.VerifyAAD", ErrIncompleteStatement)
+	}
+	c, r1, r2 := b.Proof.Values()
+	if c == nil || r1 == nil || r2 == nil {
+		return false, nil
+	}
+	x := *b
+	x.AAD = aad
+	return shareProofVryNoB(x.suite(), c, r1, r2, &b.Share, b.NodePubKey, b.TargetPubKey, b.RB)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestAAD(t *testing.T) {
+	node, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &node.PublicKey
+	docA, docB := []byte("sha256 of document A"), []byte("sha256 of document B")
+
+	D := elgamal.GenPoint()
+	act, err := EncryptWithAAD(pub, D, docA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := act.Verify(pub, docA); err != nil {
+		t.Fatal(err)
+	}
+	if err := act.Verify(pub, docB); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	if _, err := EncryptWithAAD(pub, elgamal.GenPoint(), nil); !errors.Is(err, ErrIncompleteStatement) {
+		t.Fatalf("got %v, want ErrIncompleteStatement", err)
+	}
+
+	// 节点拒绝为其他文档计算份额
+	if _, err := GenShareBundleWithAAD(docB, pub, act, &q.PublicKey, node); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	b, err := GenShareBundleWithAAD(docA, pub, act, &q.PublicKey, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Verify(); err != nil || !ok {
+		t.Fatal("bundle failed to verify with its associated data")
+	}
+	if ok, err := b.VerifyAAD(docA); err != nil || !ok {
+		t.Fatal("bundle failed to verify under docA")
+	}
+	if ok, err := b.VerifyAAD(docB); err != nil || ok {
+		t.Fatal("bundle verified under docB")
+	}
+	if failed, err := BatchVerifyShares([]*ShareBundle{b}); err != nil || len(failed) != 0 {
+		t.Fatalf("batch verification failed: %v %v", failed, err)
+	}
+
+	// 篡改记录的关联数据或去除后验证失败
+	x := *b
+	x.AAD = docB
+	if ok, err := x.Verify(); err != nil || ok {
+		t.Fatal("bundle verified after changing its associated data")
+	}
+	x.AAD = nil
+	if ok, err := x.Verify(); err != nil || ok {
+		t.Fatal("bundle verified without its associated data")
+	}
+
+	// 置换后目标私钥解密得到原明文
+	tct, err := ShareReplace(&elgamal.CipherVector{b.Share}, &act.CipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := elgamal.PointDecrypt(tct, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePoint(pt, D) {
+		t.Fatal("switched plaintext differs")
+	}
+}
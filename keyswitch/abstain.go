@@ -0,0 +1,158 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// abstainContext binds share-or-abstain proofs to their purpose.
+// 份额或弃权证明的上下文。
+const abstainContext = "ppks-share-or-abstain"
+
+// ShareRelation returns the statement of a share as a proof.Relation with the
+// witnesses (ri, priv):
+//     share.K = ri*B, nodePubKey = priv*B, share.C = ri*targetPubKey + priv*(-rB),
+// so the share can be combined with other statements, e.g. in OR proofs.
+// 份额关系：以proof.Relation表示份额的公开信息，证据为(ri, priv)：
+//     share.K = ri*B, nodePubKey = priv*B, share.C = ri*targetPubKey + priv*(-rB)，
+// 以便与其他声明组合，如用于OR证明。
+//
+// 参数：
+//		份额：		share
+//		节点公钥：	nodePubKey
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+// 返回：
+// 		关系
+func ShareRelation(share *elgamal.CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*proof.Relation, error) {
+	if nodePubKey == nil || targetPubKey == nil {
+		return nil, opError("ShareRelation", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckCipherText(share); err != nil {
+		return nil, opError("ShareRelation", err)
+	}
+	A2, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return nil, opError("ShareRelation", err)
+	}
+	B := elgamal.Generator(targetPubKey.Curve)
+	return &proof.Relation{
+		Bases: [][]*elgamal.CurvePoint{
+			{B, nil},
+			{nil, B},
+			{(*elgamal.CurvePoint)(targetPubKey), A2},
+		},
+		Targets: []*elgamal.CurvePoint{&share.K, (*elgamal.CurvePoint)(nodePubKey), &share.C},
+	}, nil
+}
+
+// shareOrAbstain returns the branches of a share-or-abstain proof: the share
+// relation, then the knowledge of the private key of each key of abstainList.
+// 返回份额或弃权证明的各分支：先为份额关系，再依次为知道abstainList中各公钥对应的私钥。
+func shareOrAbstain(op string, share *elgamal.CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, abstainList []*sm2.PublicKey) ([]*proof.Relation, error) {
+	rel, err := ShareRelation(share, nodePubKey, targetPubKey, rB)
+	if err != nil {
+		return nil, err
+	}
+	rels := []*proof.Relation{rel}
+	B := elgamal.Generator(targetPubKey.Curve)
+	for i, key := range abstainList {
+		if key == nil {
+			return nil, itemError(op, "abstain key", i, elgamal.ErrEmpty)
+		}
+		rels = append(rels, proof.DLogRelation(B, (*elgamal.CurvePoint)(key)))
+	}
+	return rels, nil
+}
+
+// ShareOrAbstainProofGen proves that share was correctly computed with ri and priv,
+// in a form that cannot be told apart from AbstainProofGen: the verifier learns
+// that the share is correct OR that the node holds a key of the published
+// abstainList, but not which.
+// 份额或弃权证明生成（份额分支）：证明份额share由ri与priv正确计算，且与AbstainProofGen生成的
+// 证明不可区分：验证者只知道份额正确或节点持有公布的弃权名单abstainList中的某个私钥，而不知道是哪一种。
+//
+// 参数：
+//		份额：		share
+//		随机数：	ri
+//		节点私钥：	priv
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+//		弃权名单：	abstainList
+// 返回：
+// 		证明
+func ShareOrAbstainProofGen(share *elgamal.CipherText, ri *big.Int, priv *sm2.PrivateKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, abstainList []*sm2.PublicKey) (*proof.OrProof, error) {
+	rels, err := shareOrAbstain("ShareOrAbstainProofGen", share, &priv.PublicKey, targetPubKey, rB, abstainList)
+	if err != nil {
+		return nil, err
+	}
+	return proof.ProveOr(abstainContext, rels, 0, []*big.Int{ri, priv.D})
+}
+
+// AbstainProofGen proves, for a node opting out, that it holds abstainPriv whose
+// public key is on abstainList, in place of a share proof for share.
+// 份额或弃权证明生成（弃权分支）：选择弃权的节点以弃权名单abstainList中某公钥对应的私钥abstainPriv
+// 代替份额share的计算证明。
+//
+// 参数：
+//		份额：		share
+//		节点公钥：	nodePubKey
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+//		弃权名单：	abstainList
+//		弃权私钥：	abstainPriv
+// 返回：
+// 		证明
+func AbstainProofGen(share *elgamal.CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, abstainList []*sm2.PublicKey, abstainPriv *sm2.PrivateKey) (*proof.OrProof, error) {
+	rels, err := shareOrAbstain("AbstainProofGen", share, nodePubKey, targetPubKey, rB, abstainList)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range abstainList {
+		if key.X.Cmp(abstainPriv.X) == 0 && key.Y.Cmp(abstainPriv.Y) == 0 {
+			return proof.ProveOr(abstainContext, rels, i+1, []*big.Int{abstainPriv.D})
+		}
+	}
+	return nil, opError("AbstainProofGen", ErrUnsupportedKey)
+}
+
+// ShareOrAbstainProofVry verifies a proof made by ShareOrAbstainProofGen or
+// AbstainProofGen for share against abstainList.
+// 份额或弃权证明验证：验证由ShareOrAbstainProofGen或AbstainProofGen针对份额share与弃权名单
+// abstainList生成的证明。
+//
+// 参数：
+//		证明：		p
+//		份额：		share
+//		节点公钥：	nodePubKey
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+//		弃权名单：	abstainList
+// 返回：
+// 		验证结果：	bool
+func ShareOrAbstainProofVry(p *proof.OrProof, share *elgamal.CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, abstainList []*sm2.PublicKey) (bool, error) {
+	rels, err := shareOrAbstain("ShareOrAbstainProofVry", share, nodePubKey, targetPubKey, rB, abstainList)
+	if err != nil {
+		return false, err
+	}
+	return proof.VerifyOr(abstainContext, rels, p)
+}
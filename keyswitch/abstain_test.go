@@ -0,0 +1,80 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareOrAbstain(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	abstainer, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := []*sm2.PublicKey{&other.PublicKey, &abstainer.PublicKey}
+	rB := elgamal.GenPoint()
+
+	// 正常节点：份额分支
+	share, ri, err := ShareCal(&q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ShareOrAbstainProofGen(share, ri, priv, &q.PublicKey, rB, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := ShareOrAbstainProofVry(p, share, &priv.PublicKey, &q.PublicKey, rB, list); err != nil || !ok {
+		t.Fatal("share branch failed to verify")
+	}
+	if ok, err := ShareOrAbstainProofVry(p, share, &priv.PublicKey, &q.PublicKey, elgamal.GenPoint(), list); err != nil || ok {
+		t.Fatal("share branch verified against a different rB")
+	}
+
+	// 弃权节点：份额不正确，但持有弃权名单中的私钥
+	bogus := &elgamal.CipherText{K: *elgamal.GenPoint(), C: *elgamal.GenPoint()}
+	a, err := AbstainProofGen(bogus, &priv.PublicKey, &q.PublicKey, rB, list, abstainer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := ShareOrAbstainProofVry(a, bogus, &priv.PublicKey, &q.PublicKey, rB, list); err != nil || !ok {
+		t.Fatal("abstain branch failed to verify")
+	}
+	if ok, err := ShareOrAbstainProofVry(a, bogus, &priv.PublicKey, &q.PublicKey, rB, list[:1]); err != nil || ok {
+		t.Fatal("abstain branch verified without its key on the list")
+	}
+
+	// 不在名单中的私钥无法弃权
+	if _, err := AbstainProofGen(bogus, &priv.PublicKey, &q.PublicKey, rB, list, priv); err == nil {
+		t.Fatal("expected error for a key not on the abstain list")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"sync"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// ShareAccumulator folds shares into a running sum as they arrive, so a coordinator
+// does not have to hold the whole CipherVector before calling ShareReplace.
+// The zero value is an empty accumulator ready to use. A ShareAccumulator is not
+// safe for concurrent use; use SafeAccumulator when several goroutines add shares.
+// 份额累加器：份额到达时即累加至聚合值sigma，协调者无需在置换前持有全部份额，内存占用为O(1)。
+// 零值即为可用的空累加器。ShareAccumulator不可并发使用，多个协程累加份额时应使用SafeAccumulator。
+type ShareAccumulator struct {
+	ctx   *ec.Context
+	sigma shareSum
+	first elgamal.CipherText
+	count int
+}
+
+// Add folds share into the running sum.
+// 累加份额：将份额share累加至聚合值。
+//
+// 参数：
+//		份额	share
+// 返回：
+// 		错误
+func (a *ShareAccumulator) Add(share *elgamal.CipherText) error {
+	// 首个份额确定曲线上下文，与ShareReplace相同
+	if a.count == 0 {
+		if err := elgamal.CheckCipherText(share); err != nil {
+			return itemError("ShareAccumulator.Add", "share", a.count, err)
+		}
+		a.ctx = ec.NewContext(share.K.Curve)
+		a.sigma = shareSum{K: a.ctx.Sum(), C: a.ctx.Sum()}
+		a.first = *share
+	} else if err := checkShare(a.ctx, share); err != nil {
+		return itemError("ShareAccumulator.Add", "share", a.count, err)
+	}
+
+	a.sigma.K.Add(share.K.X, share.K.Y)
+	a.sigma.C.Add(share.C.X, share.C.Y)
+	a.count++
+
+	return nil
+}
+
+// Count returns the number of shares added so far.
+// 返回已累加的份额数量。
+func (a *ShareAccumulator) Count() int {
+	return a.count
+}
+
+// Finalize uses the accumulated shares to convert rct(raw ciphertext) to a new
+// ciphertext, exactly as ShareReplace does for the same shares.
+// 完成置换：使用已累加的份额置换原密文rct为新密文，并返回，结果与ShareReplace一致。
+//
+// 参数：
+//		密文原文	rct
+// 返回：
+// 		新密文
+func (a *ShareAccumulator) Finalize(rct *elgamal.CipherText) (*elgamal.CipherText, error) {
+	if a.count == 0 {
+		return nil, opError("ShareAccumulator.Finalize", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckCipherText(rct); err != nil {
+		return nil, opError("ShareAccumulator.Finalize", err)
+	}
+
+	// 通过sigma的副本置换rct得到目标ct，累加器仍可继续累加
+	sigma := a.sigma
+	return replaceWith(&sigma, &a.first, rct), nil
+}
+
+// SafeAccumulator is a ShareAccumulator protected by a mutex, so several goroutines
+// receiving shares from the network can add them without external locking.
+// The zero value is an empty accumulator ready to use.
+// 并发安全的份额累加器：以互斥锁保护的ShareAccumulator，从网络接收份额的多个协程可直接并发累加，
+// 无需外部加锁。零值即为可用的空累加器。
+type SafeAccumulator struct {
+	mu  sync.Mutex
+	acc ShareAccumulator
+}
+
+// Add folds share into the running sum. It is safe for concurrent use.
+// 累加份额：将份额share累加至聚合值，可并发调用。
+//
+// 参数：
+//		份额	share
+// 返回：
+// 		错误
+func (a *SafeAccumulator) Add(share *elgamal.CipherText) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acc.Add(share)
+}
+
+// Count returns the number of shares added so far.
+// 返回已累加的份额数量。
+func (a *SafeAccumulator) Count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acc.Count()
+}
+
+// Finalize uses the accumulated shares to convert rct to a new ciphertext. Shares
+// may still be added afterwards; a later Finalize includes them.
+// 完成置换：使用已累加的份额置换原密文rct为新密文。之后仍可继续累加，再次调用时包含新的份额。
+//
+// 参数：
+//		密文原文	rct
+// 返回：
+// 		新密文
+func (a *SafeAccumulator) Finalize(rct *elgamal.CipherText) (*elgamal.CipherText, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acc.Finalize(rct)
+}
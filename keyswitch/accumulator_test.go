@@ -0,0 +1,200 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareAccumulator(t *testing.T) {
+	////////////////////////
+	// 模拟ks server数量/////
+	lens := 10
+	////////////////////////
+
+	// 生成ks server公私钥对并聚合公钥
+	pks := make([]sm2.PrivateKey, lens)
+	Pks := make([]sm2.PublicKey, lens)
+	for i := 0; i < lens; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks[i] = *priv
+		Pks[i] = priv.PublicKey
+	}
+	collPk := CollPubKey(Pks)
+
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(collPk, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := sm2.GenerateKey(rand.Reader) // 请求者密钥对
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 份额逐个到达时累加
+	var acc ShareAccumulator
+	shares := make(elgamal.CipherVector, lens)
+	for i := 0; i < lens; i++ {
+		share, _, err := ShareCal(&q.PublicKey, &ct.K, &pks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = *share
+		if err := acc.Add(share); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if acc.Count() != lens {
+		t.Fatalf("got count %d, want %d", acc.Count(), lens)
+	}
+
+	tct, err := acc.Finalize(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 与ShareReplace结果一致
+	want, err := ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != tct.K.X.Cmp(want.K.X) || 0 != tct.C.X.Cmp(want.C.X) || 0 != tct.C.Y.Cmp(want.C.Y) {
+		t.Fatal("accumulator result differs from ShareReplace")
+	}
+
+	// 请求者可解密
+	pt, err := elgamal.PointDecrypt(tct, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(pt.X) || 0 != D.Y.Cmp(pt.Y) {
+		t.Fatal("requester failed to decrypt accumulated ciphertext")
+	}
+}
+
+func TestShareAccumulatorDuplicate(t *testing.T) {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+	share, _, err := ShareCal(&q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rct := elgamal.CipherText{K: *rB, C: *elgamal.GenPoint()}
+
+	// 重复的份额须做倍点运算，结果与ShareReplace一致；Finalize之后仍可继续累加
+	var acc ShareAccumulator
+	shares := elgamal.CipherVector{*share, *share, *share}
+	for i := range shares {
+		if err := acc.Add(&shares[i]); err != nil {
+			t.Fatal(err)
+		}
+		got, err := acc.Finalize(&rct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part := shares[:i+1]
+		want, err := ShareReplace(&part, &rct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != got.K.X.Cmp(want.K.X) || 0 != got.K.Y.Cmp(want.K.Y) || 0 != got.C.X.Cmp(want.C.X) || 0 != got.C.Y.Cmp(want.C.Y) {
+			t.Fatalf("%d copies: accumulator result differs from ShareReplace", i+1)
+		}
+	}
+}
+
+func TestShareAccumulatorEmpty(t *testing.T) {
+	var acc ShareAccumulator
+	if _, err := acc.Finalize(&elgamal.CipherText{}); err == nil {
+		t.Fatal("expected error finalizing without shares")
+	}
+	if err := acc.Add(&elgamal.CipherText{}); err == nil {
+		t.Fatal("expected error adding an empty share")
+	}
+}
+
+func TestSafeAccumulator(t *testing.T) {
+	////////////////////////
+	// 模拟ks server数量/////
+	lens := 16
+	////////////////////////
+
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+	shares := make(elgamal.CipherVector, lens)
+	for i := 0; i < lens; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, _, err := ShareCal(&q.PublicKey, rB, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = *share
+	}
+
+	// 各协程并发累加份额
+	var acc SafeAccumulator
+	var wg sync.WaitGroup
+	for i := 0; i < lens; i++ {
+		wg.Add(1)
+		go func(share *elgamal.CipherText) {
+			defer wg.Done()
+			if err := acc.Add(share); err != nil {
+				t.Error(err)
+			}
+		}(&shares[i])
+	}
+	wg.Wait()
+	if acc.Count() != lens {
+		t.Fatalf("got count %d, want %d", acc.Count(), lens)
+	}
+
+	rct := elgamal.CipherText{K: *rB, C: *elgamal.GenPoint()}
+	got, err := acc.Finalize(&rct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ShareReplace(&shares, &rct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != got.K.X.Cmp(want.K.X) || 0 != got.C.X.Cmp(want.C.X) || 0 != got.C.Y.Cmp(want.C.Y) {
+		t.Fatal("concurrent accumulation differs from ShareReplace")
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// AggregateShareProof is one constant-size proof for a whole key switching
+// session: the sum of the shares of all nodes and a single proof (c,r1,r2) that
+// the sum was computed with the sum of their private keys. A requester, or a
+// contract, verifies it once instead of verifying one proof per node.
+// 聚合份额证明：整个密钥置换会话的定长证明，包括全部节点份额之和，以及证明该份额和由节点私钥之和
+// 计算得来的单个证明(c,r1,r2)。请求者（或链上合约）只需验证一次，而不必逐个验证各节点的证明。
+type AggregateShareProof struct {
+	Share elgamal.CipherText
+	Proof Pai
+}
+
+// Verify checks p against the public keys of the nodes, the target public key and
+// rB: the proof must hold for the statement of the summed share and the summed
+// node key, exactly as ShareProofVryNoB checks the share of a single node.
+// 验证聚合份额证明：以节点公钥之和及份额之和为公开信息验证p，与ShareProofVryNoB验证单个节点的
+// 份额相同。
+//
+// 参数：
+//		节点公钥slice：	nodePubKeys
+//		目标公钥：		targetPubKey
+//		密文左侧点：	rB
+// 返回：
+// 		验证结果：	bool
+func (p *AggregateShareProof) Verify(nodePubKeys []*sm2.PublicKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (bool, error) {
+	if targetPubKey == nil || rB == nil {
+		return false, opError("AggregateShareProof.Verify", ErrIncompleteStatement)
+	}
+	c, r1, r2 := p.Proof.Values()
+	if c == nil || r1 == nil || r2 == nil {
+		return false, nil
+	}
+	pub, err := AggregatePubKeys(nodePubKeys)
+	if err != nil {
+		return false, err
+	}
+	return shareProofVryNoB(p.Proof.suite(), c, r1, r2, &p.Share, pub, targetPubKey, rB)
+}
+
+// NewShareProver prepares the part of a node in an aggregated share proof, for the
+// random nonce ri of its share and its private key priv. The node publishes the
+// Commitment of the prover together with its share, then Responds once to the
+// challenge of the ProofAggregator.
+// 创建份额聚合证明的节点参与方：使用计算份额的随机数ri与节点私钥priv。节点将其承诺值与份额一同
+// 发送，随后对ProofAggregator的挑战值应答一次。
+//
+// 参数：
+//		随机数：	ri
+//		节点私钥：	priv
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+// 返回：
+// 		参与方
+func NewShareProver(ri *big.Int, priv *sm2.PrivateKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*proof.Prover, error) {
+	// y1 = ri, y2 = priv.D, A1 = targetPubKey, A2 = -rB
+	A2, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return nil, opError("NewShareProver", err)
+	}
+	return proof.NewProverNoB(ri, priv.D, (*elgamal.CurvePoint)(targetPubKey), A2)
+}
+
+// ProofAggregator runs the coordinator side of an aggregated share proof for one
+// session (targetPubKey, rB). It collects the share, node public key and prover
+// commitment of every node, issues a single Challenge, checks the response of each
+// node, and combines the responses into an AggregateShareProof. A node whose
+// response does not match its own commitment is reported by AddResponse. A
+// ProofAggregator is not safe for concurrent use.
+// 聚合证明协调者：为一次会话(targetPubKey, rB)执行份额聚合证明的协调者一方。收集各节点的份额、
+// 节点公钥与承诺值，发出一个挑战值，检查各节点的应答，并将应答合并为AggregateShareProof。
+// 应答与其承诺值不符的节点由AddResponse指出。ProofAggregator不可并发使用。
+type ProofAggregator struct {
+	suite        proof.Suite
+	targetPubKey *sm2.PublicKey
+	rB           *elgamal.CurvePoint
+	A2           *elgamal.CurvePoint
+
+	shares []elgamal.CipherText
+	pubs   []*sm2.PublicKey
+	Ts     []*proof.Commitment
+
+	c         *big.Int
+	share     elgamal.CipherText
+	r1, r2    *big.Int
+	responded []bool
+}
+
+// NewProofAggregator starts an aggregated share proof for targetPubKey and rB,
+// using the challenge hash of suite.
+// 创建聚合证明协调者：针对目标公钥targetPubKey与密文左侧点rB，使用参数组suite的挑战哈希。
+//
+// 参数：
+//		参数组		suite
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+// 返回：
+// 		协调者
+func NewProofAggregator(suite proof.Suite, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*ProofAggregator, error) {
+	if suite.Hash == 0 {
+		suite.Hash = proof.DefaultSuite.Hash
+	}
+	if targetPubKey == nil {
+		return nil, opError("NewProofAggregator", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return nil, opError("NewProofAggregator", err)
+	}
+	A2, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return nil, opError("NewProofAggregator", err)
+	}
+	return &ProofAggregator{suite: suite, targetPubKey: targetPubKey, rB: rB, A2: A2}, nil
+}
+
+// Add records the share, public key and prover commitment of one node and returns
+// the index of the node for AddResponse. It fails once the challenge is issued.
+// 添加节点：记录一个节点的份额、公钥与承诺值，返回该节点的下标以供AddResponse使用。
+// 挑战值发出后不可再添加。
+//
+// 参数：
+//		份额		share
+//		节点公钥	nodePubKey
+//		承诺值		T
+// 返回：
+// 		节点下标
+func (a *ProofAggregator) Add(share *elgamal.CipherText, nodePubKey *sm2.PublicKey, T *proof.Commitment) (int, error) {
+	if a.c != nil {
+		return -1, opError("ProofAggregator.Add", ErrChallengeIssued)
+	}
+	if nodePubKey == nil || T == nil {
+		return -1, opError("ProofAggregator.Add", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckCipherText(share); err != nil {
+		return -1, opError("ProofAggregator.Add", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(nodePubKey)); err != nil {
+		return -1, opError("ProofAggregator.Add", err)
+	}
+
+	a.shares = append(a.shares, *share)
+	a.pubs = append(a.pubs, nodePubKey)
+	a.Ts = append(a.Ts, T)
+	return len(a.shares) - 1, nil
+}
+
+// Challenge sums the shares, node keys and commitments added so far and returns
+// the challenge every node must respond to. Later calls return the same challenge.
+// 计算挑战值：加和已添加的份额、节点公钥与承诺值，返回各节点须应答的挑战值。再次调用返回同一挑战值。
+//
+// 参数：
+//
+// 返回：
+// 		挑战值：	c
+func (a *ProofAggregator) Challenge() (*big.Int, error) {
+	if a.c != nil {
+		return a.c, nil
+	}
+	if len(a.shares) == 0 {
+		return nil, opError("ProofAggregator.Challenge", elgamal.ErrEmpty)
+	}
+
+	curve := a.targetPubKey.Curve
+	share := a.shares[0]
+	for _, s := range a.shares[1:] {
+		share.K.X, share.K.Y = ec.Add(curve, share.K.X, share.K.Y, s.K.X, s.K.Y)
+		share.C.X, share.C.Y = ec.Add(curve, share.C.X, share.C.Y, s.C.X, s.C.Y)
+	}
+	pub, err := AggregatePubKeys(a.pubs)
+	if err != nil {
+		return nil, err
+	}
+	T, err := proof.AddCommitments(a.Ts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Y1 = sum share.K, Y2 = sum nodePubKey, A1 = targetPubKey, A2 = -rB, A = sum share.C
+	c, err := a.suite.ChallengeNoB(&share.K, (*elgamal.CurvePoint)(pub), (*elgamal.CurvePoint)(a.targetPubKey), a.A2, &share.C, T)
+	if err != nil {
+		return nil, err
+	}
+
+	a.c = c
+	a.share = share
+	a.r1, a.r2 = new(big.Int), new(big.Int)
+	a.responded = make([]bool, len(a.shares))
+	return c, nil
+}
+
+// AddResponse checks the responses (r1,r2) of the node at index against its own
+// share and commitment, and adds them to the aggregated proof. A mismatch is
+// reported as ErrProofFailed for that node, which can then be excluded.
+// 添加应答：以下标为index的节点自身的份额与承诺值检查其应答(r1,r2)，并计入聚合证明。
+// 不符时返回该节点的ErrProofFailed，据此可排除该节点。
+//
+// 参数：
+//		节点下标	index
+//		应答		r1,r2
+// 返回：
+// 		错误
+func (a *ProofAggregator) AddResponse(index int, r1, r2 *big.Int) error {
+	if a.c == nil {
+		return opError("ProofAggregator.AddResponse", ErrIncompleteProof)
+	}
+	if index < 0 || index >= len(a.shares) {
+		return itemError("ProofAggregator.AddResponse", "node", index, elgamal.ErrOutOfRange)
+	}
+	if a.responded[index] {
+		return nil
+	}
+	share := &a.shares[index]
+	if !proof.CheckResponseNoB(a.c, r1, r2, &share.K, (*elgamal.CurvePoint)(a.pubs[index]), (*elgamal.CurvePoint)(a.targetPubKey), a.A2, &share.C, a.Ts[index]) {
+		return itemError("ProofAggregator.AddResponse", "node", index, ErrProofFailed)
+	}
+
+	N := a.targetPubKey.Curve.Params().N
+	a.r1.Add(a.r1, r1)
+	a.r1.Mod(a.r1, N)
+	a.r2.Add(a.r2, r2)
+	a.r2.Mod(a.r2, N)
+	a.responded[index] = true
+	return nil
+}
+
+// Proof returns the aggregated share proof once every node has responded.
+// 返回聚合份额证明，须全部节点均已应答。
+//
+// 参数：
+//
+// 返回：
+// 		聚合份额证明
+func (a *ProofAggregator) Proof() (*AggregateShareProof, error) {
+	if a.c == nil {
+		return nil, opError("ProofAggregator.Proof", ErrIncompleteProof)
+	}
+	for i, ok := range a.responded {
+		if !ok {
+			return nil, itemError("ProofAggregator.Proof", "node", i, ErrIncompleteProof)
+		}
+	}
+	return &AggregateShareProof{
+		Share: a.share,
+		Proof: NewPaiWithHash(a.c, a.r1, a.r2, a.suite.Hash),
+	}, nil
+}
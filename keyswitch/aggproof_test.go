@@ -0,0 +1,174 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// aggSession 为n个节点生成份额与联合证明参与方。
+func aggSession(t *testing.T, n int) (*sm2.PublicKey, *elgamal.CurvePoint, []*sm2.PublicKey, []*elgamal.CipherText, []*proof.Prover) {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	pubs := make([]*sm2.PublicKey, n)
+	shares := make([]*elgamal.CipherText, n)
+	provers := make([]*proof.Prover, n)
+	for i := 0; i < n; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, ri, err := ShareCal(&q.PublicKey, rB, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if provers[i], err = NewShareProver(ri, priv, &q.PublicKey, rB); err != nil {
+			t.Fatal(err)
+		}
+		pubs[i], shares[i] = &priv.PublicKey, share
+	}
+	return &q.PublicKey, rB, pubs, shares, provers
+}
+
+func TestProofAggregator(t *testing.T) {
+	target, rB, pubs, shares, provers := aggSession(t, 4)
+
+	agg, err := NewProofAggregator(proof.DefaultSuite, target, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range shares {
+		if _, err := agg.Add(shares[i], pubs[i], provers[i].Commitment()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := agg.Challenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agg.Add(shares[0], pubs[0], provers[0].Commitment()); !errors.Is(err, ErrChallengeIssued) {
+		t.Fatalf("got %v, want ErrChallengeIssued", err)
+	}
+	if _, err := agg.Proof(); !errors.Is(err, ErrIncompleteProof) {
+		t.Fatalf("got %v, want ErrIncompleteProof", err)
+	}
+
+	for i, p := range provers {
+		r1, r2, err := p.Respond(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := agg.AddResponse(i, r1, r2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := provers[0].Respond(c); !errors.Is(err, proof.ErrNonceReused) {
+		t.Fatalf("got %v, want ErrNonceReused", err)
+	}
+
+	ap, err := agg.Proof()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := ap.Verify(pubs, target, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("aggregated proof failed to verify")
+	}
+
+	// 聚合份额与逐个置换的结果一致
+	var acc ShareAccumulator
+	for _, s := range shares {
+		if err := acc.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rct := &elgamal.CipherText{K: *rB, C: *elgamal.GenPoint()}
+	want, err := acc.Finalize(rct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ShareReplace(&elgamal.CipherVector{ap.Share}, rct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.K.X.Cmp(want.K.X) != 0 || got.C.X.Cmp(want.C.X) != 0 {
+		t.Fatal("aggregated share differs from the sum of shares")
+	}
+
+	// 缺少一个节点公钥后验证失败
+	if ok, err := ap.Verify(pubs[1:], target, rB); err != nil || ok {
+		t.Fatal("aggregated proof verified without a node key")
+	}
+}
+
+func TestProofAggregatorBadResponse(t *testing.T) {
+	target, rB, pubs, shares, provers := aggSession(t, 3)
+
+	agg, err := NewProofAggregator(proof.Suite{}, target, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range shares {
+		if _, err := agg.Add(shares[i], pubs[i], provers[i].Commitment()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := agg.Challenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 节点1的应答与其承诺值不符
+	for i, p := range provers {
+		r1, r2, err := p.Respond(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			r2 = new(big.Int).Add(r2, big.NewInt(1))
+		}
+		err = agg.AddResponse(i, r1, r2)
+		if i == 1 {
+			var e *elgamal.Error
+			if !errors.Is(err, ErrProofFailed) || !errors.As(err, &e) || e.Index != 1 {
+				t.Fatalf("got %v, want ErrProofFailed for node 1", err)
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := agg.Proof(); !errors.Is(err, ErrIncompleteProof) {
+		t.Fatalf("got %v, want ErrIncompleteProof", err)
+	}
+	if err := agg.AddResponse(7, c, c); !errors.Is(err, elgamal.ErrOutOfRange) {
+		t.Fatalf("got %v, want ErrOutOfRange", err)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// AggregatePrivKeys returns the addition of the private keys in privs, like
+// CollPrivKey but without copying the keys, so they can come from different sources
+// such as keystores. The keys must be on one curve, and keys summing to zero are
+// refused with ErrPointNotOnCurve, as their public key is the point at infinity.
+// 聚合私钥：加和privs中的私钥并返回，与CollPrivKey相同，但不复制私钥，私钥可来自密钥库等不同来源。
+// 私钥须属于同一曲线；和为零的私钥对应无穷远点公钥，返回ErrPointNotOnCurve。
+//
+// 参数：
+//		私钥指针slice	privs
+// 返回：
+// 		聚合私钥
+func AggregatePrivKeys(privs []*sm2.PrivateKey) (*sm2.PrivateKey, error) {
+	if len(privs) == 0 {
+		return nil, opError("AggregatePrivKeys", elgamal.ErrEmpty)
+	}
+	for i, priv := range privs {
+		if priv == nil || priv.D == nil || priv.Curve == nil {
+			return nil, itemError("AggregatePrivKeys", "key", i, elgamal.ErrEmpty)
+		}
+		if priv.Curve.Params() != privs[0].Curve.Params() {
+			return nil, itemError("AggregatePrivKeys", "key", i, elgamal.ErrCurveMismatch)
+		}
+	}
+
+	// 累加私钥，模N
+	curve := privs[0].Curve
+	N := curve.Params().N
+	d := new(big.Int)
+	for _, priv := range privs {
+		d.Add(d, priv.D)
+	}
+	d.Mod(d, N)
+	if d.Sign() == 0 {
+		return nil, opError("AggregatePrivKeys", elgamal.ErrPointNotOnCurve)
+	}
+
+	collPrivKey := new(sm2.PrivateKey)
+	collPrivKey.Curve = curve
+	collPrivKey.D = d
+	collPrivKey.X, collPrivKey.Y = curve.ScalarBaseMult(d.Bytes())
+
+	return collPrivKey, nil
+}
+
+// AggregatePubKeys returns the addition of the public keys in pubs, like CollPubKey
+// but without copying the keys. Every key is checked to be on the curve of the
+// first, and keys cancelling out to the point at infinity are refused with
+// ErrPointNotOnCurve.
+// 聚合公钥：加和pubs中的公钥并返回，与CollPubKey相同，但不复制公钥，且逐个校验公钥在第一个公钥的曲线上；
+// 公钥相互抵消、和为无穷远点时返回ErrPointNotOnCurve。
+//
+// 参数：
+//		公钥指针slice	pubs
+// 返回：
+// 		聚合公钥
+func AggregatePubKeys(pubs []*sm2.PublicKey) (*sm2.PublicKey, error) {
+	if len(pubs) == 0 {
+		return nil, opError("AggregatePubKeys", elgamal.ErrEmpty)
+	}
+	for i, pub := range pubs {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return nil, itemError("AggregatePubKeys", "key", i, err)
+		}
+		if pub.Curve.Params() != pubs[0].Curve.Params() {
+			return nil, itemError("AggregatePubKeys", "key", i, elgamal.ErrCurveMismatch)
+		}
+	}
+
+	// 累加公钥，中间结果可为无穷远点
+	curve := pubs[0].Curve
+	sum := ec.NewContext(curve).Sum()
+	for _, pub := range pubs {
+		sum.Add(pub.X, pub.Y)
+	}
+	collPubKey := new(sm2.PublicKey)
+	collPubKey.Curve = curve
+	collPubKey.X, collPubKey.Y = sum.Point()
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(collPubKey)); err != nil {
+		return nil, opError("AggregatePubKeys", err)
+	}
+
+	return collPubKey, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestAggregateKeys(t *testing.T) {
+	////////////////////////
+	// 聚合密钥数量//////////
+	lens := 10
+	////////////////////////
+
+	privs := make([]*sm2.PrivateKey, lens)
+	pubs := make([]*sm2.PublicKey, lens)
+	privVals := make([]sm2.PrivateKey, lens)
+	for i := 0; i < lens; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+		pubs[i] = &priv.PublicKey
+		privVals[i] = *priv
+	}
+
+	collPriv, err := AggregatePrivKeys(privs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collPub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != collPub.X.Cmp(collPriv.X) || 0 != collPub.Y.Cmp(collPriv.Y) {
+		t.Fatal("aggregated public key differs from aggregated private key")
+	}
+
+	// 与按值聚合结果一致
+	want := CollPrivKey(privVals)
+	if 0 != want.D.Cmp(collPriv.D) {
+		t.Fatal("AggregatePrivKeys differs from CollPrivKey")
+	}
+
+	// 重复的公钥同样正确聚合
+	twice, err := AggregatePubKeys([]*sm2.PublicKey{pubs[0], pubs[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	twicePriv, err := AggregatePrivKeys([]*sm2.PrivateKey{privs[0], privs[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != twice.X.Cmp(twicePriv.X) || 0 != twice.Y.Cmp(twicePriv.Y) {
+		t.Fatal("repeated public key aggregated incorrectly")
+	}
+
+	if _, err := AggregatePubKeys(nil); !errors.Is(err, elgamal.ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+	if _, err := AggregatePubKeys([]*sm2.PublicKey{pubs[0], nil}); !errors.Is(err, elgamal.ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+	if _, err := AggregatePrivKeys([]*sm2.PrivateKey{privs[0], nil}); err == nil {
+		t.Fatal("expected error on nil private key")
+	}
+
+	// 相互抵消的密钥和为无穷远点，须拒绝；中间抵消不影响结果
+	negPub := &sm2.PublicKey{Curve: pubs[0].Curve, X: pubs[0].X, Y: new(big.Int).Sub(pubs[0].Curve.Params().P, pubs[0].Y)}
+	if _, err := AggregatePubKeys([]*sm2.PublicKey{pubs[0], negPub}); !errors.Is(err, elgamal.ErrPointNotOnCurve) {
+		t.Fatalf("cancelling keys: got %v, want ErrPointNotOnCurve", err)
+	}
+	if got, err := AggregatePubKeys([]*sm2.PublicKey{pubs[0], negPub, pubs[1]}); err != nil || 0 != got.X.Cmp(pubs[1].X) || 0 != got.Y.Cmp(pubs[1].Y) {
+		t.Fatalf("P + (-P) + Q differs from Q: %v", err)
+	}
+	negPriv := &sm2.PrivateKey{PublicKey: *negPub, D: new(big.Int).Sub(privs[0].Curve.Params().N, privs[0].D)}
+	if _, err := AggregatePrivKeys([]*sm2.PrivateKey{privs[0], negPriv}); !errors.Is(err, elgamal.ErrPointNotOnCurve) {
+		t.Fatalf("cancelling private keys: got %v, want ErrPointNotOnCurve", err)
+	}
+
+	// 不同曲线上的密钥
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub := &sm2.PublicKey{Curve: other.Curve, X: other.X, Y: other.Y}
+	if _, err := AggregatePubKeys([]*sm2.PublicKey{pubs[0], otherPub}); !errors.Is(err, elgamal.ErrCurveMismatch) {
+		t.Fatalf("got %v, want ErrCurveMismatch", err)
+	}
+	otherPriv := &sm2.PrivateKey{PublicKey: *otherPub, D: other.D}
+	if _, err := AggregatePrivKeys([]*sm2.PrivateKey{privs[0], otherPriv}); !errors.Is(err, elgamal.ErrCurveMismatch) {
+		t.Fatalf("got %v, want ErrCurveMismatch", err)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// anonShareDomain separates the scopes of anonymous shares from other ring
+// signatures.
+// 匿名份额的环签名范围所用的域分隔标签，与其他环签名区分。
+const anonShareDomain = "ppks-anon-share"
+
+// AnonymousShare is the audit record of a share attributed to "a member of the
+// committee" rather than to the server that computed it: a linkable ring signature
+// over the committee keys replaces the node key. All records of one rB and target
+// by the same member link, so CountAnonymous does not count a member twice, while
+// records of different requests cannot be linked to each other or to a server.
+// The share proof names the node key, so the combiner verifies the bundle before
+// logging it anonymously.
+// 匿名份额：将份额归于"委员会的某一成员"而非计算它的ks server的审计记录，以对委员会公钥的可链接环签名
+// 代替节点公钥。同一成员对同一rB与目标公钥的记录相互关联，CountAnonymous因此不会重复计数；不同请求的
+// 记录之间及其与ks server之间均不可关联。份额证明包含节点公钥，因此合并方先验证份额包，再匿名记录。
+type AnonymousShare struct {
+	Share        elgamal.CipherText
+	TargetPubKey *sm2.PublicKey
+	RB           *elgamal.CurvePoint
+	Signature    *schnorr.RingSignature
+}
+
+// Anonymize returns the anonymous audit record of b, ring-signed with the node key
+// priv on behalf of committee, which must contain it.
+// 匿名化份额包：以节点私钥priv代表委员会公钥committee（须包含priv的公钥）环签名，返回b的匿名审计记录。
+//
+// 参数：
+//		节点私钥	priv
+//		委员会公钥	committee
+// 返回：
+// 		匿名份额
+func (b *ShareBundle) Anonymize(priv *sm2.PrivateKey, committee []*sm2.PublicKey) (*AnonymousShare, error) {
+	if b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return nil, opError("ShareBundle.Anonymize", ErrIncompleteStatement)
+	}
+	if priv == nil || !sameKey(&priv.PublicKey, b.NodePubKey) {
+		return nil, opError("ShareBundle.Anonymize", ErrUnsupportedKey)
+	}
+	a := &AnonymousShare{Share: b.Share, TargetPubKey: b.TargetPubKey, RB: b.RB}
+	scope, err := a.scope()
+	if err != nil {
+		return nil, opError("ShareBundle.Anonymize", err)
+	}
+	f := a.Share.Fingerprint()
+	sig, err := schnorr.RingSign(priv, committee, scope, f[:])
+	if err != nil {
+		return nil, opError("ShareBundle.Anonymize", err)
+	}
+	a.Signature = sig
+	return a, nil
+}
+
+// Verify reports whether a is signed by a member of committee.
+// 验证匿名份额：判断a是否由委员会committee的成员签名。
+//
+// 参数：
+//		委员会公钥	committee
+// 返回：
+// 		验证结果
+func (a *AnonymousShare) Verify(committee []*sm2.PublicKey) (bool, error) {
+	scope, err := a.scope()
+	if err != nil {
+		return false, opError("AnonymousShare.Verify", err)
+	}
+	f := a.Share.Fingerprint()
+	ok, err := schnorr.RingVerify(committee, scope, f[:], a.Signature)
+	if err != nil {
+		return false, opError("AnonymousShare.Verify", err)
+	}
+	return ok, nil
+}
+
+// scope returns the ring signature scope of a: the domain, the target and rB.
+// 返回a的环签名范围：域分隔标签、目标公钥及rB。
+func (a *AnonymousShare) scope() ([]byte, error) {
+	if a.TargetPubKey == nil || a.RB == nil {
+		return nil, ErrIncompleteStatement
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(a.TargetPubKey)); err != nil {
+		return nil, err
+	}
+	if err := elgamal.CheckPoint(a.RB); err != nil {
+		return nil, err
+	}
+	if err := elgamal.CheckCipherText(&a.Share); err != nil {
+		return nil, err
+	}
+	scope := []byte(anonShareDomain)
+	scope = append(scope, (*elgamal.CurvePoint)(a.TargetPubKey).CompressedBytes()...)
+	return append(scope, a.RB.CompressedBytes()...), nil
+}
+
+// CountAnonymous verifies the anonymous records of one request and returns how
+// many distinct members of committee responded, counting linked records once.
+// Records of different rB or targets fail with ErrStatementMismatch, and an
+// invalid one with ErrProofFailed.
+// 匿名份额计数：验证同一请求的各匿名记录，返回作出响应的委员会committee成员数，相互关联的记录仅计一次。
+// 记录的rB或目标公钥不同时返回ErrStatementMismatch，记录无效时返回ErrProofFailed。
+//
+// 参数：
+//		委员会公钥		committee
+//		匿名份额slice	records
+// 返回：
+// 		响应成员数
+func CountAnonymous(committee []*sm2.PublicKey, records []*AnonymousShare) (int, error) {
+	if len(records) == 0 {
+		return 0, opError("CountAnonymous", elgamal.ErrEmpty)
+	}
+	var seen []*schnorr.RingSignature
+	for i, a := range records {
+		if a == nil {
+			return 0, itemError("CountAnonymous", "record", i, elgamal.ErrEmpty)
+		}
+		if i > 0 && (a.TargetPubKey == nil || a.RB == nil ||
+			!sameKey(a.TargetPubKey, records[0].TargetPubKey) || !samePoint(a.RB, records[0].RB)) {
+			return 0, itemError("CountAnonymous", "record", i, ErrStatementMismatch)
+		}
+		ok, err := a.Verify(committee)
+		if err != nil {
+			return 0, itemError("CountAnonymous", "record", i, err)
+		}
+		if !ok {
+			return 0, itemError("CountAnonymous", "record", i, ErrProofFailed)
+		}
+		linked := false
+		for _, sig := range seen {
+			if sig.Linked(a.Signature) {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			seen = append(seen, a.Signature)
+		}
+	}
+	return len(seen), nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCountAnonymous(t *testing.T) {
+	var privs []*sm2.PrivateKey
+	var committee []*sm2.PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		committee = append(committee, &priv.PublicKey)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+	anonymize := func(priv *sm2.PrivateKey, rB *elgamal.CurvePoint) *AnonymousShare {
+		b, err := GenShareBundle(&q.PublicKey, rB, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := b.Anonymize(priv, committee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	records := []*AnonymousShare{anonymize(privs[0], rB), anonymize(privs[2], rB)}
+	if n, err := CountAnonymous(committee, records); err != nil || n != 2 {
+		t.Fatalf("CountAnonymous = %d, %v; want 2", n, err)
+	}
+	// 同一成员的第二个份额不重复计数
+	records = append(records, anonymize(privs[0], rB))
+	if n, err := CountAnonymous(committee, records); err != nil || n != 2 {
+		t.Fatalf("CountAnonymous = %d, %v; want 2", n, err)
+	}
+
+	if _, err := CountAnonymous(committee, append(records, anonymize(privs[1], elgamal.GenPoint()))); !errors.Is(err, ErrStatementMismatch) {
+		t.Fatalf("got %v, want ErrStatementMismatch", err)
+	}
+	forged := *records[1]
+	forged.Share.C = *elgamal.GenPoint()
+	if _, err := CountAnonymous(committee, []*AnonymousShare{records[0], &forged}); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+
+	outsider, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenShareBundle(&q.PublicKey, rB, outsider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Anonymize(outsider, committee); !errors.Is(err, schnorr.ErrNotInRing) {
+		t.Fatalf("got %v, want ErrNotInRing", err)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"math/big"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// authDomain separates ciphertext tags from other uses of the MAC key.
+// 密文认证标签的域分隔串，与MAC密钥的其他用途相区分。
+const authDomain = "ppks-ct-auth-v1"
+
+// AuthCipherText is a ciphertext bound to the requester it is released to: Tag is
+// the SM3-HMAC, under a key the authorizing party shares with the KS servers, of
+// the compressed points of CipherText and RequesterID. A man in the middle can
+// then neither substitute rB to harvest shares of another ciphertext nor replay
+// the request for another requester. RequesterID may be, for instance, the
+// elgamal.KeyFingerprint of the target key.
+// 认证密文：与其发放对象（请求者）绑定的密文。Tag为授权方与ks server共享的密钥下，
+// 对CipherText的压缩点及RequesterID计算的SM3-HMAC。中间人因此既不能替换rB以获取其他密文的份额，
+// 也不能将请求重放给其他请求者。RequesterID可取目标公钥的elgamal.KeyFingerprint等。
+type AuthCipherText struct {
+	CipherText  elgamal.CipherText
+	RequesterID []byte
+	Tag         []byte
+}
+
+// NewAuthCipherText binds ct to requesterID with the MAC key key.
+// 生成认证密文：以MAC密钥key将密文ct与请求者标识requesterID绑定。
+//
+// 参数：
+//		MAC密钥		key
+//		密文		ct
+//		请求者标识	requesterID
+// 返回：
+// 		认证密文
+func NewAuthCipherText(key []byte, ct *elgamal.CipherText, requesterID []byte) (*AuthCipherText, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("NewAuthCipherText", err)
+	}
+	a := &AuthCipherText{CipherText: *ct, RequesterID: append([]byte(nil), requesterID...)}
+	a.Tag = a.tag(key)
+	return a, nil
+}
+
+// Verify checks the tag of a with the MAC key key, failing with ErrAuthFailed if
+// the ciphertext or the requester was changed.
+// 验证认证密文：以MAC密钥key验证a的认证标签，密文或请求者被篡改时返回ErrAuthFailed。
+//
+// 参数：
+//		MAC密钥	key
+// 返回：
+// 		错误
+func (a *AuthCipherText) Verify(key []byte) error {
+	if err := elgamal.CheckCipherText(&a.CipherText); err != nil {
+		return opError("AuthCipherText.Verify", err)
+	}
+	if !hmac.Equal(a.Tag, a.tag(key)) {
+		return opError("AuthCipherText.Verify", ErrAuthFailed)
+	}
+	return nil
+}
+
+// tag returns the SM3-HMAC of a under key.
+// 返回a在密钥key下的SM3-HMAC。
+func (a *AuthCipherText) tag(key []byte) []byte {
+	var n [4]byte
+	mac := hmac.New(sm3.New, key)
+	mac.Write([]byte(authDomain))
+	binary.BigEndian.PutUint32(n[:], uint32(len(a.RequesterID)))
+	mac.Write(n[:])
+	mac.Write(a.RequesterID)
+	mac.Write(a.CipherText.K.CompressedBytes())
+	mac.Write(a.CipherText.C.CompressedBytes())
+	return mac.Sum(nil)
+}
+
+// ShareCalAuth verifies the tag of act with the MAC key key and only then
+// calculates the share related with its rB for targetPubKey with priv.
+// 认证份额计算：以MAC密钥key验证act的认证标签，通过后才使用私钥priv为目标公钥targetPubKey计算关于其rB的份额。
+//
+// 参数：
+//		MAC密钥		key
+//		认证密文	act
+//		目标公钥	targetPubKey
+//		私钥		priv
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func ShareCalAuth(key []byte, act *AuthCipherText, targetPubKey *sm2.PublicKey, priv *sm2.PrivateKey) (*elgamal.CipherText, *big.Int, error) {
+	if act == nil {
+		return nil, nil, opError("ShareCalAuth", ErrAuthFailed)
+	}
+	if err := act.Verify(key); err != nil {
+		return nil, nil, opError("ShareCalAuth", err)
+	}
+	return ShareCal(targetPubKey, &act.CipherText.K, priv)
+}
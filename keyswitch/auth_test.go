@@ -0,0 +1,73 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareCalAuth(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("shared mac key between owner and servers")
+	ct, err := elgamal.PointEncrypt(&priv.PublicKey, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := elgamal.KeyFingerprint(&q.PublicKey)
+
+	act, err := NewAuthCipherText(key, ct, id[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ShareCalAuth(key, act, &q.PublicKey, priv); err != nil {
+		t.Fatal(err)
+	}
+
+	// 替换rB
+	forged := *act
+	other, err := elgamal.PointEncrypt(&priv.PublicKey, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.CipherText.K = other.K
+	if _, _, err := ShareCalAuth(key, &forged, &q.PublicKey, priv); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expected ErrAuthFailed for a substituted rB, got %v", err)
+	}
+
+	// 替换请求者
+	forged = *act
+	forged.RequesterID = []byte("mallory")
+	if err := forged.Verify(key); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expected ErrAuthFailed for another requester, got %v", err)
+	}
+
+	if err := act.Verify([]byte("wrong key")); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expected ErrAuthFailed for a wrong key, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"sort"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+)
+
+// BatchVerifyShares verifies the share proofs of bundles and returns the indices
+// whose proof failed. Bundles carrying their proof commitment are checked together,
+// one randomized multi-scalar multiplication per challenge hash and policy, instead
+// of about six scalar multiplications each; only when such a check fails are its bundles
+// verified one by one to locate the failures. Bundles without a commitment are
+// always verified one by one. As in PaiVector.BatchVerify, a missing proof, an
+// incomplete statement or an invalid point counts as a failure.
+// 份额包批量验证：验证bundles中各份额包的证明，返回验证失败的下标。携带证明承诺值的份额包按
+// 挑战哈希及策略分组，每组以随机系数合并为一次多标量乘法验证，代替逐个约6次标量乘法；仅当合并验证
+// 未通过时，才逐个验证该组份额包以定位失败者。没有承诺值的份额包始终逐个验证。
+// 与PaiVector.BatchVerify相同，缺失证明、公开信息不完整或点无效均视为验证失败。
+//
+// 参数：
+//		份额包slice：	bundles
+// 返回：
+// 		验证失败的下标，全部通过时为空
+func BatchVerifyShares(bundles []*ShareBundle) ([]int, error) {
+	var failed []int
+	// 各-rB为临时点，全部验证结束后归还
+	var scratch []*elgamal.CurvePoint
+	defer func() {
+		for _, P := range scratch {
+			elgamal.PutPoint(P)
+		}
+	}()
+	verifyOne := func(i int) {
+		if ok, err := bundles[i].Verify(); err != nil || !ok {
+			failed = append(failed, i)
+		}
+	}
+
+	// 按参数组（挑战哈希与策略）分组，组内可合并验证
+	var ctx *ec.Context
+	var suites []proof.Suite
+	groups := make(map[proof.Suite][]int)
+	items := make(map[proof.Suite][]proof.BatchItem)
+	for i, b := range bundles {
+		if b == nil || b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+			failed = append(failed, i)
+			continue
+		}
+		if b.Commitment == nil {
+			verifyOne(i)
+			continue
+		}
+		c, r1, r2 := b.Proof.Values()
+		if c == nil || r1 == nil || r2 == nil {
+			failed = append(failed, i)
+			continue
+		}
+		if ctx == nil {
+			ctx = ec.NewContext(b.Share.K.Curve)
+		}
+		if checkShare(ctx, &b.Share) != nil {
+			failed = append(failed, i)
+			continue
+		}
+		A2 := elgamal.GetPoint()
+		scratch = append(scratch, A2)
+		if err := negPointTo(ctx, A2, b.RB); err != nil {
+			failed = append(failed, i)
+			continue
+		}
+
+		id := b.suite()
+		if _, ok := groups[id]; !ok {
+			suites = append(suites, id)
+		}
+		groups[id] = append(groups[id], i)
+		// Y1=share.K, Y2=nodePubKey, A1=targetPubKey, A2=-rB, A=share.C
+		items[id] = append(items[id], proof.BatchItem{
+			C: c, R1: r1, R2: r2, T: b.Commitment,
+			Y1: &b.Share.K,
+			Y2: (*elgamal.CurvePoint)(b.NodePubKey),
+			A1: (*elgamal.CurvePoint)(b.TargetPubKey),
+			A2: A2,
+			A:  &b.Share.C,
+		})
+	}
+
+	for _, id := range suites {
+		ok, err := id.BatchVerifyNoB(items[id])
+		if err == nil && ok {
+			continue
+		}
+		// 合并验证未通过：逐个验证以定位失败的份额包
+		for _, i := range groups[id] {
+			verifyOne(i)
+		}
+	}
+
+	sort.Ints(failed)
+	return failed, nil
+}
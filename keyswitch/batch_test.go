@@ -0,0 +1,117 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"reflect"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// shareBundles 生成n个节点针对同一目标公钥与rB的份额包。
+func shareBundles(t *testing.T, n int) []*ShareBundle {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	bundles := make([]*ShareBundle, n)
+	for i := range bundles {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bundles[i], err = GenShareBundle(&q.PublicKey, rB, priv); err != nil {
+			t.Fatal(err)
+		}
+		if bundles[i].Commitment == nil {
+			t.Fatal("bundle carries no commitment")
+		}
+	}
+	return bundles
+}
+
+func TestBatchVerifyShares(t *testing.T) {
+	bundles := shareBundles(t, 6)
+
+	failed, err := BatchVerifyShares(bundles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("honest bundles failed: %v", failed)
+	}
+
+	// 篡改份额：合并验证失败后逐个验证定位
+	forged := *bundles[4]
+	forged.Share.C = *elgamal.GenPoint()
+	bundles[4] = &forged
+	// 没有承诺值的份额包逐个验证
+	bare := *bundles[1]
+	bare.Commitment = nil
+	bundles[1] = &bare
+	// 缺失的份额包视为失败
+	bundles[2] = nil
+
+	failed, err = BatchVerifyShares(bundles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(failed, []int{2, 4}) {
+		t.Fatalf("got failed %v, want [2 4]", failed)
+	}
+}
+
+func TestBatchVerifySharesSuites(t *testing.T) {
+	bundles := shareBundles(t, 2)
+
+	// 不同挑战哈希的份额包分组验证
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenShareBundleWithSuite(proof.Suite{Hash: proof.HashSHA256}, bundles[0].TargetPubKey, bundles[0].RB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundles = append(bundles, b)
+
+	failed, err := BatchVerifyShares(bundles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("honest bundles failed: %v", failed)
+	}
+
+	// 承诺值与证明不符
+	swapped := *bundles[0]
+	swapped.Commitment = bundles[1].Commitment
+	bundles[0] = &swapped
+	failed, err = BatchVerifyShares(bundles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("a foreign commitment must only disable batching, got failed %v", failed)
+	}
+}
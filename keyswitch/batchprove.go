@@ -0,0 +1,223 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// batchShareProtocol names the transcripts of batched share proofs.
+// 批量份额证明记录的协议名。
+const batchShareProtocol = "ppks-share-batch"
+
+// BatchShareProof proves a batch of shares of one node for one target key at once:
+// R[i] is the response for the nonce of share i and RKey the single response for
+// the node key, which all shares have in common. Compared with one proof per share,
+// the commitment and response for the node key are made once for the whole batch,
+// saving a base-point multiplication per share, and there is one challenge.
+// 批量份额证明：一次证明同一节点为同一目标公钥计算的一批份额。R[i]为份额i随机数的应答，RKey为
+// 各份额共有的节点私钥的唯一应答。与逐个份额证明相比，节点私钥的承诺与应答对整批只计算一次，
+// 每个份额节省一次基点乘法，且只有一个挑战值。
+type BatchShareProof struct {
+	C    *big.Int
+	R    []*big.Int
+	RKey *big.Int
+}
+
+// ShareCalBatch calculates the shares related with each of rBs for targetPubKey
+// with priv and proves them all with one BatchShareProof. The proof covers the batch
+// as a whole; a verifier that needs to accept shares one by one should use
+// GenShareBundle instead.
+// 批量份额计算：使用私钥priv为目标公钥targetPubKey计算关于rBs中各点的份额，并以一个BatchShareProof
+// 证明全部份额。该证明针对整批份额，需要逐个接受份额的验证方应使用GenShareBundle。
+//
+// 参数：
+//		目标公钥		targetPubKey
+//		密文左侧点slice	rBs
+//		私钥			priv
+// 返回：
+// 		份额slice
+//		批量证明
+func ShareCalBatch(targetPubKey *sm2.PublicKey, rBs []*elgamal.CurvePoint, priv *sm2.PrivateKey) (elgamal.CipherVector, *BatchShareProof, error) {
+	if len(rBs) == 0 {
+		return nil, nil, opError("ShareCalBatch", elgamal.ErrEmpty)
+	}
+	if priv == nil || priv.D == nil {
+		return nil, nil, opError("ShareCalBatch", ErrUnsupportedKey)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return nil, nil, opError("ShareCalBatch", err)
+	}
+	for i, rB := range rBs {
+		if err := elgamal.CheckPoint(rB); err != nil {
+			return nil, nil, itemError("ShareCalBatch", "rB", i, err)
+		}
+	}
+
+	curve := priv.Curve
+	N := curve.Params().N
+	vKey, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, opError("ShareCalBatch", err)
+	}
+	shares := make(elgamal.CipherVector, len(rBs))
+	ris := make([]*big.Int, len(rBs))
+	vs := make([]*big.Int, len(rBs))
+	TK := make([]*elgamal.CurvePoint, len(rBs))
+	TC := make([]*elgamal.CurvePoint, len(rBs))
+	for i, rB := range rBs {
+		share, ri, err := ShareCal(targetPubKey, rB, priv)
+		if err != nil {
+			return nil, nil, itemError("ShareCalBatch", "rB", i, err)
+		}
+		shares[i], ris[i] = *share, ri
+		if vs[i], err = ec.RandFieldElement(curve, rand.Reader); err != nil {
+			return nil, nil, opError("ShareCalBatch", err)
+		}
+
+		// 承诺值：TK_i=v_i*B, TC_i=v_i*U-vKey*rB_i
+		TK[i] = &elgamal.CurvePoint{Curve: curve}
+		TK[i].X, TK[i].Y = curve.ScalarBaseMult(vs[i].Bytes())
+		vUx, vUy := elgamal.ScalarMult((*elgamal.CurvePoint)(targetPubKey), vs[i].Bytes())
+		vrBx, vrBy := curve.ScalarMult(rB.X, rB.Y, vKey.Bytes())
+		vrBx, vrBy = ec.Neg(curve, vrBx, vrBy)
+		TC[i] = &elgamal.CurvePoint{Curve: curve}
+		TC[i].X, TC[i].Y = ec.Add(curve, vUx, vUy, vrBx, vrBy)
+	}
+	// 节点私钥的承诺值只计算一次：TY=vKey*B
+	TY := &elgamal.CurvePoint{Curve: curve}
+	TY.X, TY.Y = curve.ScalarBaseMult(vKey.Bytes())
+
+	c, err := batchShareChallenge(&priv.PublicKey, targetPubKey, rBs, shares, TY, TK, TC)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &BatchShareProof{C: c, R: make([]*big.Int, len(rBs))}
+	for i := range ris {
+		p.R[i] = new(big.Int).Mul(c, ris[i])
+		p.R[i].Sub(vs[i], p.R[i])
+		p.R[i].Mod(p.R[i], N)
+	}
+	p.RKey = new(big.Int).Mul(c, priv.D)
+	p.RKey.Sub(vKey, p.RKey)
+	p.RKey.Mod(p.RKey, N)
+	return shares, p, nil
+}
+
+// VerifyShareBatch verifies the proof p of ShareCalBatch that shares were computed
+// by the node with key nodePubKey for targetPubKey, share i being related with rBs[i].
+// 批量份额证明验证：验证ShareCalBatch生成的证明p，即shares由公钥为nodePubKey的节点为目标公钥targetPubKey
+// 计算，且份额i关于点rBs[i]。
+//
+// 参数：
+//		份额slice		shares
+//		节点公钥		nodePubKey
+//		目标公钥		targetPubKey
+//		密文左侧点slice	rBs
+//		批量证明		p
+// 返回：
+// 		验证结果
+func VerifyShareBatch(shares elgamal.CipherVector, nodePubKey, targetPubKey *sm2.PublicKey, rBs []*elgamal.CurvePoint, p *BatchShareProof) (bool, error) {
+	if len(rBs) == 0 {
+		return false, opError("VerifyShareBatch", elgamal.ErrEmpty)
+	}
+	if len(shares) != len(rBs) {
+		return false, opError("VerifyShareBatch", elgamal.ErrLengthMismatch)
+	}
+	for _, P := range []*sm2.PublicKey{nodePubKey, targetPubKey} {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(P)); err != nil {
+			return false, opError("VerifyShareBatch", err)
+		}
+	}
+	for i := range rBs {
+		if err := elgamal.CheckPoint(rBs[i]); err != nil {
+			return false, itemError("VerifyShareBatch", "rB", i, err)
+		}
+		if err := elgamal.CheckCipherText(&shares[i]); err != nil {
+			return false, itemError("VerifyShareBatch", "share", i, err)
+		}
+	}
+	if p == nil || p.C == nil || p.RKey == nil || len(p.R) != len(rBs) {
+		return false, nil
+	}
+	for _, r := range p.R {
+		if r == nil {
+			return false, nil
+		}
+	}
+
+	// 重构承诺：TY'=RKey*B+c*Y, TK_i'=R_i*B+c*K_i, TC_i'=R_i*U-RKey*rB_i+c*C_i
+	curve := targetPubKey.Curve
+	c := p.C.Bytes()
+	TY := &elgamal.CurvePoint{Curve: curve}
+	TY.X, TY.Y = curve.ScalarBaseMult(p.RKey.Bytes())
+	cYx, cYy := curve.ScalarMult(nodePubKey.X, nodePubKey.Y, c)
+	TY.X, TY.Y = ec.Add(curve, TY.X, TY.Y, cYx, cYy)
+	TK := make([]*elgamal.CurvePoint, len(rBs))
+	TC := make([]*elgamal.CurvePoint, len(rBs))
+	for i, rB := range rBs {
+		K, C := &shares[i].K, &shares[i].C
+		TK[i] = &elgamal.CurvePoint{Curve: curve}
+		TK[i].X, TK[i].Y = curve.ScalarBaseMult(p.R[i].Bytes())
+		cKx, cKy := curve.ScalarMult(K.X, K.Y, c)
+		TK[i].X, TK[i].Y = ec.Add(curve, TK[i].X, TK[i].Y, cKx, cKy)
+
+		rUx, rUy := curve.ScalarMult(targetPubKey.X, targetPubKey.Y, p.R[i].Bytes())
+		rrBx, rrBy := curve.ScalarMult(rB.X, rB.Y, p.RKey.Bytes())
+		rrBx, rrBy = ec.Neg(curve, rrBx, rrBy)
+		cCx, cCy := curve.ScalarMult(C.X, C.Y, c)
+		TC[i] = &elgamal.CurvePoint{Curve: curve}
+		TC[i].X, TC[i].Y = ec.Add(curve, rUx, rUy, rrBx, rrBy)
+		TC[i].X, TC[i].Y = ec.Add(curve, TC[i].X, TC[i].Y, cCx, cCy)
+	}
+
+	c2, err := batchShareChallenge(nodePubKey, targetPubKey, rBs, shares, TY, TK, TC)
+	if err != nil {
+		return false, err
+	}
+	return 0 == p.C.Cmp(c2), nil
+}
+
+// batchShareChallenge returns the challenge of a batched share proof over the
+// statement and the commitments.
+// 由公开信息与承诺值计算批量份额证明的挑战值。
+func batchShareChallenge(nodePubKey, targetPubKey *sm2.PublicKey, rBs []*elgamal.CurvePoint, shares elgamal.CipherVector, TY *elgamal.CurvePoint, TK, TC []*elgamal.CurvePoint) (*big.Int, error) {
+	t, err := proof.DefaultSuite.NewTranscript(batchShareProtocol)
+	if err != nil {
+		return nil, err
+	}
+	t.AppendPoint("Y", (*elgamal.CurvePoint)(nodePubKey))
+	t.AppendPoint("U", (*elgamal.CurvePoint)(targetPubKey))
+	t.AppendScalar("n", big.NewInt(int64(len(rBs))))
+	for i, rB := range rBs {
+		t.AppendPoint("rB", rB)
+		t.AppendPoint("K", &shares[i].K)
+		t.AppendPoint("C", &shares[i].C)
+	}
+	t.AppendPoint("TY", TY)
+	for i := range TK {
+		t.AppendPoint("TK", TK[i])
+		t.AppendPoint("TC", TC[i])
+	}
+	return t.ChallengeScalar("c"), nil
+}
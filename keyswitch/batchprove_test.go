@@ -0,0 +1,89 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareCalBatch(t *testing.T) {
+	k, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cts []*elgamal.CipherText
+	var Ms []*elgamal.CurvePoint
+	var rBs []*elgamal.CurvePoint
+	for i := 0; i < 5; i++ {
+		M := elgamal.GenPoint()
+		ct, err := elgamal.PointEncrypt(&k.PublicKey, M)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts = append(cts, ct)
+		Ms = append(Ms, M)
+		rBs = append(rBs, &ct.K)
+	}
+
+	shares, p, err := ShareCalBatch(&q.PublicKey, rBs, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyShareBatch(shares, &k.PublicKey, &q.PublicKey, rBs, p); err != nil || !ok {
+		t.Fatalf("batch proof failed to verify: %v", err)
+	}
+	for i := range shares {
+		ct, err := ShareReplace(&elgamal.CipherVector{shares[i]}, cts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		D, err := elgamal.PointDecrypt(ct, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !samePoint(D, Ms[i]) {
+			t.Fatalf("share %d: decrypted point differs", i)
+		}
+	}
+
+	// 交换两个份额、更换节点公钥或篡改应答后验证失败
+	swapped := append(elgamal.CipherVector(nil), shares...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	if ok, _ := VerifyShareBatch(swapped, &k.PublicKey, &q.PublicKey, rBs, p); ok {
+		t.Fatal("swapped shares verified")
+	}
+	if ok, _ := VerifyShareBatch(shares, &q.PublicKey, &q.PublicKey, rBs, p); ok {
+		t.Fatal("batch proof verified under another node key")
+	}
+	bad := *p
+	bad.RKey = new(big.Int).Add(p.RKey, big.NewInt(1))
+	if ok, _ := VerifyShareBatch(shares, &k.PublicKey, &q.PublicKey, rBs, &bad); ok {
+		t.Fatal("tampered batch proof verified")
+	}
+	if ok, _ := VerifyShareBatch(shares[:4], &k.PublicKey, &q.PublicKey, rBs[:4], p); ok {
+		t.Fatal("truncated batch verified")
+	}
+}
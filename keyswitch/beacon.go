@@ -0,0 +1,349 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/kdf"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Domains of the randomness beacon. 随机信标的域分隔标签。
+const (
+	beaconCommitDomain = "ppks-beacon-commit-v1"
+	beaconOutputDomain = "ppks-beacon-output-v1"
+	beaconProofContext = "ppks-beacon-contribution"
+	beaconIndexInfo    = "ppks-beacon-index"
+)
+
+// BeaconCommitment is an SM3 commitment to a node's beacon contribution.
+// 信标承诺：对节点信标贡献的SM3承诺。
+type BeaconCommitment [32]byte
+
+// BeaconContribution is a node's contribution to a randomness beacon: a random
+// point X=xB and a proof, bound to the session and the node, that the node knows x.
+// 信标贡献：节点对随机信标的贡献，包括随机点X=xB，以及绑定会话与节点的、节点知道x的证明。
+type BeaconContribution struct {
+	NodePubKey *sm2.PublicKey
+	X          elgamal.CurvePoint
+	Proof      *proof.LinearProof
+}
+
+// beaconContext returns the proof context of a contribution of nodePubKey to session.
+// 返回节点nodePubKey对会话session贡献的证明上下文。
+func beaconContext(session []byte, nodePubKey *sm2.PublicKey) string {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(session)))
+	return beaconProofContext + string(n[:]) + string(session) +
+		string((*elgamal.CurvePoint)(nodePubKey).CompressedBytes())
+}
+
+// commitment returns SM3(domain, session, node key, X) of c.
+// 计算贡献c的承诺SM3(域分隔标签, 会话, 节点公钥, X)。
+func (c *BeaconContribution) commitment(session []byte) BeaconCommitment {
+	var out BeaconCommitment
+	var n [4]byte
+	h := sm3.New()
+	h.Write([]byte(beaconCommitDomain))
+	binary.BigEndian.PutUint32(n[:], uint32(len(session)))
+	h.Write(n[:])
+	h.Write(session)
+	h.Write((*elgamal.CurvePoint)(c.NodePubKey).CompressedBytes())
+	h.Write(c.X.CompressedBytes())
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// NewBeaconContribution draws the contribution of the node priv to the beacon of
+// session and returns it with its commitment. The node sends the commitment in the
+// commit phase and the contribution in the reveal phase.
+// 生成信标贡献：为节点priv生成其对会话session随机信标的贡献，连同承诺一起返回。节点在承诺阶段发送承诺，
+// 在揭示阶段发送贡献。
+//
+// 参数：
+//		节点私钥	priv
+//		会话		session
+// 返回：
+// 		信标贡献
+//		承诺值
+func NewBeaconContribution(priv *sm2.PrivateKey, session []byte) (*BeaconContribution, BeaconCommitment, error) {
+	if priv == nil || priv.D == nil {
+		return nil, BeaconCommitment{}, opError("NewBeaconContribution", ErrUnsupportedKey)
+	}
+	curve := priv.Curve
+	x, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, BeaconCommitment{}, opError("NewBeaconContribution", err)
+	}
+	c := &BeaconContribution{NodePubKey: &priv.PublicKey}
+	c.X.Curve = curve
+	c.X.X, c.X.Y = curve.ScalarBaseMult(x.Bytes())
+
+	rel := proof.DLogRelation(elgamal.Generator(curve), &c.X)
+	if c.Proof, err = proof.ProveLinear(beaconContext(session, c.NodePubKey), rel, []*big.Int{x}); err != nil {
+		return nil, BeaconCommitment{}, err
+	}
+	return c, c.commitment(session), nil
+}
+
+// Beacon collects the contributions of the nodes to a commit-reveal randomness
+// beacon for one session. Its output, the hash of the sum of all contributions,
+// is unpredictable as long as one node is honest, and no node can bias it after
+// seeing the others' contributions; a node withholding its reveal can still abort
+// the session, which should then be retried without it. A Beacon is safe for
+// concurrent use.
+// 随机信标：收集一次会话中各节点对承诺-揭示随机信标的贡献。其输出为全部贡献之和的哈希，只要有一个节点诚实
+// 即不可预测，且任何节点都无法在看到其他节点的贡献后使其产生偏差；拒绝揭示的节点仍可中止会话，此时应在排除
+// 该节点后重试。Beacon可并发使用。
+type Beacon struct {
+	session []byte
+	index   map[elgamal.Fingerprint]int
+
+	mu       sync.Mutex
+	commits  []*BeaconCommitment
+	pending  int
+	contribs []*BeaconContribution
+	revealed int
+}
+
+// NewBeacon starts the commit phase of the beacon of session for the nodes nodePubKeys.
+// 创建随机信标：为节点nodePubKeys开始会话session随机信标的承诺阶段。
+//
+// 参数：
+//		会话			session
+//		节点公钥slice	nodePubKeys
+// 返回：
+// 		随机信标
+func NewBeacon(session []byte, nodePubKeys []*sm2.PublicKey) (*Beacon, error) {
+	if len(nodePubKeys) == 0 {
+		return nil, opError("NewBeacon", elgamal.ErrEmpty)
+	}
+	b := &Beacon{
+		session:  append([]byte(nil), session...),
+		index:    make(map[elgamal.Fingerprint]int, len(nodePubKeys)),
+		commits:  make([]*BeaconCommitment, len(nodePubKeys)),
+		pending:  len(nodePubKeys),
+		contribs: make([]*BeaconContribution, len(nodePubKeys)),
+	}
+	for i, pub := range nodePubKeys {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return nil, itemError("NewBeacon", "node", i, err)
+		}
+		f := elgamal.KeyFingerprint(pub)
+		if _, ok := b.index[f]; ok {
+			return nil, itemError("NewBeacon", "node", i, ErrUnknownNode)
+		}
+		b.index[f] = i
+	}
+	return b, nil
+}
+
+// node returns the index of nodePubKey.
+// 返回节点公钥nodePubKey的下标。
+func (b *Beacon) node(op string, nodePubKey *sm2.PublicKey) (int, error) {
+	if nodePubKey == nil {
+		return 0, opError(op, ErrUnknownNode)
+	}
+	i, ok := b.index[elgamal.KeyFingerprint(nodePubKey)]
+	if !ok {
+		return 0, opError(op, ErrUnknownNode)
+	}
+	return i, nil
+}
+
+// Commit records the commitment c of the node nodePubKey. Each node commits once,
+// and only during the commit phase.
+// 提交承诺：记录节点nodePubKey的承诺c。每个节点只能提交一次，且只能在承诺阶段提交。
+//
+// 参数：
+//		节点公钥	nodePubKey
+//		承诺值		c
+// 返回：
+//
+func (b *Beacon) Commit(nodePubKey *sm2.PublicKey, c BeaconCommitment) error {
+	i, err := b.node("Beacon.Commit", nodePubKey)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending == 0 || b.commits[i] != nil {
+		return opError("Beacon.Commit", ErrWrongPhase)
+	}
+	b.commits[i] = &c
+	b.pending--
+	return nil
+}
+
+// Reveal accepts the contribution c of its node once all commitments are in. It
+// fails with ErrInvalidShare unless c opens the node's commitment, and with
+// ErrProofFailed unless its proof verifies.
+// 揭示贡献：全部承诺到齐后接受节点的贡献c。c与该节点的承诺不符时返回ErrInvalidShare，
+// 其证明未通过验证时返回ErrProofFailed。
+//
+// 参数：
+//		信标贡献	c
+// 返回：
+//
+func (b *Beacon) Reveal(c *BeaconContribution) error {
+	if c == nil {
+		return opError("Beacon.Reveal", ErrIncompleteStatement)
+	}
+	i, err := b.node("Beacon.Reveal", c.NodePubKey)
+	if err != nil {
+		return err
+	}
+	if err := elgamal.CheckPoint(&c.X); err != nil {
+		return opError("Beacon.Reveal", err)
+	}
+	b.mu.Lock()
+	if b.pending != 0 || b.contribs[i] != nil {
+		b.mu.Unlock()
+		return opError("Beacon.Reveal", ErrWrongPhase)
+	}
+	want := *b.commits[i]
+	b.mu.Unlock()
+
+	got := c.commitment(b.session)
+	if subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+		return opError("Beacon.Reveal", ErrInvalidShare)
+	}
+	rel := proof.DLogRelation(elgamal.Generator(c.X.Curve), &c.X)
+	ok, err := proof.VerifyLinear(beaconContext(b.session, c.NodePubKey), rel, c.Proof)
+	if err != nil {
+		return opError("Beacon.Reveal", err)
+	}
+	if !ok {
+		return opError("Beacon.Reveal", ErrProofFailed)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.contribs[i] != nil {
+		return opError("Beacon.Reveal", ErrWrongPhase)
+	}
+	b.contribs[i] = c
+	b.revealed++
+	return nil
+}
+
+// Output returns the beacon output once every node has revealed: the SM3 hash of
+// the session and the sum of the contributions.
+// 所有节点均已揭示后返回信标输出，即会话与全部贡献之和的SM3哈希。
+//
+// 参数：
+//
+// 返回：
+// 		信标输出
+func (b *Beacon) Output() (BeaconOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.revealed != len(b.contribs) {
+		return nil, opError("Beacon.Output", ErrWrongPhase)
+	}
+
+	curve := b.contribs[0].X.Curve
+	sx, sy := b.contribs[0].X.X, b.contribs[0].X.Y
+	for _, c := range b.contribs[1:] {
+		sx, sy = ec.Add(curve, sx, sy, c.X.X, c.X.Y)
+	}
+	S := &elgamal.CurvePoint{Curve: curve, X: sx, Y: sy}
+
+	var n [4]byte
+	h := sm3.New()
+	h.Write([]byte(beaconOutputDomain))
+	binary.BigEndian.PutUint32(n[:], uint32(len(b.session)))
+	h.Write(n[:])
+	h.Write(b.session)
+	if S.IsInfinity() {
+		h.Write([]byte{0})
+	} else {
+		h.Write(S.CompressedBytes())
+	}
+	return BeaconOutput(h.Sum(nil)), nil
+}
+
+// BeaconOutput is the 32-byte output of a Beacon.
+// 信标输出：Beacon输出的32字节随机值。
+type BeaconOutput []byte
+
+// Index derives from o an index in [0,n) for the purpose label, such as the
+// coordinator of a session. Distinct labels give independent indices; the bias
+// from reducing 128 bits modulo n is negligible.
+// 由信标输出o为用途label派生[0,n)中的下标，如会话的协调者。不同标签的下标相互独立；
+// 128比特取模n带来的偏差可忽略。
+//
+// 参数：
+//		用途标签	label
+//		范围		n
+// 返回：
+// 		下标
+func (o BeaconOutput) Index(label string, n int) (int, error) {
+	if n < 1 {
+		return 0, opError("BeaconOutput.Index", elgamal.ErrOutOfRange)
+	}
+	b, err := kdf.Expand(o, []byte(beaconIndexInfo+"|"+label), 16)
+	if err != nil {
+		return 0, opError("BeaconOutput.Index", err)
+	}
+	v := new(big.Int).SetBytes(b)
+	return int(v.Mod(v, big.NewInt(int64(n))).Int64()), nil
+}
+
+// Sample derives from o k distinct indices in [0,n) for the purpose label, such as
+// the records picked for an audit, by a partial Fisher-Yates shuffle.
+// 由信标输出o为用途label派生[0,n)中k个互不相同的下标，如审计抽取的记录，采用部分Fisher-Yates洗牌。
+//
+// 参数：
+//		用途标签	label
+//		范围		n
+//		个数		k
+// 返回：
+// 		下标slice
+func (o BeaconOutput) Sample(label string, n, k int) ([]int, error) {
+	if k < 0 || n < k {
+		return nil, opError("BeaconOutput.Sample", elgamal.ErrOutOfRange)
+	}
+	perm := make(map[int]int, 2*k)
+	at := func(i int) int {
+		if v, ok := perm[i]; ok {
+			return v
+		}
+		return i
+	}
+	out := make([]int, k)
+	for i := 0; i < k; i++ {
+		j, err := o.Index(label+"|sample|"+strconv.Itoa(i), n-i)
+		if err != nil {
+			return nil, opError("BeaconOutput.Sample", err)
+		}
+		j += i
+		out[i] = at(j)
+		perm[j] = at(i)
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestBeacon(t *testing.T) {
+	n := 4
+	session := []byte("beacon-1")
+	privs := make([]*sm2.PrivateKey, n)
+	pubs := make([]*sm2.PublicKey, n)
+	for i := range privs {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i], pubs[i] = priv, &priv.PublicKey
+	}
+
+	run := func() BeaconOutput {
+		b, err := NewBeacon(session, pubs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contribs := make([]*BeaconContribution, n)
+		for i, priv := range privs {
+			c, cm, err := NewBeaconContribution(priv, session)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contribs[i] = c
+			if i > 0 {
+				if err := b.Reveal(c); !errors.Is(err, ErrWrongPhase) {
+					t.Fatalf("got %v, want ErrWrongPhase", err)
+				}
+			}
+			if err := b.Commit(pubs[i], cm); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := b.Output(); !errors.Is(err, ErrWrongPhase) {
+			t.Fatalf("got %v, want ErrWrongPhase", err)
+		}
+
+		// 冒用其他节点的贡献被拒绝
+		forged := *contribs[1]
+		forged.NodePubKey = pubs[0]
+		if err := b.Reveal(&forged); !errors.Is(err, ErrInvalidShare) {
+			t.Fatalf("got %v, want ErrInvalidShare", err)
+		}
+		for _, c := range contribs {
+			if err := b.Reveal(c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out, err := b.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	o1, o2 := run(), run()
+	if len(o1) != 32 || bytes.Equal(o1, o2) {
+		t.Fatal("beacon outputs are not fresh")
+	}
+
+	i, err := o1.Index("coordinator", n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j, _ := o1.Index("coordinator", n); i < 0 || i >= n || i != j {
+		t.Fatal("index is not deterministic or out of range")
+	}
+	s, err := o1.Sample("audit", 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]bool)
+	for _, v := range s {
+		if v < 0 || v >= 10 || seen[v] {
+			t.Fatalf("bad sample %v", s)
+		}
+		seen[v] = true
+	}
+	if _, err := o1.Sample("audit", 3, 4); err == nil {
+		t.Fatal("expected error for k > n")
+	}
+}
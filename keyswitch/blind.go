@@ -0,0 +1,79 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Blind key switching hides the requester from the KS servers. The requester with
+// key u submits the blinded target U' = b*U for a random b, which is uniformly
+// distributed and unlinkable to U; the servers calculate and prove their shares
+// for U' as for any target; ShareReplace yields (R*G, D + R*b*U), and Unblind maps
+// it to (b*R*G, D + R*b*U), a ciphertext under U.
+// 盲化密钥置换向ks server隐藏请求者身份。持有私钥u的请求者提交盲化目标公钥U' = b*U（b为随机数），
+// 它均匀分布且与U不可关联；服务器如对待任意目标公钥一样为U'计算份额并生成证明；
+// ShareReplace得到(R*G, D + R*b*U)，Unblind将其变换为(b*R*G, D + R*b*U)，即U下的密文。
+
+// BlindTarget blinds the target key targetPubKey, returning the blinded key to send
+// to the servers and the blinding factor to keep for Unblind.
+// 目标公钥盲化：盲化目标公钥targetPubKey，返回发送给服务器的盲化公钥，以及供Unblind使用、需自行保存的盲化因子。
+//
+// 参数：
+//		目标公钥	targetPubKey
+// 返回：
+// 		盲化公钥
+//		盲化因子
+func BlindTarget(targetPubKey *sm2.PublicKey) (*sm2.PublicKey, *big.Int, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return nil, nil, opError("BlindTarget", err)
+	}
+	curve := targetPubKey.Curve
+	b, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, opError("BlindTarget", err)
+	}
+	blinded := &sm2.PublicKey{Curve: curve}
+	blinded.X, blinded.Y = curve.ScalarMult(targetPubKey.X, targetPubKey.Y, b.Bytes())
+	return blinded, b, nil
+}
+
+// Unblind turns ct, switched to the blinded key, into a ciphertext under the
+// original target key, using the blinding factor of BlindTarget.
+// 去盲：使用BlindTarget返回的盲化因子blind，将置换到盲化公钥的密文ct变换为原目标公钥下的密文。
+//
+// 参数：
+//		置换后的密文	ct
+//		盲化因子		blind
+// 返回：
+// 		原目标公钥下的密文
+func Unblind(ct *elgamal.CipherText, blind *big.Int) (*elgamal.CipherText, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("Unblind", err)
+	}
+	if blind == nil || blind.Sign() <= 0 || blind.Cmp(ct.K.Curve.Params().N) >= 0 {
+		return nil, opError("Unblind", elgamal.ErrOutOfRange)
+	}
+	out := *ct
+	out.K.X, out.K.Y = ct.K.Curve.ScalarMult(ct.K.X, ct.K.Y, blind.Bytes())
+	return &out, nil
+}
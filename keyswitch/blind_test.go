@@ -0,0 +1,98 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestBlindKeySwitch(t *testing.T) {
+	nodes := make([]*sm2.PrivateKey, 3)
+	pubs := make([]*sm2.PublicKey, len(nodes))
+	for i := range nodes {
+		var err error
+		if nodes[i], err = sm2.GenerateKey(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		pubs[i] = &nodes[i].PublicKey
+	}
+	committee, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requester, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(committee, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blinded, b, err := BlindTarget(&requester.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blinded.X.Cmp(requester.X) == 0 {
+		t.Fatal("blinded key equals the target key")
+	}
+
+	shares := make(elgamal.CipherVector, len(nodes))
+	for i, node := range nodes {
+		bundle, err := GenShareBundle(blinded, &ct.K, node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := bundle.Verify(); err != nil || !ok {
+			t.Fatalf("bundle %d failed to verify: %v", i, err)
+		}
+		shares[i] = bundle.Share
+	}
+	switched, err := ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 去盲前请求者无法解密
+	if pt, err := elgamal.PointDecrypt(switched, requester); err == nil && 0 == D.X.Cmp(pt.X) {
+		t.Fatal("blinded ciphertext decrypted without unblinding")
+	}
+	unblinded, err := Unblind(switched, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := elgamal.PointDecrypt(unblinded, requester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(pt.X) || 0 != D.Y.Cmp(pt.Y) {
+		t.Fatal("unblinded ciphertext decrypted to a different point")
+	}
+
+	if _, err := Unblind(switched, big.NewInt(0)); err == nil {
+		t.Fatal("expected error for a zero blinding factor")
+	}
+	if _, _, err := BlindTarget(nil); err == nil {
+		t.Fatal("expected error for a nil target key")
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// ShareBundle is what a KS server sends back for one request: its share, the proof
+// of the share and the public statement (node key, target key, rB) the proof is about.
+// 份额包：ks server针对一次请求返回的内容，包括份额、份额计算证明，以及证明所针对的
+// 公开信息（节点公钥、目标公钥、密文左侧点rB）。
+type ShareBundle struct {
+	Share        elgamal.CipherText
+	Proof        Pai
+	NodePubKey   *sm2.PublicKey
+	TargetPubKey *sm2.PublicKey
+	RB           *elgamal.CurvePoint
+	// Commitment is the commitment of Proof, which lets BatchVerifyShares check
+	// many bundles at once. It is optional: bundles without it, such as those
+	// decoded from the 97-byte proof encoding, are verified one by one.
+	// 证明的承诺值，供BatchVerifyShares批量验证。可为空：没有承诺值的份额包
+	// （如由97字节证明编码解码得到的）逐个验证。
+	Commitment *proof.Commitment
+	// Policy is the policy bound into Proof, if any; see GenShareBundleWithPolicy.
+	// A verifier authorizing by policy should call VerifyPolicy with the policy it
+	// expects rather than trust this field.
+	// 证明所绑定的策略，可为空，见GenShareBundleWithPolicy。按策略授权的验证方应以其预期的策略
+	// 调用VerifyPolicy，而非信任该字段。
+	Policy *Policy
+	// AAD is the associated data bound into Proof, if any; see GenShareBundleWithAAD.
+	// It is not serialized: a verifier sets it, or calls VerifyAAD, with the
+	// associated data it expects.
+	// 证明所绑定的关联数据，可为空，见GenShareBundleWithAAD。该字段不参与序列化，验证方应以其预期的
+	// 关联数据设置该字段或调用VerifyAAD。
+	AAD []byte
+	// Context is the context of the proof suite Proof was made with, if any; see
+	// GenShareBundleWithSuite. Like AAD it is not serialized: a verifier sets it
+	// to the context it expects.
+	// 生成证明所用参数组的上下文，可为空，见GenShareBundleWithSuite。与AAD相同，该字段不参与序列化，
+	// 验证方应以其预期的上下文设置该字段。
+	Context string
+}
+
+// GenShareBundle calculates the share related with rB for targetPubKey with priv,
+// proves it and returns both as a bundle.
+// 生成份额包：使用私钥priv为目标公钥targetPubKey计算关于点rB的份额，生成计算证明，
+// 打包后返回。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额包
+func GenShareBundle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	return GenShareBundleWithSuite(proof.DefaultSuite, targetPubKey, rB, priv)
+}
+
+// GenShareBundleWithSuite is GenShareBundle proving the share with the challenge
+// hash of suite, which the bundle records for verification and serialization,
+// and under the context of suite, which the bundle records for verification.
+// 生成份额包：同GenShareBundle，但以参数组suite的挑战哈希生成证明，份额包记录该哈希以供验证与序列化；
+// 证明在suite的上下文下生成，份额包记录该上下文以供验证。
+//
+// 参数：
+//		参数组		suite
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额包
+func GenShareBundleWithSuite(suite proof.Suite, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	b, err := genShareBundle(suite, targetPubKey, rB, priv)
+	if err != nil {
+		return nil, err
+	}
+	b.Context = suite.Context
+	return b, nil
+}
+
+// genShareBundle is GenShareBundleWithSuite without recording the context of
+// suite, for the callers that record what the context binds, such as a policy.
+// 即GenShareBundleWithSuite，但不记录suite的上下文，供另行记录上下文所绑定内容（如策略）的调用者使用。
+func genShareBundle(suite proof.Suite, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	if suite.Hash == 0 {
+		suite.Hash = proof.DefaultSuite.Hash
+	}
+	share, ri, err := ShareCalWithSuite(suite, targetPubKey, rB, priv)
+	if err != nil {
+		return nil, err
+	}
+	return proveShareBundle(suite, share, ri, targetPubKey, rB, priv)
+}
+
+// proveShareBundle proves share, calculated with ri, under suite and returns both
+// as a bundle.
+// 以参数组suite为使用随机数ri计算的份额share生成证明，打包后返回。
+func proveShareBundle(suite proof.Suite, share *elgamal.CipherText, ri *big.Int, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	c, r1, r2, T, err := shareProofGenNoB(suite, ri, priv, share, targetPubKey, rB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareBundle{
+		Share:        *share,
+		Proof:        NewPaiWithHash(c, r1, r2, suite.Hash),
+		NodePubKey:   &priv.PublicKey,
+		TargetPubKey: targetPubKey,
+		RB:           rB,
+		Commitment:   T,
+	}, nil
+}
+
+// Verify checks the proof carried by b against its share and statement, using the
+// challenge hash recorded in the proof.
+// 验证份额包：以证明中记录的挑战哈希，验证b中的证明是否能够证明其中的份额。
+//
+// 参数：
+//
+// 返回：
+// 		验证结果：	bool
+func (b *ShareBundle) Verify() (bool, error) {
+	if b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return false, opError("ShareBundle.Verify", ErrIncompleteStatement)
+	}
+	c, r1, r2 := b.Proof.Values()
+	if c == nil || r1 == nil || r2 == nil {
+		return false, nil
+	}
+	return shareProofVryNoB(b.suite(), c, r1, r2, &b.Share, b.NodePubKey, b.TargetPubKey, b.RB)
+}
+
+// suite returns the proof suite of b: that of its proof under its context, bound
+// to its policy and associated data if any.
+// 返回份额包b的参数组：即其证明在其上下文下的参数组，有策略与关联数据时绑定二者。
+func (b *ShareBundle) suite() proof.Suite {
+	s := b.Proof.suite()
+	s.Context = b.Context
+	if b.Policy != nil {
+		s = b.Policy.suite(s)
+	}
+	if len(b.AAD) != 0 {
+		s = aadSuite(s, b.AAD)
+	}
+	return s
+}
+
+// Fingerprint identifies b by its share and statement (node key, target key, rB),
+// so a coordinator can drop duplicate bundles. The proof is not included.
+// 返回份额包b的指纹，由份额及其公开信息（节点公钥、目标公钥、rB）得到，不含证明，
+// 协调者可据此去除重复的份额包。
+func (b *ShareBundle) Fingerprint() elgamal.Fingerprint {
+	h := sm3.New()
+	h.Write([]byte("ppks-fp-bundle"))
+	for _, f := range []elgamal.Fingerprint{
+		b.Share.Fingerprint(),
+		elgamal.KeyFingerprint(b.NodePubKey),
+		elgamal.KeyFingerprint(b.TargetPubKey),
+		b.RB.Fingerprint(),
+	} {
+		h.Write(f[:])
+	}
+
+	var f elgamal.Fingerprint
+	copy(f[:], h.Sum(nil))
+	return f
+}
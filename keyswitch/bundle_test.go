@@ -0,0 +1,187 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareBundleVerify(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	b, err := GenShareBundle(&q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := b.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("honest bundle failed to verify")
+	}
+
+	// 换用其他密文左侧点后验证失败
+	b.RB = elgamal.GenPoint()
+	ok, err = b.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("bundle verified against a different rB")
+	}
+
+	b.RB = nil
+	if _, err := b.Verify(); err == nil {
+		t.Fatal("expected error on incomplete statement")
+	}
+}
+
+func TestShareBundleContext(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	// 份额包记录生成证明的上下文，单个与批量验证均通过
+	suite := proof.Suite{Context: "tenant-a"}
+	b1, err := GenShareBundleWithSuite(suite, &q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := ShareCalWith(NewKeySwitcher(priv, suite), &q.PublicKey, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []*ShareBundle{b1, b2} {
+		if b.Context != "tenant-a" {
+			t.Fatalf("bundle records context %q", b.Context)
+		}
+		if ok, err := b.Verify(); err != nil || !ok {
+			t.Fatalf("honest bundle with a context failed to verify: %v", err)
+		}
+	}
+	failed, err := BatchVerifyShares([]*ShareBundle{b1, b2})
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("batch verification flagged %v: %v", failed, err)
+	}
+
+	// 其他上下文下验证失败
+	b1.Context = "tenant-b"
+	if ok, err := b1.Verify(); err != nil || ok {
+		t.Fatal("bundle verified under another context")
+	}
+}
+
+func TestShareBundleFingerprint(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	b1, err := GenShareBundle(&q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := GenShareBundle(&q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复提交的份额包指纹相同，重新计算的份额指纹不同
+	dup := *b1
+	seen := map[elgamal.Fingerprint]bool{b1.Fingerprint(): true}
+	if !seen[dup.Fingerprint()] {
+		t.Fatal("duplicate bundle has a different fingerprint")
+	}
+	if seen[b2.Fingerprint()] {
+		t.Fatal("distinct shares have the same fingerprint")
+	}
+}
+
+// benchKeys returns a node key, a target key and a ciphertext left point.
+func benchKeys(b *testing.B) (*sm2.PrivateKey, *sm2.PublicKey, *elgamal.CurvePoint) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return priv, &q.PublicKey, elgamal.GenPoint()
+}
+
+func BenchmarkShareCal(b *testing.B) {
+	priv, target, rB := benchKeys(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := ShareCal(target, rB, priv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenShareBundle(b *testing.B) {
+	priv, target, rB := benchKeys(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := GenShareBundle(target, rB, priv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkShareBundleVerify(b *testing.B) {
+	priv, target, rB := benchKeys(b)
+	bundle, err := GenShareBundle(target, rB, priv)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := bundle.Verify(); err != nil || !ok {
+			b.Fatal("honest bundle failed to verify")
+		}
+	}
+}
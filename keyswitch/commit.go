@@ -0,0 +1,250 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"sync"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// shareCommitDomain separates share commitments from other uses of SM3.
+// 份额承诺的域分隔标签。
+const shareCommitDomain = "ppks-share-commit-v1"
+
+// shareCommitSaltLen is the length of the random salt of a share commitment.
+// 份额承诺随机盐值的字节长度。
+const shareCommitSaltLen = 32
+
+// ShareCommitment is an SM3 commitment to a share bundle within a session.
+// 份额承诺：会话中对份额包的SM3承诺。
+type ShareCommitment [32]byte
+
+// shareCommitment returns SM3(domain, session, salt, b.SignedBytes()).
+// 计算SM3(域分隔标签, 会话, 盐值, b.SignedBytes())。
+func shareCommitment(b *ShareBundle, session, salt []byte) (ShareCommitment, error) {
+	var c ShareCommitment
+	msg, err := b.SignedBytes()
+	if err != nil {
+		return c, err
+	}
+	var n [4]byte
+	h := sm3.New()
+	h.Write([]byte(shareCommitDomain))
+	binary.BigEndian.PutUint32(n[:], uint32(len(session)))
+	h.Write(n[:])
+	h.Write(session)
+	h.Write(salt)
+	h.Write(msg)
+	copy(c[:], h.Sum(nil))
+	return c, nil
+}
+
+// CommitShare commits to b for session, returning the commitment to send in the
+// commit phase and the salt to send, with b, in the reveal phase.
+// 份额承诺：在会话session中对份额包b作承诺，返回承诺阶段发送的承诺值，以及揭示阶段与b一同发送的盐值。
+//
+// 参数：
+//		份额包	b
+//		会话	session
+// 返回：
+// 		承诺值
+//		盐值
+func CommitShare(b *ShareBundle, session []byte) (ShareCommitment, []byte, error) {
+	if b == nil || b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return ShareCommitment{}, nil, opError("CommitShare", ErrIncompleteStatement)
+	}
+	salt := make([]byte, shareCommitSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return ShareCommitment{}, nil, opError("CommitShare", err)
+	}
+	c, err := shareCommitment(b, session, salt)
+	if err != nil {
+		return ShareCommitment{}, nil, opError("CommitShare", err)
+	}
+	return c, salt, nil
+}
+
+// CommitReveal runs the two-phase submission of shares for one session: every node
+// first commits to its bundle, and bundles are accepted only once all commitments
+// are in, so no node can choose its share after seeing the others'. A node that
+// withholds its reveal can still stall the session, but not bias its result.
+// A CommitReveal is safe for concurrent use.
+// 承诺-揭示：一次会话中份额的两阶段提交。各节点先提交对其份额包的承诺，全部承诺到齐后才接受份额包，
+// 任何节点都无法在看到其他节点的份额后再选择自己的份额。拒绝揭示的节点仍可使会话停滞，但无法使结果产生偏差。
+// CommitReveal可并发使用。
+type CommitReveal struct {
+	session []byte
+	index   map[elgamal.Fingerprint]int
+
+	mu       sync.Mutex
+	commits  []*ShareCommitment
+	pending  int
+	bundles  []*ShareBundle
+	revealed int
+}
+
+// NewCommitReveal starts the commit phase of session for the nodes nodePubKeys.
+// 创建承诺-揭示流程：为节点nodePubKeys开始会话session的承诺阶段。
+//
+// 参数：
+//		会话			session
+//		节点公钥slice	nodePubKeys
+// 返回：
+// 		承诺-揭示流程
+func NewCommitReveal(session []byte, nodePubKeys []*sm2.PublicKey) (*CommitReveal, error) {
+	if len(nodePubKeys) == 0 {
+		return nil, opError("NewCommitReveal", elgamal.ErrEmpty)
+	}
+	cr := &CommitReveal{
+		session: append([]byte(nil), session...),
+		index:   make(map[elgamal.Fingerprint]int, len(nodePubKeys)),
+		commits: make([]*ShareCommitment, len(nodePubKeys)),
+		pending: len(nodePubKeys),
+		bundles: make([]*ShareBundle, len(nodePubKeys)),
+	}
+	for i, pub := range nodePubKeys {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return nil, itemError("NewCommitReveal", "node", i, err)
+		}
+		f := elgamal.KeyFingerprint(pub)
+		if _, ok := cr.index[f]; ok {
+			return nil, itemError("NewCommitReveal", "node", i, ErrUnknownNode)
+		}
+		cr.index[f] = i
+	}
+	return cr, nil
+}
+
+// node returns the index of nodePubKey.
+// 返回节点公钥nodePubKey的下标。
+func (cr *CommitReveal) node(op string, nodePubKey *sm2.PublicKey) (int, error) {
+	if nodePubKey == nil {
+		return 0, opError(op, ErrUnknownNode)
+	}
+	i, ok := cr.index[elgamal.KeyFingerprint(nodePubKey)]
+	if !ok {
+		return 0, opError(op, ErrUnknownNode)
+	}
+	return i, nil
+}
+
+// Commit records the commitment c of the node nodePubKey. Each node commits once,
+// and only during the commit phase.
+// 提交承诺：记录节点nodePubKey的承诺c。每个节点只能提交一次，且只能在承诺阶段提交。
+//
+// 参数：
+//		节点公钥	nodePubKey
+//		承诺值		c
+// 返回：
+//
+func (cr *CommitReveal) Commit(nodePubKey *sm2.PublicKey, c ShareCommitment) error {
+	i, err := cr.node("CommitReveal.Commit", nodePubKey)
+	if err != nil {
+		return err
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if cr.pending == 0 || cr.commits[i] != nil {
+		return opError("CommitReveal.Commit", ErrWrongPhase)
+	}
+	cr.commits[i] = &c
+	cr.pending--
+	return nil
+}
+
+// Revealing reports whether every node has committed, so bundles may be revealed.
+// 判断是否所有节点均已提交承诺，即是否已进入揭示阶段。
+func (cr *CommitReveal) Revealing() bool {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.pending == 0
+}
+
+// Reveal accepts the bundle b of its node with the salt from CommitShare, once all
+// commitments are in. It fails with ErrInvalidShare unless b opens the node's
+// commitment, and with ErrProofFailed unless the proof of b verifies.
+// 揭示份额：全部承诺到齐后，以CommitShare返回的盐值接受节点的份额包b。b与该节点的承诺不符时
+// 返回ErrInvalidShare，b中的证明未通过验证时返回ErrProofFailed。
+//
+// 参数：
+//		份额包	b
+//		盐值	salt
+// 返回：
+//
+func (cr *CommitReveal) Reveal(b *ShareBundle, salt []byte) error {
+	if b == nil {
+		return opError("CommitReveal.Reveal", ErrIncompleteStatement)
+	}
+	i, err := cr.node("CommitReveal.Reveal", b.NodePubKey)
+	if err != nil {
+		return err
+	}
+	cr.mu.Lock()
+	if cr.pending != 0 || cr.bundles[i] != nil {
+		cr.mu.Unlock()
+		return opError("CommitReveal.Reveal", ErrWrongPhase)
+	}
+	want := *cr.commits[i]
+	cr.mu.Unlock()
+
+	c, err := shareCommitment(b, cr.session, salt)
+	if err != nil {
+		return opError("CommitReveal.Reveal", err)
+	}
+	if subtle.ConstantTimeCompare(c[:], want[:]) != 1 {
+		return opError("CommitReveal.Reveal", ErrInvalidShare)
+	}
+	ok, err := b.Verify()
+	if err != nil {
+		return opError("CommitReveal.Reveal", err)
+	}
+	if !ok {
+		return opError("CommitReveal.Reveal", ErrProofFailed)
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if cr.bundles[i] != nil {
+		return opError("CommitReveal.Reveal", ErrWrongPhase)
+	}
+	cr.bundles[i] = b
+	cr.revealed++
+	return nil
+}
+
+// Bundles returns the revealed bundles in the order of the nodes, once every node
+// has revealed.
+// 所有节点均已揭示后，按节点顺序返回揭示的份额包。
+//
+// 参数：
+//
+// 返回：
+// 		份额包slice
+func (cr *CommitReveal) Bundles() ([]*ShareBundle, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if cr.revealed != len(cr.bundles) {
+		return nil, opError("CommitReveal.Bundles", ErrWrongPhase)
+	}
+	return append([]*ShareBundle(nil), cr.bundles...), nil
+}
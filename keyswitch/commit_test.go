@@ -0,0 +1,111 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCommitReveal(t *testing.T) {
+	n := 3
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+	session := []byte("session-1")
+
+	pubs := make([]*sm2.PublicKey, n)
+	bundles := make([]*ShareBundle, n)
+	commits := make([]ShareCommitment, n)
+	salts := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubs[i] = &priv.PublicKey
+		if bundles[i], err = GenShareBundle(&q.PublicKey, rB, priv); err != nil {
+			t.Fatal(err)
+		}
+		if commits[i], salts[i], err = CommitShare(bundles[i], session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cr, err := NewCommitReveal(session, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n-1; i++ {
+		if err := cr.Commit(pubs[i], commits[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 承诺未到齐时不可揭示，重复承诺被拒绝
+	if err := cr.Reveal(bundles[0], salts[0]); !errors.Is(err, ErrWrongPhase) {
+		t.Fatalf("got %v, want ErrWrongPhase", err)
+	}
+	if err := cr.Commit(pubs[0], commits[0]); !errors.Is(err, ErrWrongPhase) {
+		t.Fatalf("got %v, want ErrWrongPhase", err)
+	}
+	if err := cr.Commit(&q.PublicKey, commits[0]); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("got %v, want ErrUnknownNode", err)
+	}
+	if err := cr.Commit(pubs[n-1], commits[n-1]); err != nil {
+		t.Fatal(err)
+	}
+	if !cr.Revealing() {
+		t.Fatal("not revealing after all commitments")
+	}
+
+	// 揭示阶段更换份额被拒绝
+	swapped := *bundles[1]
+	swapped.Share = bundles[0].Share
+	if err := cr.Reveal(&swapped, salts[1]); !errors.Is(err, ErrInvalidShare) {
+		t.Fatalf("got %v, want ErrInvalidShare", err)
+	}
+	if err := cr.Reveal(bundles[1], salts[0]); !errors.Is(err, ErrInvalidShare) {
+		t.Fatalf("got %v, want ErrInvalidShare", err)
+	}
+
+	if _, err := cr.Bundles(); !errors.Is(err, ErrWrongPhase) {
+		t.Fatalf("got %v, want ErrWrongPhase", err)
+	}
+	for i := 0; i < n; i++ {
+		if err := cr.Reveal(bundles[i], salts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := cr.Bundles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		if got[i] != bundles[i] {
+			t.Fatalf("bundle %d out of order", i)
+		}
+	}
+	if err := cr.Commit(pubs[0], commits[0]); !errors.Is(err, ErrWrongPhase) {
+		t.Fatalf("got %v, want ErrWrongPhase", err)
+	}
+}
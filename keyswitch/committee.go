@@ -0,0 +1,186 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"encoding/binary"
+
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// committeeKeyDomain separates key registrations from other signed messages.
+// 成员公钥登记所用的域分隔标签，与其他签名消息区分。
+const committeeKeyDomain = "ppks-committee-key"
+
+// KeyRegistration is a member's registration of PubKey with a committee: Possession
+// is the member's signature with the matching private key over the committee and
+// the key, which rules out rogue keys chosen to cancel the others in the sum.
+// 成员公钥登记：成员向委员会登记公钥PubKey。Possession为成员以对应私钥对委员会标识与公钥的签名，
+// 排除为抵消其他成员公钥而构造的恶意公钥。
+type KeyRegistration struct {
+	PubKey     *sm2.PublicKey
+	Possession *schnorr.Signature
+}
+
+// RegisterKey registers the key of priv with committee.
+// 登记公钥：将私钥priv对应的公钥登记到委员会committee。
+//
+// 参数：
+//		私钥		priv
+//		委员会标识	committee
+// 返回：
+// 		公钥登记
+func RegisterKey(priv *sm2.PrivateKey, committee []byte) (*KeyRegistration, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("RegisterKey", elgamal.ErrEmpty)
+	}
+	sig, err := schnorr.Sign(priv, committeeKeyBytes(committee, &priv.PublicKey))
+	if err != nil {
+		return nil, opError("RegisterKey", err)
+	}
+	return &KeyRegistration{PubKey: &priv.PublicKey, Possession: sig}, nil
+}
+
+// Verify reports whether r is a valid registration with committee.
+// 验证公钥登记：判断r是否为委员会committee的有效登记。
+//
+// 参数：
+//		委员会标识	committee
+// 返回：
+// 		验证结果
+func (r *KeyRegistration) Verify(committee []byte) (bool, error) {
+	if r.PubKey == nil || r.Possession == nil {
+		return false, opError("KeyRegistration.Verify", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(r.PubKey)); err != nil {
+		return false, opError("KeyRegistration.Verify", err)
+	}
+	return schnorr.Verify(r.PubKey, committeeKeyBytes(committee, r.PubKey), r.Possession)
+}
+
+// CommitteeKeyCertificate certifies that PubKey is the collective key of committee:
+// it carries the registration of every member, so a requester can check each
+// possession and recompute the sum before encrypting to PubKey.
+// 委员会公钥证书：证明PubKey为委员会committee的聚合公钥。证书包含每个成员的公钥登记，
+// 请求者在向PubKey加密前可逐一检查各登记，并重新计算公钥之和。
+type CommitteeKeyCertificate struct {
+	Committee []byte
+	Members   []*KeyRegistration
+	PubKey    *sm2.PublicKey
+}
+
+// NewCommitteeKeyCertificate checks the registrations regs with committee and
+// certifies their sum. Registrations must be of distinct keys.
+// 创建委员会公钥证书：检查各成员在委员会committee的公钥登记regs，并为其公钥之和签发证书。各登记的公钥须互不相同。
+//
+// 参数：
+//		委员会标识		committee
+//		公钥登记slice	regs
+// 返回：
+// 		委员会公钥证书
+func NewCommitteeKeyCertificate(committee []byte, regs []*KeyRegistration) (*CommitteeKeyCertificate, error) {
+	pubs, err := committeeMembers("NewCommitteeKeyCertificate", committee, regs)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		return nil, err
+	}
+	return &CommitteeKeyCertificate{
+		Committee: append([]byte(nil), committee...),
+		Members:   append([]*KeyRegistration(nil), regs...),
+		PubKey:    pub,
+	}, nil
+}
+
+// Verify reports whether c is sound: every registration is valid, the keys are
+// distinct and PubKey is their sum. A failing registration is reported as
+// ErrProofFailed for its member.
+// 验证委员会公钥证书：判断c是否正确，即各登记均有效、公钥互不相同，且PubKey为其和。
+// 无效的登记返回该成员的ErrProofFailed。
+//
+// 参数：
+//
+// 返回：
+// 		验证结果
+func (c *CommitteeKeyCertificate) Verify() (bool, error) {
+	if c.PubKey == nil {
+		return false, opError("CommitteeKeyCertificate.Verify", ErrIncompleteStatement)
+	}
+	pubs, err := committeeMembers("CommitteeKeyCertificate.Verify", c.Committee, c.Members)
+	if err != nil {
+		return false, err
+	}
+	pub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		return false, err
+	}
+	return sameKey(pub, c.PubKey), nil
+}
+
+// MemberPubKeys returns the registered keys of c in order.
+// 按顺序返回c中登记的成员公钥。
+func (c *CommitteeKeyCertificate) MemberPubKeys() []*sm2.PublicKey {
+	pubs := make([]*sm2.PublicKey, len(c.Members))
+	for i, r := range c.Members {
+		pubs[i] = r.PubKey
+	}
+	return pubs
+}
+
+// committeeMembers checks regs with committee and returns their keys.
+// 检查委员会committee的各公钥登记regs，返回其公钥。
+func committeeMembers(op string, committee []byte, regs []*KeyRegistration) ([]*sm2.PublicKey, error) {
+	if len(regs) == 0 {
+		return nil, opError(op, elgamal.ErrEmpty)
+	}
+	pubs := make([]*sm2.PublicKey, len(regs))
+	for i, r := range regs {
+		if r == nil {
+			return nil, itemError(op, "member", i, ErrIncompleteStatement)
+		}
+		ok, err := r.Verify(committee)
+		if err != nil {
+			return nil, itemError(op, "member", i, err)
+		}
+		if !ok {
+			return nil, itemError(op, "member", i, ErrProofFailed)
+		}
+		for _, prev := range pubs[:i] {
+			if sameKey(prev, r.PubKey) {
+				return nil, itemError(op, "member", i, ErrUnknownNode)
+			}
+		}
+		pubs[i] = r.PubKey
+	}
+	return pubs, nil
+}
+
+// committeeKeyBytes returns the message a registration signs: the domain, the
+// committee prefixed by its 4-byte length and the compressed key.
+// 返回公钥登记所签名的消息：域分隔标签、带4字节长度前缀的委员会标识及压缩公钥。
+func committeeKeyBytes(committee []byte, pub *sm2.PublicKey) []byte {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(committee)))
+	msg := []byte(committeeKeyDomain)
+	msg = append(msg, n[:]...)
+	msg = append(msg, committee...)
+	return append(msg, (*elgamal.CurvePoint)(pub).CompressedBytes()...)
+}
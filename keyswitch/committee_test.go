@@ -0,0 +1,76 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCommitteeKeyCertificate(t *testing.T) {
+	committee := []byte("committee-1")
+	var regs []*KeyRegistration
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := RegisterKey(priv, committee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		regs = append(regs, r)
+	}
+
+	c, err := NewCommitteeKeyCertificate(committee, regs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.Verify(); err != nil || !ok {
+		t.Fatalf("certificate failed to verify: %v", err)
+	}
+	pub, err := AggregatePubKeys(c.MemberPubKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameKey(pub, c.PubKey) {
+		t.Fatal("certified key is not the sum of the members")
+	}
+
+	// 公布的公钥与成员之和不符
+	forged := *c
+	forged.PubKey = regs[0].PubKey
+	if ok, err := forged.Verify(); err != nil || ok {
+		t.Fatal("forged committee key verified")
+	}
+
+	// 登记不可用于其他委员会
+	if _, err := NewCommitteeKeyCertificate([]byte("committee-2"), regs); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+
+	// 无对应私钥签名的恶意公钥
+	rogue := &KeyRegistration{PubKey: regs[1].PubKey, Possession: regs[0].Possession}
+	if _, err := NewCommitteeKeyCertificate(committee, []*KeyRegistration{regs[0], rogue}); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+	if _, err := NewCommitteeKeyCertificate(committee, []*KeyRegistration{regs[0], regs[0]}); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("got %v, want ErrUnknownNode", err)
+	}
+}
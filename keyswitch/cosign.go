@@ -0,0 +1,126 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// coSignDomain separates co-signed switched ciphertexts from other signed messages.
+// 联合签名的置换后密文所用的域分隔标签，与其他签名消息区分。
+const coSignDomain = "ppks-cosigned-switch"
+
+// CoSignedSwitch is the final switched ciphertext Switched of CipherText for
+// TargetPubKey, co-signed by the contributing servers Signers with one Signature.
+// A downstream system that needs accountability rather than verifiability checks
+// this single signature instead of one share proof per server; each server should
+// co-sign only after checking the result, e.g. with SessionTranscript.Verify.
+// 联合签名的置换结果：密文CipherText为目标公钥TargetPubKey置换后的最终密文Switched，由参与置换的
+// ks server Signers以一个签名Signature联合签名。只需可追责而非可验证的下游系统只检查这一个签名，
+// 而非每个ks server一个份额证明；各ks server应在检查结果（如以SessionTranscript.Verify）后再联合签名。
+type CoSignedSwitch struct {
+	CipherText   elgamal.CipherText
+	TargetPubKey *sm2.PublicKey
+	Switched     elgamal.CipherText
+	Signers      []*sm2.PublicKey
+	Signature    *schnorr.Signature
+}
+
+// NewCoSigner starts the co-signature of c by the server key priv, which must be
+// one of c.Signers.
+// 新建联合签名者：以ks server私钥priv（须为c.Signers之一）参与对c的联合签名。
+//
+// 参数：
+//		节点私钥	priv
+// 返回：
+// 		联合签名者
+func (c *CoSignedSwitch) NewCoSigner(priv *sm2.PrivateKey) (*schnorr.CoSigner, error) {
+	msg, err := c.SignedBytes()
+	if err != nil {
+		return nil, opError("CoSignedSwitch.NewCoSigner", err)
+	}
+	s, err := schnorr.NewCoSigner(priv, c.Signers, msg)
+	if err != nil {
+		return nil, opError("CoSignedSwitch.NewCoSigner", err)
+	}
+	return s, nil
+}
+
+// Combine combines the nonces and partial signatures of c.Signers, in order, into
+// c.Signature.
+// 合并联合签名：按顺序将c.Signers的随机数点与部分签名合并为c.Signature。
+//
+// 参数：
+//		随机数点slice	nonces
+//		部分签名slice	partials
+func (c *CoSignedSwitch) Combine(nonces []*elgamal.CurvePoint, partials []*big.Int) error {
+	msg, err := c.SignedBytes()
+	if err != nil {
+		return opError("CoSignedSwitch.Combine", err)
+	}
+	sig, err := schnorr.CombineCoSignatures(c.Signers, msg, nonces, partials)
+	if err != nil {
+		return opError("CoSignedSwitch.Combine", err)
+	}
+	c.Signature = sig
+	return nil
+}
+
+// Verify reports whether c.Signature is the co-signature of c by c.Signers.
+// 验证联合签名：判断c.Signature是否为c.Signers对c的联合签名。
+//
+// 返回：
+// 		验证结果
+func (c *CoSignedSwitch) Verify() (bool, error) {
+	msg, err := c.SignedBytes()
+	if err != nil {
+		return false, opError("CoSignedSwitch.Verify", err)
+	}
+	pub, err := schnorr.AggregateKeys(c.Signers)
+	if err != nil {
+		return false, opError("CoSignedSwitch.Verify", err)
+	}
+	return schnorr.Verify(pub, msg, c.Signature)
+}
+
+// SignedBytes returns the message co-signed for c: the domain, the fingerprints of
+// the ciphertext and the target key, and the compressed points of the result.
+// 返回为c联合签名的消息：域分隔标签、密文与目标公钥的指纹，以及结果的压缩点。
+func (c *CoSignedSwitch) SignedBytes() ([]byte, error) {
+	if c.TargetPubKey == nil {
+		return nil, ErrIncompleteStatement
+	}
+	if err := elgamal.CheckCipherText(&c.CipherText); err != nil {
+		return nil, err
+	}
+	if err := elgamal.CheckCipherText(&c.Switched); err != nil {
+		return nil, err
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(c.TargetPubKey)); err != nil {
+		return nil, err
+	}
+	fc := c.CipherText.Fingerprint()
+	ft := elgamal.KeyFingerprint(c.TargetPubKey)
+	msg := append([]byte(coSignDomain), fc[:]...)
+	msg = append(msg, ft[:]...)
+	msg = append(msg, c.Switched.K.CompressedBytes()...)
+	return append(msg, c.Switched.C.CompressedBytes()...), nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCoSignedSwitch(t *testing.T) {
+	var privs []*sm2.PrivateKey
+	var pubs []*sm2.PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, &priv.PublicKey)
+	}
+	collPub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := elgamal.PointEncrypt(collPub, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shares elgamal.CipherVector
+	for _, priv := range privs {
+		share, _, err := ShareCal(&q.PublicKey, &ct.K, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, *share)
+	}
+	tct, err := ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &CoSignedSwitch{CipherText: *ct, TargetPubKey: &q.PublicKey, Switched: *tct, Signers: pubs}
+	var commitments [][]byte
+	var nonces []*elgamal.CurvePoint
+	var partials []*big.Int
+	signers := make([]*schnorr.CoSigner, len(privs))
+	for i, priv := range privs {
+		s, err := c.NewCoSigner(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers[i] = s
+		commitments = append(commitments, s.Commitment())
+	}
+	for _, s := range signers {
+		nonces = append(nonces, s.Nonce())
+	}
+	for _, s := range signers {
+		p, err := s.Sign(commitments, nonces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials = append(partials, p)
+	}
+	if err := c.Combine(nonces, partials); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.Verify(); err != nil || !ok {
+		t.Fatalf("co-signature failed to verify: %v", err)
+	}
+
+	// 结果或签名者被替换后签名失效
+	forged := *c
+	forged.Switched.C = *elgamal.GenPoint()
+	if ok, _ := forged.Verify(); ok {
+		t.Fatal("co-signature verified for another result")
+	}
+	forged = *c
+	forged.Signers = pubs[:2]
+	if ok, _ := forged.Verify(); ok {
+		t.Fatal("co-signature verified for fewer signers")
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// DecryptWithProof decrypts ct with priv like elgamal.PointDecrypt and proves the
+// decryption: (c,r) is a DLEQ proof that C-M = dK for the d with dB = priv.PublicKey,
+// so auditors can check the plaintext point M without the key.
+// 可验证解密：与elgamal.PointDecrypt相同地使用私钥priv解密密文ct，并证明解密正确：
+// (c,r)为C-M = dK且dB = priv.PublicKey的离散对数相等证明，审计者无需私钥即可检验明文点M。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+// 返回：
+// 		明文点M
+//		证明：	c,r
+func DecryptWithProof(priv *sm2.PrivateKey, ct *elgamal.CipherText) (*elgamal.CurvePoint, *big.Int, *big.Int, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, nil, nil, opError("DecryptWithProof", err)
+	}
+	M, err := elgamal.PointDecrypt(ct, priv)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// S = dK = C-M
+	curve := priv.Curve
+	var S elgamal.CurvePoint
+	S.Curve = curve
+	S.X, S.Y = curve.ScalarMult(ct.K.X, ct.K.Y, priv.D.Bytes())
+
+	c, r, err := proof.DLEQGen(priv.D, elgamal.Generator(curve), (*elgamal.CurvePoint)(&priv.PublicKey), &ct.K, &S)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return M, c, r, nil
+}
+
+// VerifyDecryption verifies the proof (c,r) of DecryptWithProof that M is the
+// decryption of ct under the private key of pub.
+// 解密验证：验证DecryptWithProof生成的证明(c,r)，即M为公钥pub对应私钥对密文ct的解密结果。
+//
+// 参数：
+//		证明：	c,r
+//		明文点	M
+//		密文	ct
+//		公钥	pub
+// 返回：
+// 		验证结果
+func VerifyDecryption(c, r *big.Int, M *elgamal.CurvePoint, ct *elgamal.CipherText, pub *sm2.PublicKey) (bool, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return false, opError("VerifyDecryption", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return false, opError("VerifyDecryption", err)
+	}
+	negM, err := elgamal.NegPoint(M)
+	if err != nil {
+		return false, opError("VerifyDecryption", err)
+	}
+
+	// S = C-M；S为无穷远点时M = C，不可能为正确的解密结果
+	curve := ct.C.Curve
+	var S elgamal.CurvePoint
+	S.Curve = curve
+	S.X, S.Y = ec.Add(curve, ct.C.X, ct.C.Y, negM.X, negM.Y)
+	if S.IsInfinity() {
+		return false, nil
+	}
+
+	return proof.DLEQVerify(c, r, elgamal.Generator(curve), (*elgamal.CurvePoint)(pub), &ct.K, &S)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestDecryptWithProof(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(&priv.PublicKey, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	D, c, r, err := DecryptWithProof(priv, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(M.X) || 0 != D.Y.Cmp(M.Y) {
+		t.Fatal("wrong plaintext")
+	}
+	if ok, err := VerifyDecryption(c, r, D, ct, &priv.PublicKey); err != nil || !ok {
+		t.Fatalf("valid decryption rejected: %v", err)
+	}
+
+	// 错误的明文点、公钥或证明均不能通过
+	if ok, _ := VerifyDecryption(c, r, elgamal.GenPoint(), ct, &priv.PublicKey); ok {
+		t.Fatal("wrong plaintext accepted")
+	}
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifyDecryption(c, r, D, ct, &other.PublicKey); ok {
+		t.Fatal("proof accepted for another key")
+	}
+	if ok, _ := VerifyDecryption(c, new(big.Int).Add(r, big.NewInt(1)), D, ct, &priv.PublicKey); ok {
+		t.Fatal("tampered proof accepted")
+	}
+	if ok, _ := VerifyDecryption(c, r, &ct.C, ct, &priv.PublicKey); ok {
+		t.Fatal("M = C accepted")
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// sealedShareVersion is the first byte of the plaintext of a sealed share.
+// 加密份额包明文的版本号，位于首字节。
+const sealedShareVersion = 1
+
+// Sizes of the plaintext of a sealed share: version, node key, target key, rB, the
+// share, the proof, the signature and the policy length; the policy follows.
+// 加密份额包明文各部分长度：版本、节点公钥、目标公钥、rB、份额、证明、签名及策略长度，其后为策略编码。
+const (
+	sealedPointLen = 33
+	sealedShareLen = 1 + 5*sealedPointLen + paiLen + schnorr.SignatureSize + 4
+)
+
+// sm2CipherOverhead is the length SM2 encryption adds: 0x04||C1||C3.
+// SM2加密增加的长度：0x04||C1||C3。
+const sm2CipherOverhead = 1 + 64 + 32
+
+// SealShare signs b with the node key priv and encrypts the bundle and signature to
+// b.TargetPubKey with SM2 public-key encryption, so the share crosses untrusted
+// relays unread: only the requester can open it, and OpenShare rejects any bundle
+// not signed by its node.
+// 份额包加密：以节点私钥priv对b签名，并以SM2公钥加密将份额包与签名加密给b.TargetPubKey，份额包可经不可信的
+// 中继传递而不被读取：仅请求者可以打开，且OpenShare拒绝未经其节点签名的份额包。
+//
+// 参数：
+//		份额包		b
+//		节点私钥	priv
+// 返回：
+// 		加密份额包
+func SealShare(b *ShareBundle, priv *sm2.PrivateKey) ([]byte, error) {
+	sig, err := b.Sign(priv)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := encodeSignedBundle(b, sig)
+	if err != nil {
+		return nil, opError("SealShare", err)
+	}
+	sealed, err := sm2.Encrypt(b.TargetPubKey, msg, rand.Reader)
+	if err != nil {
+		return nil, opError("SealShare", err)
+	}
+	return sealed, nil
+}
+
+// encodeSignedBundle encodes b with its signature sig: version, node key, target
+// key, rB, the share, the proof, the signature, the policy length and the policy.
+// 编码签名份额包：版本、节点公钥、目标公钥、rB、份额、证明、签名、策略长度及策略编码。
+func encodeSignedBundle(b *ShareBundle, sig *schnorr.Signature) ([]byte, error) {
+	if b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return nil, ErrIncompleteStatement
+	}
+	p, err := b.Proof.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	s, err := sig.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if err := elgamal.CheckCipherText(&b.Share); err != nil {
+		return nil, err
+	}
+	for _, P := range []*elgamal.CurvePoint{(*elgamal.CurvePoint)(b.NodePubKey), (*elgamal.CurvePoint)(b.TargetPubKey), b.RB} {
+		if err := elgamal.CheckPoint(P); err != nil {
+			return nil, err
+		}
+	}
+	var policy []byte
+	if b.Policy != nil {
+		policy = b.Policy.Encode()
+	}
+
+	var n [4]byte
+	msg := make([]byte, 0, sealedShareLen+len(policy))
+	msg = append(msg, sealedShareVersion)
+	msg = append(msg, (*elgamal.CurvePoint)(b.NodePubKey).CompressedBytes()...)
+	msg = append(msg, (*elgamal.CurvePoint)(b.TargetPubKey).CompressedBytes()...)
+	msg = append(msg, b.RB.CompressedBytes()...)
+	msg = append(msg, b.Share.K.CompressedBytes()...)
+	msg = append(msg, b.Share.C.CompressedBytes()...)
+	msg = append(msg, p...)
+	msg = append(msg, s...)
+	binary.BigEndian.PutUint32(n[:], uint32(len(policy)))
+	msg = append(msg, n[:]...)
+	msg = append(msg, policy...)
+	return msg, nil
+}
+
+// decodeSignedBundle decodes the output of encodeSignedBundle, reporting false if
+// it is malformed. Neither the signature nor the proof is verified.
+// 解码encodeSignedBundle的输出，格式错误时返回false。签名与证明均未验证。
+func decodeSignedBundle(msg []byte) (*ShareBundle, *schnorr.Signature, bool) {
+	if len(msg) < sealedShareLen || msg[0] != sealedShareVersion {
+		return nil, nil, false
+	}
+	var pts [5]*elgamal.CurvePoint
+	for i := range pts {
+		off := 1 + i*sealedPointLen
+		P, err := elgamal.NewCurvePointFromBytes(nil, msg[off:off+sealedPointLen])
+		if err != nil {
+			return nil, nil, false
+		}
+		pts[i] = P
+	}
+	b := &ShareBundle{
+		Share:        elgamal.CipherText{K: *pts[3], C: *pts[4]},
+		NodePubKey:   (*sm2.PublicKey)(pts[0]),
+		TargetPubKey: (*sm2.PublicKey)(pts[1]),
+		RB:           pts[2],
+	}
+	off := 1 + 5*sealedPointLen
+	if err := b.Proof.UnmarshalBinary(msg[off : off+paiLen]); err != nil {
+		return nil, nil, false
+	}
+	off += paiLen
+	sig := new(schnorr.Signature)
+	if err := sig.UnmarshalBinary(msg[off : off+schnorr.SignatureSize]); err != nil {
+		return nil, nil, false
+	}
+	off += schnorr.SignatureSize
+	if n := binary.BigEndian.Uint32(msg[off:]); uint64(n) != uint64(len(msg)-sealedShareLen) {
+		return nil, nil, false
+	}
+	if len(msg) > sealedShareLen {
+		policy, ok := decodePolicy(msg[sealedShareLen:])
+		if !ok {
+			return nil, nil, false
+		}
+		b.Policy = policy
+	}
+	return b, sig, true
+}
+
+// OpenShare decrypts a bundle sealed by SealShare with the requester's key priv,
+// checks that it is addressed to priv and signed by its node, and verifies its
+// proof. Anything sealed for another key, altered on the way or signed by another
+// key fails with ErrAuthFailed; a bundle whose proof fails, with ErrProofFailed.
+// The caller still checks that b.NodePubKey is a node it expects.
+// 份额包解密：以请求者私钥priv解密SealShare加密的份额包，检查其目标公钥为priv且由其节点签名，并验证其证明。
+// 加密给其他公钥、传输中被篡改或由其他公钥签名时返回ErrAuthFailed；证明未通过时返回ErrProofFailed。
+// 调用者仍须检查b.NodePubKey是否为预期的节点。
+//
+// 参数：
+//		请求者私钥	priv
+//		加密份额包	sealed
+// 返回：
+// 		份额包
+func OpenShare(priv *sm2.PrivateKey, sealed []byte) (*ShareBundle, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("OpenShare", ErrUnsupportedKey)
+	}
+	// 解密前检查C1在曲线上，防止无效曲线攻击
+	if len(sealed) < sm2CipherOverhead+sealedShareLen || sealed[0] != 4 ||
+		!priv.Curve.IsOnCurve(new(big.Int).SetBytes(sealed[1:33]), new(big.Int).SetBytes(sealed[33:65])) {
+		return nil, opError("OpenShare", ErrAuthFailed)
+	}
+	msg, err := sm2.Decrypt(priv, sealed)
+	if err != nil {
+		return nil, opError("OpenShare", ErrAuthFailed)
+	}
+	b, sig, ok := decodeSignedBundle(msg)
+	if !ok || !sameKey(b.TargetPubKey, &priv.PublicKey) {
+		return nil, opError("OpenShare", ErrAuthFailed)
+	}
+	if ok, err := b.VerifySignature(sig); err != nil || !ok {
+		return nil, opError("OpenShare", ErrAuthFailed)
+	}
+	ok, err = b.Verify()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, opError("OpenShare", ErrProofFailed)
+	}
+	return b, nil
+}
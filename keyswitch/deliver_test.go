@@ -0,0 +1,73 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSealOpenShare(t *testing.T) {
+	k, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+	policy := &Policy{Purpose: "review"}
+	b, err := GenShareBundleWithPolicy(policy, &q.PublicKey, rB, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := SealShare(b, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := OpenShare(q, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Fingerprint() != b.Fingerprint() || !samePolicy(got.Policy, policy) {
+		t.Fatal("opened bundle differs")
+	}
+
+	// 非请求者无法打开，篡改的密文被拒绝
+	if _, err := OpenShare(k, sealed); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := OpenShare(q, tampered); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	if _, err := OpenShare(q, sealed[:10]); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+
+	// 只能由份额包的节点私钥签名加密
+	if _, err := SealShare(b, q); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("got %v, want ErrUnsupportedKey", err)
+	}
+}
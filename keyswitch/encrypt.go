@@ -0,0 +1,113 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// encryptionContext binds plaintext knowledge proofs to their protocol.
+// 明文知识证明的上下文。
+const encryptionContext = "ppks-encryption-pok"
+
+// encryptionStatement returns the relation {K=r*B} and the context binding the
+// recipient key and the right point C, so the proof cannot be moved to another
+// ciphertext sharing K.
+// 返回关系{K=r*B}，以及绑定接收者公钥与右侧点C的上下文，使证明无法挪用到K相同的其他密文。
+func encryptionStatement(pub *sm2.PublicKey, ct *elgamal.CipherText) (*proof.Relation, string) {
+	context := encryptionContext +
+		string((*elgamal.CurvePoint)(pub).CompressedBytes()) +
+		string(ct.C.CompressedBytes())
+	return proof.DLogRelation(elgamal.Generator(ct.K.Curve), &ct.K), context
+}
+
+// EncryptWithProof encrypts D with pub like elgamal.PointEncrypt and proves
+// knowledge of the randomness r with K=rB, hence of the plaintext D=C-rP. A switch
+// service accepting only proven ciphertexts cannot be fed ciphertexts derived from
+// someone else's, such as a re-randomized or homomorphically shifted copy, since
+// their submitter does not know the randomness.
+// 带证明的点加密：与elgamal.PointEncrypt相同地使用公钥pub加密点D，并证明知道满足K=rB的随机数r，
+// 从而知道明文D=C-rP。只接受带证明密文的置换服务无法被提交由他人密文派生的密文（如重新随机化或
+// 同态平移的副本），因为提交者不知道其随机数。
+//
+// 参数：
+//		公钥		pub
+//		待加密点	D
+// 返回：
+// 		密文		ct{K,C}
+//		证明
+func EncryptWithProof(pub *sm2.PublicKey, D *elgamal.CurvePoint) (*elgamal.CipherText, *proof.LinearProof, error) {
+	return encryptWithProof("EncryptWithProof", pub, D, "")
+}
+
+// encryptWithProof is EncryptWithProof with bound appended to the proof context.
+// 带证明的点加密：同EncryptWithProof，但将bound追加到证明的上下文。
+func encryptWithProof(op string, pub *sm2.PublicKey, D *elgamal.CurvePoint, bound string) (*elgamal.CipherText, *proof.LinearProof, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, nil, opError(op, err)
+	}
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, nil, opError(op, err)
+	}
+
+	curve := pub.Curve
+	r, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, opError(op, err)
+	}
+
+	// K=rB, C=D+rP
+	var ct elgamal.CipherText
+	ct.K.Curve, ct.C.Curve = curve, curve
+	ct.K.X, ct.K.Y = curve.ScalarBaseMult(r.Bytes())
+	rPx, rPy := elgamal.ScalarMult((*elgamal.CurvePoint)(pub), r.Bytes())
+	ct.C.X, ct.C.Y = ec.Add(curve, rPx, rPy, D.X, D.Y)
+
+	rel, context := encryptionStatement(pub, &ct)
+	p, err := proof.ProveLinear(context+bound, rel, []*big.Int{r})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ct, p, nil
+}
+
+// VerifyEncryption verifies the proof p of EncryptWithProof that the creator of
+// ct, encrypted to pub, knows its randomness and plaintext.
+// 加密证明验证：验证EncryptWithProof生成的证明p，即公钥pub下密文ct的生成者知道其随机数与明文。
+//
+// 参数：
+//		公钥	pub
+//		密文	ct
+//		证明	p
+// 返回：
+// 		验证结果
+func VerifyEncryption(pub *sm2.PublicKey, ct *elgamal.CipherText, p *proof.LinearProof) (bool, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return false, opError("VerifyEncryption", err)
+	}
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return false, opError("VerifyEncryption", err)
+	}
+	rel, context := encryptionStatement(pub, ct)
+	return proof.VerifyLinear(context, rel, p)
+}
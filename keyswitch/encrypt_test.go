@@ -0,0 +1,77 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEncryptWithProof(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	D := elgamal.GenPoint()
+
+	ct, p, err := EncryptWithProof(pub, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyEncryption(pub, ct, p); err != nil || !ok {
+		t.Fatal("honest encryption failed to verify")
+	}
+	M, err := elgamal.PointDecrypt(ct, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != M.X.Cmp(D.X) || 0 != M.Y.Cmp(D.Y) {
+		t.Fatal("decrypted point differs")
+	}
+
+	// 重新随机化或同态平移后的密文不能沿用原证明
+	re, err := elgamal.Rerandomize(pub, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delta, err := elgamal.PointEncrypt(pub, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	shifted, err := elgamal.CipherAdd(ct, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []*elgamal.CipherText{re, shifted} {
+		if ok, _ := VerifyEncryption(pub, x, p); ok {
+			t.Fatal("proof verified for a derived ciphertext")
+		}
+	}
+
+	// 其他接收者公钥下验证失败
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifyEncryption(&q.PublicKey, ct, p); ok {
+		t.Fatal("proof verified under another key")
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/kdf"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Epoch keys give ciphertexts a cryptographic retention limit. Each server adds
+// to its key d a tweak t_e for epoch e, so the committee key of the epoch is
+// Y_e = Y + sum(t_e)*G. A tweak known to the public, such as H(e), would let
+// anybody move a ciphertext from Y_e back to Y, so the tweaks come from a secret
+// seed each server ratchets forward with SM3-HKDF, erasing the old seed: once all
+// servers have advanced past e, nobody can switch a ciphertext of epoch e any
+// more. Servers publish their epoch keys ahead of time for encryptors.
+// 纪元密钥为密文提供密码学意义上的保存期限。每个ks server在其私钥d上加入纪元e的调整值t_e，
+// 于是该纪元的委员会公钥为Y_e = Y + sum(t_e)*G。若调整值公开可算（如H(e)），任何人都能将密文由Y_e
+// 转回Y，因此调整值由各ks server的秘密种子派生，种子以SM3-HKDF单向推进并擦除旧值：
+// 所有ks server推进到e之后，任何人都无法再置换纪元e的密文。ks server预先为加密方公布各纪元的公钥。
+
+// EpochCipherText is a ciphertext under the committee key of Epoch.
+// 纪元密文：纪元Epoch的委员会公钥下的密文。
+type EpochCipherText struct {
+	Epoch      uint64
+	CipherText elgamal.CipherText
+}
+
+// EpochEncrypt encrypts D under epochPubKey, the committee key of epoch, i.e. the
+// sum of the servers' EpochKey.PublicKey(epoch).
+// 纪元加密：以纪元epoch的委员会公钥epochPubKey（即各ks server的EpochKey.PublicKey(epoch)之和）加密点D。
+//
+// 参数：
+//		纪元公钥	epochPubKey
+//		纪元		epoch
+//		待加密点	D
+// 返回：
+// 		纪元密文
+func EpochEncrypt(epochPubKey *sm2.PublicKey, epoch uint64, D *elgamal.CurvePoint) (*EpochCipherText, error) {
+	ct, err := elgamal.PointEncrypt(epochPubKey, D)
+	if err != nil {
+		return nil, opError("EpochEncrypt", err)
+	}
+	return &EpochCipherText{Epoch: epoch, CipherText: *ct}, nil
+}
+
+// EpochKey is a server key with its epoch ratchet. It is not safe for concurrent
+// use.
+// 纪元密钥：附带纪元推进状态的ks server私钥。不可并发使用。
+type EpochKey struct {
+	priv  *sm2.PrivateKey
+	epoch uint64
+	seed  []byte
+}
+
+// NewEpochKey returns the epoch key of priv whose ratchet starts at epoch 0 with
+// seed, advanced to epoch. Ratcheting costs one derivation per epoch.
+// 创建纪元密钥：私钥priv的纪元推进状态在纪元0时为种子seed，推进到纪元epoch后返回。每推进一个纪元需派生一次。
+//
+// 参数：
+//		私钥	priv
+//		种子	seed
+//		纪元	epoch
+// 返回：
+// 		纪元密钥
+func NewEpochKey(priv *sm2.PrivateKey, seed []byte, epoch uint64) (*EpochKey, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("NewEpochKey", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(&priv.PublicKey)); err != nil {
+		return nil, opError("NewEpochKey", err)
+	}
+	if len(seed) < kdf.Size {
+		return nil, opError("NewEpochKey", kdf.ErrInvalidKeyLength)
+	}
+	k := &EpochKey{priv: priv, seed: append([]byte(nil), seed...)}
+	if err := k.Advance(epoch); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Epoch returns the current epoch of k.
+// 返回k的当前纪元。
+func (k *EpochKey) Epoch() uint64 {
+	return k.epoch
+}
+
+// Advance moves k forward to epoch, erasing the seeds of the epochs passed.
+// Moving backwards fails with ErrEpochExpired.
+// 推进纪元：将k推进到纪元epoch，并擦除经过的纪元的种子。向后推进返回ErrEpochExpired。
+//
+// 参数：
+//		纪元	epoch
+// 返回：
+// 		错误
+func (k *EpochKey) Advance(epoch uint64) error {
+	if epoch < k.epoch {
+		return opError("EpochKey.Advance", ErrEpochExpired)
+	}
+	for ; k.epoch < epoch; k.epoch++ {
+		next, err := ratchet(k.seed)
+		if err != nil {
+			return opError("EpochKey.Advance", err)
+		}
+		wipe(k.seed)
+		k.seed = next
+	}
+	return nil
+}
+
+// PublicKey returns the server's public key (d + t_e)*G of epoch, the current
+// one or a later one, to publish for encryptors.
+// 返回ks server在纪元epoch（当前或之后的纪元）的公钥(d + t_e)*G，供加密方使用。
+//
+// 参数：
+//		纪元	epoch
+// 返回：
+// 		纪元公钥
+func (k *EpochKey) PublicKey(epoch uint64) (*sm2.PublicKey, error) {
+	priv, err := k.privateKey("EpochKey.PublicKey", epoch)
+	if err != nil {
+		return nil, err
+	}
+	return &priv.PublicKey, nil
+}
+
+// ShareBundle calculates and proves the share related with the ciphertext ect for
+// targetPubKey, with the key of its epoch, which must be the current one.
+// 纪元份额计算：以ect所属纪元（须为当前纪元）的密钥，为目标公钥targetPubKey计算关于密文ect的份额并生成证明。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		纪元密文	ect
+// 返回：
+// 		份额包
+func (k *EpochKey) ShareBundle(targetPubKey *sm2.PublicKey, ect *EpochCipherText) (*ShareBundle, error) {
+	if ect == nil {
+		return nil, opError("EpochKey.ShareBundle", elgamal.ErrEmpty)
+	}
+	if ect.Epoch < k.epoch {
+		return nil, opError("EpochKey.ShareBundle", ErrEpochExpired)
+	}
+	if ect.Epoch != k.epoch {
+		return nil, opError("EpochKey.ShareBundle", ErrEpochMismatch)
+	}
+	priv, err := k.privateKey("EpochKey.ShareBundle", k.epoch)
+	if err != nil {
+		return nil, err
+	}
+	return GenShareBundle(targetPubKey, &ect.CipherText.K, priv)
+}
+
+// privateKey returns the key d + t_e of epoch, ratcheting a copy of the seed
+// forward for later epochs.
+// 返回纪元epoch的私钥d + t_e，之后的纪元由种子的副本推进得到。
+func (k *EpochKey) privateKey(op string, epoch uint64) (*sm2.PrivateKey, error) {
+	if epoch < k.epoch {
+		return nil, opError(op, ErrEpochExpired)
+	}
+	seed := append([]byte(nil), k.seed...)
+	defer func() { wipe(seed) }()
+	for e := k.epoch; e < epoch; e++ {
+		next, err := ratchet(seed)
+		if err != nil {
+			return nil, opError(op, err)
+		}
+		wipe(seed)
+		seed = next
+	}
+
+	curve := k.priv.Curve
+	N := curve.Params().N
+	tweak, err := kdf.Expand(seed, []byte("ppks-epoch-tweak"), kdf.Size+8)
+	if err != nil {
+		return nil, opError(op, err)
+	}
+	d := new(big.Int).SetBytes(tweak)
+	wipe(tweak)
+	d.Add(d, k.priv.D)
+	d.Mod(d, N)
+	if d.Sign() == 0 {
+		// 概率可忽略
+		return nil, opError(op, elgamal.ErrOutOfRange)
+	}
+	priv := new(sm2.PrivateKey)
+	priv.Curve = curve
+	priv.D = d
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+	return priv, nil
+}
+
+// ratchet returns the seed of the epoch after that of seed.
+// 返回seed所在纪元的下一纪元的种子。
+func ratchet(seed []byte) ([]byte, error) {
+	return kdf.Expand(seed, []byte("ppks-epoch-ratchet"), kdf.Size)
+}
+
+// wipe zeroes b.
+// 将b清零。
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
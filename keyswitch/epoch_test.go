@@ -0,0 +1,108 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEpochKey(t *testing.T) {
+	const epoch = 5
+	keys := make([]*EpochKey, 3)
+	pubs := make([]*sm2.PublicKey, len(keys))
+	for i := range keys {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seed := bytes.Repeat([]byte{byte(i + 1)}, 32)
+		if keys[i], err = NewEpochKey(priv, seed, 2); err != nil {
+			t.Fatal(err)
+		}
+		// 预先公布之后纪元的公钥
+		if pubs[i], err = keys[i].PublicKey(epoch); err != nil {
+			t.Fatal(err)
+		}
+		if pubs[i].X.Cmp(priv.X) == 0 {
+			t.Fatal("epoch key equals the base key")
+		}
+	}
+	epochPub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.GenPoint()
+	ect, err := EpochEncrypt(epochPub, epoch, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 纪元未到时拒绝置换
+	if _, err := keys[0].ShareBundle(&q.PublicKey, ect); !errors.Is(err, ErrEpochMismatch) {
+		t.Fatalf("expected ErrEpochMismatch, got %v", err)
+	}
+
+	shares := make(elgamal.CipherVector, len(keys))
+	for i, k := range keys {
+		if err := k.Advance(epoch); err != nil {
+			t.Fatal(err)
+		}
+		b, err := k.ShareBundle(&q.PublicKey, ect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sameKey(b.NodePubKey, pubs[i]) {
+			t.Fatal("bundle made with another epoch key")
+		}
+		shares[i] = b.Share
+	}
+	switched, err := ShareReplace(&shares, &ect.CipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := elgamal.PointDecrypt(switched, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+		t.Fatal("switched epoch ciphertext decrypts to a different point")
+	}
+
+	// 推进之后不能再置换，也不能回退
+	if err := keys[0].Advance(epoch + 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys[0].ShareBundle(&q.PublicKey, ect); !errors.Is(err, ErrEpochExpired) {
+		t.Fatalf("expected ErrEpochExpired, got %v", err)
+	}
+	if err := keys[0].Advance(epoch); !errors.Is(err, ErrEpochExpired) {
+		t.Fatalf("expected ErrEpochExpired moving backwards, got %v", err)
+	}
+	if _, err := keys[0].PublicKey(epoch); !errors.Is(err, ErrEpochExpired) {
+		t.Fatalf("expected ErrEpochExpired for a past public key, got %v", err)
+	}
+}
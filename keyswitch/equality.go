@@ -0,0 +1,176 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// samePlaintextContext binds plaintext equality proofs to their protocol.
+// 明文相等证明的上下文。
+const samePlaintextContext = "ppks-same-plaintext"
+
+// PlaintextWitness is what the prover knows about one ciphertext ct=(K,C) under
+// pub: either the encryption randomness R with K=R*B, or the private key Priv of
+// pub. Exactly one of them must be set. A migration job that decrypts the old
+// ciphertext and encrypts the point again knows the old key and the new randomness.
+// 明文证据：证明方关于公钥pub下密文ct=(K,C)所知道的信息，即满足K=R*B的加密随机数R，
+// 或pub对应的私钥Priv，二者须且仅须设置其一。解密原密文并重新加密的迁移任务知道原私钥与新随机数。
+type PlaintextWitness struct {
+	R    *big.Int
+	Priv *sm2.PrivateKey
+}
+
+// SamePlaintextProof proves that two ciphertexts, possibly under different keys,
+// encrypt the same point. ByKey1 and ByKey2 record which kind of witness was used
+// for each ciphertext, as the verifier needs it to rebuild the statement.
+// 明文相等证明：证明两个密文（可在不同公钥下）加密的是同一点。ByKey1、ByKey2记录各密文所用证据的
+// 类型（是否为私钥），验证方据此重构公开信息。
+type SamePlaintextProof struct {
+	ByKey1, ByKey2 bool
+	Proof          *proof.LinearProof
+}
+
+// samePlaintextSide returns, for one ciphertext, the point Y=s*B and the base X
+// with C=D+s*X, where s is the randomness (Y=K, X=pub) or the key (Y=pub, X=K).
+// 对单个密文返回点Y=s*B及满足C=D+s*X的基点X：s为随机数时Y=K、X=pub，s为私钥时Y=pub、X=K。
+func samePlaintextSide(ct *elgamal.CipherText, pub *sm2.PublicKey, byKey bool) (Y, X *elgamal.CurvePoint) {
+	if byKey {
+		return (*elgamal.CurvePoint)(pub), &ct.K
+	}
+	return &ct.K, (*elgamal.CurvePoint)(pub)
+}
+
+// samePlaintextRelation returns the relation {Y1=s1*B, Y2=s2*B, C1-C2=s1*X1-s2*X2}.
+// 返回关系{Y1=s1*B, Y2=s2*B, C1-C2=s1*X1-s2*X2}。
+func samePlaintextRelation(op string, ct1 *elgamal.CipherText, pub1 *sm2.PublicKey, byKey1 bool, ct2 *elgamal.CipherText, pub2 *sm2.PublicKey, byKey2 bool) (*proof.Relation, error) {
+	for i, ct := range []*elgamal.CipherText{ct1, ct2} {
+		if err := elgamal.CheckCipherText(ct); err != nil {
+			return nil, itemError(op, "ciphertext", i, err)
+		}
+	}
+	for i, pub := range []*sm2.PublicKey{pub1, pub2} {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return nil, itemError(op, "key", i, err)
+		}
+	}
+	Y1, X1 := samePlaintextSide(ct1, pub1, byKey1)
+	Y2, X2 := samePlaintextSide(ct2, pub2, byKey2)
+	negX2, err := elgamal.NegPoint(X2)
+	if err != nil {
+		return nil, opError(op, err)
+	}
+	negC2, err := elgamal.NegPoint(&ct2.C)
+	if err != nil {
+		return nil, opError(op, err)
+	}
+	curve := ct1.C.Curve
+	A := &elgamal.CurvePoint{Curve: curve}
+	A.X, A.Y = ec.Add(curve, ct1.C.X, ct1.C.Y, negC2.X, negC2.Y)
+
+	B := elgamal.Generator(curve)
+	return &proof.Relation{
+		Bases: [][]*elgamal.CurvePoint{
+			{B, nil},
+			{nil, B},
+			{X1, negX2},
+		},
+		Targets: []*elgamal.CurvePoint{Y1, Y2, A},
+	}, nil
+}
+
+// witnessScalar checks w against ct and pub and returns its scalar.
+// 校验证据w与密文ct、公钥pub是否一致，返回其标量。
+func witnessScalar(op string, index int, w *PlaintextWitness, ct *elgamal.CipherText, pub *sm2.PublicKey) (*big.Int, bool, error) {
+	if w == nil || (w.R == nil) == (w.Priv == nil) {
+		return nil, false, itemError(op, "witness", index, ErrInvalidWitness)
+	}
+	if w.Priv != nil {
+		if !sameKey(&w.Priv.PublicKey, pub) {
+			return nil, false, itemError(op, "witness", index, ErrInvalidWitness)
+		}
+		return w.Priv.D, true, nil
+	}
+	curve := ct.K.Curve
+	var K elgamal.CurvePoint
+	K.Curve = curve
+	K.X, K.Y = curve.ScalarBaseMult(new(big.Int).Mod(w.R, curve.Params().N).Bytes())
+	if !samePoint(&K, &ct.K) {
+		return nil, false, itemError(op, "witness", index, ErrInvalidWitness)
+	}
+	return w.R, false, nil
+}
+
+// ProveSamePlaintext proves that ct1 under pub1 and ct2 under pub2 encrypt the
+// same point, without revealing it, so a job re-encrypting data under a new key
+// can show that the content did not change. w1 and w2 are the witnesses of ct1
+// and ct2; a witness that does not match its ciphertext is refused.
+// 明文相等证明生成：在不泄露明文的情况下，证明公钥pub1下的密文ct1与公钥pub2下的密文ct2加密的是同一点，
+// 以新公钥重新加密数据的任务可据此表明内容未变。w1、w2分别为ct1、ct2的证据，与密文不符时拒绝生成。
+//
+// 参数：
+//		密文及公钥	ct1,pub1
+//		密文及公钥	ct2,pub2
+//		证据		w1,w2
+// 返回：
+// 		明文相等证明
+func ProveSamePlaintext(ct1 *elgamal.CipherText, pub1 *sm2.PublicKey, ct2 *elgamal.CipherText, pub2 *sm2.PublicKey, w1, w2 *PlaintextWitness) (*SamePlaintextProof, error) {
+	s1, byKey1, err := witnessScalar("ProveSamePlaintext", 0, w1, ct1, pub1)
+	if err != nil {
+		return nil, err
+	}
+	s2, byKey2, err := witnessScalar("ProveSamePlaintext", 1, w2, ct2, pub2)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := samePlaintextRelation("ProveSamePlaintext", ct1, pub1, byKey1, ct2, pub2, byKey2)
+	if err != nil {
+		return nil, err
+	}
+	p, err := proof.ProveLinear(samePlaintextContext, rel, []*big.Int{s1, s2})
+	if err != nil {
+		return nil, err
+	}
+	return &SamePlaintextProof{ByKey1: byKey1, ByKey2: byKey2, Proof: p}, nil
+}
+
+// VerifySamePlaintext verifies the proof p of ProveSamePlaintext that ct1 under
+// pub1 and ct2 under pub2 encrypt the same point.
+// 明文相等证明验证：验证ProveSamePlaintext生成的证明p，即公钥pub1下的密文ct1与公钥pub2下的密文ct2
+// 加密的是同一点。
+//
+// 参数：
+//		密文及公钥	ct1,pub1
+//		密文及公钥	ct2,pub2
+//		证明		p
+// 返回：
+// 		验证结果
+func VerifySamePlaintext(ct1 *elgamal.CipherText, pub1 *sm2.PublicKey, ct2 *elgamal.CipherText, pub2 *sm2.PublicKey, p *SamePlaintextProof) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+	rel, err := samePlaintextRelation("VerifySamePlaintext", ct1, pub1, p.ByKey1, ct2, pub2, p.ByKey2)
+	if err != nil {
+		return false, err
+	}
+	return proof.VerifyLinear(samePlaintextContext, rel, p.Proof)
+}
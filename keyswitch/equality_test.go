@@ -0,0 +1,105 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// encryptWithR encrypts D with pub and returns the randomness too.
+func encryptWithR(t *testing.T, pub *sm2.PublicKey, D *elgamal.CurvePoint) (*elgamal.CipherText, *big.Int) {
+	curve := pub.Curve
+	r, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ct elgamal.CipherText
+	ct.K.Curve, ct.C.Curve = curve, curve
+	ct.K.X, ct.K.Y = curve.ScalarBaseMult(r.Bytes())
+	x, y := curve.ScalarMult(pub.X, pub.Y, r.Bytes())
+	ct.C.X, ct.C.Y = ec.Add(curve, x, y, D.X, D.Y)
+	return &ct, r
+}
+
+func TestSamePlaintext(t *testing.T) {
+	oldPriv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPriv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPub, newPub := &oldPriv.PublicKey, &newPriv.PublicKey
+	D := elgamal.GenPoint()
+
+	// 迁移：以原私钥与新随机数证明
+	ct1, err := elgamal.PointEncrypt(oldPub, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct2, r2 := encryptWithR(t, newPub, D)
+	p, err := ProveSamePlaintext(ct1, oldPub, ct2, newPub, &PlaintextWitness{Priv: oldPriv}, &PlaintextWitness{R: r2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifySamePlaintext(ct1, oldPub, ct2, newPub, p); err != nil || !ok {
+		t.Fatal("migration proof failed to verify")
+	}
+
+	// 以两个随机数证明
+	ct3, r3 := encryptWithR(t, oldPub, D)
+	p2, err := ProveSamePlaintext(ct3, oldPub, ct2, newPub, &PlaintextWitness{R: r3}, &PlaintextWitness{R: r2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifySamePlaintext(ct3, oldPub, ct2, newPub, p2); err != nil || !ok {
+		t.Fatal("randomness proof failed to verify")
+	}
+
+	// 证明不适用于其他密文或其他证据类型
+	if ok, _ := VerifySamePlaintext(ct3, oldPub, ct2, newPub, p); ok {
+		t.Fatal("proof verified for another ciphertext")
+	}
+	p.ByKey1 = false
+	if ok, _ := VerifySamePlaintext(ct1, oldPub, ct2, newPub, p); ok {
+		t.Fatal("proof verified with the wrong witness kind")
+	}
+
+	// 明文不同时无法生成有效证明
+	ct4, r4 := encryptWithR(t, newPub, elgamal.GenPoint())
+	p3, err := ProveSamePlaintext(ct1, oldPub, ct4, newPub, &PlaintextWitness{Priv: oldPriv}, &PlaintextWitness{R: r4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifySamePlaintext(ct1, oldPub, ct4, newPub, p3); ok {
+		t.Fatal("proof verified for different plaintexts")
+	}
+
+	for _, w := range []*PlaintextWitness{nil, {}, {R: r2, Priv: oldPriv}, {Priv: newPriv}, {R: r3}} {
+		if _, err := ProveSamePlaintext(ct1, oldPub, ct2, newPub, w, &PlaintextWitness{R: r2}); !errors.Is(err, ErrInvalidWitness) {
+			t.Fatalf("got %v, want ErrInvalidWitness", err)
+		}
+	}
+}
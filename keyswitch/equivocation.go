@@ -0,0 +1,117 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"ppks/schnorr"
+)
+
+// EquivocationProof is a compact fraud proof against a server: a share bundle it
+// signed whose proof fails, i.e. a share that is not the one its key gives for the
+// statement it signed. Anyone can check it with Verify, so it can be used to slash
+// or evict the server. As every valid bundle for a statement encrypts the same
+// point, two signed bundles for one rB and target that both verify are consistent,
+// even though their shares differ in randomness.
+// 违规证明：针对ks server的紧凑欺诈证明，即一个由其签名但证明未通过的份额包，亦即其份额并非其私钥
+// 对所签名的公开信息应得的份额。任何人均可以Verify检验，可据此罚没或驱逐该ks server。由于同一公开信息的
+// 有效份额包均加密同一点，对同一rB与目标公钥签名的两个份额包若均验证通过即为一致，尽管其份额的随机数不同。
+type EquivocationProof struct {
+	Bundle    *ShareBundle
+	Signature *schnorr.Signature
+}
+
+// DetectEquivocation compares two bundles signed by the same server for the same
+// rB and target, returning the fraud proof if either fails its proof and nil if
+// both are consistent. Bundles of different servers or statements fail with
+// ErrStatementMismatch, and a bad signature with ErrAuthFailed, since neither
+// proves anything against the server.
+// 违规检测：比较同一ks server对同一rB与目标公钥签名的两个份额包，其一证明未通过时返回欺诈证明，
+// 二者一致时返回nil。不同ks server或不同公开信息的份额包返回ErrStatementMismatch，签名无效时返回
+// ErrAuthFailed，二者均不能作为对该ks server不利的证据。
+//
+// 参数：
+//		份额包及签名	b1,sig1
+//		份额包及签名	b2,sig2
+// 返回：
+// 		违规证明
+func DetectEquivocation(b1 *ShareBundle, sig1 *schnorr.Signature, b2 *ShareBundle, sig2 *schnorr.Signature) (*EquivocationProof, error) {
+	if b1 == nil || b2 == nil {
+		return nil, opError("DetectEquivocation", ErrIncompleteStatement)
+	}
+	if b1.NodePubKey == nil || b1.TargetPubKey == nil || b1.RB == nil {
+		return nil, itemError("DetectEquivocation", "bundle", 0, ErrIncompleteStatement)
+	}
+	if b2.NodePubKey == nil || b2.TargetPubKey == nil || b2.RB == nil {
+		return nil, itemError("DetectEquivocation", "bundle", 1, ErrIncompleteStatement)
+	}
+	if !sameKey(b1.NodePubKey, b2.NodePubKey) || !sameKey(b1.TargetPubKey, b2.TargetPubKey) || !samePoint(b1.RB, b2.RB) {
+		return nil, opError("DetectEquivocation", ErrStatementMismatch)
+	}
+
+	for i, p := range []*EquivocationProof{{b1, sig1}, {b2, sig2}} {
+		if ok, err := p.Bundle.VerifySignature(p.Signature); err != nil || !ok {
+			return nil, itemError("DetectEquivocation", "bundle", i, ErrAuthFailed)
+		}
+	}
+	for _, p := range []*EquivocationProof{{b1, sig1}, {b2, sig2}} {
+		if ok, err := p.Bundle.Verify(); err != nil || !ok {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// Verify reports whether p proves misbehaviour of the server p.Bundle.NodePubKey:
+// the signature is valid and the proof of the bundle fails.
+// 违规证明验证：判断p是否证明了ks server p.Bundle.NodePubKey的违规行为，即签名有效而份额包的证明未通过。
+//
+// 返回：
+// 		验证结果
+func (p *EquivocationProof) Verify() (bool, error) {
+	if p.Bundle == nil || p.Signature == nil {
+		return false, opError("EquivocationProof.Verify", ErrIncompleteStatement)
+	}
+	ok, err := p.Bundle.VerifySignature(p.Signature)
+	if err != nil || !ok {
+		return false, err
+	}
+	ok, err = p.Bundle.Verify()
+	return err == nil && !ok, nil
+}
+
+// MarshalBinary encodes p in the format of the plaintext of SealShare.
+// 以SealShare明文的格式编码p。
+func (p *EquivocationProof) MarshalBinary() ([]byte, error) {
+	if p.Bundle == nil || p.Signature == nil {
+		return nil, opError("EquivocationProof.MarshalBinary", ErrIncompleteStatement)
+	}
+	b, err := encodeSignedBundle(p.Bundle, p.Signature)
+	if err != nil {
+		return nil, opError("EquivocationProof.MarshalBinary", err)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary into p.
+// 将MarshalBinary的输出解码到p。
+func (p *EquivocationProof) UnmarshalBinary(b []byte) error {
+	bundle, sig, ok := decodeSignedBundle(b)
+	if !ok {
+		return opError("EquivocationProof.UnmarshalBinary", ErrInvalidProofEncoding)
+	}
+	p.Bundle, p.Signature = bundle, sig
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestDetectEquivocation(t *testing.T) {
+	node, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+	signed := func(target *sm2.PublicKey, forge bool) (*ShareBundle, *schnorr.Signature) {
+		b, err := GenShareBundle(target, rB, node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if forge {
+			// 节点对错误的份额签名
+			b.Share.C = *elgamal.GenPoint()
+		}
+		sig, err := b.Sign(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b, sig
+	}
+
+	b1, sig1 := signed(&q.PublicKey, false)
+	b2, sig2 := signed(&q.PublicKey, false)
+	if p, err := DetectEquivocation(b1, sig1, b2, sig2); err != nil || p != nil {
+		t.Fatalf("honest bundles flagged: %v, %v", p, err)
+	}
+
+	bad, badSig := signed(&q.PublicKey, true)
+	p, err := DetectEquivocation(b1, sig1, bad, badSig)
+	if err != nil || p == nil {
+		t.Fatalf("equivocation not detected: %v", err)
+	}
+	if ok, err := p.Verify(); err != nil || !ok {
+		t.Fatalf("fraud proof failed to verify: %v", err)
+	}
+	enc, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got EquivocationProof
+	if err := got.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := got.Verify(); err != nil || !ok {
+		t.Fatalf("decoded fraud proof failed to verify: %v", err)
+	}
+	if err := got.UnmarshalBinary(enc[:len(enc)-1]); !errors.Is(err, ErrInvalidProofEncoding) {
+		t.Fatalf("got %v, want ErrInvalidProofEncoding", err)
+	}
+
+	// 有效份额包不构成违规证明
+	if ok, _ := (&EquivocationProof{Bundle: b1, Signature: sig1}).Verify(); ok {
+		t.Fatal("valid bundle accepted as fraud proof")
+	}
+	// 签名无效的份额包不能归咎于节点
+	if ok, _ := (&EquivocationProof{Bundle: bad, Signature: sig1}).Verify(); ok {
+		t.Fatal("unsigned bundle accepted as fraud proof")
+	}
+	if _, err := DetectEquivocation(b1, sig1, bad, sig1); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b3, sig3 := signed(&other.PublicKey, false)
+	if _, err := DetectEquivocation(b1, sig1, b3, sig3); !errors.Is(err, ErrStatementMismatch) {
+		t.Fatalf("got %v, want ErrStatementMismatch", err)
+	}
+}
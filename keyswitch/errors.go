@@ -0,0 +1,81 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"errors"
+
+	"ppks/elgamal"
+)
+
+// Base errors of key switching, in addition to those of package elgamal.
+// 密钥置换的基础错误，elgamal包中的基础错误之外另有下列错误。
+var (
+	// ErrIncompleteStatement 证明所针对的公开信息不完整。
+	ErrIncompleteStatement = errors.New("incomplete statement")
+	// ErrVerifierClosed Verifier关闭后再提交份额包。
+	ErrVerifierClosed = errors.New("verifier closed")
+	// ErrProofFailed 份额证明验证未通过。
+	ErrProofFailed = errors.New("proof verification failed")
+	// ErrIncompleteProof 证明缺少(c,r1,r2)中的值。
+	ErrIncompleteProof = errors.New("incomplete proof")
+	// ErrInvalidProofEncoding 证明编码格式错误。
+	ErrInvalidProofEncoding = errors.New("invalid proof encoding")
+	// ErrUnsupportedKey 密钥类型不支持密钥置换。
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	// ErrInvalidThreshold 门限参数或参与者编号非法。
+	ErrInvalidThreshold = errors.New("invalid threshold parameters")
+	// ErrInvalidShare 私钥份额与分发者的承诺不符。
+	ErrInvalidShare = errors.New("share does not match commitment")
+	// ErrChallengeIssued 聚合证明的挑战值发出后再添加节点。
+	ErrChallengeIssued = errors.New("challenge already issued")
+	// ErrAuthFailed 认证失败：数据被篡改，或密钥不匹配。
+	ErrAuthFailed = errors.New("message authentication failed")
+	// ErrEpochExpired 所请求的纪元已过期，其密钥已擦除。
+	ErrEpochExpired = errors.New("epoch expired")
+	// ErrEpochMismatch 密文的纪元与节点当前纪元不符。
+	ErrEpochMismatch = errors.New("epoch mismatch")
+	// ErrSessionClosed 会话密钥已关闭并擦除。
+	ErrSessionClosed = errors.New("session key closed")
+	// ErrPolicyExpired 策略已失效。
+	ErrPolicyExpired = errors.New("policy expired")
+	// ErrInvalidWitness 证据缺失，或与所证明的公开信息不符。
+	ErrInvalidWitness = errors.New("invalid witness")
+	// ErrWrongPhase 承诺或揭示不属于当前阶段，或节点已提交过。
+	ErrWrongPhase = errors.New("wrong commit-reveal phase")
+	// ErrUnknownNode 节点不属于该会话，或重复出现。
+	ErrUnknownNode = errors.New("unknown node")
+	// ErrInsufficientWeight 参与节点的总权重低于门限。
+	ErrInsufficientWeight = errors.New("insufficient weight")
+	// ErrInvalidTranscript 会话记录编码格式错误。
+	ErrInvalidTranscript = errors.New("invalid session transcript")
+	// ErrReplayed 请求已服务过，疑为重放。
+	ErrReplayed = errors.New("request already served")
+	// ErrStatementMismatch 两个份额包的节点、目标公钥或rB不同，无法比较。
+	ErrStatementMismatch = errors.New("bundles for different statements")
+)
+
+// opError wraps err with the failing operation.
+// 以出错的操作包装err。
+func opError(op string, err error) error {
+	return &elgamal.Error{Op: op, Err: err}
+}
+
+// itemError wraps err with the failing operation and the index of the failing element.
+// 以出错的操作及出错元素的下标包装err。
+func itemError(op, item string, index int, err error) error {
+	return &elgamal.Error{Op: op, Item: item, Index: index, Err: err}
+}
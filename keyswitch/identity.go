@@ -0,0 +1,179 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"encoding/binary"
+
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// A server may keep a long-term identity key apart from the keys it switches with:
+// the identity key, kept offline or in hardware, only certifies rotating switching
+// keys, such as the keys of an EpochKey, and ShareCal only ever uses the current
+// switching key. Requesters pin the identity key and check the certificate chain of
+// a bundle before accepting its share, so a leaked switching key is exposed for its
+// epoch only.
+// ks server可将长期身份私钥与用于置换的私钥分离：身份私钥离线或存于硬件中，仅用于为轮换的置换私钥
+// （如EpochKey的各纪元私钥）签发证书，ShareCal只使用当前的置换私钥。请求者固定信任身份公钥，
+// 接受份额前检查份额包的证书链，泄露的置换私钥仅影响其所在纪元。
+
+// switchingKeyDomain separates switching key certificates from other signed messages.
+// 置换公钥证书所用的域分隔标签，与其他签名消息区分。
+const switchingKeyDomain = "ppks-switching-key"
+
+// SwitchingKeyCert is the certificate of PubKey by Issuer from Epoch on. The last
+// certificate of a chain certifies a switching key for exactly Epoch; the ones
+// before it certify intermediate keys, from their Epoch on.
+// 置换公钥证书：Issuer自纪元Epoch起为PubKey签发的证书。证书链的最后一个证书为恰好纪元Epoch的
+// 置换公钥签发，之前的证书为中间公钥签发，自其Epoch起有效。
+type SwitchingKeyCert struct {
+	Issuer    *sm2.PublicKey
+	PubKey    *sm2.PublicKey
+	Epoch     uint64
+	Signature *schnorr.Signature
+}
+
+// CertifySwitchingKey certifies pub with the key issuer from epoch on.
+// 签发置换公钥证书：以私钥issuer为pub签发自纪元epoch起有效的证书。
+//
+// 参数：
+//		签发者私钥	issuer
+//		公钥		pub
+//		纪元		epoch
+// 返回：
+// 		置换公钥证书
+func CertifySwitchingKey(issuer *sm2.PrivateKey, pub *sm2.PublicKey, epoch uint64) (*SwitchingKeyCert, error) {
+	if issuer == nil || issuer.D == nil {
+		return nil, opError("CertifySwitchingKey", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, opError("CertifySwitchingKey", err)
+	}
+	c := &SwitchingKeyCert{Issuer: &issuer.PublicKey, PubKey: pub, Epoch: epoch}
+	sig, err := schnorr.Sign(issuer, c.signedBytes())
+	if err != nil {
+		return nil, opError("CertifySwitchingKey", err)
+	}
+	c.Signature = sig
+	return c, nil
+}
+
+// Certify certifies the switching key of epoch of k with the identity key.
+// 签发纪元公钥证书：以身份私钥identity为k在纪元epoch的置换公钥签发证书。
+//
+// 参数：
+//		身份私钥	identity
+//		纪元		epoch
+// 返回：
+// 		置换公钥证书
+func (k *EpochKey) Certify(identity *sm2.PrivateKey, epoch uint64) (*SwitchingKeyCert, error) {
+	pub, err := k.PublicKey(epoch)
+	if err != nil {
+		return nil, err
+	}
+	return CertifySwitchingKey(identity, pub, epoch)
+}
+
+// Verify reports whether c is a valid certificate by c.Issuer.
+// 验证置换公钥证书：判断c是否为c.Issuer签发的有效证书。
+//
+// 返回：
+// 		验证结果
+func (c *SwitchingKeyCert) Verify() (bool, error) {
+	if c.Issuer == nil || c.PubKey == nil || c.Signature == nil {
+		return false, opError("SwitchingKeyCert.Verify", ErrIncompleteStatement)
+	}
+	for _, pub := range []*sm2.PublicKey{c.Issuer, c.PubKey} {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return false, opError("SwitchingKeyCert.Verify", err)
+		}
+	}
+	return schnorr.Verify(c.Issuer, c.signedBytes(), c.Signature)
+}
+
+// signedBytes returns the message signed for c: the domain, the issuer, the key
+// and the epoch.
+// 返回为c签名的消息：域分隔标签、签发者公钥、公钥及纪元。
+func (c *SwitchingKeyCert) signedBytes() []byte {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], c.Epoch)
+	msg := []byte(switchingKeyDomain)
+	msg = append(msg, (*elgamal.CurvePoint)(c.Issuer).CompressedBytes()...)
+	msg = append(msg, (*elgamal.CurvePoint)(c.PubKey).CompressedBytes()...)
+	return append(msg, n[:]...)
+}
+
+// VerifyCertChain checks chain from the identity key down to the switching key of
+// epoch and returns that key. Every certificate must be valid and issued by the
+// key certified before it, the first by identity; intermediate keys must be valid
+// by epoch, and the last certificate must be for epoch itself. A broken chain
+// fails with ErrAuthFailed, a certificate of another epoch with ErrEpochMismatch.
+// 验证证书链：检查由身份公钥identity至纪元epoch的置换公钥的证书链chain，返回该置换公钥。
+// 每个证书须有效且由前一证书所证明的公钥签发，首个证书由identity签发；中间公钥须在纪元epoch时已生效，
+// 最后一个证书须恰为纪元epoch签发。证书链断裂时返回ErrAuthFailed，纪元不符时返回ErrEpochMismatch。
+//
+// 参数：
+//		身份公钥	identity
+//		证书链		chain
+//		纪元		epoch
+// 返回：
+// 		置换公钥
+func VerifyCertChain(identity *sm2.PublicKey, chain []*SwitchingKeyCert, epoch uint64) (*sm2.PublicKey, error) {
+	if len(chain) == 0 {
+		return nil, opError("VerifyCertChain", elgamal.ErrEmpty)
+	}
+	issuer := identity
+	for i, c := range chain {
+		if c == nil || c.Issuer == nil || !sameKey(c.Issuer, issuer) {
+			return nil, itemError("VerifyCertChain", "certificate", i, ErrAuthFailed)
+		}
+		if ok, err := c.Verify(); err != nil || !ok {
+			return nil, itemError("VerifyCertChain", "certificate", i, ErrAuthFailed)
+		}
+		if c.Epoch > epoch || (i == len(chain)-1 && c.Epoch != epoch) {
+			return nil, itemError("VerifyCertChain", "certificate", i, ErrEpochMismatch)
+		}
+		issuer = c.PubKey
+	}
+	return issuer, nil
+}
+
+// VerifyCertified verifies b like Verify after checking that its node key is the
+// switching key of epoch certified by chain from identity; a bundle from another
+// key fails with ErrUnknownNode.
+// 验证经认证的份额包：检查b的节点公钥为证书链chain由身份公钥identity认证的纪元epoch的置换公钥后，
+// 与Verify相同地验证b。节点公钥不符时返回ErrUnknownNode。
+//
+// 参数：
+//		身份公钥	identity
+//		证书链		chain
+//		纪元		epoch
+// 返回：
+// 		验证结果
+func (b *ShareBundle) VerifyCertified(identity *sm2.PublicKey, chain []*SwitchingKeyCert, epoch uint64) (bool, error) {
+	pub, err := VerifyCertChain(identity, chain, epoch)
+	if err != nil {
+		return false, err
+	}
+	if b.NodePubKey == nil || !sameKey(b.NodePubKey, pub) {
+		return false, opError("ShareBundle.VerifyCertified", ErrUnknownNode)
+	}
+	return b.Verify()
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestVerifyCertChain(t *testing.T) {
+	identity, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 身份私钥为中间私钥签发证书，中间私钥为各纪元置换公钥签发证书
+	inter, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interCert, err := CertifySwitchingKey(identity, &inter.PublicKey, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatal(err)
+	}
+	const epoch = 5
+	k, err := NewEpochKey(node, seed, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := k.Certify(inter, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := []*SwitchingKeyCert{interCert, leaf}
+
+	epochPub, err := k.PublicKey(epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := VerifyCertChain(&identity.PublicKey, chain, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameKey(got, epochPub) {
+		t.Fatal("chain certifies another key")
+	}
+
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ect, err := EpochEncrypt(epochPub, epoch, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := k.ShareBundle(&q.PublicKey, ect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.VerifyCertified(&identity.PublicKey, chain, epoch); err != nil || !ok {
+		t.Fatalf("certified bundle failed to verify: %v", err)
+	}
+
+	// 未经认证的节点公钥
+	other, err := GenShareBundle(&q.PublicKey, &ect.CipherText.K, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.VerifyCertified(&identity.PublicKey, chain, epoch); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("got %v, want ErrUnknownNode", err)
+	}
+	if _, err := VerifyCertChain(&identity.PublicKey, chain, epoch+1); !errors.Is(err, ErrEpochMismatch) {
+		t.Fatalf("got %v, want ErrEpochMismatch", err)
+	}
+	if _, err := VerifyCertChain(&identity.PublicKey, chain, 0); !errors.Is(err, ErrEpochMismatch) {
+		t.Fatalf("got %v, want ErrEpochMismatch", err)
+	}
+	if _, err := VerifyCertChain(&inter.PublicKey, chain, epoch); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	// 跳过中间证书
+	if _, err := VerifyCertChain(&identity.PublicKey, chain[1:], epoch); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	forged := *leaf
+	forged.PubKey = &node.PublicKey
+	if _, err := VerifyCertChain(&identity.PublicKey, []*SwitchingKeyCert{interCert, &forged}, epoch); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
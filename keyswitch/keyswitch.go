@@ -0,0 +1,370 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keyswitch implements the key switch of ppks: every KS server turns its
+// part of a ciphertext encrypted under the collective key into a share for a target
+// key, proves the share, and the shares replace the ciphertext for the target.
+// 密钥置换：各ks server针对以聚合公钥加密的密文，为目标公钥计算份额并给出计算证明，
+// 请求者以份额置换原密文，得到目标公钥下的新密文。
+//
+// Concurrency: the functions of this package keep no state and are safe for
+// concurrent use, as are Verifier and a Pipeline whose committees are. A
+// ShareAccumulator must not be shared between goroutines; SafeAccumulator can be.
+// 并发：本包函数无内部状态，可并发调用；Verifier可并发使用，Pipeline在其各委员会可并发使用时亦然。
+// ShareAccumulator不可在协程间共享，SafeAccumulator可以。
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// CollPrivKey returns the addition of the private keys in privs.
+// 聚合私钥：加和privs中的私钥，并返回。
+//
+// 参数：
+//		私钥slice	privs
+// 返回：
+// 		聚合私钥
+func CollPrivKey(privs []sm2.PrivateKey) *sm2.PrivateKey {
+
+	// 返回集合公钥
+	collPrivKey := privs[0]
+
+	// 私钥&公钥变量
+	collPriv, _ := new(big.Int).SetString("0", 16)
+	// pubKeys := make([]sm2.PublicKey, len(privs))
+
+	// 遍历私钥组
+	for i := 0; i < len(privs); i++ {
+		// 累加私钥
+		collPriv.Add(collPriv, privs[i].D)
+		if collPriv.Cmp(collPrivKey.Curve.Params().N) >= 0 {
+			collPriv.Mod(collPriv, collPrivKey.Curve.Params().N)
+		}
+		// 赋值公钥组
+		// pubKeys[i] = *(GetPubKey(&privs[i]))
+	}
+
+	// 分别赋值私钥&公钥
+	collPrivKey.D = collPriv
+	// collPrivKey.PublicKey = *CollPubKey(pubKeys)
+	collPrivKey.PublicKey.X, collPrivKey.PublicKey.Y = collPrivKey.PublicKey.Curve.ScalarBaseMult(collPrivKey.D.Bytes())
+
+	return &collPrivKey
+}
+
+// CollPubKey returns the addition of the public keys in pubs.
+// 聚合公钥：加和pubs中的公钥，并返回。
+//
+// 参数：
+//		公钥slice	pubs
+// 返回：
+// 		聚合公钥
+func CollPubKey(pubs []sm2.PublicKey) *sm2.PublicKey {
+	collPubKey := pubs[0]
+	curve := collPubKey.Curve
+	for i := 1; i < len(pubs); i++ {
+		collPubKey.X, collPubKey.Y = curve.Add(collPubKey.X, collPubKey.Y, pubs[i].X, pubs[i].Y)
+	}
+	return &collPubKey
+}
+
+// ShareCal calculates the share related with rB(left point K in ciphertext)
+// for targetPubKey with priv.
+// 份额计算: 使用私钥priv，为目标公钥targetPubKey计算关于点rB（一份密文的左侧点K）的份额，并返回。
+//
+// 参数：
+//		目标公钥	pub
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func ShareCal(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*elgamal.CipherText, *big.Int, error) {
+	return ShareCalWithSuite(proof.DefaultSuite, targetPubKey, rB, priv)
+}
+
+// ShareCalWithSuite is ShareCal with the multiplications by the private key and by
+// ri carried out by the arithmetic backend of suite.
+// 份额计算：同ShareCal，但以私钥及随机数ri进行的标量乘由参数组suite的算术后端完成。
+//
+// 参数：
+//		参数组		suite
+//		目标公钥	pub
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func ShareCalWithSuite(suite proof.Suite, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*elgamal.CipherText, *big.Int, error) {
+	var share elgamal.CipherText
+
+	// 检查目标公钥与密文左侧点，拒绝不在曲线上的点，防止无效曲线攻击泄露私钥
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return &share, nil, opError("ShareCal", err)
+	}
+	if err := elgamal.CheckPoint(rB); err != nil {
+		return &share, nil, opError("ShareCal", err)
+	}
+
+	// 生成随机数ri
+	curve := priv.Curve                                // 从公钥提取曲线
+	ri, err := ec.RandFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
+	if err != nil {
+		return &share, ri, opError("ShareCal", err)
+	}
+	backend := elgamal.Backend(suite.Backend)
+	riBytes := ri.Bytes()
+	dBytes := priv.D.Bytes()
+	defer wipe(riBytes)
+	defer wipe(dBytes)
+
+	// 计算左侧点K，riB
+	share.K.Curve = priv.Curve
+	share.K.X, share.K.Y, err = backend.ScalarBaseMult(curve, riBytes)
+	if err != nil {
+		return &share, nil, opError("ShareCal", err)
+	}
+
+	// 计算-rKi，即-rBki，其中，Ki为己方公钥，ki为己方私钥
+	rBkix, rBkiy, err := backend.ScalarMult(rB, dBytes)
+	if err != nil {
+		return &share, nil, opError("ShareCal", err)
+	}
+	rBkix, rBkiy = ec.Neg(curve, rBkix, rBkiy)
+
+	// 计算riU
+	riUx, riUy, err := backend.ScalarMult((*elgamal.CurvePoint)(targetPubKey), riBytes)
+	if err != nil {
+		return &share, nil, opError("ShareCal", err)
+	}
+
+	// 计算右侧点C，即-rKi+riU
+	share.C.Curve = priv.Curve
+	share.C.X, share.C.Y = curve.Add(rBkix, rBkiy, riUx, riUy)
+
+	return &share, ri, nil
+}
+
+// ShareProofGen generate the proof of a share for the random nonce ri and the private-key priv.
+// 份额计算证明生成: 使用计算份额生成的随机数ri和节点私钥priv，生成份额share的计算证明，并返回。
+//
+// 参数：
+//		随机数：	ri
+//		节点私钥：	priv
+//		份额：		share
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+// 返回：
+// 		证明pai：	c,r1,r2
+func ShareProofGen(ri *big.Int, priv *sm2.PrivateKey, share *elgamal.CipherText, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
+	// share.K = ri*B ; priv.PublicKey = priv*B ;
+	// targetPubKey*ri + (-rB*priv) = share.C
+	// y1 = ri
+	// y2 = priv.D
+	// B = B
+	// Y1 = riB = share.K
+	// Y2 = priv.PublicKey
+	// A1 = targetPubKey
+	// A2 = -rB
+	// A = share.C
+	B := elgamal.Generator(priv.Curve)
+	A2, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return nil, nil, nil, opError("ShareProofGen", err)
+	}
+
+	c, r1, r2, err := proof.Gen(ri, priv.D, B, &share.K, (*elgamal.CurvePoint)(&priv.PublicKey), (*elgamal.CurvePoint)(targetPubKey), A2, &share.C)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return c, r1, r2, err
+}
+
+// ShareProofGenNoB generate the proof of a share for the random nonce ri and the private-key priv.
+// 份额计算证明生成: 使用计算份额生成的随机数ri和节点私钥priv，生成份额share的计算证明，并返回。
+//
+// 参数：
+//		随机数：	ri
+//		节点私钥：	priv
+//		份额：		share
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+// 返回：
+// 		证明pai：	c,r1,r2
+func ShareProofGenNoB(ri *big.Int, priv *sm2.PrivateKey, share *elgamal.CipherText, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
+	c, r1, r2, _, err := shareProofGenNoB(proof.DefaultSuite, ri, priv, share, targetPubKey, rB)
+	return c, r1, r2, err
+}
+
+// shareProofGenNoB is ShareProofGenNoB with the challenge hash of suite, also
+// returning the proof commitment for batch verification.
+// 使用参数组suite的ShareProofGenNoB，并返回证明的承诺值以供批量验证。
+func shareProofGenNoB(suite proof.Suite, ri *big.Int, priv *sm2.PrivateKey, share *elgamal.CipherText, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, *proof.Commitment, error) {
+	// share.K = ri*B ; priv.PublicKey = priv*B ;
+	// targetPubKey*ri + (-rB*priv) = share.C
+	// y1 = ri
+	// y2 = priv.D
+	// (B = B)
+	// Y1 = riB = share.K
+	// Y2 = priv.PublicKey
+	// A1 = targetPubKey
+	// A2 = -rB
+	// A = share.C
+	A2, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return nil, nil, nil, nil, opError("ShareProofGenNoB", err)
+	}
+
+	c, r1, r2, T, err := suite.GenNoBWithCommitment(ri, priv.D, &share.K, (*elgamal.CurvePoint)(&priv.PublicKey), (*elgamal.CurvePoint)(targetPubKey), A2, &share.C)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return c, r1, r2, T, err
+}
+
+// ShareProofVry verify the proof pai=(c,r1,r2) for the calculation of the share.
+// 份额证明验证: 验证证明pai=(c,r1,r2)是否能够证明份额share是由随机数ri和节点私钥priv计算得来，即公开点(share,targetPubKey,rB)满足约束
+//     {share.K = ri*B ; priv.PublicKey = priv*B ;
+//      targetPubKey*ri + (-rB*priv) = share.C}，
+// 并返回。
+//
+// 参数：
+//		证明pai：	c,r1,r2
+//		份额：		share
+//		节点公钥：	nodePubKey
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+// 返回：
+// 		验证结果：	bool
+func ShareProofVry(c, r1, r2 *big.Int, share *elgamal.CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (bool, error) {
+	// share.K = ri*B ; priv.PublicKey = priv*B ;
+	// targetPubKey*ri + (-rB*priv) = share.C
+	// c,r1,r2 = c,r1,r2
+	// B = B
+	// Y1 = riB = share.K
+	// Y2 = nodePubKey
+	// A1 = targetPubKey
+	// A2 = -rB
+	// A = share.C
+	if err := elgamal.CheckCipherText(share); err != nil {
+		return false, opError("ShareProofVry", err)
+	}
+	if err := elgamal.CheckPoint(rB); err != nil {
+		return false, opError("ShareProofVry", err)
+	}
+
+	B := elgamal.Generator(targetPubKey.Curve)
+	A2, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return false, opError("ShareProofVry", err)
+	}
+
+	flag, err := proof.Verify(c, r1, r2, B, &share.K, (*elgamal.CurvePoint)(nodePubKey), (*elgamal.CurvePoint)(targetPubKey), A2, &share.C)
+	if err != nil {
+		return false, err
+	}
+
+	return flag, err
+}
+
+// ShareProofVryNoB verify the proof pai=(c,r1,r2) for the calculation of the share.
+// 份额证明验证: 验证证明pai=(c,r1,r2)是否能够证明份额share是由随机数ri和节点私钥priv计算得来，即公开点(share,targetPubKey,rB)满足约束
+//     {share.K = ri*B ; priv.PublicKey = priv*B ;
+//      targetPubKey*ri + (-rB*priv) = share.C}，
+// 并返回。
+//
+// 参数：
+//		证明pai：	c,r1,r2
+//		份额：		share
+//		节点公钥：	nodePubKey
+//		目标公钥：	targetPubKey
+//		密文左侧点： rB
+// 返回：
+// 		验证结果：	bool
+func ShareProofVryNoB(c, r1, r2 *big.Int, share *elgamal.CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (bool, error) {
+	return shareProofVryNoB(proof.DefaultSuite, c, r1, r2, share, nodePubKey, targetPubKey, rB)
+}
+
+// shareProofVryNoB is ShareProofVryNoB with the challenge hash of suite.
+// 使用参数组suite的ShareProofVryNoB。
+func shareProofVryNoB(suite proof.Suite, c, r1, r2 *big.Int, share *elgamal.CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (bool, error) {
+	// share.K = ri*B ; priv.PublicKey = priv*B ;
+	// targetPubKey*ri + (-rB*priv) = share.C
+	// c,r1,r2 = c,r1,r2
+	// (B = B)
+	// Y1 = riB = share.K
+	// Y2 = nodePubKey
+	// A1 = targetPubKey
+	// A2 = -rB
+	// A = share.C
+	if err := elgamal.CheckCipherText(share); err != nil {
+		return false, opError("ShareProofVryNoB", err)
+	}
+	if err := elgamal.CheckPoint(rB); err != nil {
+		return false, opError("ShareProofVryNoB", err)
+	}
+
+	A2 := elgamal.GetPoint()
+	defer elgamal.PutPoint(A2)
+	if err := elgamal.NegPointTo(A2, rB); err != nil {
+		return false, opError("ShareProofVryNoB", err)
+	}
+
+	flag, err := suite.VerifyNoB(c, r1, r2, &share.K, (*elgamal.CurvePoint)(nodePubKey), (*elgamal.CurvePoint)(targetPubKey), A2, &share.C)
+	if err != nil {
+		return false, err
+	}
+
+	return flag, err
+}
+
+// ShareReplace uses shares to convert rct(raw ciphertext) to a new ciphertext.
+// 份额置换：使用份额置换原密文为新密文，并返回。
+//
+// 参数：
+//		份额slice	shares
+//		密文原文	rct
+// 返回：
+// 		新密文
+func ShareReplace(shares *elgamal.CipherVector, rct *elgamal.CipherText) (*elgamal.CipherText, error) {
+	// 检查置换份额数量与有效性
+	lens := len(*shares)
+	if lens == 0 {
+		return nil, opError("ShareReplace", elgamal.ErrEmpty)
+	}
+	ctx := ec.NewContext((*shares)[0].K.Curve)
+	for i := 0; i < lens; i++ {
+		if err := checkShare(ctx, &(*shares)[i]); err != nil {
+			return nil, itemError("ShareReplace", "share", i, err)
+		}
+	}
+	if err := elgamal.CheckCipherText(rct); err != nil {
+		return nil, opError("ShareReplace", err)
+	}
+
+	// 聚合份额至sigma，通过sigma置换rct得到目标ct
+	sigma := sumShares(ctx, *shares)
+	return replaceWith(&sigma, &(*shares)[0], rct), nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestKeySwitch(t *testing.T) {
+	////////////////////////
+	// 模拟ks server数量/////
+	lens := 10
+	////////////////////////
+
+	// 生成ks server公私钥对并聚合
+	pks := make([]sm2.PrivateKey, lens)
+	Pks := make([]sm2.PublicKey, lens)
+	for i := 0; i < lens; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks[i] = *priv
+		Pks[i] = priv.PublicKey
+	}
+	collPk := CollPubKey(Pks)
+	collSk := CollPrivKey(pks)
+	if 0 != collPk.X.Cmp(collSk.X) || 0 != collPk.Y.Cmp(collSk.Y) {
+		t.Fatal("collective public key differs from collective private key")
+	}
+
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(collPk, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := sm2.GenerateKey(rand.Reader) // 请求者密钥对
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 各ks server计算份额及证明
+	shares := make(elgamal.CipherVector, lens)
+	for i := 0; i < lens; i++ {
+		share, ri, err := ShareCal(&q.PublicKey, &ct.K, &pks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = *share
+
+		c, r1, r2, err := ShareProofGen(ri, &pks[i], share, &q.PublicKey, &ct.K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag, err := ShareProofVry(c, r1, r2, share, &pks[i].PublicKey, &q.PublicKey, &ct.K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !flag {
+			t.Fatalf("share %d: proof failed to verify", i)
+		}
+
+		c, r1, r2, err = ShareProofGenNoB(ri, &pks[i], share, &q.PublicKey, &ct.K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag, err = ShareProofVryNoB(c, r1, r2, share, &pks[i].PublicKey, &q.PublicKey, &ct.K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !flag {
+			t.Fatalf("share %d: NoB proof failed to verify", i)
+		}
+	}
+
+	// 请求者置换密文后解密
+	tct, err := ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := elgamal.PointDecrypt(tct, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(pt.X) || 0 != D.Y.Cmp(pt.Y) {
+		t.Fatal("requester failed to decrypt replaced ciphertext")
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"io"
+	"math"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// LightVerifyShares is a light verification mode for requesters that cannot afford
+// to verify every share proof, such as mobile devices. It checks one aggregate
+// consistency equation over all bundles, that agg proves exactly the sum of their
+// shares, and fully verifies the proofs of only k bundles sampled uniformly at
+// random. The aggregate equation guarantees the summed share used for decryption;
+// the sample catches each faulty bundle with the probability given by
+// LightMissProbability, so choose k with LightSampleSize. A k that is not smaller
+// than len(bundles) verifies every proof. A nil random uses crypto/rand.
+// 轻量验证：供无法逐个验证全部份额证明的请求者（如移动设备）使用。检查一个覆盖全部份额包的聚合
+// 一致性方程，即agg恰好证明各份额之和，并仅对均匀随机抽取的k个份额包完整验证其证明。聚合方程保证
+// 解密所用的份额和正确；抽样发现错误份额包的概率见LightMissProbability，可据此以LightSampleSize
+// 选取k。k不小于len(bundles)时验证全部证明。random为nil时使用crypto/rand。
+//
+// 参数：
+//		份额包slice：	bundles
+//		聚合份额证明：	agg
+//		抽样数量：		k
+//		随机源：		random
+// 返回：
+// 		验证结果：	bool
+func LightVerifyShares(bundles []*ShareBundle, agg *AggregateShareProof, k int, random io.Reader) (bool, error) {
+	if len(bundles) == 0 {
+		return false, opError("LightVerifyShares", elgamal.ErrEmpty)
+	}
+	if agg == nil {
+		return false, opError("LightVerifyShares", ErrIncompleteProof)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	first := bundles[0]
+	if first == nil || first.TargetPubKey == nil || first.RB == nil {
+		return false, itemError("LightVerifyShares", "bundle", 0, ErrIncompleteStatement)
+	}
+	nodePubKeys := make([]*sm2.PublicKey, len(bundles))
+	for i, b := range bundles {
+		if b == nil || b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+			return false, itemError("LightVerifyShares", "bundle", i, ErrIncompleteStatement)
+		}
+		if !sameKey(b.TargetPubKey, first.TargetPubKey) || !samePoint(b.RB, first.RB) {
+			return false, itemError("LightVerifyShares", "bundle", i, ErrStatementMismatch)
+		}
+		if elgamal.CheckCipherText(&b.Share) != nil {
+			return false, nil
+		}
+		nodePubKeys[i] = b.NodePubKey
+	}
+
+	// 聚合一致性方程：agg中的份额须等于各份额之和，且agg对节点公钥之和成立
+	curve := first.TargetPubKey.Curve
+	sum := first.Share
+	for _, b := range bundles[1:] {
+		sum.K.X, sum.K.Y = ec.Add(curve, sum.K.X, sum.K.Y, b.Share.K.X, b.Share.K.Y)
+		sum.C.X, sum.C.Y = ec.Add(curve, sum.C.X, sum.C.Y, b.Share.C.X, b.Share.C.Y)
+	}
+	if !samePoint(&sum.K, &agg.Share.K) || !samePoint(&sum.C, &agg.Share.C) {
+		return false, nil
+	}
+	if ok, err := agg.Verify(nodePubKeys, first.TargetPubKey, first.RB); err != nil || !ok {
+		return false, err
+	}
+
+	// 抽样：部分Fisher-Yates置换的前k项
+	n := len(bundles)
+	if k > n {
+		k = n
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	for i := 0; i < k; i++ {
+		r, err := rand.Int(random, big.NewInt(int64(n-i)))
+		if err != nil {
+			return false, opError("LightVerifyShares", err)
+		}
+		j := i + int(r.Int64())
+		idx[i], idx[j] = idx[j], idx[i]
+		if ok, err := bundles[idx[i]].Verify(); err != nil || !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// LightMissProbability returns the probability that LightVerifyShares, sampling k
+// of n bundles, misses all of faulty invalid proofs: C(n-faulty,k)/C(n,k). It is
+// the soundness error of the sampled part; it is 0 when k+faulty exceeds n.
+// 漏检概率：LightVerifyShares从n个份额包中抽取k个时，未抽中全部faulty个无效证明的概率，
+// 即C(n-faulty,k)/C(n,k)，为抽样部分的可靠性误差；k+faulty大于n时为0。
+//
+// 参数：
+//		份额包数量：	n
+//		无效证明数量：	faulty
+//		抽样数量：		k
+// 返回：
+// 		漏检概率
+func LightMissProbability(n, faulty, k int) float64 {
+	if faulty <= 0 {
+		return 1
+	}
+	if k <= 0 {
+		return 1
+	}
+	if k+faulty > n {
+		return 0
+	}
+	// C(n-f,k)/C(n,k) = prod_{i<k} (n-f-i)/(n-i)
+	p := 1.0
+	for i := 0; i < k; i++ {
+		p *= float64(n-faulty-i) / float64(n-i)
+	}
+	return p
+}
+
+// LightSampleSize returns the smallest k for which LightMissProbability(n, faulty, k)
+// is at most bound, the number of proofs a light verifier must check to catch
+// faulty invalid ones except with probability bound. It returns n when no smaller
+// sample suffices, or faulty is not positive.
+// 抽样数量：返回使LightMissProbability(n, faulty, k)不超过bound的最小k，即轻量验证者须检查的
+// 证明数量，使faulty个无效证明的漏检概率不超过bound。没有更小的样本满足要求或faulty不为正时返回n。
+//
+// 参数：
+//		份额包数量：	n
+//		无效证明数量：	faulty
+//		漏检概率上界：	bound
+// 返回：
+// 		抽样数量
+func LightSampleSize(n, faulty int, bound float64) int {
+	if faulty <= 0 || math.IsNaN(bound) {
+		return n
+	}
+	p := 1.0
+	for k := 0; k < n; k++ {
+		if p <= bound {
+			return k
+		}
+		p *= float64(n-faulty-k) / float64(n-k)
+	}
+	return n
+}
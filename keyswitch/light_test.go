@@ -0,0 +1,174 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// lightSession 为n个节点生成份额包及其份额和的聚合证明。
+func lightSession(t *testing.T, n int) ([]*ShareBundle, *AggregateShareProof) {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	agg, err := NewProofAggregator(proof.DefaultSuite, &q.PublicKey, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundles := make([]*ShareBundle, n)
+	provers := make([]*proof.Prover, n)
+	for i := 0; i < n; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, ri, err := ShareCal(&q.PublicKey, rB, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, r1, r2, T, err := shareProofGenNoB(proof.DefaultSuite, ri, priv, share, &q.PublicKey, rB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundles[i] = &ShareBundle{
+			Share:        *share,
+			Proof:        NewPai(c, r1, r2),
+			NodePubKey:   &priv.PublicKey,
+			TargetPubKey: &q.PublicKey,
+			RB:           rB,
+			Commitment:   T,
+		}
+		if provers[i], err = NewShareProver(ri, priv, &q.PublicKey, rB); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := agg.Add(share, &priv.PublicKey, provers[i].Commitment()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := agg.Challenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range provers {
+		r1, r2, err := p.Respond(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := agg.AddResponse(i, r1, r2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ap, err := agg.Proof()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundles, ap
+}
+
+func TestLightVerifyShares(t *testing.T) {
+	bundles, agg := lightSession(t, 5)
+
+	for _, k := range []int{0, 2, 5, 9} {
+		ok, err := LightVerifyShares(bundles, agg, k, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("k=%d: light verification failed", k)
+		}
+	}
+
+	// 篡改一个证明：抽样覆盖全部时必被发现
+	bad := *bundles[3]
+	bad.Proof = NewPai(big.NewInt(1), big.NewInt(2), big.NewInt(3))
+	tampered := append([]*ShareBundle{}, bundles...)
+	tampered[3] = &bad
+	if ok, err := LightVerifyShares(tampered, agg, len(tampered), nil); err != nil || ok {
+		t.Fatal("tampered proof passed full sampling")
+	}
+
+	// 替换一个份额：聚合方程不再成立
+	other, _ := lightSession(t, 1)
+	swapped := append([]*ShareBundle{}, bundles...)
+	moved := *other[0]
+	moved.TargetPubKey, moved.RB = bundles[0].TargetPubKey, bundles[0].RB
+	swapped[1] = &moved
+	if ok, err := LightVerifyShares(swapped, agg, 0, nil); err != nil || ok {
+		t.Fatal("inconsistent shares passed the aggregate equation")
+	}
+
+	// 缺少一个份额包
+	if ok, err := LightVerifyShares(bundles[1:], agg, 0, nil); err != nil || ok {
+		t.Fatal("light verification passed without a bundle")
+	}
+
+	mismatch := append([]*ShareBundle{}, bundles...)
+	mismatch[2] = other[0]
+	if _, err := LightVerifyShares(mismatch, agg, 0, nil); !errors.Is(err, ErrStatementMismatch) {
+		t.Fatalf("got %v, want ErrStatementMismatch", err)
+	}
+	if _, err := LightVerifyShares(bundles, nil, 1, nil); !errors.Is(err, ErrIncompleteProof) {
+		t.Fatalf("got %v, want ErrIncompleteProof", err)
+	}
+	if _, err := LightVerifyShares(nil, agg, 1, nil); !errors.Is(err, elgamal.ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+}
+
+func TestLightSampleSize(t *testing.T) {
+	if p := LightMissProbability(10, 1, 5); p != 0.5 {
+		t.Fatalf("miss probability %v, want 0.5", p)
+	}
+	if p := LightMissProbability(10, 3, 8); p != 0 {
+		t.Fatalf("miss probability %v, want 0", p)
+	}
+	if p := LightMissProbability(10, 2, 0); p != 1 {
+		t.Fatalf("miss probability %v, want 1", p)
+	}
+
+	cases := []struct {
+		n, faulty int
+		bound     float64
+		want      int
+	}{
+		{10, 1, 0.5, 5},
+		{10, 1, 0, 10},
+		{100, 10, 0.01, 36},
+		{100, 50, 1e-6, 18},
+		{10, 0, 0.1, 10},
+		{10, 3, 1, 0},
+	}
+	for _, c := range cases {
+		k := LightSampleSize(c.n, c.faulty, c.bound)
+		if k != c.want {
+			t.Fatalf("LightSampleSize(%d,%d,%v)=%d, want %d", c.n, c.faulty, c.bound, k, c.want)
+		}
+		if c.faulty > 0 && LightMissProbability(c.n, c.faulty, k) > c.bound {
+			t.Fatalf("sample of %d exceeds bound %v", k, c.bound)
+		}
+	}
+}
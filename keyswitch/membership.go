@@ -0,0 +1,315 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Membership changes update the committee without the full re-key of Rekey, in
+// one of two ways. SplitNodeKey and MergeNodeKey keep the collective key: a leaving
+// server splits its key among the remaining ones, or a sponsor splits a part of its
+// key off for a joining one, so stored ciphertexts stay valid as they are. A
+// MembershipChange instead adds the key of a joining server to, or removes that of
+// a leaving one from, the collective key, and that server alone re-wraps the stored
+// ciphertexts with a proof, so a joiner brings key material of its own and a leaver
+// takes its key with it.
+// 成员变更无需Rekey的完整重新密钥化即可更新委员会，有两种方式。SplitNodeKey与MergeNodeKey保持
+// 聚合公钥不变：退出的ks server将其私钥分割给其余ks server，或由引荐者分出其私钥的一部分给加入者，
+// 存储的密文原样有效。MembershipChange则将加入者的公钥加入聚合公钥，或将退出者的公钥从中去除，
+// 并仅由该ks server附证明地重新包装存储的密文，使加入者带来自己的密钥材料，退出者带走其密钥。
+
+// membershipContext binds re-wrap proofs to their protocol.
+// 重新包装证明的上下文。
+const membershipContext = "ppks-membership-rewrap"
+
+// SplitNodeKey splits the server key priv additively into n keys with the same sum,
+// for the remaining servers when it leaves (or for itself and a joiner), which add
+// them to their keys with MergeNodeKey. The public keys of the parts add up to that
+// of priv, which anyone can check, and the collective key does not change. The
+// server must erase priv afterwards. A nil random uses crypto/rand.
+// 节点私钥分割：将ks server的私钥priv加性分割为和不变的n份，在其退出时交给其余ks server
+// （或分给自己与加入者），各方以MergeNodeKey将其加到自己的私钥上。各份公钥之和等于priv的公钥，
+// 任何人均可检验，聚合公钥不变。该ks server此后须擦除priv。random为nil时使用crypto/rand。
+//
+// 参数：
+//		私钥	priv
+//		份数	n
+//		随机源	random
+// 返回：
+// 		私钥份额slice
+func SplitNodeKey(priv *sm2.PrivateKey, n int, random io.Reader) ([]*sm2.PrivateKey, error) {
+	if priv == nil || priv.D == nil || priv.Curve == nil {
+		return nil, opError("SplitNodeKey", elgamal.ErrEmpty)
+	}
+	if n < 1 {
+		return nil, opError("SplitNodeKey", ErrInvalidThreshold)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+
+	curve := priv.Curve
+	N := curve.Params().N
+	parts := make([]*sm2.PrivateKey, n)
+	last := new(big.Int).Set(priv.D)
+	for i := range parts {
+		d := last
+		if i < n-1 {
+			r, err := ec.RandFieldElement(curve, random)
+			if err != nil {
+				return nil, opError("SplitNodeKey", err)
+			}
+			d = r
+			last.Sub(last, r)
+		}
+		if i == n-1 {
+			// 最后一份为d减去其余各份之和，为0时无法作为私钥
+			d.Mod(d, N)
+			if d.Sign() == 0 {
+				return nil, opError("SplitNodeKey", elgamal.ErrPointNotOnCurve)
+			}
+		}
+		k := new(sm2.PrivateKey)
+		k.Curve = curve
+		k.D = d
+		k.X, k.Y = curve.ScalarBaseMult(d.Bytes())
+		parts[i] = k
+	}
+	return parts, nil
+}
+
+// MergeNodeKey returns the server key priv with the part of SplitNodeKey added.
+// 节点私钥合并：返回加上SplitNodeKey所分份额part后的ks server私钥。
+//
+// 参数：
+//		私钥		priv
+//		私钥份额	part
+// 返回：
+// 		合并后的私钥
+func MergeNodeKey(priv, part *sm2.PrivateKey) (*sm2.PrivateKey, error) {
+	if priv == nil || priv.D == nil || priv.Curve == nil || part == nil || part.D == nil {
+		return nil, opError("MergeNodeKey", elgamal.ErrEmpty)
+	}
+	if part.Curve == nil || part.Curve.Params() != priv.Curve.Params() {
+		return nil, opError("MergeNodeKey", elgamal.ErrCurveMismatch)
+	}
+	curve := priv.Curve
+	d := new(big.Int).Add(priv.D, part.D)
+	d.Mod(d, curve.Params().N)
+	if d.Sign() == 0 {
+		return nil, opError("MergeNodeKey", elgamal.ErrPointNotOnCurve)
+	}
+	k := new(sm2.PrivateKey)
+	k.Curve = curve
+	k.D = d
+	k.X, k.Y = curve.ScalarBaseMult(d.Bytes())
+	return k, nil
+}
+
+// MembershipChange is the change of the collective key when the server with
+// NodePubKey joins (Join) or leaves the committee: NewPubKey is OldPubKey plus or
+// minus NodePubKey.
+// 成员变更：公钥为NodePubKey的ks server加入（Join）或退出委员会时聚合公钥的变化，
+// NewPubKey为OldPubKey加上或减去NodePubKey。
+type MembershipChange struct {
+	Join       bool
+	NodePubKey *sm2.PublicKey
+	OldPubKey  *sm2.PublicKey
+	NewPubKey  *sm2.PublicKey
+}
+
+// NewMembershipChange returns the change of the collective key oldPubKey when the
+// server with nodePubKey joins (join) or leaves. A change that would leave no key,
+// i.e. the last server leaving, is refused.
+// 新建成员变更：返回公钥为nodePubKey的ks server加入（join）或退出时聚合公钥oldPubKey的变化。
+// 变更后无聚合公钥（即最后一个ks server退出）时拒绝。
+//
+// 参数：
+//		旧聚合公钥	oldPubKey
+//		节点公钥	nodePubKey
+//		是否加入	join
+// 返回：
+// 		成员变更
+func NewMembershipChange(oldPubKey, nodePubKey *sm2.PublicKey, join bool) (*MembershipChange, error) {
+	newPub, err := changedPubKey("NewMembershipChange", oldPubKey, nodePubKey, join)
+	if err != nil {
+		return nil, err
+	}
+	return &MembershipChange{Join: join, NodePubKey: nodePubKey, OldPubKey: oldPubKey, NewPubKey: newPub}, nil
+}
+
+// changedPubKey returns oldPubKey plus or minus nodePubKey.
+// 返回oldPubKey加上或减去nodePubKey。
+func changedPubKey(op string, oldPubKey, nodePubKey *sm2.PublicKey, join bool) (*sm2.PublicKey, error) {
+	for i, pub := range []*sm2.PublicKey{oldPubKey, nodePubKey} {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return nil, itemError(op, "key", i, err)
+		}
+	}
+	if oldPubKey.Curve.Params() != nodePubKey.Curve.Params() {
+		return nil, opError(op, elgamal.ErrCurveMismatch)
+	}
+	curve := oldPubKey.Curve
+	x, y := nodePubKey.X, nodePubKey.Y
+	if !join {
+		x, y = ec.Neg(curve, x, y)
+	}
+	pub := new(sm2.PublicKey)
+	pub.Curve = curve
+	pub.X, pub.Y = ec.Add(curve, oldPubKey.X, oldPubKey.Y, x, y)
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, opError(op, err)
+	}
+	return pub, nil
+}
+
+// check reports ErrProofFailed unless NewPubKey follows from the other fields.
+// NewPubKey与其余字段不符时返回ErrProofFailed。
+func (m *MembershipChange) check(op string) error {
+	newPub, err := changedPubKey(op, m.OldPubKey, m.NodePubKey, m.Join)
+	if err != nil {
+		return err
+	}
+	if !sameKey(newPub, m.NewPubKey) {
+		return opError(op, ErrProofFailed)
+	}
+	return nil
+}
+
+// rewrapRelation returns the relation {NodePubKey=x*B, D_j=x*K_j}, where D_j is
+// C'_j-C_j on joining and C_j-C'_j on leaving.
+// 返回关系{NodePubKey=x*B, D_j=x*K_j}，加入时D_j = C'_j-C_j，退出时D_j = C_j-C'_j。
+func (m *MembershipChange) rewrapRelation(op string, cts, rewrapped elgamal.CipherVector) (*proof.Relation, error) {
+	if len(cts) == 0 {
+		return nil, opError(op, elgamal.ErrEmpty)
+	}
+	if len(rewrapped) != len(cts) {
+		return nil, opError(op, elgamal.ErrLengthMismatch)
+	}
+	curve := m.NodePubKey.Curve
+	rel := &proof.Relation{
+		Bases:   [][]*elgamal.CurvePoint{{elgamal.Generator(curve)}},
+		Targets: []*elgamal.CurvePoint{(*elgamal.CurvePoint)(m.NodePubKey)},
+	}
+	for j := range cts {
+		from, to := &cts[j], &rewrapped[j]
+		if err := elgamal.CheckCipherText(from); err != nil {
+			return nil, itemError(op, "ciphertext", j, err)
+		}
+		if err := elgamal.CheckCipherText(to); err != nil || !samePoint(&from.K, &to.K) {
+			return nil, itemError(op, "ciphertext", j, ErrProofFailed)
+		}
+		if !m.Join {
+			from, to = to, from
+		}
+		negC, err := elgamal.NegPoint(&from.C)
+		if err != nil {
+			return nil, itemError(op, "ciphertext", j, err)
+		}
+		D := &elgamal.CurvePoint{Curve: curve}
+		D.X, D.Y = ec.Add(curve, to.C.X, to.C.Y, negC.X, negC.Y)
+		if D.IsInfinity() {
+			return nil, itemError(op, "ciphertext", j, ErrProofFailed)
+		}
+		rel.Bases = append(rel.Bases, []*elgamal.CurvePoint{&cts[j].K})
+		rel.Targets = append(rel.Targets, D)
+	}
+	return rel, nil
+}
+
+// Rewrap re-wraps cts from OldPubKey to NewPubKey with the key priv of the joining
+// or leaving server, C' = C + x*K or C - x*K, and proves it with one proof for all
+// ciphertexts. A leaving server must re-wrap before it goes.
+// 重新包装：由加入或退出的ks server以其私钥priv将cts由OldPubKey重新包装到NewPubKey下，
+// 即C' = C + x*K或C - x*K，并以一个证明覆盖全部密文。退出的ks server须在退出前完成重新包装。
+//
+// 参数：
+//		私钥		priv
+//		密文向量	cts
+// 返回：
+// 		NewPubKey下的密文向量
+//		证明
+func (m *MembershipChange) Rewrap(priv *sm2.PrivateKey, cts elgamal.CipherVector) (elgamal.CipherVector, *proof.LinearProof, error) {
+	if err := m.check("MembershipChange.Rewrap"); err != nil {
+		return nil, nil, err
+	}
+	if priv == nil || priv.D == nil || !sameKey(&priv.PublicKey, m.NodePubKey) {
+		return nil, nil, opError("MembershipChange.Rewrap", ErrUnsupportedKey)
+	}
+	if len(cts) == 0 {
+		return nil, nil, opError("MembershipChange.Rewrap", elgamal.ErrEmpty)
+	}
+
+	curve := priv.Curve
+	out := make(elgamal.CipherVector, len(cts))
+	for j := range cts {
+		if err := elgamal.CheckCipherText(&cts[j]); err != nil {
+			return nil, nil, itemError("MembershipChange.Rewrap", "ciphertext", j, err)
+		}
+		K := &cts[j].K
+		dx, dy := curve.ScalarMult(K.X, K.Y, priv.D.Bytes())
+		if !m.Join {
+			dx, dy = ec.Neg(curve, dx, dy)
+		}
+		out[j].K = *K
+		out[j].C.Curve = curve
+		out[j].C.X, out[j].C.Y = ec.Add(curve, cts[j].C.X, cts[j].C.Y, dx, dy)
+	}
+
+	rel, err := m.rewrapRelation("MembershipChange.Rewrap", cts, out)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := proof.ProveLinear(membershipContext, rel, []*big.Int{priv.D})
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, p, nil
+}
+
+// VerifyRewrap verifies the proof p of Rewrap that rewrapped is cts re-wrapped
+// from OldPubKey to NewPubKey by the server with NodePubKey.
+// 重新包装验证：验证Rewrap生成的证明p，即rewrapped为公钥为NodePubKey的ks server
+// 将cts由OldPubKey重新包装到NewPubKey下的结果。
+//
+// 参数：
+//		密文向量			cts
+//		重新包装后的密文向量	rewrapped
+//		证明				p
+// 返回：
+// 		验证结果
+func (m *MembershipChange) VerifyRewrap(cts, rewrapped elgamal.CipherVector, p *proof.LinearProof) (bool, error) {
+	if err := m.check("MembershipChange.VerifyRewrap"); err != nil {
+		return false, err
+	}
+	if p == nil {
+		return false, nil
+	}
+	rel, err := m.rewrapRelation("MembershipChange.VerifyRewrap", cts, rewrapped)
+	if err != nil {
+		return false, err
+	}
+	return proof.VerifyLinear(membershipContext, rel, p)
+}
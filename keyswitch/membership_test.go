@@ -0,0 +1,153 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// sumKey returns the private key with the sum of the keys privs.
+func sumKey(privs []*sm2.PrivateKey) *sm2.PrivateKey {
+	d := new(big.Int)
+	for _, p := range privs {
+		d.Add(d, p.D)
+	}
+	k := new(sm2.PrivateKey)
+	k.Curve = privs[0].Curve
+	k.D = d.Mod(d, k.Curve.Params().N)
+	k.X, k.Y = k.Curve.ScalarBaseMult(k.D.Bytes())
+	return k
+}
+
+func TestMembership(t *testing.T) {
+	var privs []*sm2.PrivateKey
+	var pubs []*sm2.PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, &priv.PublicKey)
+	}
+	collPub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pts elgamal.PointVector
+	for i := 0; i < 3; i++ {
+		pts = append(pts, *elgamal.GenPoint())
+	}
+	cts, err := elgamal.VectorEncrypt(collPub, &pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypts := func(privs []*sm2.PrivateKey, cts elgamal.CipherVector) bool {
+		got, err := elgamal.VectorDecrypt(sumKey(privs), &cts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range pts {
+			if !samePoint(&(*got)[j], &pts[j]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// 节点2退出，将私钥分给节点0、1，聚合公钥与密文不变
+	parts, err := SplitNodeKey(privs[2], 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partPub, err := AggregatePubKeys([]*sm2.PublicKey{&parts[0].PublicKey, &parts[1].PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameKey(partPub, pubs[2]) {
+		t.Fatal("parts do not add up to the leaving key")
+	}
+	for i := 0; i < 2; i++ {
+		if privs[i], err = MergeNodeKey(privs[i], parts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	privs = privs[:2]
+	if !decrypts(privs, *cts) {
+		t.Fatal("split key changed the collective key")
+	}
+
+	// 新节点以自己的私钥加入，并重新包装密文
+	joiner, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	join, err := NewMembershipChange(collPub, &joiner.PublicKey, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined, p, err := join.Rewrap(joiner, *cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := join.VerifyRewrap(*cts, joined, p); err != nil || !ok {
+		t.Fatalf("join re-wrap failed to verify: %v", err)
+	}
+	privs = append(privs, joiner)
+	if !decrypts(privs, joined) {
+		t.Fatal("joined ciphertexts do not decrypt")
+	}
+	if ok, _ := join.VerifyRewrap(*cts, *cts, p); ok {
+		t.Fatal("unchanged ciphertexts verified as re-wrapped")
+	}
+
+	// 节点0退出，重新包装后带走其私钥
+	leave, err := NewMembershipChange(join.NewPubKey, &privs[0].PublicKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, p, err := leave.Rewrap(privs[0], joined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := leave.VerifyRewrap(joined, left, p); err != nil || !ok {
+		t.Fatalf("leave re-wrap failed to verify: %v", err)
+	}
+	if !decrypts(privs[1:], left) {
+		t.Fatal("ciphertexts do not decrypt after leaving")
+	}
+
+	// 变更的公钥被篡改，或以他人私钥重新包装时拒绝
+	leave.NewPubKey = collPub
+	if _, err := leave.VerifyRewrap(joined, left, p); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+	if _, _, err := join.Rewrap(privs[1], *cts); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("got %v, want ErrUnsupportedKey", err)
+	}
+
+	// 最后一个节点不能退出
+	if _, err := NewMembershipChange(&joiner.PublicKey, &joiner.PublicKey, false); !errors.Is(err, elgamal.ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ShareCalMulti calculates and proves the shares related with rB for each of
+// targets with priv, returning one bundle per target in the same order. The
+// costly -k*rB is computed once and shared among the targets; each share still
+// has its own nonce ri.
+// 多目标份额计算：使用私钥priv，为targets中的每个目标公钥计算关于点rB的份额并生成证明，
+// 按相同顺序返回各目标的份额包。开销较大的-k*rB只计算一次，由各目标共用；每个份额仍使用各自的随机数ri。
+//
+// 参数：
+//		目标公钥slice	targets
+//		密文左侧点		rB
+//		私钥			priv
+// 返回：
+// 		份额包slice
+func ShareCalMulti(targets []*sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) ([]*ShareBundle, error) {
+	if len(targets) == 0 {
+		return nil, opError("ShareCalMulti", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint(rB); err != nil {
+		return nil, opError("ShareCalMulti", err)
+	}
+	for i, target := range targets {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(target)); err != nil {
+			return nil, itemError("ShareCalMulti", "target", i, err)
+		}
+	}
+
+	// 计算-rBki，各目标共用
+	curve := priv.Curve
+	nx, ny := curve.ScalarMult(rB.X, rB.Y, priv.D.Bytes())
+	nx, ny = ec.Neg(curve, nx, ny)
+
+	bundles := make([]*ShareBundle, len(targets))
+	for i, target := range targets {
+		ri, err := ec.RandFieldElement(curve, rand.Reader)
+		if err != nil {
+			return nil, opError("ShareCalMulti", err)
+		}
+		var share elgamal.CipherText
+		share.K.Curve, share.C.Curve = curve, curve
+		share.K.X, share.K.Y = curve.ScalarBaseMult(ri.Bytes())
+		ux, uy := curve.ScalarMult(target.X, target.Y, ri.Bytes())
+		share.C.X, share.C.Y = curve.Add(nx, ny, ux, uy)
+
+		c, r1, r2, T, err := shareProofGenNoB(proof.DefaultSuite, ri, priv, &share, target, rB)
+		if err != nil {
+			return nil, itemError("ShareCalMulti", "target", i, err)
+		}
+		bundles[i] = &ShareBundle{
+			Share:        share,
+			Proof:        NewPaiWithHash(c, r1, r2, proof.DefaultSuite.Hash),
+			NodePubKey:   &priv.PublicKey,
+			TargetPubKey: target,
+			RB:           rB,
+			Commitment:   T,
+		}
+	}
+	return bundles, nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareCalMulti(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(&priv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets := make([]*sm2.PrivateKey, 3)
+	pubs := make([]*sm2.PublicKey, len(targets))
+	for i := range targets {
+		if targets[i], err = sm2.GenerateKey(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		pubs[i] = &targets[i].PublicKey
+	}
+
+	bundles, err := ShareCalMulti(pubs, &ct.K, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != len(pubs) {
+		t.Fatalf("got %d bundles, want %d", len(bundles), len(pubs))
+	}
+	if failed, err := BatchVerifyShares(bundles); err != nil || len(failed) != 0 {
+		t.Fatalf("bundles failed to verify: %v %v", failed, err)
+	}
+	for i, b := range bundles {
+		if b.TargetPubKey != pubs[i] {
+			t.Fatalf("bundle %d is for another target", i)
+		}
+		shares := elgamal.CipherVector{b.Share}
+		switched, err := ShareReplace(&shares, ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := elgamal.PointDecrypt(switched, targets[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+			t.Fatalf("target %d decrypted a different point", i)
+		}
+	}
+
+	bad := *pubs[1]
+	bad.Y = bad.X
+	if _, err := ShareCalMulti([]*sm2.PublicKey{pubs[0], &bad}, &ct.K, priv); err == nil {
+		t.Fatal("expected error for a target off the curve")
+	}
+	if _, err := ShareCalMulti(nil, &ct.K, priv); err == nil {
+		t.Fatal("expected error for no targets")
+	}
+}
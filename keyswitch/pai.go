@@ -0,0 +1,162 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"fmt"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Pai is a non-interactive proof pai=(c,r1,r2) produced by proof.Gen, together
+// with the challenge hash it was made with.
+// 证明，零知识证明生成函数输出的证明pai=(c,r1,r2)，及生成证明所用的挑战哈希。
+type Pai struct {
+	c, r1, r2 *(big.Int)
+	hash      proof.HashID
+}
+
+// paiLen is the length of a serialized Pai: the hash ID, then c, r1 and r2.
+// 序列化证明的字节长度：挑战哈希标识及c、r1、r2。
+const paiLen = 1 + 3*32
+
+// PaiVector is a slice of proofs, one per node in a key-switch session.
+// 证明向量，一次密钥置换中各节点的证明slice。
+type PaiVector []Pai
+
+// NewPai bundles the proof values (c,r1,r2) returned by ShareProofGen into a Pai.
+// 构造证明：将ShareProofGen返回的(c,r1,r2)打包为Pai。
+//
+// 参数：
+//		证明pai：	c,r1,r2
+// 返回：
+// 		证明Pai
+func NewPai(c, r1, r2 *big.Int) Pai {
+	return Pai{c: c, r1: r1, r2: r2, hash: proof.HashSM3}
+}
+
+// NewPaiWithHash is NewPai for a proof made with the challenge hash id.
+// 构造证明：同NewPai，证明以挑战哈希id生成。
+func NewPaiWithHash(c, r1, r2 *big.Int, id proof.HashID) Pai {
+	return Pai{c: c, r1: r1, r2: r2, hash: id}
+}
+
+// String returns the proof as short hex prefixes of (c,r1,r2).
+// 以(c,r1,r2)的十六进制短前缀表示证明。
+func (p Pai) String() string {
+	return fmt.Sprintf("Pai(c=%s, r1=%s, r2=%s)", ec.ShortHex(p.c), ec.ShortHex(p.r1), ec.ShortHex(p.r2))
+}
+
+// Hash returns the challenge hash p was made with.
+// 返回生成证明p所用的挑战哈希。
+func (p *Pai) Hash() proof.HashID {
+	if p.hash == 0 {
+		return proof.HashSM3
+	}
+	return p.hash
+}
+
+// suite returns the proof suite matching p.
+// 返回与证明p对应的参数组。
+func (p *Pai) suite() proof.Suite {
+	return proof.Suite{Hash: p.Hash()}
+}
+
+// MarshalBinary encodes p as its hash ID followed by c, r1 and r2, 32 bytes each.
+// 序列化证明：依次编码挑战哈希标识，以及各32字节的c、r1、r2。
+func (p *Pai) MarshalBinary() ([]byte, error) {
+	if p.c == nil || p.r1 == nil || p.r2 == nil {
+		return nil, opError("Pai.MarshalBinary", ErrIncompleteProof)
+	}
+	for _, v := range []*big.Int{p.c, p.r1, p.r2} {
+		if v.Sign() < 0 || v.BitLen() > 256 {
+			return nil, opError("Pai.MarshalBinary", ErrInvalidProofEncoding)
+		}
+	}
+	b := make([]byte, paiLen)
+	b[0] = byte(p.Hash())
+	p.c.FillBytes(b[1:33])
+	p.r1.FillBytes(b[33:65])
+	p.r2.FillBytes(b[65:97])
+	return b, nil
+}
+
+// UnmarshalBinary decodes a proof encoded by MarshalBinary.
+// 反序列化证明：解析MarshalBinary编码的证明。
+func (p *Pai) UnmarshalBinary(b []byte) error {
+	if len(b) != paiLen {
+		return opError("Pai.UnmarshalBinary", ErrInvalidProofEncoding)
+	}
+	id := proof.HashID(b[0])
+	if id.New() == nil {
+		return opError("Pai.UnmarshalBinary", proof.ErrUnknownHash)
+	}
+	p.hash = id
+	p.c = new(big.Int).SetBytes(b[1:33])
+	p.r1 = new(big.Int).SetBytes(b[33:65])
+	p.r2 = new(big.Int).SetBytes(b[65:97])
+	return nil
+}
+
+// Values returns the proof values (c,r1,r2) held by p.
+// 取出证明p中的(c,r1,r2)。
+func (p *Pai) Values() (c, r1, r2 *big.Int) {
+	return p.c, p.r1, p.r2
+}
+
+// BatchVerify verifies the share proofs in pv against the matching shares and node
+// public keys, and returns the indices whose proof failed.
+// 批量证明验证: 逐个验证pv中第i个证明是否能够证明份额shares[i]由公钥为nodePubKeys[i]的节点
+// 针对目标公钥targetPubKey与密文左侧点rB计算得来，返回验证失败的下标。
+// 协调者可据此识别并排除作恶节点，而不仅仅得知"有证明未通过"。
+//
+// 参数：
+//		份额slice：		shares
+//		节点公钥slice：	nodePubKeys
+//		目标公钥：		targetPubKey
+//		密文左侧点：	rB
+// 返回：
+// 		验证失败的下标，全部通过时为空
+func (pv *PaiVector) BatchVerify(shares *elgamal.CipherVector, nodePubKeys []sm2.PublicKey, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) ([]int, error) {
+	// 检查证明、份额与节点公钥数量一致
+	lens := len(*pv)
+	if len(*shares) != lens || len(nodePubKeys) != lens {
+		return nil, opError("PaiVector.BatchVerify", elgamal.ErrLengthMismatch)
+	}
+
+	var failed []int
+	for i := 0; i < lens; i++ {
+		pai := &(*pv)[i]
+		// 缺失的证明直接视为验证失败
+		if pai.c == nil || pai.r1 == nil || pai.r2 == nil {
+			failed = append(failed, i)
+			continue
+		}
+
+		// 份额不在曲线上等验证错误同样视为该节点验证失败
+		flag, err := shareProofVryNoB(pai.suite(), pai.c, pai.r1, pai.r2, &(*shares)[i], &nodePubKeys[i], targetPubKey, rB)
+		if err != nil || !flag {
+			failed = append(failed, i)
+		}
+	}
+
+	return failed, nil
+}
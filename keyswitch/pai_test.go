@@ -0,0 +1,153 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestPaiVectorBatchVerify(t *testing.T) {
+	////////////////////////
+	// 模拟ks server数量/////
+	lens := 8
+	////////////////////////
+
+	q, err := sm2.GenerateKey(rand.Reader) // 请求者密钥对
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint() // 密文左侧点
+
+	shares := make(elgamal.CipherVector, lens)
+	pubs := make([]sm2.PublicKey, lens)
+	pais := make(PaiVector, lens)
+	for i := 0; i < lens; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, ri, err := ShareCal(&q.PublicKey, rB, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, r1, r2, err := ShareProofGenNoB(ri, priv, share, &q.PublicKey, rB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = *share
+		pubs[i] = priv.PublicKey
+		pais[i] = NewPai(c, r1, r2)
+	}
+
+	failed, err := pais.BatchVerify(&shares, pubs, &q.PublicKey, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("honest proofs failed at %v", failed)
+	}
+
+	// 篡改第2个证明，并缺失第5个证明
+	c, r1, r2 := pais[2].Values()
+	pais[2] = NewPai(new(big.Int).Add(c, big.NewInt(1)), r1, r2)
+	pais[5] = Pai{}
+
+	failed, err = pais.BatchVerify(&shares, pubs, &q.PublicKey, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(failed, []int{2, 5}) {
+		t.Fatalf("got failed indices %v, want [2 5]", failed)
+	}
+
+	// 长度不一致
+	if _, err := pais.BatchVerify(&shares, pubs[1:], &q.PublicKey, rB); err == nil {
+		t.Fatal("expected error on length mismatch")
+	}
+}
+
+func TestPaiString(t *testing.T) {
+	pai := NewPai(big.NewInt(1), big.NewInt(2), big.NewInt(0x1f))
+	if got, want := pai.String(), "Pai(c=00000000..., r1=00000000..., r2=00000000...)"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestPaiMarshalBinary(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	for _, suite := range []proof.Suite{proof.DefaultSuite, {Hash: proof.HashSHA256}} {
+		b, err := GenShareBundleWithSuite(suite, &q.PublicKey, rB, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc, err := b.Proof.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enc[0] != byte(suite.Hash) {
+			t.Fatalf("got hash ID %d, want %d", enc[0], suite.Hash)
+		}
+
+		// 反序列化后的证明仍能验证
+		var dec Pai
+		if err := dec.UnmarshalBinary(enc); err != nil {
+			t.Fatal(err)
+		}
+		if dec.Hash() != suite.Hash {
+			t.Fatalf("got hash %v, want %v", dec.Hash(), suite.Hash)
+		}
+		b.Proof = dec
+		if ok, err := b.Verify(); err != nil || !ok {
+			t.Fatalf("decoded %v proof failed to verify: %v", suite.Hash, err)
+		}
+		enc2, _ := dec.MarshalBinary()
+		if !bytes.Equal(enc, enc2) {
+			t.Fatal("re-encoding differs")
+		}
+	}
+
+	bad := make([]byte, paiLen)
+	bad[0] = 99
+	var p Pai
+	if err := p.UnmarshalBinary(bad); !errors.Is(err, proof.ErrUnknownHash) {
+		t.Fatalf("got %v, want ErrUnknownHash", err)
+	}
+	if err := p.UnmarshalBinary(bad[1:]); !errors.Is(err, ErrInvalidProofEncoding) {
+		t.Fatalf("got %v, want ErrInvalidProofEncoding", err)
+	}
+	if _, err := (&Pai{}).MarshalBinary(); !errors.Is(err, ErrIncompleteProof) {
+		t.Fatalf("got %v, want ErrIncompleteProof", err)
+	}
+}
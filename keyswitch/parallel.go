@@ -0,0 +1,74 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// parallelism is the bound set by SetParallelism, 0 for GOMAXPROCS.
+// SetParallelism设置的上限，0表示GOMAXPROCS。
+var parallelism int32
+
+// SetParallelism bounds the worker goroutines of every parallel batch operation,
+// ShareCalParallel, ShareReplaceParallel and Verifier, to n, so operators can cap
+// the CPU a node takes on a shared host. A worker count of zero or less asks for
+// the bound itself, and larger counts are capped to it. n <= 0 restores the
+// default, GOMAXPROCS at the time of each call. It returns the previous bound, 0
+// for the default, and takes effect for operations started afterwards.
+// 设置并行度：将所有并行批量运算（ShareCalParallel、ShareReplaceParallel、Verifier）的工作
+// 协程数限制为n，使运维方可在共享主机上限制节点占用的CPU。协程数不大于0时取该上限，超过上限时
+// 截为上限。n <= 0恢复默认值，即每次调用时的GOMAXPROCS。返回此前的上限（默认值为0），对此后
+// 开始的运算生效。
+//
+// 参数：
+//		协程数上限	n
+// 返回：
+// 		此前的上限
+func SetParallelism(n int) int {
+	if n < 0 {
+		n = 0
+	}
+	return int(atomic.SwapInt32(&parallelism, int32(n)))
+}
+
+// Parallelism returns the bound on worker goroutines: the value set by
+// SetParallelism, or GOMAXPROCS by default.
+// 返回工作协程数上限：SetParallelism设置的值，默认为GOMAXPROCS。
+func Parallelism() int {
+	if n := atomic.LoadInt32(&parallelism); n > 0 {
+		return int(n)
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// workerCount resolves the worker count asked of a parallel operation against
+// Parallelism, and against jobs when jobs >= 0, returning at least one worker.
+// 按Parallelism及任务数jobs（jobs >= 0时）确定并行运算的协程数，至少为1。
+func workerCount(workers, jobs int) int {
+	limit := Parallelism()
+	if workers <= 0 || workers > limit {
+		workers = limit
+	}
+	if jobs >= 0 && workers > jobs {
+		workers = jobs
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"runtime"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSetParallelism(t *testing.T) {
+	prev := SetParallelism(0)
+	defer SetParallelism(prev)
+
+	if got := Parallelism(); got != runtime.GOMAXPROCS(0) {
+		t.Fatalf("default parallelism %d, want GOMAXPROCS", got)
+	}
+	if old := SetParallelism(3); old != 0 {
+		t.Fatalf("previous bound %d, want 0", old)
+	}
+	if got := Parallelism(); got != 3 {
+		t.Fatalf("parallelism %d, want 3", got)
+	}
+	for _, c := range []struct{ workers, jobs, want int }{
+		{0, 10, 3},
+		{-1, -1, 3},
+		{2, 10, 2},
+		{8, 10, 3},
+		{8, 2, 2},
+		{0, 0, 1},
+	} {
+		if got := workerCount(c.workers, c.jobs); got != c.want {
+			t.Fatalf("workerCount(%d, %d) = %d, want %d", c.workers, c.jobs, got, c.want)
+		}
+	}
+	if old := SetParallelism(-5); old != 3 {
+		t.Fatalf("previous bound %d, want 3", old)
+	}
+	if got := Parallelism(); got != runtime.GOMAXPROCS(0) {
+		t.Fatal("negative bound did not restore the default")
+	}
+
+	// 上限为1时并行运算仍得到正确结果
+	SetParallelism(1)
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := make([]ShareRequest, 4)
+	for i := range reqs {
+		reqs[i] = ShareRequest{TargetPubKey: &q.PublicKey, RB: elgamal.GenPoint()}
+	}
+	n := 0
+	for r := range ShareCalParallel(reqs, priv, false, 8) {
+		if r.Err != nil || r.Index != n {
+			t.Fatalf("result %d: index %d, error %v", n, r.Index, r.Err)
+		}
+		n++
+	}
+	if n != len(reqs) {
+		t.Fatalf("got %d results, want %d", n, len(reqs))
+	}
+}
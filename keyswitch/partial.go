@@ -0,0 +1,146 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// PartialDecryption is a server's part of a joint decryption of a ciphertext
+// (K,C): S = k*K for its key k, and the DLEQ proof (Pc,Pr) that S and NodePubKey
+// share the discrete logarithm k. Partials go to the designated party only, since
+// together they reveal the plaintext point.
+// 部分解密：ks server对密文(K,C)联合解密的部分结果，S = k*K（k为其私钥），
+// (Pc,Pr)为S与NodePubKey具有相同离散对数k的证明。部分解密结果合起来即泄露明文点，只应发给指定的接收方。
+type PartialDecryption struct {
+	S          elgamal.CurvePoint
+	NodePubKey *sm2.PublicKey
+	Pc, Pr     *big.Int
+}
+
+// PartialDecrypt calculates and proves the partial decryption of ct with priv, so
+// the committee decrypts to a designated party that holds no key pair of its own.
+// 部分解密：使用私钥priv计算密文ct的部分解密结果并生成证明，使委员会可直接为不持有密钥对的指定接收方解密。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+// 返回：
+// 		部分解密结果
+func PartialDecrypt(priv *sm2.PrivateKey, ct *elgamal.CipherText) (*PartialDecryption, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("PartialDecrypt", err)
+	}
+	if priv == nil || priv.D == nil || priv.Curve != ct.K.Curve {
+		return nil, opError("PartialDecrypt", elgamal.ErrCurveMismatch)
+	}
+
+	curve := priv.Curve
+	p := &PartialDecryption{NodePubKey: &priv.PublicKey}
+	p.S.Curve = curve
+	p.S.X, p.S.Y = curve.ScalarMult(ct.K.X, ct.K.Y, priv.D.Bytes())
+
+	c, r, err := proof.DLEQGen(priv.D, elgamal.Generator(curve), (*elgamal.CurvePoint)(&priv.PublicKey), &ct.K, &p.S)
+	if err != nil {
+		return nil, opError("PartialDecrypt", err)
+	}
+	p.Pc, p.Pr = c, r
+	return p, nil
+}
+
+// Verify checks the proof of p against the ciphertext ct.
+// 验证部分解密结果p关于密文ct的证明。
+//
+// 参数：
+//		密文	ct
+// 返回：
+// 		验证结果
+func (p *PartialDecryption) Verify(ct *elgamal.CipherText) (bool, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return false, opError("PartialDecryption.Verify", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(p.NodePubKey)); err != nil {
+		return false, opError("PartialDecryption.Verify", err)
+	}
+	if err := elgamal.CheckPoint(&p.S); err != nil {
+		return false, opError("PartialDecryption.Verify", err)
+	}
+	if p.Pc == nil || p.Pr == nil {
+		return false, nil
+	}
+	return proof.DLEQVerify(p.Pc, p.Pr, elgamal.Generator(ct.K.Curve), (*elgamal.CurvePoint)(p.NodePubKey), &ct.K, &p.S)
+}
+
+// CombinePartials verifies the partial decryptions of ct and combines them into
+// the plaintext point C - sum(coeffs[i]*S_i). A nil coeffs sums the partials as
+// for an additive committee key; for Shamir shares pass the Lagrange coefficients
+// of LagrangeCoefficient, in the order of partials.
+// 合并部分解密结果：验证密文ct的各部分解密结果，并合并为明文点C - sum(coeffs[i]*S_i)。
+// coeffs为nil时直接求和，适用于加和得到的委员会私钥；Shamir份额应按partials的顺序传入LagrangeCoefficient得到的拉格朗日系数。
+//
+// 参数：
+//		密文				ct
+//		部分解密结果slice	partials
+//		系数slice			coeffs
+// 返回：
+// 		明文点
+func CombinePartials(ct *elgamal.CipherText, partials []*PartialDecryption, coeffs []*big.Int) (*elgamal.CurvePoint, error) {
+	if len(partials) == 0 {
+		return nil, opError("CombinePartials", elgamal.ErrEmpty)
+	}
+	if coeffs != nil && len(coeffs) != len(partials) {
+		return nil, opError("CombinePartials", elgamal.ErrLengthMismatch)
+	}
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("CombinePartials", err)
+	}
+
+	curve := ct.K.Curve
+	var sx, sy *big.Int
+	for i, p := range partials {
+		if p == nil {
+			return nil, itemError("CombinePartials", "partial", i, elgamal.ErrPointNotOnCurve)
+		}
+		ok, err := p.Verify(ct)
+		if err != nil {
+			return nil, itemError("CombinePartials", "partial", i, err)
+		}
+		if !ok {
+			return nil, itemError("CombinePartials", "partial", i, ErrProofFailed)
+		}
+		x, y := p.S.X, p.S.Y
+		if coeffs != nil {
+			x, y = curve.ScalarMult(x, y, new(big.Int).Mod(coeffs[i], curve.Params().N).Bytes())
+		}
+		if sx == nil {
+			sx, sy = x, y
+		} else {
+			sx, sy = ec.Add(curve, sx, sy, x, y)
+		}
+	}
+
+	// D = C - S
+	sx, sy = ec.Neg(curve, sx, sy)
+	D := elgamal.Generator(curve)
+	D.X, D.Y = ec.Add(curve, ct.C.X, ct.C.Y, sx, sy)
+	return D, nil
+}
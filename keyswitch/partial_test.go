@@ -0,0 +1,109 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCombinePartials(t *testing.T) {
+	nodes := make([]*sm2.PrivateKey, 3)
+	pubs := make([]*sm2.PublicKey, len(nodes))
+	for i := range nodes {
+		var err error
+		if nodes[i], err = sm2.GenerateKey(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		pubs[i] = &nodes[i].PublicKey
+	}
+	committee, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(committee, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partials := make([]*PartialDecryption, len(nodes))
+	for i, node := range nodes {
+		if partials[i], err = PartialDecrypt(node, ct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := CombinePartials(ct, partials, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+		t.Fatal("combined partials differ from the plaintext point")
+	}
+
+	// 篡改的部分解密结果被拒绝
+	forged := *partials[1]
+	forged.S = *elgamal.GenPoint()
+	_, err = CombinePartials(ct, []*PartialDecryption{partials[0], &forged, partials[2]}, nil)
+	var e *elgamal.Error
+	if !errors.Is(err, ErrProofFailed) || !errors.As(err, &e) || e.Index != 1 {
+		t.Fatalf("expected proof failure of partial 1, got %v", err)
+	}
+	if _, err := CombinePartials(ct, partials, []*big.Int{big.NewInt(1)}); err == nil {
+		t.Fatal("expected error for mismatched coefficients")
+	}
+}
+
+func TestCombineThresholdPartials(t *testing.T) {
+	collPriv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := SplitPrivKey(collPriv, 2, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(&collPriv.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indices := []int{3, 1}
+	partials := make([]*PartialDecryption, len(indices))
+	coeffs := make([]*big.Int, len(indices))
+	for i, j := range indices {
+		if partials[i], err = PartialDecrypt(keys[j-1].Priv, ct); err != nil {
+			t.Fatal(err)
+		}
+		if coeffs[i], err = LagrangeCoefficient(collPriv.Curve, j, indices); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := CombinePartials(ct, partials, coeffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+		t.Fatal("combined threshold partials differ from the plaintext point")
+	}
+}
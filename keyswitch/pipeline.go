@@ -0,0 +1,213 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ShareSource is a committee of KS servers that can take part in a Pipeline.
+// 份额来源：可参与多跳置换流水线的一组ks server。
+type ShareSource interface {
+	// PublicKey returns the collective public key of the committee.
+	// 返回该组ks server的聚合公钥。
+	PublicKey() *sm2.PublicKey
+	// Bundles returns one proven share per server for targetPubKey and rB.
+	// 返回每个ks server为目标公钥targetPubKey及密文左侧点rB计算的份额包。
+	Bundles(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) ([]*ShareBundle, error)
+}
+
+// LocalCommittee is a ShareSource whose private keys are all held in process,
+// for tests and for domains that run their servers behind one gateway.
+// 本地委员会：私钥均在本进程中的份额来源，用于测试，或由单一网关代理其ks server的域。
+type LocalCommittee struct {
+	privs []*sm2.PrivateKey
+	pub   *sm2.PublicKey
+}
+
+// NewLocalCommittee returns a committee of the servers with private keys privs.
+// 创建本地委员会：以privs为各ks server的私钥。
+//
+// 参数：
+//		私钥指针slice	privs
+// 返回：
+// 		本地委员会
+func NewLocalCommittee(privs []*sm2.PrivateKey) (*LocalCommittee, error) {
+	pubs := make([]*sm2.PublicKey, len(privs))
+	for i, priv := range privs {
+		if priv == nil {
+			return nil, itemError("NewLocalCommittee", "key", i, elgamal.ErrEmpty)
+		}
+		pubs[i] = &priv.PublicKey
+	}
+	pub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalCommittee{privs: privs, pub: pub}, nil
+}
+
+// PublicKey returns the collective public key of the committee.
+// 返回委员会的聚合公钥。
+func (c *LocalCommittee) PublicKey() *sm2.PublicKey {
+	return c.pub
+}
+
+// Bundles calculates and proves the share of every server for targetPubKey and rB.
+// 为目标公钥targetPubKey及密文左侧点rB计算各ks server的份额包。
+func (c *LocalCommittee) Bundles(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) ([]*ShareBundle, error) {
+	bundles := make([]*ShareBundle, len(c.privs))
+	for i, priv := range c.privs {
+		b, err := GenShareBundle(targetPubKey, rB, priv)
+		if err != nil {
+			return nil, err
+		}
+		bundles[i] = b
+	}
+	return bundles, nil
+}
+
+// Pipeline chains key-switch rounds across committees, e.g. committee A → committee
+// B → requester, for data that crosses organizational domains. Every hop verifies
+// the shares it receives and re-randomizes the switched ciphertext before handing
+// it on, so consecutive hops cannot link their ciphertexts.
+// 多跳置换流水线：串联多个委员会的密钥置换，如委员会A → 委员会B → 请求者，用于跨组织域流转的数据。
+// 每一跳验证收到的份额，并在交给下一跳前对置换后的密文重新随机化，使相邻两跳无法关联各自的密文。
+type Pipeline struct {
+	hops []ShareSource
+}
+
+// NewPipeline returns a pipeline starting at the committee first, whose collective
+// key the input ciphertexts are encrypted under.
+// 创建流水线：输入密文以首个委员会first的聚合公钥加密。
+//
+// 参数：
+//		首个委员会	first
+// 返回：
+// 		流水线
+func NewPipeline(first ShareSource) *Pipeline {
+	return &Pipeline{hops: []ShareSource{first}}
+}
+
+// Then appends the committee next, which receives the ciphertext from the previous
+// hop under its own collective key, and returns p for chaining.
+// 追加委员会next：上一跳将密文置换为next的聚合公钥下的密文。返回p以便链式调用。
+func (p *Pipeline) Then(next ShareSource) *Pipeline {
+	p.hops = append(p.hops, next)
+	return p
+}
+
+// Len returns the number of hops in p.
+// 返回流水线的跳数。
+func (p *Pipeline) Len() int {
+	return len(p.hops)
+}
+
+// Run switches ct, encrypted under the key of the first committee, hop by hop and
+// returns it encrypted under targetPubKey.
+// 执行流水线：将以首个委员会公钥加密的密文ct逐跳置换，返回目标公钥targetPubKey下的密文。
+//
+// 参数：
+//		密文原文	ct
+//		目标公钥	targetPubKey
+// 返回：
+// 		新密文
+func (p *Pipeline) Run(ct *elgamal.CipherText, targetPubKey *sm2.PublicKey) (*elgamal.CipherText, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("Pipeline.Run", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return nil, opError("Pipeline.Run", err)
+	}
+
+	for i, hop := range p.hops {
+		// 本跳置换的目标为下一委员会，最后一跳为请求者
+		next := targetPubKey
+		if i+1 < len(p.hops) {
+			next = p.hops[i+1].PublicKey()
+		}
+
+		var err error
+		ct, err = switchHop(hop, ct, next)
+		if err != nil {
+			return nil, itemError("Pipeline.Run", "hop", i, err)
+		}
+	}
+
+	return ct, nil
+}
+
+// switchHop lets hop switch ct to next, checking the shares against the committee key.
+// 由委员会hop将密文ct置换为next下的密文，并验证份额与委员会公钥一致。
+func switchHop(hop ShareSource, ct *elgamal.CipherText, next *sm2.PublicKey) (*elgamal.CipherText, error) {
+	bundles, err := hop.Bundles(next, &ct.K)
+	if err != nil {
+		return nil, err
+	}
+	if len(bundles) == 0 {
+		return nil, elgamal.ErrEmpty
+	}
+
+	// 验证各份额的证明，且各节点公钥之和须为委员会公钥
+	var acc ShareAccumulator
+	nodePubs := make([]*sm2.PublicKey, len(bundles))
+	for i, b := range bundles {
+		if b == nil || !sameKey(b.TargetPubKey, next) || b.RB == nil || !samePoint(b.RB, &ct.K) {
+			return nil, ErrProofFailed
+		}
+		ok, err := b.Verify()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrProofFailed
+		}
+		if err := acc.Add(&b.Share); err != nil {
+			return nil, err
+		}
+		nodePubs[i] = b.NodePubKey
+	}
+	collPub, err := AggregatePubKeys(nodePubs)
+	if err != nil {
+		return nil, err
+	}
+	if !sameKey(collPub, hop.PublicKey()) {
+		return nil, ErrProofFailed
+	}
+
+	switched, err := acc.Finalize(ct)
+	if err != nil {
+		return nil, err
+	}
+	return elgamal.Rerandomize(next, switched)
+}
+
+// sameKey reports whether a and b are the same public key.
+// 判断a与b是否为同一公钥。
+func sameKey(a, b *sm2.PublicKey) bool {
+	return samePoint((*elgamal.CurvePoint)(a), (*elgamal.CurvePoint)(b))
+}
+
+// samePoint reports whether a and b have the same coordinates.
+// 判断a与b坐标是否相同。
+func samePoint(a, b *elgamal.CurvePoint) bool {
+	if a == nil || b == nil || a.X == nil || b.X == nil {
+		return false
+	}
+	return a.X.Cmp(b.X) == 0 && a.Y.Cmp(b.Y) == 0
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// newCommittee 生成n个ks server组成的本地委员会。
+func newCommittee(t *testing.T, n int) *LocalCommittee {
+	privs := make([]*sm2.PrivateKey, n)
+	for i := range privs {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+	}
+	c, err := NewLocalCommittee(privs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// tamperedSource 篡改首个份额的份额来源。
+type tamperedSource struct {
+	*LocalCommittee
+}
+
+func (s tamperedSource) Bundles(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) ([]*ShareBundle, error) {
+	bundles, err := s.LocalCommittee.Bundles(targetPubKey, rB)
+	if err != nil {
+		return nil, err
+	}
+	bundles[0].Share.C = *elgamal.GenPoint()
+	return bundles, nil
+}
+
+func TestPipeline(t *testing.T) {
+	A := newCommittee(t, 3)
+	B := newCommittee(t, 4)
+	q, err := sm2.GenerateKey(rand.Reader) // 请求者密钥对
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(A.PublicKey(), D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPipeline(A).Then(B)
+	if p.Len() != 2 {
+		t.Fatalf("got %d hops, want 2", p.Len())
+	}
+	tct, err := p.Run(ct, &q.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := elgamal.PointDecrypt(tct, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(pt.X) || 0 != D.Y.Cmp(pt.Y) {
+		t.Fatal("requester failed to decrypt pipeline output")
+	}
+
+	// 第2跳篡改份额
+	_, err = NewPipeline(A).Then(tamperedSource{B}).Run(ct, &q.PublicKey)
+	if !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+	var e *elgamal.Error
+	if !errors.As(err, &e) || e.Item != "hop" || e.Index != 1 {
+		t.Fatalf("unexpected error detail %v", err)
+	}
+
+	// 份额来自与委员会公钥不符的节点
+	_, err = NewPipeline(&LocalCommittee{privs: B.privs, pub: A.PublicKey()}).Run(ct, &q.PublicKey)
+	if !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// plaintextCheckContext binds plaintext check proofs to their protocol.
+// 明文检验证明的上下文。
+const plaintextCheckContext = "ppks-plaintext-check"
+
+// plaintextDiff returns S = C-M, the point d*K if ct encrypts M under the key d.
+// 返回S = C-M；若ct为私钥d对应公钥下M的密文，则S = d*K。
+func plaintextDiff(op string, ct *elgamal.CipherText, M *elgamal.CurvePoint) (*elgamal.CurvePoint, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError(op, err)
+	}
+	negM, err := elgamal.NegPoint(M)
+	if err != nil {
+		return nil, opError(op, err)
+	}
+	if M.Curve.Params() != ct.C.Curve.Params() {
+		return nil, opError(op, elgamal.ErrCurveMismatch)
+	}
+	curve := ct.C.Curve
+	S := &elgamal.CurvePoint{Curve: curve}
+	S.X, S.Y = ec.Add(curve, ct.C.X, ct.C.Y, negM.X, negM.Y)
+	return S, nil
+}
+
+// CheckPlaintext tests with the trapdoor priv whether ct encrypts the known point
+// M, without decrypting it, so a deduplicating store holding the trapdoor can find
+// ciphertexts of the same document key.
+// 明文检验：以陷门私钥priv检验密文ct是否为已知点M的密文，而不解密该密文，持有陷门的去重存储
+// 可据此找出同一文档密钥的密文。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+//		已知点	M
+// 返回：
+// 		检验结果
+func CheckPlaintext(priv *sm2.PrivateKey, ct *elgamal.CipherText, M *elgamal.CurvePoint) (bool, error) {
+	S, err := plaintextDiff("CheckPlaintext", ct, M)
+	if err != nil {
+		return false, err
+	}
+	if priv == nil || priv.D == nil {
+		return false, opError("CheckPlaintext", ErrUnsupportedKey)
+	}
+	if S.IsInfinity() {
+		return false, nil
+	}
+	var dK elgamal.CurvePoint
+	dK.Curve = ct.K.Curve
+	dK.X, dK.Y = ct.K.Curve.ScalarMult(ct.K.X, ct.K.Y, priv.D.Bytes())
+	return samePoint(&dK, S), nil
+}
+
+// PlaintextCheck is the proven answer of the key holder to whether a ciphertext
+// encrypts a known point. If Match, Proof shows C-M = d*K. Otherwise Q = a*pub and
+// Z = a*(d*K-(C-M)) for a random a, and Proof shows this with b = a*d; as Z is not
+// the point at infinity, d*K differs from C-M. Neither case reveals the plaintext.
+// 明文检验证明：私钥持有者对密文是否为已知点的密文给出的可验证回答。Match为真时，Proof证明C-M = d*K；
+// 否则对随机数a有Q = a*pub、Z = a*(d*K-(C-M))，Proof以b = a*d证明之，由于Z不是无穷远点，
+// d*K不等于C-M。两种情形均不泄露明文。
+type PlaintextCheck struct {
+	Match bool
+	Q, Z  *elgamal.CurvePoint
+	Proof *proof.LinearProof
+}
+
+// plaintextCheckRelation returns the relation of p: {pub=d*B, S=d*K} if it is a
+// match, and {Q=a*pub, Q=b*B, Z=b*K-a*S} otherwise.
+// 返回p对应的关系：匹配时为{pub=d*B, S=d*K}，否则为{Q=a*pub, Q=b*B, Z=b*K-a*S}。
+func plaintextCheckRelation(op string, pub *sm2.PublicKey, ct *elgamal.CipherText, S *elgamal.CurvePoint, match bool, Q, Z *elgamal.CurvePoint) (*proof.Relation, error) {
+	B := elgamal.Generator(ct.K.Curve)
+	P := (*elgamal.CurvePoint)(pub)
+	if match {
+		return proof.DLEQRelation(B, P, &ct.K, S), nil
+	}
+	negS, err := elgamal.NegPoint(S)
+	if err != nil {
+		return nil, opError(op, err)
+	}
+	return &proof.Relation{
+		Bases: [][]*elgamal.CurvePoint{
+			{P, nil},
+			{nil, B},
+			{negS, &ct.K},
+		},
+		Targets: []*elgamal.CurvePoint{Q, Q, Z},
+	}, nil
+}
+
+// ProvePlaintextCheck answers with priv whether ct encrypts the known point M and
+// proves the answer, so that parties without the key can deduplicate encrypted
+// document keys without any of them being decrypted.
+// 可验证明文检验：以私钥priv回答密文ct是否为已知点M的密文并证明该回答，不持有私钥的各方由此可在
+// 不解密的情况下对加密的文档密钥去重。
+//
+// 参数：
+//		私钥	priv
+//		密文	ct
+//		已知点	M
+// 返回：
+// 		明文检验证明
+func ProvePlaintextCheck(priv *sm2.PrivateKey, ct *elgamal.CipherText, M *elgamal.CurvePoint) (*PlaintextCheck, error) {
+	S, err := plaintextDiff("ProvePlaintextCheck", ct, M)
+	if err != nil {
+		return nil, err
+	}
+	if priv == nil || priv.D == nil {
+		return nil, opError("ProvePlaintextCheck", ErrUnsupportedKey)
+	}
+	// C = M时d*K = C-M不可能成立，任何人均可检验，无需证明
+	if S.IsInfinity() {
+		return &PlaintextCheck{}, nil
+	}
+
+	curve := ct.K.Curve
+	var dK elgamal.CurvePoint
+	dK.Curve = curve
+	dK.X, dK.Y = curve.ScalarMult(ct.K.X, ct.K.Y, priv.D.Bytes())
+	if samePoint(&dK, S) {
+		rel, err := plaintextCheckRelation("ProvePlaintextCheck", &priv.PublicKey, ct, S, true, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		p, err := proof.ProveLinear(plaintextCheckContext, rel, []*big.Int{priv.D})
+		if err != nil {
+			return nil, err
+		}
+		return &PlaintextCheck{Match: true, Proof: p}, nil
+	}
+
+	a, err := elgamal.RandScalar(curve, nil)
+	if err != nil {
+		return nil, err
+	}
+	b := new(big.Int).Mul(a, priv.D)
+	b.Mod(b, curve.Params().N)
+
+	// Q = a*pub = b*B，Z = b*K - a*S = a*(d*K - S)
+	Q := &elgamal.CurvePoint{Curve: curve}
+	Q.X, Q.Y = curve.ScalarBaseMult(b.Bytes())
+	negS, err := elgamal.NegPoint(S)
+	if err != nil {
+		return nil, opError("ProvePlaintextCheck", err)
+	}
+	Z := &elgamal.CurvePoint{Curve: curve}
+	Z.X, Z.Y = ec.Add(curve, dK.X, dK.Y, negS.X, negS.Y)
+	Z.X, Z.Y = curve.ScalarMult(Z.X, Z.Y, a.Bytes())
+
+	rel, err := plaintextCheckRelation("ProvePlaintextCheck", &priv.PublicKey, ct, S, false, Q, Z)
+	if err != nil {
+		return nil, err
+	}
+	p, err := proof.ProveLinear(plaintextCheckContext, rel, []*big.Int{a, b})
+	if err != nil {
+		return nil, err
+	}
+	return &PlaintextCheck{Q: Q, Z: Z, Proof: p}, nil
+}
+
+// VerifyPlaintextCheck verifies the answer p of ProvePlaintextCheck for ct under
+// pub and the known point M. If it is valid, p.Match tells whether ct encrypts M.
+// 明文检验证明验证：验证ProvePlaintextCheck对公钥pub下的密文ct与已知点M给出的回答p。
+// 验证通过时，p.Match即ct是否为M的密文。
+//
+// 参数：
+//		明文检验证明	p
+//		已知点			M
+//		密文			ct
+//		公钥			pub
+// 返回：
+// 		验证结果
+func VerifyPlaintextCheck(p *PlaintextCheck, M *elgamal.CurvePoint, ct *elgamal.CipherText, pub *sm2.PublicKey) (bool, error) {
+	S, err := plaintextDiff("VerifyPlaintextCheck", ct, M)
+	if err != nil {
+		return false, err
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return false, opError("VerifyPlaintextCheck", err)
+	}
+	if p == nil {
+		return false, nil
+	}
+	if S.IsInfinity() {
+		return !p.Match, nil
+	}
+	if p.Proof == nil {
+		return false, nil
+	}
+	if !p.Match {
+		// Z须为有限点，否则证明的是d*K = C-M
+		if elgamal.CheckPoint(p.Q) != nil || elgamal.CheckPoint(p.Z) != nil {
+			return false, nil
+		}
+	}
+	rel, err := plaintextCheckRelation("VerifyPlaintextCheck", pub, ct, S, p.Match, p.Q, p.Z)
+	if err != nil {
+		return false, err
+	}
+	return proof.VerifyLinear(plaintextCheckContext, rel, p.Proof)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestPlaintextCheck(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(&priv.PublicKey, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := elgamal.GenPoint()
+
+	for _, tc := range []struct {
+		name  string
+		M     *elgamal.CurvePoint
+		match bool
+	}{
+		{"same", M, true},
+		{"other", other, false},
+		{"C", &ct.C, false},
+	} {
+		ok, err := CheckPlaintext(priv, ct, tc.M)
+		if err != nil || ok != tc.match {
+			t.Fatalf("%s: CheckPlaintext = %v, %v; want %v", tc.name, ok, err, tc.match)
+		}
+		p, err := ProvePlaintextCheck(priv, ct, tc.M)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Match != tc.match {
+			t.Fatalf("%s: Match = %v, want %v", tc.name, p.Match, tc.match)
+		}
+		if ok, err := VerifyPlaintextCheck(p, tc.M, ct, &priv.PublicKey); err != nil || !ok {
+			t.Fatalf("%s: check failed to verify: %v", tc.name, err)
+		}
+		// 翻转回答后证明不再成立
+		p.Match = !p.Match
+		if ok, _ := VerifyPlaintextCheck(p, tc.M, ct, &priv.PublicKey); ok {
+			t.Fatalf("%s: flipped answer verified", tc.name)
+		}
+	}
+
+	// 以d*K = C-M伪造的不匹配证明：Z为无穷远点，须被拒绝
+	p, err := ProvePlaintextCheck(priv, ct, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Z = elgamal.Infinity(ct.C.Curve)
+	if ok, _ := VerifyPlaintextCheck(p, other, ct, &priv.PublicKey); ok {
+		t.Fatal("check with infinite Z verified")
+	}
+
+	// 其他公钥下验证失败
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = ProvePlaintextCheck(priv, ct, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifyPlaintextCheck(p, M, ct, &q.PublicKey); ok {
+		t.Fatal("check verified under another key")
+	}
+	p.Proof.R[0] = new(big.Int).Add(p.Proof.R[0], big.NewInt(1))
+	if ok, _ := VerifyPlaintextCheck(p, M, ct, &priv.PublicKey); ok {
+		t.Fatal("tampered check verified")
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// PointShare is a custodian's Shamir share of a curve point D: Point is F(Index)
+// for the point polynomial F(x) = D + x*A1 + ... + x^(t-1)*A(t-1) with random
+// points Ai.
+// 点份额：保管者持有的曲线点D的Shamir份额，Point为点多项式F(x) = D + x*A1 + ... + x^(t-1)*A(t-1)
+// 在Index处的值F(Index)，Ai为随机点。
+type PointShare struct {
+	Index int
+	Point elgamal.CurvePoint
+}
+
+// randPoint returns aB for a random scalar a drawn from random.
+// 由随机源random取随机数a，返回aB。
+func randPoint(D *elgamal.CurvePoint, random io.Reader) (*elgamal.CurvePoint, error) {
+	a, err := ec.RandFieldElement(D.Curve, random)
+	if err != nil {
+		return nil, err
+	}
+	A := &elgamal.CurvePoint{Curve: D.Curve}
+	A.X, A.Y = D.Curve.ScalarBaseMult(a.Bytes())
+	return A, nil
+}
+
+// SplitPoint Shamir-shares the point D, such as the symmetric-key point of a
+// document, into n shares of which any t recover it, for custodians 1..n. It works
+// on the point itself, independently of any ElGamal ciphertext of it. A nil random
+// uses crypto/rand.
+// 点分割：将点D（如文档的对称密钥点）以Shamir秘密共享分割为n份，分别交给编号1..n的保管者，任意t份可恢复。
+// 直接分割点本身，与其ElGamal密文无关。random为nil时使用crypto/rand。
+//
+// 参数：
+//		待分割点	D
+//		门限		t
+//		份数		n
+//		随机源		random
+// 返回：
+// 		点份额slice
+func SplitPoint(D *elgamal.CurvePoint, t, n int, random io.Reader) ([]PointShare, error) {
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, opError("SplitPoint", err)
+	}
+	if t < 1 || n < t {
+		return nil, opError("SplitPoint", ErrInvalidThreshold)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+
+	// 随机点多项式F(x) = D + x*A1 + ... + x^(t-1)*A(t-1)
+	coeffs := make([]*elgamal.CurvePoint, t)
+	coeffs[0] = D
+	for j := 1; j < t; j++ {
+		A, err := randPoint(D, random)
+		if err != nil {
+			return nil, opError("SplitPoint", err)
+		}
+		coeffs[j] = A
+	}
+
+	curve := D.Curve
+	shares := make([]PointShare, n)
+	for i := 1; i <= n; i++ {
+		// 秦九韶算法求F(i)
+		x := big.NewInt(int64(i)).Bytes()
+		sx, sy := coeffs[t-1].X, coeffs[t-1].Y
+		for j := t - 2; j >= 0; j-- {
+			sx, sy = curve.ScalarMult(sx, sy, x)
+			sx, sy = ec.Add(curve, sx, sy, coeffs[j].X, coeffs[j].Y)
+		}
+		shares[i-1] = PointShare{Index: i, Point: elgamal.CurvePoint{Curve: curve, X: sx, Y: sy}}
+	}
+
+	return shares, nil
+}
+
+// CombinePoint recovers the point shared by SplitPoint from at least t of its
+// shares with distinct indices. Fewer shares give a wrong point, not an error.
+// 点合并：由SplitPoint分割所得的至少t份编号互不相同的点份额恢复原点。份额不足t份时得到错误的点，而非报错。
+//
+// 参数：
+//		点份额slice	shares
+// 返回：
+// 		原点
+func CombinePoint(shares []PointShare) (*elgamal.CurvePoint, error) {
+	if len(shares) == 0 {
+		return nil, opError("CombinePoint", elgamal.ErrEmpty)
+	}
+	indices := make([]int, len(shares))
+	for i := range shares {
+		if err := elgamal.CheckPoint(&shares[i].Point); err != nil {
+			return nil, itemError("CombinePoint", "share", i, err)
+		}
+		indices[i] = shares[i].Index
+	}
+
+	// D = sum λi*F(i)
+	curve := shares[0].Point.Curve
+	D := &elgamal.CurvePoint{Curve: curve}
+	for i := range shares {
+		l, err := LagrangeCoefficient(curve, shares[i].Index, indices)
+		if err != nil {
+			return nil, itemError("CombinePoint", "share", i, err)
+		}
+		x, y := curve.ScalarMult(shares[i].Point.X, shares[i].Point.Y, l.Bytes())
+		if i == 0 {
+			D.X, D.Y = x, y
+		} else {
+			D.X, D.Y = ec.Add(curve, D.X, D.Y, x, y)
+		}
+	}
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, opError("CombinePoint", err)
+	}
+	return D, nil
+}
+
+// SplitPointAdditive splits D into n random points summing to D, all of which are
+// needed to recover it. A nil random uses crypto/rand.
+// 点加法分割：将点D分割为n个之和为D的随机点，恢复时需要全部n份。random为nil时使用crypto/rand。
+//
+// 参数：
+//		待分割点	D
+//		份数		n
+//		随机源		random
+// 返回：
+// 		点份额向量
+func SplitPointAdditive(D *elgamal.CurvePoint, n int, random io.Reader) (elgamal.PointVector, error) {
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, opError("SplitPointAdditive", err)
+	}
+	if n < 1 {
+		return nil, opError("SplitPointAdditive", ErrInvalidThreshold)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+
+	// 前n-1份为随机点，最后一份为D减去其和
+	curve := D.Curve
+	shares := make(elgamal.PointVector, n)
+	lx, ly := D.X, D.Y
+	for i := 0; i < n-1; i++ {
+		A, err := randPoint(D, random)
+		if err != nil {
+			return nil, opError("SplitPointAdditive", err)
+		}
+		shares[i] = *A
+		nx, ny := ec.Neg(curve, A.X, A.Y)
+		lx, ly = ec.Add(curve, lx, ly, nx, ny)
+	}
+	shares[n-1] = elgamal.CurvePoint{Curve: curve, X: lx, Y: ly}
+
+	return shares, nil
+}
+
+// CombinePointAdditive recovers the point split by SplitPointAdditive as the sum
+// of all its shares.
+// 点加法合并：将SplitPointAdditive分割所得的全部份额相加，恢复原点。
+//
+// 参数：
+//		点份额向量	shares
+// 返回：
+// 		原点
+func CombinePointAdditive(shares elgamal.PointVector) (*elgamal.CurvePoint, error) {
+	if len(shares) == 0 {
+		return nil, opError("CombinePointAdditive", elgamal.ErrEmpty)
+	}
+	curve := shares[0].Curve
+	D := &elgamal.CurvePoint{Curve: curve, X: shares[0].X, Y: shares[0].Y}
+	for i := range shares {
+		if err := elgamal.CheckPoint(&shares[i]); err != nil {
+			return nil, itemError("CombinePointAdditive", "share", i, err)
+		}
+		if i > 0 {
+			D.X, D.Y = ec.Add(curve, D.X, D.Y, shares[i].X, shares[i].Y)
+		}
+	}
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, opError("CombinePointAdditive", err)
+	}
+	return D, nil
+}
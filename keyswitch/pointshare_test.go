@@ -0,0 +1,86 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestSplitCombinePoint(t *testing.T) {
+	D := elgamal.GenPoint()
+	shares, err := SplitPoint(D, 3, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("got %d shares, want 5", len(shares))
+	}
+
+	// 任意3份可恢复
+	for _, set := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var sub []PointShare
+		for _, i := range set {
+			sub = append(sub, shares[i])
+		}
+		got, err := CombinePoint(sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !samePoint(got, D) {
+			t.Fatalf("shares %v did not recover the point", set)
+		}
+	}
+
+	// 不足3份得到错误的点
+	if got, err := CombinePoint(shares[:2]); err == nil && samePoint(got, D) {
+		t.Fatal("two shares recovered the point")
+	}
+	dup := []PointShare{shares[0], shares[0], shares[1]}
+	if _, err := CombinePoint(dup); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+	if _, err := SplitPoint(D, 4, 3, nil); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+}
+
+func TestSplitCombinePointAdditive(t *testing.T) {
+	D := elgamal.GenPoint()
+	for _, n := range []int{1, 2, 5} {
+		shares, err := SplitPointAdditive(D, n, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := CombinePointAdditive(shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !samePoint(got, D) {
+			t.Fatalf("%d shares did not recover the point", n)
+		}
+		if n > 1 {
+			if got, err := CombinePointAdditive(shares[1:]); err == nil && samePoint(got, D) {
+				t.Fatal("partial shares recovered the point")
+			}
+		}
+	}
+	if _, err := SplitPointAdditive(D, 0, nil); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+}
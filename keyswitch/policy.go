@@ -0,0 +1,133 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"encoding/binary"
+	"time"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// policyDomain starts the encoding of every policy.
+// 策略编码的域分隔标签。
+const policyDomain = "ppks-policy-v1"
+
+// Policy holds the attributes a coordinator attaches to a key-switch request, such
+// as the purpose and the class of the document. They are hashed into the challenge
+// of every share proof made for the request, so a share produced under one policy
+// does not verify as authorization under another.
+// 策略：协调者为一次密钥置换请求指定的属性，如用途、文档类别与有效期。策略被计入该请求下
+// 每个份额证明的挑战值，因此在某一策略下生成的份额无法作为其他策略下的授权通过验证。
+type Policy struct {
+	// Purpose is what the decryption is for. 解密用途。
+	Purpose string
+	// DocClass is the class of the protected document. 受保护文档的类别。
+	DocClass string
+	// Expiry is when the policy stops authorizing shares; zero means never.
+	// Only whole seconds are bound into the proofs.
+	// 策略失效时间，零值表示永不失效。证明仅绑定到秒。
+	Expiry time.Time
+}
+
+// Encode returns the canonical encoding of p: the domain, then Purpose and DocClass,
+// each prefixed by its 4-byte length, then Expiry as 8-byte Unix seconds (0 if unset).
+// 返回策略p的规范编码：域分隔标签，带4字节长度前缀的Purpose与DocClass，
+// 以及8字节的失效时间Unix秒数（未设置时为0）。
+func (p *Policy) Encode() []byte {
+	var n [8]byte
+	b := []byte(policyDomain)
+	for _, f := range []string{p.Purpose, p.DocClass} {
+		binary.BigEndian.PutUint32(n[:4], uint32(len(f)))
+		b = append(b, n[:4]...)
+		b = append(b, f...)
+	}
+	var expiry int64
+	if !p.Expiry.IsZero() {
+		expiry = p.Expiry.Unix()
+	}
+	binary.BigEndian.PutUint64(n[:], uint64(expiry))
+	return append(b, n[:]...)
+}
+
+// Expired reports whether p has expired at now.
+// 判断策略p在时刻now是否已失效。
+func (p *Policy) Expired(now time.Time) bool {
+	return !p.Expiry.IsZero() && !now.Before(p.Expiry)
+}
+
+// suite returns base with the encoding of p as its context.
+// 返回以策略p的编码为上下文的参数组base。
+func (p *Policy) suite(base proof.Suite) proof.Suite {
+	base.Context = string(p.Encode())
+	return base
+}
+
+// GenShareBundleWithPolicy is GenShareBundle binding policy into the proof, which
+// then verifies only under the same policy. The bundle records the policy. An
+// expired policy is refused.
+// 生成策略绑定的份额包：同GenShareBundle，但将策略policy计入证明的挑战值，证明仅在相同策略下
+// 通过验证，份额包记录该策略。策略已失效时拒绝生成。
+//
+// 参数：
+//		策略		policy
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额包
+func GenShareBundleWithPolicy(policy *Policy, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	if policy == nil {
+		return nil, opError("GenShareBundleWithPolicy", ErrIncompleteStatement)
+	}
+	if policy.Expired(time.Now()) {
+		return nil, opError("GenShareBundleWithPolicy", ErrPolicyExpired)
+	}
+	b, err := genShareBundle(policy.suite(proof.DefaultSuite), targetPubKey, rB, priv)
+	if err != nil {
+		return nil, err
+	}
+	p := *policy
+	b.Policy = &p
+	return b, nil
+}
+
+// VerifyPolicy checks the proof carried by b under policy, whatever policy b claims
+// to have been made for: it fails unless the share was produced under policy. An
+// expired policy is reported as ErrPolicyExpired.
+// 按策略验证份额包：在策略policy下验证b中的证明，而不论b自称所属的策略，仅当份额是在policy下
+// 生成时通过。策略已失效时返回ErrPolicyExpired。
+//
+// 参数：
+//		策略	policy
+// 返回：
+// 		验证结果：	bool
+func (b *ShareBundle) VerifyPolicy(policy *Policy) (bool, error) {
+	if policy == nil || b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return false, opError("ShareBundle.VerifyPolicy", ErrIncompleteStatement)
+	}
+	if policy.Expired(time.Now()) {
+		return false, opError("ShareBundle.VerifyPolicy", ErrPolicyExpired)
+	}
+	c, r1, r2 := b.Proof.Values()
+	if c == nil || r1 == nil || r2 == nil {
+		return false, nil
+	}
+	return shareProofVryNoB(policy.suite(b.Proof.suite()), c, r1, r2, &b.Share, b.NodePubKey, b.TargetPubKey, b.RB)
+}
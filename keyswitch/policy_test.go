@@ -0,0 +1,89 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareBundlePolicy(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	audit := &Policy{Purpose: "audit", DocClass: "contract", Expiry: time.Now().Add(time.Hour)}
+	b, err := GenShareBundleWithPolicy(audit, &q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Verify(); err != nil || !ok {
+		t.Fatal("policy bundle failed to verify")
+	}
+	if ok, err := b.VerifyPolicy(audit); err != nil || !ok {
+		t.Fatal("policy bundle failed to verify under its policy")
+	}
+
+	// 其他用途、其他类别或无策略下验证失败
+	billing := *audit
+	billing.Purpose = "billing"
+	other := *audit
+	other.DocClass = "invoice"
+	for _, p := range []*Policy{&billing, &other} {
+		if ok, err := b.VerifyPolicy(p); err != nil || ok {
+			t.Fatalf("bundle verified under policy %+v", p)
+		}
+	}
+	b.Policy = &billing
+	if ok, _ := b.Verify(); ok {
+		t.Fatal("bundle verified after its policy was replaced")
+	}
+	b.Policy = nil
+	if ok, _ := b.Verify(); ok {
+		t.Fatal("bundle verified without its policy")
+	}
+	b.Policy = audit
+
+	// 批量验证按策略分组
+	plain, err := GenShareBundle(&q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := BatchVerifyShares([]*ShareBundle{b, plain})
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("batch verification failed: %v %v", failed, err)
+	}
+
+	expired := &Policy{Purpose: "audit", Expiry: time.Now().Add(-time.Second)}
+	if _, err := GenShareBundleWithPolicy(expired, &q.PublicKey, rB, priv); !errors.Is(err, ErrPolicyExpired) {
+		t.Fatalf("got %v, want ErrPolicyExpired", err)
+	}
+	if _, err := b.VerifyPolicy(expired); !errors.Is(err, ErrPolicyExpired) {
+		t.Fatalf("got %v, want ErrPolicyExpired", err)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"sync"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// ShareReplaceParallel is ShareReplace for sessions with many shares: the shares
+// are checked and summed in chunks on workers goroutines, and the partial sums are
+// then added pairwise, level by level, in a parallel tree reduction. Point addition
+// is associative, so the result is the same ciphertext as ShareReplace, and a
+// failing share is reported with the same index. workers is capped to
+// Parallelism, which workers <= 0 selects.
+// 并行份额置换：用于份额较多的会话的ShareReplace。份额在workers个协程上分块检查与求和，
+// 各部分和再逐层两两相加，即并行树形归约。点加满足结合律，结果与ShareReplace的密文相同，
+// 无效份额报告的下标也相同。workers不超过Parallelism，workers <= 0时取Parallelism。
+//
+// 参数：
+//		份额slice	shares
+//		密文原文	rct
+//		计算协程数	workers
+// 返回：
+// 		新密文
+func ShareReplaceParallel(shares *elgamal.CipherVector, rct *elgamal.CipherText, workers int) (*elgamal.CipherText, error) {
+	lens := len(*shares)
+	if lens == 0 {
+		return nil, opError("ShareReplaceParallel", elgamal.ErrEmpty)
+	}
+	workers = workerCount(workers, lens)
+	ctx := ec.NewContext((*shares)[0].K.Curve)
+
+	// 分块：每个协程检查并顺序累加一段连续的份额，部分和保持Jacobian坐标
+	parts := make([]shareSum, workers)
+	bad := make([]int, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		lo, hi := w*lens/workers, (w+1)*lens/workers
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			for i := lo; i < hi; i++ {
+				if err := checkShare(ctx, &(*shares)[i]); err != nil {
+					bad[w], errs[w] = i, err
+					return
+				}
+			}
+			parts[w] = sumShares(ctx, (*shares)[lo:hi])
+		}(w, lo, hi)
+	}
+	wg.Wait()
+	for w, err := range errs {
+		if err != nil {
+			return nil, itemError("ShareReplaceParallel", "share", bad[w], err)
+		}
+	}
+	if err := elgamal.CheckCipherText(rct); err != nil {
+		return nil, opError("ShareReplaceParallel", err)
+	}
+
+	// 树形归约：每层将相邻的部分和两两相加，结果写回每对中的前者；
+	// 部分和不多于workers个，每层的协程数也不超过workers
+	for step := 1; step < len(parts); step *= 2 {
+		for i := 0; i+step < len(parts); i += 2 * step {
+			wg.Add(1)
+			go func(a, b *shareSum) {
+				defer wg.Done()
+				a.K.AddSum(&b.K)
+				a.C.AddSum(&b.C)
+			}(&parts[i], &parts[i+step])
+		}
+		wg.Wait()
+	}
+
+	// 通过sigma置换rct得到目标ct
+	return replaceWith(&parts[0], &(*shares)[0], rct), nil
+}
+
+// shareSum is the sum of the left and right points of shares.
+// 份额左右两侧点之和。
+type shareSum struct {
+	K, C ec.Sum
+}
+
+// sumShares returns the sum of the non-empty shares, which have been checked.
+// 返回已校验的非空份额shares之和。
+func sumShares(ctx *ec.Context, shares []elgamal.CipherText) shareSum {
+	sigma := shareSum{K: ctx.Sum(), C: ctx.Sum()}
+	for i := range shares {
+		sigma.K.Add(shares[i].K.X, shares[i].K.Y)
+		sigma.C.Add(shares[i].C.X, shares[i].C.Y)
+	}
+	return sigma
+}
+
+// replaceWith returns the new ciphertext (sigma.K, sigma.C+rct.C), on the curves of
+// the first share.
+// 返回新密文(sigma.K, sigma.C+rct.C)，曲线与第一个份额相同。
+func replaceWith(sigma *shareSum, first, rct *elgamal.CipherText) *elgamal.CipherText {
+	var ct elgamal.CipherText
+	ct.K.Curve = first.K.Curve
+	ct.K.X, ct.K.Y = sigma.K.Point()
+	sigma.C.Add(rct.C.X, rct.C.Y)
+	ct.C.Curve = first.C.Curve
+	ct.C.X, ct.C.Y = sigma.C.Point()
+	return &ct
+}
+
+// checkShare is elgamal.CheckCipherText, using ctx for the points on its curve.
+// 即elgamal.CheckCipherText，对ctx曲线上的点使用ctx校验。
+func checkShare(ctx *ec.Context, ct *elgamal.CipherText) error {
+	if ct == nil || !ctx.Has(ct.K.Curve) || !ctx.Has(ct.C.Curve) {
+		return elgamal.CheckCipherText(ct)
+	}
+	if !ctx.IsValidXY(ct.K.X, ct.K.Y) || !ctx.IsValidXY(ct.C.X, ct.C.Y) {
+		return elgamal.ErrPointNotOnCurve
+	}
+	return nil
+}
+
+// negPointTo is elgamal.NegPointTo, using ctx for a point on its curve.
+// 即elgamal.NegPointTo，对ctx曲线上的点使用ctx计算。
+func negPointTo(ctx *ec.Context, dst, p *elgamal.CurvePoint) error {
+	if p == nil || !ctx.Has(p.Curve) {
+		return elgamal.NegPointTo(dst, p)
+	}
+	if !ctx.IsValidXY(p.X, p.Y) {
+		return elgamal.ErrPointNotOnCurve
+	}
+	dst.Curve = p.Curve
+	ctx.NegTo(dst.X, dst.Y, p.X, p.Y)
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareReplaceParallel(t *testing.T) {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rct := &elgamal.CipherText{K: *elgamal.GenPoint(), C: *elgamal.GenPoint()}
+
+	for _, lens := range []int{1, 2, 7, 33} {
+		shares := make(elgamal.CipherVector, lens)
+		for i := range shares {
+			priv, err := sm2.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			share, _, err := ShareCal(&q.PublicKey, &rct.K, priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares[i] = *share
+		}
+		want, err := ShareReplace(&shares, rct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pooled := range []bool{true, false} {
+			prev := elgamal.SetPooling(pooled)
+			for _, workers := range []int{0, 1, 3, 64} {
+				got, err := ShareReplaceParallel(&shares, rct, workers)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !samePoint(&got.K, &want.K) || !samePoint(&got.C, &want.C) {
+					t.Fatalf("%d shares, %d workers: result differs from ShareReplace", lens, workers)
+				}
+				// 结果不与已归还的临时密文共享整数
+				if _, err := ShareReplaceParallel(&shares, rct, workers); err != nil {
+					t.Fatal(err)
+				}
+				if !samePoint(&got.K, &want.K) || !samePoint(&got.C, &want.C) {
+					t.Fatalf("%d shares, %d workers: result changed by a later call", lens, workers)
+				}
+			}
+			elgamal.SetPooling(prev)
+		}
+
+		// 份额不被修改
+		if again, _ := ShareReplace(&shares, rct); !samePoint(&again.C, &want.C) {
+			t.Fatal("shares modified")
+		}
+	}
+
+	// 无效份额报告与ShareReplace相同的下标
+	shares := make(elgamal.CipherVector, 10)
+	for i := range shares {
+		shares[i] = elgamal.CipherText{K: *elgamal.GenPoint(), C: *elgamal.GenPoint()}
+	}
+	shares[4].C = elgamal.CurvePoint{}
+	shares[8].K = elgamal.CurvePoint{}
+	_, want := ShareReplace(&shares, rct)
+	_, got := ShareReplaceParallel(&shares, rct, 3)
+	var we, ge *elgamal.Error
+	if !errors.As(want, &we) || !errors.As(got, &ge) || we.Index != ge.Index || ge.Index != 4 {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := ShareReplaceParallel(&elgamal.CipherVector{}, rct, 2); !errors.Is(err, elgamal.ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+}
+
+func BenchmarkShareReplace(b *testing.B) {
+	rct := &elgamal.CipherText{K: *elgamal.GenPoint(), C: *elgamal.GenPoint()}
+	shares := make(elgamal.CipherVector, 256)
+	for i := range shares {
+		shares[i] = elgamal.CipherText{K: *elgamal.GenPoint(), C: *elgamal.GenPoint()}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ShareReplace(&shares, rct); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Re-keying moves stored ciphertexts from the collective key of an old committee
+// to that of a new one, e.g. after a membership change, without any plaintext
+// being reconstructed: every old server switches each ciphertext to the new
+// collective key as it would for a requester, and the proven bundles stay with
+// the result as the audit record that new = ShareReplace(shares, old).
+// 重新密钥化：将存储的密文由旧委员会的聚合公钥转到新委员会的聚合公钥下（如成员变更后），
+// 全程不恢复任何明文：每个旧ks server如同为请求者置换一样，将各密文置换到新的聚合公钥，
+// 附证明的份额包随结果保存，作为new = ShareReplace(shares, old)的审计记录。
+
+// RekeyBundles calculates and proves, with the old server key priv, the share of
+// every ciphertext of cts for the new collective key newPubKey, in order.
+// 重新密钥化份额：使用旧ks server的私钥priv，为新聚合公钥newPubKey依次计算cts中各密文的份额并生成证明。
+//
+// 参数：
+//		新聚合公钥	newPubKey
+//		密文向量	cts
+//		私钥		priv
+// 返回：
+// 		份额包slice，与cts顺序一致
+func RekeyBundles(newPubKey *sm2.PublicKey, cts elgamal.CipherVector, priv *sm2.PrivateKey) ([]*ShareBundle, error) {
+	if len(cts) == 0 {
+		return nil, opError("RekeyBundles", elgamal.ErrEmpty)
+	}
+	bundles := make([]*ShareBundle, len(cts))
+	for j := range cts {
+		if err := elgamal.CheckCipherText(&cts[j]); err != nil {
+			return nil, itemError("RekeyBundles", "ciphertext", j, err)
+		}
+		b, err := GenShareBundle(newPubKey, &cts[j].K, priv)
+		if err != nil {
+			return nil, itemError("RekeyBundles", "ciphertext", j, err)
+		}
+		bundles[j] = b
+	}
+	return bundles, nil
+}
+
+// Rekey verifies the bundles of the old servers and returns cts switched from
+// oldPubKey to newPubKey. nodeBundles[i] is the output of RekeyBundles of server
+// i, whose node keys must add up to oldPubKey.
+// 重新密钥化：验证各旧ks server的份额包，返回由oldPubKey置换到newPubKey下的密文向量。
+// nodeBundles[i]为第i个ks server的RekeyBundles输出，各节点公钥之和须为oldPubKey。
+//
+// 参数：
+//		旧聚合公钥			oldPubKey
+//		新聚合公钥			newPubKey
+//		密文向量			cts
+//		各节点份额包slice	nodeBundles
+// 返回：
+// 		新聚合公钥下的密文向量
+func Rekey(oldPubKey, newPubKey *sm2.PublicKey, cts elgamal.CipherVector, nodeBundles [][]*ShareBundle) (elgamal.CipherVector, error) {
+	if len(cts) == 0 || len(nodeBundles) == 0 {
+		return nil, opError("Rekey", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(newPubKey)); err != nil {
+		return nil, opError("Rekey", err)
+	}
+
+	// 每个节点的份额包须针对新聚合公钥及对应密文，且使用同一节点公钥
+	nodePubs := make([]*sm2.PublicKey, len(nodeBundles))
+	all := make([]*ShareBundle, 0, len(nodeBundles)*len(cts))
+	for i, bundles := range nodeBundles {
+		if len(bundles) != len(cts) {
+			return nil, itemError("Rekey", "node", i, elgamal.ErrLengthMismatch)
+		}
+		for j, b := range bundles {
+			if b == nil || !sameKey(b.TargetPubKey, newPubKey) || !samePoint(b.RB, &cts[j].K) ||
+				(j > 0 && !sameKey(b.NodePubKey, nodePubs[i])) {
+				return nil, itemError("Rekey", "node", i, ErrProofFailed)
+			}
+			nodePubs[i] = b.NodePubKey
+		}
+		all = append(all, bundles...)
+	}
+	collPub, err := AggregatePubKeys(nodePubs)
+	if err != nil {
+		return nil, opError("Rekey", err)
+	}
+	if !sameKey(collPub, oldPubKey) {
+		return nil, opError("Rekey", ErrProofFailed)
+	}
+	failed, err := BatchVerifyShares(all)
+	if err != nil {
+		return nil, opError("Rekey", err)
+	}
+	if len(failed) > 0 {
+		return nil, itemError("Rekey", "node", failed[0]/len(cts), ErrProofFailed)
+	}
+
+	out := make(elgamal.CipherVector, len(cts))
+	shares := make(elgamal.CipherVector, len(nodeBundles))
+	for j := range cts {
+		for i, bundles := range nodeBundles {
+			shares[i] = bundles[j].Share
+		}
+		switched, err := ShareReplace(&shares, &cts[j])
+		if err != nil {
+			return nil, itemError("Rekey", "ciphertext", j, err)
+		}
+		out[j] = *switched
+	}
+	return out, nil
+}
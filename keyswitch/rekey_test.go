@@ -0,0 +1,90 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestRekey(t *testing.T) {
+	committee := func(n int) ([]*sm2.PrivateKey, *sm2.PrivateKey) {
+		privs := make([]*sm2.PrivateKey, n)
+		for i := range privs {
+			var err error
+			if privs[i], err = sm2.GenerateKey(rand.Reader); err != nil {
+				t.Fatal(err)
+			}
+		}
+		coll, err := AggregatePrivKeys(privs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return privs, coll
+	}
+	oldPrivs, oldColl := committee(3)
+	_, newColl := committee(4)
+
+	points := make([]*elgamal.CurvePoint, 4)
+	cts := make(elgamal.CipherVector, len(points))
+	for j := range points {
+		points[j] = elgamal.GenPoint()
+		ct, err := elgamal.PointEncrypt(&oldColl.PublicKey, points[j])
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts[j] = *ct
+	}
+
+	nodeBundles := make([][]*ShareBundle, len(oldPrivs))
+	for i, priv := range oldPrivs {
+		var err error
+		if nodeBundles[i], err = RekeyBundles(&newColl.PublicKey, cts, priv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rekeyed, err := Rekey(&oldColl.PublicKey, &newColl.PublicKey, cts, nodeBundles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := range rekeyed {
+		got, err := elgamal.PointDecrypt(&rekeyed[j], newColl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != points[j].X.Cmp(got.X) || 0 != points[j].Y.Cmp(got.Y) {
+			t.Fatalf("ciphertext %d decrypts to a different point", j)
+		}
+	}
+
+	// 缺少一个旧节点时，节点公钥之和与旧聚合公钥不符
+	if _, err := Rekey(&oldColl.PublicKey, &newColl.PublicKey, cts, nodeBundles[:2]); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("expected ErrProofFailed for a missing node, got %v", err)
+	}
+
+	// 篡改的份额被定位到节点
+	nodeBundles[1][2].Share.C = *elgamal.GenPoint()
+	_, err = Rekey(&oldColl.PublicKey, &newColl.PublicKey, cts, nodeBundles)
+	var e *elgamal.Error
+	if !errors.Is(err, ErrProofFailed) || !errors.As(err, &e) || e.Index != 1 {
+		t.Fatalf("expected proof failure of node 1, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"sync"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Domain separation tags of the keys a NonceRegistry records.
+// NonceRegistry所记录键的域分隔标签。
+var (
+	nonceDomainPoint   = []byte("ppks-nonce-rb")
+	nonceDomainSession = []byte("ppks-nonce-session")
+)
+
+// NonceStore is where a NonceRegistry records what was served. A store shared by
+// several server processes, such as a database with a unique key, makes the
+// registry hold across them.
+// 随机数存储：NonceRegistry记录已服务请求的位置。多个服务进程共用的存储（如带唯一键的数据库）
+// 可使登记在各进程间生效。
+type NonceStore interface {
+	// Record records key and reports whether it was new, atomically: of concurrent
+	// calls with the same key, exactly one reports true.
+	// 原子地记录key并报告其是否为新键：相同key的并发调用中恰有一个返回true。
+	Record(key elgamal.Fingerprint) (bool, error)
+}
+
+// memoryNonceStore is a NonceStore in process memory.
+// 进程内存中的NonceStore实现。
+type memoryNonceStore struct {
+	mu   sync.Mutex
+	seen map[elgamal.Fingerprint]struct{}
+}
+
+// NewMemoryNonceStore returns a NonceStore in process memory. It grows with every
+// request served and is lost on restart.
+// 创建进程内存中的NonceStore，随服务的请求增长，重启后丢失。
+//
+// 参数：
+//
+// 返回：
+// 		随机数存储
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{seen: make(map[elgamal.Fingerprint]struct{})}
+}
+
+func (s *memoryNonceStore) Record(key elgamal.Fingerprint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[key]; ok {
+		return false, nil
+	}
+	s.seen[key] = struct{}{}
+	return true, nil
+}
+
+// NonceRegistry keeps a KS server from serving the same request twice: it records,
+// per requester, the rB points and session IDs already served, so a replayed or
+// duplicated request is refused with ErrReplayed before any share is computed.
+// It is safe for concurrent use if its store is.
+// 防重放登记：防止ks server重复服务同一请求。按请求者记录已服务的rB点与会话标识，重放或重复的请求
+// 在计算份额之前即以ErrReplayed拒绝。其存储支持并发时，可并发使用。
+type NonceRegistry struct {
+	store NonceStore
+}
+
+// NewNonceRegistry returns a registry recording into store; a nil store means
+// NewMemoryNonceStore.
+// 创建防重放登记，记录到store；store为nil时使用NewMemoryNonceStore。
+//
+// 参数：
+//		随机数存储	store
+// 返回：
+// 		防重放登记
+func NewNonceRegistry(store NonceStore) *NonceRegistry {
+	if store == nil {
+		store = NewMemoryNonceStore()
+	}
+	return &NonceRegistry{store: store}
+}
+
+// CheckPoint records rB as served to the requester with key requester, failing with
+// ErrReplayed if it already was.
+// 检查并记录rB：将rB记录为已向公钥为requester的请求者服务，已服务过时返回ErrReplayed。
+//
+// 参数：
+//		请求者公钥	requester
+//		密文左侧点	rB
+// 返回：
+//
+func (r *NonceRegistry) CheckPoint(requester *sm2.PublicKey, rB *elgamal.CurvePoint) error {
+	if err := elgamal.CheckPoint(rB); err != nil {
+		return opError("NonceRegistry.CheckPoint", err)
+	}
+	f := rB.Fingerprint()
+	return r.record("NonceRegistry.CheckPoint", nonceDomainPoint, requester, f[:])
+}
+
+// CheckSession records session as served to the requester with key requester,
+// failing with ErrReplayed if it already was.
+// 检查并记录会话：将会话标识session记录为已向公钥为requester的请求者服务，已服务过时返回ErrReplayed。
+//
+// 参数：
+//		请求者公钥	requester
+//		会话标识	session
+// 返回：
+//
+func (r *NonceRegistry) CheckSession(requester *sm2.PublicKey, session []byte) error {
+	if len(session) == 0 {
+		return opError("NonceRegistry.CheckSession", elgamal.ErrEmpty)
+	}
+	return r.record("NonceRegistry.CheckSession", nonceDomainSession, requester, session)
+}
+
+// ShareBundle is GenShareBundle for the requester targetPubKey, refused with
+// ErrReplayed if rB was already served to it.
+// 生成份额包：同GenShareBundle，但rB已向目标公钥targetPubKey服务过时以ErrReplayed拒绝。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		私钥		priv
+// 返回：
+// 		份额包
+func (r *NonceRegistry) ShareBundle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	if err := r.CheckPoint(targetPubKey, rB); err != nil {
+		return nil, err
+	}
+	return GenShareBundle(targetPubKey, rB, priv)
+}
+
+// record records the key of domain, the requester and value in the store.
+// 将由domain、请求者与value得到的键记录到存储。
+func (r *NonceRegistry) record(op string, domain []byte, requester *sm2.PublicKey, value []byte) error {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(requester)); err != nil {
+		return opError(op, err)
+	}
+	f := elgamal.KeyFingerprint(requester)
+	h := sm3.New()
+	h.Write(domain)
+	h.Write(f[:])
+	h.Write(value)
+	var key elgamal.Fingerprint
+	copy(key[:], h.Sum(nil))
+
+	ok, err := r.store.Record(key)
+	if err != nil {
+		return opError(op, err)
+	}
+	if !ok {
+		return opError(op, ErrReplayed)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestNonceRegistry(t *testing.T) {
+	k, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q1, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q2, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+	reg := NewNonceRegistry(nil)
+
+	b, err := reg.ShareBundle(&q1.PublicKey, rB, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Verify(); err != nil || !ok {
+		t.Fatal("bundle failed to verify")
+	}
+	if _, err := reg.ShareBundle(&q1.PublicKey, rB, k); !errors.Is(err, ErrReplayed) {
+		t.Fatalf("got %v, want ErrReplayed", err)
+	}
+	// 其他请求者不受影响
+	if _, err := reg.ShareBundle(&q2.PublicKey, rB, k); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reg.CheckSession(&q1.PublicKey, []byte("s1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.CheckSession(&q1.PublicKey, []byte("s1")); !errors.Is(err, ErrReplayed) {
+		t.Fatalf("got %v, want ErrReplayed", err)
+	}
+
+	// 并发的相同请求中恰有一个通过
+	rB2 := elgamal.GenPoint()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	served := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if reg.CheckPoint(&q1.PublicKey, rB2) == nil {
+				mu.Lock()
+				served++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if served != 1 {
+		t.Fatalf("served %d concurrent duplicates, want 1", served)
+	}
+}
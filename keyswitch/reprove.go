@@ -0,0 +1,76 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Reprove returns a copy of b carrying a fresh proof of its share, made from the
+// witness the share was calculated with: the nonce ri returned by ShareCal and the
+// node key priv. The new proof verifies exactly like the old one, under the same
+// challenge hash and policy, but its values (c,r1,r2) are independent of it, so
+// audit data can be republished without linking the copies by identical proofs.
+// A stored proof cannot be re-randomized without the witness: that would amount
+// to forging one.
+// 重新证明：以计算份额时的证据（ShareCal返回的随机数ri与节点私钥priv）为b中的份额生成新的证明，
+// 返回携带新证明的b的副本。新证明与原证明在相同挑战哈希与策略下同样通过验证，但其值(c,r1,r2)
+// 与原证明无关，重新发布审计数据时不会因证明相同而被关联。没有证据无法对已存储的证明重新随机化，
+// 否则即可伪造证明。
+//
+// 参数：
+//		随机数		ri
+//		节点私钥	priv
+// 返回：
+// 		携带新证明的份额包
+func (b *ShareBundle) Reprove(ri *big.Int, priv *sm2.PrivateKey) (*ShareBundle, error) {
+	if b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return nil, opError("ShareBundle.Reprove", ErrIncompleteStatement)
+	}
+	if priv == nil || !sameKey(&priv.PublicKey, b.NodePubKey) {
+		return nil, opError("ShareBundle.Reprove", ErrUnsupportedKey)
+	}
+	if err := elgamal.CheckCipherText(&b.Share); err != nil {
+		return nil, opError("ShareBundle.Reprove", err)
+	}
+	if ri == nil {
+		return nil, opError("ShareBundle.Reprove", ErrInvalidShare)
+	}
+
+	// 检查证据与份额一致：share.K=ri*B
+	curve := b.Share.K.Curve
+	var K elgamal.CurvePoint
+	K.Curve = curve
+	K.X, K.Y = curve.ScalarBaseMult(new(big.Int).Mod(ri, curve.Params().N).Bytes())
+	if !samePoint(&K, &b.Share.K) {
+		return nil, opError("ShareBundle.Reprove", ErrInvalidShare)
+	}
+
+	suite := b.suite()
+	c, r1, r2, T, err := shareProofGenNoB(suite, ri, priv, &b.Share, b.TargetPubKey, b.RB)
+	if err != nil {
+		return nil, opError("ShareBundle.Reprove", err)
+	}
+
+	nb := *b
+	nb.Proof = NewPaiWithHash(c, r1, r2, suite.Hash)
+	nb.Commitment = T
+	return &nb, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareBundleReprove(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	share, ri, err := ShareCal(&q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, r1, r2, T, err := shareProofGenNoB(proof.DefaultSuite, ri, priv, share, &q.PublicKey, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &ShareBundle{
+		Share:        *share,
+		Proof:        NewPai(c, r1, r2),
+		NodePubKey:   &priv.PublicKey,
+		TargetPubKey: &q.PublicKey,
+		RB:           rB,
+		Commitment:   T,
+		Policy:       &Policy{Purpose: "audit", Expiry: time.Now().Add(time.Hour)},
+	}
+	// 原证明未绑定策略，先在策略下重新证明
+	nb, err := b.Reprove(ri, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := nb.Reprove(ri, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, x := range []*ShareBundle{nb, again} {
+		if ok, err := x.VerifyPolicy(b.Policy); err != nil || !ok {
+			t.Fatal("reproved bundle failed to verify")
+		}
+	}
+	c1, _, _ := nb.Proof.Values()
+	c2, _, _ := again.Proof.Values()
+	if c1.Cmp(c2) == 0 {
+		t.Fatal("reproving gave identical proof values")
+	}
+	if failed, _ := BatchVerifyShares([]*ShareBundle{nb, again}); len(failed) != 0 {
+		t.Fatalf("batch verification failed for %v", failed)
+	}
+
+	// 证据不符或私钥不符时拒绝
+	if _, err := nb.Reprove(new(big.Int).Add(ri, big.NewInt(1)), priv); !errors.Is(err, ErrInvalidShare) {
+		t.Fatalf("got %v, want ErrInvalidShare", err)
+	}
+	if _, err := nb.Reprove(ri, q); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("got %v, want ErrUnsupportedKey", err)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/kdf"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// sessionCertDomain separates session key certificates from other signed messages.
+// 会话密钥证书所用的域分隔标签，与其他签名消息区分。
+const sessionCertDomain = "ppks-session-key"
+
+// Session keys give a server forward secrecy: for each session it takes a fresh
+// switching key from a one-way ratchet, independent of its long-term key, and
+// erases it when the session ends. Data of the session is encrypted under the sum
+// of the servers' session keys, so a server compromised later, long-term key and
+// ratchet state included, exposes nothing of the sessions already closed. The
+// long-term key only certifies the session key.
+// 会话密钥为ks server提供前向安全：每个会话使用由单向推进的种子派生的新置换密钥，与长期私钥无关，
+// 会话结束时擦除。会话数据以各ks server会话公钥之和加密，因此即使ks server日后被攻破
+// （包括长期私钥与推进状态），已关闭会话的内容也不会泄露。长期私钥仅用于认证会话密钥。
+
+// SessionCert binds the session key PubKey of a server to Session: Endorsement is
+// the signature of the server's long-term key, Possession that of the session key
+// itself, which also stops rogue-key attacks on the aggregated session key.
+// 会话密钥证书：将ks server的会话公钥PubKey与会话标识Session绑定。Endorsement为其长期私钥的签名，
+// Possession为会话私钥本身的签名，同时防止针对聚合会话公钥的恶意密钥攻击。
+type SessionCert struct {
+	Session     []byte
+	PubKey      *sm2.PublicKey
+	Endorsement *schnorr.Signature
+	Possession  *schnorr.Signature
+}
+
+// SessionKey is a server's switching key for one session. It is not safe for
+// concurrent use.
+// 会话密钥：ks server在一个会话中使用的置换密钥。不可并发使用。
+type SessionKey struct {
+	Cert SessionCert
+	priv *sm2.PrivateKey
+}
+
+// Ratchet derives the session keys of a server, erasing each seed as it moves on.
+// It is not safe for concurrent use.
+// 推进器：派生ks server的各会话密钥，推进后擦除旧种子。不可并发使用。
+type Ratchet struct {
+	priv *sm2.PrivateKey
+	seed []byte
+}
+
+// NewRatchet returns the ratchet of the long-term key priv starting at seed, at
+// least 32 bytes of secret randomness.
+// 创建推进器：长期私钥priv的推进器，初始种子为seed，至少32字节秘密随机数。
+//
+// 参数：
+//		长期私钥	priv
+//		种子		seed
+// 返回：
+// 		推进器
+func NewRatchet(priv *sm2.PrivateKey, seed []byte) (*Ratchet, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("NewRatchet", elgamal.ErrEmpty)
+	}
+	if len(seed) < kdf.Size {
+		return nil, opError("NewRatchet", kdf.ErrInvalidKeyLength)
+	}
+	return &Ratchet{priv: priv, seed: append([]byte(nil), seed...)}, nil
+}
+
+// Next derives the key of session, certifies it and moves the ratchet on.
+// 派生会话密钥：派生会话session的密钥并签发证书，随后推进。
+//
+// 参数：
+//		会话标识	session
+// 返回：
+// 		会话密钥
+func (r *Ratchet) Next(session []byte) (*SessionKey, error) {
+	curve := r.priv.Curve
+	N := curve.Params().N
+	b, err := kdf.Expand(r.seed, []byte("ppks-session-key"), kdf.Size+8)
+	if err != nil {
+		return nil, opError("Ratchet.Next", err)
+	}
+	next, err := ratchet(r.seed)
+	if err != nil {
+		return nil, opError("Ratchet.Next", err)
+	}
+	wipe(r.seed)
+	r.seed = next
+
+	d := new(big.Int).SetBytes(b)
+	wipe(b)
+	d.Mod(d, new(big.Int).Sub(N, big.NewInt(1)))
+	d.Add(d, big.NewInt(1))
+	priv := new(sm2.PrivateKey)
+	priv.Curve = curve
+	priv.D = d
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+
+	k := &SessionKey{priv: priv}
+	k.Cert.Session = append([]byte(nil), session...)
+	k.Cert.PubKey = &priv.PublicKey
+	msg := k.Cert.signedBytes(&r.priv.PublicKey)
+	if k.Cert.Endorsement, err = schnorr.Sign(r.priv, msg); err != nil {
+		return nil, opError("Ratchet.Next", err)
+	}
+	if k.Cert.Possession, err = schnorr.Sign(priv, msg); err != nil {
+		return nil, opError("Ratchet.Next", err)
+	}
+	return k, nil
+}
+
+// ShareBundle calculates and proves the share related with rB for targetPubKey
+// with the session key, until Close.
+// 会话份额计算：在Close之前，以会话密钥为目标公钥targetPubKey计算关于点rB的份额并生成证明。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+// 返回：
+// 		份额包
+func (k *SessionKey) ShareBundle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*ShareBundle, error) {
+	if k.priv == nil {
+		return nil, opError("SessionKey.ShareBundle", ErrSessionClosed)
+	}
+	return GenShareBundle(targetPubKey, rB, k.priv)
+}
+
+// Close erases the session key. It is safe to call Close more than once.
+// 关闭会话：擦除会话私钥，可重复调用。
+func (k *SessionKey) Close() {
+	if k.priv != nil {
+		ec.WipeInt(k.priv.D)
+		k.priv = nil
+	}
+}
+
+// Verify reports whether c certifies a session key of the server with long-term
+// key nodePubKey for session.
+// 验证证书：判断c是否为长期公钥为nodePubKey的ks server在会话session中的会话密钥证书。
+//
+// 参数：
+//		长期公钥	nodePubKey
+//		会话标识	session
+// 返回：
+// 		验证结果
+func (c *SessionCert) Verify(nodePubKey *sm2.PublicKey, session []byte) (bool, error) {
+	if c.PubKey == nil || c.Endorsement == nil || c.Possession == nil {
+		return false, opError("SessionCert.Verify", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(c.PubKey)); err != nil {
+		return false, opError("SessionCert.Verify", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(nodePubKey)); err != nil {
+		return false, opError("SessionCert.Verify", err)
+	}
+	if string(c.Session) != string(session) {
+		return false, nil
+	}
+	msg := c.signedBytes(nodePubKey)
+	ok, err := schnorr.Verify(nodePubKey, msg, c.Endorsement)
+	if err != nil || !ok {
+		return false, err
+	}
+	return schnorr.Verify(c.PubKey, msg, c.Possession)
+}
+
+// signedBytes returns the message both signatures of c cover: the domain, the
+// long-term key, the session key and the session.
+// 返回c的两个签名所覆盖的消息：域分隔标签、长期公钥、会话公钥及会话标识。
+func (c *SessionCert) signedBytes(nodePubKey *sm2.PublicKey) []byte {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(c.Session)))
+	msg := []byte(sessionCertDomain)
+	msg = append(msg, (*elgamal.CurvePoint)(nodePubKey).CompressedBytes()...)
+	msg = append(msg, (*elgamal.CurvePoint)(c.PubKey).CompressedBytes()...)
+	msg = append(msg, n[:]...)
+	return append(msg, c.Session...)
+}
+
+// SessionPubKey verifies the certificates of the servers with long-term keys
+// nodePubKeys for session and returns the session committee key to encrypt the
+// session's data under.
+// 会话聚合公钥：验证长期公钥为nodePubKeys的各ks server在会话session中的证书，
+// 返回用于加密该会话数据的会话聚合公钥。
+//
+// 参数：
+//		证书slice		certs
+//		长期公钥slice	nodePubKeys
+//		会话标识		session
+// 返回：
+// 		会话聚合公钥
+func SessionPubKey(certs []*SessionCert, nodePubKeys []*sm2.PublicKey, session []byte) (*sm2.PublicKey, error) {
+	if len(certs) != len(nodePubKeys) {
+		return nil, opError("SessionPubKey", elgamal.ErrLengthMismatch)
+	}
+	pubs := make([]*sm2.PublicKey, len(certs))
+	for i, c := range certs {
+		if c == nil {
+			return nil, itemError("SessionPubKey", "cert", i, ErrIncompleteStatement)
+		}
+		ok, err := c.Verify(nodePubKeys[i], session)
+		if err != nil {
+			return nil, itemError("SessionPubKey", "cert", i, err)
+		}
+		if !ok {
+			return nil, itemError("SessionPubKey", "cert", i, ErrProofFailed)
+		}
+		pubs[i] = c.PubKey
+	}
+	return AggregatePubKeys(pubs)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSessionKey(t *testing.T) {
+	session := []byte("session-0001")
+	longTerm := make([]*sm2.PublicKey, 3)
+	keys := make([]*SessionKey, len(longTerm))
+	certs := make([]*SessionCert, len(longTerm))
+	for i := range keys {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		longTerm[i] = &priv.PublicKey
+		r, err := NewRatchet(priv, bytes.Repeat([]byte{byte(i + 7)}, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keys[i], err = r.Next(session); err != nil {
+			t.Fatal(err)
+		}
+		certs[i] = &keys[i].Cert
+
+		// 下一会话的密钥与本会话无关
+		next, err := r.Next([]byte("session-0002"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.Cert.PubKey.X.Cmp(keys[i].Cert.PubKey.X) == 0 {
+			t.Fatal("ratchet repeated a session key")
+		}
+	}
+
+	sessionPub, err := SessionPubKey(certs, longTerm, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(sessionPub, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make(elgamal.CipherVector, len(keys))
+	for i, k := range keys {
+		b, err := k.ShareBundle(&q.PublicKey, &ct.K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = b.Share
+	}
+	switched, err := ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := elgamal.PointDecrypt(switched, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+		t.Fatal("switched session ciphertext decrypts to a different point")
+	}
+
+	// 关闭后不能再置换
+	keys[0].Close()
+	keys[0].Close()
+	if _, err := keys[0].ShareBundle(&q.PublicKey, &ct.K); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected ErrSessionClosed, got %v", err)
+	}
+
+	// 证书不能用于其他会话或其他节点
+	if _, err := SessionPubKey(certs, longTerm, []byte("session-0003")); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("expected ErrProofFailed for another session, got %v", err)
+	}
+	longTerm[0], longTerm[1] = longTerm[1], longTerm[0]
+	if _, err := SessionPubKey(certs, longTerm, session); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("expected ErrProofFailed for swapped nodes, got %v", err)
+	}
+}
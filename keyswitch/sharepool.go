@@ -0,0 +1,122 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ShareRequest is one share a node is asked to calculate: the rB of a ciphertext
+// and the target public key to switch it to.
+// 份额请求：节点须计算的一个份额，即密文左侧点rB及置换的目标公钥。
+type ShareRequest struct {
+	TargetPubKey *sm2.PublicKey
+	RB           *elgamal.CurvePoint
+}
+
+// ShareResult is the outcome of one ShareRequest of ShareCalParallel. Index
+// is the position of the request; Share and Ri are those of ShareCal, and Bundle,
+// set only when proofs were asked for, also holds the share and its proof.
+// 份额计算结果：ShareCalParallel中一个份额请求的结果。Index为请求的下标；Share与Ri同ShareCal，
+// Bundle仅在要求生成证明时设置，包含份额及其证明。
+type ShareResult struct {
+	Index  int
+	Share  *elgamal.CipherText
+	Ri     *big.Int
+	Bundle *ShareBundle
+	Err    error
+}
+
+// ShareCalParallel calculates the shares of reqs with priv on a pool of workers
+// and, if prove is set, proves each of them as GenShareBundle does. Results are
+// sent on the returned channel in the order of reqs, each as soon as it and all
+// earlier ones are done, and the channel is closed after the last one. A failing
+// request reports its error in its result without stopping the others. workers is
+// capped to Parallelism, which workers <= 0 selects. The channel must be drained.
+// 并行份额计算：以workers个协程使用私钥priv计算reqs中各请求的份额，prove为真时同GenShareBundle
+// 为各份额生成证明。结果按reqs的顺序发送到返回的通道，一个结果及其之前的结果均完成后即发送，
+// 最后一个结果发送后关闭通道。失败的请求在其结果中返回错误，不影响其他请求。workers不超过
+// Parallelism，workers <= 0时取Parallelism。调用者须读尽通道。
+//
+// 参数：
+//		份额请求slice	reqs
+//		私钥			priv
+//		是否生成证明	prove
+//		计算协程数		workers
+// 返回：
+// 		结果通道
+func ShareCalParallel(reqs []ShareRequest, priv *sm2.PrivateKey, prove bool, workers int) <-chan ShareResult {
+	workers = workerCount(workers, len(reqs))
+
+	results := make([]ShareResult, len(reqs))
+	done := make([]chan struct{}, len(reqs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	jobs := make(chan int)
+	go func() {
+		for i := range reqs {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				results[i] = shareCalOne(i, &reqs[i], priv, prove)
+				close(done[i])
+			}
+		}()
+	}
+
+	// 按输入顺序发送：等待第i个结果完成后发送
+	out := make(chan ShareResult, workers)
+	go func() {
+		for i := range reqs {
+			<-done[i]
+			out <- results[i]
+			results[i] = ShareResult{}
+		}
+		close(out)
+	}()
+	return out
+}
+
+// shareCalOne calculates, and proves if asked to, the share of the request at index i.
+// 计算下标为i的请求的份额，按需生成证明。
+func shareCalOne(i int, req *ShareRequest, priv *sm2.PrivateKey, prove bool) ShareResult {
+	res := ShareResult{Index: i}
+	share, ri, err := ShareCal(req.TargetPubKey, req.RB, priv)
+	if err != nil {
+		res.Err = itemError("ShareCalParallel", "request", i, err)
+		return res
+	}
+	res.Share, res.Ri = share, ri
+	if prove {
+		b, err := proveShareBundle(proof.DefaultSuite, share, ri, req.TargetPubKey, req.RB, priv)
+		if err != nil {
+			res.Err = itemError("ShareCalParallel", "request", i, err)
+			return res
+		}
+		res.Bundle = b
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareCalParallel(t *testing.T) {
+	////////////////////////
+	// 模拟请求数量/////////
+	lens := 20
+	////////////////////////
+
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := make([]ShareRequest, lens)
+	for i := range reqs {
+		reqs[i] = ShareRequest{TargetPubKey: &q.PublicKey, RB: elgamal.GenPoint()}
+	}
+	// 第5个请求的rB无效
+	reqs[5].RB = nil
+
+	for _, prove := range []bool{false, true} {
+		next := 0
+		for res := range ShareCalParallel(reqs, priv, prove, 4) {
+			if res.Index != next {
+				t.Fatalf("got result %d, want %d", res.Index, next)
+			}
+			next++
+			if res.Index == 5 {
+				if res.Err == nil {
+					t.Fatal("invalid request succeeded")
+				}
+				continue
+			}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			if prove {
+				if res.Bundle == nil || !samePoint(&res.Bundle.Share.C, &res.Share.C) {
+					t.Fatal("missing bundle")
+				}
+				if ok, err := res.Bundle.Verify(); err != nil || !ok {
+					t.Fatalf("bundle %d failed to verify", res.Index)
+				}
+			} else if res.Bundle != nil {
+				t.Fatal("unexpected bundle")
+			}
+			// 与逐个计算的份额证明一致
+			c, r1, r2, err := ShareProofGenNoB(res.Ri, priv, res.Share, &q.PublicKey, reqs[res.Index].RB)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := ShareProofVryNoB(c, r1, r2, res.Share, &priv.PublicKey, &q.PublicKey, reqs[res.Index].RB); err != nil || !ok {
+				t.Fatalf("share %d failed to verify", res.Index)
+			}
+		}
+		if next != lens {
+			t.Fatalf("got %d results, want %d", next, lens)
+		}
+	}
+
+	for range ShareCalParallel(nil, priv, true, 0) {
+		t.Fatal("result for no request")
+	}
+}
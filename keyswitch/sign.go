@@ -0,0 +1,92 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// bundleSignDomain separates signed share bundles from other signed messages.
+// 份额包签名所用的域分隔标签，与其他签名消息区分。
+const bundleSignDomain = "ppks-share-bundle"
+
+// Sign signs b with the node key priv, which must be the key of b.NodePubKey, so
+// the bundle can travel over an unauthenticated transport. The signature covers
+// the share, the statement and the proof.
+// 份额包签名：以节点私钥priv（须与b.NodePubKey对应）对b签名，份额包可经未认证的信道传输。
+// 签名覆盖份额、公开信息与证明。
+//
+// 参数：
+//		节点私钥	priv
+// 返回：
+// 		签名
+func (b *ShareBundle) Sign(priv *sm2.PrivateKey) (*schnorr.Signature, error) {
+	if b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return nil, opError("ShareBundle.Sign", ErrIncompleteStatement)
+	}
+	if priv == nil || priv.X.Cmp(b.NodePubKey.X) != 0 || priv.Y.Cmp(b.NodePubKey.Y) != 0 {
+		return nil, opError("ShareBundle.Sign", ErrUnsupportedKey)
+	}
+	msg, err := b.SignedBytes()
+	if err != nil {
+		return nil, opError("ShareBundle.Sign", err)
+	}
+	sig, err := schnorr.Sign(priv, msg)
+	if err != nil {
+		return nil, opError("ShareBundle.Sign", err)
+	}
+	return sig, nil
+}
+
+// VerifySignature reports whether sig is a signature of b under b.NodePubKey. It
+// authenticates the sender only; Verify checks the share itself.
+// 验证份额包签名：判断sig是否为b.NodePubKey对b的签名。仅认证发送方，份额本身由Verify验证。
+//
+// 参数：
+//		签名	sig
+// 返回：
+// 		验证结果
+func (b *ShareBundle) VerifySignature(sig *schnorr.Signature) (bool, error) {
+	if b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return false, opError("ShareBundle.VerifySignature", ErrIncompleteStatement)
+	}
+	msg, err := b.SignedBytes()
+	if err != nil {
+		return false, opError("ShareBundle.VerifySignature", err)
+	}
+	ok, err := schnorr.Verify(b.NodePubKey, msg, sig)
+	if err != nil {
+		return false, opError("ShareBundle.VerifySignature", err)
+	}
+	return ok, nil
+}
+
+// SignedBytes returns the message signed for b by Sign: the domain, the fingerprint
+// of the share and statement, and the encoded proof. Other signature schemes, such
+// as SM2 signatures, sign the same bytes.
+// 返回Sign对b签名的消息：域分隔标签、份额与公开信息的指纹，以及证明编码。
+// SM2签名等其他签名方案对相同的字节签名。
+func (b *ShareBundle) SignedBytes() ([]byte, error) {
+	p, err := b.Proof.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	f := b.Fingerprint()
+	msg := append([]byte(bundleSignDomain), f[:]...)
+	return append(msg, p...), nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestShareBundleSign(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenShareBundle(&q.PublicKey, elgamal.GenPoint(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := b.Sign(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := b.VerifySignature(sig)
+	if err != nil || !ok {
+		t.Fatalf("bundle signature failed to verify: %v", err)
+	}
+
+	// 只能以节点自身私钥签名
+	if _, err := b.Sign(q); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("got %v, want ErrUnsupportedKey", err)
+	}
+
+	// 签名覆盖证明：换用另一份额包的证明后验证失败
+	other, err := GenShareBundle(&q.PublicKey, b.RB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Proof = other.Proof
+	if ok, _ := b.VerifySignature(sig); ok {
+		t.Fatal("signature verified after the proof was replaced")
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Subcommittee is the middle level of a two-level aggregated share proof, for
+// committees too large for one coordinator to talk to every node. Each department
+// leader collects the shares, keys and prover commitments of its members and Seals
+// them into one sum, which it adds to the top-level ProofAggregator as if it were a
+// single node. The leader relays the top-level challenge to its members, checks
+// each member's response against that member's own commitment, and sends the sum
+// of the responses up. The result is one AggregateShareProof for the whole
+// committee, while no party talks to more than one department's worth of peers.
+// A Subcommittee is not safe for concurrent use.
+// 子委员会：两级份额聚合证明的中间层，用于单个协调者无法与全部节点通信的大型委员会。各部门负责人收集其成员的
+// 份额、公钥与承诺值，经Seal加和后作为一个节点加入上层ProofAggregator。负责人将上层挑战值转发给成员，以各成员
+// 自身的承诺值检查其应答，再将应答之和上报。最终整个委员会得到一个AggregateShareProof，而任何一方通信的对象
+// 都不超过一个部门的规模。Subcommittee不可并发使用。
+type Subcommittee struct {
+	targetPubKey *sm2.PublicKey
+	A2           *elgamal.CurvePoint
+
+	shares []elgamal.CipherText
+	pubs   []*sm2.PublicKey
+	Ts     []*proof.Commitment
+
+	share     *elgamal.CipherText
+	c         *big.Int
+	r1, r2    *big.Int
+	responded []bool
+}
+
+// NewSubcommittee starts a department of an aggregated share proof for targetPubKey
+// and rB.
+// 创建子委员会：针对目标公钥targetPubKey与密文左侧点rB，开始聚合证明中的一个部门。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+// 返回：
+// 		子委员会
+func NewSubcommittee(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*Subcommittee, error) {
+	if targetPubKey == nil {
+		return nil, opError("NewSubcommittee", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return nil, opError("NewSubcommittee", err)
+	}
+	A2, err := elgamal.NegPoint(rB)
+	if err != nil {
+		return nil, opError("NewSubcommittee", err)
+	}
+	return &Subcommittee{targetPubKey: targetPubKey, A2: A2}, nil
+}
+
+// Add records the share, public key and prover commitment of one member and returns
+// its index for AddResponse. It fails once the department is sealed.
+// 添加成员：记录一个成员的份额、公钥与承诺值，返回其下标以供AddResponse使用。部门加和后不可再添加。
+//
+// 参数：
+//		份额		share
+//		节点公钥	nodePubKey
+//		承诺值		T
+// 返回：
+// 		成员下标
+func (s *Subcommittee) Add(share *elgamal.CipherText, nodePubKey *sm2.PublicKey, T *proof.Commitment) (int, error) {
+	if s.share != nil {
+		return -1, opError("Subcommittee.Add", ErrChallengeIssued)
+	}
+	if nodePubKey == nil || T == nil {
+		return -1, opError("Subcommittee.Add", ErrIncompleteStatement)
+	}
+	if err := elgamal.CheckCipherText(share); err != nil {
+		return -1, opError("Subcommittee.Add", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(nodePubKey)); err != nil {
+		return -1, opError("Subcommittee.Add", err)
+	}
+
+	s.shares = append(s.shares, *share)
+	s.pubs = append(s.pubs, nodePubKey)
+	s.Ts = append(s.Ts, T)
+	return len(s.shares) - 1, nil
+}
+
+// Seal sums the shares, keys and commitments of the members, to be added to the
+// upper ProofAggregator in place of the members. Later calls return the same sums.
+// 加和：加和成员的份额、公钥与承诺值，代替各成员加入上层ProofAggregator。再次调用返回相同结果。
+//
+// 参数：
+//
+// 返回：
+// 		份额和
+//		公钥和
+//		承诺值和
+func (s *Subcommittee) Seal() (*elgamal.CipherText, *sm2.PublicKey, *proof.Commitment, error) {
+	if len(s.shares) == 0 {
+		return nil, nil, nil, opError("Subcommittee.Seal", elgamal.ErrEmpty)
+	}
+	pub, err := AggregatePubKeys(s.pubs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	T, err := proof.AddCommitments(s.Ts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if s.share == nil {
+		curve := s.targetPubKey.Curve
+		share := s.shares[0]
+		for _, m := range s.shares[1:] {
+			share.K.X, share.K.Y = ec.Add(curve, share.K.X, share.K.Y, m.K.X, m.K.Y)
+			share.C.X, share.C.Y = ec.Add(curve, share.C.X, share.C.Y, m.C.X, m.C.Y)
+		}
+		s.share = &share
+	}
+	share := *s.share
+	return &share, pub, T, nil
+}
+
+// SetChallenge records the challenge of the upper ProofAggregator, which the leader
+// forwards to the members.
+// 设置挑战值：记录上层ProofAggregator的挑战值，负责人将其转发给成员。
+//
+// 参数：
+//		挑战值	c
+// 返回：
+//
+func (s *Subcommittee) SetChallenge(c *big.Int) error {
+	if s.share == nil {
+		return opError("Subcommittee.SetChallenge", ErrIncompleteStatement)
+	}
+	if c == nil {
+		return opError("Subcommittee.SetChallenge", ErrIncompleteProof)
+	}
+	if s.c != nil {
+		if s.c.Cmp(c) != 0 {
+			return opError("Subcommittee.SetChallenge", ErrChallengeIssued)
+		}
+		return nil
+	}
+	s.c = new(big.Int).Set(c)
+	s.r1, s.r2 = new(big.Int), new(big.Int)
+	s.responded = make([]bool, len(s.shares))
+	return nil
+}
+
+// AddResponse checks the responses (r1,r2) of the member at index against its own
+// share and commitment and adds them to the department's response. A mismatch is
+// reported as ErrProofFailed for that member.
+// 添加应答：以下标为index的成员自身的份额与承诺值检查其应答(r1,r2)，并计入部门应答。
+// 不符时返回该成员的ErrProofFailed。
+//
+// 参数：
+//		成员下标	index
+//		应答		r1,r2
+// 返回：
+//
+func (s *Subcommittee) AddResponse(index int, r1, r2 *big.Int) error {
+	if s.c == nil {
+		return opError("Subcommittee.AddResponse", ErrIncompleteProof)
+	}
+	if index < 0 || index >= len(s.shares) {
+		return itemError("Subcommittee.AddResponse", "node", index, elgamal.ErrOutOfRange)
+	}
+	if s.responded[index] {
+		return nil
+	}
+	share := &s.shares[index]
+	if !proof.CheckResponseNoB(s.c, r1, r2, &share.K, (*elgamal.CurvePoint)(s.pubs[index]), (*elgamal.CurvePoint)(s.targetPubKey), s.A2, &share.C, s.Ts[index]) {
+		return itemError("Subcommittee.AddResponse", "node", index, ErrProofFailed)
+	}
+
+	N := s.targetPubKey.Curve.Params().N
+	s.r1.Add(s.r1, r1)
+	s.r1.Mod(s.r1, N)
+	s.r2.Add(s.r2, r2)
+	s.r2.Mod(s.r2, N)
+	s.responded[index] = true
+	return nil
+}
+
+// Response returns the department's response to the upper challenge, the sum of
+// the members' responses, once every member has responded.
+// 返回部门对上层挑战值的应答，即成员应答之和，须全部成员均已应答。
+//
+// 参数：
+//
+// 返回：
+// 		应答	r1,r2
+func (s *Subcommittee) Response() (*big.Int, *big.Int, error) {
+	if s.c == nil {
+		return nil, nil, opError("Subcommittee.Response", ErrIncompleteProof)
+	}
+	for i, ok := range s.responded {
+		if !ok {
+			return nil, nil, itemError("Subcommittee.Response", "node", i, ErrIncompleteProof)
+		}
+	}
+	return new(big.Int).Set(s.r1), new(big.Int).Set(s.r2), nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSubcommittee(t *testing.T) {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := elgamal.GenPoint()
+
+	// 3个部门，每部门3名成员
+	var privs [][]*sm2.PrivateKey
+	var all []*sm2.PrivateKey
+	for d := 0; d < 3; d++ {
+		var dept []*sm2.PrivateKey
+		for m := 0; m < 3; m++ {
+			priv, err := sm2.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dept = append(dept, priv)
+			all = append(all, priv)
+		}
+		privs = append(privs, dept)
+	}
+	var allPubs []*sm2.PublicKey
+	for _, priv := range all {
+		allPubs = append(allPubs, &priv.PublicKey)
+	}
+	collPub, err := AggregatePubKeys(allPubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rct, err := elgamal.PointEncrypt(collPub, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := &rct.K
+
+	top, err := NewProofAggregator(proof.DefaultSuite, &q.PublicKey, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depts := make([]*Subcommittee, len(privs))
+	provers := make([][]*proof.Prover, len(privs))
+	for d, dept := range privs {
+		if depts[d], err = NewSubcommittee(&q.PublicKey, rB); err != nil {
+			t.Fatal(err)
+		}
+		for _, priv := range dept {
+			share, ri, err := ShareCal(&q.PublicKey, rB, priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := NewShareProver(ri, priv, &q.PublicKey, rB)
+			if err != nil {
+				t.Fatal(err)
+			}
+			provers[d] = append(provers[d], p)
+			if _, err := depts[d].Add(share, &priv.PublicKey, p.Commitment()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		share, pub, T, err := depts[d].Seal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := top.Add(share, pub, T); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := top.Challenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for d, dept := range depts {
+		if err := dept.SetChallenge(c); err != nil {
+			t.Fatal(err)
+		}
+		if d == 0 {
+			// 错误的成员应答在部门内即被发现
+			if err := dept.AddResponse(0, big.NewInt(1), big.NewInt(2)); !errors.Is(err, ErrProofFailed) {
+				t.Fatalf("got %v, want ErrProofFailed", err)
+			}
+			if _, _, err := dept.Response(); !errors.Is(err, ErrIncompleteProof) {
+				t.Fatalf("got %v, want ErrIncompleteProof", err)
+			}
+		}
+		for m, p := range provers[d] {
+			r1, r2, err := p.Respond(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := dept.AddResponse(m, r1, r2); err != nil {
+				t.Fatal(err)
+			}
+		}
+		r1, r2, err := dept.Response()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := top.AddResponse(d, r1, r2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ap, err := top.Proof()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := ap.Verify(allPubs, &q.PublicKey, rB); err != nil || !ok {
+		t.Fatal("two-level aggregated proof failed to verify")
+	}
+
+	ct, err := ShareReplace(&elgamal.CipherVector{ap.Share}, rct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D, err := elgamal.PointDecrypt(ct, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(M.X) || 0 != D.Y.Cmp(M.Y) {
+		t.Fatal("decrypted point differs")
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// KeySwitcher is a node key that can take part in key switching. It extends
+// crypto.Signer, so keys held by an HSM or a KMS, whose private scalar never
+// exists in process memory, can serve as KS server keys.
+// 密钥置换接口：可参与密钥置换的节点密钥，扩展了crypto.Signer，
+// 使私钥从不出现在进程内存中的HSM或KMS密钥亦可作为ks server密钥。
+type KeySwitcher interface {
+	crypto.Signer
+	// ShareBundle calculates and proves the share related with rB for targetPubKey.
+	// 为目标公钥targetPubKey计算关于点rB的份额及其证明。
+	ShareBundle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*ShareBundle, error)
+}
+
+// privKeySwitcher is the KeySwitcher of an in-memory SM2 private key. It is also a
+// crypto.Decrypter through the embedded key.
+// 内存中SM2私钥的KeySwitcher实现，通过内嵌私钥同时实现crypto.Decrypter。
+type privKeySwitcher struct {
+	*sm2.PrivateKey
+	suite proof.Suite
+}
+
+func (s privKeySwitcher) ShareBundle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*ShareBundle, error) {
+	return GenShareBundleWithSuite(s.suite, targetPubKey, rB, s.PrivateKey)
+}
+
+// NewKeySwitcher returns the KeySwitcher of the in-memory private key priv, proving
+// shares with suite.
+// 创建内存私钥priv的KeySwitcher，以参数组suite生成份额证明。
+//
+// 参数：
+//		私钥	priv
+//		参数组	suite
+// 返回：
+// 		KeySwitcher
+func NewKeySwitcher(priv *sm2.PrivateKey, suite proof.Suite) KeySwitcher {
+	return privKeySwitcher{PrivateKey: priv, suite: suite}
+}
+
+// AsKeySwitcher adapts key to a KeySwitcher. Only two kinds of keys are
+// accepted: a KeySwitcher, returned as is, and an in-memory *sm2.PrivateKey,
+// wrapped with proof.DefaultSuite. Any other key, including a plain HSM or KMS
+// crypto.Signer or crypto.Decrypter, fails with ErrUnsupportedKey: neither a
+// signature nor an SM2 decryption yields the point k*rB a share needs, so such a
+// key must implement KeySwitcher itself.
+// 适配密钥：将key适配为KeySwitcher，仅接受两类密钥：KeySwitcher原样返回，内存中的*sm2.PrivateKey
+// 以默认参数组封装。其他密钥，包括仅实现crypto.Signer或crypto.Decrypter的HSM或KMS密钥，均返回
+// ErrUnsupportedKey：签名与SM2解密都不给出份额所需的点k*rB，此类密钥须自行实现KeySwitcher。
+//
+// 参数：
+//		密钥	key
+// 返回：
+// 		KeySwitcher
+func AsKeySwitcher(key crypto.PrivateKey) (KeySwitcher, error) {
+	switch k := key.(type) {
+	case KeySwitcher:
+		return k, nil
+	case *sm2.PrivateKey:
+		return NewKeySwitcher(k, proof.DefaultSuite), nil
+	default:
+		return nil, opError("AsKeySwitcher", ErrUnsupportedKey)
+	}
+}
+
+// ShareCalWith lets ks calculate and prove the share related with rB for
+// targetPubKey, checking the inputs and that the bundle is made by ks.
+// 份额计算：由ks为目标公钥targetPubKey计算关于点rB的份额及证明，并校验输入以及份额包确由ks生成。
+//
+// 参数：
+//		节点密钥	ks
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+// 返回：
+// 		份额包
+func ShareCalWith(ks KeySwitcher, targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*ShareBundle, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return nil, opError("ShareCalWith", err)
+	}
+	if err := elgamal.CheckPoint(rB); err != nil {
+		return nil, opError("ShareCalWith", err)
+	}
+
+	b, err := ks.ShareBundle(targetPubKey, rB)
+	if err != nil {
+		return nil, opError("ShareCalWith", err)
+	}
+	pub, ok := ks.Public().(*sm2.PublicKey)
+	if !ok {
+		return nil, opError("ShareCalWith", ErrUnsupportedKey)
+	}
+	if b == nil || !sameKey(b.NodePubKey, pub) {
+		return nil, opError("ShareCalWith", ErrProofFailed)
+	}
+	return b, nil
+}
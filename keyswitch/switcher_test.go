@@ -0,0 +1,176 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// hsmKey 模拟私钥不出设备的HSM密钥：只暴露KeySwitcher接口。
+type hsmKey struct {
+	ks KeySwitcher
+}
+
+func (k hsmKey) Public() crypto.PublicKey { return k.ks.Public() }
+
+func (k hsmKey) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return k.ks.Sign(r, digest, opts)
+}
+
+func (k hsmKey) ShareBundle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*ShareBundle, error) {
+	return k.ks.ShareBundle(targetPubKey, rB)
+}
+
+// countingCurve stands in for an optimized backend: the SM2 curve counting its
+// scalar multiplications.
+type countingCurve struct {
+	elliptic.Curve
+	mults *int64
+}
+
+func (c countingCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(c.mults, 1)
+	return c.Curve.ScalarMult(x, y, k)
+}
+
+func (c countingCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(c.mults, 1)
+	return c.Curve.ScalarBaseMult(k)
+}
+
+func TestKeySwitcherBackend(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	var mults int64
+	if err := proof.RegisterBackend("counting-ks", countingCurve{sm2.P256Sm2(), &mults}); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt64(&mults, 0)
+
+	// 份额计算的三次标量乘（riB、ki*rB、riU）均由后端完成
+	share, _, err := ShareCalWithSuite(proof.Suite{Backend: "counting-ks"}, &q.PublicKey, rB, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&mults); n != 3 {
+		t.Fatalf("ShareCal: %d backend multiplications, want 3", n)
+	}
+	if err := elgamal.CheckCipherText(share); err != nil {
+		t.Fatal(err)
+	}
+
+	// 经NewKeySwitcher生成的份额包同样使用后端，且无需后端即可验证
+	atomic.StoreInt64(&mults, 0)
+	b, err := ShareCalWith(NewKeySwitcher(priv, proof.Suite{Backend: "counting-ks"}), &q.PublicKey, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&mults); n < 3 {
+		t.Fatalf("ShareBundle: %d backend multiplications, want at least 3", n)
+	}
+	if ok, err := b.Verify(); err != nil || !ok {
+		t.Fatalf("bundle made with a backend failed to verify: %v", err)
+	}
+
+	if _, _, err := ShareCalWithSuite(proof.Suite{Backend: "missing"}, &q.PublicKey, rB, priv); !errors.Is(err, proof.ErrUnknownBackend) {
+		t.Fatalf("unknown backend: got %v", err)
+	}
+}
+
+func TestKeySwitcher(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	ks, err := AsKeySwitcher(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存私钥的KeySwitcher同时为crypto.Decrypter
+	if _, ok := ks.(crypto.Decrypter); !ok {
+		t.Fatal("in-memory switcher is not a crypto.Decrypter")
+	}
+
+	for _, k := range []KeySwitcher{ks, hsmKey{NewKeySwitcher(priv, proof.Suite{Hash: proof.HashSHA256})}} {
+		b, err := ShareCalWith(k, &q.PublicKey, rB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := b.Verify(); err != nil || !ok {
+			t.Fatalf("bundle failed to verify: %v", err)
+		}
+	}
+
+	// 以他人公钥冒充
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ShareCalWith(mixedKey{pub: other, ks: ks}, &q.PublicKey, rB); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AsKeySwitcher(ecKey); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("got %v, want ErrUnsupportedKey", err)
+	}
+}
+
+// mixedKey 声称的公钥与实际计算份额的密钥不一致。
+type mixedKey struct {
+	pub *sm2.PrivateKey
+	ks  KeySwitcher
+}
+
+func (k mixedKey) Public() crypto.PublicKey { return &k.pub.PublicKey }
+
+func (k mixedKey) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return k.ks.Sign(r, digest, opts)
+}
+
+func (k mixedKey) ShareBundle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*ShareBundle, error) {
+	return k.ks.ShareBundle(targetPubKey, rB)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ThresholdKey is a server's Shamir share of the collective private key: Priv.D is
+// f(Index) for the secret polynomial f with f(0) the collective private key, and
+// Priv.PublicKey is f(Index)B.
+// 门限私钥份额：ks server持有的聚合私钥的Shamir份额，Priv.D为秘密多项式f在Index处的值f(Index)，
+// f(0)为聚合私钥，Priv.PublicKey为f(Index)B。
+type ThresholdKey struct {
+	Index int
+	Priv  *sm2.PrivateKey
+}
+
+// SplitPrivKey Shamir-shares priv into n keys of which any t recover it, for servers
+// 1..n. The dealer should discard priv afterwards. A nil random uses crypto/rand.
+// 私钥分割：将私钥priv以Shamir秘密共享分割为n份，分别交给编号1..n的ks server，任意t份可恢复。
+// 分发者此后应丢弃priv。random为nil时使用crypto/rand。
+//
+// 参数：
+//		私钥	priv
+//		门限	t
+//		份数	n
+//		随机源	random
+// 返回：
+// 		门限私钥份额slice
+func SplitPrivKey(priv *sm2.PrivateKey, t, n int, random io.Reader) ([]ThresholdKey, error) {
+	keys, _, err := splitPrivKey("SplitPrivKey", priv, t, n, random)
+	return keys, err
+}
+
+// splitPrivKey Shamir-shares priv like SplitPrivKey and also returns the coefficients
+// of the polynomial, f(0) = priv.D first.
+// 与SplitPrivKey相同地分割私钥priv，并返回多项式系数，首项为f(0) = priv.D。
+func splitPrivKey(op string, priv *sm2.PrivateKey, t, n int, random io.Reader) ([]ThresholdKey, []*big.Int, error) {
+	if priv == nil || priv.D == nil || priv.Curve == nil {
+		return nil, nil, opError(op, elgamal.ErrEmpty)
+	}
+	if t < 1 || n < t {
+		return nil, nil, opError(op, ErrInvalidThreshold)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+
+	// 随机多项式f(x) = d + a1x + ... + a(t-1)x^(t-1)
+	curve := priv.Curve
+	N := curve.Params().N
+	coeffs := make([]*big.Int, t)
+	coeffs[0] = priv.D
+	for j := 1; j < t; j++ {
+		a, err := ec.RandFieldElement(curve, random)
+		if err != nil {
+			return nil, nil, opError(op, err)
+		}
+		coeffs[j] = a
+	}
+
+	keys := make([]ThresholdKey, n)
+	for i := 1; i <= n; i++ {
+		// 秦九韶算法求f(i)
+		x := big.NewInt(int64(i))
+		d := new(big.Int)
+		for j := t - 1; j >= 0; j-- {
+			d.Mul(d, x)
+			d.Add(d, coeffs[j])
+			d.Mod(d, N)
+		}
+
+		k := new(sm2.PrivateKey)
+		k.Curve = curve
+		k.D = d
+		k.X, k.Y = curve.ScalarBaseMult(d.Bytes())
+		keys[i-1] = ThresholdKey{Index: i, Priv: k}
+	}
+
+	return keys, coeffs, nil
+}
+
+// LagrangeCoefficient returns the Lagrange coefficient at 0 of server index within
+// the set indices of the servers taking part, modulo the order of curve. Indices
+// must be distinct, positive and contain index.
+// 拉格朗日系数：返回编号index在参与的ks server编号集合indices中于0处的拉格朗日系数（模曲线的阶）。
+// 编号须互不相同、为正，且包含index。
+//
+// 参数：
+//		曲线	curve
+//		编号	index
+//		编号集合	indices
+// 返回：
+// 		拉格朗日系数
+func LagrangeCoefficient(curve elliptic.Curve, index int, indices []int) (*big.Int, error) {
+	if curve == nil {
+		curve = sm2.P256Sm2()
+	}
+	N := curve.Params().N
+
+	found := false
+	seen := make(map[int]bool, len(indices))
+	for i, j := range indices {
+		if j < 1 || seen[j] {
+			return nil, itemError("LagrangeCoefficient", "index", i, ErrInvalidThreshold)
+		}
+		seen[j] = true
+		found = found || j == index
+	}
+	if !found {
+		return nil, opError("LagrangeCoefficient", ErrInvalidThreshold)
+	}
+
+	// λ = Π j/(j-index)，j取indices中index之外的编号
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+	for _, j := range indices {
+		if j == index {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		num.Mod(num, N)
+		den.Mul(den, big.NewInt(int64(j-index)))
+		den.Mod(den, N)
+	}
+	den.ModInverse(den, N)
+
+	return num.Mul(num, den).Mod(num, N), nil
+}
+
+// Weighted returns the key λf(Index), λ being the Lagrange coefficient of k within
+// indices. The shares that the servers in indices calculate with their weighted
+// keys are combined by ShareReplace as usual, and are proven with ShareProofGen.
+// 加权私钥：返回私钥λf(Index)，λ为k在indices中的拉格朗日系数。indices中的ks server以加权私钥计算的份额，
+// 照常由ShareReplace置换，并以ShareProofGen证明。
+//
+// 参数：
+//		编号集合	indices
+// 返回：
+// 		加权私钥
+func (k *ThresholdKey) Weighted(indices []int) (*sm2.PrivateKey, error) {
+	if k.Priv == nil || k.Priv.D == nil || k.Priv.Curve == nil {
+		return nil, opError("ThresholdKey.Weighted", elgamal.ErrEmpty)
+	}
+	curve := k.Priv.Curve
+	lambda, err := LagrangeCoefficient(curve, k.Index, indices)
+	if err != nil {
+		return nil, err
+	}
+
+	w := new(sm2.PrivateKey)
+	w.Curve = curve
+	w.D = lambda.Mul(lambda, k.Priv.D).Mod(lambda, curve.Params().N)
+	w.X, w.Y = curve.ScalarBaseMult(w.D.Bytes())
+
+	return w, nil
+}
+
+// WeightedPubKey returns λK, the public key matching ThresholdKey.Weighted for the
+// server index with public key pub, so that its share proof can be verified.
+// 加权公钥：返回λK，与编号为index、公钥为pub的ks server的ThresholdKey.Weighted对应，用于验证其份额证明。
+//
+// 参数：
+//		公钥	pub
+//		编号	index
+//		编号集合	indices
+// 返回：
+// 		加权公钥
+func WeightedPubKey(pub *sm2.PublicKey, index int, indices []int) (*sm2.PublicKey, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, opError("WeightedPubKey", err)
+	}
+	lambda, err := LagrangeCoefficient(pub.Curve, index, indices)
+	if err != nil {
+		return nil, err
+	}
+
+	w := new(sm2.PublicKey)
+	w.Curve = pub.Curve
+	w.X, w.Y = pub.Curve.ScalarMult(pub.X, pub.Y, lambda.Bytes())
+
+	return w, nil
+}
+
+// ThresholdShareCal calculates the share related with rB for targetPubKey with the
+// threshold key k, weighted by its Lagrange coefficient within indices, the servers
+// taking part. ShareReplace then succeeds with the shares of any t servers.
+// 门限份额计算：使用以indices（参与的ks server编号集合）中拉格朗日系数加权的门限私钥k，
+// 为目标公钥targetPubKey计算关于点rB的份额。任意t个ks server的份额即可由ShareReplace完成置换。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		门限私钥份额	k
+//		编号集合	indices
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func ThresholdShareCal(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, k *ThresholdKey, indices []int) (*elgamal.CipherText, *big.Int, error) {
+	w, err := k.Weighted(indices)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ShareCal(targetPubKey, rB, w)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestThresholdShareReplace(t *testing.T) {
+	collPriv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := SplitPrivKey(collPriv, 3, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	M := elgamal.GenPoint()
+	rct, err := elgamal.PointEncrypt(&collPriv.PublicKey, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 任意3个ks server即可完成置换
+	for _, indices := range [][]int{{1, 2, 3}, {2, 4, 5}, {5, 1, 3}} {
+		shares := make(elgamal.CipherVector, len(indices))
+		for i, j := range indices {
+			k := &keys[j-1]
+			share, ri, err := ThresholdShareCal(&q.PublicKey, &rct.K, k, indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, err := k.Weighted(indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, r1, r2, err := ShareProofGen(ri, w, share, &q.PublicKey, &rct.K)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 验证者由公开的份额公钥得到加权公钥
+			wPub, err := WeightedPubKey(&k.Priv.PublicKey, k.Index, indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := ShareProofVry(c, r1, r2, share, wPub, &q.PublicKey, &rct.K); err != nil || !ok {
+				t.Fatalf("share proof of server %d failed: %v", j, err)
+			}
+			shares[i] = *share
+		}
+
+		ct, err := ShareReplace(&shares, rct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		D, err := elgamal.PointDecrypt(ct, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != D.X.Cmp(M.X) || 0 != D.Y.Cmp(M.Y) {
+			t.Fatalf("servers %v failed to switch", indices)
+		}
+	}
+
+	// 少于门限的份额无法置换
+	indices := []int{1, 2}
+	shares := make(elgamal.CipherVector, len(indices))
+	for i, j := range indices {
+		share, _, err := ThresholdShareCal(&q.PublicKey, &rct.K, &keys[j-1], indices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = *share
+	}
+	ct, err := ShareReplace(&shares, rct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if D, err := elgamal.PointDecrypt(ct, q); err == nil && 0 == D.X.Cmp(M.X) {
+		t.Fatal("2 of 3 servers switched the ciphertext")
+	}
+}
+
+func TestThresholdParameters(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SplitPrivKey(priv, 4, 3, nil); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+	if _, err := SplitPrivKey(priv, 0, 3, nil); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+
+	// 1-of-n即复制私钥
+	keys, err := SplitPrivKey(priv, 1, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != keys[2].Priv.D.Cmp(priv.D) {
+		t.Fatal("1-of-n share differs from the key")
+	}
+
+	for _, indices := range [][]int{{1, 1, 2}, {0, 1}, {2, 3}} {
+		if _, err := LagrangeCoefficient(nil, 1, indices); !errors.Is(err, ErrInvalidThreshold) {
+			t.Fatalf("%v: got %v, want ErrInvalidThreshold", indices, err)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"encoding/binary"
+	"time"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// transcriptVersion is the first byte of every encoded SessionTranscript.
+// SessionTranscript编码的版本号，位于编码首字节。
+const transcriptVersion = 1
+
+// Sizes of the SessionTranscript encoding.
+// SessionTranscript编码各部分长度。
+const (
+	transcriptPointLen  = 33
+	transcriptBundleLen = 2 + 2*transcriptPointLen + paiLen
+)
+
+// SessionTranscript is the public record of one key switch: the ciphertext under
+// the committee key, the requester's target key, the registered committee and the
+// share bundle of every member. Anyone holding it can Verify that the requester
+// legitimately obtained the plaintext, that is every member proved its share for
+// exactly this ciphertext and requester, and recompute with Result what the
+// requester received. It holds no secret, so it can be written to a ledger as is.
+// 会话记录：一次密钥置换的公开记录，包括委员会公钥下的密文、请求者的目标公钥、登记的委员会成员，以及每个成员的
+// 份额包。任何持有者均可调用Verify确认请求者合法获得了明文，即每个成员都针对该密文与该请求者证明了其份额，
+// 并可调用Result重新计算请求者得到的密文。记录不含任何秘密，可原样写入账本。
+type SessionTranscript struct {
+	CipherText   elgamal.CipherText
+	TargetPubKey *sm2.PublicKey
+	NodePubKeys  []*sm2.PublicKey
+	// Policy is the policy the shares were produced under, if any. Verify checks
+	// the proofs under it but, being an audit after the fact, not its expiry.
+	// 份额生成时所在的策略，可为空。Verify在该策略下验证证明，但作为事后审计，不检查其是否失效。
+	Policy  *Policy
+	Bundles []*ShareBundle
+}
+
+// NewSessionTranscript starts the transcript of switching ct, encrypted under the
+// sum of nodePubKeys, to targetPubKey, optionally under policy.
+// 创建会话记录：记录将委员会公钥nodePubKeys之和下的密文ct置换到目标公钥targetPubKey的过程，策略policy可为空。
+//
+// 参数：
+//		密文			ct
+//		目标公钥		targetPubKey
+//		节点公钥slice	nodePubKeys
+//		策略			policy
+// 返回：
+// 		会话记录
+func NewSessionTranscript(ct *elgamal.CipherText, targetPubKey *sm2.PublicKey, nodePubKeys []*sm2.PublicKey, policy *Policy) (*SessionTranscript, error) {
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("NewSessionTranscript", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(targetPubKey)); err != nil {
+		return nil, opError("NewSessionTranscript", err)
+	}
+	if len(nodePubKeys) == 0 {
+		return nil, opError("NewSessionTranscript", elgamal.ErrEmpty)
+	}
+	for i, pub := range nodePubKeys {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return nil, itemError("NewSessionTranscript", "node", i, err)
+		}
+		for _, prev := range nodePubKeys[:i] {
+			if sameKey(prev, pub) {
+				return nil, itemError("NewSessionTranscript", "node", i, ErrUnknownNode)
+			}
+		}
+	}
+
+	t := &SessionTranscript{
+		CipherText:   *ct,
+		TargetPubKey: targetPubKey,
+		NodePubKeys:  append([]*sm2.PublicKey(nil), nodePubKeys...),
+	}
+	if policy != nil {
+		p := *policy
+		t.Policy = &p
+	}
+	return t, nil
+}
+
+// Add records the bundle b of a member. b must be about the transcript's ciphertext,
+// target key and policy, and come from a member not yet recorded; its proof is left
+// to Verify.
+// 添加份额包：记录成员的份额包b。b须针对本记录的密文、目标公钥与策略，且来自尚未记录的成员，其证明由Verify验证。
+//
+// 参数：
+//		份额包	b
+// 返回：
+//
+func (t *SessionTranscript) Add(b *ShareBundle) error {
+	if b == nil || b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+		return opError("SessionTranscript.Add", ErrIncompleteStatement)
+	}
+	if !sameKey(b.TargetPubKey, t.TargetPubKey) || !samePoint(b.RB, &t.CipherText.K) {
+		return opError("SessionTranscript.Add", ErrIncompleteStatement)
+	}
+	if !samePolicy(b.Policy, t.Policy) {
+		return opError("SessionTranscript.Add", ErrIncompleteStatement)
+	}
+	i := t.nodeIndex(b.NodePubKey)
+	if i < 0 {
+		return opError("SessionTranscript.Add", ErrUnknownNode)
+	}
+	for _, prev := range t.Bundles {
+		if sameKey(prev.NodePubKey, b.NodePubKey) {
+			return itemError("SessionTranscript.Add", "node", i, ErrUnknownNode)
+		}
+	}
+	t.Bundles = append(t.Bundles, b)
+	return nil
+}
+
+// Verify reports whether t is complete and sound: every registered member has
+// exactly one bundle, about the recorded ciphertext, target key and policy, whose
+// proof verifies. A failing bundle is reported as ErrProofFailed for its member.
+// 验证会话记录：判断t是否完整且正确，即每个登记成员恰有一个份额包，均针对所记录的密文、目标公钥与策略，
+// 且证明通过验证。未通过的份额包返回该成员的ErrProofFailed。
+//
+// 参数：
+//
+// 返回：
+// 		验证结果：	bool
+func (t *SessionTranscript) Verify() (bool, error) {
+	if len(t.NodePubKeys) == 0 || t.TargetPubKey == nil {
+		return false, opError("SessionTranscript.Verify", ErrIncompleteStatement)
+	}
+	seen := make([]bool, len(t.NodePubKeys))
+	for _, b := range t.Bundles {
+		if b == nil || b.NodePubKey == nil || b.TargetPubKey == nil || b.RB == nil {
+			return false, opError("SessionTranscript.Verify", ErrIncompleteStatement)
+		}
+		i := t.nodeIndex(b.NodePubKey)
+		if i < 0 || seen[i] {
+			return false, opError("SessionTranscript.Verify", ErrUnknownNode)
+		}
+		seen[i] = true
+		if !sameKey(b.TargetPubKey, t.TargetPubKey) || !samePoint(b.RB, &t.CipherText.K) || !samePolicy(b.Policy, t.Policy) {
+			return false, nil
+		}
+
+		c, r1, r2 := b.Proof.Values()
+		if c == nil || r1 == nil || r2 == nil {
+			return false, itemError("SessionTranscript.Verify", "node", i, ErrProofFailed)
+		}
+		ok, err := shareProofVryNoB(b.suite(), c, r1, r2, &b.Share, b.NodePubKey, b.TargetPubKey, b.RB)
+		if err != nil {
+			return false, itemError("SessionTranscript.Verify", "node", i, err)
+		}
+		if !ok {
+			return false, itemError("SessionTranscript.Verify", "node", i, ErrProofFailed)
+		}
+	}
+	for i, ok := range seen {
+		if !ok {
+			return false, itemError("SessionTranscript.Verify", "node", i, ErrIncompleteProof)
+		}
+	}
+	return true, nil
+}
+
+// PublicKey returns the committee key the ciphertext is encrypted under, the sum of
+// NodePubKeys, for comparison with the published one.
+// 返回密文所用的委员会公钥，即NodePubKeys之和，供与公布的委员会公钥比对。
+func (t *SessionTranscript) PublicKey() (*sm2.PublicKey, error) {
+	return AggregatePubKeys(t.NodePubKeys)
+}
+
+// Result returns the ciphertext the requester obtained, the recorded ciphertext
+// with its shares replaced. Call Verify first.
+// 返回请求者得到的密文，即以所记录的份额置换后的密文。应先调用Verify。
+//
+// 参数：
+//
+// 返回：
+// 		新密文
+func (t *SessionTranscript) Result() (*elgamal.CipherText, error) {
+	shares := make(elgamal.CipherVector, len(t.Bundles))
+	for i, b := range t.Bundles {
+		shares[i] = b.Share
+	}
+	return ShareReplace(&shares, &t.CipherText)
+}
+
+// Digest returns the SM3 hash of the encoding of t, a short reference to the
+// transcript for a ledger entry.
+// 返回t编码的SM3哈希值，可作为账本条目中对该记录的简短引用。
+func (t *SessionTranscript) Digest() ([]byte, error) {
+	b, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	h := sm3.New()
+	h.Write(b)
+	return h.Sum(nil), nil
+}
+
+// MarshalBinary encodes t as the version, the compressed points of the ciphertext
+// and the target key, the policy encoding prefixed by its 4-byte length (0 if
+// none), the 2-byte count and compressed keys of the members, then the 2-byte count
+// of bundles, each as the member index, the compressed share and the proof.
+// 序列化会话记录：依次编码版本号、密文与目标公钥的压缩点、带4字节长度前缀的策略编码（无策略时长度为0）、
+// 2字节成员数及各成员压缩公钥，最后为2字节份额包数及各份额包（成员下标、压缩份额与证明）。
+func (t *SessionTranscript) MarshalBinary() ([]byte, error) {
+	if err := elgamal.CheckCipherText(&t.CipherText); err != nil {
+		return nil, opError("SessionTranscript.MarshalBinary", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(t.TargetPubKey)); err != nil {
+		return nil, opError("SessionTranscript.MarshalBinary", err)
+	}
+	if len(t.NodePubKeys) > 0xffff || len(t.Bundles) > len(t.NodePubKeys) {
+		return nil, opError("SessionTranscript.MarshalBinary", ErrInvalidTranscript)
+	}
+
+	var n [4]byte
+	b := []byte{transcriptVersion}
+	b = append(b, t.CipherText.K.CompressedBytes()...)
+	b = append(b, t.CipherText.C.CompressedBytes()...)
+	b = append(b, (*elgamal.CurvePoint)(t.TargetPubKey).CompressedBytes()...)
+	var policy []byte
+	if t.Policy != nil {
+		policy = t.Policy.Encode()
+	}
+	binary.BigEndian.PutUint32(n[:], uint32(len(policy)))
+	b = append(b, n[:]...)
+	b = append(b, policy...)
+
+	binary.BigEndian.PutUint16(n[:2], uint16(len(t.NodePubKeys)))
+	b = append(b, n[:2]...)
+	for i, pub := range t.NodePubKeys {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+			return nil, itemError("SessionTranscript.MarshalBinary", "node", i, err)
+		}
+		b = append(b, (*elgamal.CurvePoint)(pub).CompressedBytes()...)
+	}
+	binary.BigEndian.PutUint16(n[:2], uint16(len(t.Bundles)))
+	b = append(b, n[:2]...)
+	for j, bundle := range t.Bundles {
+		i := -1
+		if bundle != nil && bundle.NodePubKey != nil {
+			i = t.nodeIndex(bundle.NodePubKey)
+		}
+		if i < 0 {
+			return nil, itemError("SessionTranscript.MarshalBinary", "bundle", j, ErrUnknownNode)
+		}
+		if err := elgamal.CheckCipherText(&bundle.Share); err != nil {
+			return nil, itemError("SessionTranscript.MarshalBinary", "bundle", j, err)
+		}
+		p, err := bundle.Proof.MarshalBinary()
+		if err != nil {
+			return nil, itemError("SessionTranscript.MarshalBinary", "bundle", j, err)
+		}
+		binary.BigEndian.PutUint16(n[:2], uint16(i))
+		b = append(b, n[:2]...)
+		b = append(b, bundle.Share.K.CompressedBytes()...)
+		b = append(b, bundle.Share.C.CompressedBytes()...)
+		b = append(b, p...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a transcript encoded by MarshalBinary, rebuilding the
+// statement of every bundle from the transcript.
+// 反序列化会话记录：解析MarshalBinary编码的会话记录，并由记录本身重建各份额包的公开信息。
+func (t *SessionTranscript) UnmarshalBinary(b []byte) error {
+	const op = "SessionTranscript.UnmarshalBinary"
+	if len(b) < 1+3*transcriptPointLen+4 || b[0] != transcriptVersion {
+		return opError(op, ErrInvalidTranscript)
+	}
+	b = b[1:]
+	var pts [3]*elgamal.CurvePoint
+	for i := range pts {
+		p, err := elgamal.NewCurvePointFromBytes(nil, b[:transcriptPointLen])
+		if err != nil {
+			return opError(op, err)
+		}
+		pts[i] = p
+		b = b[transcriptPointLen:]
+	}
+
+	plen := int(binary.BigEndian.Uint32(b))
+	b = b[4:]
+	if plen < 0 || len(b) < plen+2 {
+		return opError(op, ErrInvalidTranscript)
+	}
+	var policy *Policy
+	if plen > 0 {
+		var ok bool
+		if policy, ok = decodePolicy(b[:plen]); !ok {
+			return opError(op, ErrInvalidTranscript)
+		}
+	}
+	b = b[plen:]
+
+	nodes := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	if len(b) < nodes*transcriptPointLen+2 {
+		return opError(op, ErrInvalidTranscript)
+	}
+	pubs := make([]*sm2.PublicKey, nodes)
+	for i := range pubs {
+		p, err := elgamal.NewCurvePointFromBytes(nil, b[:transcriptPointLen])
+		if err != nil {
+			return itemError(op, "node", i, err)
+		}
+		pubs[i] = (*sm2.PublicKey)(p)
+		b = b[transcriptPointLen:]
+	}
+
+	count := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	if count > nodes || len(b) != count*transcriptBundleLen {
+		return opError(op, ErrInvalidTranscript)
+	}
+	target := (*sm2.PublicKey)(pts[2])
+	rB := pts[0]
+	bundles := make([]*ShareBundle, count)
+	for j := range bundles {
+		i := int(binary.BigEndian.Uint16(b))
+		if i >= nodes {
+			return itemError(op, "bundle", j, ErrUnknownNode)
+		}
+		K, err := elgamal.NewCurvePointFromBytes(nil, b[2:2+transcriptPointLen])
+		if err != nil {
+			return itemError(op, "bundle", j, err)
+		}
+		C, err := elgamal.NewCurvePointFromBytes(nil, b[2+transcriptPointLen:2+2*transcriptPointLen])
+		if err != nil {
+			return itemError(op, "bundle", j, err)
+		}
+		bundle := &ShareBundle{
+			Share:        elgamal.CipherText{K: *K, C: *C},
+			NodePubKey:   pubs[i],
+			TargetPubKey: target,
+			RB:           rB,
+			Policy:       policy,
+		}
+		if err := bundle.Proof.UnmarshalBinary(b[2+2*transcriptPointLen : transcriptBundleLen]); err != nil {
+			return itemError(op, "bundle", j, err)
+		}
+		bundles[j] = bundle
+		b = b[transcriptBundleLen:]
+	}
+
+	*t = SessionTranscript{
+		CipherText:   elgamal.CipherText{K: *pts[0], C: *pts[1]},
+		TargetPubKey: target,
+		NodePubKeys:  pubs,
+		Policy:       policy,
+		Bundles:      bundles,
+	}
+	return nil
+}
+
+// nodeIndex returns the index of pub among the members, or -1.
+// 返回pub在成员中的下标，不存在时返回-1。
+func (t *SessionTranscript) nodeIndex(pub *sm2.PublicKey) int {
+	for i, node := range t.NodePubKeys {
+		if sameKey(node, pub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// samePolicy reports whether a and b are both absent or encode the same.
+// 判断策略a与b是否均为空或编码相同。
+func samePolicy(a, b *Policy) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return string(a.Encode()) == string(b.Encode())
+}
+
+// decodePolicy parses the encoding of Policy.Encode.
+// 解析Policy.Encode所得的编码。
+func decodePolicy(b []byte) (*Policy, bool) {
+	if len(b) < len(policyDomain) || string(b[:len(policyDomain)]) != policyDomain {
+		return nil, false
+	}
+	b = b[len(policyDomain):]
+	var fields [2]string
+	for i := range fields {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.BigEndian.Uint32(b)
+		if uint64(len(b)-4) < uint64(n) {
+			return nil, false
+		}
+		fields[i] = string(b[4 : 4+n])
+		b = b[4+n:]
+	}
+	if len(b) != 8 {
+		return nil, false
+	}
+	p := &Policy{Purpose: fields[0], DocClass: fields[1]}
+	if expiry := int64(binary.BigEndian.Uint64(b)); expiry != 0 {
+		p.Expiry = time.Unix(expiry, 0)
+	}
+	return p, true
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSessionTranscript(t *testing.T) {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var privs []*sm2.PrivateKey
+	var pubs []*sm2.PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, &priv.PublicKey)
+	}
+	collPub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(collPub, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &Policy{Purpose: "audit", DocClass: "contract"}
+
+	tr, err := NewSessionTranscript(ct, &q.PublicKey, pubs, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, priv := range privs {
+		b, err := GenShareBundleWithPolicy(policy, &q.PublicKey, &ct.K, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 重复的成员、其他策略下的份额包不能加入
+	dup, err := GenShareBundleWithPolicy(policy, &q.PublicKey, &ct.K, privs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Add(dup); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("got %v, want ErrUnknownNode", err)
+	}
+	other, err := GenShareBundle(&q.PublicKey, &ct.K, privs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Add(other); !errors.Is(err, ErrIncompleteStatement) {
+		t.Fatalf("got %v, want ErrIncompleteStatement", err)
+	}
+
+	// 第三方由编码恢复记录并验证
+	enc, err := tr.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audited SessionTranscript
+	if err := audited.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := audited.Verify(); err != nil || !ok {
+		t.Fatalf("transcript failed to verify: %v", err)
+	}
+	if pub, err := audited.PublicKey(); err != nil || !sameKey(pub, collPub) {
+		t.Fatal("committee key differs")
+	}
+	d1, err := tr.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := audited.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d1) != string(d2) {
+		t.Fatal("digest differs after round trip")
+	}
+	rct, err := audited.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	D, err := elgamal.PointDecrypt(rct, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePoint(D, M) {
+		t.Fatal("decrypted point differs")
+	}
+
+	// 篡改份额或缺少成员时验证失败
+	audited.Bundles[1].Share.C = audited.Bundles[0].Share.C
+	if _, err := audited.Verify(); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+	tr.Bundles = tr.Bundles[:2]
+	if _, err := tr.Verify(); !errors.Is(err, ErrIncompleteProof) {
+		t.Fatalf("got %v, want ErrIncompleteProof", err)
+	}
+	if err := audited.UnmarshalBinary(enc[:len(enc)-1]); !errors.Is(err, ErrInvalidTranscript) {
+		t.Fatalf("got %v, want ErrInvalidTranscript", err)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/pre"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Universal re-keying is a non-interactive mode for latency-critical workloads:
+// once per epoch, each server publishes for an approved requester a proxy
+// re-encryption key (package pre) from its epoch key. Combined into an EpochReKey,
+// the keys let anyone, without contacting the servers, switch every ciphertext of
+// the epoch to the requester, trading one key per server, requester and epoch for
+// the round trip of each request. The material gives the requester the whole
+// epoch: from it the requester can recover the servers' epoch keys, so it is only
+// for requesters approved for all data of the epoch, and the epoch ratchet bounds
+// what it exposes.
+// 通用重加密密钥是面向时延敏感场景的非交互模式：每个纪元中，各ks server以其纪元私钥为获批的请求者
+// 公布一个代理重加密密钥（pre包）。合并为EpochReKey后，任何人无需联系ks server即可将该纪元的任一密文
+// 置换给该请求者，以每个ks server、请求者与纪元各一个密钥的存储，换取每次请求的往返时延。
+// 该材料等同于将整个纪元授予请求者：请求者可由其恢复各ks server的纪元私钥，因此仅适用于获准访问该纪元
+// 全部数据的请求者，其暴露范围由纪元推进限定。
+
+// ReKey returns the proxy re-encryption key from the server's key of the current
+// epoch to targetPubKey, its part of the EpochReKey of the requester.
+// 生成重加密密钥：返回由ks server当前纪元私钥到目标公钥targetPubKey的代理重加密密钥，
+// 即该请求者EpochReKey中属于本ks server的部分。
+//
+// 参数：
+//		目标公钥	targetPubKey
+// 返回：
+// 		重加密密钥
+func (k *EpochKey) ReKey(targetPubKey *sm2.PublicKey) (*pre.ReKey, error) {
+	priv, err := k.privateKey("EpochKey.ReKey", k.epoch)
+	if err != nil {
+		return nil, err
+	}
+	rk, err := pre.GenReKey(priv, targetPubKey)
+	if err != nil {
+		return nil, opError("EpochKey.ReKey", err)
+	}
+	return rk, nil
+}
+
+// EpochReKey is the universal re-key material of one requester for one epoch: the
+// re-encryption keys of all servers, in the order of their epoch keys.
+// 通用重加密密钥：一个请求者在一个纪元中的通用重加密材料，即全部ks server的重加密密钥，
+// 顺序与其纪元公钥一致。
+type EpochReKey struct {
+	Epoch        uint64
+	TargetPubKey *sm2.PublicKey
+	ReKeys       []*pre.ReKey
+}
+
+// Verify lets the requester check, with its key priv, that every server's
+// re-encryption key is from that server's epoch key in nodeEpochPubKeys.
+// 验证通用重加密密钥：请求者以其私钥priv检查每个ks server的重加密密钥均由nodeEpochPubKeys中
+// 该ks server的纪元公钥生成。
+//
+// 参数：
+//		纪元公钥slice	nodeEpochPubKeys
+//		请求者私钥		priv
+// 返回：
+// 		验证结果
+func (rk *EpochReKey) Verify(nodeEpochPubKeys []*sm2.PublicKey, priv *sm2.PrivateKey) (bool, error) {
+	if len(rk.ReKeys) == 0 {
+		return false, opError("EpochReKey.Verify", elgamal.ErrEmpty)
+	}
+	if len(nodeEpochPubKeys) != len(rk.ReKeys) {
+		return false, opError("EpochReKey.Verify", elgamal.ErrLengthMismatch)
+	}
+	if priv == nil || !sameKey(&priv.PublicKey, rk.TargetPubKey) {
+		return false, opError("EpochReKey.Verify", ErrUnsupportedKey)
+	}
+	for i, k := range rk.ReKeys {
+		if k == nil {
+			return false, itemError("EpochReKey.Verify", "node", i, elgamal.ErrEmpty)
+		}
+		ok, err := k.Verify(nodeEpochPubKeys[i], priv)
+		if err != nil {
+			return false, itemError("EpochReKey.Verify", "node", i, err)
+		}
+		if !ok {
+			return false, itemError("EpochReKey.Verify", "node", i, ErrProofFailed)
+		}
+	}
+	return true, nil
+}
+
+// Switch switches ect, a ciphertext of rk.Epoch, to the requester without the
+// servers, returning (K, C - sum(S_i)*K); only Decrypt with the same rk opens it.
+// 置换密文：无需ks server即可将纪元rk.Epoch的密文ect置换给请求者，返回(K, C - sum(S_i)*K)，
+// 仅能以相同的rk经Decrypt打开。
+//
+// 参数：
+//		纪元密文	ect
+// 返回：
+// 		置换后的密文
+func (rk *EpochReKey) Switch(ect *EpochCipherText) (*elgamal.CipherText, error) {
+	if ect == nil || len(rk.ReKeys) == 0 {
+		return nil, opError("EpochReKey.Switch", elgamal.ErrEmpty)
+	}
+	if ect.Epoch != rk.Epoch {
+		return nil, opError("EpochReKey.Switch", ErrEpochMismatch)
+	}
+	ct := &ect.CipherText
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("EpochReKey.Switch", err)
+	}
+
+	curve := ct.K.Curve
+	s := new(big.Int)
+	for i, k := range rk.ReKeys {
+		if k == nil || k.S == nil {
+			return nil, itemError("EpochReKey.Switch", "node", i, elgamal.ErrEmpty)
+		}
+		s.Add(s, k.S)
+	}
+	s.Mod(s, curve.Params().N)
+
+	// C - sum(S_i)*K = D + sum(x_i)*K
+	out := &elgamal.CipherText{K: ct.K}
+	sx, sy := curve.ScalarMult(ct.K.X, ct.K.Y, s.Bytes())
+	sx, sy = ec.Neg(curve, sx, sy)
+	out.C.Curve = curve
+	out.C.X, out.C.Y = ec.Add(curve, ct.C.X, ct.C.Y, sx, sy)
+	return out, nil
+}
+
+// Decrypt opens a ciphertext switched by rk.Switch with the requester's key priv,
+// which must be the key of rk.TargetPubKey.
+// 解密：以请求者私钥priv打开经rk.Switch置换的密文，priv须为rk.TargetPubKey对应的私钥。
+//
+// 参数：
+//		请求者私钥	priv
+//		置换后的密文	ct
+// 返回：
+// 		明文点
+func (rk *EpochReKey) Decrypt(priv *sm2.PrivateKey, ct *elgamal.CipherText) (*elgamal.CurvePoint, error) {
+	if ct == nil || len(rk.ReKeys) == 0 {
+		return nil, opError("EpochReKey.Decrypt", elgamal.ErrEmpty)
+	}
+	if priv == nil || !sameKey(&priv.PublicKey, rk.TargetPubKey) {
+		return nil, opError("EpochReKey.Decrypt", ErrUnsupportedKey)
+	}
+	// 逐个去除各ks server的x_i*K
+	body := *ct
+	for i, k := range rk.ReKeys {
+		if k == nil {
+			return nil, itemError("EpochReKey.Decrypt", "node", i, elgamal.ErrEmpty)
+		}
+		D, err := pre.Decrypt(&pre.CipherText{Body: body, Key: k.Key}, priv)
+		if err != nil {
+			return nil, itemError("EpochReKey.Decrypt", "node", i, err)
+		}
+		body.C = *D
+	}
+	return &body.C, nil
+}
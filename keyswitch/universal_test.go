@@ -0,0 +1,128 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/pre"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEpochReKey(t *testing.T) {
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const epoch = 3
+	var keys []*EpochKey
+	var pubs []*sm2.PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seed := make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			t.Fatal(err)
+		}
+		k, err := NewEpochKey(priv, seed, epoch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := k.PublicKey(epoch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+		pubs = append(pubs, pub)
+	}
+	epochPub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 各ks server每纪元为请求者公布一次重加密密钥
+	rk := &EpochReKey{Epoch: epoch, TargetPubKey: &q.PublicKey}
+	for _, k := range keys {
+		r, err := k.ReKey(&q.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rk.ReKeys = append(rk.ReKeys, r)
+	}
+	if ok, err := rk.Verify(pubs, q); err != nil || !ok {
+		t.Fatalf("epoch re-key failed to verify: %v", err)
+	}
+
+	// 之后的每个密文无需ks server参与即可置换
+	for i := 0; i < 3; i++ {
+		M := elgamal.GenPoint()
+		ect, err := EpochEncrypt(epochPub, epoch, M)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, err := rk.Switch(ect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		D, err := rk.Decrypt(q, ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !samePoint(D, M) {
+			t.Fatal("decrypted point differs")
+		}
+	}
+
+	// 空密文与非目标私钥须拒绝
+	if _, err := rk.Decrypt(q, nil); !errors.Is(err, elgamal.ErrEmpty) {
+		t.Fatalf("nil ciphertext: got %v, want ErrEmpty", err)
+	}
+	stranger, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rk.Decrypt(stranger, &elgamal.CipherText{K: *elgamal.GenPoint(), C: *elgamal.GenPoint()}); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("other key: got %v, want ErrUnsupportedKey", err)
+	}
+
+	ect, err := EpochEncrypt(epochPub, epoch+1, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rk.Switch(ect); !errors.Is(err, ErrEpochMismatch) {
+		t.Fatalf("got %v, want ErrEpochMismatch", err)
+	}
+
+	// 以其他私钥生成的重加密密钥无法通过验证
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := pre.GenReKey(other, &q.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rk.ReKeys[1] = forged
+	if _, err := rk.Verify(pubs, q); !errors.Is(err, ErrProofFailed) {
+		t.Fatalf("got %v, want ErrProofFailed", err)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"sync"
+
+	"ppks/elgamal"
+)
+
+// VerifyResult is the outcome of verifying one submitted ShareBundle.
+// 验证结果：一个已提交份额包的验证结果。
+type VerifyResult struct {
+	Bundle *ShareBundle
+	OK     bool
+	Err    error
+}
+
+// Verifier verifies submitted share bundles concurrently in the background, so a
+// server can keep receiving from the network while proofs are being checked.
+// Results arrive in completion order, not submission order, and must be consumed.
+// A Verifier is safe for concurrent use.
+// 流水线验证器：在后台并发验证提交的份额包，使服务器接收网络数据与验证证明互不阻塞。
+// 结果按完成顺序而非提交顺序输出，调用者须持续读取Results。Verifier可并发使用。
+type Verifier struct {
+	in  chan *ShareBundle
+	out chan VerifyResult
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewVerifier starts a Verifier with the given number of workers, capped to
+// Parallelism, which workers <= 0 selects. queue is the capacity of the
+// submission and result channels.
+// 创建验证器：启动workers个验证协程，不超过Parallelism，workers <= 0时取Parallelism；queue为
+// 提交与结果通道的容量。
+//
+// 参数：
+//		验证协程数	workers
+//		通道容量	queue
+// 返回：
+// 		验证器
+func NewVerifier(workers, queue int) *Verifier {
+	workers = workerCount(workers, -1)
+	if queue < 0 {
+		queue = 0
+	}
+
+	v := &Verifier{
+		in:  make(chan *ShareBundle, queue),
+		out: make(chan VerifyResult, queue),
+	}
+	v.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go v.work()
+	}
+	go func() {
+		v.wg.Wait()
+		close(v.out)
+	}()
+
+	return v
+}
+
+// Submit queues b for verification, blocking while the queue is full. A nil b is
+// refused.
+// 提交份额包b等待验证，队列满时阻塞。拒绝nil份额包。
+func (v *Verifier) Submit(b *ShareBundle) error {
+	if b == nil {
+		return opError("Verifier.Submit", elgamal.ErrEmpty)
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.closed {
+		return opError("Verifier.Submit", ErrVerifierClosed)
+	}
+	v.in <- b
+	return nil
+}
+
+// Results returns the channel of verification results. It is closed once the
+// Verifier has been closed and every submitted bundle has been verified.
+// 返回验证结果通道。验证器关闭且所有已提交份额包验证完毕后，通道关闭。
+func (v *Verifier) Results() <-chan VerifyResult {
+	return v.out
+}
+
+// Close stops accepting submissions. Bundles already submitted are still verified.
+// 关闭验证器：不再接受提交，已提交的份额包仍会完成验证。
+func (v *Verifier) Close() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.closed {
+		v.closed = true
+		close(v.in)
+	}
+}
+
+func (v *Verifier) work() {
+	defer v.wg.Done()
+	for b := range v.in {
+		// Submit已拒绝nil，此处仍不在nil上调用方法，避免后台协程崩溃
+		if b == nil {
+			v.out <- VerifyResult{Err: opError("Verifier.Submit", elgamal.ErrEmpty)}
+			continue
+		}
+		ok, err := b.Verify()
+		v.out <- VerifyResult{Bundle: b, OK: ok, Err: err}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestVerifier(t *testing.T) {
+	////////////////////////
+	// 模拟ks server数量/////
+	lens := 12
+	////////////////////////
+
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB := elgamal.GenPoint()
+
+	bundles := make([]*ShareBundle, lens)
+	for i := 0; i < lens; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundles[i], err = GenShareBundle(&q.PublicKey, rB, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 第3个份额包被篡改
+	bad := bundles[3]
+	bad.Share.C = *elgamal.GenPoint()
+
+	v := NewVerifier(4, 2)
+	go func() {
+		for _, b := range bundles {
+			if err := v.Submit(b); err != nil {
+				t.Error(err)
+			}
+		}
+		v.Close()
+	}()
+
+	count := 0
+	for res := range v.Results() {
+		count++
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		if res.OK == (res.Bundle == bad) {
+			t.Fatalf("unexpected result %v for bundle %p", res.OK, res.Bundle)
+		}
+	}
+	if count != lens {
+		t.Fatalf("got %d results, want %d", count, lens)
+	}
+
+	if err := v.Submit(nil); !errors.Is(err, elgamal.ErrEmpty) {
+		t.Fatalf("nil bundle: got %v, want ErrEmpty", err)
+	}
+	if err := v.Submit(bundles[0]); !errors.Is(err, ErrVerifierClosed) {
+		t.Fatalf("got %v, want ErrVerifierClosed", err)
+	}
+	v.Close() // 可重复关闭
+}
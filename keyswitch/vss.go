@@ -0,0 +1,151 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// VSSCommitment is the Feldman commitment to a sharing polynomial f: the points
+// a_kB for its coefficients a_0..a_(t-1). It is published by the dealer, so every
+// server can check its share, and a_0B is the shared public key.
+// Feldman承诺：对分割多项式f的系数a_0..a_(t-1)的承诺点a_kB。由分发者公开，
+// 每个ks server据此校验自己的份额，a_0B即被分割私钥的公钥。
+type VSSCommitment []*elgamal.CurvePoint
+
+// SplitPrivKeyVSS Shamir-shares priv like SplitPrivKey and returns the Feldman
+// commitment to the polynomial as well, to publish with the shares.
+// 可验证私钥分割：与SplitPrivKey相同地分割私钥priv，并返回多项式的Feldman承诺，随份额一同公开。
+//
+// 参数：
+//		私钥	priv
+//		门限	t
+//		份数	n
+//		随机源	random
+// 返回：
+// 		门限私钥份额slice
+//		Feldman承诺
+func SplitPrivKeyVSS(priv *sm2.PrivateKey, t, n int, random io.Reader) ([]ThresholdKey, VSSCommitment, error) {
+	keys, coeffs, err := splitPrivKey("SplitPrivKeyVSS", priv, t, n, random)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	curve := priv.Curve
+	c := make(VSSCommitment, len(coeffs))
+	for k, a := range coeffs {
+		c[k] = new(elgamal.CurvePoint)
+		c[k].Curve = curve
+		c[k].X, c[k].Y = curve.ScalarBaseMult(a.Bytes())
+	}
+	// 系数随后不再使用，清零
+	for _, a := range coeffs[1:] {
+		ec.WipeInt(a)
+	}
+
+	return keys, c, nil
+}
+
+// PublicKey returns a_0B, the public key of the shared private key.
+// 返回a_0B，即被分割私钥的公钥。
+func (c VSSCommitment) PublicKey() (*sm2.PublicKey, error) {
+	if err := c.check(); err != nil {
+		return nil, opError("VSSCommitment.PublicKey", err)
+	}
+	pub := new(sm2.PublicKey)
+	pub.Curve = c[0].Curve
+	pub.X = new(big.Int).Set(c[0].X)
+	pub.Y = new(big.Int).Set(c[0].Y)
+	return pub, nil
+}
+
+// ShareKey returns f(index)B = Σ index^k a_kB, the public key of the share of server
+// index, e.g. for WeightedPubKey.
+// 返回编号为index的ks server份额的公钥f(index)B = Σ index^k a_kB，可用于WeightedPubKey等。
+//
+// 参数：
+//		编号	index
+// 返回：
+// 		份额公钥
+func (c VSSCommitment) ShareKey(index int) (*sm2.PublicKey, error) {
+	if err := c.check(); err != nil {
+		return nil, opError("VSSCommitment.ShareKey", err)
+	}
+	if index < 1 {
+		return nil, opError("VSSCommitment.ShareKey", ErrInvalidThreshold)
+	}
+
+	curve := c[0].Curve
+	N := curve.Params().N
+	x := big.NewInt(int64(index))
+	xk := big.NewInt(1)
+	rx, ry := new(big.Int), new(big.Int)
+	for _, point := range c {
+		px, py := curve.ScalarMult(point.X, point.Y, xk.Bytes())
+		rx, ry = ec.Add(curve, rx, ry, px, py)
+		xk.Mul(xk, x)
+		xk.Mod(xk, N)
+	}
+
+	pub := new(sm2.PublicKey)
+	pub.Curve = curve
+	pub.X, pub.Y = rx, ry
+	return pub, nil
+}
+
+// Verify checks the threshold key k against the commitment, failing with
+// ErrInvalidShare unless f(k.Index)B = k.Priv.D B.
+// 校验门限私钥份额k与承诺相符，即f(k.Index)B = k.Priv.D B，否则返回ErrInvalidShare。
+//
+// 参数：
+//		门限私钥份额	k
+func (c VSSCommitment) Verify(k *ThresholdKey) error {
+	if k == nil || k.Priv == nil || k.Priv.D == nil {
+		return opError("VSSCommitment.Verify", elgamal.ErrEmpty)
+	}
+	want, err := c.ShareKey(k.Index)
+	if err != nil {
+		return err
+	}
+
+	curve := want.Curve
+	x, y := curve.ScalarBaseMult(new(big.Int).Mod(k.Priv.D, curve.Params().N).Bytes())
+	if x.Cmp(want.X) != 0 || y.Cmp(want.Y) != 0 {
+		return opError("VSSCommitment.Verify", ErrInvalidShare)
+	}
+	return nil
+}
+
+// check reports ErrEmpty for an empty commitment and ErrPointNotOnCurve unless
+// every point is valid.
+// 承诺为空时返回ErrEmpty，有无效点时返回ErrPointNotOnCurve。
+func (c VSSCommitment) check() error {
+	if len(c) == 0 {
+		return elgamal.ErrEmpty
+	}
+	for _, point := range c {
+		if err := elgamal.CheckPoint(point); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSplitPrivKeyVSS(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, c, err := SplitPrivKeyVSS(priv, 3, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c) != 3 {
+		t.Fatalf("got %d commitment points, want 3", len(c))
+	}
+
+	pub, err := c.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != pub.X.Cmp(priv.X) || 0 != pub.Y.Cmp(priv.Y) {
+		t.Fatal("committed public key differs from the key")
+	}
+
+	for i := range keys {
+		if err := c.Verify(&keys[i]); err != nil {
+			t.Fatalf("share %d: %v", keys[i].Index, err)
+		}
+		share, err := c.ShareKey(keys[i].Index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != share.X.Cmp(keys[i].Priv.X) || 0 != share.Y.Cmp(keys[i].Priv.Y) {
+			t.Fatalf("share key %d differs", keys[i].Index)
+		}
+	}
+
+	// 篡改的份额与承诺不符
+	bad := keys[1]
+	bad.Priv = &sm2.PrivateKey{PublicKey: bad.Priv.PublicKey, D: new(big.Int).Add(bad.Priv.D, big.NewInt(1))}
+	if err := c.Verify(&bad); !errors.Is(err, ErrInvalidShare) {
+		t.Fatalf("got %v, want ErrInvalidShare", err)
+	}
+	// 份额冒用其他编号
+	bad = keys[1]
+	bad.Index = 3
+	if err := c.Verify(&bad); !errors.Is(err, ErrInvalidShare) {
+		t.Fatalf("got %v, want ErrInvalidShare", err)
+	}
+
+	if _, err := VSSCommitment(nil).PublicKey(); !errors.Is(err, elgamal.ErrEmpty) {
+		t.Fatalf("got %v, want ErrEmpty", err)
+	}
+	if _, err := (VSSCommitment{c[0], nil}).ShareKey(1); !errors.Is(err, elgamal.ErrPointNotOnCurve) {
+		t.Fatalf("got %v, want ErrPointNotOnCurve", err)
+	}
+}
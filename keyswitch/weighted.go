@@ -0,0 +1,286 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// WeightScheme assigns weights, such as stake or trust levels, to the nodes of a
+// committee and requires a total weight of at least T to switch a ciphertext.
+// Node i holds Weights[i] of the Shamir shares of a degree T-1 sharing, at
+// consecutive indices, so any nodes together holding T shares can switch.
+// 权重方案：为委员会中的节点指定权重（如质押量或信任等级），置换密文需要参与节点的总权重不少于T。
+// 节点i在次数为T-1的Shamir共享中持有Weights[i]个编号连续的份额，总计持有T个份额的任意节点即可完成置换。
+type WeightScheme struct {
+	Weights []int
+	T       int
+}
+
+// NewWeightScheme returns the scheme of the node weights weights with the weight
+// threshold T. Weights must be positive and T at most their total.
+// 创建权重方案：节点权重为weights，权重门限为T。权重须为正，T不超过权重总和。
+//
+// 参数：
+//		节点权重slice	weights
+//		权重门限		T
+// 返回：
+// 		权重方案
+func NewWeightScheme(weights []int, T int) (*WeightScheme, error) {
+	if len(weights) == 0 {
+		return nil, opError("NewWeightScheme", elgamal.ErrEmpty)
+	}
+	total := 0
+	for i, w := range weights {
+		if w < 1 {
+			return nil, itemError("NewWeightScheme", "weight", i, ErrInvalidThreshold)
+		}
+		total += w
+	}
+	if T < 1 || T > total {
+		return nil, opError("NewWeightScheme", ErrInvalidThreshold)
+	}
+	return &WeightScheme{Weights: append([]int(nil), weights...), T: T}, nil
+}
+
+// total returns the sum of the weights.
+// 返回权重总和。
+func (s *WeightScheme) total() int {
+	total := 0
+	for _, w := range s.Weights {
+		total += w
+	}
+	return total
+}
+
+// Indices returns the Shamir indices held by node.
+// 返回节点node持有的Shamir份额编号。
+func (s *WeightScheme) Indices(node int) []int {
+	if node < 0 || node >= len(s.Weights) {
+		return nil
+	}
+	first := 1
+	for _, w := range s.Weights[:node] {
+		first += w
+	}
+	indices := make([]int, s.Weights[node])
+	for i := range indices {
+		indices[i] = first + i
+	}
+	return indices
+}
+
+// SharingIndices checks that the distinct nodes together weigh at least T and
+// returns all the Shamir indices they hold, the set the Lagrange coefficients of
+// a session are taken within.
+// 检查参与节点nodes互不相同且总权重不少于T，返回其持有的全部Shamir份额编号，即一次会话中计算拉格朗日系数所用的编号集合。
+//
+// 参数：
+//		参与节点slice	nodes
+// 返回：
+// 		份额编号集合
+func (s *WeightScheme) SharingIndices(nodes []int) ([]int, error) {
+	seen := make(map[int]bool, len(nodes))
+	var indices []int
+	for i, node := range nodes {
+		if node < 0 || node >= len(s.Weights) || seen[node] {
+			return nil, itemError("WeightScheme.SharingIndices", "node", i, ErrInvalidThreshold)
+		}
+		seen[node] = true
+		indices = append(indices, s.Indices(node)...)
+	}
+	if len(indices) < s.T {
+		return nil, opError("WeightScheme.SharingIndices", ErrInsufficientWeight)
+	}
+	return indices, nil
+}
+
+// WeightedKey is a node's part of a weighted sharing: one ThresholdKey per Shamir
+// index the node holds.
+// 加权私钥份额：节点在加权共享中持有的部分，其持有的每个Shamir编号对应一个ThresholdKey。
+type WeightedKey struct {
+	Node int
+	Keys []ThresholdKey
+}
+
+// SplitPrivKeyWeighted shares priv among the nodes of s, so that any nodes weighing
+// at least s.T together recover it. The dealer should discard priv afterwards. A nil
+// random uses crypto/rand.
+// 加权私钥分割：按权重方案s在节点间分割私钥priv，总权重不少于s.T的任意节点可共同恢复。分发者此后应丢弃priv。
+// random为nil时使用crypto/rand。
+//
+// 参数：
+//		私钥		priv
+//		权重方案	s
+//		随机源		random
+// 返回：
+// 		加权私钥份额slice，按节点顺序
+func SplitPrivKeyWeighted(priv *sm2.PrivateKey, s *WeightScheme, random io.Reader) ([]WeightedKey, error) {
+	if s == nil {
+		return nil, opError("SplitPrivKeyWeighted", ErrInvalidThreshold)
+	}
+	keys, _, err := splitPrivKey("SplitPrivKeyWeighted", priv, s.T, s.total(), random)
+	if err != nil {
+		return nil, err
+	}
+	wkeys := make([]WeightedKey, len(s.Weights))
+	next := 0
+	for i, w := range s.Weights {
+		wkeys[i] = WeightedKey{Node: i, Keys: keys[next : next+w]}
+		next += w
+	}
+	return wkeys, nil
+}
+
+// PublicKeys returns the public keys of the Shamir shares of k, which verifiers use
+// with WeightedNodePubKey.
+// 返回k中各Shamir份额的公钥，验证者以之调用WeightedNodePubKey。
+func (k *WeightedKey) PublicKeys() []*sm2.PublicKey {
+	pubs := make([]*sm2.PublicKey, len(k.Keys))
+	for i := range k.Keys {
+		pubs[i] = &k.Keys[i].Priv.PublicKey
+	}
+	return pubs
+}
+
+// Combined returns the key sum λj*f(j) over the indices j held by k, the Lagrange
+// coefficients being taken within the indices of nodes under s. The node computes
+// and proves its share with it like with any private key.
+// 合并私钥：返回sum λj*f(j)，j取k持有的编号，拉格朗日系数在方案s下参与节点nodes的编号集合中计算。
+// 节点如使用普通私钥一样以之计算并证明份额。
+//
+// 参数：
+//		权重方案		s
+//		参与节点slice	nodes
+// 返回：
+// 		合并私钥
+func (k *WeightedKey) Combined(s *WeightScheme, nodes []int) (*sm2.PrivateKey, error) {
+	if s == nil || len(k.Keys) == 0 {
+		return nil, opError("WeightedKey.Combined", ErrInvalidThreshold)
+	}
+	indices, err := s.SharingIndices(nodes)
+	if err != nil {
+		return nil, err
+	}
+	curve := k.Keys[0].Priv.Curve
+	N := curve.Params().N
+	d := new(big.Int)
+	for i := range k.Keys {
+		w, err := k.Keys[i].Weighted(indices)
+		if err != nil {
+			return nil, err
+		}
+		d.Add(d, w.D)
+	}
+	d.Mod(d, N)
+
+	c := new(sm2.PrivateKey)
+	c.Curve = curve
+	c.D = d
+	c.X, c.Y = curve.ScalarBaseMult(d.Bytes())
+	return c, nil
+}
+
+// WeightedNodePubKey returns the public key matching WeightedKey.Combined of node,
+// whose share public keys are pubs, so that its share proof can be verified.
+// 返回与节点node的WeightedKey.Combined对应的公钥，pubs为该节点各份额的公钥，用于验证其份额证明。
+//
+// 参数：
+//		权重方案		s
+//		节点			node
+//		份额公钥slice	pubs
+//		参与节点slice	nodes
+// 返回：
+// 		合并公钥
+func WeightedNodePubKey(s *WeightScheme, node int, pubs []*sm2.PublicKey, nodes []int) (*sm2.PublicKey, error) {
+	if s == nil {
+		return nil, opError("WeightedNodePubKey", ErrInvalidThreshold)
+	}
+	own := s.Indices(node)
+	if len(own) == 0 || len(pubs) != len(own) {
+		return nil, opError("WeightedNodePubKey", elgamal.ErrLengthMismatch)
+	}
+	indices, err := s.SharingIndices(nodes)
+	if err != nil {
+		return nil, err
+	}
+	var x, y *big.Int
+	for i, pub := range pubs {
+		w, err := WeightedPubKey(pub, own[i], indices)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			x, y = w.X, w.Y
+		} else {
+			x, y = ec.Add(w.Curve, x, y, w.X, w.Y)
+		}
+	}
+	return &sm2.PublicKey{Curve: pubs[0].Curve, X: x, Y: y}, nil
+}
+
+// WeightedShareCal calculates the share related with rB for targetPubKey with the
+// weighted key k, for a session of the nodes under s.
+// 加权份额计算：在方案s下参与节点为nodes的会话中，使用加权私钥份额k为目标公钥targetPubKey计算关于点rB的份额。
+//
+// 参数：
+//		目标公钥		targetPubKey
+//		密文左侧点		rB
+//		加权私钥份额	k
+//		权重方案		s
+//		参与节点slice	nodes
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func WeightedShareCal(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint, k *WeightedKey, s *WeightScheme, nodes []int) (*elgamal.CipherText, *big.Int, error) {
+	c, err := k.Combined(s, nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ShareCal(targetPubKey, rB, c)
+}
+
+// WeightedShareReplace is ShareReplace enforcing s: shares[i] must come from
+// nodes[i], and the nodes must be distinct and weigh at least s.T together, or
+// ErrInsufficientWeight is returned.
+// 加权份额置换：在方案s下执行ShareReplace，shares[i]须来自节点nodes[i]，节点须互不相同且总权重不少于s.T，
+// 否则返回ErrInsufficientWeight。
+//
+// 参数：
+//		权重方案		s
+//		参与节点slice	nodes
+//		份额slice		shares
+//		密文原文		rct
+// 返回：
+// 		新密文
+func WeightedShareReplace(s *WeightScheme, nodes []int, shares *elgamal.CipherVector, rct *elgamal.CipherText) (*elgamal.CipherText, error) {
+	if s == nil {
+		return nil, opError("WeightedShareReplace", ErrInvalidThreshold)
+	}
+	if shares == nil || len(*shares) != len(nodes) {
+		return nil, opError("WeightedShareReplace", elgamal.ErrLengthMismatch)
+	}
+	if _, err := s.SharingIndices(nodes); err != nil {
+		return nil, err
+	}
+	return ShareReplace(shares, rct)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyswitch
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestWeightedShareReplace(t *testing.T) {
+	collPriv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 权重3,2,1,1，门限4
+	s, err := NewWeightScheme([]int{3, 2, 1, 1}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := SplitPrivKeyWeighted(collPriv, s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	M := elgamal.GenPoint()
+	rct, err := elgamal.PointEncrypt(&collPriv.PublicKey, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, nodes := range [][]int{{0, 2}, {1, 2, 3}, {0, 1}, {3, 0, 1, 2}} {
+		shares := make(elgamal.CipherVector, len(nodes))
+		for i, node := range nodes {
+			k := &keys[node]
+			share, ri, err := WeightedShareCal(&q.PublicKey, &rct.K, k, s, nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := k.Combined(s, nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p1, p2, p3, err := ShareProofGen(ri, c, share, &q.PublicKey, &rct.K)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 验证者由公开的份额公钥得到合并公钥
+			pub, err := WeightedNodePubKey(s, node, k.PublicKeys(), nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := ShareProofVry(p1, p2, p3, share, pub, &q.PublicKey, &rct.K); err != nil || !ok {
+				t.Fatalf("share proof of node %d failed: %v", node, err)
+			}
+			shares[i] = *share
+		}
+
+		ct, err := WeightedShareReplace(s, nodes, &shares, rct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		D, err := elgamal.PointDecrypt(ct, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if 0 != D.X.Cmp(M.X) || 0 != D.Y.Cmp(M.Y) {
+			t.Fatalf("nodes %v: decrypted point differs", nodes)
+		}
+	}
+
+	// 权重不足时拒绝
+	for _, nodes := range [][]int{{1, 2}, {2, 3}, {0}} {
+		if _, _, err := WeightedShareCal(&q.PublicKey, &rct.K, &keys[nodes[0]], s, nodes); !errors.Is(err, ErrInsufficientWeight) {
+			t.Fatalf("nodes %v: got %v, want ErrInsufficientWeight", nodes, err)
+		}
+		shares := make(elgamal.CipherVector, len(nodes))
+		for i := range shares {
+			shares[i] = *rct
+		}
+		if _, err := WeightedShareReplace(s, nodes, &shares, rct); !errors.Is(err, ErrInsufficientWeight) {
+			t.Fatalf("nodes %v: got %v, want ErrInsufficientWeight", nodes, err)
+		}
+	}
+	if _, err := s.SharingIndices([]int{0, 0}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+	if _, err := NewWeightScheme([]int{1, 0}, 1); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("got %v, want ErrInvalidThreshold", err)
+	}
+}
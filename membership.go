@@ -0,0 +1,74 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"io"
+
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SplitNodeKey splits the server key priv additively into n keys with the same sum,
+// for the remaining servers when it leaves, keeping the collective key. A nil
+// random uses crypto/rand.
+// It is a wrapper of keyswitch.SplitNodeKey.
+// 节点私钥分割：将ks server的私钥priv加性分割为和不变的n份，在其退出时交给其余ks server，
+// 聚合公钥保持不变。random为nil时使用crypto/rand。
+//
+// 参数：
+//		私钥	priv
+//		份数	n
+//		随机源	random
+// 返回：
+// 		私钥份额slice
+func SplitNodeKey(priv *sm2.PrivateKey, n int, random io.Reader) ([]*sm2.PrivateKey, error) {
+	return keyswitch.SplitNodeKey(priv, n, random)
+}
+
+// MergeNodeKey returns the server key priv with the part of SplitNodeKey added.
+// It is a wrapper of keyswitch.MergeNodeKey.
+// 节点私钥合并：返回加上SplitNodeKey所分份额part后的ks server私钥。
+//
+// 参数：
+//		私钥		priv
+//		私钥份额	part
+// 返回：
+// 		合并后的私钥
+func MergeNodeKey(priv, part *sm2.PrivateKey) (*sm2.PrivateKey, error) {
+	return keyswitch.MergeNodeKey(priv, part)
+}
+
+// MembershipChange is the change of the collective key when a server joins or
+// leaves the committee.
+// 成员变更：ks server加入或退出委员会时聚合公钥的变化。
+type MembershipChange = keyswitch.MembershipChange
+
+// NewMembershipChange returns the change of the collective key oldPubKey when the
+// server with nodePubKey joins (join) or leaves.
+// It is a wrapper of keyswitch.NewMembershipChange.
+// 新建成员变更：返回公钥为nodePubKey的ks server加入（join）或退出时聚合公钥oldPubKey的变化。
+//
+// 参数：
+//		旧聚合公钥	oldPubKey
+//		节点公钥	nodePubKey
+//		是否加入	join
+// 返回：
+// 		成员变更
+func NewMembershipChange(oldPubKey, nodePubKey *sm2.PublicKey, join bool) (*MembershipChange, error) {
+	return keyswitch.NewMembershipChange(oldPubKey, nodePubKey, join)
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"math/big"
+
+	"ppks/keyswitch"
+)
+
+// NewPai bundles the proof values (c,r1,r2) returned by ShareProofGen into a Pai.
+// It is a wrapper of keyswitch.NewPai.
+// 构造证明：将ShareProofGen返回的(c,r1,r2)打包为Pai。
+//
+// 参数：
+//		证明pai：	c,r1,r2
+// 返回：
+// 		证明Pai
+func NewPai(c, r1, r2 *big.Int) Pai {
+	return keyswitch.NewPai(c, r1, r2)
+}
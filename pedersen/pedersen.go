@@ -0,0 +1,203 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pedersen implements Pedersen commitments over SM2: C = m*G + r*H, where
+// G is the base point and H a second generator derived with hash-to-curve, so
+// nobody knows log_G(H). A commitment hides m and binds the committer to it, and
+// commitments add up: Commit(m1,r1) + Commit(m2,r2) = Commit(m1+m2, r1+r2).
+// They are a building block for DKG and range proofs.
+// Pedersen承诺：基于SM2实现C = m*G + r*H，G为基点，H为以哈希到曲线派生的第二生成元，任何人都不知道
+// log_G(H)。承诺隐藏m并约束承诺者不能更改m，且承诺可加：Commit(m1,r1) + Commit(m2,r2) = Commit(m1+m2, r1+r2)。
+// 可用作DKG与范围证明的基础组件。
+package pedersen
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// GeneratorDomain is the hash-to-curve domain separation tag of H.
+// 派生H所用的哈希到曲线域分隔标签。
+const GeneratorDomain = "ppks-pedersen-H-v1"
+
+var (
+	hOnce  sync.Once
+	hPoint *elgamal.CurvePoint
+)
+
+// H returns the second generator, hash-to-curve of the encoding of the SM2 base
+// point under GeneratorDomain. Its discrete logarithm to G is unknown.
+// 返回第二生成元：在GeneratorDomain下对SM2基点编码做哈希到曲线的结果，其关于G的离散对数未知。
+func H() *elgamal.CurvePoint {
+	hOnce.Do(func() {
+		G := elgamal.Generator(sm2.P256Sm2())
+		P, err := elgamal.HashToPoint([]byte(GeneratorDomain), G.Bytes())
+		if err != nil {
+			panic(err)
+		}
+		hPoint = P
+	})
+	P := *hPoint
+	return &P
+}
+
+// Commitment is a Pedersen commitment m*G + r*H.
+// Pedersen承诺m*G + r*H。
+type Commitment elgamal.CurvePoint
+
+// Opening is what opens a commitment: the message M and the blinding factor R.
+// 承诺的打开值：消息M与盲化因子R。
+type Opening struct {
+	M, R *big.Int
+}
+
+// Point returns c as a curve point.
+// 以曲线点形式返回c。
+func (c *Commitment) Point() *elgamal.CurvePoint {
+	return (*elgamal.CurvePoint)(c)
+}
+
+// Commit commits to m with a fresh random blinding factor and returns the
+// commitment and its opening, which the committer keeps until it opens.
+// 承诺：以新的随机盲化因子对m做承诺，返回承诺及其打开值，承诺者须保存打开值直至打开。
+//
+// 参数：
+//		消息	m
+// 返回：
+// 		承诺
+//		打开值
+func Commit(m *big.Int) (*Commitment, *Opening, error) {
+	curve := sm2.P256Sm2()
+	r, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, opError("Commit", err)
+	}
+	o := &Opening{M: new(big.Int).Mod(m, curve.Params().N), R: r}
+	c, err := CommitWith(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, o, nil
+}
+
+// CommitWith returns the commitment M*G + R*H of the opening o.
+// 由打开值o计算承诺M*G + R*H并返回。
+//
+// 参数：
+//		打开值	o
+// 返回：
+// 		承诺
+func CommitWith(o *Opening) (*Commitment, error) {
+	if o == nil || o.M == nil || o.R == nil {
+		return nil, opError("CommitWith", ErrInvalidOpening)
+	}
+	curve := sm2.P256Sm2()
+	N := curve.Params().N
+	m := new(big.Int).Mod(o.M, N)
+	r := new(big.Int).Mod(o.R, N)
+	h := H()
+
+	// m*G + r*H，各项为0时为无穷远点
+	x, y := new(big.Int), new(big.Int)
+	if m.Sign() != 0 {
+		x, y = curve.ScalarBaseMult(m.Bytes())
+	}
+	if r.Sign() != 0 {
+		hx, hy := curve.ScalarMult(h.X, h.Y, r.Bytes())
+		if x.Sign() == 0 && y.Sign() == 0 {
+			x, y = hx, hy
+		} else {
+			x, y = ec.Add(curve, x, y, hx, hy)
+		}
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, opError("CommitWith", ErrInvalidOpening)
+	}
+	return &Commitment{Curve: curve, X: x, Y: y}, nil
+}
+
+// Verify reports whether o opens c.
+// 判断o是否为c的打开值。
+//
+// 参数：
+//		承诺	c
+//		打开值	o
+// 返回：
+// 		验证结果
+func Verify(c *Commitment, o *Opening) bool {
+	if c == nil || elgamal.CheckPoint(c.Point()) != nil {
+		return false
+	}
+	d, err := CommitWith(o)
+	if err != nil {
+		return false
+	}
+	return d.X.Cmp(c.X) == 0 && d.Y.Cmp(c.Y) == 0
+}
+
+// Open checks the opening o of c and returns the committed message, or
+// ErrInvalidOpening when o does not open c.
+// 打开承诺：检查c的打开值o并返回承诺的消息，o不能打开c时返回ErrInvalidOpening。
+//
+// 参数：
+//		承诺	c
+//		打开值	o
+// 返回：
+// 		消息
+func Open(c *Commitment, o *Opening) (*big.Int, error) {
+	if !Verify(c, o) {
+		return nil, opError("Open", ErrInvalidOpening)
+	}
+	return new(big.Int).Mod(o.M, c.Curve.Params().N), nil
+}
+
+// Add returns the commitment a + b, which (a's opening).Add(b's opening) opens.
+// 承诺相加：返回承诺a + b，其打开值为二者打开值之和。
+//
+// 参数：
+//		承诺	a,b
+// 返回：
+// 		承诺之和
+func Add(a, b *Commitment) (*Commitment, error) {
+	for _, c := range []*Commitment{a, b} {
+		if c == nil {
+			return nil, opError("Add", elgamal.ErrEmpty)
+		}
+		if err := elgamal.CheckPoint(c.Point()); err != nil {
+			return nil, opError("Add", err)
+		}
+	}
+	x, y := ec.Add(a.Curve, a.X, a.Y, b.X, b.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, opError("Add", elgamal.ErrPointNotOnCurve)
+	}
+	return &Commitment{Curve: a.Curve, X: x, Y: y}, nil
+}
+
+// Add returns the opening (o.M+p.M, o.R+p.R) of the sum of the commitments
+// opened by o and p.
+// 打开值相加：返回o与p所打开承诺之和的打开值(o.M+p.M, o.R+p.R)。
+func (o *Opening) Add(p *Opening) *Opening {
+	N := sm2.P256Sm2().Params().N
+	m := new(big.Int).Add(o.M, p.M)
+	r := new(big.Int).Add(o.R, p.R)
+	return &Opening{M: m.Mod(m, N), R: r.Mod(r, N)}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pedersen
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestGeneratorH(t *testing.T) {
+	h := H()
+	if err := elgamal.CheckPoint(h); err != nil {
+		t.Fatal(err)
+	}
+	G := elgamal.Generator(h.Curve)
+	if h.X.Cmp(G.X) == 0 {
+		t.Fatal("H equals G")
+	}
+	// 确定性：重复调用得到同一点，且修改返回值不影响H
+	h.X = big.NewInt(1)
+	if H().X.Cmp(big.NewInt(1)) == 0 {
+		t.Fatal("H was modified through its result")
+	}
+	want, err := elgamal.HashToPoint([]byte(GeneratorDomain), G.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if H().X.Cmp(want.X) != 0 || H().Y.Cmp(want.Y) != 0 {
+		t.Fatal("H is not the hash of G")
+	}
+}
+
+func TestCommitOpen(t *testing.T) {
+	c, o, err := Commit(big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Open(c, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != 42 {
+		t.Fatalf("got %v, want 42", m)
+	}
+
+	// 隐藏性：同一消息的两次承诺不同
+	d, _, err := Commit(big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.X.Cmp(c.X) == 0 {
+		t.Fatal("commitments of one message are equal")
+	}
+
+	// 约束性：其他消息或盲化因子无法打开
+	if Verify(c, &Opening{M: big.NewInt(43), R: o.R}) {
+		t.Fatal("commitment opened to another message")
+	}
+	if _, err := Open(c, &Opening{M: o.M, R: new(big.Int).Add(o.R, big.NewInt(1))}); !errors.Is(err, ErrInvalidOpening) {
+		t.Fatalf("got %v, want ErrInvalidOpening", err)
+	}
+	if Verify(c, nil) || Verify(nil, o) {
+		t.Fatal("nil commitment or opening verified")
+	}
+}
+
+func TestCommitAdd(t *testing.T) {
+	a, oa, err := Commit(big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ob, err := Commit(big.NewInt(-2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := Add(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Open(sum, oa.Add(ob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != 3 {
+		t.Fatalf("got %v, want 3", m)
+	}
+
+	// 打开值与CommitWith一致
+	c, err := CommitWith(oa.Add(ob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.X.Cmp(sum.X) != 0 || c.Y.Cmp(sum.Y) != 0 {
+		t.Fatal("sum differs from the commitment of the summed opening")
+	}
+	if _, err := CommitWith(&Opening{M: big.NewInt(0), R: big.NewInt(0)}); err == nil {
+		t.Fatal("expected error for the commitment at infinity")
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// NewCurvePointFromXY returns the point (x,y) on curve after checking that it is a
+// finite point on the curve. A nil curve selects SM2.
+// It is a wrapper of elgamal.NewCurvePointFromXY.
+// 由坐标构造点：校验(x,y)为曲线curve上的有限点后返回，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+//		坐标	x,y
+// 返回：
+// 		点
+func NewCurvePointFromXY(curve elliptic.Curve, x, y *big.Int) (*CurvePoint, error) {
+	return elgamal.NewCurvePointFromXY(curve, x, y)
+}
+
+// NewCurvePointFromBytes decodes a point on curve from its SEC 1 encoding, either
+// uncompressed (0x04||X||Y) or compressed (0x02/0x03||X). A nil curve selects SM2.
+// It is a wrapper of elgamal.NewCurvePointFromBytes.
+// 由字节构造点：解析SEC 1格式的点编码（非压缩0x04||X||Y或压缩0x02/0x03||X），
+// 校验后返回，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+//		编码	b
+// 返回：
+// 		点
+func NewCurvePointFromBytes(curve elliptic.Curve, b []byte) (*CurvePoint, error) {
+	return elgamal.NewCurvePointFromBytes(curve, b)
+}
+
+// MustParseCurvePoint decodes a hex SEC 1 encoded SM2 point and panics on error.
+// It is intended for tests and fixed constants.
+// It is a wrapper of elgamal.MustParseCurvePoint.
+// 解析十六进制编码的SM2点，出错时panic，用于测试及常量。
+func MustParseCurvePoint(s string) *CurvePoint {
+	return elgamal.MustParseCurvePoint(s)
+}
+
+// GenPointFromSeed maps seed to a curve point deterministically by try-and-increment
+// hashing, so the same content identifier always yields the same document key point.
+// It is a wrapper of elgamal.GenPointFromSeed.
+// 由种子生成点：以"尝试-递增"方式将种子seed哈希到曲线上，确定性地生成一个点并返回，
+// 相同的内容标识总是得到相同的文档密钥点。
+//
+// 参数：
+//		种子	seed
+// 返回：
+// 		点
+func GenPointFromSeed(seed []byte) (*CurvePoint, error) {
+	return elgamal.GenPointFromSeed(seed)
+}
+
+// HashToPoint hashes msg to an SM2 point under the domain separation tag domain,
+// following hash_to_curve of RFC 9380 with SM3 and the simplified SWU map.
+// It is a wrapper of elgamal.HashToPoint.
+// 哈希到曲线：按RFC 9380的hash_to_curve，以SM3及简化SWU映射，在域分隔标签domain下将msg哈希为SM2点。
+//
+// 参数：
+//		域分隔标签	domain
+//		消息		msg
+// 返回：
+// 		点
+func HashToPoint(domain, msg []byte) (*CurvePoint, error) {
+	return elgamal.HashToPoint(domain, msg)
+}
+
+// MaxPayloadLen is the longest payload EncodeToPoint embeds in an SM2 point.
+// EncodeToPoint可嵌入SM2点的最长数据长度。
+const MaxPayloadLen = elgamal.MaxPayloadLen
+
+// EncodeToPoint embeds data, at most MaxPayloadLen bytes, in the X coordinate of an
+// SM2 point, so applications can encrypt a secret of their choice.
+// It is a wrapper of elgamal.EncodeToPoint.
+// 数据编码为点：将至多MaxPayloadLen字节的数据data嵌入SM2点的横坐标，应用因此可加密自选的秘密。
+//
+// 参数：
+//		数据	data
+// 返回：
+// 		点
+func EncodeToPoint(data []byte) (*CurvePoint, error) {
+	return elgamal.EncodeToPoint(data)
+}
+
+// DecodeFromPoint returns the data embedded in p by EncodeToPoint.
+// It is a wrapper of elgamal.DecodeFromPoint.
+// 由点解码数据：返回EncodeToPoint嵌入点p的数据。
+//
+// 参数：
+//		点	p
+// 返回：
+// 		数据
+func DecodeFromPoint(p *CurvePoint) ([]byte, error) {
+	return elgamal.DecodeFromPoint(p)
+}
+
+// NewCipherText returns the ciphertext ((kx,ky), (cx,cy)) on curve after checking
+// both points. A nil curve selects SM2.
+// It is a wrapper of elgamal.NewCipherText.
+// 由坐标构造密文：校验两点后返回密文，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线		curve
+//		左侧点坐标	kx,ky
+//		右侧点坐标	cx,cy
+// 返回：
+// 		密文
+func NewCipherText(curve elliptic.Curve, kx, ky, cx, cy *big.Int) (*CipherText, error) {
+	return elgamal.NewCipherText(curve, kx, ky, cx, cy)
+}
+
+// CipherAdd returns a+b, which decrypts to the sum of the plaintext points for
+// ciphertexts under the same public key.
+// It is a wrapper of elgamal.CipherAdd.
+// 密文加法：返回a+b，同一公钥下的密文相加后，解密结果为明文点之和。
+//
+// 参数：
+//		密文	a,b
+// 返回：
+// 		密文a+b
+func CipherAdd(a, b *CipherText) (*CipherText, error) {
+	return elgamal.CipherAdd(a, b)
+}
+
+// CipherSub returns a-b, which decrypts to the difference of the plaintext points.
+// It is a wrapper of elgamal.CipherSub.
+// 密文减法：返回a-b，解密结果为明文点之差。
+//
+// 参数：
+//		密文	a,b
+// 返回：
+// 		密文a-b
+func CipherSub(a, b *CipherText) (*CipherText, error) {
+	return elgamal.CipherSub(a, b)
+}
+
+// Rerandomize returns ct, encrypted under pub, with fresh randomness. It decrypts
+// to the same point but cannot be linked to ct.
+// It is a wrapper of elgamal.Rerandomize.
+// 重新随机化：以新随机数刷新公钥pub下的密文ct并返回，解密结果不变，但无法与ct关联。
+//
+// 参数：
+//		公钥	pub
+//		密文	ct
+// 返回：
+// 		新密文
+func Rerandomize(pub *sm2.PublicKey, ct *CipherText) (*CipherText, error) {
+	return elgamal.Rerandomize(pub, ct)
+}
+
+// NegPoint returns -p after checking that p is a finite point on its curve.
+// It is a wrapper of elgamal.NegPoint.
+// 点取负：校验p为其曲线上的有限点后，返回点-p。
+//
+// 参数：
+//		点	p
+// 返回：
+// 		点-p
+func NegPoint(p *CurvePoint) (*CurvePoint, error) {
+	return elgamal.NegPoint(p)
+}
+
+// RandScalar returns a random scalar in [1, N-1] on curve. A nil curve selects SM2
+// and a nil random uses crypto/rand.
+// It is a wrapper of elgamal.RandScalar.
+// 生成随机标量：生成[1, N-1]中的随机数并返回，curve为nil时使用SM2曲线，random为nil时使用crypto/rand。
+//
+// 参数：
+//		曲线		curve
+//		随机源		random
+// 返回：
+// 		随机标量
+func RandScalar(curve elliptic.Curve, random io.Reader) (*big.Int, error) {
+	return elgamal.RandScalar(curve, random)
+}
+
+// Generator returns the base point G of curve. A nil curve selects SM2.
+// It is a wrapper of elgamal.Generator.
+// 生成元：返回曲线curve的基点G，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+// 返回：
+// 		基点G
+func Generator(curve elliptic.Curve) *CurvePoint {
+	return elgamal.Generator(curve)
+}
+
+// Infinity returns the identity element of curve, represented as (0,0). A nil
+// curve selects SM2.
+// It is a wrapper of elgamal.Infinity.
+// 无穷远点：返回曲线curve的单位元，表示为(0,0)，curve为nil时使用SM2曲线。
+//
+// 参数：
+//		曲线	curve
+// 返回：
+// 		无穷远点
+func Infinity(curve elliptic.Curve) *CurvePoint {
+	return elgamal.Infinity(curve)
+}
+
+// Fingerprint is the SM3 digest of the canonical encoding of a point, key or
+// ciphertext, comparable and usable as a map key.
+// 指纹：点、公钥或密文规范编码的SM3摘要，可比较，可用作map的键。
+type Fingerprint = elgamal.Fingerprint
+
+// KeyFingerprint returns the fingerprint of the public key pub.
+// It is a wrapper of elgamal.KeyFingerprint.
+// 返回公钥pub的指纹。
+//
+// 参数：
+//		公钥	pub
+// 返回：
+// 		指纹
+func KeyFingerprint(pub *sm2.PublicKey) Fingerprint {
+	return elgamal.KeyFingerprint(pub)
+}
+
+// Precompute builds a fixed-base table for pub, speeding up every later
+// multiplication of pub by a scalar, such as encrypting to it or switching to it.
+// It is a wrapper of elgamal.Precompute.
+// 预计算公钥pub的固定基点表，加速此后以标量乘pub的运算，如以其加密或置换到该公钥。
+//
+// 参数：
+//		公钥	pub
+// 返回：
+// 		错误
+func Precompute(pub *sm2.PublicKey) error {
+	return elgamal.Precompute(pub)
+}
+
+// Forget drops the table Precompute built for pub, if any.
+// It is a wrapper of elgamal.Forget.
+// 删除Precompute为公钥pub建立的固定基点表。
+//
+// 参数：
+//		公钥	pub
+func Forget(pub *sm2.PublicKey) {
+	elgamal.Forget(pub)
+}
+
+// SetPooling enables or disables the pools of scratch points and ciphertexts
+// behind batch operations such as ShareReplaceParallel and BatchVerifyShares, and
+// returns the previous setting.
+// It is a wrapper of elgamal.SetPooling.
+// 开关批量运算（如ShareReplaceParallel、BatchVerifyShares）所用临时点与临时密文的对象池，
+// 返回此前的设置。
+//
+// 参数：
+//		是否启用	enabled
+// 返回：
+// 		此前的设置
+func SetPooling(enabled bool) bool {
+	return elgamal.SetPooling(enabled)
+}
@@ -14,17 +14,47 @@ limitations under the License.
 */
 
 // Package ppks Practical Parallel Key Switch
+//
+// The implementation lives in the subpackages elgamal (point encryption), proof
+// (zero-knowledge proofs) and keyswitch (shares, proofs of shares and replacement);
+// package ppks keeps the original API as thin wrappers over them. Package dkg lets
+// a committee generate its threshold key without a dealer, package kdf derives
+// keys from points with SM3-HKDF, package pedersen provides Pedersen commitments,
+// package rangeproof proves ranges of encrypted integers, package shuffle
+// provides a verifiable shuffle of ciphertext vectors, package ristretto offers
+// the ristretto255 group as an alternative to SM2, package bn254 a pairing
+// backend with aggregate share witnesses, BLS signatures and SM9-style
+// identity-based key switching, package schnorr signs share bundles and DKG
+// messages, package pre provides unidirectional proxy re-encryption, package
+// hdkey derives hierarchical deterministic sub-keys, package tsign produces
+// threshold SM2 signatures with the committee's key shares, package vrf is a
+// verifiable random function over the nodes' SM2 keys, package escrow backs up
+// user keys under the committee key with verifiable encryption, and package
+// stealth derives unlinkable one-time target keys for requesters.
+// 具体实现位于子包elgamal（点加密）、proof（零知识证明）与keyswitch（份额计算、份额证明与置换），
+// ppks包以轻量封装保留原有接口。dkg包供委员会在无分发者的情况下生成门限密钥，kdf包以SM3-HKDF由点派生密钥，
+// pedersen包提供Pedersen承诺，rangeproof包证明加密整数的取值范围，shuffle包提供密文向量的可验证混洗，
+// ristretto包提供可替代SM2的ristretto255群，bn254包提供支持份额见证聚合、BLS签名及SM9风格基于标识的密钥置换的配对后端，
+// schnorr包用于对份额包与DKG消息签名，pre包提供单向代理重加密，hdkey包用于分层确定性派生子密钥，
+// tsign包以委员会的私钥份额生成门限SM2签名，vrf包提供基于节点SM2密钥的可验证随机函数，
+// escrow包以可验证加密将用户私钥托管于委员会公钥之下，stealth包为请求者派生不可关联的一次性目标公钥。
+//
+// Concurrency: functions are safe for concurrent use, as are KeyPair and Verifier.
+// ShareAccumulator and SecretBytes must not be shared between goroutines without
+// locking; SafeAccumulator is the concurrency-safe accumulator.
+// 并发：函数均可并发调用，KeyPair与Verifier亦可并发使用。ShareAccumulator与SecretBytes
+// 未加锁时不可在协程间共享，SafeAccumulator为并发安全的累加器。
 package ppks
 
 import (
-	"crypto/elliptic"
 	"crypto/rand"
-	"io"
-	"log"
 	"math/big"
 
+	"ppks/elgamal"
+	"ppks/keyswitch"
+	"ppks/proof"
+
 	"github.com/tjfoc/gmsm/sm2"
-	"github.com/tjfoc/gmsm/sm3"
 )
 
 /*
@@ -43,26 +73,26 @@ type PrivateKey struct {
 */
 
 // CurvePoint 曲线上的点
-type CurvePoint sm2.PublicKey
+type CurvePoint = elgamal.CurvePoint
 
 // PointVector 曲线点向量
-type PointVector []CurvePoint
+type PointVector = elgamal.PointVector
 
 // CipherText is an ElGamal encrypted point.
 // 密文，基于群的ElGamal加密文本，形式为群上点对。
-type CipherText struct {
-	K, C CurvePoint
-}
+type CipherText = elgamal.CipherText
 
 // CipherVector is a slice of ElGamal encrypted points.
 // 密文向量，基于群的ElGamal密文slice。
-type CipherVector []CipherText
+type CipherVector = elgamal.CipherVector
 
-type Pai struct {
-	c, r1, r2 *(big.Int)
-}
+// Pai is a non-interactive proof pai=(c,r1,r2) produced by ProofGen.
+// 证明，零知识证明生成函数输出的证明pai=(c,r1,r2)。
+type Pai = keyswitch.Pai
 
-type PaiVector []Pai
+// PaiVector is a slice of proofs, one per node in a key-switch session.
+// 证明向量，一次密钥置换中各节点的证明slice。
+type PaiVector = keyswitch.PaiVector
 
 // GenPrivKey generates a private key at random.
 // 生成私钥：随机生成一个私钥并返回。
@@ -87,6 +117,7 @@ func GetPubKey(priv *sm2.PrivateKey) *sm2.PublicKey {
 }
 
 // GenPoint generates a curve point at random.
+// It is a wrapper of elgamal.GenPoint.
 // 生成点：随机生成一个点并返回。
 //
 // 参数：
@@ -94,15 +125,11 @@ func GetPubKey(priv *sm2.PrivateKey) *sm2.PublicKey {
 // 返回：
 // 		点
 func GenPoint() *CurvePoint {
-	d, err := GenPrivKey()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return (*CurvePoint)(&d.PublicKey)
+	return elgamal.GenPoint()
 }
 
 // CollPrivKey returns the addition of the private keys in privs.
+// It is a wrapper of keyswitch.CollPrivKey.
 // 聚合私钥：加和privs中的私钥，并返回。
 //
 // 参数：
@@ -110,34 +137,11 @@ func GenPoint() *CurvePoint {
 // 返回：
 // 		聚合私钥
 func CollPrivKey(privs []sm2.PrivateKey) *sm2.PrivateKey {
-
-	// 返回集合公钥
-	collPrivKey := privs[0]
-
-	// 私钥&公钥变量
-	collPriv, _ := new(big.Int).SetString("0", 16)
-	// pubKeys := make([]sm2.PublicKey, len(privs))
-
-	// 遍历私钥组
-	for i := 0; i < len(privs); i++ {
-		// 累加私钥
-		collPriv.Add(collPriv, privs[i].D)
-		if collPriv.Cmp(collPrivKey.Curve.Params().N) >= 0 {
-			collPriv.Mod(collPriv, collPrivKey.Curve.Params().N)
-		}
-		// 赋值公钥组
-		// pubKeys[i] = *(GetPubKey(&privs[i]))
-	}
-
-	// 分别赋值私钥&公钥
-	collPrivKey.D = collPriv
-	// collPrivKey.PublicKey = *CollPubKey(pubKeys)
-	collPrivKey.PublicKey.X, collPrivKey.PublicKey.Y = collPrivKey.PublicKey.Curve.ScalarBaseMult(collPrivKey.D.Bytes())
-
-	return &collPrivKey
+	return keyswitch.CollPrivKey(privs)
 }
 
 // CollPubKey returns the addition of the public keys in pubs.
+// It is a wrapper of keyswitch.CollPubKey.
 // 聚合公钥：加和pubs中的公钥，并返回。
 //
 // 参数：
@@ -145,15 +149,73 @@ func CollPrivKey(privs []sm2.PrivateKey) *sm2.PrivateKey {
 // 返回：
 // 		聚合公钥
 func CollPubKey(pubs []sm2.PublicKey) *sm2.PublicKey {
-	collPubKey := pubs[0]
-	curve := collPubKey.Curve
-	for i := 1; i < len(pubs); i++ {
-		collPubKey.X, collPubKey.Y = curve.Add(collPubKey.X, collPubKey.Y, pubs[i].X, pubs[i].Y)
-	}
-	return &collPubKey
+	return keyswitch.CollPubKey(pubs)
+}
+
+// AggregatePrivKeys returns the addition of the private keys in privs without
+// copying them.
+// It is a wrapper of keyswitch.AggregatePrivKeys.
+// 聚合私钥：加和privs中的私钥并返回，不复制私钥。
+//
+// 参数：
+//		私钥指针slice	privs
+// 返回：
+// 		聚合私钥
+func AggregatePrivKeys(privs []*sm2.PrivateKey) (*sm2.PrivateKey, error) {
+	return keyswitch.AggregatePrivKeys(privs)
+}
+
+// AggregatePubKeys returns the addition of the public keys in pubs without copying them.
+// It is a wrapper of keyswitch.AggregatePubKeys.
+// 聚合公钥：加和pubs中的公钥并返回，不复制公钥。
+//
+// 参数：
+//		公钥指针slice	pubs
+// 返回：
+// 		聚合公钥
+func AggregatePubKeys(pubs []*sm2.PublicKey) (*sm2.PublicKey, error) {
+	return keyswitch.AggregatePubKeys(pubs)
+}
+
+// KeyRegistration is a member's registration of its key with a committee, signed
+// with the matching private key.
+// 成员公钥登记：成员向委员会登记的公钥，附对应私钥的签名。
+type KeyRegistration = keyswitch.KeyRegistration
+
+// CommitteeKeyCertificate certifies that its PubKey is the sum of the registered
+// keys of the committee members.
+// 委员会公钥证书：证明其PubKey为委员会各成员登记公钥之和。
+type CommitteeKeyCertificate = keyswitch.CommitteeKeyCertificate
+
+// RegisterKey registers the key of priv with committee.
+// It is a wrapper of keyswitch.RegisterKey.
+// 登记公钥：将私钥priv对应的公钥登记到委员会committee。
+//
+// 参数：
+//		私钥		priv
+//		委员会标识	committee
+// 返回：
+// 		公钥登记
+func RegisterKey(priv *sm2.PrivateKey, committee []byte) (*KeyRegistration, error) {
+	return keyswitch.RegisterKey(priv, committee)
+}
+
+// NewCommitteeKeyCertificate checks the registrations regs with committee and
+// certifies their sum.
+// It is a wrapper of keyswitch.NewCommitteeKeyCertificate.
+// 创建委员会公钥证书：检查各成员在委员会committee的公钥登记regs，并为其公钥之和签发证书。
+//
+// 参数：
+//		委员会标识		committee
+//		公钥登记slice	regs
+// 返回：
+// 		委员会公钥证书
+func NewCommitteeKeyCertificate(committee []byte, regs []*KeyRegistration) (*CommitteeKeyCertificate, error) {
+	return keyswitch.NewCommitteeKeyCertificate(committee, regs)
 }
 
 // PointEncrypt encrypts D with pub and returns the ciphertext.
+// It is a wrapper of elgamal.PointEncrypt.
 // 点加密：使用公钥加密点D，返回密文。
 // 本项目中使用点D的指定坐标来作为对称密钥（暂定为横坐标）。
 //
@@ -163,31 +225,40 @@ func CollPubKey(pubs []sm2.PublicKey) *sm2.PublicKey {
 // 返回：
 // 		密文		ct{K,C}
 func PointEncrypt(pub *sm2.PublicKey, D *CurvePoint) (*CipherText, error) {
-	var ct CipherText
-
-	// 从公钥提取曲线
-	curve := pub.Curve
-	// 从有限域中获得随机元素
-	r, err := randFieldElement(curve, rand.Reader)
-	if err != nil {
-		return &ct, err
-	}
-
-	// 随机数数乘生成元，生成密文左侧点K，rB
-	ct.K.Curve = curve
-	ct.K.X, ct.K.Y = curve.ScalarBaseMult(r.Bytes())
-
-	// 随机数乘公钥得到点rK
-	rKx, rKy := curve.ScalarMult(pub.X, pub.Y, r.Bytes())
+	return elgamal.PointEncrypt(pub, D)
+}
 
-	// 待加密点与点rK相加，得到右侧点，ct.C
-	ct.C.Curve = curve
-	ct.C.X, ct.C.Y = curve.Add(rKx, rKy, D.X, D.Y)
+// EncryptWithProof encrypts D with pub and proves knowledge of the randomness, and
+// so of the plaintext, of the ciphertext.
+// It is a wrapper of keyswitch.EncryptWithProof.
+// 带证明的点加密：使用公钥pub加密点D，并证明知道密文的随机数，从而知道其明文。
+//
+// 参数：
+//		公钥		pub
+//		待加密点	D
+// 返回：
+// 		密文		ct{K,C}
+//		证明
+func EncryptWithProof(pub *sm2.PublicKey, D *CurvePoint) (*CipherText, *proof.LinearProof, error) {
+	return keyswitch.EncryptWithProof(pub, D)
+}
 
-	return &ct, nil
+// VerifyEncryption verifies the proof p of EncryptWithProof for ct under pub.
+// It is a wrapper of keyswitch.VerifyEncryption.
+// 加密证明验证：验证EncryptWithProof为公钥pub下的密文ct生成的证明p。
+//
+// 参数：
+//		公钥	pub
+//		密文	ct
+//		证明	p
+// 返回：
+// 		验证结果
+func VerifyEncryption(pub *sm2.PublicKey, ct *CipherText, p *proof.LinearProof) (bool, error) {
+	return keyswitch.VerifyEncryption(pub, ct, p)
 }
 
 // PointDecrypt decrypts ct with priv and returns the resulting curve point.
+// It is a wrapper of elgamal.PointDecrypt.
 // 点解密：使用私钥priv解密密文ct，返回结果点。
 // 本项目中使用其中指定坐标来作为对称密钥（暂定为横坐标）。
 //
@@ -197,44 +268,12 @@ func PointEncrypt(pub *sm2.PublicKey, D *CurvePoint) (*CipherText, error) {
 // 返回：
 // 		明文点
 func PointDecrypt(ct *CipherText, priv *sm2.PrivateKey) (*CurvePoint, error) {
-
-	curve := priv.Curve
-
-	// 原算法
-	////////////////////////////////////////////////////////////////////////
-	// 私钥数乘左侧点K(rB)，得到点rK
-	rKx, rKy := curve.ScalarMult(ct.K.X, ct.K.Y, priv.D.Bytes())
-
-	// 求点-rK，纵坐标取负值
-	negrKy := new(big.Int).Neg(rKy)
-	negrKy.Mod(negrKy, curve.Params().P)
-
-	// 密文右侧点C减去rK(加上负rK)，得到密文点
-	var D CurvePoint
-	D.Curve = curve
-	D.X, D.Y = priv.Curve.Add(ct.C.X, ct.C.Y, rKx, negrKy)
-	////////////////////////////////////////////////////////////////////////
-
-	// 新算法
-	////////////////////////////////////////////////////////////////////////
-	// // 计算私钥模n的负数，-priv
-	// negPriv := priv.D.Neg(priv.D)
-	// negPriv.Mod(negPriv, curve.Params().N)
-
-	// // 计算点-rK，即rB*(-priv)
-	// negrKx, negrKy := curve.ScalarMult(ct.K.X, ct.K.Y, negPriv.Bytes())
-
-	// // 密文右侧点C加上点-rK，计算明文点D
-	// var D CurvePoint
-	// D.Curve = curve
-	// D.X, D.Y = curve.Add(ct.C.X, ct.C.Y, negrKx, negrKy)
-	////////////////////////////////////////////////////////////////////////
-
-	return &D, nil
+	return elgamal.PointDecrypt(ct, priv)
 }
 
 // ShareCal calculates the share related with rB(left point K in ciphertext)
 // for targetPubKey with priv.
+// It is a wrapper of keyswitch.ShareCal.
 // 份额计算: 使用私钥priv，为目标公钥targetPubKey计算关于点rB（一份密文的左侧点K）的份额，并返回。
 //
 // 参数：
@@ -245,35 +284,11 @@ func PointDecrypt(ct *CipherText, priv *sm2.PrivateKey) (*CurvePoint, error) {
 // 		份额密文：	share
 //		随机数：	ri
 func ShareCal(targetPubKey *sm2.PublicKey, rB *CurvePoint, priv *sm2.PrivateKey) (*CipherText, *big.Int, error) {
-	var share CipherText
-
-	// 生成随机数ri
-	curve := priv.Curve                             // 从公钥提取曲线
-	ri, err := randFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
-	if err != nil {
-		return &share, ri, err
-	}
-
-	// 计算左侧点K，riB
-	share.K.Curve = priv.Curve
-	share.K.X, share.K.Y = curve.ScalarBaseMult(ri.Bytes())
-
-	// 计算-rKi，即-rBki，其中，Ki为己方公钥，ki为己方私钥
-	rBkix, rBkiy := curve.ScalarMult(rB.X, rB.Y, priv.D.Bytes())
-	rBkiy.Neg(rBkiy)
-	rBkiy.Mod(rBkiy, curve.Params().P)
-
-	// 计算riU
-	riUx, riUy := curve.ScalarMult(targetPubKey.X, targetPubKey.Y, ri.Bytes())
-
-	// 计算右侧点C，即-rKi+riU
-	share.C.Curve = priv.Curve
-	share.C.X, share.C.Y = curve.Add(rBkix, rBkiy, riUx, riUy)
-
-	return &share, ri, nil
+	return keyswitch.ShareCal(targetPubKey, rB, priv)
 }
 
 // ShareProofGen generate the proof of a share for the random nonce ri and the private-key priv.
+// It is a wrapper of keyswitch.ShareProofGen.
 // 份额计算证明生成: 使用计算份额生成的随机数ri和节点私钥priv，生成份额share的计算证明，并返回。
 //
 // 参数：
@@ -285,37 +300,11 @@ func ShareCal(targetPubKey *sm2.PublicKey, rB *CurvePoint, priv *sm2.PrivateKey)
 // 返回：
 // 		证明pai：	c,r1,r2
 func ShareProofGen(ri *big.Int, priv *sm2.PrivateKey, share *CipherText, targetPubKey *sm2.PublicKey, rB *CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
-	// share.K = ri*B ; priv.PublicKey = priv*B ;
-	// targetPubKey*ri + (-rB*priv) = share.C
-	// y1 = ri
-	// y2 = priv.D
-	// B = B
-	// Y1 = riB = share.K
-	// Y2 = priv.PublicKey
-	// A1 = targetPubKey
-	// A2 = -rB
-	// A = share.C
-	curve := priv.Curve
-	var B CurvePoint
-	B.Curve = curve
-	B.X = curve.Params().Gx
-	B.Y = curve.Params().Gy
-	A2 := new(CurvePoint)
-	A2.Curve = rB.Curve
-	A2.X = new(big.Int).Set(rB.X)
-	A2.Y = new(big.Int).Set(rB.Y)
-	A2.Y.Neg(A2.Y)
-	A2.Y.Mod(A2.Y, curve.Params().P)
-
-	c, r1, r2, err := ProofGen(ri, priv.D, &B, &share.K, (*CurvePoint)(&priv.PublicKey), (*CurvePoint)(targetPubKey), A2, &share.C)
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	return c, r1, r2, err
+	return keyswitch.ShareProofGen(ri, priv, share, targetPubKey, rB)
 }
 
 // ShareProofGenNoB generate the proof of a share for the random nonce ri and the private-key priv.
+// It is a wrapper of keyswitch.ShareProofGenNoB.
 // 份额计算证明生成: 使用计算份额生成的随机数ri和节点私钥priv，生成份额share的计算证明，并返回。
 //
 // 参数：
@@ -327,33 +316,11 @@ func ShareProofGen(ri *big.Int, priv *sm2.PrivateKey, share *CipherText, targetP
 // 返回：
 // 		证明pai：	c,r1,r2
 func ShareProofGenNoB(ri *big.Int, priv *sm2.PrivateKey, share *CipherText, targetPubKey *sm2.PublicKey, rB *CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
-	// share.K = ri*B ; priv.PublicKey = priv*B ;
-	// targetPubKey*ri + (-rB*priv) = share.C
-	// y1 = ri
-	// y2 = priv.D
-	// (B = B)
-	// Y1 = riB = share.K
-	// Y2 = priv.PublicKey
-	// A1 = targetPubKey
-	// A2 = -rB
-	// A = share.C
-	curve := priv.Curve
-	A2 := new(CurvePoint)
-	A2.Curve = rB.Curve
-	A2.X = new(big.Int).Set(rB.X)
-	A2.Y = new(big.Int).Set(rB.Y)
-	A2.Y.Neg(A2.Y)
-	A2.Y.Mod(A2.Y, curve.Params().P)
-
-	c, r1, r2, err := ProofGenNoB(ri, priv.D, &share.K, (*CurvePoint)(&priv.PublicKey), (*CurvePoint)(targetPubKey), A2, &share.C)
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	return c, r1, r2, err
+	return keyswitch.ShareProofGenNoB(ri, priv, share, targetPubKey, rB)
 }
 
 // ShareProofVry verify the proof pai=(c,r1,r2) for the calculation of the share.
+// It is a wrapper of keyswitch.ShareProofVry.
 // 份额证明验证: 验证证明pai=(c,r1,r2)是否能够证明份额share是由随机数ri和节点私钥priv计算得来，即公开点(share,targetPubKey,rB)满足约束
 //     {share.K = ri*B ; priv.PublicKey = priv*B ;
 //      targetPubKey*ri + (-rB*priv) = share.C}，
@@ -368,36 +335,11 @@ func ShareProofGenNoB(ri *big.Int, priv *sm2.PrivateKey, share *CipherText, targ
 // 返回：
 // 		验证结果：	bool
 func ShareProofVry(c, r1, r2 *big.Int, share *CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *CurvePoint) (bool, error) {
-	// share.K = ri*B ; priv.PublicKey = priv*B ;
-	// targetPubKey*ri + (-rB*priv) = share.C
-	// c,r1,r2 = c,r1,r2
-	// B = B
-	// Y1 = riB = share.K
-	// Y2 = nodePubKey
-	// A1 = targetPubKey
-	// A2 = -rB
-	// A = share.C
-	curve := targetPubKey.Curve
-	var B CurvePoint
-	B.Curve = curve
-	B.X = curve.Params().Gx
-	B.Y = curve.Params().Gy
-	A2 := new(CurvePoint)
-	A2.Curve = rB.Curve
-	A2.X = new(big.Int).Set(rB.X)
-	A2.Y = new(big.Int).Set(rB.Y)
-	A2.Y.Neg(A2.Y)
-	A2.Y.Mod(A2.Y, curve.Params().P)
-
-	flag, err := ProofVrf(c, r1, r2, &B, &share.K, (*CurvePoint)(nodePubKey), (*CurvePoint)(targetPubKey), A2, &share.C)
-	if err != nil {
-		return false, err
-	}
-
-	return flag, err
+	return keyswitch.ShareProofVry(c, r1, r2, share, nodePubKey, targetPubKey, rB)
 }
 
 // ShareProofVryNoB verify the proof pai=(c,r1,r2) for the calculation of the share.
+// It is a wrapper of keyswitch.ShareProofVryNoB.
 // 份额证明验证: 验证证明pai=(c,r1,r2)是否能够证明份额share是由随机数ri和节点私钥priv计算得来，即公开点(share,targetPubKey,rB)满足约束
 //     {share.K = ri*B ; priv.PublicKey = priv*B ;
 //      targetPubKey*ri + (-rB*priv) = share.C}，
@@ -412,32 +354,11 @@ func ShareProofVry(c, r1, r2 *big.Int, share *CipherText, nodePubKey, targetPubK
 // 返回：
 // 		验证结果：	bool
 func ShareProofVryNoB(c, r1, r2 *big.Int, share *CipherText, nodePubKey, targetPubKey *sm2.PublicKey, rB *CurvePoint) (bool, error) {
-	// share.K = ri*B ; priv.PublicKey = priv*B ;
-	// targetPubKey*ri + (-rB*priv) = share.C
-	// c,r1,r2 = c,r1,r2
-	// (B = B)
-	// Y1 = riB = share.K
-	// Y2 = nodePubKey
-	// A1 = targetPubKey
-	// A2 = -rB
-	// A = share.C
-	curve := targetPubKey.Curve
-	A2 := new(CurvePoint)
-	A2.Curve = rB.Curve
-	A2.X = new(big.Int).Set(rB.X)
-	A2.Y = new(big.Int).Set(rB.Y)
-	A2.Y.Neg(A2.Y)
-	A2.Y.Mod(A2.Y, curve.Params().P)
-
-	flag, err := ProofVrfNoB(c, r1, r2, &share.K, (*CurvePoint)(nodePubKey), (*CurvePoint)(targetPubKey), A2, &share.C)
-	if err != nil {
-		return false, err
-	}
-
-	return flag, err
+	return keyswitch.ShareProofVryNoB(c, r1, r2, share, nodePubKey, targetPubKey, rB)
 }
 
 // ProofGen generate the proof for (y1,y2) with constraints {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}.
+// It is a wrapper of proof.Gen.
 // 零知识证明生成: 为（y1,y2）生成满足约束
 //     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}
 // 的证明pai=(c,r1,r2)，并返回。
@@ -448,65 +369,11 @@ func ShareProofVryNoB(c, r1, r2 *big.Int, share *CipherText, nodePubKey, targetP
 // 返回：
 // 		证明:	c,r1,r2
 func ProofGen(y1, y2 *big.Int, B, Y1, Y2, A1, A2, A *CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
-	// 生成两个随机数v1,v2
-	curve := Y1.Curve                               // 从公钥提取曲线
-	v1, err := randFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	v2, err := randFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	// 计算承诺值：T1=v1*B, T2=v2*B, T3=v1*A1+v2*A2
-	var T1, T2, T3 CurvePoint
-	T1.Curve = curve
-	T1.X, T1.Y = curve.ScalarMult(B.X, B.Y, v1.Bytes())
-	T2.Curve = curve
-	T2.X, T2.Y = curve.ScalarMult(B.X, B.Y, v2.Bytes())
-	T3.Curve = curve
-	vA1x, vA1y := curve.ScalarMult(A1.X, A1.Y, v1.Bytes())
-	vA2x, vA2y := curve.ScalarMult(A2.X, A2.Y, v2.Bytes())
-	T3.X, T3.Y = curve.Add(vA1x, vA1y, vA2x, vA2y)
-
-	// 计算挑战：c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3)
-	h := sm3.New()
-	h.Write(B.X.Bytes())
-	h.Write(B.Y.Bytes())
-	h.Write(Y1.X.Bytes())
-	h.Write(Y1.Y.Bytes())
-	h.Write(Y2.X.Bytes())
-	h.Write(Y2.Y.Bytes())
-	h.Write(A1.X.Bytes())
-	h.Write(A1.Y.Bytes())
-	h.Write(A2.X.Bytes())
-	h.Write(A2.Y.Bytes())
-	h.Write(A.X.Bytes())
-	h.Write(A.Y.Bytes())
-	h.Write(T1.X.Bytes())
-	h.Write(T1.Y.Bytes())
-	h.Write(T2.X.Bytes())
-	h.Write(T2.Y.Bytes())
-	h.Write(T3.X.Bytes())
-	h.Write(T3.Y.Bytes())
-	c := new(big.Int).SetBytes(h.Sum(nil)[:32])
-
-	// 计算应答：r1=v1-c*y1, r2=v2-c*y2
-	r1 := new(big.Int).Mul(c, y1)
-	r1.Mod(r1, curve.Params().N)
-	r1 = new(big.Int).Sub(v1, r1)
-	r1.Mod(r1, curve.Params().N)
-
-	r2 := new(big.Int).Mul(c, y2)
-	r2.Mod(r2, curve.Params().N)
-	r2.Sub(v2, r2)
-	r2.Mod(r2, curve.Params().N)
-
-	return c, r1, r2, nil
+	return proof.Gen(y1, y2, B, Y1, Y2, A1, A2, A)
 }
 
 // ProofGenNoB generate the proof for (y1,y2) with constraints {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}.
+// It is a wrapper of proof.GenNoB.
 // 零知识证明生成: 为（y1,y2）生成满足约束
 //     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}
 // 的证明pai=(c,r1,r2)，并返回。
@@ -517,65 +384,11 @@ func ProofGen(y1, y2 *big.Int, B, Y1, Y2, A1, A2, A *CurvePoint) (*big.Int, *big
 // 返回：
 // 		证明:	c,r1,r2
 func ProofGenNoB(y1, y2 *big.Int, Y1, Y2, A1, A2, A *CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
-	// 生成两个随机数v1,v2
-	curve := Y1.Curve                               // 从公钥提取曲线
-	v1, err := randFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	v2, err := randFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	// 计算承诺值：T1=v1*B, T2=v2*B, T3=v1*A1+v2*A2
-	var T1, T2, T3 CurvePoint
-	T1.Curve = curve
-	T1.X, T1.Y = curve.ScalarBaseMult(v1.Bytes())
-	T2.Curve = curve
-	T2.X, T2.Y = curve.ScalarBaseMult(v2.Bytes())
-	T3.Curve = curve
-	vA1x, vA1y := curve.ScalarMult(A1.X, A1.Y, v1.Bytes())
-	vA2x, vA2y := curve.ScalarMult(A2.X, A2.Y, v2.Bytes())
-	T3.X, T3.Y = curve.Add(vA1x, vA1y, vA2x, vA2y)
-
-	// 计算挑战：c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3)
-	h := sm3.New()
-	h.Write(curve.Params().Gx.Bytes())
-	h.Write(curve.Params().Gy.Bytes())
-	h.Write(Y1.X.Bytes())
-	h.Write(Y1.Y.Bytes())
-	h.Write(Y2.X.Bytes())
-	h.Write(Y2.Y.Bytes())
-	h.Write(A1.X.Bytes())
-	h.Write(A1.Y.Bytes())
-	h.Write(A2.X.Bytes())
-	h.Write(A2.Y.Bytes())
-	h.Write(A.X.Bytes())
-	h.Write(A.Y.Bytes())
-	h.Write(T1.X.Bytes())
-	h.Write(T1.Y.Bytes())
-	h.Write(T2.X.Bytes())
-	h.Write(T2.Y.Bytes())
-	h.Write(T3.X.Bytes())
-	h.Write(T3.Y.Bytes())
-	c := new(big.Int).SetBytes(h.Sum(nil)[:32])
-
-	// 计算应答：r1=v1-c*y1, r2=v2-c*y2
-	r1 := new(big.Int).Mul(c, y1)
-	r1.Mod(r1, curve.Params().N)
-	r1 = new(big.Int).Sub(v1, r1)
-	r1.Mod(r1, curve.Params().N)
-
-	r2 := new(big.Int).Mul(c, y2)
-	r2.Mod(r2, curve.Params().N)
-	r2.Sub(v2, r2)
-	r2.Mod(r2, curve.Params().N)
-
-	return c, r1, r2, nil
+	return proof.GenNoB(y1, y2, Y1, Y2, A1, A2, A)
 }
 
 // ProofVrf verify the proof pai=(c,r1,r2) with public points (B,Y1,Y2,A1,A2,A).
+// It is a wrapper of proof.Verify.
 // 零知识证明验证: 验证证明pai=(c,r1,r2)是否能够证明公开点(B,Y1,Y2,A1,A2,A)满足约束
 //     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}，
 // 并返回。
@@ -586,61 +399,11 @@ func ProofGenNoB(y1, y2 *big.Int, Y1, Y2, A1, A2, A *CurvePoint) (*big.Int, *big
 // 返回：
 // 		份额密文
 func ProofVrf(c, r1, r2 *big.Int, B, Y1, Y2, A1, A2, A *CurvePoint) (bool, error) {
-	curve := Y1.Curve
-
-	// 重构承诺：T1'=r1*B+c*Y1, T2'=r2*B+c*Y2, T3'=r1*A1+r2*A2+c*A
-	// 下文Ti' 用Ti指代
-	var T1, T2, T3 CurvePoint
-
-	T1.Curve = curve
-	rB1x, rB1y := curve.ScalarMult(B.X, B.Y, r1.Bytes())
-	cY1x, cY1y := curve.ScalarMult(Y1.X, Y1.Y, c.Bytes())
-	T1.X, T1.Y = curve.Add(rB1x, rB1y, cY1x, cY1y)
-
-	T2.Curve = curve
-	rB2x, rB2y := curve.ScalarMult(B.X, B.Y, r2.Bytes())
-	cY2x, cY2y := curve.ScalarMult(Y2.X, Y2.Y, c.Bytes())
-	T2.X, T2.Y = curve.Add(rB2x, rB2y, cY2x, cY2y)
-
-	T3.Curve = curve
-	rA1x, rA1y := curve.ScalarMult(A1.X, A1.Y, r1.Bytes())
-	rA2x, rA2y := curve.ScalarMult(A2.X, A2.Y, r2.Bytes())
-	cAx, cAy := curve.ScalarMult(A.X, A.Y, c.Bytes())
-	T3.X, T3.Y = curve.Add(rA1x, rA1y, rA2x, rA2y)
-	T3.X, T3.Y = curve.Add(T3.X, T3.Y, cAx, cAy)
-
-	// 计算新的挑战值：c'=H(B,Y1,Y2,A1,A2,A,T1',T2',T3')
-	// 如上，c'用c_new代替
-	h := sm3.New()
-	h.Write(B.X.Bytes())
-	h.Write(B.Y.Bytes())
-	h.Write(Y1.X.Bytes())
-	h.Write(Y1.Y.Bytes())
-	h.Write(Y2.X.Bytes())
-	h.Write(Y2.Y.Bytes())
-	h.Write(A1.X.Bytes())
-	h.Write(A1.Y.Bytes())
-	h.Write(A2.X.Bytes())
-	h.Write(A2.Y.Bytes())
-	h.Write(A.X.Bytes())
-	h.Write(A.Y.Bytes())
-	h.Write(T1.X.Bytes())
-	h.Write(T1.Y.Bytes())
-	h.Write(T2.X.Bytes())
-	h.Write(T2.Y.Bytes())
-	h.Write(T3.X.Bytes())
-	h.Write(T3.Y.Bytes())
-	c_new := new(big.Int).SetBytes(h.Sum(nil)[:32])
-
-	// 检查一致性：c?=c'
-	if 0 == c.Cmp(c_new) {
-		return true, nil
-	} else {
-		return false, nil
-	}
+	return proof.Verify(c, r1, r2, B, Y1, Y2, A1, A2, A)
 }
 
 // ProofVrfNoB verify the proof pai=(c,r1,r2) with public points (Y1,Y2,A1,A2,A).
+// It is a wrapper of proof.VerifyNoB.
 // 零知识证明验证: 验证证明pai=(c,r1,r2)是否能够证明公开点(Y1,Y2,A1,A2,A)满足约束
 //     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}，
 // 并返回。
@@ -651,61 +414,11 @@ func ProofVrf(c, r1, r2 *big.Int, B, Y1, Y2, A1, A2, A *CurvePoint) (bool, error
 // 返回：
 // 		份额密文
 func ProofVrfNoB(c, r1, r2 *big.Int, Y1, Y2, A1, A2, A *CurvePoint) (bool, error) {
-	curve := Y1.Curve
-
-	// 重构承诺：T1'=r1*B+c*Y1, T2'=r2*B+c*Y2, T3'=r1*A1+r2*A2+c*A
-	// 下文Ti' 用Ti指代
-	var T1, T2, T3 CurvePoint
-
-	T1.Curve = curve
-	r1Bx, r1By := curve.ScalarBaseMult(r1.Bytes())
-	cY1x, cY1y := curve.ScalarMult(Y1.X, Y1.Y, c.Bytes())
-	T1.X, T1.Y = curve.Add(r1Bx, r1By, cY1x, cY1y)
-
-	T2.Curve = curve
-	rB2x, rB2y := curve.ScalarBaseMult(r2.Bytes())
-	cY2x, cY2y := curve.ScalarMult(Y2.X, Y2.Y, c.Bytes())
-	T2.X, T2.Y = curve.Add(rB2x, rB2y, cY2x, cY2y)
-
-	T3.Curve = curve
-	rA1x, rA1y := curve.ScalarMult(A1.X, A1.Y, r1.Bytes())
-	rA2x, rA2y := curve.ScalarMult(A2.X, A2.Y, r2.Bytes())
-	cAx, cAy := curve.ScalarMult(A.X, A.Y, c.Bytes())
-	T3.X, T3.Y = curve.Add(rA1x, rA1y, rA2x, rA2y)
-	T3.X, T3.Y = curve.Add(T3.X, T3.Y, cAx, cAy)
-
-	// 计算新的挑战值：c'=H(B,Y1,Y2,A1,A2,A,T1',T2',T3')
-	// 如上，c'用c_new代替
-	h := sm3.New()
-	h.Write(curve.Params().Gx.Bytes())
-	h.Write(curve.Params().Gy.Bytes())
-	h.Write(Y1.X.Bytes())
-	h.Write(Y1.Y.Bytes())
-	h.Write(Y2.X.Bytes())
-	h.Write(Y2.Y.Bytes())
-	h.Write(A1.X.Bytes())
-	h.Write(A1.Y.Bytes())
-	h.Write(A2.X.Bytes())
-	h.Write(A2.Y.Bytes())
-	h.Write(A.X.Bytes())
-	h.Write(A.Y.Bytes())
-	h.Write(T1.X.Bytes())
-	h.Write(T1.Y.Bytes())
-	h.Write(T2.X.Bytes())
-	h.Write(T2.Y.Bytes())
-	h.Write(T3.X.Bytes())
-	h.Write(T3.Y.Bytes())
-	c_new := new(big.Int).SetBytes(h.Sum(nil)[:32])
-
-	// 检查一致性：c?=c'
-	if 0 == c.Cmp(c_new) {
-		return true, nil
-	} else {
-		return false, nil
-	}
+	return proof.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A)
 }
 
 // ShareReplace uses shares to convert rct(raw ciphertext) to a new ciphertext.
+// It is a wrapper of keyswitch.ShareReplace.
 // 份额置换：使用份额置换原密文为新密文，并返回。
 //
 // 参数：
@@ -714,43 +427,44 @@ func ProofVrfNoB(c, r1, r2 *big.Int, Y1, Y2, A1, A2, A *CurvePoint) (bool, error
 // 返回：
 // 		新密文
 func ShareReplace(shares *CipherVector, rct *CipherText) (*CipherText, error) {
-	curve := rct.K.Curve
-
-	// 检查置换份额数量
-	lens := len(*shares)
-	// 聚合份额至sigma
-	sigma := (*shares)[0]
-	for i := 1; i < lens; i++ {
-		sigma.K.X, sigma.K.Y = curve.Add(sigma.K.X, sigma.K.Y, (*shares)[i].K.X, (*shares)[i].K.Y)
-		sigma.C.X, sigma.C.Y = curve.Add(sigma.C.X, sigma.C.Y, (*shares)[i].C.X, (*shares)[i].C.Y)
-	}
-
-	// 通过sigma置换rct得到目标ct
-	ct := sigma
-	ct.C.X, ct.C.Y = curve.Add(sigma.C.X, sigma.C.Y, rct.C.X, rct.C.Y)
+	return keyswitch.ShareReplace(shares, rct)
+}
 
-	return &ct, nil
+// ShareReplaceParallel is ShareReplace summing the shares in a parallel tree
+// reduction on workers goroutines, capped to Parallelism, which workers <= 0
+// selects.
+// It is a wrapper of keyswitch.ShareReplaceParallel.
+// 并行份额置换：同ShareReplace，但以workers个协程并行树形归约份额之和；workers不超过Parallelism，
+// workers <= 0时取Parallelism。
+//
+// 参数：
+//		份额slice	shares
+//		密文原文	rct
+//		计算协程数	workers
+// 返回：
+// 		新密文
+func ShareReplaceParallel(shares *CipherVector, rct *CipherText, workers int) (*CipherText, error) {
+	return keyswitch.ShareReplaceParallel(shares, rct, workers)
 }
 
-var one = new(big.Int).SetInt64(1)
+// SetParallelism bounds the worker goroutines of every parallel batch operation
+// to n and returns the previous bound; n <= 0 restores the default, GOMAXPROCS.
+// It is a wrapper of keyswitch.SetParallelism.
+// 设置并行度：将所有并行批量运算的工作协程数限制为n，返回此前的上限；n <= 0恢复默认值GOMAXPROCS。
+//
+// 参数：
+//		协程数上限	n
+// 返回：
+// 		此前的上限
+func SetParallelism(n int) int {
+	return keyswitch.SetParallelism(n)
+}
 
-// randFieldElement generates a random k in Z_curve.N and returns.
-// 在椭圆曲线对生成元点G的秩N内生成随机数并返回。
-func randFieldElement(c elliptic.Curve, random io.Reader) (k *big.Int, err error) {
-	if random == nil {
-		random = rand.Reader //If there is no external trusted random source,please use rand.Reader to instead of it.
-	}
-	params := c.Params()
-	b := make([]byte, params.BitSize/8+8)
-	_, err = io.ReadFull(random, b)
-	if err != nil {
-		return
-	}
-	k = new(big.Int).SetBytes(b)
-	n := new(big.Int).Sub(params.N, one)
-	k.Mod(k, n)
-	k.Add(k, one)
-	return
+// Parallelism returns the bound on worker goroutines of parallel batch operations.
+// It is a wrapper of keyswitch.Parallelism.
+// 返回并行批量运算的工作协程数上限。
+func Parallelism() int {
+	return keyswitch.Parallelism()
 }
 
 // 32byte
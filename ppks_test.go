@@ -35,7 +35,7 @@ func TestGenPrivKey(t *testing.T) {
 
 	vr := priv
 	fmt.Println("type of var: ", reflect.TypeOf(vr))
-	fmt.Println("value of var: ", vr)
+	fmt.Println("value of var: ", RedactedPrivKey(vr))
 
 	fmt.Println()
 }
@@ -99,7 +99,7 @@ func TestCollPrivKey(t *testing.T) {
 	vr := collPrivKey
 	fmt.Println("调用集合私钥函数得到的私钥")
 	fmt.Println("type of var: ", reflect.TypeOf(vr))
-	fmt.Println("value of var: ", vr)
+	fmt.Println("value of var: ", RedactedPrivKey(vr))
 
 	vr1 := collPubKey
 	fmt.Println("调用集合公钥函数得到的公钥")
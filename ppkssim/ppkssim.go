@@ -0,0 +1,280 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ppkssim runs the whole ppks workflow in process: n virtual KS servers,
+// a coordinator and a requester, with configurable faults, and reports what
+// happened. It turns the workflow of the ppks tests into a reusable API for
+// experiments and integration tests.
+// 进程内模拟：在本进程中运行n个虚拟ks server、一个协调者和一个请求者，执行完整的ppks流程，
+// 可配置故障注入，并返回结构化报告。
+package ppkssim
+
+import (
+	"crypto/rand"
+	"math/big"
+	"time"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Fault is the misbehaviour injected into a virtual server.
+// 故障：注入虚拟ks server的异常行为。
+type Fault int
+
+const (
+	// FaultNone behaves honestly. 诚实执行。
+	FaultNone Fault = iota
+	// FaultMissingShare never answers. 不返回份额。
+	FaultMissingShare
+	// FaultBadProof returns a correct share with a corrupted proof. 份额正确但证明被篡改。
+	FaultBadProof
+	// FaultBadShare returns a random share with the proof of the honest one. 返回随机份额及诚实份额的证明。
+	FaultBadShare
+)
+
+// String returns the name of the fault.
+// 返回故障名称。
+func (f Fault) String() string {
+	switch f {
+	case FaultNone:
+		return "none"
+	case FaultMissingShare:
+		return "missing-share"
+	case FaultBadProof:
+		return "bad-proof"
+	case FaultBadShare:
+		return "bad-share"
+	default:
+		return "unknown"
+	}
+}
+
+// Config configures a simulated cluster.
+// 模拟集群配置。
+type Config struct {
+	// Servers is the number of KS servers. ks server数量。
+	Servers int
+	// Faults maps server indices to injected faults; absent servers are honest.
+	// 各ks server下标对应的故障，未列出的ks server诚实执行。
+	Faults map[int]Fault
+	// Suite is the proof suite; the zero value is proof.DefaultSuite.
+	// 证明参数组，零值即proof.DefaultSuite。
+	Suite proof.Suite
+}
+
+// Server is a virtual KS server.
+// 虚拟ks server。
+type Server struct {
+	// Index is the position of the server in the cluster. 在集群中的下标。
+	Index int
+	// Fault is the injected fault. 注入的故障。
+	Fault Fault
+
+	priv  *sm2.PrivateKey
+	suite proof.Suite
+}
+
+// PublicKey returns the public key of s.
+// 返回ks server的公钥。
+func (s *Server) PublicKey() *sm2.PublicKey {
+	return &s.priv.PublicKey
+}
+
+// Handle answers a key-switch request for targetPubKey and rB, applying the fault
+// of s. A server with FaultMissingShare returns nil.
+// 处理一次密钥置换请求：为目标公钥targetPubKey及密文左侧点rB生成份额包，并按s的故障篡改。
+// FaultMissingShare的ks server返回nil。
+func (s *Server) Handle(targetPubKey *sm2.PublicKey, rB *elgamal.CurvePoint) (*keyswitch.ShareBundle, error) {
+	if s.Fault == FaultMissingShare {
+		return nil, nil
+	}
+	b, err := keyswitch.GenShareBundleWithSuite(s.suite, targetPubKey, rB, s.priv)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s.Fault {
+	case FaultBadProof:
+		c, r1, r2 := b.Proof.Values()
+		b.Proof = keyswitch.NewPaiWithHash(new(big.Int).Add(c, big.NewInt(1)), r1, r2, b.Proof.Hash())
+	case FaultBadShare:
+		b.Share.C = *elgamal.GenPoint()
+	}
+	return b, nil
+}
+
+// Cluster is a set of virtual KS servers sharing a collective key.
+// 模拟集群：共享聚合公钥的一组虚拟ks server。
+type Cluster struct {
+	// Servers are the virtual KS servers. 虚拟ks server。
+	Servers []*Server
+
+	collPubKey *sm2.PublicKey
+}
+
+// New creates a cluster of cfg.Servers servers with fresh keys.
+// 创建模拟集群：生成cfg.Servers个ks server的密钥。
+//
+// 参数：
+//		配置	cfg
+// 返回：
+// 		模拟集群
+func New(cfg Config) (*Cluster, error) {
+	if cfg.Servers <= 0 {
+		return nil, &elgamal.Error{Op: "ppkssim.New", Err: elgamal.ErrEmpty}
+	}
+	suite := cfg.Suite
+	if suite.Hash == 0 {
+		suite = proof.DefaultSuite
+	}
+
+	c := &Cluster{Servers: make([]*Server, cfg.Servers)}
+	pubs := make([]*sm2.PublicKey, cfg.Servers)
+	for i := range c.Servers {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, &elgamal.Error{Op: "ppkssim.New", Err: err}
+		}
+		c.Servers[i] = &Server{Index: i, Fault: cfg.Faults[i], priv: priv, suite: suite}
+		pubs[i] = &priv.PublicKey
+	}
+	collPubKey, err := keyswitch.AggregatePubKeys(pubs)
+	if err != nil {
+		return nil, err
+	}
+	c.collPubKey = collPubKey
+
+	return c, nil
+}
+
+// PublicKey returns the collective public key of the cluster.
+// 返回集群的聚合公钥。
+func (c *Cluster) PublicKey() *sm2.PublicKey {
+	return c.collPubKey
+}
+
+// Report is the outcome of one simulated workflow.
+// 报告：一次模拟流程的结果。
+type Report struct {
+	// Servers is the number of KS servers. ks server数量。
+	Servers int
+	// Accepted lists the servers whose bundle verified. 份额包验证通过的ks server。
+	Accepted []int
+	// Missing lists the servers that sent no bundle. 未返回份额包的ks server。
+	Missing []int
+	// Rejected lists the servers whose bundle failed to verify. 份额包验证失败的ks server。
+	Rejected []int
+	// Switched reports whether the coordinator could switch the ciphertext, which
+	// needs a verified share from every server.
+	// 协调者能否完成置换，需全部ks server的份额均验证通过。
+	Switched bool
+	// Recovered reports whether the requester decrypted the original point.
+	// 请求者是否解密得到原始点。
+	Recovered bool
+	// Duration is the wall time of the workflow. 流程耗时。
+	Duration time.Duration
+}
+
+// Run runs the workflow once: a user encrypts a random point under the collective
+// key, every server answers a requester's key-switch request, the coordinator
+// verifies and replaces, and the requester decrypts.
+// 执行一次完整流程：用户以聚合公钥加密随机点，各ks server响应请求者的置换请求，
+// 协调者验证份额并置换密文，请求者解密。
+//
+// 参数：
+//
+// 返回：
+// 		报告
+func (c *Cluster) Run() (*Report, error) {
+	start := time.Now()
+	report := &Report{Servers: len(c.Servers)}
+
+	// 用户以聚合公钥加密点D
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(c.collPubKey, D)
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求者密钥对
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// 协调者收集并验证各ks server的份额包
+	var acc keyswitch.ShareAccumulator
+	for _, s := range c.Servers {
+		b, err := s.Handle(&q.PublicKey, &ct.K)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			report.Missing = append(report.Missing, s.Index)
+			continue
+		}
+		ok, err := b.Verify()
+		if err != nil || !ok || !sameKey(b.NodePubKey, s.PublicKey()) {
+			report.Rejected = append(report.Rejected, s.Index)
+			continue
+		}
+		if err := acc.Add(&b.Share); err != nil {
+			return nil, err
+		}
+		report.Accepted = append(report.Accepted, s.Index)
+	}
+
+	// 全部份额通过验证时置换并解密
+	if len(report.Accepted) == len(c.Servers) {
+		tct, err := acc.Finalize(ct)
+		if err != nil {
+			return nil, err
+		}
+		report.Switched = true
+		pt, err := elgamal.PointDecrypt(tct, q)
+		if err != nil {
+			return nil, err
+		}
+		report.Recovered = 0 == D.X.Cmp(pt.X) && 0 == D.Y.Cmp(pt.Y)
+	}
+
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// Run creates a cluster from cfg and runs the workflow once.
+// 由cfg创建模拟集群并执行一次完整流程。
+//
+// 参数：
+//		配置	cfg
+// 返回：
+// 		报告
+func Run(cfg Config) (*Report, error) {
+	c, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return c.Run()
+}
+
+// sameKey reports whether a and b are the same public key.
+// 判断a与b是否为同一公钥。
+func sameKey(a, b *sm2.PublicKey) bool {
+	return a != nil && b != nil && a.X.Cmp(b.X) == 0 && a.Y.Cmp(b.Y) == 0
+}
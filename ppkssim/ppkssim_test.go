@@ -0,0 +1,78 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppkssim
+
+import (
+	"reflect"
+	"testing"
+
+	"ppks/proof"
+)
+
+func TestRunHonest(t *testing.T) {
+	report, err := Run(Config{Servers: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Switched || !report.Recovered || len(report.Accepted) != 20 {
+		t.Fatalf("honest run failed: %+v", report)
+	}
+
+	report, err = Run(Config{Servers: 5, Suite: proof.Suite{Hash: proof.HashSHA256}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Recovered {
+		t.Fatalf("SHA-256 run failed: %+v", report)
+	}
+}
+
+func TestRunFaults(t *testing.T) {
+	c, err := New(Config{
+		Servers: 10,
+		Faults:  map[int]Fault{1: FaultMissingShare, 4: FaultBadProof, 7: FaultBadShare},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := c.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Missing, []int{1}) || !reflect.DeepEqual(report.Rejected, []int{4, 7}) {
+		t.Fatalf("unexpected faults in report: %+v", report)
+	}
+	if report.Switched || report.Recovered || len(report.Accepted) != 7 {
+		t.Fatalf("faulty run reported success: %+v", report)
+	}
+
+	// 修复故障后同一集群可完成置换
+	for _, s := range c.Servers {
+		s.Fault = FaultNone
+	}
+	report, err = c.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Recovered {
+		t.Fatalf("repaired run failed: %+v", report)
+	}
+
+	if _, err := New(Config{}); err == nil {
+		t.Fatal("expected error for an empty cluster")
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pre implements unidirectional, single-hop proxy re-encryption of point
+// ciphertexts. The owner A of a key issues a re-encryption key for B using only
+// B's public key; a single semi-trusted proxy then turns ciphertexts under A into
+// ciphertexts B decrypts, without interaction and without learning the points.
+// It complements the multi-server key switch where one proxy is acceptable and
+// latency matters.
+//
+// The re-encryption key is (a - x, Enc_B(P)) with x derived from a random point
+// P: the proxy rewrites C = D + r*A into D + r*x*G and attaches Enc_B(P), from
+// which B recovers x. The proxy alone learns nothing of a, and B cannot re-encrypt
+// onwards, but a proxy colluding with B recovers a.
+//
+// pre包实现点密文的单向、单跳代理重加密。密钥所有者A仅凭B的公钥即可为B生成重加密密钥；
+// 之后由单个半可信代理将A的密文转换为B可解密的密文，无需交互，代理也无法得知明文点。
+// 在可接受单一代理且对时延敏感的场景下，可作为多服务器密钥置换的补充。
+//
+// 重加密密钥为(a - x, Enc_B(P))，x由随机点P派生：代理将C = D + r*A改写为D + r*x*G，
+// 并附上Enc_B(P)，B由其恢复x。代理单独无法得知a的任何信息，B也无法继续重加密，
+// 但代理与B合谋可恢复a。
+package pre
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// kdfLabel separates the derivation of x from other uses of the SM2 KDF.
+// 派生x时使用的标签，与SM2 KDF的其他用途相区分。
+const kdfLabel = "ppks-pre"
+
+// ReKey is a re-encryption key from A to B, held by the proxy.
+// 重加密密钥：由A为B生成，交由代理持有。
+type ReKey struct {
+	// S is a - x mod N. a - x mod N。
+	S *big.Int
+	// Key is the encryption to B of the point x is derived from.
+	// 派生x所用点在B公钥下的密文。
+	Key elgamal.CipherText
+}
+
+// CipherText is a re-encrypted ciphertext: Body = (K, D + x*K) and the encrypted
+// point Key that B derives x from.
+// 重加密密文：Body = (K, D + x*K)，以及B用于派生x的点密文Key。
+type CipherText struct {
+	Body elgamal.CipherText
+	Key  elgamal.CipherText
+}
+
+// GenReKey generates the re-encryption key from privA to pubB.
+// 生成重加密密钥：生成由私钥privA到公钥pubB的重加密密钥。
+//
+// 参数：
+//		A的私钥	privA
+//		B的公钥	pubB
+// 返回：
+// 		重加密密钥
+func GenReKey(privA *sm2.PrivateKey, pubB *sm2.PublicKey) (*ReKey, error) {
+	if privA == nil || privA.D == nil {
+		return nil, opError("GenReKey", elgamal.ErrPointNotOnCurve)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pubB)); err != nil {
+		return nil, opError("GenReKey", err)
+	}
+	if pubB.Curve != privA.Curve {
+		return nil, opError("GenReKey", elgamal.ErrCurveMismatch)
+	}
+
+	curve := privA.Curve
+	p, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, opError("GenReKey", err)
+	}
+	P := elgamal.Generator(curve)
+	P.X, P.Y = curve.ScalarBaseMult(p.Bytes())
+	key, err := elgamal.PointEncrypt(pubB, P)
+	if err != nil {
+		return nil, opError("GenReKey", err)
+	}
+
+	s := new(big.Int).Sub(privA.D, deriveX(P))
+	s.Mod(s, curve.Params().N)
+	return &ReKey{S: s, Key: *key}, nil
+}
+
+// ReEncrypt transforms ct, encrypted under A, with the re-encryption key rk from A
+// to B.
+// 重加密：使用由A到B的重加密密钥rk，将A公钥下的密文ct转换为B可解密的密文。
+//
+// 参数：
+//		重加密密钥	rk
+//		密文		ct
+// 返回：
+// 		重加密密文
+func ReEncrypt(rk *ReKey, ct *elgamal.CipherText) (*CipherText, error) {
+	if rk == nil || rk.S == nil {
+		return nil, opError("ReEncrypt", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return nil, opError("ReEncrypt", err)
+	}
+	if err := elgamal.CheckCipherText(&rk.Key); err != nil {
+		return nil, opError("ReEncrypt", err)
+	}
+	if ct.K.Curve != rk.Key.K.Curve {
+		return nil, opError("ReEncrypt", elgamal.ErrCurveMismatch)
+	}
+
+	// C - (a-x)K = D + xK
+	curve := ct.K.Curve
+	var out CipherText
+	out.Body.K = ct.K
+	sx, sy := curve.ScalarMult(ct.K.X, ct.K.Y, rk.S.Bytes())
+	sx, sy = ec.Neg(curve, sx, sy)
+	out.Body.C.Curve = curve
+	out.Body.C.X, out.Body.C.Y = ec.Add(curve, ct.C.X, ct.C.Y, sx, sy)
+	out.Key = rk.Key
+	return &out, nil
+}
+
+// Decrypt decrypts the re-encrypted ciphertext ct with B's private key priv and
+// returns the point.
+// 解密：使用B的私钥priv解密重加密密文ct，返回明文点。
+//
+// 参数：
+//		重加密密文	ct
+//		B的私钥		priv
+// 返回：
+// 		明文点
+func Decrypt(ct *CipherText, priv *sm2.PrivateKey) (*elgamal.CurvePoint, error) {
+	if ct == nil {
+		return nil, opError("Decrypt", elgamal.ErrPointNotOnCurve)
+	}
+	if err := elgamal.CheckCipherText(&ct.Body); err != nil {
+		return nil, opError("Decrypt", err)
+	}
+	P, err := elgamal.PointDecrypt(&ct.Key, priv)
+	if err != nil {
+		return nil, opError("Decrypt", err)
+	}
+	if err := elgamal.CheckPoint(P); err != nil {
+		return nil, opError("Decrypt", err)
+	}
+
+	// D = C' - xK
+	curve := priv.Curve
+	xx, xy := curve.ScalarMult(ct.Body.K.X, ct.Body.K.Y, deriveX(P).Bytes())
+	xx, xy = ec.Neg(curve, xx, xy)
+	D := elgamal.Generator(curve)
+	D.X, D.Y = ec.Add(curve, ct.Body.C.X, ct.Body.C.Y, xx, xy)
+	return D, nil
+}
+
+// Verify lets B check, with its private key privB, that rk is a re-encryption key
+// from the key pubA: with x recovered from rk.Key, (S + x)*G must be pubA.
+// 验证重加密密钥：B以其私钥privB检查rk是否为由公钥pubA生成的重加密密钥，即由rk.Key恢复x后，
+// (S + x)*G须等于pubA。
+//
+// 参数：
+//		A的公钥	pubA
+//		B的私钥	privB
+// 返回：
+// 		验证结果
+func (rk *ReKey) Verify(pubA *sm2.PublicKey, privB *sm2.PrivateKey) (bool, error) {
+	if rk.S == nil {
+		return false, opError("ReKey.Verify", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pubA)); err != nil {
+		return false, opError("ReKey.Verify", err)
+	}
+	P, err := elgamal.PointDecrypt(&rk.Key, privB)
+	if err != nil {
+		return false, opError("ReKey.Verify", err)
+	}
+	if err := elgamal.CheckPoint(P); err != nil {
+		return false, nil
+	}
+
+	curve := pubA.Curve
+	a := new(big.Int).Add(rk.S, deriveX(P))
+	a.Mod(a, curve.Params().N)
+	x, y := curve.ScalarBaseMult(a.Bytes())
+	return x.Cmp(pubA.X) == 0 && y.Cmp(pubA.Y) == 0, nil
+}
+
+// deriveX derives x in [1, N-1] from the point P.
+// 由点P派生[1, N-1]中的x。
+func deriveX(P *elgamal.CurvePoint) *big.Int {
+	byteLen := (P.Curve.Params().BitSize + 7) / 8
+	px := make([]byte, byteLen)
+	py := make([]byte, byteLen)
+	P.X.FillBytes(px)
+	P.Y.FillBytes(py)
+	// 多取8字节以使约简后的偏差可忽略
+	x := new(big.Int).SetBytes(ec.KDF(byteLen+8, []byte(kdfLabel), px, py))
+	n1 := new(big.Int).Sub(P.Curve.Params().N, big.NewInt(1))
+	x.Mod(x, n1)
+	return x.Add(x, big.NewInt(1))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pre
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestReEncrypt(t *testing.T) {
+	a, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(&a.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rk, err := GenReKey(a, &b.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rct, err := ReEncrypt(rk, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(rct, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+		t.Fatal("delegatee decrypted a different point")
+	}
+
+	// 其他私钥无法解密，A的私钥也不能直接解密重加密后的密文
+	got, err = Decrypt(rct, a)
+	if err == nil && 0 == D.X.Cmp(got.X) && 0 == D.Y.Cmp(got.Y) {
+		t.Fatal("delegator decrypted the re-encrypted ciphertext")
+	}
+	if pt, err := elgamal.PointDecrypt(&rct.Body, a); err == nil && 0 == D.X.Cmp(pt.X) {
+		t.Fatal("body still decrypts under the delegator's key")
+	}
+}
+
+func TestReEncryptInvalid(t *testing.T) {
+	a, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenReKey(a, nil); err == nil {
+		t.Fatal("expected error for a nil delegatee key")
+	}
+	rk, err := GenReKey(a, &a.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReEncrypt(rk, &elgamal.CipherText{}); err == nil {
+		t.Fatal("expected error for an invalid ciphertext")
+	}
+	if _, err := ReEncrypt(&ReKey{}, &elgamal.CipherText{}); err == nil {
+		t.Fatal("expected error for an empty key")
+	}
+}
+
+func TestReKeyVerify(t *testing.T) {
+	a, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rk, err := GenReKey(a, &b.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := rk.Verify(&a.PublicKey, b); err != nil || !ok {
+		t.Fatal("re-encryption key failed to verify")
+	}
+	// 其他公钥生成的或被篡改的重加密密钥
+	if ok, _ := rk.Verify(&b.PublicKey, b); ok {
+		t.Fatal("re-encryption key verified for another key")
+	}
+	rk.S.Add(rk.S, big.NewInt(1))
+	if ok, _ := rk.Verify(&a.PublicKey, b); ok {
+		t.Fatal("tampered re-encryption key verified")
+	}
+}
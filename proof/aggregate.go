@@ -0,0 +1,228 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// Prover is one party of a jointly generated two-witness proof. Several parties,
+// each holding witnesses (y1_i,y2_i) of the statement
+//     {Y1_i=y1_i*B,Y2_i=y2_i*B,A1*y1_i+A2*y2_i=A_i}
+// with the same A1 and A2, can prove the summed statement (sum Y1_i, sum Y2_i,
+// sum A_i) with one proof (c,r1,r2) that VerifyNoB accepts: each party publishes
+// its Commitment, the challenge is computed over the summed commitments with
+// ChallengeNoB, each party Responds to it, and the responses are added mod N.
+// A Prover responds only once, since two responses with one commitment reveal the
+// witnesses.
+// 联合证明参与方：多个参与方分别持有满足
+//     {Y1_i=y1_i*B,Y2_i=y2_i*B,A1*y1_i+A2*y2_i=A_i}
+// （A1、A2相同）的证据(y1_i,y2_i)，可为求和后的公开点(sum Y1_i, sum Y2_i, sum A_i)联合生成
+// 一个VerifyNoB可验证的证明(c,r1,r2)：各方公布承诺值Commitment，以ChallengeNoB对承诺值之和
+// 计算挑战值，各方对其Respond，应答模N相加即得。同一承诺值下的两个应答会泄露证据，
+// 故Prover只应答一次。
+type Prover struct {
+	y1, y2 *big.Int
+	v1, v2 *big.Int
+	n      *big.Int
+	T      Commitment
+}
+
+// NewProverNoB draws the nonces of a party holding (y1,y2) and computes its
+// commitment T1=v1*B, T2=v2*B, T3=v1*A1+v2*A2 with DefaultSuite.
+// 创建联合证明参与方：使用默认参数组DefaultSuite。
+func NewProverNoB(y1, y2 *big.Int, A1, A2 *elgamal.CurvePoint) (*Prover, error) {
+	return DefaultSuite.NewProverNoB(y1, y2, A1, A2)
+}
+
+// NewProverNoB draws the nonces of a party holding (y1,y2) and computes its
+// commitment T1=v1*B, T2=v2*B, T3=v1*A1+v2*A2, with the backend of s.
+// 创建联合证明参与方：为持有(y1,y2)的参与方生成随机数，并以s的算术后端计算承诺值
+// T1=v1*B, T2=v2*B, T3=v1*A1+v2*A2。
+//
+// 参数：
+//		标量：	y1,y2
+//		点：A1,A2
+// 返回：
+// 		参与方
+func (s Suite) NewProverNoB(y1, y2 *big.Int, A1, A2 *elgamal.CurvePoint) (*Prover, error) {
+	for _, P := range []*elgamal.CurvePoint{A1, A2} {
+		if err := elgamal.CheckPoint(P); err != nil {
+			return nil, opError("NewProverNoB", err)
+		}
+	}
+	curve := A1.Curve
+	v1, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, opError("NewProverNoB", err)
+	}
+	v2, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, opError("NewProverNoB", err)
+	}
+	impl, err := s.backend("NewProverNoB", curve)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Prover{y1: y1, y2: y2, v1: v1, v2: v2, n: curve.Params().N}
+	B := elgamal.Generator(curve)
+	p.T.T1.Curve = curve
+	p.T.T1.X, p.T.T1.Y = scalarMult(impl, B, v1.Bytes())
+	p.T.T2.Curve = curve
+	p.T.T2.X, p.T.T2.Y = scalarMult(impl, B, v2.Bytes())
+	p.T.T3.Curve = curve
+	vA1x, vA1y := scalarMult(impl, A1, v1.Bytes())
+	vA2x, vA2y := scalarMult(impl, A2, v2.Bytes())
+	p.T.T3.X, p.T.T3.Y = add(impl, curve, vA1x, vA1y, vA2x, vA2y)
+	return p, nil
+}
+
+// Commitment returns the commitment of p, to be published before the challenge.
+// 返回参与方的承诺值，须在计算挑战值之前公布。
+func (p *Prover) Commitment() *Commitment {
+	T := p.T
+	return &T
+}
+
+// Respond returns the responses r1=v1-c*y1, r2=v2-c*y2 of p to the challenge c and
+// forgets the nonces; a second call fails with ErrNonceReused.
+// 应答：返回参与方对挑战值c的应答r1=v1-c*y1, r2=v2-c*y2，并丢弃随机数；再次调用返回ErrNonceReused。
+//
+// 参数：
+//		挑战值：	c
+// 返回：
+// 		应答：	r1,r2
+func (p *Prover) Respond(c *big.Int) (*big.Int, *big.Int, error) {
+	if p.v1 == nil {
+		return nil, nil, opError("Prover.Respond", ErrNonceReused)
+	}
+	r1 := new(big.Int).Mul(c, p.y1)
+	r1.Sub(p.v1, r1)
+	r1.Mod(r1, p.n)
+	r2 := new(big.Int).Mul(c, p.y2)
+	r2.Sub(p.v2, r2)
+	r2.Mod(r2, p.n)
+
+	p.v1, p.v2 = nil, nil
+	return r1, r2, nil
+}
+
+// AddCommitments returns the sum of the commitments Ts.
+// 承诺值求和：返回Ts中承诺值的逐点之和。
+//
+// 参数：
+//		承诺值slice：	Ts
+// 返回：
+// 		承诺值之和
+func AddCommitments(Ts []*Commitment) (*Commitment, error) {
+	if len(Ts) == 0 {
+		return nil, opError("AddCommitments", elgamal.ErrEmpty)
+	}
+	for i, T := range Ts {
+		if T == nil {
+			return nil, itemError("AddCommitments", "commitment", i, elgamal.ErrEmpty)
+		}
+		for _, P := range []*elgamal.CurvePoint{&T.T1, &T.T2, &T.T3} {
+			if err := elgamal.CheckPoint(P); err != nil {
+				return nil, itemError("AddCommitments", "commitment", i, err)
+			}
+		}
+	}
+
+	sum := *Ts[0]
+	curve := sum.T1.Curve
+	for _, T := range Ts[1:] {
+		sum.T1.X, sum.T1.Y = ec.Add(curve, sum.T1.X, sum.T1.Y, T.T1.X, T.T1.Y)
+		sum.T2.X, sum.T2.Y = ec.Add(curve, sum.T2.X, sum.T2.Y, T.T2.X, T.T2.Y)
+		sum.T3.X, sum.T3.Y = ec.Add(curve, sum.T3.X, sum.T3.Y, T.T3.X, T.T3.Y)
+	}
+	return &sum, nil
+}
+
+// ChallengeNoB returns the challenge c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3) of a two-witness
+// proof with commitment T, using the challenge hash of s, as GenNoB computes it.
+// 计算挑战值：以s的挑战哈希，按GenNoB的方式计算承诺值为T的证明的挑战值
+// c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3)。
+//
+// 参数：
+//		点：Y1,Y2,A1,A2,A
+//		承诺值：	T
+// 返回：
+// 		挑战值：	c
+func (s Suite) ChallengeNoB(Y1, Y2, A1, A2, A *elgamal.CurvePoint, T *Commitment) (*big.Int, error) {
+	t, err := s.newTranscript("ChallengeNoB", twoWitnessProtocol)
+	if err != nil {
+		return nil, err
+	}
+	if T == nil {
+		return nil, opError("ChallengeNoB", elgamal.ErrEmpty)
+	}
+	for _, P := range []*elgamal.CurvePoint{Y1, Y2, A1, A2, A} {
+		if err := elgamal.CheckPoint(P); err != nil {
+			return nil, opError("ChallengeNoB", err)
+		}
+	}
+	B := elgamal.Generator(Y1.Curve)
+	return twoWitnessChallenge(t, B, Y1, Y2, A1, A2, A, &T.T1, &T.T2, &T.T3), nil
+}
+
+// ChallengeNoB returns the challenge of a two-witness proof with DefaultSuite.
+// 计算挑战值：使用默认参数组DefaultSuite。
+func ChallengeNoB(Y1, Y2, A1, A2, A *elgamal.CurvePoint, T *Commitment) (*big.Int, error) {
+	return DefaultSuite.ChallengeNoB(Y1, Y2, A1, A2, A, T)
+}
+
+// CheckResponseNoB reports whether the responses (r1,r2) of one party to the
+// challenge c match its commitment T and its statement (Y1,Y2,A1,A2,A), i.e.
+//     r1*B+c*Y1=T1, r2*B+c*Y2=T2, r1*A1+r2*A2+c*A=T3,
+// so the party that spoils a joint proof can be identified.
+// 检查应答：判断参与方对挑战值c的应答(r1,r2)是否与其承诺值T及公开点(Y1,Y2,A1,A2,A)相符，即
+//     r1*B+c*Y1=T1, r2*B+c*Y2=T2, r1*A1+r2*A2+c*A=T3，
+// 据此识别破坏联合证明的参与方。
+//
+// 参数：
+//		挑战值：	c
+//		应答：	r1,r2
+//		点：Y1,Y2,A1,A2,A
+//		承诺值：	T
+// 返回：
+// 		检查结果
+func CheckResponseNoB(c, r1, r2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint, T *Commitment) bool {
+	if c == nil || r1 == nil || r2 == nil || T == nil {
+		return false
+	}
+	for _, P := range []*elgamal.CurvePoint{Y1, Y2, A1, A2, A, &T.T1, &T.T2, &T.T3} {
+		if elgamal.CheckPoint(P) != nil {
+			return false
+		}
+	}
+
+	// 重构承诺：T1'=r1*B+c*Y1, T2'=r2*B+c*Y2, T3'=r1*A1+r2*A2+c*A
+	curve := Y1.Curve
+	B := elgamal.Generator(curve)
+	T1 := combine(curve, []*elgamal.CurvePoint{B, Y1}, r1, c)
+	T2 := combine(curve, []*elgamal.CurvePoint{B, Y2}, r2, c)
+	T3 := combine(curve, []*elgamal.CurvePoint{A1, A2, A}, r1, r2, c)
+
+	return T1.X.Cmp(T.T1.X) == 0 && T1.Y.Cmp(T.T1.Y) == 0 &&
+		T2.X.Cmp(T.T2.X) == 0 && T2.Y.Cmp(T.T2.Y) == 0 &&
+		T3.X.Cmp(T.T3.X) == 0 && T3.Y.Cmp(T.T3.Y) == 0
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestProverJoint(t *testing.T) {
+	// 两个参与方，A1、A2相同
+	y1a, y2a, Y1a, Y2a, A1, A2, Aa := statement(t)
+	y1b, y2b, Y1b, Y2b, _, _, _ := statement(t)
+	curve := A1.Curve
+	Ab := new(elgamal.CurvePoint)
+	Ab.Curve = curve
+	x1, y1 := curve.ScalarMult(A1.X, A1.Y, y1b.Bytes())
+	x2, y2 := curve.ScalarMult(A2.X, A2.Y, y2b.Bytes())
+	Ab.X, Ab.Y = curve.Add(x1, y1, x2, y2)
+
+	pa, err := NewProverNoB(y1a, y2a, A1, A2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb, err := NewProverNoB(y1b, y2b, A1, A2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T, err := AddCommitments([]*Commitment{pa.Commitment(), pb.Commitment()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := func(P, Q *elgamal.CurvePoint) *elgamal.CurvePoint {
+		R := &elgamal.CurvePoint{Curve: curve}
+		R.X, R.Y = curve.Add(P.X, P.Y, Q.X, Q.Y)
+		return R
+	}
+	Y1, Y2, A := sum(Y1a, Y1b), sum(Y2a, Y2b), sum(Aa, Ab)
+	c, err := ChallengeNoB(Y1, Y2, A1, A2, A, T)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r1a, r2a, err := pa.Respond(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1b, r2b, err := pb.Respond(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !CheckResponseNoB(c, r1a, r2a, Y1a, Y2a, A1, A2, Aa, pa.Commitment()) {
+		t.Fatal("honest response rejected")
+	}
+	if CheckResponseNoB(c, r1b, r2b, Y1a, Y2a, A1, A2, Aa, pa.Commitment()) {
+		t.Fatal("response of another party accepted")
+	}
+	if _, _, err := pa.Respond(c); !errors.Is(err, ErrNonceReused) {
+		t.Fatalf("got %v, want ErrNonceReused", err)
+	}
+
+	// 应答之和即为求和公开点的证明
+	N := curve.Params().N
+	r1 := new(big.Int).Add(r1a, r1b)
+	r1.Mod(r1, N)
+	r2 := new(big.Int).Add(r2a, r2b)
+	r2.Mod(r2, N)
+	flag, err := VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag {
+		t.Fatal("joint proof failed to verify")
+	}
+	if flag, err = VerifyNoB(c, r1a, r2a, Y1, Y2, A1, A2, A); err != nil || flag {
+		t.Fatal("partial response verified as a joint proof")
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// batchCoeffBits is the size of the random coefficients of BatchVerifyNoB: a batch
+// containing an invalid proof passes with probability about 2^-128.
+// 批量验证随机系数的比特长度：含无效证明的批次通过验证的概率约为2^-128。
+const batchCoeffBits = 128
+
+// Commitment is the commitment (T1,T2,T3) of a two-witness proof. The challenge c
+// commits to it, so a verifier holding T can check all equations of many proofs
+// in one multi-scalar multiplication instead of recomputing T for each proof.
+// 承诺：双证据证明的承诺值(T1,T2,T3)。挑战值c由其计算得到，持有T的验证者可将多个证明的
+// 验证方程合并为一次多标量乘法，而无需逐个重构T。
+type Commitment struct {
+	T1, T2, T3 elgamal.CurvePoint
+}
+
+// BatchItem is one proof (c,r1,r2) with its commitment T and its statement
+// {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}, for BatchVerifyNoB.
+// 批量验证项：一个证明(c,r1,r2)及其承诺T与公开点，用于BatchVerifyNoB。
+type BatchItem struct {
+	C, R1, R2         *big.Int
+	T                 *Commitment
+	Y1, Y2, A1, A2, A *elgamal.CurvePoint
+}
+
+// BatchVerifyNoB verifies the proofs of items at once, using the challenge hash of s.
+// Each challenge is checked against its commitment, then the equations
+//     r1*B+c*Y1=T1, r2*B+c*Y2=T2, r1*A1+r2*A2+c*A=T3
+// of all items are combined with random coefficients into one multi-scalar
+// multiplication, where bases shared by several items (B, and for key switching the
+// public keys) are multiplied once. It reports true only if every proof is valid,
+// but does not tell which one failed; verify the items one by one to find out.
+// 批量零知识证明验证：一次验证items中的全部证明。先检查各挑战值与承诺一致，再以随机系数将所有
+// 验证方程
+//     r1*B+c*Y1=T1, r2*B+c*Y2=T2, r1*A1+r2*A2+c*A=T3
+// 合并为一次多标量乘法，多个证明共用的基点（B，以及密钥置换中的公钥）只计算一次。
+// 全部证明有效时返回true，但不指出失败的证明，需逐个验证以定位。
+//
+// 参数：
+//		验证项：	items
+// 返回：
+// 		验证结果
+func (s Suite) BatchVerifyNoB(items []BatchItem) (bool, error) {
+	if len(items) == 0 {
+		return false, opError("BatchVerifyNoB", elgamal.ErrEmpty)
+	}
+	if _, err := s.newTranscript("BatchVerifyNoB", twoWitnessProtocol); err != nil {
+		return false, err
+	}
+	if items[0].Y1 == nil {
+		return false, nil
+	}
+	curve := items[0].Y1.Curve
+	ctx := ec.NewContext(curve)
+	B := elgamal.Generator(curve)
+
+	acc := newMultiScalar(ctx.N)
+	for i := range items {
+		it := &items[i]
+		if it.C == nil || it.R1 == nil || it.R2 == nil || it.T == nil {
+			return false, nil
+		}
+		points := []*elgamal.CurvePoint{it.Y1, it.Y2, it.A1, it.A2, it.A, &it.T.T1, &it.T.T2, &it.T.T3}
+		for _, P := range points {
+			if P == nil || P.Curve == nil || P.X == nil || P.Y == nil {
+				return false, nil
+			}
+			if !ctx.Has(P.Curve) {
+				return false, opError("BatchVerifyNoB", elgamal.ErrCurveMismatch)
+			}
+			if !ctx.IsValidXY(P.X, P.Y) {
+				return false, nil
+			}
+		}
+
+		// 挑战值须由承诺计算得到：c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3)
+		t, _ := s.newTranscript("BatchVerifyNoB", twoWitnessProtocol)
+		if it.C.Cmp(twoWitnessChallenge(t, B, it.Y1, it.Y2, it.A1, it.A2, it.A, &it.T.T1, &it.T.T2, &it.T.T3)) != 0 {
+			return false, nil
+		}
+
+		// 随机系数rho,sigma,tau分别作用于三个方程
+		rho, err := randCoeff()
+		if err != nil {
+			return false, opError("BatchVerifyNoB", err)
+		}
+		sigma, err := randCoeff()
+		if err != nil {
+			return false, opError("BatchVerifyNoB", err)
+		}
+		tau, err := randCoeff()
+		if err != nil {
+			return false, opError("BatchVerifyNoB", err)
+		}
+
+		// rho*(r1*B+c*Y1-T1) + sigma*(r2*B+c*Y2-T2) + tau*(r1*A1+r2*A2+c*A-T3)
+		acc.addBase(new(big.Int).Add(mul(rho, it.R1), mul(sigma, it.R2)))
+		acc.add(it.Y1, mul(rho, it.C))
+		acc.add(&it.T.T1, new(big.Int).Neg(rho))
+		acc.add(it.Y2, mul(sigma, it.C))
+		acc.add(&it.T.T2, new(big.Int).Neg(sigma))
+		acc.add(it.A1, mul(tau, it.R1))
+		acc.add(it.A2, mul(tau, it.R2))
+		acc.add(it.A, mul(tau, it.C))
+		acc.add(&it.T.T3, new(big.Int).Neg(tau))
+	}
+
+	x, y := acc.sum(ctx)
+	return x.Sign() == 0 && y.Sign() == 0, nil
+}
+
+// BatchVerifyNoB verifies the proofs of items at once with DefaultSuite.
+// 批量零知识证明验证：使用默认参数组DefaultSuite。
+func BatchVerifyNoB(items []BatchItem) (bool, error) {
+	return DefaultSuite.BatchVerifyNoB(items)
+}
+
+// coeffBound is 2^batchCoeffBits, the exclusive bound of the coefficients.
+// 系数的上界（不含）2^batchCoeffBits。
+var coeffBound = new(big.Int).Lsh(big.NewInt(1), batchCoeffBits)
+
+// randCoeff returns a uniformly random batchCoeffBits-bit coefficient.
+// 返回batchCoeffBits比特的均匀随机系数。
+func randCoeff() (*big.Int, error) {
+	return rand.Int(rand.Reader, coeffBound)
+}
+
+// mul returns a*b.
+// 返回a*b。
+func mul(a, b *big.Int) *big.Int {
+	return new(big.Int).Mul(a, b)
+}
+
+// multiScalar accumulates sum(k_i*P_i), merging the scalars of equal points.
+// 多标量乘法累加器：累加sum(k_i*P_i)，相同的点合并标量。
+type multiScalar struct {
+	n     *big.Int
+	base  *big.Int
+	keys  []string
+	terms map[string]*msmTerm
+}
+
+// msmTerm is one term k*P of a multiScalar.
+// 多标量乘法中的一项k*P。
+type msmTerm struct {
+	P *elgamal.CurvePoint
+	k *big.Int
+}
+
+// newMultiScalar returns an empty accumulator with scalars reduced mod n.
+// 返回空累加器，标量模n约化。
+func newMultiScalar(n *big.Int) *multiScalar {
+	return &multiScalar{n: n, base: new(big.Int), terms: make(map[string]*msmTerm)}
+}
+
+// addBase adds k*B, B being the base point of the curve.
+// 累加k*B，B为曲线基点。
+func (m *multiScalar) addBase(k *big.Int) {
+	m.base.Add(m.base, k)
+	m.base.Mod(m.base, m.n)
+}
+
+// add adds k*P.
+// 累加k*P。
+func (m *multiScalar) add(P *elgamal.CurvePoint, k *big.Int) {
+	key := string(P.Bytes())
+	t, ok := m.terms[key]
+	if !ok {
+		t = &msmTerm{P: P, k: new(big.Int)}
+		m.terms[key] = t
+		m.keys = append(m.keys, key)
+	}
+	t.k.Add(t.k, k)
+	t.k.Mod(t.k, m.n)
+}
+
+// sum returns the accumulated point on the curve of ctx, (0,0) being the point at
+// infinity, in one multi-scalar multiplication.
+// 以一次多标量乘法返回ctx曲线上的累加结果，(0,0)表示无穷远点。
+func (m *multiScalar) sum(ctx *ec.Context) (*big.Int, *big.Int) {
+	xs := make([]*big.Int, 0, len(m.keys)+1)
+	ys := make([]*big.Int, 0, len(m.keys)+1)
+	ks := make([]*big.Int, 0, len(m.keys)+1)
+	if m.base.Sign() != 0 {
+		xs, ys, ks = append(xs, ctx.Gx), append(ys, ctx.Gy), append(ks, m.base)
+	}
+	for _, key := range m.keys {
+		t := m.terms[key]
+		if t.k.Sign() == 0 {
+			continue
+		}
+		xs, ys, ks = append(xs, t.P.X), append(ys, t.P.Y), append(ks, t.k)
+	}
+	return ec.MultiScalarMult(ctx.Curve, xs, ys, ks)
+}
+
+// combine returns sum(ks[i]*points[i]) with the scalars interleaved (Shamir's
+// trick), for the two- and three-term verification equations of a single proof.
+// 以交错标量（Shamir技巧）返回sum(ks[i]*points[i])，用于单个证明中两三项的验证方程。
+func combine(curve elliptic.Curve, points []*elgamal.CurvePoint, ks ...*big.Int) elgamal.CurvePoint {
+	xs := make([]*big.Int, len(points))
+	ys := make([]*big.Int, len(points))
+	for i, P := range points {
+		xs[i], ys[i] = P.X, P.Y
+	}
+	x, y := ec.ShamirMult(curve, xs, ys, ks)
+	return elgamal.CurvePoint{Curve: curve, X: x, Y: y}
+}
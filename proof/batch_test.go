@@ -0,0 +1,118 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+// batchItem 生成一个有效的批量验证项。
+func batchItem(t testing.TB) BatchItem {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+	c, r1, r2, T, err := GenNoBWithCommitment(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return BatchItem{C: c, R1: r1, R2: r2, T: T, Y1: Y1, Y2: Y2, A1: A1, A2: A2, A: A}
+}
+
+func TestBatchVerifyNoB(t *testing.T) {
+	items := make([]BatchItem, 4)
+	for i := range items {
+		items[i] = batchItem(t)
+		// 带承诺生成的证明同样可单独验证
+		flag, err := VerifyNoB(items[i].C, items[i].R1, items[i].R2, items[i].Y1, items[i].Y2, items[i].A1, items[i].A2, items[i].A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !flag {
+			t.Fatal("proof with commitment failed to verify")
+		}
+	}
+
+	flag, err := BatchVerifyNoB(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag {
+		t.Fatal("honest batch failed to verify")
+	}
+
+	// 篡改应答
+	bad := append([]BatchItem(nil), items...)
+	bad[2].R1 = new(big.Int).Add(bad[2].R1, big.NewInt(1))
+	if flag, err = BatchVerifyNoB(bad); err != nil || flag {
+		t.Fatal("batch with a forged response verified")
+	}
+
+	// 篡改公开点
+	bad = append([]BatchItem(nil), items...)
+	bad[1].A = elgamal.GenPoint()
+	if flag, err = BatchVerifyNoB(bad); err != nil || flag {
+		t.Fatal("batch with a different statement verified")
+	}
+
+	// 替换承诺后挑战值不再一致
+	bad = append([]BatchItem(nil), items...)
+	bad[0].T = items[3].T
+	if flag, err = BatchVerifyNoB(bad); err != nil || flag {
+		t.Fatal("batch with a foreign commitment verified")
+	}
+
+	// 缺少承诺
+	bad = append([]BatchItem(nil), items...)
+	bad[3].T = nil
+	if flag, err = BatchVerifyNoB(bad); err != nil || flag {
+		t.Fatal("batch without a commitment verified")
+	}
+
+	if _, err := BatchVerifyNoB(nil); err == nil {
+		t.Fatal("expected error for an empty batch")
+	}
+}
+
+func TestBatchVerifyNoBSuite(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+	s := Suite{Hash: HashSHA256}
+	c, r1, r2, T, err := s.GenNoBWithCommitment(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []BatchItem{{C: c, R1: r1, R2: r2, T: T, Y1: Y1, Y2: Y2, A1: A1, A2: A2, A: A}}
+	if flag, err := s.BatchVerifyNoB(items); err != nil || !flag {
+		t.Fatal("SHA-256 batch failed to verify")
+	}
+	if flag, err := BatchVerifyNoB(items); err != nil || flag {
+		t.Fatal("SHA-256 proof verified under the default suite")
+	}
+}
+
+func BenchmarkBatchVerifyNoB(b *testing.B) {
+	items := make([]BatchItem, 16)
+	for i := range items {
+		items[i] = batchItem(b)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := BatchVerifyNoB(items); err != nil || !ok {
+			b.Fatal("honest batch failed to verify")
+		}
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// Term is one term x*Base of a constraint, x being the witness named Witness.
+// 约束中的一项x*Base，x为名为Witness的证据。
+type Term struct {
+	Witness string
+	Base    *elgamal.CurvePoint
+}
+
+// Builder composes linear constraints over named witnesses into one Relation,
+// proven with a single challenge (AND composition). A protocol needing a new
+// statement adds one Constrain call per equation instead of writing another
+// proof generator and verifier, e.g. the two-witness proof of ppks is
+//     b.Constrain(Y1, Term{"y1", B})
+//     b.Constrain(Y2, Term{"y2", B})
+//     b.Constrain(A, Term{"y1", A1}, Term{"y2", A2})
+// Witnesses are numbered in order of first use. The zero Suite is DefaultSuite.
+// 证明构造器：将关于具名证据的多个线性约束组合为一个关系Relation，以单个挑战值证明（AND组合）。
+// 需要新声明的协议只需为每个方程调用一次Constrain，而无需另写证明生成与验证函数，如ppks的双证据证明即
+//     b.Constrain(Y1, Term{"y1", B})
+//     b.Constrain(Y2, Term{"y2", B})
+//     b.Constrain(A, Term{"y1", A1}, Term{"y2", A2})
+// 证据按首次出现的顺序编号。Suite零值即DefaultSuite。
+type Builder struct {
+	Suite Suite
+
+	names   []string
+	index   map[string]int
+	rows    []map[int]*elgamal.CurvePoint
+	targets []*elgamal.CurvePoint
+	err     error
+}
+
+// NewBuilder returns an empty Builder using DefaultSuite.
+// 创建空的证明构造器，使用默认参数组DefaultSuite。
+func NewBuilder() *Builder {
+	return &Builder{Suite: DefaultSuite}
+}
+
+// Constrain adds the equation Y = sum of the terms and returns b, so calls can be
+// chained. A witness occurring twice in one equation has its bases added. Invalid
+// points are reported by Relation, Prove and Verify.
+// 添加约束：添加方程Y = 各项之和并返回b，以便链式调用。同一证据在一个方程中出现两次时，基点相加。
+// 无效的点由Relation、Prove与Verify报告。
+//
+// 参数：
+//		点：	Y
+//		各项：	terms
+// 返回：
+// 		构造器
+func (b *Builder) Constrain(Y *elgamal.CurvePoint, terms ...Term) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if b.index == nil {
+		b.index = make(map[string]int)
+	}
+	if len(terms) == 0 {
+		b.err = itemError("Builder.Constrain", "constraint", len(b.rows), elgamal.ErrEmpty)
+		return b
+	}
+
+	row := make(map[int]*elgamal.CurvePoint)
+	for _, t := range terms {
+		if err := elgamal.CheckPoint(t.Base); err != nil {
+			b.err = itemError("Builder.Constrain", "constraint", len(b.rows), err)
+			return b
+		}
+		i, ok := b.index[t.Witness]
+		if !ok {
+			i = len(b.names)
+			b.index[t.Witness] = i
+			b.names = append(b.names, t.Witness)
+		}
+		if G, ok := row[i]; ok {
+			sum := &elgamal.CurvePoint{Curve: G.Curve}
+			sum.X, sum.Y = ec.Add(G.Curve, G.X, G.Y, t.Base.X, t.Base.Y)
+			row[i] = sum
+		} else {
+			row[i] = t.Base
+		}
+	}
+	b.rows = append(b.rows, row)
+	b.targets = append(b.targets, Y)
+	return b
+}
+
+// Witnesses returns the names of the witnesses in the order of the responses of
+// the proof.
+// 返回证据名称，顺序与证明中的应答一致。
+func (b *Builder) Witnesses() []string {
+	return append([]string(nil), b.names...)
+}
+
+// Relation returns the relation built so far.
+// 返回目前构造的关系。
+//
+// 参数：
+//
+// 返回：
+// 		关系
+func (b *Builder) Relation() (*Relation, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	rel := &Relation{Targets: append([]*elgamal.CurvePoint(nil), b.targets...)}
+	for _, row := range b.rows {
+		bases := make([]*elgamal.CurvePoint, len(b.names))
+		for i, G := range row {
+			bases[i] = G
+		}
+		rel.Bases = append(rel.Bases, bases)
+	}
+	if _, err := rel.check("Builder.Relation", len(b.names)); err != nil {
+		return nil, err
+	}
+	return rel, nil
+}
+
+// Prove proves the constraints of b with the witness values, bound to context as
+// in ProveLinear.
+// 证明生成：以证据取值values证明b中的全部约束，context的作用与ProveLinear相同。
+//
+// 参数：
+//		上下文：	context
+//		证据取值：	values
+// 返回：
+// 		证明
+func (b *Builder) Prove(context string, values map[string]*big.Int) (*LinearProof, error) {
+	rel, err := b.Relation()
+	if err != nil {
+		return nil, err
+	}
+	x := make([]*big.Int, len(b.names))
+	for i, name := range b.names {
+		if x[i] = values[name]; x[i] == nil {
+			return nil, itemError("Builder.Prove", "witness", i, elgamal.ErrEmpty)
+		}
+	}
+	return b.Suite.ProveLinear(context, rel, x)
+}
+
+// Verify verifies the proof p of the constraints of b made with the same context.
+// 证明验证：验证以相同context生成的、b中全部约束的证明p。
+//
+// 参数：
+//		上下文：	context
+//		证明：		p
+// 返回：
+// 		验证结果
+func (b *Builder) Verify(context string, p *LinearProof) (bool, error) {
+	rel, err := b.Relation()
+	if err != nil {
+		return false, err
+	}
+	if p == nil || len(p.R) != len(b.names) {
+		return false, nil
+	}
+	return b.Suite.VerifyLinear(context, rel, p)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestBuilderTwoWitness(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+	B := elgamal.Generator(Y1.Curve)
+	build := func(A *elgamal.CurvePoint) *Builder {
+		return NewBuilder().
+			Constrain(Y1, Term{"y1", B}).
+			Constrain(Y2, Term{"y2", B}).
+			Constrain(A, Term{"y1", A1}, Term{"y2", A2})
+	}
+
+	b := build(A)
+	if !reflect.DeepEqual(b.Witnesses(), []string{"y1", "y2"}) {
+		t.Fatalf("got witnesses %v", b.Witnesses())
+	}
+	p, err := b.Prove("ks", map[string]*big.Int{"y1": y1, "y2": y2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Verify("ks", p); err != nil || !ok {
+		t.Fatal("honest proof failed to verify")
+	}
+	if ok, err := build(elgamal.GenPoint()).Verify("ks", p); err != nil || ok {
+		t.Fatal("proof verified against a different statement")
+	}
+
+	// 与直接使用ProveLinear构造的关系一致
+	rel, err := b.Relation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyLinear("ks", rel, p); err != nil || !ok {
+		t.Fatal("builder relation differs from the proven one")
+	}
+
+	// 缺少证据取值
+	if _, err := b.Prove("ks", map[string]*big.Int{"y1": y1}); err == nil {
+		t.Fatal("expected error for a missing witness")
+	}
+	// 错误的证据取值
+	bad, err := b.Prove("ks", map[string]*big.Int{"y1": y2, "y2": y1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Verify("ks", bad); err != nil || ok {
+		t.Fatal("proof with wrong witnesses verified")
+	}
+}
+
+func TestBuilderRepeatedWitness(t *testing.T) {
+	// Y=x*G1+x*G2 等价于 Y=x*(G1+G2)
+	x, _, _, _, G1, G2, _ := statement(t)
+	curve := G1.Curve
+	Y := &elgamal.CurvePoint{Curve: curve}
+	ax, ay := curve.ScalarMult(G1.X, G1.Y, x.Bytes())
+	bx, by := curve.ScalarMult(G2.X, G2.Y, x.Bytes())
+	Y.X, Y.Y = curve.Add(ax, ay, bx, by)
+
+	b := &Builder{Suite: Suite{Hash: HashSHA256}}
+	b.Constrain(Y, Term{"x", G1}, Term{"x", G2})
+	p, err := b.Prove("rep", map[string]*big.Int{"x": x})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Verify("rep", p); err != nil || !ok {
+		t.Fatal("repeated witness proof failed to verify")
+	}
+}
+
+func TestBuilderInvalid(t *testing.T) {
+	Y := elgamal.GenPoint()
+	b := NewBuilder().Constrain(Y).Constrain(Y, Term{"x", Y})
+	if _, err := b.Relation(); err == nil {
+		t.Fatal("expected error for an empty constraint")
+	}
+	b = NewBuilder().Constrain(Y, Term{"x", elgamal.Infinity(Y.Curve)})
+	if _, err := b.Prove("x", map[string]*big.Int{"x": big.NewInt(1)}); err == nil {
+		t.Fatal("expected error for an invalid base")
+	}
+	if _, err := NewBuilder().Relation(); err == nil {
+		t.Fatal("expected error for no constraints")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// dleqProtocol names the transcripts of DLEQ proofs, separating their challenges
+// from those of the two-witness proof.
+// DLEQ证明记录的协议名，与双证据证明的挑战值区分。
+const dleqProtocol = "ppks-dleq"
+
+// DLEQGen generates the Chaum-Pedersen proof (c,r) that log_B1(Y1) = log_B2(Y2) = x,
+// using the challenge hash of s.
+// 离散对数相等证明生成：为x生成满足约束{Y1=x*B1,Y2=x*B2}的Chaum-Pedersen证明(c,r)，并返回。
+//
+// 参数：
+//		标量：	x
+//		点：B1,Y1,B2,Y2
+// 返回：
+// 		证明:	c,r
+func (s Suite) DLEQGen(x *big.Int, B1, Y1, B2, Y2 *elgamal.CurvePoint) (*big.Int, *big.Int, error) {
+	t, err := s.newTranscript("DLEQGen", dleqProtocol)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, P := range []*elgamal.CurvePoint{B1, Y1, B2, Y2} {
+		if err := elgamal.CheckPoint(P); err != nil {
+			return nil, nil, opError("DLEQGen", err)
+		}
+	}
+
+	// 生成随机数v
+	curve := B1.Curve
+	v, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, opError("DLEQGen", err)
+	}
+	impl, err := s.backend("DLEQGen", curve)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 计算承诺值：T1=v*B1, T2=v*B2
+	T1x, T1y := scalarMult(impl, B1, v.Bytes())
+	T2x, T2y := scalarMult(impl, B2, v.Bytes())
+
+	// 计算挑战：c=H(B1,Y1,B2,Y2,T1,T2)
+	c := dleqChallenge(t, B1, Y1, B2, Y2, T1x, T1y, T2x, T2y)
+
+	// 计算应答：r=v-c*x
+	N := curve.Params().N
+	r := new(big.Int).Mul(c, x)
+	r.Mod(r, N)
+	r.Sub(v, r)
+	r.Mod(r, N)
+
+	return c, r, nil
+}
+
+// DLEQVerify verifies the proof (c,r) that log_B1(Y1) = log_B2(Y2), using the
+// challenge hash of s.
+// 离散对数相等证明验证：验证证明(c,r)是否能够证明公开点(B1,Y1,B2,Y2)满足约束{Y1=x*B1,Y2=x*B2}，并返回。
+//
+// 参数：
+//		证明：	c,r
+//		点：B1,Y1,B2,Y2
+// 返回：
+// 		验证结果
+func (s Suite) DLEQVerify(c, r *big.Int, B1, Y1, B2, Y2 *elgamal.CurvePoint) (bool, error) {
+	t, err := s.newTranscript("DLEQVerify", dleqProtocol)
+	if err != nil {
+		return false, err
+	}
+	for _, P := range []*elgamal.CurvePoint{B1, Y1, B2, Y2} {
+		if err := elgamal.CheckPoint(P); err != nil {
+			return false, opError("DLEQVerify", err)
+		}
+	}
+	if c == nil || r == nil {
+		return false, nil
+	}
+
+	// 重构承诺：T1'=r*B1+c*Y1, T2'=r*B2+c*Y2
+	curve := B1.Curve
+	T1 := combine(curve, []*elgamal.CurvePoint{B1, Y1}, r, c)
+	T2 := combine(curve, []*elgamal.CurvePoint{B2, Y2}, r, c)
+
+	// 检查一致性：c?=c'
+	return 0 == c.Cmp(dleqChallenge(t, B1, Y1, B2, Y2, T1.X, T1.Y, T2.X, T2.Y)), nil
+}
+
+// DLEQGen generates the DLEQ proof for x with DefaultSuite.
+// 离散对数相等证明生成：使用默认参数组DefaultSuite。
+func DLEQGen(x *big.Int, B1, Y1, B2, Y2 *elgamal.CurvePoint) (*big.Int, *big.Int, error) {
+	return DefaultSuite.DLEQGen(x, B1, Y1, B2, Y2)
+}
+
+// DLEQVerify verifies the DLEQ proof (c,r) with DefaultSuite.
+// 离散对数相等证明验证：使用默认参数组DefaultSuite。
+func DLEQVerify(c, r *big.Int, B1, Y1, B2, Y2 *elgamal.CurvePoint) (bool, error) {
+	return DefaultSuite.DLEQVerify(c, r, B1, Y1, B2, Y2)
+}
+
+// dleqChallenge appends the statement and commitment of a DLEQ proof to t and
+// returns the challenge H(B1,Y1,B2,Y2,T1,T2).
+// 将DLEQ证明的公开点与承诺值追加到记录t，返回挑战值H(B1,Y1,B2,Y2,T1,T2)。
+func dleqChallenge(t *Transcript, B1, Y1, B2, Y2 *elgamal.CurvePoint, T1x, T1y, T2x, T2y *big.Int) *big.Int {
+	t.AppendPoint("B1", B1)
+	t.AppendPoint("Y1", Y1)
+	t.AppendPoint("B2", B2)
+	t.AppendPoint("Y2", Y2)
+	curve := B1.Curve
+	t.AppendPoint("T1", &elgamal.CurvePoint{Curve: curve, X: T1x, Y: T1y})
+	t.AppendPoint("T2", &elgamal.CurvePoint{Curve: curve, X: T2x, Y: T2y})
+	return t.ChallengeScalar("c")
+}
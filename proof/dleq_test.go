@@ -0,0 +1,91 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestDLEQ(t *testing.T) {
+	k, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := k.D
+	B1 := elgamal.Generator(nil)
+	Y1 := (*elgamal.CurvePoint)(&k.PublicKey)
+	B2 := elgamal.GenPoint()
+	var Y2 elgamal.CurvePoint
+	Y2.Curve = B2.Curve
+	Y2.X, Y2.Y = B2.Curve.ScalarMult(B2.X, B2.Y, x.Bytes())
+
+	for _, s := range []Suite{DefaultSuite, {Hash: HashSHA256}} {
+		c, r, err := s.DLEQGen(x, B1, Y1, B2, &Y2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := s.DLEQVerify(c, r, B1, Y1, B2, &Y2); err != nil || !ok {
+			t.Fatalf("%v: valid proof rejected: %v", s.Hash, err)
+		}
+		// 交换两组点后挑战值不同
+		if ok, _ := s.DLEQVerify(c, r, B2, &Y2, B1, Y1); ok {
+			t.Fatalf("%v: proof accepted for swapped statement", s.Hash)
+		}
+	}
+
+	// 离散对数不相等时无法通过
+	c, r, err := DLEQGen(new(big.Int).Add(x, big.NewInt(1)), B1, Y1, B2, &Y2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := DLEQVerify(c, r, B1, Y1, B2, &Y2); ok {
+		t.Fatal("proof for unequal logarithms accepted")
+	}
+
+	if _, _, err := DLEQGen(x, B1, Y1, B2, elgamal.Infinity(nil)); err == nil {
+		t.Fatal("expected error for infinity")
+	}
+}
+
+func BenchmarkDLEQVerify(b *testing.B) {
+	k, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	B1 := elgamal.Generator(nil)
+	Y1 := (*elgamal.CurvePoint)(&k.PublicKey)
+	B2 := elgamal.GenPoint()
+	var Y2 elgamal.CurvePoint
+	Y2.Curve = B2.Curve
+	Y2.X, Y2.Y = B2.Curve.ScalarMult(B2.X, B2.Y, k.D.Bytes())
+	c, r, err := DLEQGen(k.D, B1, Y1, B2, &Y2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := DLEQVerify(c, r, B1, Y1, B2, &Y2); err != nil || !ok {
+			b.Fatal("honest proof failed to verify")
+		}
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"errors"
+
+	"ppks/elgamal"
+)
+
+var (
+	// ErrUnknownHash 未知的挑战哈希。
+	ErrUnknownHash = errors.New("unknown challenge hash")
+	// ErrNonceReused 联合证明参与方的随机数已用于应答。
+	ErrNonceReused = errors.New("prover nonce already used")
+	// ErrUnknownBackend 参数组所选的算术后端未为该曲线注册。
+	ErrUnknownBackend = elgamal.ErrUnknownBackend
+	// ErrInvalidBackend 曲线实现未通过算术后端的自检。
+	ErrInvalidBackend = elgamal.ErrInvalidBackend
+)
+
+// opError wraps err with the failing operation.
+// 以出错的操作包装err。
+func opError(op string, err error) error {
+	return &elgamal.Error{Op: op, Err: err}
+}
+
+// itemError wraps err with the failing operation and the index of the failing element.
+// 以出错的操作及出错元素的下标包装err。
+func itemError(op, item string, index int, err error) error {
+	return &elgamal.Error{Op: op, Item: item, Index: index, Err: err}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// linearProtocol names the transcripts of linear relation proofs.
+// 线性关系证明记录的协议名。
+const linearProtocol = "ppks-linear"
+
+// Relation is a statement of knowledge of witnesses x_0..x_{m-1} satisfying the
+// linear equations
+//     Targets[j] = sum_i x_i*Bases[j][i],   j = 0..n-1,
+// where a nil base means x_i does not occur in equation j. The Chaum-Pedersen
+// proof is the relation {Y=x*B, Z=x*A}, a Schnorr proof is {Y=x*B}, and the
+// two-witness proof of ppks is {Y1=y1*B, Y2=y2*B, A=y1*A1+y2*A2}.
+// 线性关系：声明知道满足线性方程组
+//     Targets[j] = sum_i x_i*Bases[j][i],   j = 0..n-1
+// 的证据x_0..x_{m-1}，基点为nil表示x_i不出现在方程j中。Chaum-Pedersen证明即关系{Y=x*B, Z=x*A}，
+// Schnorr证明即{Y=x*B}，ppks的双证据证明即{Y1=y1*B, Y2=y2*B, A=y1*A1+y2*A2}。
+type Relation struct {
+	Bases   [][]*elgamal.CurvePoint
+	Targets []*elgamal.CurvePoint
+}
+
+// DLogRelation returns the relation {Y=x*B}.
+// 返回关系{Y=x*B}。
+func DLogRelation(B, Y *elgamal.CurvePoint) *Relation {
+	return &Relation{
+		Bases:   [][]*elgamal.CurvePoint{{B}},
+		Targets: []*elgamal.CurvePoint{Y},
+	}
+}
+
+// DLEQRelation returns the relation {Y=x*B, Z=x*A}.
+// 返回关系{Y=x*B, Z=x*A}。
+func DLEQRelation(B, Y, A, Z *elgamal.CurvePoint) *Relation {
+	return &Relation{
+		Bases:   [][]*elgamal.CurvePoint{{B}, {A}},
+		Targets: []*elgamal.CurvePoint{Y, Z},
+	}
+}
+
+// LinearProof is a proof of a Relation: the challenge C and one response per witness.
+// 线性关系证明：挑战值C，以及每个证据对应的应答。
+type LinearProof struct {
+	C *big.Int
+	R []*big.Int
+}
+
+// check validates rel for m witnesses and returns its curve.
+// 校验关系rel（m个证据），返回其曲线。
+func (rel *Relation) check(op string, m int) (*elgamal.CurvePoint, error) {
+	n := len(rel.Targets)
+	if n == 0 || m == 0 {
+		return nil, opError(op, elgamal.ErrEmpty)
+	}
+	if len(rel.Bases) != n {
+		return nil, opError(op, elgamal.ErrLengthMismatch)
+	}
+	first := rel.Targets[0]
+	if err := elgamal.CheckPoint(first); err != nil {
+		return nil, itemError(op, "target", 0, err)
+	}
+	params := first.Curve.Params()
+	for j, row := range rel.Bases {
+		if len(row) != m {
+			return nil, itemError(op, "equation", j, elgamal.ErrLengthMismatch)
+		}
+		if err := elgamal.CheckPoint(rel.Targets[j]); err != nil {
+			return nil, itemError(op, "target", j, err)
+		}
+		if rel.Targets[j].Curve.Params() != params {
+			return nil, itemError(op, "target", j, elgamal.ErrCurveMismatch)
+		}
+		used := false
+		for _, G := range row {
+			if G == nil {
+				continue
+			}
+			if err := elgamal.CheckPoint(G); err != nil {
+				return nil, itemError(op, "equation", j, err)
+			}
+			if G.Curve.Params() != params {
+				return nil, itemError(op, "equation", j, elgamal.ErrCurveMismatch)
+			}
+			used = true
+		}
+		if !used {
+			return nil, itemError(op, "equation", j, elgamal.ErrEmpty)
+		}
+	}
+	return first, nil
+}
+
+// appendTo appends the statement of rel to t.
+// 将关系rel的公开信息追加到记录t。
+func (rel *Relation) appendTo(t *Transcript) {
+	t.AppendScalar("equations", big.NewInt(int64(len(rel.Targets))))
+	t.AppendScalar("witnesses", big.NewInt(int64(len(rel.Bases[0]))))
+	for j, row := range rel.Bases {
+		for _, G := range row {
+			if G == nil {
+				t.AppendMessage("G", nil)
+			} else {
+				t.AppendPoint("G", G)
+			}
+		}
+		t.AppendPoint("Y", rel.Targets[j])
+	}
+}
+
+// challenge appends rel and the commitments Ts to t and returns the challenge.
+// 将关系rel及承诺值Ts追加到记录t，返回挑战值。
+func (rel *Relation) challenge(t *Transcript, context string, Ts []*elgamal.CurvePoint) *big.Int {
+	t.AppendMessage("context", []byte(context))
+	rel.appendTo(t)
+	for _, T := range Ts {
+		t.AppendPoint("T", T)
+	}
+	return t.ChallengeScalar("c")
+}
+
+// eval returns the points sum_i r_i*G_ji + c*Y_j of every equation j; a nil c
+// leaves out the targets.
+// 对每个方程j计算sum_i r_i*G_ji + c*Y_j并返回；c为nil时不计入Y_j。
+func (rel *Relation) eval(curve elliptic.Curve, r []*big.Int, c *big.Int) []*elgamal.CurvePoint {
+	ctx := ec.NewContext(curve)
+	Ts := make([]*elgamal.CurvePoint, len(rel.Bases))
+	for j, row := range rel.Bases {
+		m := newMultiScalar(ctx.N)
+		for i, G := range row {
+			if G != nil {
+				m.add(G, r[i])
+			}
+		}
+		if c != nil {
+			m.add(rel.Targets[j], c)
+		}
+		Ts[j] = &elgamal.CurvePoint{Curve: curve}
+		Ts[j].X, Ts[j].Y = m.sum(ctx)
+	}
+	return Ts
+}
+
+// commit returns the commitments T_j = sum_i v_i*G_ji of the nonces v. With no
+// backend (impl nil) it is eval; otherwise every term is multiplied by impl.
+// 计算随机数v的承诺T_j = sum_i v_i*G_ji。未选择后端（impl为nil）时即eval，否则各项由impl计算。
+func (rel *Relation) commit(impl, curve elliptic.Curve, v []*big.Int) []*elgamal.CurvePoint {
+	if impl == nil {
+		return rel.eval(curve, v, nil)
+	}
+	Ts := make([]*elgamal.CurvePoint, len(rel.Bases))
+	for j, row := range rel.Bases {
+		Ts[j] = &elgamal.CurvePoint{Curve: curve}
+		for i, G := range row {
+			if G == nil {
+				continue
+			}
+			x, y := scalarMult(impl, G, v[i].Bytes())
+			if Ts[j].X == nil {
+				Ts[j].X, Ts[j].Y = x, y
+			} else {
+				Ts[j].X, Ts[j].Y = add(impl, curve, Ts[j].X, Ts[j].Y, x, y)
+			}
+		}
+	}
+	return Ts
+}
+
+// ProveLinear proves knowledge of the witnesses x of rel, using the challenge hash
+// of s. context is bound into the challenge, so a proof made for one protocol or
+// session does not verify in another.
+// 线性关系证明生成：以s的挑战哈希，证明知道满足关系rel的证据x。context参与挑战值计算，
+// 为某一协议或会话生成的证明在其他协议或会话中无法通过验证。
+//
+// 参数：
+//		上下文：	context
+//		关系：		rel
+//		证据：		x
+// 返回：
+// 		证明
+func (s Suite) ProveLinear(context string, rel *Relation, x []*big.Int) (*LinearProof, error) {
+	t, err := s.newTranscript("ProveLinear", linearProtocol)
+	if err != nil {
+		return nil, err
+	}
+	P, err := rel.check("ProveLinear", len(x))
+	if err != nil {
+		return nil, err
+	}
+	curve := P.Curve
+	N := curve.Params().N
+	impl, err := s.backend("ProveLinear", curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// 生成随机数v_i，计算承诺值：T_j=sum_i v_i*G_ji
+	v := make([]*big.Int, len(x))
+	for i := range v {
+		if v[i], err = ec.RandFieldElement(curve, rand.Reader); err != nil {
+			return nil, opError("ProveLinear", err)
+		}
+	}
+	Ts := rel.commit(impl, curve, v)
+
+	// 计算挑战与应答：r_i=v_i-c*x_i
+	c := rel.challenge(t, context, Ts)
+	r := make([]*big.Int, len(x))
+	for i := range x {
+		r[i] = new(big.Int).Mul(c, x[i])
+		r[i].Sub(v[i], r[i])
+		r[i].Mod(r[i], N)
+	}
+	return &LinearProof{C: c, R: r}, nil
+}
+
+// VerifyLinear verifies the proof p of rel made with the same context, using the
+// challenge hash of s.
+// 线性关系证明验证：以s的挑战哈希，验证以相同context生成的关系rel的证明p。
+//
+// 参数：
+//		上下文：	context
+//		关系：		rel
+//		证明：		p
+// 返回：
+// 		验证结果
+func (s Suite) VerifyLinear(context string, rel *Relation, p *LinearProof) (bool, error) {
+	t, err := s.newTranscript("VerifyLinear", linearProtocol)
+	if err != nil {
+		return false, err
+	}
+	if p == nil || p.C == nil {
+		return false, nil
+	}
+	for _, ri := range p.R {
+		if ri == nil {
+			return false, nil
+		}
+	}
+	P, err := rel.check("VerifyLinear", len(p.R))
+	if err != nil {
+		return false, err
+	}
+
+	// 重构承诺：T_j'=sum_i r_i*G_ji+c*Y_j
+	Ts := rel.eval(P.Curve, p.R, p.C)
+
+	// 检查一致性：c?=c'
+	return 0 == p.C.Cmp(rel.challenge(t, context, Ts)), nil
+}
+
+// ProveLinear proves knowledge of the witnesses of rel with DefaultSuite.
+// 线性关系证明生成：使用默认参数组DefaultSuite。
+func ProveLinear(context string, rel *Relation, x []*big.Int) (*LinearProof, error) {
+	return DefaultSuite.ProveLinear(context, rel, x)
+}
+
+// VerifyLinear verifies the proof p of rel with DefaultSuite.
+// 线性关系证明验证：使用默认参数组DefaultSuite。
+func VerifyLinear(context string, rel *Relation, p *LinearProof) (bool, error) {
+	return DefaultSuite.VerifyLinear(context, rel, p)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestLinearDLEQ(t *testing.T) {
+	x, _, Y, _, A, _, _ := statement(t)
+	B := elgamal.Generator(Y.Curve)
+	Z := &elgamal.CurvePoint{Curve: A.Curve}
+	Z.X, Z.Y = A.Curve.ScalarMult(A.X, A.Y, x.Bytes())
+
+	rel := DLEQRelation(B, Y, A, Z)
+	p, err := ProveLinear("test", rel, []*big.Int{x})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyLinear("test", rel, p); err != nil || !ok {
+		t.Fatal("honest DLEQ proof failed to verify")
+	}
+	if ok, err := VerifyLinear("other", rel, p); err != nil || ok {
+		t.Fatal("proof verified under another context")
+	}
+	if ok, err := VerifyLinear("test", DLEQRelation(B, Y, A, elgamal.GenPoint()), p); err != nil || ok {
+		t.Fatal("proof verified against a different statement")
+	}
+
+	// 单一方程即Schnorr证明
+	s, err := ProveLinear("test", DLogRelation(B, Y), []*big.Int{x})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyLinear("test", DLogRelation(B, Y), s); err != nil || !ok {
+		t.Fatal("honest Schnorr proof failed to verify")
+	}
+	if ok, err := VerifyLinear("test", rel, s); err == nil && ok {
+		t.Fatal("Schnorr proof verified as a DLEQ proof")
+	}
+
+	// 错误的证据得到无效证明
+	bad, err := ProveLinear("test", rel, []*big.Int{new(big.Int).Add(x, big.NewInt(1))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyLinear("test", rel, bad); err != nil || ok {
+		t.Fatal("proof with a wrong witness verified")
+	}
+}
+
+func TestLinearTwoWitness(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+	B := elgamal.Generator(Y1.Curve)
+	rel := &Relation{
+		Bases: [][]*elgamal.CurvePoint{
+			{B, nil},
+			{nil, B},
+			{A1, A2},
+		},
+		Targets: []*elgamal.CurvePoint{Y1, Y2, A},
+	}
+
+	p, err := Suite{Hash: HashSHA256}.ProveLinear("ks", rel, []*big.Int{y1, y2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := (Suite{Hash: HashSHA256}).VerifyLinear("ks", rel, p); err != nil || !ok {
+		t.Fatal("honest two-witness proof failed to verify")
+	}
+	if ok, err := VerifyLinear("ks", rel, p); err != nil || ok {
+		t.Fatal("SHA-256 proof verified under the default suite")
+	}
+
+	// 缺少应答
+	p.R = p.R[:1]
+	if _, err := VerifyLinear("ks", rel, p); !errors.Is(err, elgamal.ErrLengthMismatch) {
+		t.Fatalf("got %v, want ErrLengthMismatch", err)
+	}
+}
+
+func TestLinearInvalidRelation(t *testing.T) {
+	x, _, Y, _, _, _, _ := statement(t)
+	for name, rel := range map[string]*Relation{
+		"empty":      {},
+		"mismatch":   {Bases: [][]*elgamal.CurvePoint{{Y}, {Y}}, Targets: []*elgamal.CurvePoint{Y}},
+		"unused":     {Bases: [][]*elgamal.CurvePoint{{nil}}, Targets: []*elgamal.CurvePoint{Y}},
+		"infinity":   {Bases: [][]*elgamal.CurvePoint{{elgamal.Infinity(Y.Curve)}}, Targets: []*elgamal.CurvePoint{Y}},
+		"row length": {Bases: [][]*elgamal.CurvePoint{{Y, Y}}, Targets: []*elgamal.CurvePoint{Y}},
+	} {
+		if _, err := ProveLinear("test", rel, []*big.Int{x}); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// orProtocol names the transcripts of OR proofs.
+// OR证明记录的协议名。
+const orProtocol = "ppks-or"
+
+// OrProof is a proof that at least one of several relations holds, without
+// revealing which: one challenge and one response vector per branch, where the
+// challenges add up to the challenge of the transcript (Cramer-Damgard-Schoenmakers).
+// OR证明：证明若干关系中至少一个成立，且不泄露是哪一个。每个分支各有一个挑战值与一组应答，
+// 各分支挑战值之和等于记录导出的挑战值（Cramer-Damgard-Schoenmakers组合）。
+type OrProof struct {
+	C []*big.Int
+	R [][]*big.Int
+}
+
+// ProveOr proves that one of rels holds, knowing the witnesses x of rels[known],
+// using the challenge hash of s. The other branches are simulated, so the proof
+// does not reveal known. context is bound into the challenge as in ProveLinear.
+// OR证明生成：以s的挑战哈希，使用关系rels[known]的证据x，证明rels中至少一个关系成立。
+// 其余分支以模拟方式生成，证明不泄露known。context参与挑战值计算，与ProveLinear相同。
+//
+// 参数：
+//		上下文：	context
+//		关系：		rels
+//		已知分支：	known
+//		证据：		x
+// 返回：
+// 		证明
+func (s Suite) ProveOr(context string, rels []*Relation, known int, x []*big.Int) (*OrProof, error) {
+	t, err := s.newTranscript("ProveOr", orProtocol)
+	if err != nil {
+		return nil, err
+	}
+	if len(rels) == 0 {
+		return nil, opError("ProveOr", elgamal.ErrEmpty)
+	}
+	if known < 0 || known >= len(rels) {
+		return nil, opError("ProveOr", elgamal.ErrOutOfRange)
+	}
+	curve, err := checkOr("ProveOr", rels)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != len(rels[known].Bases[0]) {
+		return nil, itemError("ProveOr", "relation", known, elgamal.ErrLengthMismatch)
+	}
+	N := curve.Params().N
+	impl, err := s.backend("ProveOr", curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已知分支：T=sum v_i*G；其余分支随机选取c_k,r_k，模拟T=sum r_ki*G+c_k*Y
+	p := &OrProof{C: make([]*big.Int, len(rels)), R: make([][]*big.Int, len(rels))}
+	Ts := make([][]*elgamal.CurvePoint, len(rels))
+	var v []*big.Int
+	for k, rel := range rels {
+		m := len(rel.Bases[0])
+		rs := make([]*big.Int, m)
+		for i := range rs {
+			if rs[i], err = ec.RandFieldElement(curve, rand.Reader); err != nil {
+				return nil, opError("ProveOr", err)
+			}
+		}
+		if k == known {
+			v = rs
+			Ts[k] = rel.commit(impl, curve, v)
+			continue
+		}
+		if p.C[k], err = ec.RandFieldElement(curve, rand.Reader); err != nil {
+			return nil, opError("ProveOr", err)
+		}
+		p.R[k] = rs
+		Ts[k] = rel.eval(curve, rs, p.C[k])
+	}
+
+	// 已知分支的挑战值：c_known=c-sum c_k，应答r_i=v_i-c_known*x_i
+	c := orChallenge(t, context, rels, Ts)
+	ck := new(big.Int).Set(c)
+	for k := range rels {
+		if k != known {
+			ck.Sub(ck, p.C[k])
+		}
+	}
+	ck.Mod(ck, N)
+	p.C[known] = ck
+	p.R[known] = make([]*big.Int, len(x))
+	for i := range x {
+		r := new(big.Int).Mul(ck, x[i])
+		r.Sub(v[i], r)
+		p.R[known][i] = r.Mod(r, N)
+	}
+	return p, nil
+}
+
+// VerifyOr verifies the proof p that one of rels holds, made with the same
+// context, using the challenge hash of s.
+// OR证明验证：以s的挑战哈希，验证以相同context生成的、rels中至少一个关系成立的证明p。
+//
+// 参数：
+//		上下文：	context
+//		关系：		rels
+//		证明：		p
+// 返回：
+// 		验证结果
+func (s Suite) VerifyOr(context string, rels []*Relation, p *OrProof) (bool, error) {
+	t, err := s.newTranscript("VerifyOr", orProtocol)
+	if err != nil {
+		return false, err
+	}
+	if len(rels) == 0 {
+		return false, opError("VerifyOr", elgamal.ErrEmpty)
+	}
+	curve, err := checkOr("VerifyOr", rels)
+	if err != nil {
+		return false, err
+	}
+	if p == nil || len(p.C) != len(rels) || len(p.R) != len(rels) {
+		return false, nil
+	}
+	N := curve.Params().N
+
+	// 重构各分支承诺：T_k=sum r_ki*G+c_k*Y，并检查sum c_k?=c
+	Ts := make([][]*elgamal.CurvePoint, len(rels))
+	sum := new(big.Int)
+	for k, rel := range rels {
+		if p.C[k] == nil || len(p.R[k]) != len(rel.Bases[0]) {
+			return false, nil
+		}
+		for _, r := range p.R[k] {
+			if r == nil {
+				return false, nil
+			}
+		}
+		Ts[k] = rel.eval(curve, p.R[k], p.C[k])
+		sum.Add(sum, p.C[k])
+	}
+	c := orChallenge(t, context, rels, Ts)
+	return 0 == sum.Mod(sum, N).Cmp(c.Mod(c, N)), nil
+}
+
+// ProveOr proves that one of rels holds with DefaultSuite.
+// OR证明生成：使用默认参数组DefaultSuite。
+func ProveOr(context string, rels []*Relation, known int, x []*big.Int) (*OrProof, error) {
+	return DefaultSuite.ProveOr(context, rels, known, x)
+}
+
+// VerifyOr verifies the proof p that one of rels holds with DefaultSuite.
+// OR证明验证：使用默认参数组DefaultSuite。
+func VerifyOr(context string, rels []*Relation, p *OrProof) (bool, error) {
+	return DefaultSuite.VerifyOr(context, rels, p)
+}
+
+// checkOr validates every branch of rels and returns their common curve.
+// 校验rels的各分支，返回其共同的曲线。
+func checkOr(op string, rels []*Relation) (elliptic.Curve, error) {
+	var curve elliptic.Curve
+	for k, rel := range rels {
+		if rel == nil || len(rel.Bases) == 0 {
+			return nil, itemError(op, "relation", k, elgamal.ErrEmpty)
+		}
+		P, err := rel.check(op, len(rel.Bases[0]))
+		if err != nil {
+			return nil, err
+		}
+		if curve == nil {
+			curve = P.Curve
+		} else if P.Curve.Params() != curve.Params() {
+			return nil, itemError(op, "relation", k, elgamal.ErrCurveMismatch)
+		}
+	}
+	return curve, nil
+}
+
+// orChallenge appends every branch and its commitments to t and returns the challenge.
+// 将各分支及其承诺值追加到记录t，返回挑战值。
+func orChallenge(t *Transcript, context string, rels []*Relation, Ts [][]*elgamal.CurvePoint) *big.Int {
+	t.AppendMessage("context", []byte(context))
+	t.AppendScalar("branches", big.NewInt(int64(len(rels))))
+	for k, rel := range rels {
+		rel.appendTo(t)
+		for _, T := range Ts[k] {
+			t.AppendPoint("T", T)
+		}
+	}
+	return t.ChallengeScalar("c")
+}
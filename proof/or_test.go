@@ -0,0 +1,94 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestOrProof(t *testing.T) {
+	// 三个Schnorr分支，仅知道其中一个私钥
+	rels := make([]*Relation, 3)
+	for i := range rels {
+		_, _, Y, _, _, _, _ := statement(t)
+		rels[i] = DLogRelation(elgamal.Generator(Y.Curve), Y)
+	}
+	x, _, Y, _, _, _, _ := statement(t)
+	rels[1] = DLogRelation(elgamal.Generator(Y.Curve), Y)
+
+	p, err := ProveOr("test", rels, 1, []*big.Int{x})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyOr("test", rels, p); err != nil || !ok {
+		t.Fatal("honest OR proof failed to verify")
+	}
+	if ok, err := VerifyOr("other", rels, p); err != nil || ok {
+		t.Fatal("OR proof verified under another context")
+	}
+
+	// 调换分支顺序或篡改挑战值后验证失败
+	swapped := []*Relation{rels[1], rels[0], rels[2]}
+	if ok, err := VerifyOr("test", swapped, p); err != nil || ok {
+		t.Fatal("OR proof verified with reordered branches")
+	}
+	p.C[0] = new(big.Int).Add(p.C[0], big.NewInt(1))
+	if ok, err := VerifyOr("test", rels, p); err != nil || ok {
+		t.Fatal("OR proof verified with a forged challenge")
+	}
+
+	// 不知道任何分支的证据时无法生成有效证明
+	q, err := ProveOr("test", rels, 0, []*big.Int{x})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyOr("test", rels, q); err != nil || ok {
+		t.Fatal("OR proof without a witness verified")
+	}
+
+	if _, err := ProveOr("test", rels, 3, []*big.Int{x}); err == nil {
+		t.Fatal("expected error for a branch out of range")
+	}
+}
+
+func TestOrProofMixed(t *testing.T) {
+	// 双证据分支与Schnorr分支组合
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+	B := elgamal.Generator(Y1.Curve)
+	two := &Relation{
+		Bases:   [][]*elgamal.CurvePoint{{B, nil}, {nil, B}, {A1, A2}},
+		Targets: []*elgamal.CurvePoint{Y1, Y2, A},
+	}
+	rels := []*Relation{two, DLogRelation(B, elgamal.GenPoint())}
+
+	s := Suite{Hash: HashSHA256}
+	p, err := s.ProveOr("mixed", rels, 0, []*big.Int{y1, y2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.VerifyOr("mixed", rels, p); err != nil || !ok {
+		t.Fatal("honest mixed OR proof failed to verify")
+	}
+	if ok, err := VerifyOr("mixed", rels, p); err != nil || ok {
+		t.Fatal("SHA-256 OR proof verified under the default suite")
+	}
+	if _, err := s.ProveOr("mixed", rels, 0, []*big.Int{y1}); err == nil {
+		t.Fatal("expected error for a missing witness")
+	}
+}
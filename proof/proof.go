@@ -0,0 +1,294 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proof implements the non-interactive zero-knowledge proof used by ppks:
+// knowledge of (y1,y2) with {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}, made non-interactive
+// with SM3 by default. A Suite selects another challenge hash. DLEQGen and DLEQVerify
+// prove equality of discrete logarithms, as for verifiable decryption, and
+// ProveLinear and VerifyLinear prove any Relation of linear equations, and ProveOr
+// and VerifyOr prove that one of several relations holds. All
+// challenges are derived from a Transcript with labeled, length-prefixed entries.
+// ppks使用的非交互零知识证明：证明知道满足{Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}的(y1,y2)，
+// 默认以SM3实现非交互，可通过Suite选用其他挑战哈希。DLEQGen与DLEQVerify证明离散对数相等，用于可验证解密等；
+// ProveLinear与VerifyLinear证明任意线性方程组关系Relation，ProveOr与VerifyOr证明若干关系中至少一个成立。
+// 所有挑战值均由Transcript记录导出，记录中各项带标签与长度前缀。
+package proof
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+)
+
+// Gen generates the proof for (y1,y2) with constraints {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A},
+// using the challenge hash of s.
+// 零知识证明生成: 为（y1,y2）生成满足约束
+//     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}
+// 的证明pai=(c,r1,r2)，并返回。
+//
+// 参数：
+//		标量：	y1,y2
+//		点：B,Y1,Y2,A1,A2,A
+// 返回：
+// 		证明:	c,r1,r2
+func (s Suite) Gen(y1, y2 *big.Int, B, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
+	t, err := s.newTranscript("ProofGen", twoWitnessProtocol)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 生成两个随机数v1,v2
+	curve := Y1.Curve                                  // 从公钥提取曲线
+	v1, err := ec.RandFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
+	if err != nil {
+		return nil, nil, nil, opError("ProofGen", err)
+	}
+	v2, err := ec.RandFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
+	if err != nil {
+		return nil, nil, nil, opError("ProofGen", err)
+	}
+	impl, err := s.backend("ProofGen", curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 计算承诺值：T1=v1*B, T2=v2*B, T3=v1*A1+v2*A2
+	var T1, T2, T3 elgamal.CurvePoint
+	T1.Curve = curve
+	T1.X, T1.Y = scalarMult(impl, B, v1.Bytes())
+	T2.Curve = curve
+	T2.X, T2.Y = scalarMult(impl, B, v2.Bytes())
+	T3.Curve = curve
+	vA1x, vA1y := scalarMult(impl, A1, v1.Bytes())
+	vA2x, vA2y := scalarMult(impl, A2, v2.Bytes())
+	T3.X, T3.Y = add(impl, curve, vA1x, vA1y, vA2x, vA2y)
+
+	// 计算挑战：c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3)
+	c := twoWitnessChallenge(t, B, Y1, Y2, A1, A2, A, &T1, &T2, &T3)
+
+	// 计算应答：r1=v1-c*y1, r2=v2-c*y2
+	r1 := new(big.Int).Mul(c, y1)
+	r1.Mod(r1, curve.Params().N)
+	r1 = new(big.Int).Sub(v1, r1)
+	r1.Mod(r1, curve.Params().N)
+
+	r2 := new(big.Int).Mul(c, y2)
+	r2.Mod(r2, curve.Params().N)
+	r2.Sub(v2, r2)
+	r2.Mod(r2, curve.Params().N)
+
+	return c, r1, r2, nil
+}
+
+// GenNoB generates the proof for (y1,y2) with constraints {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A},
+// using the challenge hash of s.
+// 零知识证明生成: 为（y1,y2）生成满足约束
+//     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}
+// 的证明pai=(c,r1,r2)，并返回。
+//
+// 参数：
+//		标量：	y1,y2
+//		点：Y1,Y2,A1,A2,A
+// 返回：
+// 		证明:	c,r1,r2
+func (s Suite) GenNoB(y1, y2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
+	c, r1, r2, _, err := s.GenNoBWithCommitment(y1, y2, Y1, Y2, A1, A2, A)
+	return c, r1, r2, err
+}
+
+// GenNoBWithCommitment is GenNoB also returning the commitment (T1,T2,T3) the
+// challenge was computed from, so the proof can take part in BatchVerifyNoB.
+// 零知识证明生成：同GenNoB，并返回计算挑战值所用的承诺(T1,T2,T3)，使证明可参与BatchVerifyNoB。
+//
+// 参数：
+//		标量：	y1,y2
+//		点：Y1,Y2,A1,A2,A
+// 返回：
+// 		证明:	c,r1,r2
+//		承诺:	T
+func (s Suite) GenNoBWithCommitment(y1, y2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, *Commitment, error) {
+	t, err := s.newTranscript("ProofGenNoB", twoWitnessProtocol)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// 生成两个随机数v1,v2
+	curve := Y1.Curve                                  // 从公钥提取曲线
+	v1, err := ec.RandFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
+	if err != nil {
+		return nil, nil, nil, nil, opError("ProofGenNoB", err)
+	}
+	v2, err := ec.RandFieldElement(curve, rand.Reader) // 从有限域中获得随机元素
+	if err != nil {
+		return nil, nil, nil, nil, opError("ProofGenNoB", err)
+	}
+	impl, err := s.backend("ProofGenNoB", curve)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// 计算承诺值：T1=v1*B, T2=v2*B, T3=v1*A1+v2*A2
+	B := elgamal.Generator(curve)
+	var T1, T2, T3 elgamal.CurvePoint
+	T1.Curve = curve
+	T1.X, T1.Y = scalarMult(impl, B, v1.Bytes())
+	T2.Curve = curve
+	T2.X, T2.Y = scalarMult(impl, B, v2.Bytes())
+	T3.Curve = curve
+	vA1x, vA1y := scalarMult(impl, A1, v1.Bytes())
+	vA2x, vA2y := scalarMult(impl, A2, v2.Bytes())
+	T3.X, T3.Y = add(impl, curve, vA1x, vA1y, vA2x, vA2y)
+
+	// 计算挑战：c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3)
+	c := twoWitnessChallenge(t, B, Y1, Y2, A1, A2, A, &T1, &T2, &T3)
+
+	// 计算应答：r1=v1-c*y1, r2=v2-c*y2
+	r1 := new(big.Int).Mul(c, y1)
+	r1.Mod(r1, curve.Params().N)
+	r1 = new(big.Int).Sub(v1, r1)
+	r1.Mod(r1, curve.Params().N)
+
+	r2 := new(big.Int).Mul(c, y2)
+	r2.Mod(r2, curve.Params().N)
+	r2.Sub(v2, r2)
+	r2.Mod(r2, curve.Params().N)
+
+	return c, r1, r2, &Commitment{T1: T1, T2: T2, T3: T3}, nil
+}
+
+// Verify verifies the proof pai=(c,r1,r2) with public points (B,Y1,Y2,A1,A2,A),
+// using the challenge hash of s.
+// 零知识证明验证: 验证证明pai=(c,r1,r2)是否能够证明公开点(B,Y1,Y2,A1,A2,A)满足约束
+//     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}，
+// 并返回。
+//
+// 参数：
+//		证明：	c,r1,r2
+//		点：B,Y1,Y2,A1,A2,A
+// 返回：
+// 		份额密文
+func (s Suite) Verify(c, r1, r2 *big.Int, B, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (bool, error) {
+	v, err := s.newVerifier("ProofVrf")
+	if err != nil {
+		return false, err
+	}
+	return v.Verify(c, r1, r2, B, Y1, Y2, A1, A2, A)
+}
+
+// VerifyNoB verifies the proof pai=(c,r1,r2) with public points (Y1,Y2,A1,A2,A),
+// using the challenge hash of s.
+// 零知识证明验证: 验证证明pai=(c,r1,r2)是否能够证明公开点(Y1,Y2,A1,A2,A)满足约束
+//     {Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}，
+// 并返回。
+//
+// 参数：
+//		证明：	c,r1,r2
+//		点：Y1,Y2,A1,A2,A
+// 返回：
+// 		份额密文
+func (s Suite) VerifyNoB(c, r1, r2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (bool, error) {
+	v, err := s.newVerifier("ProofVrfNoB")
+	if err != nil {
+		return false, err
+	}
+	return v.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A)
+}
+
+// Verifier is a verification context for many two-witness proofs under one
+// suite. It keeps the hash state and scratch buffers of its transcript from one
+// proof to the next, where Suite.Verify allocates them for every call. A
+// Verifier must not be used by several goroutines at once.
+// 验证上下文：在同一参数组下验证多个双证据证明，在证明之间复用记录的哈希状态与暂存区，而
+// Suite.Verify每次调用都重新分配。Verifier不可被多个goroutine同时使用。
+type Verifier struct {
+	suite Suite
+	t     *Transcript
+}
+
+// NewVerifier returns a verification context for the proofs of s.
+// 创建验证上下文：用于验证参数组s下的证明。
+//
+// 返回：
+// 		验证上下文
+func (s Suite) NewVerifier() (*Verifier, error) {
+	return s.newVerifier("NewVerifier")
+}
+
+// newVerifier is NewVerifier reporting errors for op.
+// 创建验证上下文，出错时报告操作op。
+func (s Suite) newVerifier(op string) (*Verifier, error) {
+	h, err := s.newHash(op)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{suite: s, t: &Transcript{h: h}}, nil
+}
+
+// Verify verifies the proof pai=(c,r1,r2) with public points (B,Y1,Y2,A1,A2,A),
+// like Suite.Verify.
+// 零知识证明验证：同Suite.Verify，复用上下文的记录。
+func (v *Verifier) Verify(c, r1, r2 *big.Int, B, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (bool, error) {
+	return v.verify(c, r1, r2, B, Y1, Y2, A1, A2, A), nil
+}
+
+// VerifyNoB verifies the proof pai=(c,r1,r2) with public points (Y1,Y2,A1,A2,A),
+// like Suite.VerifyNoB.
+// 零知识证明验证：同Suite.VerifyNoB，复用上下文的记录。
+func (v *Verifier) VerifyNoB(c, r1, r2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (bool, error) {
+	return v.verify(c, r1, r2, elgamal.Generator(Y1.Curve), Y1, Y2, A1, A2, A), nil
+}
+
+// verify recomputes the commitment and challenge of the proof (c,r1,r2) on the
+// restarted transcript of v.
+// 在v重新开始的记录上重构证明(c,r1,r2)的承诺与挑战值。
+func (v *Verifier) verify(c, r1, r2 *big.Int, B, Y1, Y2, A1, A2, A *elgamal.CurvePoint) bool {
+	v.t.start(v.suite, twoWitnessProtocol)
+	curve := Y1.Curve
+
+	// 重构承诺：T1'=r1*B+c*Y1, T2'=r2*B+c*Y2, T3'=r1*A1+r2*A2+c*A，各以Shamir技巧联合计算
+	// 下文Ti' 用Ti指代
+	T1 := combine(curve, []*elgamal.CurvePoint{B, Y1}, r1, c)
+	T2 := combine(curve, []*elgamal.CurvePoint{B, Y2}, r2, c)
+	T3 := combine(curve, []*elgamal.CurvePoint{A1, A2, A}, r1, r2, c)
+
+	// 计算新的挑战值：c'=H(B,Y1,Y2,A1,A2,A,T1',T2',T3')
+	// 如上，c'用c_new代替
+	c_new := twoWitnessChallenge(v.t, B, Y1, Y2, A1, A2, A, &T1, &T2, &T3)
+
+	// 检查一致性：c?=c'
+	return 0 == c.Cmp(c_new)
+}
+
+// twoWitnessProtocol names the transcripts of the two-witness proof.
+// 双证据证明记录的协议名。
+const twoWitnessProtocol = "ppks-two-witness"
+
+// twoWitnessChallenge appends the statement and commitment of a two-witness proof
+// to t and returns the challenge c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3).
+// 将双证据证明的公开点与承诺值追加到记录t，返回挑战值c=H(B,Y1,Y2,A1,A2,A,T1,T2,T3)。
+func twoWitnessChallenge(t *Transcript, B, Y1, Y2, A1, A2, A, T1, T2, T3 *elgamal.CurvePoint) *big.Int {
+	t.AppendPoint("B", B)
+	t.AppendPoint("Y1", Y1)
+	t.AppendPoint("Y2", Y2)
+	t.AppendPoint("A1", A1)
+	t.AppendPoint("A2", A2)
+	t.AppendPoint("A", A)
+	t.AppendPoint("T1", T1)
+	t.AppendPoint("T2", T2)
+	t.AppendPoint("T3", T3)
+	return t.ChallengeScalar("c")
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// statement 生成满足{Y1=y1*B,Y2=y2*B,A1*y1+A2*y2=A}的秘密与公开点。
+func statement(t testing.TB) (y1, y2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) {
+	k1, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	A1 = elgamal.GenPoint()
+	A2 = elgamal.GenPoint()
+
+	// A=A1*y1+A2*y2
+	curve := A1.Curve
+	A = new(elgamal.CurvePoint)
+	A.Curve = curve
+	Ay1x, Ay1y := curve.ScalarMult(A1.X, A1.Y, k1.D.Bytes())
+	Ay2x, Ay2y := curve.ScalarMult(A2.X, A2.Y, k2.D.Bytes())
+	A.X, A.Y = curve.Add(Ay1x, Ay1y, Ay2x, Ay2y)
+
+	return k1.D, k2.D, (*elgamal.CurvePoint)(&k1.PublicKey), (*elgamal.CurvePoint)(&k2.PublicKey), A1, A2, A
+}
+
+func TestGenVerify(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+	var B elgamal.CurvePoint
+	B.Curve = Y1.Curve
+	B.X = B.Curve.Params().Gx
+	B.Y = B.Curve.Params().Gy
+
+	c, r1, r2, err := Gen(y1, y2, &B, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flag, err := Verify(c, r1, r2, &B, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag {
+		t.Fatal("honest proof failed to verify")
+	}
+
+	// 换用其他公开点后验证失败
+	flag, err = Verify(c, r1, r2, &B, Y1, Y2, A1, A2, elgamal.GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag {
+		t.Fatal("proof verified against a different statement")
+	}
+}
+
+func TestGenVerifyNoB(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+
+	c, r1, r2, err := GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flag, err := VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag {
+		t.Fatal("honest proof failed to verify")
+	}
+
+	// 篡改应答后验证失败
+	flag, err = VerifyNoB(c, new(big.Int).Add(r1, big.NewInt(1)), r2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag {
+		t.Fatal("tampered proof verified")
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+	B := elgamal.Generator(Y1.Curve)
+	for _, s := range []Suite{DefaultSuite, {Hash: HashSHA256, Context: "verifier"}} {
+		c, r1, r2, err := s.GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cB, r1B, r2B, err := s.Gen(y1, y2, B, Y1, Y2, A1, A2, A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := s.NewVerifier()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 同一上下文先后验证多个证明，失败的验证不影响后续结果
+		for i := 0; i < 2; i++ {
+			if ok, err := v.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A); err != nil || !ok {
+				t.Fatalf("%v: honest proof rejected: %v", s.Hash, err)
+			}
+			if ok, _ := v.VerifyNoB(c, r2, r1, Y1, Y2, A1, A2, A); ok {
+				t.Fatalf("%v: tampered proof verified", s.Hash)
+			}
+			if ok, err := v.Verify(cB, r1B, r2B, B, Y1, Y2, A1, A2, A); err != nil || !ok {
+				t.Fatalf("%v: honest proof with B rejected: %v", s.Hash, err)
+			}
+		}
+	}
+	if _, err := (Suite{Hash: 99}).NewVerifier(); !errors.Is(err, ErrUnknownHash) {
+		t.Fatalf("got %v, want ErrUnknownHash", err)
+	}
+}
+
+func BenchmarkGen(b *testing.B) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(b)
+	B := elgamal.Generator(Y1.Curve)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := Gen(y1, y2, B, Y1, Y2, A1, A2, A); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(b)
+	B := elgamal.Generator(Y1.Curve)
+	c, r1, r2, err := Gen(y1, y2, B, Y1, Y2, A1, A2, A)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := Verify(c, r1, r2, B, Y1, Y2, A1, A2, A); err != nil || !ok {
+			b.Fatal("honest proof failed to verify")
+		}
+	}
+}
+
+func BenchmarkVerifyNoB(b *testing.B) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(b)
+	c, r1, r2, err := GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A); err != nil || !ok {
+			b.Fatal("honest proof failed to verify")
+		}
+	}
+}
+
+func BenchmarkVerifierNoB(b *testing.B) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(b)
+	c, r1, r2, err := GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		b.Fatal(err)
+	}
+	v, err := DefaultSuite.NewVerifier()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := v.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A); err != nil || !ok {
+			b.Fatal("honest proof failed to verify")
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"hash"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// HashID identifies the hash that derives the Fiat-Shamir challenge. It is written
+// into serialized proofs, so its values must never change.
+// 挑战哈希标识：标识Fiat-Shamir变换中计算挑战值的哈希函数，会写入序列化的证明，取值不可更改。
+type HashID uint8
+
+const (
+	// HashSM3 is SM3, the default. 默认的SM3。
+	HashSM3 HashID = 1
+	// HashSHA256 is SHA-256, for deployments standardized on it. 用于统一采用SHA-256的部署。
+	HashSHA256 HashID = 2
+)
+
+// New returns a new hash of the kind id, or nil when id is unknown.
+// 返回id对应的新哈希实例，id未知时返回nil。
+func (id HashID) New() hash.Hash {
+	switch id {
+	case HashSM3:
+		return sm3.New()
+	case HashSHA256:
+		return sha256.New()
+	default:
+		return nil
+	}
+}
+
+// String returns the name of the hash.
+// 返回哈希名称。
+func (id HashID) String() string {
+	switch id {
+	case HashSM3:
+		return "SM3"
+	case HashSHA256:
+		return "SHA-256"
+	default:
+		return "unknown"
+	}
+}
+
+// Suite selects the parameters of the proofs. Both prover and verifier must use
+// the same suite; the zero Suite is DefaultSuite.
+// 证明参数组：选择证明所用参数，证明方与验证方须使用相同参数组。Suite零值即DefaultSuite。
+type Suite struct {
+	// Hash derives the challenge. 计算挑战值的哈希。
+	Hash HashID
+	// Context, when set, is appended to every transcript of the suite, so a proof
+	// made under one context does not verify under any other. It is not written into
+	// serialized proofs: the verifier must know it.
+	// 上下文：非空时追加到该参数组的每个记录中，在某一上下文下生成的证明在其他上下文下无法通过验证。
+	// 上下文不写入序列化的证明，验证方须自行知晓。
+	Context string
+	// Backend, when set, names the arithmetic backend registered by RegisterBackend
+	// that carries out the scalar multiplications by secret scalars, such as an
+	// optimized constant-time SM2 implementation: the prover's nonces, and the
+	// node key and share randomness of the shares proved with the suite. It changes
+	// no result, only how it is computed, so prover and verifier may choose
+	// backends independently.
+	// 算术后端：非空时为RegisterBackend注册的后端名称，以秘密标量进行的标量乘由该后端完成，
+	// 如优化的常数时间SM2实现：即证明方的随机数，以及以该参数组证明的份额所用的节点私钥与份额随机数。
+	// 后端不改变任何结果，仅改变计算方式，证明方与验证方可各自选择。
+	Backend string
+}
+
+// DefaultSuite is used by the package level functions: SM3 challenges, as in the
+// original protocol.
+// 包级函数使用的默认参数组：与原协议相同，以SM3计算挑战值。
+var DefaultSuite = Suite{Hash: HashSM3}
+
+// RegisterBackend registers impl under name as an arithmetic backend for the curve
+// with the same parameters, which a Suite then selects by its Backend field.
+// It is a wrapper of elgamal.RegisterBackend.
+// 注册算术后端：以name注册impl，作为与其参数相同的曲线的算术实现，参数组通过Backend字段选用。
+// 封装了elgamal.RegisterBackend。
+//
+// 参数：
+//		后端名称	name
+//		曲线实现	impl
+// 返回：
+// 		错误
+func RegisterBackend(name string, impl elliptic.Curve) error {
+	return elgamal.RegisterBackend(name, impl)
+}
+
+// backend returns the implementation of curve selected by s, or nil when s
+// selects none.
+// 返回s为曲线curve选用的实现，未选择后端时返回nil。
+func (s Suite) backend(op string, curve elliptic.Curve) (elliptic.Curve, error) {
+	if s.Backend == "" {
+		return nil, nil
+	}
+	impl, ok := ec.Backend(s.Backend, curve)
+	if !ok {
+		return nil, opError(op, ErrUnknownBackend)
+	}
+	return impl, nil
+}
+
+// scalarMult returns k*P with impl, the result of Suite.backend, or with
+// elgamal.ScalarMult and its fixed-base tables when impl is nil.
+// 以impl（Suite.backend的结果）计算k*P；impl为nil时使用elgamal.ScalarMult及其固定基点表。
+func scalarMult(impl elliptic.Curve, P *elgamal.CurvePoint, k []byte) (*big.Int, *big.Int) {
+	if impl == nil {
+		return elgamal.ScalarMult(P, k)
+	}
+	if params := impl.Params(); P.X.Cmp(params.Gx) == 0 && P.Y.Cmp(params.Gy) == 0 {
+		return impl.ScalarBaseMult(k)
+	}
+	return impl.ScalarMult(P.X, P.Y, k)
+}
+
+// add returns (x1,y1)+(x2,y2) with impl, or on curve when impl is nil.
+// 以impl计算(x1,y1)+(x2,y2)；impl为nil时在curve上计算。
+func add(impl, curve elliptic.Curve, x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if impl != nil {
+		curve = impl
+	}
+	return ec.Add(curve, x1, y1, x2, y2)
+}
+
+// newHash returns the challenge hash of s.
+// 返回s的挑战哈希实例。
+func (s Suite) newHash(op string) (hash.Hash, error) {
+	id := s.Hash
+	if id == 0 {
+		id = DefaultSuite.Hash
+	}
+	h := id.New()
+	if h == nil {
+		return nil, opError(op, ErrUnknownHash)
+	}
+	return h, nil
+}
+
+// Gen generates the proof for (y1,y2) with DefaultSuite.
+// 零知识证明生成：使用默认参数组DefaultSuite。
+func Gen(y1, y2 *big.Int, B, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
+	return DefaultSuite.Gen(y1, y2, B, Y1, Y2, A1, A2, A)
+}
+
+// GenNoB generates the proof for (y1,y2) with DefaultSuite.
+// 零知识证明生成：使用默认参数组DefaultSuite。
+func GenNoB(y1, y2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, error) {
+	return DefaultSuite.GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+}
+
+// GenNoBWithCommitment generates the proof for (y1,y2) and its commitment with DefaultSuite.
+// 零知识证明生成并返回承诺：使用默认参数组DefaultSuite。
+func GenNoBWithCommitment(y1, y2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (*big.Int, *big.Int, *big.Int, *Commitment, error) {
+	return DefaultSuite.GenNoBWithCommitment(y1, y2, Y1, Y2, A1, A2, A)
+}
+
+// Verify verifies the proof pai=(c,r1,r2) with DefaultSuite.
+// 零知识证明验证：使用默认参数组DefaultSuite。
+func Verify(c, r1, r2 *big.Int, B, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (bool, error) {
+	return DefaultSuite.Verify(c, r1, r2, B, Y1, Y2, A1, A2, A)
+}
+
+// VerifyNoB verifies the proof pai=(c,r1,r2) with DefaultSuite.
+// 零知识证明验证：使用默认参数组DefaultSuite。
+func VerifyNoB(c, r1, r2 *big.Int, Y1, Y2, A1, A2, A *elgamal.CurvePoint) (bool, error) {
+	return DefaultSuite.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A)
+}
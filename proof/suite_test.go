@@ -0,0 +1,166 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSuiteHash(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+
+	sha := Suite{Hash: HashSHA256}
+	c, r1, r2, err := sha.GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flag, err := sha.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag {
+		t.Fatal("SHA-256 proof failed to verify")
+	}
+
+	// 挑战哈希不同则验证失败
+	flag, err = VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag {
+		t.Fatal("SHA-256 proof verified with SM3")
+	}
+
+	// Suite零值即默认参数组
+	c, r1, r2, err = Suite{}.GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag, _ := VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A); !flag {
+		t.Fatal("zero Suite does not match DefaultSuite")
+	}
+
+	if _, _, _, err := (Suite{Hash: 99}).GenNoB(y1, y2, Y1, Y2, A1, A2, A); !errors.Is(err, ErrUnknownHash) {
+		t.Fatalf("got %v, want ErrUnknownHash", err)
+	}
+	if HashSHA256.String() != "SHA-256" || HashID(99).String() != "unknown" {
+		t.Fatal("unexpected hash names")
+	}
+}
+
+func TestSuiteContext(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+
+	s := Suite{Context: "purpose=audit"}
+	c, r1, r2, err := s.GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag, err := s.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A); err != nil || !flag {
+		t.Fatal("proof failed to verify under its own context")
+	}
+
+	// 上下文不同或缺失则验证失败
+	for _, other := range []Suite{{Context: "purpose=billing"}, DefaultSuite} {
+		if flag, _ := other.VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A); flag {
+			t.Fatalf("proof verified under context %q", other.Context)
+		}
+	}
+}
+
+// countingCurve stands in for an optimized backend: the SM2 curve counting its
+// scalar multiplications.
+type countingCurve struct {
+	elliptic.Curve
+	mults *int64
+}
+
+func (c countingCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(c.mults, 1)
+	return c.Curve.ScalarMult(x, y, k)
+}
+
+func (c countingCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(c.mults, 1)
+	return c.Curve.ScalarBaseMult(k)
+}
+
+// brokenCurve returns the doubled point from every base multiplication.
+type brokenCurve struct{ elliptic.Curve }
+
+func (c brokenCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	x, y := c.Curve.ScalarBaseMult(k)
+	return c.Curve.Double(x, y)
+}
+
+func TestSuiteBackend(t *testing.T) {
+	y1, y2, Y1, Y2, A1, A2, A := statement(t)
+
+	var mults int64
+	if err := RegisterBackend("counting", countingCurve{sm2.P256Sm2(), &mults}); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt64(&mults, 0)
+	s := Suite{Backend: "counting"}
+
+	// 后端只改变计算方式，证明可在未选择后端的参数组下验证
+	c, r1, r2, err := s.GenNoB(y1, y2, Y1, Y2, A1, A2, A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag, err := VerifyNoB(c, r1, r2, Y1, Y2, A1, A2, A); err != nil || !flag {
+		t.Fatal("proof made with a backend failed to verify")
+	}
+	if n := atomic.LoadInt64(&mults); n != 4 {
+		t.Fatalf("backend ran %d multiplications, want 4", n)
+	}
+
+	B := elgamal.Generator(Y1.Curve)
+	Z := &elgamal.CurvePoint{Curve: A1.Curve}
+	Z.X, Z.Y = A1.Curve.ScalarMult(A1.X, A1.Y, y1.Bytes())
+	if c, r, err := s.DLEQGen(y1, B, Y1, A1, Z); err != nil {
+		t.Fatal(err)
+	} else if flag, _ := DLEQVerify(c, r, B, Y1, A1, Z); !flag {
+		t.Fatal("DLEQ proof made with a backend failed to verify")
+	}
+	rel := DLogRelation(B, Y1)
+	if p, err := s.ProveLinear("test", rel, []*big.Int{y1}); err != nil {
+		t.Fatal(err)
+	} else if flag, _ := VerifyLinear("test", rel, p); !flag {
+		t.Fatal("linear proof made with a backend failed to verify")
+	}
+	if n := atomic.LoadInt64(&mults); n != 7 {
+		t.Fatalf("backend ran %d multiplications, want 7", n)
+	}
+
+	if _, _, _, err := (Suite{Backend: "missing"}).GenNoB(y1, y2, Y1, Y2, A1, A2, A); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("got %v, want ErrUnknownBackend", err)
+	}
+	if err := RegisterBackend("broken", brokenCurve{sm2.P256Sm2()}); !errors.Is(err, ErrInvalidBackend) {
+		t.Fatalf("got %v, want ErrInvalidBackend", err)
+	}
+	if _, _, _, err := (Suite{Backend: "broken"}).GenNoB(y1, y2, Y1, Y2, A1, A2, A); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatal("failed backend was registered")
+	}
+}
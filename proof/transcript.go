@@ -0,0 +1,153 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/big"
+
+	"ppks/elgamal"
+)
+
+// transcriptDomain starts every transcript, separating ppks challenges from any
+// other use of the hash.
+// 所有记录的起始域分隔标签，将ppks的挑战值与该哈希的其他用途区分。
+const transcriptDomain = "ppks-transcript-v1"
+
+// Frame kinds of a transcript. 记录中各帧的类型。
+const (
+	frameMessage   = 'm'
+	frameChallenge = 'c'
+)
+
+// Transcript is a Merlin-style Fiat-Shamir transcript. A proof appends every public
+// value under a label and derives its challenges from the transcript, instead of
+// hashing raw bytes: each entry is written as kind||len(label)||label||len(value)||value,
+// and every transcript starts with the name of its protocol, so no two distinct
+// sequences of entries, and no two protocols, hash the same input. Each challenge
+// is appended back, so later challenges depend on earlier ones.
+// 证明记录：Merlin风格的Fiat-Shamir记录。证明将各公开值连同标签追加到记录中，并由记录导出挑战值，
+// 而非直接对原始字节求哈希：每项以 类型||len(标签)||标签||len(值)||值 的形式写入，且每个记录以协议名
+// 开始，因此不同的追加序列、不同的协议不会得到相同的哈希输入。挑战值会被追加回记录，后续挑战值依赖于之前的挑战值。
+type Transcript struct {
+	h hash.Hash
+	// 帧头与值的暂存区，跨追加复用以免每项分配内存
+	hdr, val []byte
+}
+
+// NewTranscript starts a transcript for protocol, using the challenge hash of s.
+// 创建记录：以s的挑战哈希，为协议protocol创建记录。
+//
+// 参数：
+//		协议名：	protocol
+// 返回：
+// 		记录
+func (s Suite) NewTranscript(protocol string) (*Transcript, error) {
+	return s.newTranscript("NewTranscript", protocol)
+}
+
+// NewTranscript starts a transcript for protocol with DefaultSuite.
+// 创建记录：使用默认参数组DefaultSuite。
+func NewTranscript(protocol string) *Transcript {
+	t, _ := DefaultSuite.NewTranscript(protocol)
+	return t
+}
+
+// newTranscript is NewTranscript reporting errors for op.
+// 创建记录，出错时报告操作op。
+func (s Suite) newTranscript(op, protocol string) (*Transcript, error) {
+	h, err := s.newHash(op)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transcript{h: h}
+	t.start(s, protocol)
+	return t, nil
+}
+
+// start resets t and appends the domain separator, protocol and context that
+// begin every transcript of s, so one transcript can serve many proofs.
+// 重置记录t，并追加s的每个记录开头的域分隔标签、协议名与上下文，使一个记录可用于多个证明。
+func (t *Transcript) start(s Suite, protocol string) {
+	t.h.Reset()
+	t.appendString("dom-sep", transcriptDomain)
+	t.appendString("protocol", protocol)
+	if s.Context != "" {
+		t.appendString("context", s.Context)
+	}
+}
+
+// appendString appends msg under label without converting it to a fresh slice.
+// 将字符串msg以标签label追加到记录，不另行转换为新切片。
+func (t *Transcript) appendString(label, msg string) {
+	t.val = append(t.val[:0], msg...)
+	t.frame(frameMessage, label, t.val)
+}
+
+// AppendMessage appends msg under label.
+// 追加消息：将消息msg以标签label追加到记录。
+func (t *Transcript) AppendMessage(label string, msg []byte) {
+	t.frame(frameMessage, label, msg)
+}
+
+// AppendPoint appends the uncompressed encoding of P under label; the point at
+// infinity is encoded as the single byte 0x00.
+// 追加点：将点P的非压缩编码以标签label追加到记录，无穷远点编码为单字节0x00。
+func (t *Transcript) AppendPoint(label string, P *elgamal.CurvePoint) {
+	if P.IsInfinity() {
+		t.val = append(t.val[:0], 0)
+	} else {
+		t.val = P.AppendBytes(t.val[:0])
+	}
+	t.frame(frameMessage, label, t.val)
+}
+
+// AppendScalar appends the big-endian encoding of k under label.
+// 追加标量：将k的大端编码以标签label追加到记录。
+func (t *Transcript) AppendScalar(label string, k *big.Int) {
+	n := (k.BitLen() + 7) / 8
+	if cap(t.val) < n {
+		t.val = make([]byte, n)
+	}
+	t.val = t.val[:n]
+	k.FillBytes(t.val)
+	t.frame(frameMessage, label, t.val)
+}
+
+// ChallengeScalar derives the challenge named label from everything appended so
+// far, a 256-bit integer, and appends it back to the transcript.
+// 导出挑战值：由目前追加的全部内容导出名为label的256比特挑战值，并将其追加回记录。
+func (t *Transcript) ChallengeScalar(label string) *big.Int {
+	t.frame(frameChallenge, label, nil)
+	out := t.h.Sum(nil)[:32]
+	t.h.Write(out)
+	return new(big.Int).SetBytes(out)
+}
+
+// frame writes one length-prefixed entry.
+// 写入一个带长度前缀的项。
+func (t *Transcript) frame(kind byte, label string, value []byte) {
+	var n [4]byte
+	t.hdr = append(t.hdr[:0], kind)
+	binary.BigEndian.PutUint32(n[:], uint32(len(label)))
+	t.hdr = append(t.hdr, n[:]...)
+	t.hdr = append(t.hdr, label...)
+	binary.BigEndian.PutUint32(n[:], uint32(len(value)))
+	t.hdr = append(t.hdr, n[:]...)
+	t.h.Write(t.hdr)
+	t.h.Write(value)
+}
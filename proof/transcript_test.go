@@ -0,0 +1,87 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proof
+
+import (
+	"testing"
+
+	"ppks/elgamal"
+)
+
+func TestTranscript(t *testing.T) {
+	P := elgamal.GenPoint()
+	challenge := func(protocol string, appends func(*Transcript)) string {
+		tr := NewTranscript(protocol)
+		appends(tr)
+		return tr.ChallengeScalar("c").String()
+	}
+
+	base := challenge("p", func(tr *Transcript) { tr.AppendPoint("P", P) })
+	if base != challenge("p", func(tr *Transcript) { tr.AppendPoint("P", P) }) {
+		t.Fatal("transcript is not deterministic")
+	}
+
+	// 协议名、标签、帧边界与无穷远点均影响挑战值
+	for name, got := range map[string]string{
+		"protocol": challenge("q", func(tr *Transcript) { tr.AppendPoint("P", P) }),
+		"label":    challenge("p", func(tr *Transcript) { tr.AppendPoint("Q", P) }),
+		"framing": challenge("p", func(tr *Transcript) {
+			tr.AppendMessage("a", []byte("bc"))
+		}),
+		"infinity": challenge("p", func(tr *Transcript) { tr.AppendPoint("P", elgamal.Infinity(P.Curve)) }),
+	} {
+		if got == base {
+			t.Fatalf("%s did not change the challenge", name)
+		}
+	}
+	if challenge("p", func(tr *Transcript) { tr.AppendMessage("a", []byte("bc")) }) ==
+		challenge("p", func(tr *Transcript) { tr.AppendMessage("ab", []byte("c")) }) {
+		t.Fatal("label and message boundary is ambiguous")
+	}
+
+	// 后续挑战值依赖之前的挑战值
+	tr := NewTranscript("p")
+	c1 := tr.ChallengeScalar("c")
+	c2 := tr.ChallengeScalar("c")
+	if c1.Cmp(c2) == 0 {
+		t.Fatal("repeated challenge did not change")
+	}
+
+	// 不同挑战哈希得到不同挑战值
+	s, err := Suite{Hash: HashSHA256}.NewTranscript("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AppendPoint("P", P)
+	if s.ChallengeScalar("c").String() == base {
+		t.Fatal("hash did not change the challenge")
+	}
+	if _, err := (Suite{Hash: HashID(9)}).NewTranscript("p"); err == nil {
+		t.Fatal("expected error for unknown hash")
+	}
+}
+
+func BenchmarkTranscript(b *testing.B) {
+	P := elgamal.GenPoint()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		t := NewTranscript("bench")
+		for j := 0; j < 9; j++ {
+			t.AppendPoint("P", P)
+		}
+		t.ChallengeScalar("c")
+	}
+}
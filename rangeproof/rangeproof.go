@@ -0,0 +1,226 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rangeproof proves that exponential ElGamal ciphertexts, as made by
+// elgamal.EncryptInt, encrypt integers in [0, 2^n), without revealing them. The
+// value is encrypted bit by bit, the ciphertext is the weighted sum of the bit
+// ciphertexts, and each bit ciphertext carries an OR proof that it encrypts 0 or 1,
+// so encrypted metering data can be audited while it stays encrypted.
+// 范围证明：证明指数ElGamal密文（如elgamal.EncryptInt所生成）加密的整数位于[0, 2^n)内，且不泄露该整数。
+// 整数逐比特加密，密文为各比特密文的加权和，每个比特密文附带其加密0或1的OR证明，
+// 从而可在数据保持加密的情况下审计加密的计量数据。
+package rangeproof
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"strconv"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// MaxBits is the largest supported n: values are int64.
+// 支持的最大比特数n：整数为int64。
+const MaxBits = 63
+
+// Proof is a range proof: the ciphertexts of the bits of the value and, for each,
+// a proof that it encrypts 0 or 1.
+// 范围证明：整数各比特的密文，以及各比特密文加密0或1的证明。
+type Proof struct {
+	Bits   []elgamal.CipherText
+	Proofs []*proof.OrProof
+}
+
+// EncryptInt encrypts m with pub like elgamal.EncryptInt and proves that m lies in
+// [0, 2^bits). The ciphertext is an ordinary exponential ElGamal ciphertext: it
+// adds, switches and decrypts as usual.
+// 带范围证明的整数加密：与elgamal.EncryptInt相同，使用公钥pub加密m，并证明m位于[0, 2^bits)内。
+// 所得密文为普通的指数ElGamal密文，可照常相加、置换与解密。
+//
+// 参数：
+//		公钥	pub
+//		整数	m
+//		比特数	bits
+// 返回：
+// 		密文
+//		范围证明
+func EncryptInt(pub *sm2.PublicKey, m int64, bits int) (*elgamal.CipherText, *Proof, error) {
+	ct, p, _, err := encryptInt("EncryptInt", pub, m, bits)
+	return ct, p, err
+}
+
+// EncryptIntWithRandomness is EncryptInt also returning the randomness r of the
+// ciphertext, K = r*B, so the caller can prove further relations about it. r must
+// be kept as secret as m.
+// 带范围证明的整数加密：同EncryptInt，但同时返回密文的随机数r（K = r*B），供调用者证明关于该密文的其他关系。
+// r须与m同样保密。
+//
+// 参数：
+//		公钥	pub
+//		整数	m
+//		比特数	bits
+// 返回：
+// 		密文
+//		范围证明
+//		随机数
+func EncryptIntWithRandomness(pub *sm2.PublicKey, m int64, bits int) (*elgamal.CipherText, *Proof, *big.Int, error) {
+	return encryptInt("EncryptIntWithRandomness", pub, m, bits)
+}
+
+// encryptInt implements EncryptInt, reporting errors for op.
+// EncryptInt的实现，出错时报告操作op。
+func encryptInt(op string, pub *sm2.PublicKey, m int64, bits int) (*elgamal.CipherText, *Proof, *big.Int, error) {
+	if bits <= 0 || bits > MaxBits || m < 0 || m>>uint(bits) != 0 {
+		return nil, nil, nil, opError(op, elgamal.ErrOutOfRange)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, nil, nil, opError(op, err)
+	}
+
+	curve := pub.Curve
+	N := curve.Params().N
+	sum := new(big.Int)
+	p := &Proof{Bits: make([]elgamal.CipherText, bits), Proofs: make([]*proof.OrProof, bits)}
+	for i := 0; i < bits; i++ {
+		// 比特密文：K_i = r_i*B, C_i = r_i*pub + b_i*B
+		r, err := ec.RandFieldElement(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, nil, opError(op, err)
+		}
+		sum.Add(sum, new(big.Int).Lsh(r, uint(i)))
+		b := int((m >> uint(i)) & 1)
+		ct := &p.Bits[i]
+		ct.K.Curve = curve
+		ct.K.X, ct.K.Y = curve.ScalarBaseMult(r.Bytes())
+		ct.C.Curve = curve
+		ct.C.X, ct.C.Y = curve.ScalarMult(pub.X, pub.Y, r.Bytes())
+		if b == 1 {
+			ct.C.X, ct.C.Y = ec.Add(curve, ct.C.X, ct.C.Y, curve.Params().Gx, curve.Params().Gy)
+		}
+
+		rels, err := bitRelations(pub, ct)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if p.Proofs[i], err = proof.ProveOr(bitContext(i), rels, b, []*big.Int{r}); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	ct := weightedSum(curve, p.Bits)
+	return ct, p, sum.Mod(sum, N), nil
+}
+
+// Verify reports whether p proves that ct, encrypted with pub, encrypts an integer
+// in [0, 2^bits).
+// 验证范围证明：判断p是否证明了以公钥pub加密的密文ct所加密的整数位于[0, 2^bits)内。
+//
+// 参数：
+//		公钥	pub
+//		密文	ct
+//		范围证明	p
+//		比特数	bits
+// 返回：
+// 		验证结果
+func Verify(pub *sm2.PublicKey, ct *elgamal.CipherText, p *Proof, bits int) (bool, error) {
+	if bits <= 0 || bits > MaxBits {
+		return false, opError("Verify", elgamal.ErrOutOfRange)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return false, opError("Verify", err)
+	}
+	if err := elgamal.CheckCipherText(ct); err != nil {
+		return false, opError("Verify", err)
+	}
+	if p == nil || len(p.Bits) != bits || len(p.Proofs) != bits {
+		return false, nil
+	}
+
+	for i := range p.Bits {
+		if elgamal.CheckCipherText(&p.Bits[i]) != nil {
+			return false, nil
+		}
+		rels, err := bitRelations(pub, &p.Bits[i])
+		if err != nil {
+			return false, nil
+		}
+		ok, err := proof.VerifyOr(bitContext(i), rels, p.Proofs[i])
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	// 密文须为比特密文的加权和：ct = sum 2^i*ct_i
+	sum := weightedSum(pub.Curve, p.Bits)
+	return sum.K.X.Cmp(ct.K.X) == 0 && sum.K.Y.Cmp(ct.K.Y) == 0 &&
+		sum.C.X.Cmp(ct.C.X) == 0 && sum.C.Y.Cmp(ct.C.Y) == 0, nil
+}
+
+// bitContext binds the proof of bit i to its position.
+// 比特i的证明上下文，与其位置绑定。
+func bitContext(i int) string {
+	return "ppks-range-bit-" + strconv.Itoa(i)
+}
+
+// bitRelations returns the two branches of the proof of a bit ciphertext ct:
+// ct encrypts 0, {K=r*B, C=r*pub}, or ct encrypts 1, {K=r*B, C-B=r*pub}.
+// 返回比特密文ct证明的两个分支：加密0即{K=r*B, C=r*pub}，加密1即{K=r*B, C-B=r*pub}。
+func bitRelations(pub *sm2.PublicKey, ct *elgamal.CipherText) ([]*proof.Relation, error) {
+	curve := pub.Curve
+	B := elgamal.Generator(curve)
+	negB, err := elgamal.NegPoint(B)
+	if err != nil {
+		return nil, opError("bitRelations", err)
+	}
+	C1 := &elgamal.CurvePoint{Curve: curve}
+	C1.X, C1.Y = ec.Add(curve, ct.C.X, ct.C.Y, negB.X, negB.Y)
+	if err := elgamal.CheckPoint(C1); err != nil {
+		return nil, opError("bitRelations", err)
+	}
+	P := (*elgamal.CurvePoint)(pub)
+	return []*proof.Relation{
+		proof.DLEQRelation(B, &ct.K, P, &ct.C),
+		proof.DLEQRelation(B, &ct.K, P, C1),
+	}, nil
+}
+
+// weightedSum returns sum 2^i*cts[i].
+// 返回sum 2^i*cts[i]。
+func weightedSum(curve elliptic.Curve, cts []elgamal.CipherText) *elgamal.CipherText {
+	sum := &elgamal.CipherText{}
+	sum.K.Curve, sum.C.Curve = curve, curve
+	sum.K.X, sum.K.Y = new(big.Int), new(big.Int)
+	sum.C.X, sum.C.Y = new(big.Int), new(big.Int)
+	add := func(P *elgamal.CurvePoint, x, y *big.Int) {
+		if P.X.Sign() == 0 && P.Y.Sign() == 0 {
+			P.X, P.Y = x, y
+			return
+		}
+		P.X, P.Y = ec.Add(curve, P.X, P.Y, x, y)
+	}
+	for i := range cts {
+		w := new(big.Int).Lsh(big.NewInt(1), uint(i)).Bytes()
+		x, y := curve.ScalarMult(cts[i].K.X, cts[i].K.Y, w)
+		add(&sum.K, x, y)
+		x, y = curve.ScalarMult(cts[i].C.X, cts[i].C.Y, w)
+		add(&sum.C, x, y)
+	}
+	return sum
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangeproof
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestRangeProof(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := elgamal.NewDLogTable(priv.Curve, 1<<8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []int64{0, 1, 200, 255} {
+		ct, p, err := EncryptInt(&priv.PublicKey, m, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := Verify(&priv.PublicKey, ct, p, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("range proof of %d failed to verify", m)
+		}
+		// 密文为普通的指数ElGamal密文
+		got, err := elgamal.DecryptInt(priv, ct, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != m {
+			t.Fatalf("decrypted %d, want %d", got, m)
+		}
+	}
+
+	for _, m := range []int64{-1, 256} {
+		if _, _, err := EncryptInt(&priv.PublicKey, m, 8); err == nil {
+			t.Fatalf("expected error for %d outside [0, 256)", m)
+		}
+	}
+}
+
+func TestEncryptIntWithRandomness(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, p, r, err := EncryptIntWithRandomness(&priv.PublicKey, 77, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(&priv.PublicKey, ct, p, 8); err != nil || !ok {
+		t.Fatal("range proof failed to verify")
+	}
+	// K = r*B
+	x, y := priv.Curve.ScalarBaseMult(r.Bytes())
+	if x.Cmp(ct.K.X) != 0 || y.Cmp(ct.K.Y) != 0 {
+		t.Fatal("returned randomness does not match K")
+	}
+}
+
+func TestRangeProofForged(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	ct, p, err := EncryptInt(pub, 77, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 证明不适用于其他密文或其他比特数
+	other, err := elgamal.EncryptInt(pub, 77)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(pub, other, p, 8); err != nil || ok {
+		t.Fatal("range proof verified for another ciphertext")
+	}
+	if ok, err := Verify(pub, ct, p, 7); err != nil || ok {
+		t.Fatal("range proof verified for fewer bits")
+	}
+
+	// 加密2的"比特"无法通过验证
+	two, err := elgamal.EncryptInt(pub, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rels, err := bitRelations(pub, two)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, err := proof.ProveOr(bitContext(0), rels, 1, []*big.Int{big.NewInt(12345)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := &Proof{Bits: []elgamal.CipherText{*two}, Proofs: []*proof.OrProof{fake}}
+	if ok, err := Verify(pub, two, forged, 1); err != nil || ok {
+		t.Fatal("proof for an encryption of 2 verified")
+	}
+
+	// 交换比特顺序后验证失败
+	p.Bits[0], p.Bits[1] = p.Bits[1], p.Bits[0]
+	p.Proofs[0], p.Proofs[1] = p.Proofs[1], p.Proofs[0]
+	if ok, err := Verify(pub, ct, p, 8); err != nil || ok {
+		t.Fatal("range proof verified with reordered bits")
+	}
+}
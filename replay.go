@@ -0,0 +1,54 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/keyswitch"
+)
+
+// NonceStore is where a NonceRegistry records what was served, atomically.
+// 随机数存储：NonceRegistry原子地记录已服务请求的位置。
+type NonceStore = keyswitch.NonceStore
+
+// NonceRegistry keeps a KS server from serving the same rB or session to the same
+// requester twice.
+// 防重放登记：防止ks server向同一请求者重复服务相同的rB或会话。
+type NonceRegistry = keyswitch.NonceRegistry
+
+// NewMemoryNonceStore returns a NonceStore in process memory.
+// It is a wrapper of keyswitch.NewMemoryNonceStore.
+// 创建进程内存中的NonceStore。
+//
+// 参数：
+//
+// 返回：
+// 		随机数存储
+func NewMemoryNonceStore() NonceStore {
+	return keyswitch.NewMemoryNonceStore()
+}
+
+// NewNonceRegistry returns a registry recording into store; a nil store means
+// NewMemoryNonceStore.
+// It is a wrapper of keyswitch.NewNonceRegistry.
+// 创建防重放登记，记录到store；store为nil时使用NewMemoryNonceStore。
+//
+// 参数：
+//		随机数存储	store
+// 返回：
+// 		防重放登记
+func NewNonceRegistry(store NonceStore) *NonceRegistry {
+	return keyswitch.NewNonceRegistry(store)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ristretto
+
+import (
+	"math/big"
+)
+
+// extended is a point in extended twisted Edwards coordinates (X:Y:Z:T), with
+// x = X/Z, y = Y/Z and xy = T/Z.
+// 扩展扭曲Edwards坐标(X:Y:Z:T)下的点，x = X/Z，y = Y/Z，xy = T/Z。
+type extended struct {
+	X, Y, Z, T *big.Int
+}
+
+// identity returns the Edwards identity (0,1).
+// 返回Edwards曲线的单位元(0,1)。
+func identity() *extended {
+	return &extended{X: new(big.Int), Y: big.NewInt(1), Z: big.NewInt(1), T: new(big.Int)}
+}
+
+// fromAffine lifts (x,y) to extended coordinates, mapping (0,0) to the identity.
+// 将(x,y)转换为扩展坐标，(0,0)映射为单位元。
+func fromAffine(x, y *big.Int) *extended {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return identity()
+	}
+	return &extended{X: fe(x), Y: fe(y), Z: big.NewInt(1), T: feMul(x, y)}
+}
+
+// add returns P+Q with the complete formula for a = -1 (add-2008-hwcd-3), which
+// also doubles.
+// 点加：使用a = -1时的完备公式（add-2008-hwcd-3），同样适用于倍点。
+func (P *extended) add(Q *extended) *extended {
+	A := feMul(feSub(P.Y, P.X), feSub(Q.Y, Q.X))
+	B := feMul(feAdd(P.Y, P.X), feAdd(Q.Y, Q.X))
+	C := feMul(feMul(P.T, edwardsD2), Q.T)
+	D := feMul(feAdd(P.Z, P.Z), Q.Z)
+	E, F, G, H := feSub(B, A), feSub(D, C), feAdd(D, C), feAdd(B, A)
+	return &extended{X: feMul(E, F), Y: feMul(G, H), Z: feMul(F, G), T: feMul(E, H)}
+}
+
+// encode returns the ristretto255 encoding of P (RFC 9496, 4.3.2).
+// 返回P的ristretto255编码（RFC 9496 4.3.2节）。
+func (P *extended) encode() []byte {
+	u1 := feMul(feAdd(P.Z, P.Y), feSub(P.Z, P.Y))
+	u2 := feMul(P.X, P.Y)
+	_, invsqrt := sqrtRatioM1(big.NewInt(1), feMul(u1, feMul(u2, u2)))
+	den1 := feMul(invsqrt, u1)
+	den2 := feMul(invsqrt, u2)
+	zInv := feMul(feMul(den1, den2), P.T)
+
+	X, Y, denInv := P.X, P.Y, den2
+	if isNegative(feMul(P.T, zInv)) {
+		X, Y = feMul(P.Y, sqrtM1), feMul(P.X, sqrtM1)
+		denInv = feMul(den1, invSqrtAMinusD)
+	}
+	if isNegative(feMul(X, zInv)) {
+		Y = feNeg(Y)
+	}
+	s := feAbs(feMul(denInv, feSub(P.Z, Y)))
+
+	// 小端序
+	b := make([]byte, EncodingSize)
+	s.FillBytes(b)
+	reverse(b)
+	return b
+}
+
+// decode parses a ristretto255 encoding (RFC 9496, 4.3.1) into the affine
+// coordinates of the canonical representative.
+// 解析ristretto255编码（RFC 9496 4.3.1节），得到规范代表元的仿射坐标。
+func decode(b []byte) (x, y *big.Int, ok bool) {
+	le := make([]byte, len(b))
+	copy(le, b)
+	reverse(le)
+	s := new(big.Int).SetBytes(le)
+	if s.Cmp(fieldP) >= 0 || isNegative(s) {
+		return nil, nil, false
+	}
+
+	ss := feMul(s, s)
+	u1 := feSub(big.NewInt(1), ss)
+	u2 := feAdd(big.NewInt(1), ss)
+	u2sq := feMul(u2, u2)
+	v := feSub(feNeg(feMul(edwardsD, feMul(u1, u1))), u2sq)
+	wasSquare, invsqrt := sqrtRatioM1(big.NewInt(1), feMul(v, u2sq))
+	denX := feMul(invsqrt, u2)
+	denY := feMul(feMul(invsqrt, denX), v)
+
+	x = feAbs(feMul(feAdd(s, s), denX))
+	y = feMul(u1, denY)
+	if !wasSquare || isNegative(feMul(x, y)) || y.Sign() == 0 {
+		return nil, nil, false
+	}
+	return x, y, true
+}
+
+// canonical returns the affine coordinates of the canonical representative of P,
+// the decoding of its encoding, with the identity as (0,0).
+// 返回P的规范代表元（即其编码的解码结果）的仿射坐标，单位元为(0,0)。
+func (P *extended) canonical() (*big.Int, *big.Int) {
+	x, y, _ := decode(P.encode())
+	if x.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	return x, y
+}
+
+// sqrtRatioM1 returns (true, sqrt(u/v)) when u/v is square and (false, sqrt(i*u/v))
+// otherwise, the root being non-negative (RFC 9496, 4.2).
+// u/v为平方数时返回(true, sqrt(u/v))，否则返回(false, sqrt(i*u/v))，平方根取非负值（RFC 9496 4.2节）。
+func sqrtRatioM1(u, v *big.Int) (bool, *big.Int) {
+	v3 := feMul(feMul(v, v), v)
+	v7 := feMul(feMul(v3, v3), v)
+	r := feMul(feMul(u, v3), new(big.Int).Exp(feMul(u, v7), expP58, fieldP))
+	check := feMul(v, feMul(r, r))
+
+	correctSign := check.Cmp(fe(u)) == 0
+	flippedSign := check.Cmp(feNeg(u)) == 0
+	flippedSignI := check.Cmp(feMul(feNeg(u), sqrtM1)) == 0
+	if flippedSign || flippedSignI {
+		r = feMul(r, sqrtM1)
+	}
+	return correctSign || flippedSign, feAbs(r)
+}
+
+// 域运算：结果均约简到[0,p)
+
+func fe(a *big.Int) *big.Int { return new(big.Int).Mod(a, fieldP) }
+
+func feAdd(a, b *big.Int) *big.Int { return fe(new(big.Int).Add(a, b)) }
+
+func feSub(a, b *big.Int) *big.Int { return fe(new(big.Int).Sub(a, b)) }
+
+func feMul(a, b *big.Int) *big.Int { return fe(new(big.Int).Mul(a, b)) }
+
+func feNeg(a *big.Int) *big.Int { return fe(new(big.Int).Neg(a)) }
+
+// isNegative reports whether a, reduced mod p, is odd.
+// 判断a模p后是否为奇数，即是否为"负"。
+func isNegative(a *big.Int) bool { return fe(a).Bit(0) == 1 }
+
+// feAbs returns the non-negative one of a and -a.
+// 返回a与-a中非负的一个。
+func feAbs(a *big.Int) *big.Int {
+	if isNegative(a) {
+		return feNeg(a)
+	}
+	return fe(a)
+}
+
+// reverse reverses b in place, converting between big- and little-endian.
+// 原地反转b，用于大小端转换。
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
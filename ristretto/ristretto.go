@@ -0,0 +1,245 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ristretto provides the ristretto255 group (RFC 9496) as an alternative
+// backend for deployments outside the GM regulatory scope. The group is presented
+// through the elliptic.Curve interface, so keys, points and ciphertexts built on
+// Curve() work unchanged with packages elgamal, proof and keyswitch.
+//
+// Points are kept as the affine Edwards coordinates of a canonical representative:
+// every element of the prime order group has exactly one representation, so points
+// compare by their coordinates as they do on SM2, and there is no cofactor to clear.
+// The identity is (0,0) like on SM2. The arithmetic is built on math/big and is not
+// constant time.
+// ristretto255群（RFC 9496）：为国密监管范围之外的部署提供的另一种群后端。该群以elliptic.Curve
+// 接口呈现，基于Curve()构造的密钥、点与密文可直接用于elgamal、proof与keyswitch包。
+//
+// 点以规范代表元的Edwards仿射坐标保存：素数阶群中每个元素只有一种表示，因此与SM2一样可按坐标比较，
+// 且无需处理余因子。单位元与SM2一样表示为(0,0)。运算基于math/big实现，非常数时间。
+package ristretto
+
+import (
+	"crypto/elliptic"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// EncodingSize is the length of an encoded element.
+// 群元素编码的字节长度。
+const EncodingSize = 32
+
+// Suite is the proof suite of ristretto255 deployments: SHA-256 challenges, so no
+// GM algorithm is involved.
+// ristretto255部署使用的证明参数组：以SHA-256计算挑战值，不涉及国密算法。
+var Suite = proof.Suite{Hash: proof.HashSHA256}
+
+// 域与群参数
+var (
+	// p = 2^255 - 19
+	fieldP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	// l = 2^252 + 27742317777372353535851937790883648493
+	order, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+	// d = -121665/121666
+	edwardsD  = fe(new(big.Int).Mul(big.NewInt(-121665), new(big.Int).ModInverse(big.NewInt(121666), fieldP)))
+	edwardsD2 = feMul(edwardsD, big.NewInt(2))
+	// sqrt(-1)
+	sqrtM1 = feAbs(new(big.Int).Exp(big.NewInt(2), new(big.Int).Rsh(new(big.Int).Sub(fieldP, big.NewInt(1)), 2), fieldP))
+	// 1/sqrt(a-d)，a = -1
+	_, invSqrtAMinusD = sqrtRatioM1(big.NewInt(1), fe(new(big.Int).Sub(big.NewInt(-1), edwardsD)))
+	// (p-5)/8
+	expP58 = new(big.Int).Rsh(new(big.Int).Sub(fieldP, big.NewInt(5)), 3)
+
+	group = newCurve()
+)
+
+// Curve returns the ristretto255 group. Its Params carry the field prime P, the
+// group order N and the canonical base point; B holds the Edwards constant d and
+// is not a Weierstrass coefficient, so compressed SEC 1 encodings do not apply.
+// 返回ristretto255群。其Params给出域素数P、群的阶N与规范基点；B为Edwards曲线常数d，
+// 并非Weierstrass系数，因此不适用SEC 1压缩编码。
+func Curve() elliptic.Curve {
+	return group
+}
+
+// curve implements elliptic.Curve for ristretto255.
+// ristretto255的elliptic.Curve实现。
+type curve struct {
+	params *elliptic.CurveParams
+}
+
+func newCurve() *curve {
+	// Ed25519基点：y = 4/5，x取非负的根
+	y := feMul(big.NewInt(4), new(big.Int).ModInverse(big.NewInt(5), fieldP))
+	yy := feMul(y, y)
+	_, x := sqrtRatioM1(fe(new(big.Int).Sub(yy, big.NewInt(1))), fe(new(big.Int).Add(feMul(edwardsD, yy), big.NewInt(1))))
+	Gx, Gy := fromAffine(x, y).canonical()
+
+	return &curve{params: &elliptic.CurveParams{
+		P:       fieldP,
+		N:       order,
+		B:       edwardsD,
+		Gx:      Gx,
+		Gy:      Gy,
+		BitSize: 255,
+		Name:    "ristretto255",
+	}}
+}
+
+// Params returns the parameters of the group.
+// 返回群参数。
+func (c *curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+// IsOnCurve reports whether (x,y) is the canonical representative of a group
+// element other than the identity.
+// 判断(x,y)是否为单位元以外的群元素的规范代表元。
+func (c *curve) IsOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(fieldP) >= 0 || y.Sign() < 0 || y.Cmp(fieldP) >= 0 {
+		return false
+	}
+
+	// -x^2 + y^2 = 1 + d*x^2*y^2
+	xx, yy := feMul(x, x), feMul(y, y)
+	lhs := fe(new(big.Int).Sub(yy, xx))
+	rhs := fe(new(big.Int).Add(big.NewInt(1), feMul(edwardsD, feMul(xx, yy))))
+	if lhs.Cmp(rhs) != 0 {
+		return false
+	}
+	cx, cy := fromAffine(x, y).canonical()
+	return cx.Cmp(x) == 0 && cy.Cmp(y) == 0 && !(cx.Sign() == 0 && cy.Sign() == 0)
+}
+
+// Add returns (x1,y1)+(x2,y2).
+// 点加。
+func (c *curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	return fromAffine(x1, y1).add(fromAffine(x2, y2)).canonical()
+}
+
+// Double returns 2(x1,y1).
+// 倍点。
+func (c *curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	P := fromAffine(x1, y1)
+	return P.add(P).canonical()
+}
+
+// ScalarMult returns k(x1,y1), k in big-endian form.
+// 数乘：返回k(x1,y1)，k为大端字节。
+func (c *curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	P := fromAffine(x1, y1)
+	Q := identity()
+	for _, b := range k {
+		for i := 7; i >= 0; i-- {
+			Q = Q.add(Q)
+			if b>>uint(i)&1 == 1 {
+				Q = Q.add(P)
+			}
+		}
+	}
+	return Q.canonical()
+}
+
+// ScalarBaseMult returns kG, k in big-endian form.
+// 基点数乘：返回kG，k为大端字节。
+func (c *curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+// Neg returns -(x,y), used by ec.Neg in place of the Weierstrass (x, P-y).
+// 点取负，供ec.Neg使用，以替代Weierstrass曲线的(x, P-y)。
+func (c *curve) Neg(x, y *big.Int) (*big.Int, *big.Int) {
+	return fromAffine(fe(new(big.Int).Neg(x)), y).canonical()
+}
+
+// Encode returns the 32-byte ristretto255 encoding of p, a finite point of Curve().
+// 编码：返回Curve()上有限点p的32字节ristretto255编码。
+//
+// 参数：
+//		点	p
+// 返回：
+// 		编码
+func Encode(p *elgamal.CurvePoint) ([]byte, error) {
+	if err := elgamal.CheckPoint(p); err != nil {
+		return nil, opError("Encode", err)
+	}
+	if p.Curve != group {
+		return nil, opError("Encode", elgamal.ErrCurveMismatch)
+	}
+	return fromAffine(p.X, p.Y).encode(), nil
+}
+
+// Decode parses a 32-byte ristretto255 encoding. Non-canonical encodings and the
+// identity are rejected.
+// 解码：解析32字节的ristretto255编码，拒绝非规范编码及单位元。
+//
+// 参数：
+//		编码	b
+// 返回：
+// 		点
+func Decode(b []byte) (*elgamal.CurvePoint, error) {
+	if len(b) != EncodingSize {
+		return nil, opError("Decode", elgamal.ErrInvalidPointEncoding)
+	}
+	x, y, ok := decode(b)
+	if !ok {
+		return nil, opError("Decode", elgamal.ErrInvalidPointEncoding)
+	}
+	if x.Sign() == 0 {
+		return nil, opError("Decode", elgamal.ErrPointNotOnCurve)
+	}
+	return elgamal.NewCurvePointFromXY(group, x, y)
+}
+
+// GenerateKey generates a ristretto255 key pair for PPKS, in the sm2 key types the
+// other packages take. Such keys must not be used with SM2 signatures or encryption.
+// 生成密钥：生成用于PPKS的ristretto255密钥对，采用其他包所用的sm2密钥类型。
+// 此类密钥不可用于SM2签名或加密。
+//
+// 参数：
+//		随机源	random，为nil时使用crypto/rand
+// 返回：
+// 		私钥
+func GenerateKey(random io.Reader) (*sm2.PrivateKey, error) {
+	k, err := ec.RandFieldElement(group, random)
+	if err != nil {
+		return nil, opError("GenerateKey", err)
+	}
+	priv := new(sm2.PrivateKey)
+	priv.Curve = group
+	priv.D = k
+	priv.X, priv.Y = group.ScalarBaseMult(k.Bytes())
+	return priv, nil
+}
+
+// GenPoint generates a ristretto255 point at random.
+// 生成点：随机生成一个ristretto255点并返回。
+//
+// 参数：
+//
+// 返回：
+// 		点
+func GenPoint() (*elgamal.CurvePoint, error) {
+	priv, err := GenerateKey(nil)
+	if err != nil {
+		return nil, opError("GenPoint", err)
+	}
+	return (*elgamal.CurvePoint)(&priv.PublicKey), nil
+}
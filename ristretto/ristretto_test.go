@@ -0,0 +1,155 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ristretto
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 基点的小倍数及其编码，取自RFC 9496附录A.1
+var multiples = []string{
+	"e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76",
+	"6a493210f7499cd17fecb510ae0cea23a110e8d5b901f8acadd3095c73a3b919",
+	"94741f5d5d52755ece4f23f044ee27d5d1ea1e2bd196b462166b16152a9d0259",
+	"da80862773358b466ffadfe0b3293ab3d9fd53c5ea6c955358f568322daf6a57",
+}
+
+func TestMultiples(t *testing.T) {
+	curve := Curve()
+	for i, want := range multiples {
+		P := elgamal.BaseMultiple(curve, int64(i+1))
+		b, err := Encode(P)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(b) != want {
+			t.Fatalf("%dG encodes to %x, want %s", i+1, b, want)
+		}
+		Q, err := Decode(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Q.X.Cmp(P.X) != 0 || Q.Y.Cmp(P.Y) != 0 {
+			t.Fatalf("%dG does not round-trip", i+1)
+		}
+	}
+
+	// 逐次点加与数乘一致，nG为单位元
+	G := elgamal.Generator(curve)
+	x, y := curve.Add(G.X, G.Y, G.X, G.Y)
+	if b, _ := Encode(&elgamal.CurvePoint{Curve: curve, X: x, Y: y}); hex.EncodeToString(b) != multiples[1] {
+		t.Fatal("G+G differs from 2G")
+	}
+	if !elgamal.BaseMultiple(curve, 0).IsInfinity() {
+		t.Fatal("0G is not the identity")
+	}
+	x, y = curve.ScalarBaseMult(curve.Params().N.Bytes())
+	if x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("NG is not the identity")
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	bad := []string{
+		// 单位元
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		// 非规范域元素
+		"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		// 负的域元素
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		// 非平方数
+		"26948d35ca62e643e26a83177332e6b6afeb9d08e4268b650f1f5bbd8d81d371",
+	}
+	for _, s := range bad {
+		b, _ := hex.DecodeString(s)
+		if _, err := Decode(b); err == nil {
+			t.Fatalf("decoded invalid encoding %s", s)
+		}
+	}
+	if _, err := Decode(make([]byte, 31)); !errors.Is(err, elgamal.ErrInvalidPointEncoding) {
+		t.Fatalf("got %v, want ErrInvalidPointEncoding", err)
+	}
+
+	// 同一陪集中的非规范代表元不在曲线上
+	G := elgamal.Generator(Curve())
+	if Curve().IsOnCurve(fe(G.X.Neg(G.X)), fe(G.Y.Neg(G.Y))) {
+		t.Fatal("non-canonical representative accepted")
+	}
+}
+
+func TestKeySwitch(t *testing.T) {
+	var privs [3]*sm2.PrivateKey
+	for i := range privs {
+		priv, err := GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+	}
+	target, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := keyswitch.AggregatePubKeys([]*sm2.PublicKey{&privs[0].PublicKey, &privs[1].PublicKey, &privs[2].PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	D, err := GenPoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := elgamal.PointEncrypt(pub, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := make(elgamal.CipherVector, len(privs))
+	for i, priv := range privs {
+		share, ri, err := keyswitch.ShareCal(&target.PublicKey, &ct.K, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, r1, r2, err := keyswitch.ShareProofGenNoB(ri, priv, share, &target.PublicKey, &ct.K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := keyswitch.ShareProofVryNoB(c, r1, r2, share, &priv.PublicKey, &target.PublicKey, &ct.K)
+		if err != nil || !ok {
+			t.Fatalf("share proof %d failed: %v", i, err)
+		}
+		shares[i] = *share
+	}
+
+	switched, err := keyswitch.ShareReplace(&shares, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := elgamal.PointDecrypt(switched, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.X.Cmp(D.X) != 0 || got.Y.Cmp(D.Y) != 0 {
+		t.Fatal("switched ciphertext decrypts to another point")
+	}
+}
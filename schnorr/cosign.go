@@ -0,0 +1,281 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schnorr
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Co-signatures let n signers produce one Signature on msg that Verify accepts
+// under AggregateKeys of their keys, in the manner of MuSig: every key is weighted
+// by a coefficient bound to the whole key set, so no signer can choose its key to
+// cancel the others, and nonces are committed to before any is revealed.
+// 联合签名使n个签名者对消息msg生成一个签名，Verify以其公钥的AggregateKeys即可验证，方式同MuSig：
+// 每个公钥乘以与整个公钥集合绑定的系数，签名者无法构造公钥以抵消他人；随机数在公开前先行承诺。
+
+// musigProtocol is the transcript protocol label of key aggregation coefficients.
+// 公钥聚合系数所用记录的协议标签。
+const musigProtocol = "ppks-musig"
+
+// nonceDomain separates nonce commitments from other hashes.
+// 随机数承诺的域分隔标签。
+const nonceDomain = "ppks-musig-nonce"
+
+// AggregateKeys returns the co-signing key of pubs, sum(a_i*P_i) with the
+// coefficients a_i = H(pubs, P_i). The order of pubs matters.
+// 聚合公钥：返回pubs的联合签名公钥sum(a_i*P_i)，系数a_i = H(pubs, P_i)。pubs的顺序影响结果。
+//
+// 参数：
+//		公钥slice	pubs
+// 返回：
+// 		联合签名公钥
+func AggregateKeys(pubs []*sm2.PublicKey) (*sm2.PublicKey, error) {
+	if err := checkRing(pubs); err != nil {
+		return nil, opError("AggregateKeys", err)
+	}
+	curve := pubs[0].Curve
+	var x, y *big.Int
+	for i, P := range pubs {
+		ax, ay := curve.ScalarMult(P.X, P.Y, keyCoefficient(pubs, i).Bytes())
+		if i == 0 {
+			x, y = ax, ay
+		} else {
+			x, y = ec.Add(curve, x, y, ax, ay)
+		}
+	}
+	pub := new(sm2.PublicKey)
+	pub.Curve = curve
+	pub.X, pub.Y = x, y
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, opError("AggregateKeys", err)
+	}
+	return pub, nil
+}
+
+// keyCoefficient returns a_i = H(pubs, P_i) modulo the order of the curve.
+// 返回a_i = H(pubs, P_i)模曲线的阶。
+func keyCoefficient(pubs []*sm2.PublicKey, i int) *big.Int {
+	t := proof.NewTranscript(musigProtocol)
+	t.AppendScalar("n", big.NewInt(int64(len(pubs))))
+	for _, P := range pubs {
+		t.AppendPoint("P", (*elgamal.CurvePoint)(P))
+	}
+	t.AppendPoint("Pi", (*elgamal.CurvePoint)(pubs[i]))
+	a := t.ChallengeScalar("a")
+	return a.Mod(a, pubs[i].Curve.Params().N)
+}
+
+// CoSigner is one signer's state in a co-signature on msg by pubs. It is used in
+// three rounds: publish Commitment, publish Nonce once all commitments are in, then
+// Sign once all nonces are in; a CoSigner signs only once.
+// 联合签名者：pubs对消息msg联合签名时某一签名者的状态。分三轮使用：公布Commitment，收齐全部承诺后
+// 公布Nonce，收齐全部随机数点后调用Sign。每个CoSigner只签名一次。
+type CoSigner struct {
+	priv  *sm2.PrivateKey
+	pubs  []*sm2.PublicKey
+	index int
+	msg   []byte
+	k     *big.Int
+	R     *elgamal.CurvePoint
+}
+
+// NewCoSigner starts a co-signature of msg by pubs with priv, whose key must be in
+// pubs.
+// 新建联合签名者：以私钥priv（其公钥须在pubs中）参与pubs对消息msg的联合签名。
+//
+// 参数：
+//		私钥		priv
+//		公钥slice	pubs
+//		消息		msg
+// 返回：
+// 		联合签名者
+func NewCoSigner(priv *sm2.PrivateKey, pubs []*sm2.PublicKey, msg []byte) (*CoSigner, error) {
+	if priv == nil || priv.D == nil || priv.D.Sign() <= 0 {
+		return nil, opError("NewCoSigner", elgamal.ErrOutOfRange)
+	}
+	if err := checkRing(pubs); err != nil {
+		return nil, opError("NewCoSigner", err)
+	}
+	index := -1
+	for i, P := range pubs {
+		if P.X.Cmp(priv.X) == 0 && P.Y.Cmp(priv.Y) == 0 {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, opError("NewCoSigner", ErrNotInRing)
+	}
+	curve := priv.Curve
+	k, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, opError("NewCoSigner", err)
+	}
+	R := &elgamal.CurvePoint{Curve: curve}
+	R.X, R.Y = curve.ScalarBaseMult(k.Bytes())
+	return &CoSigner{
+		priv:  priv,
+		pubs:  append([]*sm2.PublicKey(nil), pubs...),
+		index: index,
+		msg:   append([]byte(nil), msg...),
+		k:     k,
+		R:     R,
+	}, nil
+}
+
+// Commitment returns the commitment to the nonce of s, to publish in round one.
+// 返回s的随机数点承诺，于第一轮公布。
+func (s *CoSigner) Commitment() []byte {
+	return nonceCommitment(s.R)
+}
+
+// Nonce returns the nonce point of s, to publish in round two.
+// 返回s的随机数点，于第二轮公布。
+func (s *CoSigner) Nonce() *elgamal.CurvePoint {
+	return s.R
+}
+
+// Sign checks the nonces of all signers against their commitments, in the order of
+// pubs, and returns the partial signature of s. A nonce that does not match fails
+// with ErrNonceMismatch for its signer, and a second call with ErrNonceUsed.
+// 部分签名：按pubs的顺序检查各签名者的随机数点与其承诺相符，返回s的部分签名。随机数点不符时
+// 返回该签名者的ErrNonceMismatch，重复调用时返回ErrNonceUsed。
+//
+// 参数：
+//		承诺slice		commitments
+//		随机数点slice	nonces
+// 返回：
+// 		部分签名
+func (s *CoSigner) Sign(commitments [][]byte, nonces []*elgamal.CurvePoint) (*big.Int, error) {
+	if s.k == nil {
+		return nil, opError("CoSigner.Sign", ErrNonceUsed)
+	}
+	if len(commitments) != len(s.pubs) || len(nonces) != len(s.pubs) {
+		return nil, opError("CoSigner.Sign", elgamal.ErrLengthMismatch)
+	}
+	for i, R := range nonces {
+		if elgamal.CheckPoint(R) != nil || !hmac.Equal(nonceCommitment(R), commitments[i]) {
+			return nil, itemError("CoSigner.Sign", "signer", i, ErrNonceMismatch)
+		}
+	}
+	if nonces[s.index].X.Cmp(s.R.X) != 0 || nonces[s.index].Y.Cmp(s.R.Y) != 0 {
+		return nil, itemError("CoSigner.Sign", "signer", s.index, ErrNonceMismatch)
+	}
+	e, err := coChallenge("CoSigner.Sign", s.pubs, s.msg, nonces)
+	if err != nil {
+		return nil, err
+	}
+
+	// s_i = k_i + e*a_i*x_i mod N
+	N := s.priv.Curve.Params().N
+	si := new(big.Int).Mod(e, N)
+	si.Mul(si, keyCoefficient(s.pubs, s.index))
+	si.Mul(si, s.priv.D)
+	si.Add(si, s.k)
+	si.Mod(si, N)
+	ec.WipeInt(s.k)
+	s.k = nil
+	return si, nil
+}
+
+// CombineCoSignatures checks the partial signatures of pubs on msg with the nonces
+// and returns the co-signature, which Verify accepts under AggregateKeys(pubs). A
+// partial signature that does not verify fails with ErrInvalidSignature for its
+// signer.
+// 合并联合签名：以各随机数点检查pubs对消息msg的部分签名，返回联合签名，Verify以AggregateKeys(pubs)即可验证。
+// 部分签名验证未通过时返回该签名者的ErrInvalidSignature。
+//
+// 参数：
+//		公钥slice		pubs
+//		消息			msg
+//		随机数点slice	nonces
+//		部分签名slice	partials
+// 返回：
+// 		联合签名
+func CombineCoSignatures(pubs []*sm2.PublicKey, msg []byte, nonces []*elgamal.CurvePoint, partials []*big.Int) (*Signature, error) {
+	if err := checkRing(pubs); err != nil {
+		return nil, opError("CombineCoSignatures", err)
+	}
+	if len(nonces) != len(pubs) || len(partials) != len(pubs) {
+		return nil, opError("CombineCoSignatures", elgamal.ErrLengthMismatch)
+	}
+	for i, R := range nonces {
+		if err := elgamal.CheckPoint(R); err != nil {
+			return nil, itemError("CombineCoSignatures", "signer", i, err)
+		}
+	}
+	e, err := coChallenge("CombineCoSignatures", pubs, msg, nonces)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := pubs[0].Curve
+	N := curve.Params().N
+	em := new(big.Int).Mod(e, N)
+	S := new(big.Int)
+	for i, si := range partials {
+		if si == nil || si.Sign() < 0 || si.Cmp(N) >= 0 {
+			return nil, itemError("CombineCoSignatures", "signer", i, ErrInvalidSignature)
+		}
+		// s_i*B = R_i + e*a_i*P_i
+		c := new(big.Int).Mul(em, keyCoefficient(pubs, i))
+		c.Mod(c, N)
+		lx, ly := curve.ScalarBaseMult(si.Bytes())
+		px, py := curve.ScalarMult(pubs[i].X, pubs[i].Y, c.Bytes())
+		rx, ry := ec.Add(curve, nonces[i].X, nonces[i].Y, px, py)
+		if lx.Cmp(rx) != 0 || ly.Cmp(ry) != 0 {
+			return nil, itemError("CombineCoSignatures", "signer", i, ErrInvalidSignature)
+		}
+		S.Add(S, si)
+	}
+	return &Signature{E: e, S: S.Mod(S, N)}, nil
+}
+
+// coChallenge returns the challenge H(AggregateKeys(pubs), sum(R_i), msg) of Verify.
+// 返回Verify所用的挑战值H(AggregateKeys(pubs), sum(R_i), msg)。
+func coChallenge(op string, pubs []*sm2.PublicKey, msg []byte, nonces []*elgamal.CurvePoint) (*big.Int, error) {
+	P, err := AggregateKeys(pubs)
+	if err != nil {
+		return nil, opError(op, err)
+	}
+	curve := P.Curve
+	R := &elgamal.CurvePoint{Curve: curve, X: nonces[0].X, Y: nonces[0].Y}
+	for _, Ri := range nonces[1:] {
+		R.X, R.Y = ec.Add(curve, R.X, R.Y, Ri.X, Ri.Y)
+	}
+	if R.IsInfinity() {
+		return nil, opError(op, ErrNonceMismatch)
+	}
+	return challenge((*elgamal.CurvePoint)(P), R, msg), nil
+}
+
+// nonceCommitment returns SM3(domain || R).
+// 返回SM3(domain || R)。
+func nonceCommitment(R *elgamal.CurvePoint) []byte {
+	h := sm3.New()
+	h.Write([]byte(nonceDomain))
+	h.Write(R.Bytes())
+	return h.Sum(nil)
+}
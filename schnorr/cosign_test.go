@@ -0,0 +1,107 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schnorr
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestCoSignature(t *testing.T) {
+	var privs []*sm2.PrivateKey
+	var pubs []*sm2.PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, &priv.PublicKey)
+	}
+	msg := []byte("switched ciphertext")
+
+	signers := make([]*CoSigner, len(privs))
+	commitments := make([][]byte, len(privs))
+	nonces := make([]*elgamal.CurvePoint, len(privs))
+	for i, priv := range privs {
+		s, err := NewCoSigner(priv, pubs, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers[i] = s
+		commitments[i] = s.Commitment()
+	}
+	for i, s := range signers {
+		nonces[i] = s.Nonce()
+	}
+	partials := make([]*big.Int, len(privs))
+	for i, s := range signers {
+		p, err := s.Sign(commitments, nonces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials[i] = p
+	}
+	if _, err := signers[0].Sign(commitments, nonces); !errors.Is(err, ErrNonceUsed) {
+		t.Fatalf("got %v, want ErrNonceUsed", err)
+	}
+
+	sig, err := CombineCoSignatures(pubs, msg, nonces, partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agg, err := AggregateKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(agg, msg, sig); err != nil || !ok {
+		t.Fatalf("co-signature failed to verify: %v", err)
+	}
+	if ok, _ := Verify(agg, []byte("other"), sig); ok {
+		t.Fatal("co-signature verified for another message")
+	}
+
+	// 错误的部分签名可归咎于其签名者
+	partials[1] = new(big.Int).Add(partials[1], big.NewInt(1))
+	var e *elgamal.Error
+	if _, err := CombineCoSignatures(pubs, msg, nonces, partials); !errors.Is(err, ErrInvalidSignature) || !errors.As(err, &e) || e.Index != 1 {
+		t.Fatalf("got %v, want ErrInvalidSignature of signer 1", err)
+	}
+
+	// 公布的随机数点与承诺不符
+	s, err := NewCoSigner(privs[0], pubs, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonces[2] = elgamal.GenPoint()
+	if _, err := s.Sign(commitments, nonces); !errors.Is(err, ErrNonceMismatch) {
+		t.Fatalf("got %v, want ErrNonceMismatch", err)
+	}
+
+	outsider, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCoSigner(outsider, pubs, msg); !errors.Is(err, ErrNotInRing) {
+		t.Fatalf("got %v, want ErrNotInRing", err)
+	}
+}
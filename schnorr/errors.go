@@ -0,0 +1,45 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schnorr
+
+import (
+	"errors"
+
+	"ppks/elgamal"
+)
+
+var (
+	// ErrInvalidSignature 签名编码格式错误。
+	ErrInvalidSignature = errors.New("invalid signature encoding")
+	// ErrNotInRing 签名者的公钥不在公钥环中。
+	ErrNotInRing = errors.New("signer not in ring")
+	// ErrNonceMismatch 联合签名的随机数点与其承诺不符。
+	ErrNonceMismatch = errors.New("nonce does not match commitment")
+	// ErrNonceUsed 联合签名者已签名，随机数不可重用。
+	ErrNonceUsed = errors.New("nonce already used")
+)
+
+// opError wraps err with the failing operation.
+// 以出错的操作包装err。
+func opError(op string, err error) error {
+	return &elgamal.Error{Op: op, Err: err}
+}
+
+// itemError wraps err with the failing operation and the index of the failing element.
+// 以出错的操作及出错元素的下标包装err。
+func itemError(op, item string, index int, err error) error {
+	return &elgamal.Error{Op: op, Item: item, Index: index, Err: err}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ringProtocol is the transcript protocol label of ring signature challenges.
+// 环签名挑战值所用记录的协议标签。
+const ringProtocol = "ppks-ring"
+
+// ringScopeDomain is the hash-to-curve domain of the base of key images.
+// 密钥镜像基点所用的哈希到曲线域分隔标签。
+var ringScopeDomain = []byte("ppks-ring-scope")
+
+// RingSignature is a linkable ring signature (LSAG): it shows that one of the keys
+// of a ring signed, without telling which. Tag is the key image x*H(scope) of the
+// signer's key x: two signatures with one scope by the same signer share it, while
+// tags of different scopes are unlinkable.
+// 可链接环签名（LSAG）：证明环中某一公钥的持有者签名，而不透露是哪一个。Tag为签名者私钥x的密钥镜像
+// x*H(scope)：同一签名者在同一范围scope内的两个签名具有相同的Tag，不同范围的Tag则不可关联。
+type RingSignature struct {
+	C   *big.Int
+	S   []*big.Int
+	Tag *elgamal.CurvePoint
+}
+
+// RingSign signs msg in scope with priv on behalf of ring, which must contain the
+// public key of priv.
+// 环签名：以私钥priv代表公钥环ring在范围scope内对消息msg签名，ring须包含priv的公钥。
+//
+// 参数：
+//		私钥	priv
+//		公钥环	ring
+//		范围	scope
+//		消息	msg
+// 返回：
+// 		环签名
+func RingSign(priv *sm2.PrivateKey, ring []*sm2.PublicKey, scope, msg []byte) (*RingSignature, error) {
+	if priv == nil || priv.D == nil || priv.D.Sign() <= 0 {
+		return nil, opError("RingSign", elgamal.ErrOutOfRange)
+	}
+	if err := checkRing(ring); err != nil {
+		return nil, opError("RingSign", err)
+	}
+	me := -1
+	for i, P := range ring {
+		if P.X.Cmp(priv.X) == 0 && P.Y.Cmp(priv.Y) == 0 {
+			me = i
+			break
+		}
+	}
+	if me < 0 {
+		return nil, opError("RingSign", ErrNotInRing)
+	}
+	H, err := elgamal.HashToPoint(ringScopeDomain, scope)
+	if err != nil {
+		return nil, opError("RingSign", err)
+	}
+
+	curve := priv.Curve
+	N := curve.Params().N
+	n := len(ring)
+	I := &elgamal.CurvePoint{Curve: curve}
+	I.X, I.Y = curve.ScalarMult(H.X, H.Y, priv.D.Bytes())
+
+	// L = aB，R = aH
+	a, err := ec.RandFieldElement(curve, rand.Reader)
+	if err != nil {
+		return nil, opError("RingSign", err)
+	}
+	L := &elgamal.CurvePoint{Curve: curve}
+	L.X, L.Y = curve.ScalarBaseMult(a.Bytes())
+	R := &elgamal.CurvePoint{Curve: curve}
+	R.X, R.Y = curve.ScalarMult(H.X, H.Y, a.Bytes())
+
+	c := make([]*big.Int, n)
+	s := make([]*big.Int, n)
+	c[(me+1)%n] = ringChallenge(ring, scope, msg, I, L, R)
+	for k := 1; k < n; k++ {
+		i := (me + k) % n
+		if s[i], err = ec.RandFieldElement(curve, rand.Reader); err != nil {
+			return nil, opError("RingSign", err)
+		}
+		L, R = ringStep(ring[i], H, I, s[i], c[i])
+		c[(i+1)%n] = ringChallenge(ring, scope, msg, I, L, R)
+	}
+
+	// s = a - c*x mod N
+	s[me] = new(big.Int).Mul(c[me], priv.D)
+	s[me].Sub(a, s[me])
+	s[me].Mod(s[me], N)
+	ec.WipeInt(a)
+	return &RingSignature{C: c[0], S: s, Tag: I}, nil
+}
+
+// RingVerify reports whether sig is a ring signature of msg in scope on behalf of
+// ring.
+// 环签名验证：判断sig是否为代表公钥环ring在范围scope内对消息msg的环签名。
+//
+// 参数：
+//		公钥环	ring
+//		范围	scope
+//		消息	msg
+//		环签名	sig
+// 返回：
+// 		验证结果
+func RingVerify(ring []*sm2.PublicKey, scope, msg []byte, sig *RingSignature) (bool, error) {
+	if err := checkRing(ring); err != nil {
+		return false, opError("RingVerify", err)
+	}
+	if sig == nil || sig.C == nil || len(sig.S) != len(ring) || elgamal.CheckPoint(sig.Tag) != nil {
+		return false, nil
+	}
+	curve := ring[0].Curve
+	N := curve.Params().N
+	if sig.Tag.Curve.Params() != curve.Params() || sig.C.Sign() < 0 {
+		return false, nil
+	}
+	for _, s := range sig.S {
+		if s == nil || s.Sign() < 0 || s.Cmp(N) >= 0 {
+			return false, nil
+		}
+	}
+	H, err := elgamal.HashToPoint(ringScopeDomain, scope)
+	if err != nil {
+		return false, opError("RingVerify", err)
+	}
+
+	c := sig.C
+	for i, P := range ring {
+		L, R := ringStep(P, H, sig.Tag, sig.S[i], c)
+		c = ringChallenge(ring, scope, msg, sig.Tag, L, R)
+	}
+	return c.Cmp(sig.C) == 0, nil
+}
+
+// Linked reports whether sig and other were made by the same signer, given that
+// both verify for the same scope.
+// 判断sig与other是否由同一签名者生成，前提是二者均在同一范围内验证通过。
+//
+// 参数：
+//		环签名	other
+// 返回：
+// 		是否关联
+func (sig *RingSignature) Linked(other *RingSignature) bool {
+	if sig.Tag == nil || other == nil || other.Tag == nil {
+		return false
+	}
+	return sig.Tag.X.Cmp(other.Tag.X) == 0 && sig.Tag.Y.Cmp(other.Tag.Y) == 0
+}
+
+// ringStep returns L = sB + cP and R = sH + cI.
+// 返回L = sB + cP与R = sH + cI。
+func ringStep(P *sm2.PublicKey, H, I *elgamal.CurvePoint, s, c *big.Int) (*elgamal.CurvePoint, *elgamal.CurvePoint) {
+	curve := P.Curve
+	cm := new(big.Int).Mod(c, curve.Params().N).Bytes()
+	L := &elgamal.CurvePoint{Curve: curve}
+	sx, sy := curve.ScalarBaseMult(s.Bytes())
+	cx, cy := curve.ScalarMult(P.X, P.Y, cm)
+	L.X, L.Y = ec.Add(curve, sx, sy, cx, cy)
+	R := &elgamal.CurvePoint{Curve: curve}
+	sx, sy = curve.ScalarMult(H.X, H.Y, s.Bytes())
+	cx, cy = curve.ScalarMult(I.X, I.Y, cm)
+	R.X, R.Y = ec.Add(curve, sx, sy, cx, cy)
+	return L, R
+}
+
+// ringChallenge returns H(ring, scope, I, msg, L, R).
+// 计算挑战值H(ring, scope, I, msg, L, R)。
+func ringChallenge(ring []*sm2.PublicKey, scope, msg []byte, I, L, R *elgamal.CurvePoint) *big.Int {
+	t := proof.NewTranscript(ringProtocol)
+	t.AppendScalar("n", big.NewInt(int64(len(ring))))
+	for _, P := range ring {
+		t.AppendPoint("P", (*elgamal.CurvePoint)(P))
+	}
+	t.AppendMessage("scope", scope)
+	t.AppendPoint("I", I)
+	t.AppendMessage("msg", msg)
+	t.AppendPoint("L", L)
+	t.AppendPoint("R", R)
+	return t.ChallengeScalar("c")
+}
+
+// checkRing reports ErrEmpty for an empty ring and an error unless its keys are
+// valid points of one curve.
+// 公钥环为空时返回ErrEmpty，公钥无效或不在同一曲线上时返回错误。
+func checkRing(ring []*sm2.PublicKey) error {
+	if len(ring) == 0 {
+		return elgamal.ErrEmpty
+	}
+	for _, P := range ring {
+		if err := elgamal.CheckPoint((*elgamal.CurvePoint)(P)); err != nil {
+			return err
+		}
+		if P.Curve.Params() != ring[0].Curve.Params() {
+			return elgamal.ErrCurveMismatch
+		}
+	}
+	return nil
+}
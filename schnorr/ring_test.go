@@ -0,0 +1,92 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schnorr
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestRingSignature(t *testing.T) {
+	var privs []*sm2.PrivateKey
+	var ring []*sm2.PublicKey
+	for i := 0; i < 4; i++ {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		ring = append(ring, &priv.PublicKey)
+	}
+	scope, msg := []byte("request-1"), []byte("share")
+
+	sigs := make([]*RingSignature, len(privs))
+	for i, priv := range privs {
+		sig, err := RingSign(priv, ring, scope, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := RingVerify(ring, scope, msg, sig); err != nil || !ok {
+			t.Fatalf("signature of member %d failed to verify: %v", i, err)
+		}
+		sigs[i] = sig
+	}
+	if sigs[0].Linked(sigs[1]) {
+		t.Fatal("signatures of different members linked")
+	}
+
+	// 同一成员在同一范围内的签名可关联，在不同范围内不可关联
+	again, err := RingSign(privs[2], ring, scope, []byte("another share"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Linked(sigs[2]) {
+		t.Fatal("signatures of one member in one scope not linked")
+	}
+	other, err := RingSign(privs[2], ring, []byte("request-2"), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Linked(sigs[2]) {
+		t.Fatal("signatures in different scopes linked")
+	}
+
+	if ok, _ := RingVerify(ring, scope, []byte("forged"), sigs[0]); ok {
+		t.Fatal("signature verified for another message")
+	}
+	if ok, _ := RingVerify(ring, []byte("request-2"), msg, sigs[0]); ok {
+		t.Fatal("signature verified in another scope")
+	}
+	if ok, _ := RingVerify(ring[1:], scope, msg, sigs[1]); ok {
+		t.Fatal("signature verified for another ring")
+	}
+	sigs[0].S[3] = new(big.Int).Add(sigs[0].S[3], big.NewInt(1))
+	if ok, _ := RingVerify(ring, scope, msg, sigs[0]); ok {
+		t.Fatal("tampered signature verified")
+	}
+
+	outsider, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RingSign(outsider, ring, scope, msg); !errors.Is(err, ErrNotInRing) {
+		t.Fatalf("got %v, want ErrNotInRing", err)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schnorr implements Schnorr signatures over the SM2 group with SM3
+// challenges, to authenticate share bundles and DKG messages with the keys the
+// nodes already hold, without a separate PKI stack.
+//
+// A signature (E,S) on msg under P = xG satisfies E = H(P, sG - EP, msg), with H the
+// SM3 transcript of package proof. The nonce is derived from the private key, the
+// message and fresh randomness, so a weak random source does not leak the key.
+// RingSign adds linkable ring signatures, which hide the signer among a set of keys,
+// and CoSigner co-signatures, which Verify checks under the aggregate of the keys.
+// Schnorr签名：基于SM2群、以SM3计算挑战值的Schnorr签名，供节点以已有密钥认证份额包与DKG消息，
+// 无需另行引入PKI。
+//
+// 公钥P = xG下对消息msg的签名(E,S)满足E = H(P, sG - EP, msg)，H为proof包基于SM3的记录。
+// 随机数由私钥、消息及新鲜随机数共同派生，随机源较弱时也不会泄露私钥。
+// RingSign另提供可链接环签名，在一组公钥中隐藏签名者；CoSigner提供联合签名，Verify以各公钥的聚合公钥验证。
+package schnorr
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// protocol is the transcript protocol label of the challenge.
+// 挑战值所用记录的协议标签。
+const protocol = "ppks-schnorr"
+
+// SignatureSize is the length of an encoded signature, E||S.
+// 签名编码E||S的字节长度。
+const SignatureSize = 64
+
+// Signature is a Schnorr signature: the challenge E and the response S.
+// Schnorr签名：挑战值E与应答S。
+type Signature struct {
+	E, S *big.Int
+}
+
+// Sign signs msg with priv.
+// 签名：使用私钥priv对消息msg签名。
+//
+// 参数：
+//		私钥	priv
+//		消息	msg
+// 返回：
+// 		签名
+func Sign(priv *sm2.PrivateKey, msg []byte) (*Signature, error) {
+	return SignWithRand(priv, msg, rand.Reader)
+}
+
+// SignWithRand is Sign drawing the fresh randomness of the nonce from random.
+// 签名：同Sign，但随机数的新鲜随机部分取自random。
+//
+// 参数：
+//		私钥	priv
+//		消息	msg
+//		随机源	random
+// 返回：
+// 		签名
+func SignWithRand(priv *sm2.PrivateKey, msg []byte, random io.Reader) (*Signature, error) {
+	if priv == nil || priv.D == nil || priv.D.Sign() <= 0 {
+		return nil, opError("Sign", elgamal.ErrOutOfRange)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(&priv.PublicKey)); err != nil {
+		return nil, opError("Sign", err)
+	}
+	curve := priv.Curve
+	N := curve.Params().N
+
+	// k = KDF(x || z || msg) mod N，z为新鲜随机数
+	z := make([]byte, 32)
+	if _, err := io.ReadFull(random, z); err != nil {
+		return nil, opError("Sign", err)
+	}
+	x := make([]byte, (curve.Params().BitSize+7)/8)
+	priv.D.FillBytes(x)
+	k := new(big.Int).SetBytes(ec.KDF(40, []byte(protocol), x, z, msg))
+	k.Mod(k, N)
+	if k.Sign() == 0 {
+		k.SetInt64(1)
+	}
+
+	var R elgamal.CurvePoint
+	R.Curve = curve
+	R.X, R.Y = curve.ScalarBaseMult(k.Bytes())
+	E := challenge((*elgamal.CurvePoint)(&priv.PublicKey), &R, msg)
+
+	// S = k + E*x mod N
+	S := new(big.Int).Mul(E, priv.D)
+	S.Add(S, k)
+	S.Mod(S, N)
+	return &Signature{E: E, S: S}, nil
+}
+
+// Verify reports whether sig is a signature of msg under pub.
+// 验签：验证sig是否为公钥pub下对消息msg的签名。
+//
+// 参数：
+//		公钥	pub
+//		消息	msg
+//		签名	sig
+// 返回：
+// 		验证结果
+func Verify(pub *sm2.PublicKey, msg []byte, sig *Signature) (bool, error) {
+	P := (*elgamal.CurvePoint)(pub)
+	if err := elgamal.CheckPoint(P); err != nil {
+		return false, opError("Verify", err)
+	}
+	if sig == nil || sig.E == nil || sig.S == nil {
+		return false, nil
+	}
+	curve := pub.Curve
+	N := curve.Params().N
+	if sig.S.Sign() < 0 || sig.S.Cmp(N) >= 0 || sig.E.Sign() < 0 {
+		return false, nil
+	}
+
+	// R = sG - EP
+	negE := new(big.Int).Neg(sig.E)
+	negE.Mod(negE, N)
+	sGx, sGy := curve.ScalarBaseMult(sig.S.Bytes())
+	ePx, ePy := curve.ScalarMult(pub.X, pub.Y, negE.Bytes())
+	var R elgamal.CurvePoint
+	R.Curve = curve
+	R.X, R.Y = ec.Add(curve, sGx, sGy, ePx, ePy)
+	if R.IsInfinity() {
+		return false, nil
+	}
+
+	return challenge(P, &R, msg).Cmp(sig.E) == 0, nil
+}
+
+// challenge returns H(P, R, msg).
+// 计算挑战值H(P, R, msg)。
+func challenge(P, R *elgamal.CurvePoint, msg []byte) *big.Int {
+	t := proof.NewTranscript(protocol)
+	t.AppendPoint("P", P)
+	t.AppendPoint("R", R)
+	t.AppendMessage("msg", msg)
+	return t.ChallengeScalar("e")
+}
+
+// MarshalBinary encodes sig as E||S, 32 bytes each.
+// 将签名编码为E||S，各32字节。
+func (sig *Signature) MarshalBinary() ([]byte, error) {
+	if sig.E == nil || sig.S == nil || sig.E.Sign() < 0 || sig.S.Sign() < 0 ||
+		sig.E.BitLen() > 256 || sig.S.BitLen() > 256 {
+		return nil, opError("Signature.MarshalBinary", ErrInvalidSignature)
+	}
+	b := make([]byte, SignatureSize)
+	sig.E.FillBytes(b[:32])
+	sig.S.FillBytes(b[32:])
+	return b, nil
+}
+
+// UnmarshalBinary decodes an encoding produced by MarshalBinary.
+// 解析MarshalBinary生成的编码。
+func (sig *Signature) UnmarshalBinary(b []byte) error {
+	if len(b) != SignatureSize {
+		return opError("Signature.UnmarshalBinary", ErrInvalidSignature)
+	}
+	sig.E = new(big.Int).SetBytes(b[:32])
+	sig.S = new(big.Int).SetBytes(b[32:])
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schnorr
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSignVerify(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("share bundle")
+
+	sig, err := Sign(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := Verify(&priv.PublicKey, msg, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("signature failed to verify")
+	}
+
+	// 篡改消息、换用公钥或篡改签名后验证失败
+	if ok, _ := Verify(&priv.PublicKey, []byte("share bundle!"), sig); ok {
+		t.Fatal("signature verified for another message")
+	}
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := Verify(&other.PublicKey, msg, sig); ok {
+		t.Fatal("signature verified under another key")
+	}
+	forged := &Signature{E: sig.E, S: new(big.Int).Add(sig.S, big.NewInt(1))}
+	if ok, _ := Verify(&priv.PublicKey, msg, forged); ok {
+		t.Fatal("forged signature verified")
+	}
+	if ok, _ := Verify(&priv.PublicKey, msg, &Signature{}); ok {
+		t.Fatal("empty signature verified")
+	}
+}
+
+func TestSignNonce(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("dkg deal")
+
+	// 随机源固定时签名确定，随机源不同时签名不同
+	zero := func() *bytes.Reader { return bytes.NewReader(make([]byte, 32)) }
+	a, err := SignWithRand(priv, msg, zero())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SignWithRand(priv, msg, zero())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Sign(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.E.Cmp(b.E) != 0 || a.E.Cmp(c.E) == 0 {
+		t.Fatal("unexpected nonce derivation")
+	}
+	if _, err := SignWithRand(priv, msg, bytes.NewReader(nil)); err == nil {
+		t.Fatal("expected error for exhausted random source")
+	}
+}
+
+func TestSignatureEncoding(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := Sign(priv, []byte("m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := sig.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Signature
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(&priv.PublicKey, []byte("m"), &got); err != nil || !ok {
+		t.Fatal("decoded signature failed to verify")
+	}
+	if err := got.UnmarshalBinary(b[1:]); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("got %v, want ErrInvalidSignature", err)
+	}
+}
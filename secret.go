@@ -0,0 +1,105 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// SecretBytes holds secret key material, such as a symmetric key derived from a
+// decrypted point, and gives it a well-defined cleanup path through Wipe.
+// Formatting a SecretBytes never prints its content. It is not safe for concurrent use.
+// 秘密字节：保存由明文点派生的对称密钥等秘密数据，通过Wipe擦除。格式化输出时不打印内容。不可并发使用。
+type SecretBytes struct {
+	b     []byte
+	wiped bool
+}
+
+// NewSecretBytes wraps b, taking ownership of it: b is zeroed when the result is wiped.
+// 包装b为SecretBytes并接管其所有权：擦除时b被清零。
+func NewSecretBytes(b []byte) *SecretBytes {
+	return &SecretBytes{b: b}
+}
+
+// Bytes returns the secret without copying it, or nil once s has been wiped.
+// The returned slice is zeroed by Wipe and must not be retained past it.
+// 返回秘密数据本身（不复制），擦除后返回nil。返回的slice会被Wipe清零，不应在擦除后继续持有。
+func (s *SecretBytes) Bytes() []byte {
+	if s.wiped {
+		return nil
+	}
+	return s.b
+}
+
+// Len returns the length of the secret, or 0 once s has been wiped.
+// 返回秘密数据长度，擦除后为0。
+func (s *SecretBytes) Len() int {
+	if s.wiped {
+		return 0
+	}
+	return len(s.b)
+}
+
+// Copy returns an independent copy of s that must be wiped separately.
+// It fails with ErrSecretWiped once s has been wiped.
+// 复制：返回s的独立副本，副本需单独擦除。s已擦除时返回ErrSecretWiped。
+func (s *SecretBytes) Copy() (*SecretBytes, error) {
+	if s.wiped {
+		return nil, opError("SecretBytes.Copy", ErrSecretWiped)
+	}
+	b := make([]byte, len(s.b))
+	copy(b, s.b)
+	return &SecretBytes{b: b}, nil
+}
+
+// Wipe zeroes the secret. It is safe to call Wipe more than once.
+// 擦除：将秘密数据清零，可重复调用。
+func (s *SecretBytes) Wipe() {
+	wipeBytes(s.b)
+	s.b = nil
+	s.wiped = true
+}
+
+// Wiped reports whether s has been wiped.
+// 返回s是否已擦除。
+func (s *SecretBytes) Wiped() bool {
+	return s.wiped
+}
+
+// String implements fmt.Stringer without revealing the secret.
+// 不泄露内容的字符串形式。
+func (s *SecretBytes) String() string {
+	if s.wiped {
+		return "SecretBytes(wiped)"
+	}
+	return fmt.Sprintf("SecretBytes(%d bytes)", len(s.b))
+}
+
+// GoString implements fmt.GoStringer without revealing the secret.
+// %#v格式下同样不泄露内容。
+func (s *SecretBytes) GoString() string {
+	return s.String()
+}
+
+// wipeBytes zeroes b.
+// 将b清零。
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecretBytesWipe(t *testing.T) {
+	raw := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	s := NewSecretBytes(raw)
+
+	c, err := s.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c.Bytes(), s.Bytes()) {
+		t.Fatal("copy differs from original")
+	}
+
+	s.Wipe()
+	if !s.Wiped() || s.Bytes() != nil || s.Len() != 0 {
+		t.Fatal("wiped secret still exposes data")
+	}
+	// 底层数组已清零
+	for _, b := range raw {
+		if b != 0 {
+			t.Fatal("underlying buffer not zeroed")
+		}
+	}
+	// 副本不受影响
+	if c.Len() != 8 || c.Bytes()[0] != 1 {
+		t.Fatal("copy affected by wiping the original")
+	}
+
+	if _, err := s.Copy(); !errors.Is(err, ErrSecretWiped) {
+		t.Fatalf("got %v, want ErrSecretWiped", err)
+	}
+	s.Wipe() // 可重复擦除
+}
+
+func TestSecretBytesFormat(t *testing.T) {
+	s := NewSecretBytes([]byte("super-secret-key"))
+	for _, f := range []string{"%v", "%s", "%#v"} {
+		out := fmt.Sprintf(f, s)
+		if strings.Contains(out, "super") {
+			t.Fatalf("%s leaked the secret: %s", f, out)
+		}
+	}
+}
+
+func TestSymmetricKeyFromPointSecret(t *testing.T) {
+	D := GenPoint()
+	k, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceKDF, Length: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Wipe()
+	if k.Bytes() != nil {
+		t.Fatal("derived key not wiped")
+	}
+}
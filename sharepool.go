@@ -0,0 +1,46 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ShareRequest is one share a node is asked to calculate.
+// 份额请求：节点须计算的一个份额。
+type ShareRequest = keyswitch.ShareRequest
+
+// ShareResult is the outcome of one ShareRequest of ShareCalParallel.
+// 份额计算结果：ShareCalParallel中一个份额请求的结果。
+type ShareResult = keyswitch.ShareResult
+
+// ShareCalParallel calculates, and optionally proves, the shares of reqs on a pool
+// of workers, sending the results in the order of reqs.
+// It is a wrapper of keyswitch.ShareCalParallel.
+// 并行份额计算：以协程池计算reqs中各请求的份额并按需生成证明，结果按reqs的顺序发送。
+//
+// 参数：
+//		份额请求slice	reqs
+//		私钥			priv
+//		是否生成证明	prove
+//		计算协程数		workers
+// 返回：
+// 		结果通道
+func ShareCalParallel(reqs []ShareRequest, priv *sm2.PrivateKey, prove bool, workers int) <-chan ShareResult {
+	return keyswitch.ShareCalParallel(reqs, priv, prove, workers)
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package shuffle implements a verifiable shuffle (mixnet step) of ElGamal
+// ciphertexts: Shuffle permutes and re-randomizes a CipherVector and proves that
+// the output holds re-encryptions of exactly the inputs, so a committee can
+// anonymize a batch of encrypted keys before switching them.
+//
+// The proof is the cut-and-choose proof of Sako and Kilian made non-interactive:
+// the prover publishes Rounds intermediate shuffles and, for each, reveals either
+// how it was obtained from the input or how the output was obtained from it. A
+// cheating prover is caught unless it guesses every challenge bit, with
+// probability 2^-Rounds. The proof grows with Rounds times the batch size, which
+// suits the batches of a key switching session.
+// 可验证混洗（混合网络的一步）：Shuffle对ElGamal密文向量置换并重新随机化，并证明输出恰为输入的
+// 重新加密，委员会可据此在置换前匿名化一批加密密钥。
+//
+// 证明为Sako-Kilian"切分-选择"证明的非交互形式：证明者公布Rounds个中间混洗，并对每个中间混洗，
+// 或公开其由输入得到的方式，或公开输出由其得到的方式。作弊的证明者只有猜中全部挑战比特才不被发现，
+// 概率为2^-Rounds。证明大小为Rounds乘以批次大小，适用于一次密钥置换会话的批次。
+package shuffle
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Rounds is the number of cut-and-choose rounds, the security level in bits. It
+// must not exceed the 256 bits of a transcript challenge.
+// 切分-选择的轮数，即以比特计的安全强度，不得超过记录挑战值的256比特。
+const Rounds = 128
+
+// shuffleProtocol names the transcripts of shuffle proofs.
+// 混洗证明记录的协议名。
+const shuffleProtocol = "ppks-shuffle"
+
+// Round is one cut-and-choose round: an intermediate shuffle Shadow and the
+// opening of one of its two links. Perm[i] and Rand[i] tell that element i of the
+// later vector re-encrypts element Perm[i] of the earlier one with randomness
+// Rand[i]; the earlier vector is the input when the challenge bit is 0, Shadow
+// when it is 1.
+// 一轮切分-选择：中间混洗Shadow，及其两个环节之一的打开值。Perm[i]与Rand[i]表示后一向量的第i个
+// 元素是前一向量第Perm[i]个元素以随机数Rand[i]重新加密的结果；挑战比特为0时前一向量为输入、
+// 后一向量为Shadow，为1时前一向量为Shadow、后一向量为输出。
+type Round struct {
+	Shadow elgamal.CipherVector
+	Perm   []int
+	Rand   []*big.Int
+}
+
+// Proof is a proof of correct shuffle.
+// 混洗正确性证明。
+type Proof struct {
+	Rounds []Round
+}
+
+// Shuffle permutes in at random, re-randomizes every ciphertext under pub, and
+// returns the result with a proof of correct shuffle.
+// 混洗：随机置换in，并以公钥pub重新随机化每个密文，返回结果及混洗正确性证明。
+//
+// 参数：
+//		公钥	pub
+//		输入密文向量	in
+// 返回：
+// 		输出密文向量
+//		证明
+func Shuffle(pub *sm2.PublicKey, in elgamal.CipherVector) (elgamal.CipherVector, *Proof, error) {
+	if len(in) == 0 {
+		return nil, nil, opError("Shuffle", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, nil, opError("Shuffle", err)
+	}
+	for i := range in {
+		if err := elgamal.CheckCipherText(&in[i]); err != nil {
+			return nil, nil, itemError("Shuffle", "ciphertext", i, err)
+		}
+	}
+
+	// 输出：out[i] = Reenc(in[pi[i]], rho[i])
+	n := len(in)
+	pi, rho, err := randomShuffle(pub, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := apply(pub, in, pi, rho)
+
+	// 中间混洗：S_j[i] = Reenc(in[sigma_j[i]], s_j[i])
+	p := &Proof{Rounds: make([]Round, Rounds)}
+	sigmas := make([][]int, Rounds)
+	ss := make([][]*big.Int, Rounds)
+	for j := range p.Rounds {
+		if sigmas[j], ss[j], err = randomShuffle(pub, n); err != nil {
+			return nil, nil, err
+		}
+		p.Rounds[j].Shadow = apply(pub, in, sigmas[j], ss[j])
+	}
+
+	e := challenge(pub, in, out, p)
+	N := pub.Curve.Params().N
+	for j := range p.Rounds {
+		r := &p.Rounds[j]
+		if e.Bit(j) == 0 {
+			r.Perm, r.Rand = sigmas[j], ss[j]
+			continue
+		}
+		// out[i] = Reenc(S_j[tau[i]], rho[i]-s_j[tau[i]])，其中sigma_j[tau[i]] = pi[i]
+		inv := make([]int, n)
+		for k, v := range sigmas[j] {
+			inv[v] = k
+		}
+		r.Perm = make([]int, n)
+		r.Rand = make([]*big.Int, n)
+		for i := 0; i < n; i++ {
+			t := inv[pi[i]]
+			r.Perm[i] = t
+			d := new(big.Int).Sub(rho[i], ss[j][t])
+			r.Rand[i] = d.Mod(d, N)
+		}
+	}
+	return out, p, nil
+}
+
+// Verify reports whether p proves that out is a shuffle of in under pub.
+// 验证混洗：判断p是否证明了out为in在公钥pub下的混洗。
+//
+// 参数：
+//		公钥	pub
+//		输入密文向量	in
+//		输出密文向量	out
+//		证明	p
+// 返回：
+// 		验证结果
+func Verify(pub *sm2.PublicKey, in, out elgamal.CipherVector, p *Proof) (bool, error) {
+	if len(in) == 0 {
+		return false, opError("Verify", elgamal.ErrEmpty)
+	}
+	if len(out) != len(in) {
+		return false, opError("Verify", elgamal.ErrLengthMismatch)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return false, opError("Verify", err)
+	}
+	for i := range in {
+		if err := elgamal.CheckCipherText(&in[i]); err != nil {
+			return false, itemError("Verify", "ciphertext", i, err)
+		}
+		if err := elgamal.CheckCipherText(&out[i]); err != nil {
+			return false, itemError("Verify", "ciphertext", i, err)
+		}
+	}
+	if p == nil || len(p.Rounds) != Rounds {
+		return false, nil
+	}
+	n := len(in)
+	for j := range p.Rounds {
+		r := &p.Rounds[j]
+		if len(r.Shadow) != n || !isPermutation(r.Perm, n) || len(r.Rand) != n {
+			return false, nil
+		}
+		for i := range r.Shadow {
+			if elgamal.CheckCipherText(&r.Shadow[i]) != nil || r.Rand[i] == nil {
+				return false, nil
+			}
+		}
+	}
+
+	e := challenge(pub, in, out, p)
+	for j := range p.Rounds {
+		r := &p.Rounds[j]
+		from, to := in, r.Shadow
+		if e.Bit(j) == 1 {
+			from, to = r.Shadow, out
+		}
+		got := apply(pub, from, r.Perm, r.Rand)
+		for i := range got {
+			if !equal(&got[i], &to[i]) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// randomShuffle returns a uniformly random permutation of n elements and n
+// random re-encryption scalars.
+// 返回n个元素的均匀随机置换及n个随机重新加密标量。
+func randomShuffle(pub *sm2.PublicKey, n int) ([]int, []*big.Int, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	// Fisher-Yates
+	for i := n - 1; i > 0; i-- {
+		k, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, nil, opError("Shuffle", err)
+		}
+		j := int(k.Int64())
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+	rs := make([]*big.Int, n)
+	for i := range rs {
+		r, err := ec.RandFieldElement(pub.Curve, rand.Reader)
+		if err != nil {
+			return nil, nil, opError("Shuffle", err)
+		}
+		rs[i] = r
+	}
+	return perm, rs, nil
+}
+
+// apply returns the vector whose element i re-encrypts from[perm[i]] with rs[i].
+// 返回第i个元素为from[perm[i]]以rs[i]重新加密结果的向量。
+func apply(pub *sm2.PublicKey, from elgamal.CipherVector, perm []int, rs []*big.Int) elgamal.CipherVector {
+	to := make(elgamal.CipherVector, len(perm))
+	for i, k := range perm {
+		ct, err := elgamal.RerandomizeWith(pub, &from[k], rs[i])
+		if err != nil {
+			// 输入均已校验
+			panic(err)
+		}
+		to[i] = *ct
+	}
+	return to
+}
+
+// challenge derives the challenge bits from pub, in, out and the shadows of p.
+// 由pub、in、out及p中的中间混洗导出挑战比特。
+func challenge(pub *sm2.PublicKey, in, out elgamal.CipherVector, p *Proof) *big.Int {
+	t := proof.NewTranscript(shuffleProtocol)
+	t.AppendPoint("pub", (*elgamal.CurvePoint)(pub))
+	appendVector := func(label string, v elgamal.CipherVector) {
+		t.AppendScalar(label, big.NewInt(int64(len(v))))
+		for i := range v {
+			t.AppendPoint("K", &v[i].K)
+			t.AppendPoint("C", &v[i].C)
+		}
+	}
+	appendVector("in", in)
+	appendVector("out", out)
+	for j := range p.Rounds {
+		appendVector("shadow", p.Rounds[j].Shadow)
+	}
+	// 挑战值为256比特，足以覆盖Rounds轮
+	return t.ChallengeScalar("e")
+}
+
+// isPermutation reports whether perm is a permutation of 0..n-1.
+// 判断perm是否为0..n-1的置换。
+func isPermutation(perm []int, n int) bool {
+	if len(perm) != n {
+		return false
+	}
+	seen := make([]bool, n)
+	for _, k := range perm {
+		if k < 0 || k >= n || seen[k] {
+			return false
+		}
+		seen[k] = true
+	}
+	return true
+}
+
+// equal reports whether a and b are the same ciphertext.
+// 判断a与b是否为同一密文。
+func equal(a, b *elgamal.CipherText) bool {
+	return a.K.X.Cmp(b.K.X) == 0 && a.K.Y.Cmp(b.K.Y) == 0 &&
+		a.C.X.Cmp(b.C.X) == 0 && a.C.Y.Cmp(b.C.Y) == 0
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shuffle
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// batch 生成n个加密的随机点。
+func batch(t *testing.T, pub *sm2.PublicKey, n int) (elgamal.PointVector, elgamal.CipherVector) {
+	pts := make(elgamal.PointVector, n)
+	cts := make(elgamal.CipherVector, n)
+	for i := range pts {
+		pts[i] = *elgamal.GenPoint()
+		ct, err := elgamal.PointEncrypt(pub, &pts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts[i] = *ct
+	}
+	return pts, cts
+}
+
+func TestShuffle(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts, in := batch(t, &priv.PublicKey, 5)
+
+	out, p, err := Shuffle(&priv.PublicKey, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := Verify(&priv.PublicKey, in, out, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("honest shuffle failed to verify")
+	}
+
+	// 输出解密后为输入明文的一个排列
+	seen := make(map[string]bool)
+	for i := range out {
+		D, err := elgamal.PointDecrypt(&out[i], priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[D.X.String()] = true
+	}
+	for i := range pts {
+		if !seen[pts[i].X.String()] {
+			t.Fatal("shuffle lost a plaintext")
+		}
+	}
+
+	// 证明不适用于其他公钥
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(&other.PublicKey, in, out, p); err != nil || ok {
+		t.Fatal("shuffle verified under another key")
+	}
+}
+
+func TestShuffleForged(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	_, in := batch(t, pub, 4)
+	out, p, err := Shuffle(pub, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 以另一明文的密文替换一个输出
+	_, extra := batch(t, pub, 1)
+	forged := append(elgamal.CipherVector(nil), out...)
+	forged[2] = extra[0]
+	if ok, err := Verify(pub, in, forged, p); err != nil || ok {
+		t.Fatal("shuffle with a replaced output verified")
+	}
+
+	// 重复一个输出
+	dup := append(elgamal.CipherVector(nil), out...)
+	dup[1] = dup[0]
+	if ok, err := Verify(pub, in, dup, p); err != nil || ok {
+		t.Fatal("shuffle with a duplicated output verified")
+	}
+
+	// 篡改一轮中的置换
+	r := &p.Rounds[7]
+	r.Perm[0], r.Perm[1] = r.Perm[1], r.Perm[0]
+	if ok, err := Verify(pub, in, out, p); err != nil || ok {
+		t.Fatal("shuffle with a forged round verified")
+	}
+
+	if _, err := Verify(pub, in, out[:3], p); err == nil {
+		t.Fatal("expected error for vectors of different lengths")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/elgamal"
+	"ppks/schnorr"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// signcryptDomain separates signcryption signatures from other Schnorr signatures.
+// 签密签名的域分隔串，与其他Schnorr签名相区分。
+const signcryptDomain = "ppks-signcrypt-v1"
+
+// SignedCipherText is a ciphertext of D with the sender's Schnorr signature on D.
+// The signature is on the plaintext point rather than the ciphertext, so it
+// survives key switching: replace CipherText with the switched ciphertext and the
+// final recipient still authenticates the origin when decrypting. D should be a
+// random point, as from GenPoint or Encapsulate; for a low-entropy D the signature
+// lets anybody confirm a guess of it.
+// 签密密文：点D的密文及发送方对D的Schnorr签名。签名针对明文点而非密文，因此在密钥置换后仍然有效：
+// 以置换后的密文替换CipherText，最终接收方解密时仍可认证数据来源。D应为随机点（如GenPoint或Encapsulate生成的点）；
+// D熵较低时，任何人可借助签名验证对D的猜测。
+type SignedCipherText struct {
+	CipherText CipherText
+	Signature  schnorr.Signature
+}
+
+// Signcrypt encrypts D for pub and signs it with the sender key senderPriv.
+// 签密：为公钥pub加密点D，并以发送方私钥senderPriv对其签名。
+//
+// 参数：
+//		发送方私钥	senderPriv
+//		公钥		pub
+//		待加密点	D
+// 返回：
+// 		签密密文
+func Signcrypt(senderPriv *sm2.PrivateKey, pub *sm2.PublicKey, D *CurvePoint) (*SignedCipherText, error) {
+	ct, err := PointEncrypt(pub, D)
+	if err != nil {
+		return nil, opError("Signcrypt", err)
+	}
+	sig, err := schnorr.Sign(senderPriv, signcryptMessage(D))
+	if err != nil {
+		return nil, opError("Signcrypt", err)
+	}
+	return &SignedCipherText{CipherText: *ct, Signature: *sig}, nil
+}
+
+// Unsigncrypt decrypts sct with priv and verifies the signature of the sender
+// senderPub on the point, failing with ErrAuthFailed if it does not hold.
+// 解签密：使用私钥priv解密sct，并验证发送方公钥senderPub对明文点的签名，验证未通过时返回ErrAuthFailed。
+//
+// 参数：
+//		私钥		priv
+//		签密密文	sct
+//		发送方公钥	senderPub
+// 返回：
+// 		明文点
+func Unsigncrypt(priv *sm2.PrivateKey, sct *SignedCipherText, senderPub *sm2.PublicKey) (*CurvePoint, error) {
+	if sct == nil {
+		return nil, opError("Unsigncrypt", ErrAuthFailed)
+	}
+	D, err := PointDecrypt(&sct.CipherText, priv)
+	if err != nil {
+		return nil, opError("Unsigncrypt", err)
+	}
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, opError("Unsigncrypt", ErrAuthFailed)
+	}
+	ok, err := schnorr.Verify(senderPub, signcryptMessage(D), &sct.Signature)
+	if err != nil {
+		return nil, opError("Unsigncrypt", err)
+	}
+	if !ok {
+		return nil, opError("Unsigncrypt", ErrAuthFailed)
+	}
+	return D, nil
+}
+
+// signcryptMessage returns the signed message of the point D.
+// 返回点D对应的签名消息。
+func signcryptMessage(D *CurvePoint) []byte {
+	return append([]byte(signcryptDomain), D.CompressedBytes()...)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSigncrypt(t *testing.T) {
+	sender, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committee, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D := GenPoint()
+	sct, err := Signcrypt(sender, &committee.PublicKey, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 签名在置换后仍然有效
+	share, _, err := ShareCal(&q.PublicKey, &sct.CipherText.K, committee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switched, err := ShareReplace(&CipherVector{*share}, &sct.CipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwarded := *sct
+	forwarded.CipherText = *switched
+	got, err := Unsigncrypt(q, &forwarded, &sender.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != D.X.Cmp(got.X) || 0 != D.Y.Cmp(got.Y) {
+		t.Fatal("unsigncrypted a different point")
+	}
+
+	// 其他发送方或其他明文点均不能通过验证
+	if _, err := Unsigncrypt(q, &forwarded, &q.PublicKey); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expected ErrAuthFailed for another sender, got %v", err)
+	}
+	ct, err := PointEncrypt(&q.PublicKey, GenPoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwarded.CipherText = *ct
+	if _, err := Unsigncrypt(q, &forwarded, &sender.PublicKey); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expected ErrAuthFailed for a substituted ciphertext, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// DefaultUID is the default user ID of GM/T 0009, used when the uid passed to
+// SignSM2 or VerifySM2 is empty.
+// GM/T 0009规定的默认用户标识，SignSM2与VerifySM2的uid为空时使用。
+const DefaultUID = "1234567812345678"
+
+// sm2Signature is the ASN.1 form of an SM2 signature, SEQUENCE { r, s }.
+// SM2签名的ASN.1结构SEQUENCE { r, s }。
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+// SignSM2 signs msg with the node key priv in the standard SM2 signature scheme
+// (GM/T 0003.2): the digest is SM3(ZA || msg), ZA binding the user ID uid and the
+// public key. The signature is DER encoded as in GM/T 0009, so existing GM
+// toolchains verify it.
+// SM2签名：以节点私钥priv按标准SM2签名算法（GM/T 0003.2）对msg签名，摘要为SM3(ZA || msg)，
+// ZA绑定用户标识uid与公钥。签名按GM/T 0009以DER编码，可由现有国密工具链验证。
+//
+// 参数：
+//		私钥		priv
+//		消息		msg
+//		用户标识	uid，为空时使用DefaultUID
+// 返回：
+// 		DER编码的签名
+func SignSM2(priv *sm2.PrivateKey, msg, uid []byte) ([]byte, error) {
+	if priv == nil || priv.D == nil {
+		return nil, opError("SignSM2", ErrUnsupportedKey)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(&priv.PublicKey)); err != nil {
+		return nil, opError("SignSM2", err)
+	}
+	if len(uid) == 0 {
+		uid = []byte(DefaultUID)
+	}
+	r, s, err := sm2.Sm2Sign(priv, msg, uid, rand.Reader)
+	if err != nil {
+		return nil, opError("SignSM2", err)
+	}
+	sig, err := asn1.Marshal(sm2Signature{R: r, S: s})
+	if err != nil {
+		return nil, opError("SignSM2", err)
+	}
+	return sig, nil
+}
+
+// VerifySM2 verifies the DER encoded SM2 signature sig of msg under pub and the
+// user ID uid.
+// SM2验签：以公钥pub及用户标识uid验证DER编码的SM2签名sig。
+//
+// 参数：
+//		公钥		pub
+//		消息		msg
+//		用户标识	uid，为空时使用DefaultUID
+//		签名		sig
+// 返回：
+// 		验证结果
+func VerifySM2(pub *sm2.PublicKey, msg, uid, sig []byte) (bool, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return false, opError("VerifySM2", err)
+	}
+	var rs sm2Signature
+	rest, err := asn1.Unmarshal(sig, &rs)
+	if err != nil || len(rest) != 0 || rs.R == nil || rs.S == nil {
+		return false, opError("VerifySM2", ErrInvalidSignature)
+	}
+	if len(uid) == 0 {
+		uid = []byte(DefaultUID)
+	}
+	return sm2.Sm2Verify(pub, msg, uid, rs.R, rs.S), nil
+}
+
+// SignSM2 signs msg with the private key of kp, see SignSM2.
+// 以kp的私钥进行SM2签名，见SignSM2。
+func (kp *KeyPair) SignSM2(msg, uid []byte) ([]byte, error) {
+	return SignSM2(kp.priv, msg, uid)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSignSM2(t *testing.T) {
+	kp, err := NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("share bundle")
+
+	sig, err := kp.SignSM2(msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := VerifySM2(kp.PublicKey(), msg, nil, sig)
+	if err != nil || !ok {
+		t.Fatalf("signature failed to verify: %v", err)
+	}
+
+	// 默认用户标识的签名可由gmsm的标准接口验证
+	if !kp.PublicKey().Verify(msg, sig) {
+		t.Fatal("gmsm rejected the signature")
+	}
+	gm, err := kp.PrivateKey().Sign(nil, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifySM2(kp.PublicKey(), msg, []byte(DefaultUID), gm); !ok {
+		t.Fatal("gmsm signature rejected")
+	}
+
+	// 用户标识参与ZA计算
+	sig, err = SignSM2(kp.PrivateKey(), msg, []byte("node-1@ppks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifySM2(kp.PublicKey(), msg, []byte("node-1@ppks"), sig); !ok {
+		t.Fatal("signature with user ID failed to verify")
+	}
+	if ok, _ := VerifySM2(kp.PublicKey(), msg, nil, sig); ok {
+		t.Fatal("signature verified under another user ID")
+	}
+	if ok, _ := VerifySM2(kp.PublicKey(), []byte("share bundle!"), []byte("node-1@ppks"), sig); ok {
+		t.Fatal("signature verified for another message")
+	}
+
+	if _, err := VerifySM2(kp.PublicKey(), msg, nil, sig[:10]); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := SignSM2(&sm2.PrivateKey{}, msg, nil); err == nil {
+		t.Fatal("expected error for empty key")
+	}
+}
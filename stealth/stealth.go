@@ -0,0 +1,159 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stealth derives one-time target keys for requesters. From the long-term
+// public key A of a requester and a fresh ephemeral key r, a one-time key
+// P = A + H(rA, R)B is derived with R = rB; the requester recovers its private key
+// a + H(aR, R) from R alone. Servers see a different unlinkable target key in every
+// session, yet the requester only keeps its long-term key.
+//
+// stealth包为请求者派生一次性目标公钥。由请求者的长期公钥A与新的临时私钥r派生一次性公钥
+// P = A + H(rA, R)B，其中R = rB；请求者仅凭R即可恢复其私钥a + H(aR, R)。ks server在每个会话中
+// 看到的是不同且不可关联的目标公钥，而请求者只需保存其长期私钥。
+package stealth
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/kdf"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// tweakLabel is the KDF label of the tweak H(rA, R).
+// 偏移量H(rA, R)的KDF标签。
+const tweakLabel = "ppks-stealth"
+
+// OneTimeKey is a one-time target key PubKey with the ephemeral point R from which
+// the requester recovers its private key.
+// 一次性公钥：一次性目标公钥PubKey，以及请求者据以恢复私钥的临时点R。
+type OneTimeKey struct {
+	PubKey *sm2.PublicKey
+	R      *elgamal.CurvePoint
+}
+
+// New derives a fresh one-time key for the requester with the long-term public
+// key pub. The requester may call it itself or leave it to anybody who knows pub.
+// A nil random uses crypto/rand.
+// 派生一次性公钥：为长期公钥为pub的请求者派生新的一次性公钥，可由请求者自己调用，
+// 也可交由任何知道pub的一方调用。random为nil时使用crypto/rand。
+//
+// 参数：
+//		长期公钥	pub
+//		随机源		random
+// 返回：
+// 		一次性公钥
+func New(pub *sm2.PublicKey, random io.Reader) (*OneTimeKey, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, opError("New", err)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	curve := pub.Curve
+	r, err := ec.RandFieldElement(curve, random)
+	if err != nil {
+		return nil, opError("New", err)
+	}
+	R := &elgamal.CurvePoint{Curve: curve}
+	R.X, R.Y = curve.ScalarBaseMult(r.Bytes())
+
+	// S = rA
+	var S elgamal.CurvePoint
+	S.Curve = curve
+	S.X, S.Y = curve.ScalarMult(pub.X, pub.Y, r.Bytes())
+	ec.WipeInt(r)
+	t, err := tweak(&S, R)
+	if err != nil {
+		return nil, opError("New", err)
+	}
+
+	// P = A + tB
+	P := new(sm2.PublicKey)
+	P.Curve = curve
+	tx, ty := curve.ScalarBaseMult(t.Bytes())
+	P.X, P.Y = ec.Add(curve, pub.X, pub.Y, tx, ty)
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(P)); err != nil {
+		return nil, opError("New", err)
+	}
+	return &OneTimeKey{PubKey: P, R: R}, nil
+}
+
+// PrivateKey recovers the private key of k with the long-term private key priv,
+// failing with ErrNotOwner if k was not derived for priv. A requester receiving
+// keys derived by others can use it to recognise its own.
+// 恢复私钥：以长期私钥priv恢复一次性公钥k的私钥，k不是为priv派生时返回ErrNotOwner。
+// 接收他人所派生公钥的请求者可据此识别属于自己的公钥。
+//
+// 参数：
+//		长期私钥	priv
+// 返回：
+// 		一次性私钥
+func (k *OneTimeKey) PrivateKey(priv *sm2.PrivateKey) (*sm2.PrivateKey, error) {
+	if priv == nil || priv.D == nil || priv.Curve == nil {
+		return nil, opError("OneTimeKey.PrivateKey", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint(k.R); err != nil {
+		return nil, opError("OneTimeKey.PrivateKey", err)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(k.PubKey)); err != nil {
+		return nil, opError("OneTimeKey.PrivateKey", err)
+	}
+	curve := priv.Curve
+	if k.R.Curve.Params() != curve.Params() || k.PubKey.Curve.Params() != curve.Params() {
+		return nil, opError("OneTimeKey.PrivateKey", elgamal.ErrCurveMismatch)
+	}
+
+	// S = aR = rA
+	var S elgamal.CurvePoint
+	S.Curve = curve
+	S.X, S.Y = curve.ScalarMult(k.R.X, k.R.Y, priv.D.Bytes())
+	t, err := tweak(&S, k.R)
+	if err != nil {
+		return nil, opError("OneTimeKey.PrivateKey", err)
+	}
+
+	d := t.Add(t, priv.D)
+	d.Mod(d, curve.Params().N)
+	out := new(sm2.PrivateKey)
+	out.Curve = curve
+	out.D = d
+	out.X, out.Y = curve.ScalarBaseMult(d.Bytes())
+	if out.X.Cmp(k.PubKey.X) != 0 || out.Y.Cmp(k.PubKey.Y) != 0 {
+		ec.WipeInt(d)
+		return nil, opError("OneTimeKey.PrivateKey", ErrNotOwner)
+	}
+	return out, nil
+}
+
+// tweak returns H(S, R) modulo the order of the curve, from 16 extra bytes so its
+// bias is negligible.
+// 返回H(S, R)模曲线的阶，多取16字节以使偏差可忽略。
+func tweak(S, R *elgamal.CurvePoint) (*big.Int, error) {
+	N := S.Curve.Params().N
+	b, err := kdf.FromPoint(S, R.Bytes(), tweakLabel, (N.BitLen()+7)/8+16)
+	if err != nil {
+		return nil, err
+	}
+	t := new(big.Int).SetBytes(b)
+	for i := range b {
+		b[i] = 0
+	}
+	return t.Mod(t, N), nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stealth
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"ppks/elgamal"
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestOneTimeKey(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, err := New(&priv.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := New(&priv.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1.PubKey.X.Cmp(k2.PubKey.X) == 0 || k1.PubKey.X.Cmp(priv.PublicKey.X) == 0 {
+		t.Fatal("one-time keys are linkable")
+	}
+
+	// 以一次性公钥为目标置换的密文，请求者以长期私钥恢复的一次性私钥解密
+	node, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	M := elgamal.GenPoint()
+	ct, err := elgamal.PointEncrypt(&node.PublicKey, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, _, err := keyswitch.ShareCal(k1.PubKey, &ct.K, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switched, err := keyswitch.ShareReplace(&elgamal.CipherVector{*share}, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otk, err := k1.PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D, err := elgamal.PointDecrypt(switched, otk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if D.X.Cmp(M.X) != 0 || D.Y.Cmp(M.Y) != 0 {
+		t.Fatal("decrypted point differs")
+	}
+
+	other, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k1.PrivateKey(other); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("got %v, want ErrNotOwner", err)
+	}
+	k2.R = k1.R
+	if _, err := k2.PrivateKey(priv); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("got %v, want ErrNotOwner", err)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/kdf"
+)
+
+// KeySource selects how SymmetricKeyFromPoint turns a point into key bytes.
+// 对称密钥来源：选择由点得到对称密钥的方式。
+type KeySource int
+
+const (
+	// KeySourceX uses the raw X coordinate, the convention so far in this project.
+	// 直接使用横坐标，即本项目一直以来的约定。
+	KeySourceX KeySource = iota
+	// KeySourceY uses the raw Y coordinate.
+	// 直接使用纵坐标。
+	KeySourceY
+	// KeySourceKDF derives the key with the SM2 key derivation function over X||Y||Info.
+	// 使用SM2密钥派生函数，对X||Y||Info派生密钥。
+	KeySourceKDF
+	// KeySourceHKDF derives the key with SM3-HKDF (package kdf) from X||Y, using Salt
+	// and Info as the label, so keys for distinct labels are independent.
+	// 使用SM3-HKDF（kdf包）由X||Y派生密钥，以Salt为盐值、Info为标签，不同标签的密钥互相独立。
+	KeySourceHKDF
+)
+
+// SymmetricKeyOpts configures SymmetricKeyFromPoint.
+// 对称密钥选项。
+type SymmetricKeyOpts struct {
+	// Source selects the derivation. 派生方式。
+	Source KeySource
+	// Length is the key length in bytes. Zero means the full coordinate (32 bytes)
+	// for raw sources and 16 bytes (an SM4 key) for KeySourceKDF and KeySourceHKDF.
+	// 密钥字节长度。为0时，直接取坐标得到完整32字节，KDF及HKDF派生得到16字节（SM4密钥长度）。
+	Length int
+	// Info is optional context mixed into KeySourceKDF and KeySourceHKDF, ignored
+	// otherwise.
+	// KDF及HKDF派生时附加的上下文信息，其他方式忽略。
+	Info []byte
+	// Salt is the optional HKDF salt of KeySourceHKDF, ignored otherwise.
+	// HKDF派生时的盐值，可选，其他方式忽略。
+	Salt []byte
+}
+
+// SymmetricKeyFromPoint returns the symmetric key carried by the point D.
+// A nil opts uses the raw X coordinate. The caller should Wipe the key once done.
+// 由点得到对称密钥：按opts指定的方式，由明文点D得到对称密钥并返回，opts为nil时直接使用横坐标。
+// 密钥使用完毕后，调用者应调用Wipe擦除。
+//
+// 参数：
+//		明文点	D
+//		选项	opts
+// 返回：
+// 		对称密钥
+func SymmetricKeyFromPoint(D *CurvePoint, opts *SymmetricKeyOpts) (*SecretBytes, error) {
+	if opts == nil {
+		opts = &SymmetricKeyOpts{Source: KeySourceX}
+	}
+	if err := elgamal.CheckPoint(D); err != nil {
+		return nil, opError("SymmetricKeyFromPoint", err)
+	}
+	if opts.Length < 0 {
+		return nil, opError("SymmetricKeyFromPoint", ErrInvalidKeyLength)
+	}
+
+	// 坐标按曲线字节长度定长编码，中间结果返回前清零
+	byteLen := (D.Curve.Params().BitSize + 7) / 8
+	x := make([]byte, byteLen)
+	y := make([]byte, byteLen)
+	D.X.FillBytes(x)
+	D.Y.FillBytes(y)
+	defer wipeBytes(x)
+	defer wipeBytes(y)
+
+	switch opts.Source {
+	case KeySourceX, KeySourceY:
+		coord := x
+		if opts.Source == KeySourceY {
+			coord = y
+		}
+		length := opts.Length
+		if length == 0 {
+			length = byteLen
+		}
+		if length > byteLen {
+			return nil, opError("SymmetricKeyFromPoint", ErrInvalidKeyLength)
+		}
+		key := make([]byte, length)
+		copy(key, coord)
+		return NewSecretBytes(key), nil
+	case KeySourceKDF:
+		length := opts.Length
+		if length == 0 {
+			length = 16
+		}
+		return NewSecretBytes(ec.KDF(length, x, y, opts.Info)), nil
+	case KeySourceHKDF:
+		length := opts.Length
+		if length == 0 {
+			length = 16
+		}
+		key, err := kdf.FromPoint(D, opts.Salt, string(opts.Info), length)
+		if err != nil {
+			return nil, opError("SymmetricKeyFromPoint", err)
+		}
+		return NewSecretBytes(key), nil
+	default:
+		return nil, opError("SymmetricKeyFromPoint", ErrUnknownKeySource)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/kdf"
+)
+
+func TestSymmetricKeyFromPointRaw(t *testing.T) {
+	D := GenPoint()
+
+	// 默认直接取横坐标
+	k, err := SymmetricKeyFromPoint(D, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Len() != 32 || 0 != new(big.Int).SetBytes(k.Bytes()).Cmp(D.X) {
+		t.Fatal("default key is not the X coordinate")
+	}
+
+	k, err = SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceY, Length: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	y := make([]byte, 32)
+	D.Y.FillBytes(y)
+	if !bytes.Equal(k.Bytes(), y[:16]) {
+		t.Fatal("truncated Y key differs")
+	}
+
+	if _, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceX, Length: 33}); err == nil {
+		t.Fatal("expected error for raw key longer than a coordinate")
+	}
+}
+
+func TestSymmetricKeyFromPointKDF(t *testing.T) {
+	D := GenPoint()
+
+	k16, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceKDF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k16.Len() != 16 {
+		t.Fatalf("got %d bytes, want 16", k16.Len())
+	}
+
+	// 较长密钥的前缀与较短密钥一致
+	k48, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceKDF, Length: 48})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k48.Len() != 48 || !bytes.Equal(k48.Bytes()[:16], k16.Bytes()) {
+		t.Fatal("KDF output is not prefix consistent")
+	}
+
+	// 不同上下文得到不同密钥
+	kInfo, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceKDF, Info: []byte("doc-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(kInfo.Bytes(), k16.Bytes()) {
+		t.Fatal("Info did not change the derived key")
+	}
+
+	// HKDF派生与kdf包一致，且不同于SM2 KDF
+	kh, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceHKDF, Info: []byte("doc-1"), Salt: []byte("s")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := kdf.FromPoint(D, []byte("s"), "doc-1", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(kh.Bytes(), want) || bytes.Equal(kh.Bytes(), kInfo.Bytes()) {
+		t.Fatal("unexpected HKDF key")
+	}
+
+	// HKDF的错误以本函数为操作名包装
+	_, err = SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySourceHKDF, Length: kdf.MaxLength + 1})
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "SymmetricKeyFromPoint" || !errors.Is(err, kdf.ErrInvalidKeyLength) {
+		t.Fatalf("got %v, want a SymmetricKeyFromPoint error wrapping ErrInvalidKeyLength", err)
+	}
+
+	if _, err := SymmetricKeyFromPoint(D, &SymmetricKeyOpts{Source: KeySource(9)}); err == nil {
+		t.Fatal("expected error for unknown key source")
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"io"
+	"math/big"
+
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// ThresholdKey is a server's Shamir share of the collective private key.
+// 门限私钥份额：ks server持有的聚合私钥的Shamir份额。
+type ThresholdKey = keyswitch.ThresholdKey
+
+// SplitPrivKey Shamir-shares priv into n keys of which any t recover it, for servers
+// 1..n. A nil random uses crypto/rand.
+// It is a wrapper of keyswitch.SplitPrivKey.
+// 私钥分割：将私钥priv以Shamir秘密共享分割为n份，分别交给编号1..n的ks server，任意t份可恢复。
+// random为nil时使用crypto/rand。
+//
+// 参数：
+//		私钥	priv
+//		门限	t
+//		份数	n
+//		随机源	random
+// 返回：
+// 		门限私钥份额slice
+func SplitPrivKey(priv *sm2.PrivateKey, t, n int, random io.Reader) ([]ThresholdKey, error) {
+	return keyswitch.SplitPrivKey(priv, t, n, random)
+}
+
+// VSSCommitment is the Feldman commitment to a sharing polynomial, published by the
+// dealer so every server can check its share.
+// Feldman承诺：对分割多项式的承诺，由分发者公开，每个ks server据此校验自己的份额。
+type VSSCommitment = keyswitch.VSSCommitment
+
+// SplitPrivKeyVSS Shamir-shares priv like SplitPrivKey and returns the Feldman
+// commitment to the polynomial as well, to publish with the shares.
+// It is a wrapper of keyswitch.SplitPrivKeyVSS.
+// 可验证私钥分割：与SplitPrivKey相同地分割私钥priv，并返回多项式的Feldman承诺，随份额一同公开。
+//
+// 参数：
+//		私钥	priv
+//		门限	t
+//		份数	n
+//		随机源	random
+// 返回：
+// 		门限私钥份额slice
+//		Feldman承诺
+func SplitPrivKeyVSS(priv *sm2.PrivateKey, t, n int, random io.Reader) ([]ThresholdKey, VSSCommitment, error) {
+	return keyswitch.SplitPrivKeyVSS(priv, t, n, random)
+}
+
+// ThresholdShareCal calculates the share related with rB for targetPubKey with the
+// threshold key k, weighted by its Lagrange coefficient within indices, the servers
+// taking part. ShareReplace then succeeds with the shares of any t servers.
+// It is a wrapper of keyswitch.ThresholdShareCal.
+// 门限份额计算：使用以indices（参与的ks server编号集合）中拉格朗日系数加权的门限私钥k，
+// 为目标公钥targetPubKey计算关于点rB的份额。任意t个ks server的份额即可由ShareReplace完成置换。
+//
+// 参数：
+//		目标公钥	targetPubKey
+//		密文左侧点	rB
+//		门限私钥份额	k
+//		编号集合	indices
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func ThresholdShareCal(targetPubKey *sm2.PublicKey, rB *CurvePoint, k *ThresholdKey, indices []int) (*CipherText, *big.Int, error) {
+	return keyswitch.ThresholdShareCal(targetPubKey, rB, k, indices)
+}
+
+// PointShare is a custodian's Shamir share of a curve point.
+// 点份额：保管者持有的曲线点的Shamir份额。
+type PointShare = keyswitch.PointShare
+
+// SplitPoint Shamir-shares the point D into n shares of which any t recover it,
+// for custodians 1..n. A nil random uses crypto/rand.
+// It is a wrapper of keyswitch.SplitPoint.
+// 点分割：将点D以Shamir秘密共享分割为n份，分别交给编号1..n的保管者，任意t份可恢复。
+// random为nil时使用crypto/rand。
+//
+// 参数：
+//		待分割点	D
+//		门限		t
+//		份数		n
+//		随机源		random
+// 返回：
+// 		点份额slice
+func SplitPoint(D *CurvePoint, t, n int, random io.Reader) ([]PointShare, error) {
+	return keyswitch.SplitPoint(D, t, n, random)
+}
+
+// CombinePoint recovers the point shared by SplitPoint from at least t of its shares.
+// It is a wrapper of keyswitch.CombinePoint.
+// 点合并：由SplitPoint分割所得的至少t份点份额恢复原点。
+//
+// 参数：
+//		点份额slice	shares
+// 返回：
+// 		原点
+func CombinePoint(shares []PointShare) (*CurvePoint, error) {
+	return keyswitch.CombinePoint(shares)
+}
+
+// SplitPointAdditive splits D into n random points summing to D. A nil random uses
+// crypto/rand.
+// It is a wrapper of keyswitch.SplitPointAdditive.
+// 点加法分割：将点D分割为n个之和为D的随机点。random为nil时使用crypto/rand。
+//
+// 参数：
+//		待分割点	D
+//		份数		n
+//		随机源		random
+// 返回：
+// 		点份额向量
+func SplitPointAdditive(D *CurvePoint, n int, random io.Reader) (PointVector, error) {
+	return keyswitch.SplitPointAdditive(D, n, random)
+}
+
+// CombinePointAdditive recovers the point split by SplitPointAdditive.
+// It is a wrapper of keyswitch.CombinePointAdditive.
+// 点加法合并：将SplitPointAdditive分割所得的全部份额相加，恢复原点。
+//
+// 参数：
+//		点份额向量	shares
+// 返回：
+// 		原点
+func CombinePointAdditive(shares PointVector) (*CurvePoint, error) {
+	return keyswitch.CombinePointAdditive(shares)
+}
+
+// WeightScheme assigns weights to the nodes of a committee and requires a total
+// weight of at least T to switch a ciphertext.
+// 权重方案：为委员会中的节点指定权重，置换密文需要参与节点的总权重不少于T。
+type WeightScheme = keyswitch.WeightScheme
+
+// WeightedKey is a node's part of a weighted sharing.
+// 加权私钥份额：节点在加权共享中持有的部分。
+type WeightedKey = keyswitch.WeightedKey
+
+// NewWeightScheme returns the scheme of the node weights weights with the weight
+// threshold T.
+// It is a wrapper of keyswitch.NewWeightScheme.
+// 创建权重方案：节点权重为weights，权重门限为T。
+//
+// 参数：
+//		节点权重slice	weights
+//		权重门限		T
+// 返回：
+// 		权重方案
+func NewWeightScheme(weights []int, T int) (*WeightScheme, error) {
+	return keyswitch.NewWeightScheme(weights, T)
+}
+
+// SplitPrivKeyWeighted shares priv among the nodes of s, so that any nodes weighing
+// at least s.T together recover it. A nil random uses crypto/rand.
+// It is a wrapper of keyswitch.SplitPrivKeyWeighted.
+// 加权私钥分割：按权重方案s在节点间分割私钥priv，总权重不少于s.T的任意节点可共同恢复。
+// random为nil时使用crypto/rand。
+//
+// 参数：
+//		私钥		priv
+//		权重方案	s
+//		随机源		random
+// 返回：
+// 		加权私钥份额slice，按节点顺序
+func SplitPrivKeyWeighted(priv *sm2.PrivateKey, s *WeightScheme, random io.Reader) ([]WeightedKey, error) {
+	return keyswitch.SplitPrivKeyWeighted(priv, s, random)
+}
+
+// WeightedShareCal calculates the share related with rB for targetPubKey with the
+// weighted key k, for a session of the nodes under s.
+// It is a wrapper of keyswitch.WeightedShareCal.
+// 加权份额计算：在方案s下参与节点为nodes的会话中，使用加权私钥份额k为目标公钥targetPubKey计算关于点rB的份额。
+//
+// 参数：
+//		目标公钥		targetPubKey
+//		密文左侧点		rB
+//		加权私钥份额	k
+//		权重方案		s
+//		参与节点slice	nodes
+// 返回：
+// 		份额密文：	share
+//		随机数：	ri
+func WeightedShareCal(targetPubKey *sm2.PublicKey, rB *CurvePoint, k *WeightedKey, s *WeightScheme, nodes []int) (*CipherText, *big.Int, error) {
+	return keyswitch.WeightedShareCal(targetPubKey, rB, k, s, nodes)
+}
+
+// WeightedShareReplace is ShareReplace enforcing that the nodes weigh at least s.T.
+// It is a wrapper of keyswitch.WeightedShareReplace.
+// 加权份额置换：在方案s下执行ShareReplace，参与节点总权重须不少于s.T。
+//
+// 参数：
+//		权重方案		s
+//		参与节点slice	nodes
+//		份额slice		shares
+//		密文原文		rct
+// 返回：
+// 		新密文
+func WeightedShareReplace(s *WeightScheme, nodes []int, shares *CipherVector, rct *CipherText) (*CipherText, error) {
+	return keyswitch.WeightedShareReplace(s, nodes, shares, rct)
+}
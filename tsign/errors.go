@@ -0,0 +1,45 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tsign
+
+import (
+	"errors"
+
+	"ppks/elgamal"
+)
+
+var (
+	// ErrWrongRound 消息或调用不属于当前轮次。
+	ErrWrongRound = errors.New("wrong signing round")
+	// ErrInvalidMessage 消息来源、接收方或内容非法。
+	ErrInvalidMessage = errors.New("invalid signing message")
+	// ErrRetry 随机数不满足SM2签名的要求，需以新的会话重新签名，概率可忽略。
+	ErrRetry = errors.New("signing session must restart")
+	// ErrSignatureFailed 合成的签名未通过验证，有签名者提交了错误的值。
+	ErrSignatureFailed = errors.New("combined signature failed to verify")
+)
+
+// opError wraps err with the failing operation.
+// 以出错的操作包装err。
+func opError(op string, err error) error {
+	return &elgamal.Error{Op: op, Err: err}
+}
+
+// itemError wraps err with the failing operation and the index of the failing element.
+// 以出错的操作及出错元素的下标包装err。
+func itemError(op, item string, index int, err error) error {
+	return &elgamal.Error{Op: op, Item: item, Index: index, Err: err}
+}
@@ -0,0 +1,584 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tsign lets a committee holding Shamir shares of an SM2 key, as made by
+// keyswitch.SplitPrivKey or package dkg, produce standard SM2 signatures
+// (GM/T 0003.2) with t of its n servers, alongside key switching with the same
+// shares.
+//
+// SM2 computes s = (1+d)^-1 (k - r*d) = (1+d)^-1 (k + r) - r, so the signers only
+// need shares of (1+d)^-1 and of the nonce k. With every share polynomial of
+// degree t-1, the signers jointly share k and a random rho, open u = rho*(1+d) and
+// take rho/u as their shares of (1+d)^-1; products of two shares have degree
+// 2t-2, so at least 2t-1 signers take part, and fresh sharings of zero mask the
+// opened products. Shares are checked against Feldman commitments, and each
+// signer proves knowledge of its nonce contribution with the transcripts of
+// package proof. The protocol tolerates a minority of honest-but-curious signers;
+// a signer sending wrong values makes Combine fail rather than yield a bad
+// signature.
+//
+// tsign包使委员会以其持有的SM2私钥Shamir份额（由keyswitch.SplitPrivKey或dkg包生成），
+// 由n个ks server中的t个生成标准SM2签名（GM/T 0003.2），与使用同一份额的密钥置换并行。
+//
+// SM2签名s = (1+d)^-1 (k - r*d) = (1+d)^-1 (k + r) - r，因此签名者只需持有(1+d)^-1及随机数k的份额。
+// 各份额多项式的次数均为t-1：签名者共同分享k及随机数rho，公开u = rho*(1+d)，以rho/u作为(1+d)^-1的份额；
+// 两个份额之积的次数为2t-2，因此至少需要2t-1个签名者参与，并以新的零分享掩盖公开的乘积。
+// 份额以Feldman承诺校验，各签名者以proof包的记录证明其知晓自己贡献的随机数。
+// 协议可容忍少数诚实但好奇的签名者；签名者提交错误的值时，Combine失败而不会得到错误的签名。
+package tsign
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"ppks/elgamal"
+	"ppks/internal/ec"
+	"ppks/keyswitch"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// defaultUID is the default user ID of GM/T 0009, used when uid is empty.
+// GM/T 0009规定的默认用户标识，uid为空时使用。
+const defaultUID = "1234567812345678"
+
+// Commit is broadcast by signer From: Feldman commitments to its sharings of k
+// and rho, commitments to the coefficients 1..2t-2 of its two sharings of zero
+// (empty when t = 1), and the proof of knowledge of the constant term of its
+// sharing of k.
+// 承诺：签名者From广播的对其k与rho分享多项式的Feldman承诺、对其两个零分享多项式第1..2t-2项系数的承诺
+// （t = 1时为空），以及其知晓k分享多项式常数项的证明。
+type Commit struct {
+	From   int
+	K, Rho keyswitch.VSSCommitment
+	Z1, Z2 keyswitch.VSSCommitment
+	Proof  *proof.LinearProof
+}
+
+// Deal is sent privately from signer From to signer To: its four polynomials at To.
+// 份额：签名者From私下发给签名者To的份额，即其四个多项式在To处的值。
+type Deal struct {
+	From, To       int
+	K, Rho, Z1, Z2 *big.Int
+}
+
+// Product is broadcast by signer From: its share of u = rho*(1+d).
+// 乘积：签名者From广播的u = rho*(1+d)的份额。
+type Product struct {
+	From int
+	U    *big.Int
+}
+
+// Partial is broadcast by signer From: its share of (1+d)^-1 (k + r).
+// 部分签名：签名者From广播的(1+d)^-1 (k + r)的份额。
+type Partial struct {
+	From int
+	S    *big.Int
+}
+
+// round is the round a session is in.
+// 会话所处的轮次。
+type round int
+
+const (
+	roundCommit round = iota
+	roundProduct
+	roundPartial
+)
+
+// Session signs one message for one signer. It is not safe for concurrent use,
+// and must not be reused for another message.
+// 签名会话：为一个签名者对一条消息签名。不可并发使用，也不可用于其他消息。
+type Session struct {
+	index, t int
+	signers  []int
+	curve    elliptic.Curve
+	d        *big.Int
+	pub      *sm2.PublicKey
+	msg, uid []byte
+	e        *big.Int
+	round    round
+
+	// polys holds the polynomials of k, rho and the two sharings of zero.
+	// 依次为k、rho及两个零分享的多项式。
+	polys    [4][]*big.Int
+	commits  map[int]*Commit
+	deals    map[int]*Deal
+	products map[int]*big.Int
+
+	k, rho, z1, z2 *big.Int
+	R              *elgamal.CurvePoint
+	r              *big.Int
+}
+
+// NewSession starts signing msg under the collective key pub and user ID uid for
+// the holder of the share key, together with signers, the indices of all
+// signers taking part, key's own included; at least 2t-1 of them are needed for
+// threshold t. A nil random uses crypto/rand.
+// 创建签名会话：为份额key的持有者，在聚合公钥pub及用户标识uid下对msg签名，参与签名者的编号为signers
+// （含key自身的编号）；门限为t时至少需要2t-1个签名者。random为nil时使用crypto/rand。
+//
+// 参数：
+//		私钥份额	key
+//		聚合公钥	pub
+//		门限		t
+//		签名者编号	signers
+//		消息		msg
+//		用户标识	uid，为空时使用默认用户标识
+//		随机源		random
+// 返回：
+// 		签名会话
+func NewSession(key *keyswitch.ThresholdKey, pub *sm2.PublicKey, t int, signers []int, msg, uid []byte, random io.Reader) (*Session, error) {
+	if key == nil || key.Priv == nil || key.Priv.D == nil {
+		return nil, opError("NewSession", elgamal.ErrEmpty)
+	}
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, opError("NewSession", err)
+	}
+	if t < 1 || len(signers) < 2*t-1 {
+		return nil, opError("NewSession", keyswitch.ErrInvalidThreshold)
+	}
+	sorted := append([]int(nil), signers...)
+	sort.Ints(sorted)
+	found := false
+	for i, j := range sorted {
+		if j < 1 || (i > 0 && sorted[i-1] == j) {
+			return nil, itemError("NewSession", "signer", i, keyswitch.ErrInvalidThreshold)
+		}
+		found = found || j == key.Index
+	}
+	if !found {
+		return nil, opError("NewSession", keyswitch.ErrInvalidThreshold)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	if len(uid) == 0 {
+		uid = []byte(defaultUID)
+	}
+
+	// e = SM3(ZA || msg)
+	za, err := sm2.ZA(pub, uid)
+	if err != nil {
+		return nil, opError("NewSession", err)
+	}
+	h := sm3.New()
+	h.Write(za)
+	h.Write(msg)
+
+	s := &Session{
+		index:    key.Index,
+		t:        t,
+		signers:  sorted,
+		curve:    pub.Curve,
+		d:        new(big.Int).Set(key.Priv.D),
+		pub:      pub,
+		msg:      append([]byte(nil), msg...),
+		uid:      append([]byte(nil), uid...),
+		e:        new(big.Int).SetBytes(h.Sum(nil)),
+		commits:  make(map[int]*Commit),
+		deals:    make(map[int]*Deal),
+		products: make(map[int]*big.Int),
+	}
+
+	// k与rho的多项式次数为t-1，零分享的多项式次数为2t-2且常数项为0
+	for p := range s.polys {
+		size, zero := t, p >= 2
+		if zero {
+			size = 2*t - 1
+		}
+		poly := make([]*big.Int, size)
+		for c := range poly {
+			if zero && c == 0 {
+				poly[c] = new(big.Int)
+				continue
+			}
+			if poly[c], err = ec.RandFieldElement(s.curve, random); err != nil {
+				return nil, opError("NewSession", err)
+			}
+		}
+		s.polys[p] = poly
+	}
+
+	c := &Commit{
+		From: s.index,
+		K:    s.commitment(s.polys[0]),
+		Rho:  s.commitment(s.polys[1]),
+		Z1:   s.commitment(s.polys[2][1:]),
+		Z2:   s.commitment(s.polys[3][1:]),
+	}
+	if c.Proof, err = proof.ProveLinear(s.nonceContext(s.index), nonceRelation(c), []*big.Int{s.polys[0][0]}); err != nil {
+		return nil, opError("NewSession", err)
+	}
+	s.commits[s.index] = c
+	s.deals[s.index] = s.deal(s.index)
+	return s, nil
+}
+
+// Commit returns the commitment of the session to broadcast.
+// 返回本会话需广播的承诺。
+func (s *Session) Commit() *Commit {
+	return s.commits[s.index]
+}
+
+// Deals returns the deals of the session, one to send privately to each other
+// signer.
+// 返回本会话的份额，每个份额私下发给一个其他签名者。
+func (s *Session) Deals() []*Deal {
+	if s.round != roundCommit {
+		return nil
+	}
+	deals := make([]*Deal, 0, len(s.signers)-1)
+	for _, j := range s.signers {
+		if j != s.index {
+			deals = append(deals, s.deal(j))
+		}
+	}
+	return deals
+}
+
+// ProcessCommit records the commitment c of another signer after checking its
+// shape and proof.
+// 处理承诺：检查其他签名者的承诺c的形式与证明，并记录。
+//
+// 参数：
+//		承诺	c
+// 返回：
+// 		错误
+func (s *Session) ProcessCommit(c *Commit) error {
+	if s.round != roundCommit {
+		return opError("ProcessCommit", ErrWrongRound)
+	}
+	if c == nil || !s.isPeer(c.From) || s.commits[c.From] != nil {
+		return opError("ProcessCommit", ErrInvalidMessage)
+	}
+	if len(c.K) != s.t || len(c.Rho) != s.t || len(c.Z1) != 2*s.t-2 || len(c.Z2) != 2*s.t-2 {
+		return itemError("ProcessCommit", "signer", c.From, ErrInvalidMessage)
+	}
+	for _, points := range []keyswitch.VSSCommitment{c.K, c.Rho, c.Z1, c.Z2} {
+		for _, P := range points {
+			if err := elgamal.CheckPoint(P); err != nil {
+				return itemError("ProcessCommit", "signer", c.From, err)
+			}
+		}
+	}
+	ok, err := proof.VerifyLinear(s.nonceContext(c.From), nonceRelation(c), c.Proof)
+	if err != nil {
+		return itemError("ProcessCommit", "signer", c.From, err)
+	}
+	if !ok {
+		return itemError("ProcessCommit", "signer", c.From, keyswitch.ErrProofFailed)
+	}
+	s.commits[c.From] = c
+	return nil
+}
+
+// ProcessDeal records the deal d for this signer after checking it against the
+// commitment of its sender, which must have been processed first.
+// 处理份额：以发送方的承诺（须已处理）校验发给本签名者的份额d，并记录。
+//
+// 参数：
+//		份额	d
+// 返回：
+// 		错误
+func (s *Session) ProcessDeal(d *Deal) error {
+	if s.round != roundCommit {
+		return opError("ProcessDeal", ErrWrongRound)
+	}
+	if d == nil || d.To != s.index || !s.isPeer(d.From) || s.deals[d.From] != nil {
+		return opError("ProcessDeal", ErrInvalidMessage)
+	}
+	c := s.commits[d.From]
+	if c == nil {
+		return itemError("ProcessDeal", "signer", d.From, ErrWrongRound)
+	}
+	for _, v := range []*big.Int{d.K, d.Rho, d.Z1, d.Z2} {
+		if v == nil || v.Sign() < 0 || v.Cmp(s.curve.Params().N) >= 0 {
+			return itemError("ProcessDeal", "signer", d.From, ErrInvalidMessage)
+		}
+	}
+	if !s.verifyDeal(c.K, d.K, false) || !s.verifyDeal(c.Rho, d.Rho, false) ||
+		!s.verifyDeal(c.Z1, d.Z1, true) || !s.verifyDeal(c.Z2, d.Z2, true) {
+		return itemError("ProcessDeal", "signer", d.From, keyswitch.ErrInvalidShare)
+	}
+	s.deals[d.From] = d
+	return nil
+}
+
+// Product ends the first round once the commitments and deals of all signers are
+// in, fixing the nonce point R and r = e + x(R), and returns this signer's share
+// of u = rho*(1+d) to broadcast. ErrRetry asks for a new session.
+// 乘积：收齐所有签名者的承诺与份额后结束第一轮，确定随机点R及r = e + x(R)，
+// 返回本签名者需广播的u = rho*(1+d)的份额。返回ErrRetry时需以新的会话重新签名。
+//
+// 参数：
+//
+// 返回：
+// 		乘积份额
+func (s *Session) Product() (*Product, error) {
+	if s.round != roundCommit {
+		return nil, opError("Product", ErrWrongRound)
+	}
+	for _, j := range s.signers {
+		if s.commits[j] == nil || s.deals[j] == nil {
+			return nil, itemError("Product", "signer", j, ErrWrongRound)
+		}
+	}
+
+	curve := s.curve
+	N := curve.Params().N
+	s.k, s.rho, s.z1, s.z2 = new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	rx, ry := new(big.Int), new(big.Int)
+	for _, j := range s.signers {
+		d := s.deals[j]
+		s.k.Add(s.k, d.K)
+		s.rho.Add(s.rho, d.Rho)
+		s.z1.Add(s.z1, d.Z1)
+		s.z2.Add(s.z2, d.Z2)
+		K0 := s.commits[j].K[0]
+		rx, ry = ec.Add(curve, rx, ry, K0.X, K0.Y)
+	}
+	for _, v := range []*big.Int{s.k, s.rho, s.z1, s.z2} {
+		v.Mod(v, N)
+	}
+	s.R = &elgamal.CurvePoint{Curve: curve, X: rx, Y: ry}
+
+	// r = e + x(R) mod N，r = 0或r + k = N时重新签名
+	s.r = new(big.Int).Add(s.e, rx)
+	s.r.Mod(s.r, N)
+	gx, gy := curve.ScalarBaseMult(s.r.Bytes())
+	if sx, sy := ec.Add(curve, rx, ry, gx, gy); s.r.Sign() == 0 || s.R.IsInfinity() || (sx.Sign() == 0 && sy.Sign() == 0) {
+		return nil, opError("Product", ErrRetry)
+	}
+
+	// u_i = rho_i * (1 + d_i) + z1_i
+	u := new(big.Int).Add(s.d, big.NewInt(1))
+	u.Mul(u, s.rho)
+	u.Add(u, s.z1)
+	u.Mod(u, N)
+	s.products[s.index] = u
+	s.round = roundProduct
+	s.polys = [4][]*big.Int{}
+	return &Product{From: s.index, U: new(big.Int).Set(u)}, nil
+}
+
+// ProcessProduct records the product share p of another signer.
+// 处理乘积：记录其他签名者的乘积份额p。
+//
+// 参数：
+//		乘积份额	p
+// 返回：
+// 		错误
+func (s *Session) ProcessProduct(p *Product) error {
+	if s.round != roundProduct {
+		return opError("ProcessProduct", ErrWrongRound)
+	}
+	if p == nil || !s.isPeer(p.From) || s.products[p.From] != nil ||
+		p.U == nil || p.U.Sign() < 0 || p.U.Cmp(s.curve.Params().N) >= 0 {
+		return opError("ProcessProduct", ErrInvalidMessage)
+	}
+	s.products[p.From] = new(big.Int).Set(p.U)
+	return nil
+}
+
+// Partial opens u from the product shares of all signers and returns this
+// signer's share of (1+d)^-1 (k + r) to broadcast. ErrRetry asks for a new
+// session.
+// 部分签名：由所有签名者的乘积份额恢复u，返回本签名者需广播的(1+d)^-1 (k + r)的份额。
+// 返回ErrRetry时需以新的会话重新签名。
+//
+// 参数：
+//
+// 返回：
+// 		部分签名
+func (s *Session) Partial() (*Partial, error) {
+	if s.round != roundProduct {
+		return nil, opError("Partial", ErrWrongRound)
+	}
+	values := make([]*big.Int, len(s.signers))
+	for i, j := range s.signers {
+		if values[i] = s.products[j]; values[i] == nil {
+			return nil, itemError("Partial", "signer", j, ErrWrongRound)
+		}
+	}
+	u, err := s.interpolate(values)
+	if err != nil {
+		return nil, opError("Partial", err)
+	}
+	N := s.curve.Params().N
+	if u.Sign() == 0 {
+		return nil, opError("Partial", ErrRetry)
+	}
+
+	// s_i = rho_i / u * (k_i + r) + z2_i
+	v := new(big.Int).ModInverse(u, N)
+	v.Mul(v, s.rho)
+	v.Mul(v, new(big.Int).Add(s.k, s.r))
+	v.Add(v, s.z2)
+	v.Mod(v, N)
+	s.round = roundPartial
+	s.k, s.rho, s.z1, s.z2 = nil, nil, nil, nil
+	return &Partial{From: s.index, S: v}, nil
+}
+
+// Combine combines the partial signatures of all signers into the SM2 signature
+// (r, s) and verifies it under the collective key, failing with
+// ErrSignatureFailed if a signer sent a wrong value.
+// 合成签名：将所有签名者的部分签名合成为SM2签名(r, s)，并以聚合公钥验证；
+// 有签名者提交了错误的值时返回ErrSignatureFailed。
+//
+// 参数：
+//		部分签名slice	partials
+// 返回：
+// 		签名：	r,s
+func (s *Session) Combine(partials []*Partial) (*big.Int, *big.Int, error) {
+	if s.round != roundPartial {
+		return nil, nil, opError("Combine", ErrWrongRound)
+	}
+	N := s.curve.Params().N
+	byFrom := make(map[int]*big.Int, len(partials))
+	for i, p := range partials {
+		if p == nil || p.S == nil || p.S.Sign() < 0 || p.S.Cmp(N) >= 0 || byFrom[p.From] != nil {
+			return nil, nil, itemError("Combine", "partial", i, ErrInvalidMessage)
+		}
+		byFrom[p.From] = p.S
+	}
+	values := make([]*big.Int, len(s.signers))
+	for i, j := range s.signers {
+		if values[i] = byFrom[j]; values[i] == nil {
+			return nil, nil, itemError("Combine", "signer", j, ErrInvalidMessage)
+		}
+	}
+	sig, err := s.interpolate(values)
+	if err != nil {
+		return nil, nil, opError("Combine", err)
+	}
+
+	// s = (1+d)^-1 (k + r) - r
+	sig.Sub(sig, s.r)
+	sig.Mod(sig, N)
+	if sig.Sign() == 0 {
+		return nil, nil, opError("Combine", ErrRetry)
+	}
+	r := new(big.Int).Set(s.r)
+	if !sm2.Sm2Verify(s.pub, s.msg, s.uid, r, sig) {
+		return nil, nil, opError("Combine", ErrSignatureFailed)
+	}
+	return r, sig, nil
+}
+
+// deal returns the values of the session's polynomials at j.
+// 返回本会话各多项式在j处的值。
+func (s *Session) deal(j int) *Deal {
+	return &Deal{
+		From: s.index,
+		To:   j,
+		K:    s.eval(s.polys[0], j),
+		Rho:  s.eval(s.polys[1], j),
+		Z1:   s.eval(s.polys[2], j),
+		Z2:   s.eval(s.polys[3], j),
+	}
+}
+
+// eval returns poly at x.
+// 返回多项式poly在x处的值。
+func (s *Session) eval(poly []*big.Int, x int) *big.Int {
+	N := s.curve.Params().N
+	X := big.NewInt(int64(x))
+	v := new(big.Int)
+	for k := len(poly) - 1; k >= 0; k-- {
+		v.Mul(v, X)
+		v.Add(v, poly[k])
+		v.Mod(v, N)
+	}
+	return v
+}
+
+// commitment returns the points a*G for the coefficients a.
+// 返回各系数a对应的点a*G。
+func (s *Session) commitment(coeffs []*big.Int) keyswitch.VSSCommitment {
+	c := make(keyswitch.VSSCommitment, len(coeffs))
+	for i, a := range coeffs {
+		c[i] = elgamal.Generator(s.curve)
+		c[i].X, c[i].Y = s.curve.ScalarBaseMult(a.Bytes())
+	}
+	return c
+}
+
+// verifyDeal checks v against the commitment c at this signer's index. For a
+// sharing of zero c commits to the coefficients from the first on, so v/index is
+// checked instead, and with no coefficients v must be zero.
+// 以承诺c校验本签名者编号处的值v。零分享的承诺从一次项系数开始，因此校验v/index；没有系数时v须为0。
+func (s *Session) verifyDeal(c keyswitch.VSSCommitment, v *big.Int, zero bool) bool {
+	if zero {
+		if len(c) == 0 {
+			return v.Sign() == 0
+		}
+		N := s.curve.Params().N
+		inv := new(big.Int).ModInverse(big.NewInt(int64(s.index)), N)
+		v = inv.Mul(inv, v)
+		v.Mod(v, N)
+	}
+	k := keyswitch.ThresholdKey{Index: s.index, Priv: &sm2.PrivateKey{D: v}}
+	return c.Verify(&k) == nil
+}
+
+// interpolate returns the value at 0 of the polynomial through values at the
+// signers' indices.
+// 返回经过各签名者编号处取值values的多项式在0处的值。
+func (s *Session) interpolate(values []*big.Int) (*big.Int, error) {
+	N := s.curve.Params().N
+	v := new(big.Int)
+	for i, j := range s.signers {
+		l, err := keyswitch.LagrangeCoefficient(s.curve, j, s.signers)
+		if err != nil {
+			return nil, err
+		}
+		v.Add(v, l.Mul(l, values[i]))
+	}
+	return v.Mod(v, N), nil
+}
+
+// nonceContext returns the proof context of the nonce commitment of signer from,
+// bound to the digest being signed.
+// 返回签名者from随机数承诺证明的上下文，与待签名摘要绑定。
+func (s *Session) nonceContext(from int) string {
+	return fmt.Sprintf("ppks-tsign-nonce/%d/%x", from, s.e.Bytes())
+}
+
+// nonceRelation returns {K_0 = k_0*G} for the commitment c.
+// 返回承诺c对应的关系{K_0 = k_0*G}。
+func nonceRelation(c *Commit) *proof.Relation {
+	return &proof.Relation{
+		Bases:   [][]*elgamal.CurvePoint{{elgamal.Generator(c.K[0].Curve)}},
+		Targets: []*elgamal.CurvePoint{c.K[0]},
+	}
+}
+
+// isPeer reports whether j is another signer of the session.
+// 判断j是否为本会话的其他签名者。
+func (s *Session) isPeer(j int) bool {
+	if j == s.index {
+		return false
+	}
+	i := sort.SearchInts(s.signers, j)
+	return i < len(s.signers) && s.signers[i] == j
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tsign
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"ppks/keyswitch"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// runSessions runs the sessions to the partial signatures, delivering every
+// message, and returns the partials.
+// 运行各会话直至得到部分签名，投递所有消息，返回部分签名。
+func runSessions(t *testing.T, sessions []*Session) []*Partial {
+	for _, s := range sessions {
+		for _, o := range sessions {
+			if o != s {
+				if err := o.ProcessCommit(s.Commit()); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	for _, s := range sessions {
+		for _, d := range s.Deals() {
+			for _, o := range sessions {
+				if o.index == d.To {
+					if err := o.ProcessDeal(d); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+		}
+	}
+	products := make([]*Product, len(sessions))
+	for i, s := range sessions {
+		var err error
+		if products[i], err = s.Product(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range sessions {
+		for _, p := range products {
+			if p.From != s.index {
+				if err := s.ProcessProduct(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	partials := make([]*Partial, len(sessions))
+	for i, s := range sessions {
+		var err error
+		if partials[i], err = s.Partial(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return partials
+}
+
+func newSessions(t *testing.T, keys []keyswitch.ThresholdKey, pub *sm2.PublicKey, threshold int, signers []int, msg []byte) []*Session {
+	sessions := make([]*Session, len(signers))
+	for i, j := range signers {
+		var err error
+		if sessions[i], err = NewSession(&keys[j-1], pub, threshold, signers, msg, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sessions
+}
+
+func TestThresholdSign(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("committee statement")
+
+	for _, c := range []struct {
+		t, n    int
+		signers []int
+	}{
+		{1, 1, []int{1}},
+		{2, 4, []int{1, 3, 4}},
+		{3, 5, []int{5, 1, 2, 4, 3}},
+	} {
+		keys, err := keyswitch.SplitPrivKey(priv, c.t, c.n, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions := newSessions(t, keys, &priv.PublicKey, c.t, c.signers, msg)
+		partials := runSessions(t, sessions)
+		for _, s := range sessions {
+			r, sig, err := s.Combine(partials)
+			if err != nil {
+				t.Fatalf("t=%d: %v", c.t, err)
+			}
+			// 标准SM2验签
+			if !sm2.Sm2Verify(&priv.PublicKey, msg, []byte(defaultUID), r, sig) {
+				t.Fatalf("t=%d: signature rejected", c.t)
+			}
+		}
+	}
+}
+
+func TestThresholdSignFaults(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := keyswitch.SplitPrivKey(priv, 2, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("msg")
+
+	if _, err := NewSession(&keys[0], &priv.PublicKey, 2, []int{1, 2}, msg, nil, nil); !errors.Is(err, keyswitch.ErrInvalidThreshold) {
+		t.Fatalf("expected ErrInvalidThreshold for too few signers, got %v", err)
+	}
+
+	// 错误的份额被拒绝
+	signers := []int{1, 2, 3}
+	sessions := newSessions(t, keys, &priv.PublicKey, 2, signers, msg)
+	if err := sessions[1].ProcessCommit(sessions[0].Commit()); err != nil {
+		t.Fatal(err)
+	}
+	d := sessions[0].Deals()[0]
+	d.K = new(big.Int).Add(d.K, big.NewInt(1))
+	if err := sessions[1].ProcessDeal(d); !errors.Is(err, keyswitch.ErrInvalidShare) {
+		t.Fatalf("expected ErrInvalidShare, got %v", err)
+	}
+
+	// 错误的部分签名使合成失败
+	sessions = newSessions(t, keys, &priv.PublicKey, 2, signers, msg)
+	partials := runSessions(t, sessions)
+	partials[2].S = new(big.Int).Add(partials[2].S, big.NewInt(1))
+	if _, _, err := sessions[0].Combine(partials); !errors.Is(err, ErrSignatureFailed) {
+		t.Fatalf("expected ErrSignatureFailed, got %v", err)
+	}
+	if _, _, err := sessions[0].Combine(partials[:2]); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("expected ErrInvalidMessage for a missing partial, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/elgamal"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// VectorEncrypt encrypts every point in points with pub and returns the ciphertexts in order.
+// It is a wrapper of elgamal.VectorEncrypt.
+// 向量加密：使用公钥pub逐个加密points中的点，按原顺序返回密文向量。
+// 曲线与公钥坐标只提取一次，供整个向量共用。
+//
+// 参数：
+//		公钥		pub
+//		待加密点向量	points
+// 返回：
+// 		密文向量
+func VectorEncrypt(pub *sm2.PublicKey, points *PointVector) (*CipherVector, error) {
+	return elgamal.VectorEncrypt(pub, points)
+}
+
+// EncryptBatch encrypts every point in points with pub like VectorEncrypt, for
+// large batches: one buffered read of randomness serves the whole batch.
+// It is a wrapper of elgamal.EncryptBatch.
+// 批量加密：同VectorEncrypt，适用于大批量：整批共用一次缓冲的随机数读取。
+//
+// 参数：
+//		公钥		pub
+//		待加密点向量	points
+// 返回：
+// 		密文向量
+func EncryptBatch(pub *sm2.PublicKey, points *PointVector) (*CipherVector, error) {
+	return elgamal.EncryptBatch(pub, points)
+}
+
+// VectorDecrypt decrypts every ciphertext in cts with priv and returns the points in order.
+// It is a wrapper of elgamal.VectorDecrypt.
+// 向量解密：使用私钥priv逐个解密cts中的密文，按原顺序返回明文点向量。
+// 私钥字节与模数P只计算一次，供整个向量共用。
+//
+// 参数：
+//		私钥		priv
+//		密文向量	cts
+// 返回：
+// 		明文点向量
+func VectorDecrypt(priv *sm2.PrivateKey, cts *CipherVector) (*PointVector, error) {
+	return elgamal.VectorDecrypt(priv, cts)
+}
+
+// MultiCipherText is one point encrypted for several recipients with shared
+// randomness: one left point K and one right point per recipient.
+// 多接收者密文：以共享随机数为多个接收者加密同一点，仅含一个左侧点K，每个接收者一个右侧点。
+type MultiCipherText = elgamal.MultiCipherText
+
+// MultiEncrypt encrypts D for every key in pubs with one shared random value.
+// It is a wrapper of elgamal.MultiEncrypt.
+// 多接收者加密：以同一随机数为pubs中的每个公钥加密点D。
+//
+// 参数：
+//		公钥slice	pubs
+//		待加密点	D
+// 返回：
+// 		多接收者密文
+func MultiEncrypt(pubs []*sm2.PublicKey, D *CurvePoint) (*MultiCipherText, error) {
+	return elgamal.MultiEncrypt(pubs, D)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ppks
+
+import (
+	"ppks/keyswitch"
+)
+
+// VerifyResult is the outcome of verifying one submitted ShareBundle.
+// 验证结果：一个已提交份额包的验证结果。
+type VerifyResult = keyswitch.VerifyResult
+
+// Verifier verifies submitted share bundles concurrently in the background, so a
+// server can keep receiving from the network while proofs are being checked.
+// Results arrive in completion order, not submission order, and must be consumed.
+// 流水线验证器：在后台并发验证提交的份额包，使服务器接收网络数据与验证证明互不阻塞。
+// 结果按完成顺序而非提交顺序输出，调用者须持续读取Results。
+type Verifier = keyswitch.Verifier
+
+// NewVerifier starts a Verifier with the given number of workers, capped to
+// Parallelism, which workers <= 0 selects. queue is the capacity of the
+// submission and result channels.
+// It is a wrapper of keyswitch.NewVerifier.
+// 创建验证器：启动workers个验证协程，不超过Parallelism，workers <= 0时取Parallelism；queue为
+// 提交与结果通道的容量。
+//
+// 参数：
+//		验证协程数	workers
+//		通道容量	queue
+// 返回：
+// 		验证器
+func NewVerifier(workers, queue int) *Verifier {
+	return keyswitch.NewVerifier(workers, queue)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vrf implements a verifiable random function over the SM2 group, so the
+// nodes can be assigned to sessions pseudo-randomly and verifiably, for load
+// balancing or sortition, with the keys they already hold.
+//
+// For a key x with P = xG, the output on alpha is beta = SM3(Gamma) with
+// Gamma = xH and H = HashToPoint(P, alpha); the proof is Gamma together with a
+// Chaum-Pedersen proof that log_G(P) = log_H(Gamma). Beta is unique for (P, alpha)
+// and looks random to anyone without x.
+// 可验证随机函数：基于SM2群的VRF，节点可用已有密钥被伪随机且可验证地分配到会话，用于负载均衡或抽签。
+//
+// 对私钥x（P = xG），输入alpha的输出为beta = SM3(Gamma)，其中Gamma = xH，H = HashToPoint(P, alpha)；
+// 证明为Gamma，以及log_G(P) = log_H(Gamma)的Chaum-Pedersen证明。对给定(P, alpha)，beta唯一，
+// 且对不知道x者而言与随机值不可区分。
+package vrf
+
+import (
+	"math/big"
+
+	"ppks/elgamal"
+	"ppks/kdf"
+	"ppks/proof"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Domains of the VRF. VRF的域分隔标签。
+const (
+	hashDomain   = "ppks-vrf-v1-h2c"
+	outputDomain = "ppks-vrf-v1-output"
+	indexInfo    = "ppks-vrf-v1-index"
+)
+
+// OutputSize is the length of a VRF output.
+// VRF输出的字节长度。
+const OutputSize = 32
+
+// ProofSize is the length of an encoded proof, Gamma (compressed)||C||S.
+// 证明编码Gamma（压缩）||C||S的字节长度。
+const ProofSize = 33 + 32 + 32
+
+// Proof is a VRF proof: the point Gamma and the Chaum-Pedersen proof (C,S).
+// VRF证明：点Gamma及Chaum-Pedersen证明(C,S)。
+type Proof struct {
+	Gamma elgamal.CurvePoint
+	C, S  *big.Int
+}
+
+// hashPoint returns H = HashToPoint(P, alpha).
+// 计算H = HashToPoint(P, alpha)。
+func hashPoint(pub *sm2.PublicKey, alpha []byte) (*elgamal.CurvePoint, error) {
+	msg := append((*elgamal.CurvePoint)(pub).CompressedBytes(), alpha...)
+	return elgamal.HashToPoint([]byte(hashDomain), msg)
+}
+
+// Prove evaluates the VRF of priv on alpha, returning the output and its proof.
+// 求值并证明：以私钥priv对输入alpha求VRF值，返回输出及其证明。
+//
+// 参数：
+//		私钥	priv
+//		输入	alpha
+// 返回：
+// 		输出	beta
+//		证明
+func Prove(priv *sm2.PrivateKey, alpha []byte) ([]byte, *Proof, error) {
+	if priv == nil || priv.D == nil || priv.D.Sign() <= 0 {
+		return nil, nil, opError("Prove", elgamal.ErrOutOfRange)
+	}
+	pub := &priv.PublicKey
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, nil, opError("Prove", err)
+	}
+	H, err := hashPoint(pub, alpha)
+	if err != nil {
+		return nil, nil, opError("Prove", err)
+	}
+
+	// Gamma = xH
+	curve := priv.Curve
+	p := &Proof{}
+	p.Gamma.Curve = curve
+	p.Gamma.X, p.Gamma.Y = curve.ScalarMult(H.X, H.Y, priv.D.Bytes())
+	p.C, p.S, err = proof.DLEQGen(priv.D, elgamal.Generator(curve), (*elgamal.CurvePoint)(pub), H, &p.Gamma)
+	if err != nil {
+		return nil, nil, opError("Prove", err)
+	}
+	return ProofToHash(p), p, nil
+}
+
+// Verify checks the proof p of the VRF of pub on alpha and returns the output it
+// proves; the output is nil when the proof does not verify.
+// 验证：验证公钥pub对输入alpha的VRF证明p，返回其证明的输出；证明未通过验证时输出为nil。
+//
+// 参数：
+//		公钥	pub
+//		输入	alpha
+//		证明	p
+// 返回：
+// 		输出	beta
+//		验证结果
+func Verify(pub *sm2.PublicKey, alpha []byte, p *Proof) ([]byte, bool, error) {
+	if err := elgamal.CheckPoint((*elgamal.CurvePoint)(pub)); err != nil {
+		return nil, false, opError("Verify", err)
+	}
+	if p == nil || !validScalars(p.C, p.S, pub.Curve.Params().N) {
+		return nil, false, nil
+	}
+	if err := elgamal.CheckPoint(&p.Gamma); err != nil {
+		return nil, false, nil
+	}
+	H, err := hashPoint(pub, alpha)
+	if err != nil {
+		return nil, false, opError("Verify", err)
+	}
+	ok, err := proof.DLEQVerify(p.C, p.S, elgamal.Generator(pub.Curve), (*elgamal.CurvePoint)(pub), H, &p.Gamma)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return ProofToHash(p), true, nil
+}
+
+// validScalars reports whether c and s are set and in [0,N). DLEQVerify reduces
+// them modulo N, so without this check S+N would verify as well as S.
+// 判断c与s是否非空且位于[0,N)。DLEQVerify会将其模N约简，不检查时S+N与S同样能通过验证。
+func validScalars(c, s, N *big.Int) bool {
+	return c != nil && s != nil && c.Sign() >= 0 && s.Sign() >= 0 && c.Cmp(N) < 0 && s.Cmp(N) < 0
+}
+
+// ProofToHash returns the output proven by p, without verifying p.
+// 证明转输出：返回证明p所证明的输出，不验证p。
+//
+// 参数：
+//		证明	p
+// 返回：
+// 		输出	beta
+func ProofToHash(p *Proof) []byte {
+	h := sm3.New()
+	h.Write([]byte(outputDomain))
+	h.Write(p.Gamma.CompressedBytes())
+	return h.Sum(nil)
+}
+
+// Index maps the output beta to an index in [0,n), such as the session a node is
+// assigned to. The bias from reducing 128 bits modulo n is negligible.
+// 将输出beta映射为[0,n)中的下标，如节点被分配到的会话。128比特取模n带来的偏差可忽略。
+//
+// 参数：
+//		输出	beta
+//		范围	n
+// 返回：
+// 		下标
+func Index(beta []byte, n int) (int, error) {
+	if n < 1 || len(beta) != OutputSize {
+		return 0, opError("Index", elgamal.ErrOutOfRange)
+	}
+	b, err := kdf.Expand(beta, []byte(indexInfo), 16)
+	if err != nil {
+		return 0, opError("Index", err)
+	}
+	v := new(big.Int).SetBytes(b)
+	return int(v.Mod(v, big.NewInt(int64(n))).Int64()), nil
+}
+
+// MarshalBinary encodes p as Gamma (compressed)||C||S.
+// 将证明编码为Gamma（压缩）||C||S。
+func (p *Proof) MarshalBinary() ([]byte, error) {
+	if !validScalars(p.C, p.S, sm2.P256Sm2().Params().N) {
+		return nil, opError("Proof.MarshalBinary", ErrInvalidProof)
+	}
+	if err := elgamal.CheckPoint(&p.Gamma); err != nil {
+		return nil, opError("Proof.MarshalBinary", err)
+	}
+	b := make([]byte, ProofSize)
+	copy(b, p.Gamma.CompressedBytes())
+	p.C.FillBytes(b[33:65])
+	p.S.FillBytes(b[65:])
+	return b, nil
+}
+
+// UnmarshalBinary decodes an encoding produced by MarshalBinary, rejecting C or S
+// not below the group order.
+// 解析MarshalBinary生成的编码，拒绝不小于群阶的C或S。
+func (p *Proof) UnmarshalBinary(b []byte) error {
+	if len(b) != ProofSize {
+		return opError("Proof.UnmarshalBinary", ErrInvalidProof)
+	}
+	G, err := elgamal.NewCurvePointFromBytes(sm2.P256Sm2(), b[:33])
+	if err != nil {
+		return opError("Proof.UnmarshalBinary", err)
+	}
+	C := new(big.Int).SetBytes(b[33:65])
+	S := new(big.Int).SetBytes(b[65:])
+	if !validScalars(C, S, G.Curve.Params().N) {
+		return opError("Proof.UnmarshalBinary", ErrInvalidProof)
+	}
+	p.Gamma, p.C, p.S = *G, C, S
+	return nil
+}
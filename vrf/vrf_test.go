@@ -0,0 +1,126 @@
+/*
+Copyright 2021 XiaoYao(Beijing Institute of Technology)
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vrf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestProveVerify(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	alpha := []byte("session-42")
+
+	beta, p, err := Prove(priv, alpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(beta) != OutputSize {
+		t.Fatalf("got %d output bytes, want %d", len(beta), OutputSize)
+	}
+	got, ok, err := Verify(pub, alpha, p)
+	if err != nil || !ok || !bytes.Equal(got, beta) {
+		t.Fatal("honest proof failed to verify")
+	}
+
+	// 输出唯一：再次求值得到相同输出，证明不同
+	beta2, p2, err := Prove(priv, alpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(beta, beta2) || p.C.Cmp(p2.C) == 0 {
+		t.Fatal("output not unique or proof not fresh")
+	}
+
+	// 其他输入、其他公钥下验证失败
+	if _, ok, _ := Verify(pub, []byte("session-43"), p); ok {
+		t.Fatal("proof verified for another input")
+	}
+	q, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := Verify(&q.PublicKey, alpha, p); ok {
+		t.Fatal("proof verified under another key")
+	}
+	other, _, err := Prove(priv, []byte("session-43"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(other, beta) {
+		t.Fatal("distinct inputs gave the same output")
+	}
+
+	// S+N与S模N相同，须被拒绝，使证明不可延展
+	N := pub.Curve.Params().N
+	malleated := &Proof{Gamma: p.Gamma, C: p.C, S: new(big.Int).Add(p.S, N)}
+	if _, ok, err := Verify(pub, alpha, malleated); ok || err != nil {
+		t.Fatalf("proof with S+N accepted: %v", err)
+	}
+
+	i, err := Index(beta, 7)
+	if err != nil || i < 0 || i >= 7 {
+		t.Fatalf("bad index %d: %v", i, err)
+	}
+}
+
+func TestProofEncoding(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beta, p, err := Prove(priv, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != ProofSize {
+		t.Fatalf("got %d bytes, want %d", len(b), ProofSize)
+	}
+	var q Proof
+	if err := q.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := Verify(&priv.PublicKey, []byte("x"), &q)
+	if err != nil || !ok || !bytes.Equal(got, beta) {
+		t.Fatal("decoded proof failed to verify")
+	}
+	if err := q.UnmarshalBinary(b[1:]); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("got %v, want ErrInvalidProof", err)
+	}
+
+	// 编码中的C或S不小于群阶时拒绝
+	N := priv.Curve.Params().N
+	for _, off := range []int{33, 65} {
+		bad := append([]byte(nil), b...)
+		N.FillBytes(bad[off : off+32])
+		if err := q.UnmarshalBinary(bad); !errors.Is(err, ErrInvalidProof) {
+			t.Fatalf("scalar at %d equal to N: got %v, want ErrInvalidProof", off, err)
+		}
+	}
+}